@@ -4,6 +4,8 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -21,21 +23,47 @@ type BacktestEngine struct {
 	startTime  time.Time
 	endTime    time.Time
 	progress   float64
+	runID      string
+	dataSource DataSource
+	snapshots  *SnapshotStore
+	snapshot   *DataSnapshot
+	calendar   *TradingCalendar
+	store      ResultStore // 回测结果持久化存储，nil表示仅保存在内存
+
+	restingOrders []*restingOrder // 挂单中的限价单
+	commission    CommissionModel // 本次回测的手续费模型
+	slippage      SlippageModel   // 本次回测的滑点模型
+
+	benchmarkPrices []float64 // 与权益曲线对齐的基准收盘价
+	benchmarkFailed bool      // 基准数据加载失败，本次回测不做基准比较
 }
 
 // BacktestConfig 回测配置
 type BacktestConfig struct {
-	StartDate        time.Time        `yaml:"start_date"`
-	EndDate          time.Time        `yaml:"end_date"`
-	InitialCapital   float64          `yaml:"initial_capital"`
-	Commission       float64          `yaml:"commission"`         // 手续费率
-	Slippage         float64          `yaml:"slippage"`           // 滑点
-	Symbols          []string         `yaml:"symbols"`            // 回测股票
-	Strategies       []StrategyConfig `yaml:"strategies"`         // 策略配置
-	RiskFreeRate     float64          `yaml:"risk_free_rate"`     // 无风险利率
-	BenchmarkSymbol  string           `yaml:"benchmark_symbol"`   // 基准股票
-	MaxDrawdownLimit float64          `yaml:"max_drawdown_limit"` // 最大回撤限制
-	Realtime         bool             `yaml:"realtime"`           // 实时模式
+	StartDate        time.Time           `yaml:"start_date"`
+	EndDate          time.Time           `yaml:"end_date"`
+	InitialCapital   float64             `yaml:"initial_capital"`
+	Commission       float64             `yaml:"commission"`         // 手续费率
+	CommissionModel  CommissionModelType `yaml:"commission_model"`   // 手续费模型：flat/ashare，默认flat
+	MinCommission    float64             `yaml:"min_commission"`     // ashare模型每笔最低佣金
+	StampTax         float64             `yaml:"stamp_tax"`          // ashare模型卖出印花税率
+	TransferFee      float64             `yaml:"transfer_fee"`       // ashare模型过户费，每1000股
+	Slippage         float64             `yaml:"slippage"`           // 滑点比例
+	SlippageModel    SlippageModelType   `yaml:"slippage_model"`     // 滑点模型：fixed/volume，默认fixed
+	SlippageImpact   float64             `yaml:"slippage_impact"`    // volume模型冲击系数，订单量等于K线成交量时额外增加的滑点比例
+	MaxSlippage      float64             `yaml:"max_slippage"`       // volume模型滑点比例上限，0表示不限
+	Symbols          []string            `yaml:"symbols"`            // 回测股票
+	Strategies       []StrategyConfig    `yaml:"strategies"`         // 策略配置
+	RiskFreeRate     float64             `yaml:"risk_free_rate"`     // 无风险利率
+	BenchmarkSymbol  string              `yaml:"benchmark_symbol"`   // 基准股票
+	MaxDrawdownLimit float64             `yaml:"max_drawdown_limit"` // 最大回撤限制，超过后停止开新仓，0表示不限制
+	HaltOnDrawdown   bool                `yaml:"halt_on_drawdown"`   // 超过最大回撤限制时直接结束回测，而不只是停止开新仓
+	Realtime         bool                `yaml:"realtime"`           // 实时模式
+	SnapshotData     bool                `yaml:"snapshot_data"`      // 保存回测所用数据快照
+	BarInterval      BarInterval         `yaml:"bar_interval"`       // K线周期：1d/1h/1m，默认日线
+	RiskWindow       int                 `yaml:"risk_window"`        // 滚动风险指标窗口（K线数），0表示不计算
+	LimitOrders      bool                `yaml:"limit_orders"`       // 按K线高低价撮合限价单，关闭时限价单按信号价格成交
+	LimitOrderTTL    int                 `yaml:"limit_order_ttl"`    // 限价单未成交时继续挂单的K线数，0表示当根K线未成交即撤销
 }
 
 // StrategyConfig 策略配置
@@ -49,17 +77,21 @@ type StrategyConfig struct {
 
 // BacktestResults 回测结果
 type BacktestResults struct {
-	Summary        *BacktestSummary                `json:"summary"`         // 回测摘要
-	EquityCurve    []EquityPoint                   `json:"equity_curve"`    // 收益曲线
-	Trades         []BacktestTrade                 `json:"trades"`          // 交易记录
-	Returns        []ReturnPoint                   `json:"returns"`         // 收益率序列
-	Drawdowns      []DrawdownPoint                 `json:"drawdowns"`       // 回撤序列
-	MonthlyReturns map[string]float64              `json:"monthly_returns"` // 月度收益
-	StrategyStats  map[string]*StrategyPerformance `json:"strategy_stats"`  // 策略统计
-	Benchmark      *BenchmarkComparison            `json:"benchmark"`       // 基准比较
-	RiskMetrics    *RiskMetrics                    `json:"risk_metrics"`    // 风险指标
-	Exposures      map[string][]ExposurePoint      `json:"exposures"`       // 暴露情况
-	Errors         []string                        `json:"errors"`          // 错误信息
+	ID             string                          `json:"id"`                  // 回测ID
+	DataHash       string                          `json:"data_hash,omitempty"` // 数据快照哈希
+	ConfigHash     string                          `json:"config_hash"`         // 回测配置哈希
+	Summary        *BacktestSummary                `json:"summary"`             // 回测摘要
+	EquityCurve    []EquityPoint                   `json:"equity_curve"`        // 收益曲线
+	Trades         []BacktestTrade                 `json:"trades"`              // 交易记录
+	Returns        []ReturnPoint                   `json:"returns"`             // 收益率序列
+	Drawdowns      []DrawdownPoint                 `json:"drawdowns"`           // 回撤序列
+	MonthlyReturns map[string]float64              `json:"monthly_returns"`     // 月度收益
+	StrategyStats  map[string]*StrategyPerformance `json:"strategy_stats"`      // 策略统计
+	Benchmark      *BenchmarkComparison            `json:"benchmark"`           // 基准比较
+	RiskMetrics    *RiskMetrics                    `json:"risk_metrics"`        // 风险指标
+	RollingRisk    []RollingRiskPoint              `json:"rolling_risk"`        // 滚动风险指标序列
+	Exposures      map[string][]ExposurePoint      `json:"exposures"`           // 暴露情况
+	Errors         []string                        `json:"errors"`              // 错误信息
 	StartTime      time.Time                       `json:"start_time"`
 	EndTime        time.Time                       `json:"end_time"`
 	Duration       time.Duration                   `json:"duration"`
@@ -72,6 +104,8 @@ type BacktestSummary struct {
 	TotalReturn         float64       `json:"total_return"`
 	AnnualizedReturn    float64       `json:"annualized_return"`
 	TotalTrades         int           `json:"total_trades"`
+	ExpiredOrders       int           `json:"expired_orders"`   // 未成交而过期的限价单数
+	CancelledOrders     int           `json:"cancelled_orders"` // 回测结束时仍在挂单而撤销的限价单数
 	WinningTrades       int           `json:"winning_trades"`
 	LosingTrades        int           `json:"losing_trades"`
 	WinRate             float64       `json:"win_rate"`
@@ -167,7 +201,7 @@ type RiskMetrics struct {
 	Volatility        float64 `json:"volatility"`
 	DownsideDeviation float64 `json:"downside_deviation"`
 	Skewness          float64 `json:"skewness"`
-	Kurtosis          float64 `json:"kurtosis"`
+	Kurtosis          float64 `json:"kurtosis"` // 超额峰度，正态分布为0
 	VaR95             float64 `json:"var_95"`
 	CVaR95            float64 `json:"cvar_95"`
 	VaR99             float64 `json:"var_99"`
@@ -182,12 +216,17 @@ type ExposurePoint struct {
 
 // NewBacktestEngine 创建回测引擎
 func NewBacktestEngine(config BacktestConfig) *BacktestEngine {
+	// 复制切片，调用方之后修改传入的配置不影响回测主循环
+	config.Symbols = append([]string(nil), config.Symbols...)
+	config.Strategies = append([]StrategyConfig(nil), config.Strategies...)
+
 	return &BacktestEngine{
 		config:     &config,
 		strategies: make(map[string]strategies.Strategy),
 		results:    &BacktestResults{},
 		started:    false,
 		completed:  false,
+		calendar:   DefaultTradingCalendar(),
 	}
 }
 
@@ -209,51 +248,204 @@ func (b *BacktestEngine) AddStrategy(strategy strategies.Strategy) error {
 	return nil
 }
 
-// Run 执行回测
-func (b *BacktestEngine) Run(ctx context.Context) (*BacktestResults, error) {
+// SetDataSource 设置回测数据源，未设置时使用模拟数据
+func (b *BacktestEngine) SetDataSource(source DataSource) error {
 	b.mu.Lock()
 	defer b.mu.Unlock()
 
 	if b.started {
-		return nil, fmt.Errorf("backtest is already running")
+		return fmt.Errorf("cannot set data source after backtest started")
 	}
 
-	if len(b.strategies) == 0 {
-		return nil, fmt.Errorf("no strategies added")
+	b.dataSource = source
+	return nil
+}
+
+// SetSnapshotStore 设置数据快照存储
+func (b *BacktestEngine) SetSnapshotStore(store *SnapshotStore) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.snapshots = store
+}
+
+// SetResultStore 设置回测结果持久化存储，每次回测完成后自动保存
+func (b *BacktestEngine) SetResultStore(store ResultStore) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.store = store
+}
+
+// SetCalendar 设置交易日历，用于自定义休市日期或交易时段
+func (b *BacktestEngine) SetCalendar(calendar *TradingCalendar) error {
+	if calendar == nil {
+		return fmt.Errorf("calendar is nil")
 	}
 
-	b.started = true
-	b.startTime = time.Now()
-	b.progress = 0.0
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		return fmt.Errorf("cannot set calendar after backtest started")
+	}
+
+	b.calendar = calendar
+	return nil
+}
+
+// withPeriod 以相同配置、策略、数据源和交易日历创建覆盖指定区间的新引擎，用于参数搜索和滚动窗口
+func (b *BacktestEngine) withPeriod(start, end time.Time) *BacktestEngine {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	config := *b.config
+	config.StartDate = start
+	config.EndDate = end
+
+	engine := NewBacktestEngine(config)
+	for name, strategy := range b.strategies {
+		engine.strategies[name] = strategy
+	}
+	engine.dataSource = b.dataSource
+	engine.calendar = b.calendar
+	return engine
+}
+
+// GetDataSnapshot 获取本次回测的数据快照，未启用快照或回测进行中时返回nil
+func (b *BacktestEngine) GetDataSnapshot() *DataSnapshot {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.started && !b.completed {
+		return nil
+	}
+	return b.snapshot
+}
+
+// Run 执行回测。仅在准备和收尾时持有锁，回测过程中可并发查询进度和运行状态
+func (b *BacktestEngine) Run(ctx context.Context) (*BacktestResults, error) {
+	if err := b.prepareRun(); err != nil {
+		return nil, err
+	}
 
 	defer func() {
+		b.mu.Lock()
 		b.completed = true
 		b.endTime = time.Now()
 		b.progress = 100.0
+		b.mu.Unlock()
 	}()
 
-	log.Printf("Starting backtest: %s to %s", b.config.StartDate.Format("2006-01-02"), b.config.EndDate.Format("2006-01-02"))
+	log.Printf("Starting backtest: %s to %s, interval=%s", b.config.StartDate.Format("2006-01-02"), b.config.EndDate.Format("2006-01-02"), b.config.BarInterval)
 
-	// 初始化回测结果
-	if err := b.initializeResults(); err != nil {
-		return nil, fmt.Errorf("failed to initialize results: %v", err)
+	// 按策略数据需求预热历史数据
+	if err := b.warmUp(ctx); err != nil {
+		return nil, fmt.Errorf("failed to warm up strategies: %v", err)
 	}
 
 	// 执行回测主循环
 	if err := b.runBacktestLoop(ctx); err != nil {
 		return nil, fmt.Errorf("backtest failed: %v", err)
 	}
+	b.cancelRestingOrders()
+
+	b.mu.RLock()
+	snapshots, store := b.snapshots, b.store
+	b.mu.RUnlock()
+
+	// 保存数据快照
+	if b.snapshot != nil {
+		if err := b.snapshot.seal(); err != nil {
+			return nil, fmt.Errorf("failed to snapshot data: %v", err)
+		}
+		b.results.DataHash = b.snapshot.Hash
+		if snapshots != nil {
+			snapshots.Save(b.snapshot)
+		}
+	}
 
 	// 计算最终指标
 	b.calculateFinalMetrics()
 
-	log.Printf("Backtest completed: duration=%v, final_value=%.2f", b.endTime.Sub(b.startTime), b.results.Summary.FinalValue)
+	// 持久化失败不影响本次回测结果
+	if store != nil {
+		if err := store.SaveResults(b.results); err != nil {
+			log.Printf("Failed to save backtest results %s: %v", b.runID, err)
+			b.results.Errors = append(b.results.Errors, fmt.Sprintf("save results: %v", err))
+		}
+	}
+
+	log.Printf("Backtest completed: duration=%v, final_value=%.2f", time.Since(b.startTime), b.results.Summary.FinalValue)
 	return b.results, nil
 }
 
+// prepareRun 校验配置并标记回测开始，之后策略、配置和结果只由Run所在的goroutine访问
+func (b *BacktestEngine) prepareRun() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.started {
+		if b.completed {
+			return fmt.Errorf("backtest already completed, create a new engine to run again")
+		}
+		return fmt.Errorf("backtest is already running")
+	}
+
+	if len(b.strategies) == 0 {
+		return fmt.Errorf("no strategies added")
+	}
+
+	if err := b.config.BarInterval.Validate(); err != nil {
+		return err
+	}
+	if b.config.RiskWindow < 0 || b.config.RiskWindow == 1 {
+		return fmt.Errorf("risk window must be 0 or at least 2, got %d", b.config.RiskWindow)
+	}
+	if b.config.LimitOrderTTL < 0 {
+		return fmt.Errorf("limit order ttl must not be negative, got %d", b.config.LimitOrderTTL)
+	}
+	if b.config.BarInterval == "" {
+		b.config.BarInterval = BarDaily
+	}
+	commission, err := b.config.newCommissionModel()
+	if err != nil {
+		return err
+	}
+	b.commission = commission
+	slippage, err := b.config.newSlippageModel()
+	if err != nil {
+		return err
+	}
+	b.slippage = slippage
+
+	b.startTime = time.Now()
+	b.progress = 0.0
+	b.restingOrders = nil
+	b.benchmarkPrices = nil
+	b.benchmarkFailed = false
+	b.runID = generateBacktestID()
+	b.snapshot = nil
+	if b.config.SnapshotData {
+		b.snapshot = newDataSnapshot(b.runID)
+	}
+
+	// 初始化回测结果
+	if err := b.initializeResults(); err != nil {
+		return fmt.Errorf("failed to initialize results: %v", err)
+	}
+
+	b.started = true
+	return nil
+}
+
 // initializeResults 初始化回测结果
 func (b *BacktestEngine) initializeResults() error {
+	configHash, err := b.config.Hash()
+	if err != nil {
+		return err
+	}
+
 	b.results = &BacktestResults{
+		ID:         b.runID,
+		ConfigHash: configHash,
 		Summary: &BacktestSummary{
 			InitialCapital: b.config.InitialCapital,
 		},
@@ -261,6 +453,7 @@ func (b *BacktestEngine) initializeResults() error {
 		Trades:         make([]BacktestTrade, 0),
 		Returns:        make([]ReturnPoint, 0),
 		Drawdowns:      make([]DrawdownPoint, 0),
+		RollingRisk:    make([]RollingRiskPoint, 0),
 		MonthlyReturns: make(map[string]float64),
 		StrategyStats:  make(map[string]*StrategyPerformance),
 		Exposures:      make(map[string][]ExposurePoint),
@@ -277,18 +470,19 @@ func (b *BacktestEngine) initializeResults() error {
 	return nil
 }
 
-// runBacktestLoop 执行回测主循环
+// runBacktestLoop 执行回测主循环，按K线周期逐根推进并跳过非交易时段
 func (b *BacktestEngine) runBacktestLoop(ctx context.Context) error {
-	// 模拟回测数据生成
-	// 实际应用中需要加载真实的历史数据
+	bars := b.calendar.Bars(b.config.StartDate, b.config.EndDate, b.config.BarInterval)
 
-	currentDate := b.config.StartDate
 	currentValue := b.config.InitialCapital
 	peakValue := currentValue
+	lastLogged := 0
 
 	tradeID := 1
+	drawdownBreached := false
+	lastBar := time.Time{}
 
-	for !currentDate.After(b.config.EndDate) {
+	for i, barTime := range bars {
 		// 检查上下文是否取消
 		select {
 		case <-ctx.Done():
@@ -297,12 +491,17 @@ func (b *BacktestEngine) runBacktestLoop(ctx context.Context) error {
 		}
 
 		// 更新进度
-		totalDays := int(b.config.EndDate.Sub(b.config.StartDate).Hours() / 24)
-		elapsedDays := int(currentDate.Sub(b.config.StartDate).Hours() / 24)
-		b.progress = float64(elapsedDays) / float64(totalDays) * 100
+		progress := float64(i) / float64(len(bars)) * 100
+		b.setProgress(progress)
 
-		// 生成市场数据（模拟）
-		marketData := b.generateMockMarketData(currentDate)
+		// 加载市场数据
+		marketData, err := b.loadMarketData(barTime)
+		if err != nil {
+			return fmt.Errorf("failed to load market data for %s: %v", barTime.Format("2006-01-02 15:04"), err)
+		}
+		if b.snapshot != nil {
+			b.snapshot.record(marketData)
+		}
 
 		// 执行策略
 		signals, err := b.executeStrategies(ctx, marketData)
@@ -311,9 +510,18 @@ func (b *BacktestEngine) runBacktestLoop(ctx context.Context) error {
 			continue
 		}
 
-		// 处理信号并生成交易
-		for _, signal := range signals {
-			trade := b.createBacktestTrade(tradeID, signal, currentDate)
+		// 触及回撤限制后不再开新仓，只允许平仓
+		if drawdownBreached {
+			signals = withoutBuys(signals)
+		}
+
+		// 先撮合挂单，再处理新信号
+		fills := b.matchRestingOrders(marketData, barTime)
+		fills = append(fills, b.placeOrders(signals, marketData, barTime)...)
+
+		// 按成交生成交易
+		for _, signal := range fills {
+			trade := b.createBacktestTrade(tradeID, signal, marketData[signal.Symbol], barTime)
 			if trade != nil {
 				b.results.Trades = append(b.results.Trades, *trade)
 				currentValue += trade.PnL
@@ -324,51 +532,195 @@ func (b *BacktestEngine) runBacktestLoop(ctx context.Context) error {
 		// 更新权益曲线
 		drawdown := (peakValue - currentValue) / peakValue
 		b.results.EquityCurve = append(b.results.EquityCurve, EquityPoint{
-			Timestamp: currentDate,
+			Timestamp: barTime,
 			Value:     currentValue,
 			Drawdown:  drawdown,
 		})
+		lastBar = barTime
+
+		// 检查回撤限制，模拟风控下线策略
+		if !drawdownBreached && b.config.MaxDrawdownLimit > 0 && drawdown > b.config.MaxDrawdownLimit {
+			drawdownBreached = true
+			b.breachDrawdownLimit(barTime, drawdown)
+		}
+
+		b.recordBenchmark(barTime, marketData)
 
 		// 更新峰值
 		if currentValue > peakValue {
 			peakValue = currentValue
 		}
 
-		// 计算日收益率
+		// 计算单周期收益率
 		if len(b.results.EquityCurve) > 1 {
 			prevValue := b.results.EquityCurve[len(b.results.EquityCurve)-2].Value
-			dailyReturn := (currentValue - prevValue) / prevValue
+			periodReturn := (currentValue - prevValue) / prevValue
 			b.results.Returns = append(b.results.Returns, ReturnPoint{
-				Timestamp: currentDate,
+				Timestamp: barTime,
 				Value:     currentValue,
-				Return:    dailyReturn,
+				Return:    periodReturn,
 			})
+			b.addMonthlyReturn(barTime, periodReturn)
+		} else if b.config.InitialCapital > 0 {
+			// 首根K线相对初始资金，保证各月复利之积等于总收益
+			b.addMonthlyReturn(barTime, currentValue/b.config.InitialCapital-1)
 		}
 
-		// 移动到下一个交易日
-		currentDate = currentDate.AddDate(0, 0, 1)
+		// 计算滚动风险指标
+		if b.config.RiskWindow > 0 {
+			if point, ok := rollingRiskPoint(b.results.Returns, b.results.EquityCurve, b.config.RiskWindow, b.periodsPerYear()); ok {
+				b.results.RollingRisk = append(b.results.RollingRisk, point)
+			}
+		}
 
-		// 更新进度日志
-		if int(b.progress)%10 == 0 && int(b.progress) > 0 {
-			log.Printf("Backtest progress: %.1f%%", b.progress)
+		// 每推进10%输出一次进度
+		if step := int(progress) / 10 * 10; step > lastLogged {
+			lastLogged = step
+			log.Printf("Backtest progress: %.1f%%", progress)
+		}
+
+		if drawdownBreached && b.config.HaltOnDrawdown {
+			break
 		}
 	}
 
 	// 更新最终结果
 	b.results.Summary.FinalValue = currentValue
 	b.results.Summary.TotalReturn = (currentValue - b.config.InitialCapital) / b.config.InitialCapital
-	b.results.EndTime = currentDate
-	b.results.Duration = b.endTime.Sub(b.startTime)
+	if !lastBar.IsZero() {
+		b.results.EndTime = lastBar
+	}
+	b.results.Duration = time.Since(b.startTime)
+
+	return nil
+}
+
+// breachDrawdownLimit 记录回撤超限事件；不结束回测时撤销挂单中的买入限价单
+func (b *BacktestEngine) breachDrawdownLimit(barTime time.Time, drawdown float64) {
+	action := "new positions blocked"
+	if b.config.HaltOnDrawdown {
+		action = "backtest halted"
+	}
+	msg := fmt.Sprintf("max drawdown limit %.2f%% exceeded at %s (drawdown %.2f%%): %s",
+		b.config.MaxDrawdownLimit*100, barTime.Format("2006-01-02 15:04"), drawdown*100, action)
+	b.results.Errors = append(b.results.Errors, msg)
+	log.Print(msg)
+
+	resting := b.restingOrders[:0]
+	for _, order := range b.restingOrders {
+		if order.signal.SignalType != "buy" {
+			resting = append(resting, order)
+		}
+	}
+	b.restingOrders = resting
+}
+
+// withoutBuys 过滤掉买入信号
+func withoutBuys(signals []*strategies.Signal) []*strategies.Signal {
+	filtered := signals[:0]
+	for _, signal := range signals {
+		if signal.SignalType != "buy" {
+			filtered = append(filtered, signal)
+		}
+	}
+	return filtered
+}
+
+// dataRequirements 合并所有策略的数据需求
+func (b *BacktestEngine) dataRequirements() strategies.DataRequirements {
+	reqs := make([]strategies.DataRequirements, 0, len(b.strategies))
+	for _, strategy := range b.strategies {
+		reqs = append(reqs, strategy.DataRequirements())
+	}
+	return strategies.MergeDataRequirements(reqs...)
+}
+
+// warmUp 加载回测开始前策略所需的历史行情并预热策略
+func (b *BacktestEngine) warmUp(ctx context.Context) error {
+	req := b.dataRequirements()
+
+	// 校验策略所需股票都在回测范围内
+	universe := make(map[string]bool, len(b.config.Symbols))
+	for _, symbol := range b.config.Symbols {
+		universe[symbol] = true
+	}
+	for _, symbol := range req.Symbols {
+		if !universe[symbol] {
+			return fmt.Errorf("required symbol %s not in backtest symbols", symbol)
+		}
+	}
+
+	if req.MinHistory <= 0 {
+		return nil
+	}
+
+	history := make(map[string][]*strategies.MarketData)
+	for _, barTime := range b.calendar.BarsBefore(b.config.StartDate, req.MinHistory, b.config.BarInterval) {
+		marketData, err := b.loadMarketData(barTime)
+		if err != nil {
+			return fmt.Errorf("failed to load history for %s: %v", barTime.Format("2006-01-02 15:04"), err)
+		}
+		if b.snapshot != nil {
+			b.snapshot.record(marketData)
+		}
+		for symbol, data := range marketData {
+			history[symbol] = append(history[symbol], data)
+		}
+	}
+
+	names := make([]string, 0, len(b.strategies))
+	for name := range b.strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, symbol := range b.config.Symbols {
+		for _, name := range names {
+			strategy := b.strategies[name]
+			if !strategy.IsEnabled() {
+				continue
+			}
+			if err := strategies.CheckDataReady(strategy, len(history[symbol])); err != nil {
+				log.Printf("Backtest history incomplete for %s: %v", symbol, err)
+			}
+			if err := strategies.WarmUp(ctx, strategy, history[symbol]); err != nil {
+				return err
+			}
+		}
+	}
 
 	return nil
 }
 
+// loadMarketData 从数据源加载行情，未设置数据源时生成模拟数据
+func (b *BacktestEngine) loadMarketData(date time.Time) (map[string]*strategies.MarketData, error) {
+	if b.dataSource == nil {
+		return b.generateMockMarketData(date), nil
+	}
+	return b.dataSource.LoadMarketData(date, b.config.Symbols)
+}
+
 // executeStrategies 执行策略
 func (b *BacktestEngine) executeStrategies(ctx context.Context, marketData map[string]*strategies.MarketData) ([]*strategies.Signal, error) {
 	var allSignals []*strategies.Signal
 
-	for symbol, data := range marketData {
-		for name, strategy := range b.strategies {
+	// 按固定顺序遍历，保证相同数据的回测结果可复现
+	symbols := make([]string, 0, len(marketData))
+	for symbol := range marketData {
+		symbols = append(symbols, symbol)
+	}
+	sort.Strings(symbols)
+
+	names := make([]string, 0, len(b.strategies))
+	for name := range b.strategies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, symbol := range symbols {
+		data := marketData[symbol]
+		for _, name := range names {
+			strategy := b.strategies[name]
 			if !strategy.IsEnabled() {
 				continue
 			}
@@ -395,40 +747,46 @@ func (b *BacktestEngine) generateMockMarketData(date time.Time) map[string]*stra
 	marketData := make(map[string]*strategies.MarketData)
 
 	for _, symbol := range b.config.Symbols {
-		// 简化的模拟数据生成
-		// 实际应用中应该从数据源获取真实历史数据
-		basePrice := 10.0 + float64(len(symbol)) // 基于股票代码生成基础价格
-
-		// 添加随机波动
-		dayOfYear := date.YearDay()
-		volatility := 0.02 // 2%日波动率
-
-		priceChange := basePrice * volatility * (float64(dayOfYear%100) - 50) / 50
-		open := basePrice + priceChange
-		high := open * (1 + volatility*0.5)
-		low := open * (1 - volatility*0.5)
-		close := open + priceChange*0.5
-
-		marketData[symbol] = &strategies.MarketData{
-			Symbol:        symbol,
-			Open:          open,
-			High:          high,
-			Low:           low,
-			Close:         close,
-			Volume:        1000000 + int64(dayOfYear)*1000,
-			Amount:        close * 1000000,
-			Timestamp:     date,
-			PreClose:      basePrice,
-			Change:        close - basePrice,
-			ChangePercent: (close - basePrice) / basePrice * 100,
-		}
+		marketData[symbol] = mockMarketData(symbol, date)
 	}
 
 	return marketData
 }
 
+// mockMarketData 生成单只股票的模拟K线
+func mockMarketData(symbol string, date time.Time) *strategies.MarketData {
+	// 简化的模拟数据生成
+	// 实际应用中应该从数据源获取真实历史数据
+	basePrice := 10.0 + float64(len(symbol)) // 基于股票代码生成基础价格
+
+	// 添加随机波动
+	dayOfYear := date.YearDay()
+	volatility := 0.02 // 2%日波动率
+
+	priceChange := basePrice * volatility * (float64(dayOfYear%100) - 50) / 50
+	open := basePrice + priceChange
+	high := open * (1 + volatility*0.5)
+	low := open * (1 - volatility*0.5)
+	close := open + priceChange*0.5
+
+	return &strategies.MarketData{
+		Symbol:        symbol,
+		Open:          open,
+		High:          high,
+		Low:           low,
+		Close:         close,
+		Volume:        1000000 + int64(dayOfYear)*1000,
+		Amount:        close * 1000000,
+		Timestamp:     date,
+		PreClose:      basePrice,
+		Change:        close - basePrice,
+		ChangePercent: (close - basePrice) / basePrice * 100,
+		BarClosed:     true,
+	}
+}
+
 // createBacktestTrade 创建回测交易
-func (b *BacktestEngine) createBacktestTrade(tradeID int, signal *strategies.Signal, currentDate time.Time) *BacktestTrade {
+func (b *BacktestEngine) createBacktestTrade(tradeID int, signal *strategies.Signal, bar *strategies.MarketData, currentDate time.Time) *BacktestTrade {
 	// 简化的交易逻辑
 	if signal.SignalType != "buy" && signal.SignalType != "sell" {
 		return nil
@@ -437,24 +795,30 @@ func (b *BacktestEngine) createBacktestTrade(tradeID int, signal *strategies.Sig
 	// 模拟交易参数
 	quantity := int64(1000) // 固定交易数量
 	price := signal.Price
-	commission := price * float64(quantity) * b.config.Commission
-	slippage := price * float64(quantity) * b.config.Slippage
+	slippage := b.slippage.Slippage(signal.SignalType, price, quantity, bar)
+
+	// 滑点体现在成交价上：买入成交价高于信号价格，卖出成交价低于信号价格
+	fillPrice := price + slippage/float64(quantity)
+	if signal.SignalType == "sell" {
+		fillPrice = price - slippage/float64(quantity)
+	}
+	commission := b.commission.Commission(signal.SignalType, fillPrice, quantity)
 
 	// 模拟PnL（简化）
 	var pnl float64
 	if signal.SignalType == "buy" {
-		// 模拟买入后价格上涨
-		pnl = price * float64(quantity) * 0.02 // 2%收益
+		// 模拟买入后价格较信号价格上涨2%，按成交价计算收益
+		pnl = (price*1.02 - fillPrice) * float64(quantity)
 	} else {
-		// 模拟卖出
-		pnl = -commission - slippage // 只有成本
+		// 模拟卖出：低于信号价格成交的差额和佣金为成本
+		pnl = (fillPrice-price)*float64(quantity) - commission
 	}
 
 	return &BacktestTrade{
 		ID:           fmt.Sprintf("trade_%d", tradeID),
 		Symbol:       signal.Symbol,
 		EntryTime:    currentDate,
-		EntryPrice:   price,
+		EntryPrice:   fillPrice,
 		ExitTime:     currentDate.Add(time.Hour),
 		ExitPrice:    price * 1.02,
 		Quantity:     quantity,
@@ -474,10 +838,9 @@ func (b *BacktestEngine) calculateFinalMetrics() {
 		return
 	}
 
-	// 计算年化收益率
-	days := b.results.EndTime.Sub(b.results.StartTime).Hours() / 24
-	if days > 0 {
-		b.results.Summary.AnnualizedReturn = b.results.Summary.TotalReturn * 365 / days
+	// 按K线周期计算年化收益率
+	if periods := len(b.results.EquityCurve); periods > 0 {
+		b.results.Summary.AnnualizedReturn = b.results.Summary.TotalReturn * b.periodsPerYear() / float64(periods)
 	}
 
 	// 计算交易统计
@@ -519,10 +882,16 @@ func (b *BacktestEngine) calculateFinalMetrics() {
 	// 计算夏普比率
 	b.calculateSharpeRatio()
 
+	// 计算收益分布风险指标和索提诺比率
+	b.calculateRiskMetrics()
+
 	// 计算卡尔玛比率
 	if b.results.Summary.MaxDrawdown > 0 {
 		b.results.Summary.CalmarRatio = b.results.Summary.AnnualizedReturn / b.results.Summary.MaxDrawdown
 	}
+
+	// 计算基准比较
+	b.calculateBenchmark()
 }
 
 // calculateMaxDrawdown 计算最大回撤
@@ -586,11 +955,55 @@ func (b *BacktestEngine) calculateSharpeRatio() {
 	// 计算年化指标
 	if stdDev > 0 {
 		annualizedReturn := b.results.Summary.AnnualizedReturn
-		annualizedStdDev := stdDev * b.sqrt(252) // 假设252个交易日
+		annualizedStdDev := stdDev * b.sqrt(b.periodsPerYear())
 		b.results.Summary.SharpeRatio = (annualizedReturn - b.config.RiskFreeRate) / annualizedStdDev
 	}
 }
 
+// calculateRiskMetrics 由单周期收益率计算波动率、下行偏差、偏度、峰度和VaR/CVaR，
+// 并以年化下行偏差计算索提诺比率
+func (b *BacktestEngine) calculateRiskMetrics() {
+	if len(b.results.Returns) < 2 {
+		return
+	}
+
+	returns := make([]float64, len(b.results.Returns))
+	for i, point := range b.results.Returns {
+		returns[i] = point.Return
+	}
+
+	periodsPerYear := b.periodsPerYear()
+	annualize := b.sqrt(periodsPerYear)
+	metrics := &RiskMetrics{
+		Volatility:        sampleStdDev(returns) * annualize,
+		DownsideDeviation: downsideDeviation(returns, b.config.RiskFreeRate/periodsPerYear) * annualize,
+		Skewness:          skewness(returns),
+		Kurtosis:          excessKurtosis(returns),
+	}
+	metrics.VaR95, metrics.CVaR95 = historicalVaR(returns, 0.95)
+	metrics.VaR99, metrics.CVaR99 = historicalVaR(returns, 0.99)
+	b.results.RiskMetrics = metrics
+
+	b.results.Summary.ValueAtRisk = metrics.VaR95
+	b.results.Summary.ConditionalVaR = metrics.CVaR95
+	if metrics.DownsideDeviation > 0 {
+		b.results.Summary.SortinoRatio = (b.results.Summary.AnnualizedReturn - b.config.RiskFreeRate) / metrics.DownsideDeviation
+	}
+}
+
+// addMonthlyReturn 将单周期收益率按YYYY-MM复利累计到当月收益
+func (b *BacktestEngine) addMonthlyReturn(barTime time.Time, periodReturn float64) {
+	month := barTime.Format("2006-01")
+	b.results.MonthlyReturns[month] = (1+b.results.MonthlyReturns[month])*(1+periodReturn) - 1
+}
+
+// setProgress 更新回测进度，只短暂持有锁以免阻塞进度查询
+func (b *BacktestEngine) setProgress(progress float64) {
+	b.mu.Lock()
+	b.progress = progress
+	b.mu.Unlock()
+}
+
 // GetProgress 获取回测进度
 func (b *BacktestEngine) GetProgress() float64 {
 	b.mu.RLock()
@@ -605,17 +1018,30 @@ func (b *BacktestEngine) IsRunning() bool {
 	return b.started && !b.completed
 }
 
-// GetResults 获取回测结果
+// GetResults 获取回测结果，回测进行中结果仍在写入，返回nil
 func (b *BacktestEngine) GetResults() *BacktestResults {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
+	if b.started && !b.completed {
+		return nil
+	}
 	return b.results
 }
 
-// sqrt 计算平方根（简化实现）
+// generateBacktestID 生成回测ID
+func generateBacktestID() string {
+	return fmt.Sprintf("bt_%d", time.Now().UnixNano())
+}
+
+// periodsPerYear 当前K线周期的年化因子
+func (b *BacktestEngine) periodsPerYear() float64 {
+	return b.calendar.PeriodsPerYear(b.config.BarInterval)
+}
+
+// sqrt 计算平方根
 func (b *BacktestEngine) sqrt(x float64) float64 {
 	if x < 0 {
 		return 0
 	}
-	return x
+	return math.Sqrt(x)
 }
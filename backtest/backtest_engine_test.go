@@ -0,0 +1,433 @@
+package backtest
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+// countingDataSource 记录被请求的日期
+type countingDataSource struct {
+	mu    sync.Mutex
+	dates []time.Time
+}
+
+func (c *countingDataSource) LoadMarketData(date time.Time, symbols []string) (map[string]*strategies.MarketData, error) {
+	c.mu.Lock()
+	c.dates = append(c.dates, date)
+	c.mu.Unlock()
+
+	marketData := make(map[string]*strategies.MarketData)
+	for _, symbol := range symbols {
+		marketData[symbol] = &strategies.MarketData{
+			Symbol:    symbol,
+			Open:      10,
+			High:      10.5,
+			Low:       9.5,
+			Close:     10 + float64(date.YearDay()%7)*0.1,
+			Volume:    1000000,
+			Timestamp: date,
+		}
+	}
+	return marketData, nil
+}
+
+func TestBacktestFetchesDeclaredHistory(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        start.AddDate(0, 0, 4),
+		InitialCapital: 100000,
+		Symbols:        []string{"sh600000"},
+	})
+
+	strategy := strategies.NewMLStrategy()
+	if err := strategy.Init(context.Background(), "sh600000", map[string]interface{}{"lookback_days": 10}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if err := engine.AddStrategy(strategy); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+
+	source := &countingDataSource{}
+	if err := engine.SetDataSource(source); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	if _, err := engine.Run(context.Background()); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var history []time.Time
+	for _, date := range source.dates {
+		if date.Before(start) {
+			history = append(history, date)
+		}
+	}
+
+	want := strategy.DataRequirements().MinHistory
+	if len(history) != want {
+		t.Fatalf("fetched %d days of history, want %d", len(history), want)
+	}
+	// 预热只取交易日：2024-03-01为周五，往前15个交易日从02-09开始
+	if first := time.Date(2024, 2, 9, 0, 0, 0, 0, time.UTC); !history[0].Equal(first) {
+		t.Errorf("history starts at %s, want %s", history[0].Format("2006-01-02"), first.Format("2006-01-02"))
+	}
+	if last := start.AddDate(0, 0, -1); !history[len(history)-1].Equal(last) {
+		t.Errorf("history ends at %s, want %s", history[len(history)-1].Format("2006-01-02"), last.Format("2006-01-02"))
+	}
+}
+
+func TestBacktestRejectsMissingRequiredSymbol(t *testing.T) {
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:   time.Date(2024, 3, 2, 0, 0, 0, 0, time.UTC),
+		Symbols:   []string{"sh600000"},
+	})
+	if err := engine.AddStrategy(&pairStrategy{strategies.NewBaseStrategy("pair", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+
+	if _, err := engine.Run(context.Background()); err == nil {
+		t.Fatal("expected error when a required symbol is outside the backtest universe")
+	}
+}
+
+// pairStrategy 需要额外股票的配对策略
+type pairStrategy struct {
+	*strategies.BaseStrategy
+}
+
+func (p *pairStrategy) GenerateSignal(ctx context.Context, data *strategies.MarketData) (*strategies.Signal, error) {
+	return nil, nil
+}
+
+func (p *pairStrategy) DataRequirements() strategies.DataRequirements {
+	return strategies.DataRequirements{
+		Symbols:    []string{"sh600000", "sh600036"},
+		MinHistory: 5,
+		Timeframe:  strategies.DefaultTimeframe,
+	}
+}
+
+// noopStrategy 不产生信号的策略
+type noopStrategy struct {
+	*strategies.BaseStrategy
+}
+
+func (n *noopStrategy) GenerateSignal(ctx context.Context, data *strategies.MarketData) (*strategies.Signal, error) {
+	return nil, nil
+}
+
+func TestHourlyBacktestStepsThroughSessions(t *testing.T) {
+	calendar := DefaultTradingCalendar()
+	// 2024-03-01为周五，03-04为周一，中间周末不应产生K线
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, calendar.Location)
+	end := time.Date(2024, 3, 4, 23, 59, 0, 0, calendar.Location)
+
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        end,
+		InitialCapital: 100000,
+		Symbols:        []string{"sh600000"},
+		BarInterval:    BarHourly,
+	})
+	if err := engine.AddStrategy(&noopStrategy{strategies.NewBaseStrategy("noop", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+
+	source := &countingDataSource{}
+	if err := engine.SetDataSource(source); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var want []time.Time
+	for _, day := range []int{1, 4} {
+		for _, hm := range [][2]int{{9, 30}, {10, 30}, {13, 0}, {14, 0}} {
+			want = append(want, time.Date(2024, 3, day, hm[0], hm[1], 0, 0, calendar.Location))
+		}
+	}
+
+	if len(source.dates) != len(want) {
+		t.Fatalf("loaded %d bars, want %d: %v", len(source.dates), len(want), source.dates)
+	}
+	for i, date := range source.dates {
+		if !date.Equal(want[i]) {
+			t.Errorf("bar %d at %s, want %s", i, date.Format("2006-01-02 15:04"), want[i].Format("2006-01-02 15:04"))
+		}
+	}
+
+	if len(results.EquityCurve) != len(want) {
+		t.Errorf("equity curve has %d points, want %d", len(results.EquityCurve), len(want))
+	}
+	if !results.EndTime.Equal(want[len(want)-1]) {
+		t.Errorf("end time %s, want %s", results.EndTime, want[len(want)-1])
+	}
+}
+
+// blockingDataSource 第blockAt次加载行情时通知测试并阻塞，直到release被关闭
+type blockingDataSource struct {
+	countingDataSource
+	blockAt int
+	calls   int
+	reached chan struct{}
+	release chan struct{}
+}
+
+func (s *blockingDataSource) LoadMarketData(date time.Time, symbols []string) (map[string]*strategies.MarketData, error) {
+	s.calls++
+	if s.calls == s.blockAt {
+		close(s.reached)
+		<-s.release
+	}
+	return s.countingDataSource.LoadMarketData(date, symbols)
+}
+
+func TestProgressObservableDuringRun(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        start.AddDate(0, 1, 0),
+		InitialCapital: 100000,
+		Symbols:        []string{"sh600000"},
+	})
+	if err := engine.AddStrategy(&noopStrategy{strategies.NewBaseStrategy("noop", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	source := &blockingDataSource{blockAt: 10, reached: make(chan struct{}), release: make(chan struct{})}
+	if err := engine.SetDataSource(source); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := engine.Run(context.Background())
+		done <- err
+	}()
+	<-source.reached
+
+	// 回测阻塞在第10根K线时，进度查询必须立即返回
+	progress := make(chan float64, 1)
+	go func() { progress <- engine.GetProgress() }()
+	select {
+	case p := <-progress:
+		if p <= 0 || p >= 100 {
+			t.Errorf("mid-run progress = %.1f, want between 0 and 100", p)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("GetProgress blocked while backtest was running")
+	}
+	if !engine.IsRunning() {
+		t.Error("IsRunning = false mid-run")
+	}
+	if engine.GetResults() != nil {
+		t.Error("GetResults should return nil while results are still being written")
+	}
+
+	close(source.release)
+	if err := <-done; err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if engine.IsRunning() || engine.GetProgress() != 100 || engine.GetResults() == nil {
+		t.Errorf("after run: running=%v progress=%.1f results=%v", engine.IsRunning(), engine.GetProgress(), engine.GetResults())
+	}
+}
+
+func TestConcurrentPollingDuringRun(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        start.AddDate(0, 3, 0),
+		InitialCapital: 100000,
+		Symbols:        []string{"sh600000", "sh600036"},
+		SnapshotData:   true,
+	})
+	if err := engine.AddStrategy(&noopStrategy{strategies.NewBaseStrategy("noop", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if err := engine.SetDataSource(&countingDataSource{}); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			last := 0.0
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				progress := engine.GetProgress()
+				if progress < last {
+					t.Errorf("progress went backwards: %.1f -> %.1f", last, progress)
+					return
+				}
+				last = progress
+				engine.IsRunning()
+				engine.GetResults()
+				engine.GetDataSnapshot()
+			}
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if _, err := engine.Run(context.Background()); err != nil {
+			t.Errorf("Run: %v", err)
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		t.Fatal("backtest did not finish")
+	}
+	close(stop)
+	wg.Wait()
+
+	if err := engine.AddStrategy(&noopStrategy{strategies.NewBaseStrategy("late", 1)}); err == nil {
+		t.Error("AddStrategy after run should fail")
+	}
+	if _, err := engine.Run(context.Background()); err == nil {
+		t.Error("second Run on a completed engine should fail")
+	}
+}
+
+func TestCalendarPeriodsPerYear(t *testing.T) {
+	calendar := DefaultTradingCalendar()
+	tests := []struct {
+		interval BarInterval
+		want     float64
+	}{
+		{BarDaily, 252},
+		{BarHourly, 252 * 4},
+		{BarMinute, 252 * 240},
+	}
+	for _, tt := range tests {
+		if got := calendar.PeriodsPerYear(tt.interval); got != tt.want {
+			t.Errorf("PeriodsPerYear(%s) = %v, want %v", tt.interval, got, tt.want)
+		}
+	}
+}
+
+func TestNewTradingCalendar(t *testing.T) {
+	calendar, err := NewTradingCalendar(CalendarConfig{
+		Holidays: []string{"2026-10-01"},
+		Sessions: []string{"09:30-11:30", "13:00-14:57"},
+	})
+	if err != nil {
+		t.Fatalf("NewTradingCalendar: %v", err)
+	}
+
+	cst := calendar.Location
+	if calendar.IsTradingDay(time.Date(2026, 10, 1, 0, 0, 0, 0, cst)) {
+		t.Error("configured holiday is a trading day")
+	}
+	if calendar.IsMarketOpen(time.Date(2026, 10, 2, 14, 58, 0, 0, cst)) {
+		t.Error("market open after the configured session close")
+	}
+	// UTC 01:30 为北京时间 09:30
+	if !calendar.IsMarketOpen(time.Date(2026, 10, 2, 1, 30, 0, 0, time.UTC)) {
+		t.Error("market closed at the morning open")
+	}
+	if got, want := calendar.NextOpen(time.Date(2026, 9, 30, 15, 0, 0, 0, cst)), time.Date(2026, 10, 2, 9, 30, 0, 0, cst); !got.Equal(want) {
+		t.Errorf("NextOpen over holiday = %s, want %s", got, want)
+	}
+
+	for _, config := range []CalendarConfig{
+		{Holidays: []string{"2026/10/01"}},
+		{Sessions: []string{"09:30"}},
+		{Sessions: []string{"11:30-09:30"}},
+		{Sessions: []string{"13:00-15:00", "09:30-11:30"}},
+	} {
+		if _, err := NewTradingCalendar(config); err == nil {
+			t.Errorf("NewTradingCalendar(%+v) accepted", config)
+		}
+	}
+}
+
+func TestBacktestDrawdownLimit(t *testing.T) {
+	const limit = 0.05
+
+	for _, halt := range []bool{false, true} {
+		start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+		engine := NewBacktestEngine(BacktestConfig{
+			StartDate:        start,
+			EndDate:          start.AddDate(0, 1, 0),
+			InitialCapital:   10000,
+			Commission:       0.05, // 每次卖出亏损约500，远超买入的2%收益
+			Symbols:          []string{"sh600000"},
+			MaxDrawdownLimit: limit,
+			HaltOnDrawdown:   halt,
+		})
+		if err := engine.AddStrategy(&alternatingStrategy{BaseStrategy: strategies.NewBaseStrategy("loser", 1)}); err != nil {
+			t.Fatalf("AddStrategy: %v", err)
+		}
+		if err := engine.SetDataSource(&countingDataSource{}); err != nil {
+			t.Fatalf("SetDataSource: %v", err)
+		}
+
+		results, err := engine.Run(context.Background())
+		if err != nil {
+			t.Fatalf("Run: %v", err)
+		}
+
+		breach := -1
+		for i, point := range results.EquityCurve {
+			if point.Drawdown > limit {
+				breach = i
+				break
+			}
+		}
+		if breach < 0 {
+			t.Fatalf("halt=%v: drawdown never exceeded %.2f", halt, limit)
+		}
+		if len(results.Errors) != 1 {
+			t.Errorf("halt=%v: errors = %v, want one drawdown event", halt, results.Errors)
+		}
+
+		bars := len(engine.calendar.Bars(start, start.AddDate(0, 1, 0), BarDaily))
+		breachTime := results.EquityCurve[breach].Timestamp
+		if halt {
+			if len(results.EquityCurve) != breach+1 {
+				t.Errorf("halted run has %d equity points, want %d", len(results.EquityCurve), breach+1)
+			}
+			if !results.EndTime.Equal(breachTime) {
+				t.Errorf("EndTime = %s, want breach bar %s", results.EndTime, breachTime)
+			}
+			continue
+		}
+
+		if len(results.EquityCurve) != bars {
+			t.Errorf("run has %d equity points, want all %d bars", len(results.EquityCurve), bars)
+		}
+		sellsAfter := 0
+		for _, trade := range results.Trades {
+			if !trade.EntryTime.After(breachTime) {
+				continue
+			}
+			if trade.Side == "buy" {
+				t.Errorf("buy trade at %s after drawdown limit was hit", trade.EntryTime)
+			} else {
+				sellsAfter++
+			}
+		}
+		if sellsAfter == 0 {
+			t.Error("sells should still execute after drawdown limit was hit")
+		}
+	}
+}
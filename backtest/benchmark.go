@@ -0,0 +1,163 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+// loadBenchmarkBar 加载基准在指定K线的行情，基准使用与回测相同的数据源
+func (b *BacktestEngine) loadBenchmarkBar(date time.Time) (*strategies.MarketData, error) {
+	symbol := b.config.BenchmarkSymbol
+	if b.dataSource == nil {
+		return mockMarketData(symbol, date), nil
+	}
+
+	marketData, err := b.dataSource.LoadMarketData(date, []string{symbol})
+	if err != nil {
+		return nil, err
+	}
+	if b.snapshot != nil {
+		b.snapshot.record(marketData)
+	}
+	return marketData[symbol], nil
+}
+
+// recordBenchmark 记录当前K线的基准收盘价，基准也是回测股票时直接使用已加载的行情，
+// 缺失时沿用上一根K线的价格；加载失败时记录错误并停止基准比较，不影响回测本身
+func (b *BacktestEngine) recordBenchmark(date time.Time, marketData map[string]*strategies.MarketData) {
+	if b.config.BenchmarkSymbol == "" || b.benchmarkFailed {
+		return
+	}
+
+	data, ok := marketData[b.config.BenchmarkSymbol]
+	var err error
+	if !ok {
+		data, err = b.loadBenchmarkBar(date)
+	}
+	if err != nil {
+		b.benchmarkFailed = true
+		b.results.Errors = append(b.results.Errors, fmt.Sprintf("load benchmark %s: %v", b.config.BenchmarkSymbol, err))
+		return
+	}
+
+	price := 0.0
+	if data != nil && data.Close > 0 {
+		price = data.Close
+	} else if n := len(b.benchmarkPrices); n > 0 {
+		price = b.benchmarkPrices[n-1]
+	}
+	b.benchmarkPrices = append(b.benchmarkPrices, price)
+}
+
+// calculateBenchmark 计算基准表现以及策略相对基准的alpha、beta、相关系数、跟踪误差和信息比率
+func (b *BacktestEngine) calculateBenchmark() {
+	if b.config.BenchmarkSymbol == "" || b.benchmarkFailed || len(b.benchmarkPrices) != len(b.results.EquityCurve) {
+		return
+	}
+
+	comparison := compareWithBenchmark(b.results.EquityCurve, b.benchmarkPrices, b.periodsPerYear(), b.config.RiskFreeRate)
+	if comparison == nil {
+		b.results.Errors = append(b.results.Errors, fmt.Sprintf("benchmark %s: no price data in backtest period", b.config.BenchmarkSymbol))
+		return
+	}
+	comparison.Symbol = b.config.BenchmarkSymbol
+
+	b.results.Benchmark = comparison.BenchmarkComparison
+	summary := b.results.Summary
+	summary.Alpha = comparison.Alpha
+	summary.Beta = comparison.Beta
+	summary.Correlation = comparison.Correlation
+	summary.TrackingError = comparison.trackingError
+	summary.InformationRatio = comparison.informationRatio
+}
+
+// benchmarkStats 基准比较结果及只写入回测摘要的相对指标
+type benchmarkStats struct {
+	*BenchmarkComparison
+	trackingError    float64
+	informationRatio float64
+}
+
+// compareWithBenchmark 按K线对齐策略权益和基准价格计算比较指标，两者长度相同。
+// 基准价格为0的K线（首个有效价格之前）不参与计算，有效K线少于2根时返回nil。
+// 年化方式与回测摘要一致，alpha为CAPM意义下的年化超额收益。
+func compareWithBenchmark(equity []EquityPoint, prices []float64, periodsPerYear, riskFreeRate float64) *benchmarkStats {
+	first := -1
+	for i, price := range prices {
+		if price > 0 {
+			first = i
+			break
+		}
+	}
+	if first < 0 || len(prices)-first < 2 {
+		return nil
+	}
+
+	periods := len(prices) - first
+	strategyReturns := make([]float64, 0, periods-1)
+	benchmarkReturns := make([]float64, 0, periods-1)
+	for i := first + 1; i < len(prices); i++ {
+		if equity[i-1].Value <= 0 {
+			continue
+		}
+		strategyReturns = append(strategyReturns, equity[i].Value/equity[i-1].Value-1)
+		benchmarkReturns = append(benchmarkReturns, prices[i]/prices[i-1]-1)
+	}
+
+	comparison := &BenchmarkComparison{
+		TotalReturn: prices[len(prices)-1]/prices[first] - 1,
+	}
+	comparison.AnnualizedReturn = comparison.TotalReturn * periodsPerYear / float64(periods)
+
+	// 基准最大回撤
+	peak := prices[first]
+	for _, price := range prices[first:] {
+		peak = math.Max(peak, price)
+		comparison.MaxDrawdown = math.Max(comparison.MaxDrawdown, (peak-price)/peak)
+	}
+
+	stats := &benchmarkStats{BenchmarkComparison: comparison}
+	if len(benchmarkReturns) < 2 {
+		return stats
+	}
+
+	strategyMean, benchmarkMean := mean(strategyReturns), mean(benchmarkReturns)
+	var covariance, strategyVar, benchmarkVar, activeMean float64
+	active := make([]float64, len(benchmarkReturns))
+	for i := range benchmarkReturns {
+		ds, db := strategyReturns[i]-strategyMean, benchmarkReturns[i]-benchmarkMean
+		covariance += ds * db
+		strategyVar += ds * ds
+		benchmarkVar += db * db
+		active[i] = strategyReturns[i] - benchmarkReturns[i]
+		activeMean += active[i]
+	}
+	activeMean /= float64(len(active))
+
+	if benchmarkVar > 0 {
+		comparison.Beta = covariance / benchmarkVar
+		comparison.SharpeRatio = (comparison.AnnualizedReturn - riskFreeRate) /
+			(math.Sqrt(benchmarkVar/float64(len(benchmarkReturns)-1)) * math.Sqrt(periodsPerYear))
+	}
+	if strategyVar > 0 && benchmarkVar > 0 {
+		comparison.Correlation = covariance / math.Sqrt(strategyVar*benchmarkVar)
+	}
+
+	strategyTotal := equity[len(equity)-1].Value/equity[first].Value - 1
+	strategyAnnualized := strategyTotal * periodsPerYear / float64(periods)
+	comparison.Alpha = (strategyAnnualized - riskFreeRate) - comparison.Beta*(comparison.AnnualizedReturn-riskFreeRate)
+
+	var activeVar float64
+	for _, r := range active {
+		activeVar += (r - activeMean) * (r - activeMean)
+	}
+	stats.trackingError = math.Sqrt(activeVar/float64(len(active)-1)) * math.Sqrt(periodsPerYear)
+	if stats.trackingError > 0 {
+		stats.informationRatio = activeMean * periodsPerYear / stats.trackingError
+	}
+
+	return stats
+}
@@ -0,0 +1,106 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+func TestCompareWithBenchmark(t *testing.T) {
+	// 策略每根K线的收益恰为基准的两倍，beta为2、相关系数为1
+	benchmarkReturns := []float64{0.01, -0.02, 0.015, 0.005, -0.01, 0.02}
+	prices := []float64{0, 100} // 首根K线基准缺失，不参与计算
+	equity := []EquityPoint{{Value: 100000}, {Value: 100000}}
+	for _, r := range benchmarkReturns {
+		prices = append(prices, prices[len(prices)-1]*(1+r))
+		equity = append(equity, EquityPoint{Value: equity[len(equity)-1].Value * (1 + 2*r)})
+	}
+
+	stats := compareWithBenchmark(equity, prices, 252, 0)
+	if stats == nil {
+		t.Fatal("expected benchmark comparison")
+	}
+	if math.Abs(stats.Beta-2) > 1e-9 || math.Abs(stats.Correlation-1) > 1e-9 {
+		t.Errorf("beta=%.6f correlation=%.6f, want 2 and 1", stats.Beta, stats.Correlation)
+	}
+	if want := prices[len(prices)-1]/100 - 1; math.Abs(stats.TotalReturn-want) > 1e-12 {
+		t.Errorf("benchmark total return %.6f, want %.6f", stats.TotalReturn, want)
+	}
+	// 峰值101后跌2%
+	if math.Abs(stats.MaxDrawdown-0.02) > 1e-9 {
+		t.Errorf("benchmark max drawdown %.6f, want 0.02", stats.MaxDrawdown)
+	}
+	if stats.trackingError <= 0 {
+		t.Errorf("tracking error %.6f, want positive", stats.trackingError)
+	}
+
+	if compareWithBenchmark(equity[:2], []float64{0, 100}, 252, 0) != nil {
+		t.Error("expected nil with fewer than two benchmark bars")
+	}
+}
+
+// benchmarkDataSource 基准逐日加速上涨，回测股票价格固定
+type benchmarkDataSource struct {
+	start time.Time
+}
+
+func (s *benchmarkDataSource) LoadMarketData(date time.Time, symbols []string) (map[string]*strategies.MarketData, error) {
+	days := int(date.Sub(s.start).Hours() / 24)
+	marketData := make(map[string]*strategies.MarketData)
+	for _, symbol := range symbols {
+		price := 10.0
+		if symbol == "sh000300" {
+			price = 4000 * (1 + 0.01*float64(days*days))
+		}
+		marketData[symbol] = &strategies.MarketData{Symbol: symbol, Open: price, High: price, Low: price, Close: price, Timestamp: date, BarClosed: true}
+	}
+	return marketData, nil
+}
+
+func TestBacktestPopulatesBenchmark(t *testing.T) {
+	calendar := DefaultTradingCalendar()
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, calendar.Location) // 周一
+	end := time.Date(2024, 3, 8, 0, 0, 0, 0, calendar.Location)
+
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:       start,
+		EndDate:         end,
+		InitialCapital:  100000,
+		Symbols:         []string{"sh600000"},
+		BenchmarkSymbol: "sh000300",
+	})
+	if err := engine.AddStrategy(&noopStrategy{strategies.NewBaseStrategy("noop", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if err := engine.SetDataSource(&benchmarkDataSource{start: start}); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if results.Benchmark == nil {
+		t.Fatalf("benchmark not populated, errors: %v", results.Errors)
+	}
+
+	bars := len(results.EquityCurve)
+	if bars < 2 {
+		t.Fatalf("got %d bars", bars)
+	}
+	days := results.EquityCurve[bars-1].Timestamp.Sub(results.EquityCurve[0].Timestamp).Hours() / 24
+	want := 0.01 * math.Round(days) * math.Round(days)
+	if results.Benchmark.Symbol != "sh000300" || math.Abs(results.Benchmark.TotalReturn-want) > 1e-9 {
+		t.Errorf("benchmark %+v, want sh000300 with total return %.6f", results.Benchmark, want)
+	}
+	// 策略不交易，权益不变：beta为0，超额收益为负
+	if results.Summary.Beta != results.Benchmark.Beta || results.Summary.Alpha != results.Benchmark.Alpha {
+		t.Errorf("summary alpha/beta %.4f/%.4f differ from benchmark comparison %+v", results.Summary.Alpha, results.Summary.Beta, results.Benchmark)
+	}
+	if results.Summary.InformationRatio >= 0 || results.Summary.TrackingError <= 0 {
+		t.Errorf("flat strategy against rising benchmark: information ratio %.4f, tracking error %.4f", results.Summary.InformationRatio, results.Summary.TrackingError)
+	}
+}
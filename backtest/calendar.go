@@ -0,0 +1,263 @@
+package backtest
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// BarInterval K线周期
+type BarInterval string
+
+const (
+	BarDaily  BarInterval = "1d" // 日线
+	BarHourly BarInterval = "1h" // 小时线
+	BarMinute BarInterval = "1m" // 分钟线
+)
+
+// tradingDaysPerYear 年交易日数
+const tradingDaysPerYear = 252
+
+// Duration K线时长，日线返回0
+func (i BarInterval) Duration() time.Duration {
+	switch i {
+	case BarHourly:
+		return time.Hour
+	case BarMinute:
+		return time.Minute
+	default:
+		return 0
+	}
+}
+
+// Validate 校验K线周期
+func (i BarInterval) Validate() error {
+	switch i {
+	case "", BarDaily, BarHourly, BarMinute:
+		return nil
+	default:
+		return fmt.Errorf("unsupported bar interval: %s", i)
+	}
+}
+
+// TradingSession 交易时段，以距当日零点的偏移表示
+type TradingSession struct {
+	Open  time.Duration
+	Close time.Duration
+}
+
+// TradingCalendar 交易日历
+type TradingCalendar struct {
+	Location *time.Location   // 交易所时区
+	Sessions []TradingSession // 每日交易时段
+	Holidays map[string]bool  // 休市日期，格式 2006-01-02
+}
+
+// DefaultTradingCalendar A股交易日历：工作日 9:30-11:30、13:00-15:00（北京时间）
+func DefaultTradingCalendar() *TradingCalendar {
+	return &TradingCalendar{
+		Location: time.FixedZone("CST", 8*3600),
+		Sessions: []TradingSession{
+			{Open: 9*time.Hour + 30*time.Minute, Close: 11*time.Hour + 30*time.Minute},
+			{Open: 13 * time.Hour, Close: 15 * time.Hour},
+		},
+		Holidays: make(map[string]bool),
+	}
+}
+
+// CalendarConfig 交易日历配置
+type CalendarConfig struct {
+	Holidays []string `yaml:"holidays"` // 工作日休市日期，格式 2006-01-02
+	Sessions []string `yaml:"sessions"` // 交易时段，格式 09:30-11:30，按交易所时区，为空时使用A股时段
+}
+
+// NewTradingCalendar 按配置创建A股交易日历，在默认日历基础上设置休市日期和交易时段
+func NewTradingCalendar(config CalendarConfig) (*TradingCalendar, error) {
+	calendar := DefaultTradingCalendar()
+
+	for _, holiday := range config.Holidays {
+		day, err := time.Parse("2006-01-02", holiday)
+		if err != nil {
+			return nil, fmt.Errorf("invalid holiday %q: expected 2006-01-02", holiday)
+		}
+		calendar.Holidays[day.Format("2006-01-02")] = true
+	}
+
+	if len(config.Sessions) > 0 {
+		calendar.Sessions = make([]TradingSession, 0, len(config.Sessions))
+		for _, spec := range config.Sessions {
+			session, err := parseSession(spec)
+			if err != nil {
+				return nil, err
+			}
+			if n := len(calendar.Sessions); n > 0 && session.Open < calendar.Sessions[n-1].Close {
+				return nil, fmt.Errorf("session %q overlaps or precedes the previous session", spec)
+			}
+			calendar.Sessions = append(calendar.Sessions, session)
+		}
+	}
+
+	return calendar, nil
+}
+
+// parseSession 解析 09:30-11:30 格式的交易时段
+func parseSession(spec string) (TradingSession, error) {
+	openAt, closeAt, ok := strings.Cut(spec, "-")
+	if !ok {
+		return TradingSession{}, fmt.Errorf("invalid session %q: expected 09:30-11:30", spec)
+	}
+
+	parse := func(s string) (time.Duration, error) {
+		t, err := time.Parse("15:04", strings.TrimSpace(s))
+		if err != nil {
+			return 0, fmt.Errorf("invalid session %q: expected 09:30-11:30", spec)
+		}
+		return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+	}
+
+	session := TradingSession{}
+	var err error
+	if session.Open, err = parse(openAt); err != nil {
+		return TradingSession{}, err
+	}
+	if session.Close, err = parse(closeAt); err != nil {
+		return TradingSession{}, err
+	}
+	if session.Close <= session.Open {
+		return TradingSession{}, fmt.Errorf("invalid session %q: close must be after open", spec)
+	}
+	return session, nil
+}
+
+// IsTradingDay 判断日期是否为交易日
+func (c *TradingCalendar) IsTradingDay(day time.Time) bool {
+	switch day.Weekday() {
+	case time.Saturday, time.Sunday:
+		return false
+	}
+	return !c.Holidays[day.Format("2006-01-02")]
+}
+
+// IsMarketOpen 判断t是否在交易日的交易时段内，时段包含开盘和收盘时刻
+func (c *TradingCalendar) IsMarketOpen(t time.Time) bool {
+	local := t.In(c.Location)
+	if !c.IsTradingDay(local) {
+		return false
+	}
+
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.Location)
+	offset := local.Sub(midnight)
+	for _, session := range c.Sessions {
+		if offset >= session.Open && offset <= session.Close {
+			return true
+		}
+	}
+	return false
+}
+
+// NextOpen 返回不早于t的最近开市时刻：t在交易时段内时返回t，否则返回下一个交易时段的开盘时间，
+// 一年内没有交易时段时返回零值
+func (c *TradingCalendar) NextOpen(t time.Time) time.Time {
+	if c.IsMarketOpen(t) {
+		return t
+	}
+
+	local := t.In(c.Location)
+	day := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, c.Location)
+	for i := 0; i <= 366; i++ {
+		if c.IsTradingDay(day) {
+			for _, session := range c.Sessions {
+				if open := day.Add(session.Open); open.After(t) {
+					return open
+				}
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// BarsPerDay 每个交易日的K线数
+func (c *TradingCalendar) BarsPerDay(interval BarInterval) int {
+	step := interval.Duration()
+	if step == 0 {
+		return 1
+	}
+
+	count := 0
+	for _, session := range c.Sessions {
+		count += int((session.Close - session.Open) / step)
+	}
+	return count
+}
+
+// PeriodsPerYear 年化因子：每年的K线数
+func (c *TradingCalendar) PeriodsPerYear(interval BarInterval) float64 {
+	return float64(tradingDaysPerYear * c.BarsPerDay(interval))
+}
+
+// Bars 返回[start, end]内所有K线的起始时间，跳过非交易日和非交易时段。
+// 日线以start所在时区的零点作为时间戳，日内K线以交易所时区的时段开始时间计算。
+func (c *TradingCalendar) Bars(start, end time.Time, interval BarInterval) []time.Time {
+	var bars []time.Time
+
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+	for !day.After(end) {
+		for _, bar := range c.barsOnDay(day, interval) {
+			if !bar.Before(start) && !bar.After(end) {
+				bars = append(bars, bar)
+			}
+		}
+		day = day.AddDate(0, 0, 1)
+	}
+
+	return bars
+}
+
+// BarsBefore 返回start之前最近的n根K线的起始时间，按时间升序
+func (c *TradingCalendar) BarsBefore(start time.Time, n int, interval BarInterval) []time.Time {
+	if n <= 0 {
+		return nil
+	}
+
+	bars := make([]time.Time, 0, n)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+	// 最多回溯n个交易日加上节假日余量
+	for lookback := 0; len(bars) < n && lookback < n*2+30; lookback++ {
+		dayBars := c.barsOnDay(day, interval)
+		for i := len(dayBars) - 1; i >= 0 && len(bars) < n; i-- {
+			if dayBars[i].Before(start) {
+				bars = append(bars, dayBars[i])
+			}
+		}
+		day = day.AddDate(0, 0, -1)
+	}
+
+	// 反转为升序
+	for i, j := 0, len(bars)-1; i < j; i, j = i+1, j-1 {
+		bars[i], bars[j] = bars[j], bars[i]
+	}
+	return bars
+}
+
+// barsOnDay 返回某个交易日内的全部K线起始时间，非交易日返回空
+func (c *TradingCalendar) barsOnDay(day time.Time, interval BarInterval) []time.Time {
+	if !c.IsTradingDay(day) {
+		return nil
+	}
+
+	step := interval.Duration()
+	if step == 0 {
+		return []time.Time{day}
+	}
+
+	midnight := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, c.Location)
+	bars := make([]time.Time, 0, c.BarsPerDay(interval))
+	for _, session := range c.Sessions {
+		for offset := session.Open; offset+step <= session.Close; offset += step {
+			bars = append(bars, midnight.Add(offset))
+		}
+	}
+	return bars
+}
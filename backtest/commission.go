@@ -0,0 +1,70 @@
+package backtest
+
+import (
+	"fmt"
+	"math"
+)
+
+// CommissionModelType 手续费模型类型
+type CommissionModelType string
+
+const (
+	CommissionFlat   CommissionModelType = "flat"   // 按成交额固定费率
+	CommissionAShare CommissionModelType = "ashare" // A股：最低佣金、卖出印花税、过户费
+)
+
+// CommissionModel 计算单笔成交的交易费用
+type CommissionModel interface {
+	// Commission 返回按side("buy"/"sell")成交quantity股、成交价price的全部费用
+	Commission(side string, price float64, quantity int64) float64
+}
+
+// FlatCommission 按成交额收取固定费率
+type FlatCommission struct {
+	Rate float64
+}
+
+// Commission 实现CommissionModel
+func (f FlatCommission) Commission(side string, price float64, quantity int64) float64 {
+	return price * float64(quantity) * f.Rate
+}
+
+// AShareCommission A股交易费用：佣金不低于最低收费，卖出另收印花税，双向收取过户费
+type AShareCommission struct {
+	Rate        float64 // 佣金费率
+	MinFee      float64 // 每笔最低佣金
+	StampTax    float64 // 卖出印花税率
+	TransferFee float64 // 过户费，每1000股
+}
+
+// Commission 实现CommissionModel
+func (a AShareCommission) Commission(side string, price float64, quantity int64) float64 {
+	amount := price * float64(quantity)
+
+	fee := math.Max(amount*a.Rate, a.MinFee)
+	if side == "sell" {
+		fee += amount * a.StampTax
+	}
+	fee += float64(quantity) / 1000 * a.TransferFee
+	return fee
+}
+
+// newCommissionModel 按回测配置创建手续费模型，未指定时使用固定费率
+func (c BacktestConfig) newCommissionModel() (CommissionModel, error) {
+	switch c.CommissionModel {
+	case "", CommissionFlat:
+		return FlatCommission{Rate: c.Commission}, nil
+	case CommissionAShare:
+		if c.MinCommission < 0 || c.StampTax < 0 || c.TransferFee < 0 {
+			return nil, fmt.Errorf("ashare commission fees must not be negative")
+		}
+		return AShareCommission{
+			Rate:        c.Commission,
+			MinFee:      c.MinCommission,
+			StampTax:    c.StampTax,
+			TransferFee: c.TransferFee,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported commission model: %s", c.CommissionModel)
+	}
+}
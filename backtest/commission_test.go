@@ -0,0 +1,102 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+func TestAShareCommission(t *testing.T) {
+	model := AShareCommission{Rate: 0.00025, MinFee: 5, StampTax: 0.0005, TransferFee: 0.6}
+
+	tests := []struct {
+		name     string
+		side     string
+		price    float64
+		quantity int64
+		want     float64
+	}{
+		// 成交额1万元，佣金2.5元低于最低收费，按5元计
+		{"small buy pays minimum", "buy", 10, 1000, 5 + 0.6},
+		{"small sell adds stamp tax", "sell", 10, 1000, 5 + 5 + 0.6},
+		// 成交额100万元，佣金250元
+		{"large buy", "buy", 100, 10000, 250 + 6},
+		{"large sell", "sell", 100, 10000, 250 + 500 + 6},
+	}
+	for _, tt := range tests {
+		if got := model.Commission(tt.side, tt.price, tt.quantity); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: Commission = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	flat := FlatCommission{Rate: 0.001}
+	if got := flat.Commission("sell", 10, 1000); math.Abs(got-10) > 1e-9 {
+		t.Errorf("flat Commission = %v, want 10", got)
+	}
+}
+
+func TestCommissionModelConfig(t *testing.T) {
+	if _, err := (BacktestConfig{CommissionModel: "tiered"}).newCommissionModel(); err == nil {
+		t.Error("unknown commission model should fail")
+	}
+	if _, err := (BacktestConfig{CommissionModel: CommissionAShare, StampTax: -0.001}).newCommissionModel(); err == nil {
+		t.Error("negative stamp tax should fail")
+	}
+	if model, err := (BacktestConfig{Commission: 0.001}).newCommissionModel(); err != nil || model != (FlatCommission{Rate: 0.001}) {
+		t.Errorf("default model = %v, %v; want flat", model, err)
+	}
+}
+
+func TestBacktestAppliesCommissionModel(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:       start,
+		EndDate:         start.AddDate(0, 0, 7),
+		InitialCapital:  100000,
+		Commission:      0.00025,
+		CommissionModel: CommissionAShare,
+		MinCommission:   5,
+		StampTax:        0.0005,
+		TransferFee:     0.6,
+		Symbols:         []string{"sh600000"},
+	})
+	if err := engine.AddStrategy(&alternatingStrategy{BaseStrategy: strategies.NewBaseStrategy("alt", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if err := engine.SetDataSource(&countingDataSource{}); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	var buys, sells int
+	for _, trade := range results.Trades {
+		amount := trade.EntryPrice * float64(trade.Quantity)
+		want := 5 + 0.6 // 成交额约1万元，佣金按最低收费
+		if trade.Side == "sell" {
+			want += amount * 0.0005
+			sells++
+		} else {
+			buys++
+		}
+		if math.Abs(trade.Commission-want) > 1e-9 {
+			t.Errorf("%s trade commission = %v, want %v", trade.Side, trade.Commission, want)
+		}
+		// 佣金只替换成本项，买入仍按模拟的2%收益计算
+		if trade.Side == "buy" && math.Abs(trade.PnL-trade.EntryPrice*float64(trade.Quantity)*0.02) > 1e-9 {
+			t.Errorf("buy trade pnl = %v, want the simulated 2%% gain", trade.PnL)
+		}
+		if trade.Side == "sell" && math.Abs(trade.PnL+trade.Commission) > 1e-9 {
+			t.Errorf("sell trade pnl = %v, want -%v commission", trade.PnL, trade.Commission)
+		}
+	}
+	if buys == 0 || sells == 0 {
+		t.Fatalf("got %d buys and %d sells, want both", buys, sells)
+	}
+}
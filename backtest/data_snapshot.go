@@ -0,0 +1,185 @@
+package backtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+// DataSource 回测数据源
+type DataSource interface {
+	// LoadMarketData 加载指定日期各股票的行情数据，缺失的股票不出现在结果中
+	LoadMarketData(date time.Time, symbols []string) (map[string]*strategies.MarketData, error)
+}
+
+// DataSnapshot 回测数据快照，保存一次回测实际使用的全部行情及其哈希
+type DataSnapshot struct {
+	ID        string                             `json:"id"`
+	Hash      string                             `json:"hash"`
+	Symbols   []string                           `json:"symbols"`
+	Bars      map[string][]strategies.MarketData `json:"bars"`
+	CreatedAt time.Time                          `json:"created_at"`
+}
+
+// newDataSnapshot 创建空快照
+func newDataSnapshot(id string) *DataSnapshot {
+	return &DataSnapshot{
+		ID:        id,
+		Bars:      make(map[string][]strategies.MarketData),
+		CreatedAt: time.Now(),
+	}
+}
+
+// record 记录一个交易日的行情副本
+func (s *DataSnapshot) record(marketData map[string]*strategies.MarketData) {
+	for symbol, data := range marketData {
+		if data == nil {
+			continue
+		}
+		if _, ok := s.Bars[symbol]; !ok {
+			s.Symbols = append(s.Symbols, symbol)
+		}
+		s.Bars[symbol] = append(s.Bars[symbol], *data)
+	}
+}
+
+// seal 整理快照并计算哈希
+func (s *DataSnapshot) seal() error {
+	sort.Strings(s.Symbols)
+	for _, bars := range s.Bars {
+		sort.SliceStable(bars, func(i, j int) bool {
+			return bars[i].Timestamp.Before(bars[j].Timestamp)
+		})
+	}
+
+	hash, err := s.ComputeHash()
+	if err != nil {
+		return err
+	}
+	s.Hash = hash
+	return nil
+}
+
+// ComputeHash 计算快照行情的SHA-256哈希
+func (s *DataSnapshot) ComputeHash() (string, error) {
+	// json.Marshal 对map按键排序，保证相同数据得到相同哈希
+	data, err := json.Marshal(s.Bars)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode snapshot: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// BarCount 快照中的K线总数
+func (s *DataSnapshot) BarCount() int {
+	count := 0
+	for _, bars := range s.Bars {
+		count += len(bars)
+	}
+	return count
+}
+
+// SnapshotDataSource 基于快照的数据源，用于按原始数据重跑回测
+type SnapshotDataSource struct {
+	snapshot *DataSnapshot
+	index    map[string]map[int64]strategies.MarketData
+}
+
+// NewSnapshotDataSource 创建快照数据源，快照内容与哈希不一致时返回错误
+func NewSnapshotDataSource(snapshot *DataSnapshot) (*SnapshotDataSource, error) {
+	if snapshot == nil {
+		return nil, fmt.Errorf("snapshot is nil")
+	}
+
+	hash, err := snapshot.ComputeHash()
+	if err != nil {
+		return nil, err
+	}
+	if hash != snapshot.Hash {
+		return nil, fmt.Errorf("snapshot %s hash mismatch: expected %s, got %s", snapshot.ID, snapshot.Hash, hash)
+	}
+
+	index := make(map[string]map[int64]strategies.MarketData, len(snapshot.Bars))
+	for symbol, bars := range snapshot.Bars {
+		index[symbol] = make(map[int64]strategies.MarketData, len(bars))
+		for _, bar := range bars {
+			index[symbol][bar.Timestamp.UnixNano()] = bar
+		}
+	}
+
+	return &SnapshotDataSource{
+		snapshot: snapshot,
+		index:    index,
+	}, nil
+}
+
+// LoadMarketData 实现DataSource接口
+func (s *SnapshotDataSource) LoadMarketData(date time.Time, symbols []string) (map[string]*strategies.MarketData, error) {
+	marketData := make(map[string]*strategies.MarketData)
+	for _, symbol := range symbols {
+		bar, ok := s.index[symbol][date.UnixNano()]
+		if !ok {
+			continue
+		}
+		marketData[symbol] = &bar
+	}
+	return marketData, nil
+}
+
+// Hash 快照哈希
+func (s *SnapshotDataSource) Hash() string {
+	return s.snapshot.Hash
+}
+
+// DefaultMaxSnapshots 快照存储默认保留的快照数
+const DefaultMaxSnapshots = 20
+
+// SnapshotStore 回测数据快照存储，快照包含完整K线序列，超出容量时淘汰最早保存的快照
+type SnapshotStore struct {
+	mu        sync.RWMutex
+	snapshots map[string]*DataSnapshot
+	order     []string // 按保存顺序排列的快照ID
+	max       int
+}
+
+// NewSnapshotStore 创建快照存储，maxSnapshots<=0时使用 DefaultMaxSnapshots
+func NewSnapshotStore(maxSnapshots int) *SnapshotStore {
+	if maxSnapshots <= 0 {
+		maxSnapshots = DefaultMaxSnapshots
+	}
+	return &SnapshotStore{
+		snapshots: make(map[string]*DataSnapshot),
+		max:       maxSnapshots,
+	}
+}
+
+// Save 保存快照，超出容量时淘汰最早保存的快照
+func (s *SnapshotStore) Save(snapshot *DataSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.snapshots[snapshot.ID]; !exists {
+		s.order = append(s.order, snapshot.ID)
+	}
+	s.snapshots[snapshot.ID] = snapshot
+
+	for len(s.order) > s.max {
+		delete(s.snapshots, s.order[0])
+		s.order = s.order[1:]
+	}
+}
+
+// Get 获取回测对应的快照
+func (s *SnapshotStore) Get(id string) (*DataSnapshot, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	snapshot, ok := s.snapshots[id]
+	return snapshot, ok
+}
@@ -0,0 +1,170 @@
+package backtest
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+// liveDataSource 模拟可能被修订的实时数据源
+type liveDataSource struct {
+	mu        sync.Mutex
+	basePrice map[string]float64
+}
+
+func (l *liveDataSource) LoadMarketData(date time.Time, symbols []string) (map[string]*strategies.MarketData, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	marketData := make(map[string]*strategies.MarketData)
+	for _, symbol := range symbols {
+		base, ok := l.basePrice[symbol]
+		if !ok {
+			continue
+		}
+		close := base + float64(date.Day())*0.1
+		marketData[symbol] = &strategies.MarketData{
+			Symbol:    symbol,
+			Open:      base,
+			High:      close,
+			Low:       base,
+			Close:     close,
+			Volume:    1000000,
+			Timestamp: date,
+			PreClose:  base,
+		}
+	}
+	return marketData, nil
+}
+
+func (l *liveDataSource) restate(symbol string, price float64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.basePrice[symbol] = price
+}
+
+// closeBuyStrategy 每个交易日按收盘价发出买入信号
+type closeBuyStrategy struct {
+	*strategies.BaseStrategy
+}
+
+func (s *closeBuyStrategy) GenerateSignal(ctx context.Context, data *strategies.MarketData) (*strategies.Signal, error) {
+	return strategies.NewSignal(data.Symbol, "buy", 0.8, data.Close), nil
+}
+
+func runSnapshotBacktest(t *testing.T, source DataSource, store *SnapshotStore) *BacktestResults {
+	t.Helper()
+
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		EndDate:        time.Date(2024, 1, 10, 0, 0, 0, 0, time.UTC),
+		InitialCapital: 100000,
+		Commission:     0.001,
+		Symbols:        []string{"sh600000", "sh600519"},
+		SnapshotData:   true,
+	})
+	if err := engine.AddStrategy(&closeBuyStrategy{strategies.NewBaseStrategy("close_buy", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if err := engine.SetDataSource(source); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+	engine.SetSnapshotStore(store)
+
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return results
+}
+
+func TestBacktestSnapshotReproducesResults(t *testing.T) {
+	live := &liveDataSource{basePrice: map[string]float64{
+		"sh600000": 10,
+		"sh600519": 1500,
+	}}
+	store := NewSnapshotStore(0)
+
+	original := runSnapshotBacktest(t, live, store)
+	if original.DataHash == "" {
+		t.Fatal("expected data hash on snapshotted backtest")
+	}
+
+	snapshot, ok := store.Get(original.ID)
+	if !ok {
+		t.Fatalf("snapshot for %s not stored", original.ID)
+	}
+	if snapshot.Hash != original.DataHash {
+		t.Errorf("snapshot hash %s != result hash %s", snapshot.Hash, original.DataHash)
+	}
+	// 2024-01-01至01-10共8个交易日，2只股票
+	if snapshot.BarCount() != 16 {
+		t.Errorf("expected 16 bars in snapshot, got %d", snapshot.BarCount())
+	}
+
+	// 实时数据被修订后，直接重跑会得到不同结果
+	live.restate("sh600519", 1800)
+	restated := runSnapshotBacktest(t, live, NewSnapshotStore(0))
+	if restated.DataHash == original.DataHash {
+		t.Fatal("expected restated live data to change the data hash")
+	}
+	if restated.Summary.FinalValue == original.Summary.FinalValue {
+		t.Fatal("expected restated live data to change the results")
+	}
+
+	// 基于快照重跑应得到完全相同的结果
+	source, err := NewSnapshotDataSource(snapshot)
+	if err != nil {
+		t.Fatalf("NewSnapshotDataSource: %v", err)
+	}
+	replayed := runSnapshotBacktest(t, source, NewSnapshotStore(0))
+
+	if replayed.DataHash != original.DataHash {
+		t.Errorf("replayed hash %s != original hash %s", replayed.DataHash, original.DataHash)
+	}
+	if !reflect.DeepEqual(replayed.Summary, original.Summary) {
+		t.Errorf("summary differs:\noriginal %+v\nreplayed %+v", original.Summary, replayed.Summary)
+	}
+	if !reflect.DeepEqual(replayed.Trades, original.Trades) {
+		t.Error("trades differ between original and replayed backtest")
+	}
+	if !reflect.DeepEqual(replayed.EquityCurve, original.EquityCurve) {
+		t.Error("equity curve differs between original and replayed backtest")
+	}
+}
+
+func TestNewSnapshotDataSourceRejectsTamperedSnapshot(t *testing.T) {
+	snapshot := newDataSnapshot("bt_test")
+	snapshot.record(map[string]*strategies.MarketData{
+		"sh600000": {Symbol: "sh600000", Close: 10, Timestamp: time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)},
+	})
+	if err := snapshot.seal(); err != nil {
+		t.Fatalf("seal: %v", err)
+	}
+
+	snapshot.Bars["sh600000"][0].Close = 11
+	if _, err := NewSnapshotDataSource(snapshot); err == nil {
+		t.Fatal("expected hash mismatch error for tampered snapshot")
+	}
+}
+
+func TestSnapshotStoreEvictsOldest(t *testing.T) {
+	store := NewSnapshotStore(2)
+	for _, id := range []string{"run_1", "run_2", "run_1", "run_3"} {
+		store.Save(&DataSnapshot{ID: id})
+	}
+
+	// 重复保存不占用额外容量，超出容量时淘汰最早保存的run_1
+	if _, ok := store.Get("run_1"); ok {
+		t.Error("oldest snapshot run_1 was not evicted")
+	}
+	for _, id := range []string{"run_2", "run_3"} {
+		if _, ok := store.Get(id); !ok {
+			t.Errorf("snapshot %s evicted, want kept", id)
+		}
+	}
+}
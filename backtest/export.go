@@ -0,0 +1,160 @@
+package backtest
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// CSVSection 可单独导出的回测结果部分
+type CSVSection string
+
+const (
+	CSVTrades      CSVSection = "trades"       // 交易记录
+	CSVEquity      CSVSection = "equity"       // 收益曲线
+	CSVMonthly     CSVSection = "monthly"      // 月度收益
+	CSVRollingRisk CSVSection = "rolling_risk" // 滚动风险指标
+)
+
+// CSVSections 完整导出时各部分的顺序
+var CSVSections = []CSVSection{CSVTrades, CSVEquity, CSVMonthly, CSVRollingRisk}
+
+// ExportCSV 依次导出交易记录、收益曲线、月度收益和滚动风险指标，各部分以标题行开头并以空行分隔，
+// 便于在Excel中打开；没有数据的部分只输出表头
+func (r *BacktestResults) ExportCSV(w io.Writer) error {
+	for i, section := range CSVSections {
+		if i > 0 {
+			if _, err := io.WriteString(w, "\n"); err != nil {
+				return err
+			}
+		}
+		if _, err := fmt.Fprintf(w, "# %s\n", section); err != nil {
+			return err
+		}
+		if err := r.ExportSectionCSV(w, section); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ExportSectionCSV 以CSV导出单个部分
+func (r *BacktestResults) ExportSectionCSV(w io.Writer, section CSVSection) error {
+	var header []string
+	var rows [][]string
+
+	switch section {
+	case CSVTrades:
+		header = []string{"id", "symbol", "strategy", "side", "entry_time", "entry_price", "exit_time", "exit_price",
+			"quantity", "pnl", "return", "commission", "slippage", "hold_duration"}
+		for _, trade := range r.Trades {
+			rows = append(rows, []string{
+				trade.ID,
+				trade.Symbol,
+				trade.Strategy,
+				trade.Side,
+				formatCSVTime(trade.EntryTime),
+				formatCSVFloat(trade.EntryPrice),
+				formatCSVTime(trade.ExitTime),
+				formatCSVFloat(trade.ExitPrice),
+				strconv.FormatInt(trade.Quantity, 10),
+				formatCSVFloat(trade.PnL),
+				formatCSVFloat(trade.Return),
+				formatCSVFloat(trade.Commission),
+				formatCSVFloat(trade.Slippage),
+				trade.HoldDuration.String(),
+			})
+		}
+	case CSVEquity:
+		header = []string{"timestamp", "value", "drawdown"}
+		for _, point := range r.EquityCurve {
+			rows = append(rows, []string{formatCSVTime(point.Timestamp), formatCSVFloat(point.Value), formatCSVFloat(point.Drawdown)})
+		}
+	case CSVMonthly:
+		header = []string{"month", "return"}
+		for _, monthly := range r.MonthlyReturnSeries() {
+			rows = append(rows, []string{monthly.Month, formatCSVFloat(monthly.Return)})
+		}
+	case CSVRollingRisk:
+		header = []string{"timestamp", "volatility", "var_95", "drawdown"}
+		for _, point := range r.RollingRisk {
+			rows = append(rows, []string{
+				formatCSVTime(point.Timestamp),
+				formatCSVFloat(point.Volatility),
+				formatCSVFloat(point.VaR95),
+				formatCSVFloat(point.Drawdown),
+			})
+		}
+	default:
+		return fmt.Errorf("unknown CSV section %q", section)
+	}
+
+	writer := csv.NewWriter(w)
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+	if err := writer.WriteAll(rows); err != nil {
+		return err
+	}
+	return writer.Error()
+}
+
+// MonthlyReturn 单月收益
+type MonthlyReturn struct {
+	Month  string  `json:"month"`  // YYYY-MM
+	Return float64 `json:"return"` // 当月复利收益率
+}
+
+// MonthlyReturnSeries 按月份升序排列的月度收益
+func (r *BacktestResults) MonthlyReturnSeries() []MonthlyReturn {
+	monthly := r.monthlyReturns()
+	series := make([]MonthlyReturn, 0, len(monthly))
+	for month, ret := range monthly {
+		series = append(series, MonthlyReturn{Month: month, Return: ret})
+	}
+	sort.Slice(series, func(i, j int) bool { return series[i].Month < series[j].Month })
+	return series
+}
+
+// monthlyReturns 月度收益，未单独统计时由收益曲线的月末权益计算，首月相对初始资金
+func (r *BacktestResults) monthlyReturns() map[string]float64 {
+	if len(r.MonthlyReturns) > 0 {
+		return r.MonthlyReturns
+	}
+
+	monthly := make(map[string]float64)
+	if len(r.EquityCurve) == 0 {
+		return monthly
+	}
+
+	base := r.EquityCurve[0].Value
+	if r.Summary != nil && r.Summary.InitialCapital > 0 {
+		base = r.Summary.InitialCapital
+	}
+	for i, point := range r.EquityCurve {
+		month := point.Timestamp.Format("2006-01")
+		last := i == len(r.EquityCurve)-1 || r.EquityCurve[i+1].Timestamp.Format("2006-01") != month
+		if !last {
+			continue
+		}
+		if base > 0 {
+			monthly[month] = point.Value/base - 1
+		}
+		base = point.Value
+	}
+	return monthly
+}
+
+func formatCSVFloat(value float64) string {
+	return strconv.FormatFloat(value, 'f', -1, 64)
+}
+
+func formatCSVTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format("2006-01-02 15:04:05")
+}
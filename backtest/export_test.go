@@ -0,0 +1,122 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+func TestExportCSVSections(t *testing.T) {
+	day := func(month time.Month, d int) time.Time { return time.Date(2024, month, d, 15, 0, 0, 0, time.UTC) }
+	results := &BacktestResults{
+		ID:      "bt_1",
+		Summary: &BacktestSummary{InitialCapital: 100000},
+		EquityCurve: []EquityPoint{
+			{Timestamp: day(1, 30), Value: 105000},
+			{Timestamp: day(1, 31), Value: 110000},
+			{Timestamp: day(2, 1), Value: 99000, Drawdown: 0.1},
+		},
+		Trades: []BacktestTrade{{
+			ID: "trade_1", Symbol: "sh600000", Side: "long", Strategy: "ml",
+			EntryTime: day(1, 30), EntryPrice: 10, ExitTime: day(2, 1), ExitPrice: 11,
+			Quantity: 100, PnL: 100, Return: 0.1, HoldDuration: 48 * time.Hour,
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := results.ExportSectionCSV(&buf, CSVMonthly); err != nil {
+		t.Fatalf("ExportSectionCSV: %v", err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("read monthly CSV: %v", err)
+	}
+	if len(rows) != 3 || rows[1][0] != "2024-01" || rows[2][0] != "2024-02" {
+		t.Fatalf("monthly rows = %v, want header plus 2024-01 and 2024-02", rows)
+	}
+	for i, want := range []float64{0.1, -0.1} {
+		got, _ := strconv.ParseFloat(rows[i+1][1], 64)
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s return = %v, want %v", rows[i+1][0], got, want)
+		}
+	}
+
+	buf.Reset()
+	if err := results.ExportCSV(&buf); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	out := buf.String()
+	for _, want := range []string{"# trades\n", "trade_1,sh600000,ml,long,2024-01-30 15:00:00,10,", "# equity\n", "2024-02-01 15:00:00,99000,0.1", "# monthly\n", "# rolling_risk\ntimestamp,volatility,var_95,drawdown\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("export missing %q:\n%s", want, out)
+		}
+	}
+
+	if err := results.ExportSectionCSV(&buf, "positions"); err == nil {
+		t.Error("unknown section should fail")
+	}
+}
+
+func TestExportCSVEmptyResults(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (&BacktestResults{}).ExportCSV(&buf); err != nil {
+		t.Fatalf("ExportCSV: %v", err)
+	}
+	want := "# trades\n" +
+		"id,symbol,strategy,side,entry_time,entry_price,exit_time,exit_price,quantity,pnl,return,commission,slippage,hold_duration\n" +
+		"\n# equity\ntimestamp,value,drawdown\n" +
+		"\n# monthly\nmonth,return\n" +
+		"\n# rolling_risk\ntimestamp,volatility,var_95,drawdown\n"
+	if buf.String() != want {
+		t.Errorf("empty export = %q, want %q", buf.String(), want)
+	}
+}
+
+func TestBacktestAccumulatesMonthlyReturns(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)
+	bars := DefaultTradingCalendar().Bars(start, end, BarDaily)
+
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        end,
+		InitialCapital: 100000,
+		Commission:     0.02,
+		Symbols:        []string{"sh600000"},
+	})
+	if err := engine.AddStrategy(&alternatingStrategy{BaseStrategy: strategies.NewBaseStrategy("alternating", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if err := engine.SetDataSource(&regimeDataSource{switchAt: bars[30]}); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	series := results.MonthlyReturnSeries()
+	if len(series) != 3 || series[0].Month != "2024-01" || series[2].Month != "2024-03" {
+		t.Fatalf("monthly series = %+v, want 2024-01 through 2024-03", series)
+	}
+
+	// 逐日复利累计的结果应与按月末权益计算的结果一致，各月之积等于总收益
+	fromEquity := (&BacktestResults{Summary: results.Summary, EquityCurve: results.EquityCurve}).monthlyReturns()
+	compounded := 1.0
+	for _, monthly := range series {
+		if math.Abs(monthly.Return-fromEquity[monthly.Month]) > 1e-9 {
+			t.Errorf("%s return = %v, equity curve gives %v", monthly.Month, monthly.Return, fromEquity[monthly.Month])
+		}
+		compounded *= 1 + monthly.Return
+	}
+	if math.Abs(compounded-1-results.Summary.TotalReturn) > 1e-9 {
+		t.Errorf("compounded monthly return = %v, want total return %v", compounded-1, results.Summary.TotalReturn)
+	}
+}
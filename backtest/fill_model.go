@@ -0,0 +1,152 @@
+package backtest
+
+import (
+	"log"
+	"math"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+// 策略通过信号Metadata声明限价单
+const (
+	MetaOrderType  = "order_type"  // 订单类型：market(默认)或limit
+	MetaLimitPrice = "limit_price" // 限价，未设置时使用信号价格
+
+	OrderTypeMarket = "market"
+	OrderTypeLimit  = "limit"
+)
+
+// restingOrder 尚未成交的限价单
+type restingOrder struct {
+	signal    *strategies.Signal
+	limit     float64
+	placedAt  time.Time
+	remaining int // 剩余可撮合的K线数
+}
+
+// limitPrice 解析信号中的限价，非限价单返回false
+func limitPrice(signal *strategies.Signal) (float64, bool) {
+	if signal.Metadata == nil {
+		return 0, false
+	}
+	if orderType, _ := signal.Metadata[MetaOrderType].(string); orderType != OrderTypeLimit {
+		return 0, false
+	}
+	if limit, ok := signal.Metadata[MetaLimitPrice].(float64); ok && limit > 0 {
+		return limit, true
+	}
+	return signal.Price, signal.Price > 0
+}
+
+// limitFillPrice 判断限价单能否在该K线成交并返回成交价。
+// 买入限价要求最低价不高于限价，卖出限价要求最高价不低于限价；
+// 挂单在后续K线开盘即穿过限价时按开盘价成交。
+func limitFillPrice(bar *strategies.MarketData, side string, limit float64, resting bool) (float64, bool) {
+	if bar == nil {
+		return 0, false
+	}
+
+	switch side {
+	case "buy":
+		if bar.Low > limit {
+			return 0, false
+		}
+		if resting && bar.Open > 0 {
+			return math.Min(limit, bar.Open), true
+		}
+		return limit, true
+	case "sell":
+		if bar.High < limit {
+			return 0, false
+		}
+		if resting && bar.Open > 0 {
+			return math.Max(limit, bar.Open), true
+		}
+		return limit, true
+	default:
+		return 0, false
+	}
+}
+
+// filledAt 复制信号并设置成交价
+func filledAt(signal *strategies.Signal, price float64) *strategies.Signal {
+	filled := *signal
+	filled.Price = price
+	return &filled
+}
+
+// placeOrders 处理新信号：市价单按信号价格立即成交，限价单按当前K线区间撮合，
+// 未成交的限价单在 LimitOrderTTL 根K线内继续挂单
+func (b *BacktestEngine) placeOrders(signals []*strategies.Signal, marketData map[string]*strategies.MarketData, barTime time.Time) []*strategies.Signal {
+	if !b.config.LimitOrders {
+		return signals
+	}
+
+	filled := make([]*strategies.Signal, 0, len(signals))
+	for _, signal := range signals {
+		limit, ok := limitPrice(signal)
+		if !ok {
+			filled = append(filled, signal)
+			continue
+		}
+
+		if price, ok := limitFillPrice(marketData[signal.Symbol], signal.SignalType, limit, false); ok {
+			filled = append(filled, filledAt(signal, price))
+			continue
+		}
+
+		if b.config.LimitOrderTTL > 0 {
+			b.restingOrders = append(b.restingOrders, &restingOrder{
+				signal:    signal,
+				limit:     limit,
+				placedAt:  barTime,
+				remaining: b.config.LimitOrderTTL,
+			})
+			continue
+		}
+		b.expireOrder(signal, limit, barTime)
+	}
+
+	return filled
+}
+
+// matchRestingOrders 用当前K线撮合挂单中的限价单，到期未成交的订单被撤销
+func (b *BacktestEngine) matchRestingOrders(marketData map[string]*strategies.MarketData, barTime time.Time) []*strategies.Signal {
+	var filled []*strategies.Signal
+	resting := b.restingOrders[:0]
+
+	for _, order := range b.restingOrders {
+		if price, ok := limitFillPrice(marketData[order.signal.Symbol], order.signal.SignalType, order.limit, true); ok {
+			filled = append(filled, filledAt(order.signal, price))
+			continue
+		}
+
+		order.remaining--
+		if order.remaining <= 0 {
+			b.expireOrder(order.signal, order.limit, order.placedAt)
+			continue
+		}
+		resting = append(resting, order)
+	}
+
+	b.restingOrders = resting
+	return filled
+}
+
+// cancelRestingOrders 回测结束时撤销仍在挂单的限价单
+func (b *BacktestEngine) cancelRestingOrders() {
+	for _, order := range b.restingOrders {
+		b.results.Summary.CancelledOrders++
+		log.Printf("Limit %s order for %s at %.2f placed %s cancelled at end of backtest",
+			order.signal.SignalType, order.signal.Symbol, order.limit, order.placedAt.Format("2006-01-02 15:04"))
+	}
+	b.restingOrders = nil
+}
+
+// expireOrder 记录未成交而过期的限价单
+func (b *BacktestEngine) expireOrder(signal *strategies.Signal, limit float64, placedAt time.Time) {
+	b.results.Summary.ExpiredOrders++
+	log.Printf("Limit %s order for %s at %.2f placed %s expired unfilled",
+		signal.SignalType, signal.Symbol, limit, placedAt.Format("2006-01-02 15:04"))
+}
@@ -0,0 +1,143 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+// rangeDataSource 每根K线开盘10，区间[low, 10.5]，指定日期起最低价下探到dipLow
+type rangeDataSource struct {
+	low    float64
+	dipAt  time.Time
+	dipLow float64
+}
+
+func (r *rangeDataSource) LoadMarketData(date time.Time, symbols []string) (map[string]*strategies.MarketData, error) {
+	low := r.low
+	if !r.dipAt.IsZero() && !date.Before(r.dipAt) {
+		low = r.dipLow
+	}
+
+	marketData := make(map[string]*strategies.MarketData)
+	for _, symbol := range symbols {
+		marketData[symbol] = &strategies.MarketData{
+			Symbol:    symbol,
+			Open:      10,
+			High:      10.5,
+			Low:       low,
+			Close:     10,
+			Timestamp: date,
+			BarClosed: true,
+		}
+	}
+	return marketData, nil
+}
+
+// limitBuyOnceStrategy 在第一根K线发出一笔限价买单
+type limitBuyOnceStrategy struct {
+	*strategies.BaseStrategy
+	limit float64
+	sent  bool
+}
+
+func (l *limitBuyOnceStrategy) GenerateSignal(ctx context.Context, data *strategies.MarketData) (*strategies.Signal, error) {
+	if l.sent {
+		return nil, nil
+	}
+	l.sent = true
+	return &strategies.Signal{
+		Symbol:     data.Symbol,
+		SignalType: "buy",
+		Price:      data.Close,
+		Timestamp:  data.Timestamp,
+		Metadata: map[string]interface{}{
+			MetaOrderType:  OrderTypeLimit,
+			MetaLimitPrice: l.limit,
+		},
+	}, nil
+}
+
+func runLimitBacktest(t *testing.T, limit float64, ttl int, source DataSource) *BacktestResults {
+	t.Helper()
+
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        start.AddDate(0, 0, 4),
+		InitialCapital: 100000,
+		Symbols:        []string{"sh600000"},
+		LimitOrders:    true,
+		LimitOrderTTL:  ttl,
+	})
+	if err := engine.AddStrategy(&limitBuyOnceStrategy{BaseStrategy: strategies.NewBaseStrategy("limit", 1), limit: limit}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if err := engine.SetDataSource(source); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	return results
+}
+
+func TestLimitBuyBelowBarRangeDoesNotFill(t *testing.T) {
+	results := runLimitBacktest(t, 9.0, 0, &rangeDataSource{low: 9.5})
+
+	if len(results.Trades) != 0 {
+		t.Fatalf("got %d trades, want none for a limit below the bar low", len(results.Trades))
+	}
+	if results.Summary.ExpiredOrders != 1 {
+		t.Errorf("expired orders = %d, want 1", results.Summary.ExpiredOrders)
+	}
+}
+
+func TestLimitBuyWithinBarRangeFillsAtLimit(t *testing.T) {
+	results := runLimitBacktest(t, 9.8, 0, &rangeDataSource{low: 9.5})
+
+	if len(results.Trades) != 1 {
+		t.Fatalf("got %d trades, want 1", len(results.Trades))
+	}
+	if trade := results.Trades[0]; trade.EntryPrice != 9.8 {
+		t.Errorf("filled at %.2f, want limit 9.80", trade.EntryPrice)
+	}
+	if results.Summary.ExpiredOrders != 0 {
+		t.Errorf("expired orders = %d, want 0", results.Summary.ExpiredOrders)
+	}
+}
+
+func TestRestingLimitFillsOnLaterBar(t *testing.T) {
+	dipAt := time.Date(2024, 3, 6, 0, 0, 0, 0, time.UTC)
+	results := runLimitBacktest(t, 9.0, 3, &rangeDataSource{low: 9.5, dipAt: dipAt, dipLow: 8.8})
+
+	if len(results.Trades) != 1 {
+		t.Fatalf("got %d trades, want 1", len(results.Trades))
+	}
+	trade := results.Trades[0]
+	if !trade.EntryTime.Equal(dipAt) || trade.EntryPrice != 9.0 {
+		t.Errorf("filled at %.2f on %s, want 9.00 on %s", trade.EntryPrice, trade.EntryTime.Format("2006-01-02"), dipAt.Format("2006-01-02"))
+	}
+
+	// 挂单期内价格始终未触及限价则过期
+	results = runLimitBacktest(t, 9.0, 1, &rangeDataSource{low: 9.5, dipAt: dipAt, dipLow: 8.8})
+	if len(results.Trades) != 0 || results.Summary.ExpiredOrders != 1 {
+		t.Errorf("got %d trades and %d expired orders, want 0 and 1", len(results.Trades), results.Summary.ExpiredOrders)
+	}
+}
+
+func TestRestingLimitCancelledAtEndOfBacktest(t *testing.T) {
+	// 挂单期长于回测区间，结束时仍未成交的挂单被撤销
+	results := runLimitBacktest(t, 9.0, 10, &rangeDataSource{low: 9.5})
+
+	if len(results.Trades) != 0 {
+		t.Fatalf("got %d trades, want none", len(results.Trades))
+	}
+	if results.Summary.CancelledOrders != 1 || results.Summary.ExpiredOrders != 0 {
+		t.Errorf("cancelled %d and expired %d orders, want 1 and 0", results.Summary.CancelledOrders, results.Summary.ExpiredOrders)
+	}
+}
@@ -102,18 +102,20 @@ func NewParameterSearch(config SearchConfig, engine *BacktestEngine) *ParameterS
 
 // Optimize 执行参数优化
 func (p *ParameterSearch) Optimize(ctx context.Context) (*OptimizationResult, error) {
+	// 搜索过程中storeResults需要获取锁，这里只在状态切换时持有
 	p.mu.Lock()
-	defer p.mu.Unlock()
-
 	if p.started {
+		p.mu.Unlock()
 		return nil, fmt.Errorf("parameter search is already running")
 	}
-
 	p.started = true
 	p.completed = false
+	p.mu.Unlock()
 
 	defer func() {
+		p.mu.Lock()
 		p.completed = true
+		p.mu.Unlock()
 	}()
 
 	log.Printf("Starting parameter optimization: method=%s, metric=%s", p.config.Method, p.config.Metric)
@@ -466,23 +468,13 @@ func (p *ParameterSearch) generateRandomParameters(space *ParameterSpace) map[st
 
 // runBacktestWithParams 使用指定参数运行回测
 func (p *ParameterSearch) runBacktestWithParams(params map[string]interface{}) (*BacktestResults, error) {
-	// 创建回测配置副本
-	config := *p.engine.config
-
 	// 应用参数到策略
 	if err := p.applyParametersToStrategies(params); err != nil {
 		return nil, fmt.Errorf("failed to apply parameters: %v", err)
 	}
 
-	// 创建新的回测引擎
-	engine := NewBacktestEngine(config)
-
-	// 复制策略
-	for _, strategy := range p.engine.strategies {
-		if err := engine.AddStrategy(strategy); err != nil {
-			return nil, fmt.Errorf("failed to add strategy: %v", err)
-		}
-	}
+	// 创建新的回测引擎，沿用原引擎的策略、数据源和交易日历
+	engine := p.engine.withPeriod(p.engine.config.StartDate, p.engine.config.EndDate)
 
 	// 执行回测
 	ctx := context.Background()
@@ -0,0 +1,63 @@
+package backtest
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// ErrRunNotFound 指定ID的回测结果不存在
+var ErrRunNotFound = errors.New("backtest run not found")
+
+// RunSummary 已保存回测的主要指标，用于跨会话比较同一策略调参前后的表现
+type RunSummary struct {
+	ID          string    `json:"id"`
+	ConfigHash  string    `json:"config_hash"`
+	DataHash    string    `json:"data_hash,omitempty"`
+	TotalReturn float64   `json:"total_return"`
+	SharpeRatio float64   `json:"sharpe_ratio"`
+	MaxDrawdown float64   `json:"max_drawdown"`
+	WinRate     float64   `json:"win_rate"`
+	TotalTrades int       `json:"total_trades"`
+	StartTime   time.Time `json:"start_time"`
+	EndTime     time.Time `json:"end_time"`
+}
+
+// ResultStore 回测结果持久化存储，ListRuns按回测开始时间倒序
+type ResultStore interface {
+	SaveResults(results *BacktestResults) error
+	LoadResults(id string) (*BacktestResults, error)
+	// ListRuns 列出已保存的回测，configHash为空表示全部
+	ListRuns(configHash string) ([]RunSummary, error)
+}
+
+// NewRunSummary 提取回测结果的主要指标
+func NewRunSummary(results *BacktestResults) RunSummary {
+	summary := RunSummary{
+		ID:         results.ID,
+		ConfigHash: results.ConfigHash,
+		DataHash:   results.DataHash,
+		StartTime:  results.StartTime,
+		EndTime:    results.EndTime,
+	}
+	if results.Summary != nil {
+		summary.TotalReturn = results.Summary.TotalReturn
+		summary.SharpeRatio = results.Summary.SharpeRatio
+		summary.MaxDrawdown = results.Summary.MaxDrawdown
+		summary.WinRate = results.Summary.WinRate
+		summary.TotalTrades = results.Summary.TotalTrades
+	}
+	return summary
+}
+
+// Hash 回测配置的SHA-256哈希，配置相同的回测哈希相同，可据此归类同一组参数的多次运行
+func (c BacktestConfig) Hash() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
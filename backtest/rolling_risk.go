@@ -0,0 +1,53 @@
+package backtest
+
+import (
+	"math"
+	"time"
+)
+
+// RollingRiskPoint 滚动风险指标点
+type RollingRiskPoint struct {
+	Timestamp  time.Time `json:"timestamp"`
+	Volatility float64   `json:"volatility"` // 窗口内年化波动率
+	VaR95      float64   `json:"var_95"`     // 窗口内95%历史VaR，以正数表示损失比例
+	Drawdown   float64   `json:"drawdown"`   // 相对窗口内权益高点的回撤
+}
+
+// rollingRiskPoint 以最近window个收益率计算当前K线的风险指标，数据不足时返回false
+func rollingRiskPoint(returns []ReturnPoint, equity []EquityPoint, window int, periodsPerYear float64) (RollingRiskPoint, bool) {
+	if window < 2 || len(returns) < window || len(equity) == 0 {
+		return RollingRiskPoint{}, false
+	}
+
+	recent := make([]float64, window)
+	for i, point := range returns[len(returns)-window:] {
+		recent[i] = point.Return
+	}
+
+	// 历史VaR：取收益率分布的5%分位数
+	valueAtRisk, _ := historicalVaR(recent, 0.95)
+
+	// 收益率窗口对应window+1个权益点
+	start := len(equity) - window - 1
+	if start < 0 {
+		start = 0
+	}
+	current := equity[len(equity)-1]
+	peak := current.Value
+	for _, point := range equity[start:] {
+		if point.Value > peak {
+			peak = point.Value
+		}
+	}
+	var drawdown float64
+	if peak > 0 {
+		drawdown = (peak - current.Value) / peak
+	}
+
+	return RollingRiskPoint{
+		Timestamp:  current.Timestamp,
+		Volatility: sampleStdDev(recent) * math.Sqrt(periodsPerYear),
+		VaR95:      valueAtRisk,
+		Drawdown:   drawdown,
+	}, true
+}
@@ -0,0 +1,143 @@
+package backtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+// regimeDataSource 在切换时间前后给出不同价位，用于驱动不同幅度的盈亏
+type regimeDataSource struct {
+	switchAt time.Time
+}
+
+func (r *regimeDataSource) LoadMarketData(date time.Time, symbols []string) (map[string]*strategies.MarketData, error) {
+	price := 1.0
+	if !date.Before(r.switchAt) {
+		price = 100
+	}
+
+	marketData := make(map[string]*strategies.MarketData)
+	for _, symbol := range symbols {
+		marketData[symbol] = &strategies.MarketData{
+			Symbol:    symbol,
+			Close:     price,
+			Timestamp: date,
+			BarClosed: true,
+		}
+	}
+	return marketData, nil
+}
+
+// alternatingStrategy 交替发出买入和卖出信号
+type alternatingStrategy struct {
+	*strategies.BaseStrategy
+	calls int
+}
+
+func (a *alternatingStrategy) GenerateSignal(ctx context.Context, data *strategies.MarketData) (*strategies.Signal, error) {
+	signalType := "buy"
+	if a.calls%2 == 1 {
+		signalType = "sell"
+	}
+	a.calls++
+	return &strategies.Signal{
+		Symbol:     data.Symbol,
+		SignalType: signalType,
+		Price:      data.Close,
+		Timestamp:  data.Timestamp,
+		Metadata:   make(map[string]interface{}),
+	}, nil
+}
+
+func TestRollingVolatilityTracksRegimeChange(t *testing.T) {
+	const window = 10
+
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)
+	bars := DefaultTradingCalendar().Bars(start, end, BarDaily)
+	switchAt := bars[30]
+
+	// 手续费2%：买入盈利与卖出亏损幅度相同，价位决定收益率波动大小
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        end,
+		InitialCapital: 100000,
+		Commission:     0.02,
+		Symbols:        []string{"sh600000"},
+		RiskWindow:     window,
+	})
+	if err := engine.AddStrategy(&alternatingStrategy{BaseStrategy: strategies.NewBaseStrategy("alternating", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if err := engine.SetDataSource(&regimeDataSource{switchAt: switchAt}); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// 第一个点需要window个收益率，即第window+1根K线
+	if want := len(bars) - window; len(results.RollingRisk) != want {
+		t.Fatalf("got %d rolling points, want %d", len(results.RollingRisk), want)
+	}
+	if first := results.RollingRisk[0].Timestamp; !first.Equal(bars[window]) {
+		t.Errorf("first rolling point at %s, want %s", first.Format("2006-01-02"), bars[window].Format("2006-01-02"))
+	}
+
+	const threshold = 0.02
+	for _, point := range results.RollingRisk {
+		switch {
+		case point.Timestamp.Before(switchAt):
+			if point.Volatility >= threshold {
+				t.Errorf("calm regime volatility %.4f at %s, want < %.2f", point.Volatility, point.Timestamp.Format("2006-01-02"), threshold)
+			}
+		default:
+			if point.Volatility < threshold {
+				t.Errorf("volatile regime volatility %.4f at %s, want >= %.2f", point.Volatility, point.Timestamp.Format("2006-01-02"), threshold)
+			}
+			if point.Timestamp.After(bars[30+window]) && point.VaR95 <= 0 {
+				t.Errorf("VaR at %s = %v, want positive", point.Timestamp.Format("2006-01-02"), point.VaR95)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := results.ExportSectionCSV(&buf, CSVRollingRisk); err != nil {
+		t.Fatalf("ExportSectionCSV: %v", err)
+	}
+	records, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("parse csv: %v", err)
+	}
+	if len(records) != len(results.RollingRisk)+1 || records[0][1] != "volatility" {
+		t.Errorf("csv has %d rows, header %v", len(records), records[0])
+	}
+}
+
+func TestRollingRiskDisabledByDefault(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        start.AddDate(0, 1, 0),
+		InitialCapital: 100000,
+		Symbols:        []string{"sh600000"},
+	})
+	if err := engine.AddStrategy(&noopStrategy{strategies.NewBaseStrategy("noop", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results.RollingRisk) != 0 {
+		t.Errorf("got %d rolling points with risk window disabled", len(results.RollingRisk))
+	}
+}
@@ -0,0 +1,70 @@
+package backtest
+
+import (
+	"fmt"
+
+	"cloudquant/trading/strategies"
+)
+
+// SlippageModelType 滑点模型类型
+type SlippageModelType string
+
+const (
+	SlippageFixed  SlippageModelType = "fixed"  // 按成交额固定比例
+	SlippageVolume SlippageModelType = "volume" // 按订单占K线成交量的比例放大冲击成本
+)
+
+// SlippageModel 计算单笔成交的滑点成本
+type SlippageModel interface {
+	// Slippage 返回在bar上成交quantity股、成交价price的滑点成本（元），bar可能为nil
+	Slippage(side string, price float64, quantity int64, bar *strategies.MarketData) float64
+}
+
+// FixedSlippage 按成交额收取固定比例的滑点
+type FixedSlippage struct {
+	Rate float64
+}
+
+// Slippage 实现SlippageModel
+func (f FixedSlippage) Slippage(side string, price float64, quantity int64, bar *strategies.MarketData) float64 {
+	return price * float64(quantity) * f.Rate
+}
+
+// VolumeSlippage 成交量参与率滑点：比例为 Rate + Impact * 订单量/K线成交量，
+// 订单占K线成交量越大冲击成本越高；K线无成交量时只收取Rate
+type VolumeSlippage struct {
+	Rate    float64 // 基础滑点比例
+	Impact  float64 // 冲击系数，参与率为100%时额外增加的滑点比例
+	MaxRate float64 // 滑点比例上限，0表示不限
+}
+
+// Slippage 实现SlippageModel
+func (v VolumeSlippage) Slippage(side string, price float64, quantity int64, bar *strategies.MarketData) float64 {
+	rate := v.Rate
+	if bar != nil && bar.Volume > 0 {
+		rate += v.Impact * float64(quantity) / float64(bar.Volume)
+	}
+	if v.MaxRate > 0 && rate > v.MaxRate {
+		rate = v.MaxRate
+	}
+	return price * float64(quantity) * rate
+}
+
+// newSlippageModel 按回测配置创建滑点模型，未指定时使用固定比例
+func (c BacktestConfig) newSlippageModel() (SlippageModel, error) {
+	switch c.SlippageModel {
+	case "", SlippageFixed:
+		return FixedSlippage{Rate: c.Slippage}, nil
+	case SlippageVolume:
+		if c.SlippageImpact < 0 || c.MaxSlippage < 0 {
+			return nil, fmt.Errorf("volume slippage impact and cap must not be negative")
+		}
+		return VolumeSlippage{
+			Rate:    c.Slippage,
+			Impact:  c.SlippageImpact,
+			MaxRate: c.MaxSlippage,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unsupported slippage model: %s", c.SlippageModel)
+	}
+}
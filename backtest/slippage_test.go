@@ -0,0 +1,117 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+func TestFixedSlippage(t *testing.T) {
+	model := FixedSlippage{Rate: 0.0005}
+	bar := &strategies.MarketData{Volume: 1000}
+
+	// 固定比例与订单规模和成交量无关
+	if got := model.Slippage("buy", 10, 1000, bar); math.Abs(got-5) > 1e-9 {
+		t.Errorf("Slippage = %v, want 5", got)
+	}
+	if got := model.Slippage("sell", 10, 100000, nil); math.Abs(got-500) > 1e-9 {
+		t.Errorf("Slippage = %v, want 500", got)
+	}
+}
+
+func TestVolumeSlippage(t *testing.T) {
+	model := VolumeSlippage{Rate: 0.0005, Impact: 0.1, MaxRate: 0.01}
+
+	tests := []struct {
+		name     string
+		quantity int64
+		volume   int64
+		wantRate float64
+	}{
+		{"negligible participation", 1000, 10000000, 0.0005 + 0.1*0.0001},
+		{"five percent participation", 5000, 100000, 0.0005 + 0.1*0.05},
+		{"capped", 100000, 100000, 0.01},
+		{"no volume falls back to base", 1000, 0, 0.0005},
+	}
+	for _, tt := range tests {
+		bar := &strategies.MarketData{Volume: tt.volume}
+		want := 10 * float64(tt.quantity) * tt.wantRate
+		if got := model.Slippage("buy", 10, tt.quantity, bar); math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s: Slippage = %v, want %v", tt.name, got, want)
+		}
+	}
+
+	// 同一根K线上，订单越大单位滑点越高
+	bar := &strategies.MarketData{Volume: 1000000}
+	small := model.Slippage("buy", 10, 1000, bar) / 1000
+	large := model.Slippage("buy", 10, 50000, bar) / 50000
+	if large <= small {
+		t.Errorf("per-share slippage %v for large order not above %v for small order", large, small)
+	}
+}
+
+func TestSlippageModelConfig(t *testing.T) {
+	if _, err := (BacktestConfig{SlippageModel: "sqrt"}).newSlippageModel(); err == nil {
+		t.Error("unknown slippage model should fail")
+	}
+	if _, err := (BacktestConfig{SlippageModel: SlippageVolume, SlippageImpact: -1}).newSlippageModel(); err == nil {
+		t.Error("negative impact should fail")
+	}
+	if model, err := (BacktestConfig{Slippage: 0.001}).newSlippageModel(); err != nil || model != (FixedSlippage{Rate: 0.001}) {
+		t.Errorf("default model = %v, %v; want fixed", model, err)
+	}
+}
+
+func TestBacktestAppliesVolumeSlippage(t *testing.T) {
+	start := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        start.AddDate(0, 0, 7),
+		InitialCapital: 100000,
+		Slippage:       0.0005,
+		SlippageModel:  SlippageVolume,
+		SlippageImpact: 0.1,
+		Symbols:        []string{"sh600000"},
+	})
+	if err := engine.AddStrategy(&alternatingStrategy{BaseStrategy: strategies.NewBaseStrategy("alt", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if err := engine.SetDataSource(&countingDataSource{}); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results.Trades) == 0 {
+		t.Fatal("no trades")
+	}
+
+	// countingDataSource每根K线成交量为100万股，固定下单1000股
+	rate := 0.0005 + 0.1*1000.0/1000000
+	for _, trade := range results.Trades {
+		signalPrice := 10 + float64(trade.EntryTime.YearDay()%7)*0.1
+		want := signalPrice * float64(trade.Quantity) * rate
+		if math.Abs(trade.Slippage-want) > 1e-9 {
+			t.Errorf("%s trade slippage = %v, want %v", trade.Side, trade.Slippage, want)
+		}
+
+		// 滑点通过成交价体现：买入抬高、卖出压低，而不是每笔固定扣减
+		fillPrice := signalPrice + signalPrice*rate
+		pnl := (signalPrice*1.02 - fillPrice) * float64(trade.Quantity)
+		if trade.Side == "sell" {
+			fillPrice = signalPrice - signalPrice*rate
+			pnl = (fillPrice-signalPrice)*float64(trade.Quantity) - trade.Commission
+		}
+		if math.Abs(trade.EntryPrice-fillPrice) > 1e-9 {
+			t.Errorf("%s trade filled at %v, want %v", trade.Side, trade.EntryPrice, fillPrice)
+		}
+		if math.Abs(trade.PnL-pnl) > 1e-6 {
+			t.Errorf("%s trade pnl = %v, want %v", trade.Side, trade.PnL, pnl)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package backtest
+
+import (
+	"math"
+	"sort"
+)
+
+// mean 计算均值
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// sampleStdDev 样本标准差，样本少于2个时返回0
+func sampleStdDev(values []float64) float64 {
+	if len(values) < 2 {
+		return 0
+	}
+	m := mean(values)
+	var variance float64
+	for _, v := range values {
+		variance += (v - m) * (v - m)
+	}
+	return math.Sqrt(variance / float64(len(values)-1))
+}
+
+// downsideDeviation 低于目标收益部分的下行标准差，高于目标的收益按0计入
+func downsideDeviation(values []float64, target float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		if v < target {
+			sum += (v - target) * (v - target)
+		}
+	}
+	return math.Sqrt(sum / float64(len(values)))
+}
+
+// skewness 偏度，负值表示左尾更长；样本少于3个或无波动时返回0
+func skewness(values []float64) float64 {
+	n := float64(len(values))
+	if n < 3 {
+		return 0
+	}
+	m := mean(values)
+	var m2, m3 float64
+	for _, v := range values {
+		d := v - m
+		m2 += d * d
+		m3 += d * d * d
+	}
+	m2 /= n
+	m3 /= n
+	if m2 == 0 {
+		return 0
+	}
+	return m3 / math.Pow(m2, 1.5)
+}
+
+// excessKurtosis 超额峰度（正态分布为0），正值表示尾部更厚；样本少于4个或无波动时返回0
+func excessKurtosis(values []float64) float64 {
+	n := float64(len(values))
+	if n < 4 {
+		return 0
+	}
+	m := mean(values)
+	var m2, m4 float64
+	for _, v := range values {
+		d := v - m
+		m2 += d * d
+		m4 += d * d * d * d
+	}
+	m2 /= n
+	m4 /= n
+	if m2 == 0 {
+		return 0
+	}
+	return m4/(m2*m2) - 3
+}
+
+// historicalVaR 历史模拟法VaR和CVaR，以正数表示损失比例，没有损失时为0。
+// VaR取收益率分布的(1-confidence)分位数，CVaR为不高于该分位数的收益率均值。
+func historicalVaR(values []float64, confidence float64) (valueAtRisk, conditionalVaR float64) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+	sorted := append([]float64(nil), values...)
+	sort.Float64s(sorted)
+
+	// 加微小量避免(1-0.9)*10这类浮点误差向下取整到前一位
+	index := int(math.Floor((1-confidence)*float64(len(sorted)) + 1e-9))
+	if index >= len(sorted) {
+		index = len(sorted) - 1
+	}
+	tail := mean(sorted[:index+1])
+	return math.Max(-sorted[index], 0), math.Max(-tail, 0)
+}
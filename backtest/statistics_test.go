@@ -0,0 +1,99 @@
+package backtest
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+func TestReturnStatistics(t *testing.T) {
+	returns := []float64{0.02, -0.01, 0.03, -0.04, 0.01, 0.00, -0.02, 0.05, -0.03, 0.01}
+
+	if got := mean(returns); math.Abs(got-0.002) > 1e-12 {
+		t.Errorf("mean = %v, want 0.002", got)
+	}
+	if got, want := sampleStdDev([]float64{1, 2, 3, 4}), math.Sqrt(5.0/3); math.Abs(got-want) > 1e-12 {
+		t.Errorf("sampleStdDev = %v, want %v", got, want)
+	}
+	// 低于0的收益：-0.01、-0.04、-0.02、-0.03，平方和0.003除以10
+	if got, want := downsideDeviation(returns, 0), math.Sqrt(0.0003); math.Abs(got-want) > 1e-12 {
+		t.Errorf("downsideDeviation = %v, want %v", got, want)
+	}
+
+	// 对称分布偏度为0，右偏分布偏度为正
+	if got := skewness([]float64{-2, -1, 0, 1, 2}); math.Abs(got) > 1e-12 {
+		t.Errorf("symmetric skewness = %v, want 0", got)
+	}
+	if got := skewness([]float64{0, 0, 0, 0, 10}); got <= 0 {
+		t.Errorf("right-skewed skewness = %v, want positive", got)
+	}
+	// 两点等概率分布的超额峰度为-2，单个极端值使尾部变厚
+	if got := excessKurtosis([]float64{-1, 1, -1, 1}); math.Abs(got+2) > 1e-12 {
+		t.Errorf("two-point kurtosis = %v, want -2", got)
+	}
+	if got := excessKurtosis([]float64{0, 0, 0, 0, 0, 0, 0, 0, 0, 10}); got <= 0 {
+		t.Errorf("fat-tailed kurtosis = %v, want positive", got)
+	}
+
+	// 10个样本的95%分位取最小值-0.04，90%取第二小值-0.03，CVaR为其以下的均值
+	if v, cv := historicalVaR(returns, 0.95); math.Abs(v-0.04) > 1e-12 || math.Abs(cv-0.04) > 1e-12 {
+		t.Errorf("VaR95 = %v, CVaR95 = %v, want 0.04, 0.04", v, cv)
+	}
+	if v, cv := historicalVaR(returns, 0.90); math.Abs(v-0.03) > 1e-12 || math.Abs(cv-0.035) > 1e-12 {
+		t.Errorf("VaR90 = %v, CVaR90 = %v, want 0.03, 0.035", v, cv)
+	}
+	if v, cv := historicalVaR([]float64{0.01, 0.02}, 0.95); v != 0 || cv != 0 {
+		t.Errorf("VaR without losses = %v, %v, want 0", v, cv)
+	}
+}
+
+func TestBacktestPopulatesRiskMetrics(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	end := time.Date(2024, 3, 29, 0, 0, 0, 0, time.UTC)
+
+	bars := DefaultTradingCalendar().Bars(start, end, BarDaily)
+
+	// 交替买卖的盈亏相互抵消，总收益为0，索提诺比率由无风险利率决定符号
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        end,
+		InitialCapital: 100000,
+		Commission:     0.02,
+		RiskFreeRate:   0.03,
+		Symbols:        []string{"sh600000"},
+	})
+	if err := engine.AddStrategy(&alternatingStrategy{BaseStrategy: strategies.NewBaseStrategy("alternating", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	if err := engine.SetDataSource(&regimeDataSource{switchAt: bars[30]}); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	metrics := results.RiskMetrics
+	if metrics == nil {
+		t.Fatal("risk metrics not populated")
+	}
+	if metrics.Volatility <= 0 || metrics.DownsideDeviation <= 0 || metrics.VaR95 <= 0 {
+		t.Errorf("risk metrics %+v, want positive volatility, downside deviation and VaR", metrics)
+	}
+	if metrics.VaR99 < metrics.VaR95 || metrics.CVaR95 < metrics.VaR95 || metrics.CVaR99 < metrics.VaR99 {
+		t.Errorf("tail metrics out of order: %+v", metrics)
+	}
+	if results.Summary.SortinoRatio >= 0 || results.Summary.ValueAtRisk != metrics.VaR95 {
+		t.Errorf("summary sortino=%v var=%v, want negative sortino and VaR matching %v",
+			results.Summary.SortinoRatio, results.Summary.ValueAtRisk, metrics.VaR95)
+	}
+
+	search := NewParameterSearch(SearchConfig{Metric: "sortino_ratio"}, engine)
+	if metric, err := search.calculateOptimizationMetric(results); err != nil || metric != results.Summary.SortinoRatio {
+		t.Errorf("sortino optimization metric = %v, %v; want %v", metric, err, results.Summary.SortinoRatio)
+	}
+}
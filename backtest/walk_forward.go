@@ -0,0 +1,197 @@
+package backtest
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+)
+
+const (
+	defaultWalkForwardWindows = 4    // 默认滚动窗口数
+	defaultValidationSplit    = 0.25 // 默认样本外占单个窗口的比例
+)
+
+// WalkForwardConfig 滚动前推分析配置
+type WalkForwardConfig struct {
+	Search          SearchConfig `yaml:"search"`             // 样本内参数搜索配置，ValidationSplit为样本外占单个窗口的比例
+	Windows         int          `yaml:"windows"`            // 窗口数量，0表示默认4个；指定样本长度时为窗口数上限
+	InSampleBars    int          `yaml:"in_sample_bars"`     // 样本内K线数，0表示按Windows和ValidationSplit划分
+	OutOfSampleBars int          `yaml:"out_of_sample_bars"` // 样本外K线数，同时为窗口滚动步长
+}
+
+// WalkForwardWindow 单个窗口的结果
+type WalkForwardWindow struct {
+	Index             int                    `json:"index"`
+	InSampleStart     time.Time              `json:"in_sample_start"`
+	InSampleEnd       time.Time              `json:"in_sample_end"`
+	OutOfSampleStart  time.Time              `json:"out_of_sample_start"`
+	OutOfSampleEnd    time.Time              `json:"out_of_sample_end"`
+	Parameters        map[string]interface{} `json:"parameters"`           // 样本内最优参数
+	InSampleMetric    float64                `json:"in_sample_metric"`     // 样本内最优指标
+	OutOfSampleMetric float64                `json:"out_of_sample_metric"` // 最优参数在样本外的指标
+	OutOfSample       *BacktestSummary       `json:"out_of_sample"`        // 样本外回测摘要
+}
+
+// WalkForwardResult 滚动前推分析结果，汇总指标只基于样本外窗口
+type WalkForwardResult struct {
+	Metric                string              `json:"metric"`
+	Windows               []WalkForwardWindow `json:"windows"`
+	MeanInSampleMetric    float64             `json:"mean_in_sample_metric"`
+	MeanOutOfSampleMetric float64             `json:"mean_out_of_sample_metric"`
+	Efficiency            float64             `json:"efficiency"`   // 样本外与样本内平均指标之比，远小于1说明参数过拟合
+	TotalReturn           float64             `json:"total_return"` // 各样本外窗口收益复利累计
+	MaxDrawdown           float64             `json:"max_drawdown"` // 样本外窗口中的最大回撤
+	TotalTrades           int                 `json:"total_trades"` // 样本外交易总数
+	Duration              time.Duration       `json:"duration"`
+}
+
+// WalkForward 滚动前推分析：将回测区间划分为连续的样本内/样本外窗口，
+// 在样本内搜索最优参数后在紧随其后的样本外区间检验，避免参数只适配单一时期
+type WalkForward struct {
+	config WalkForwardConfig
+	engine *BacktestEngine
+}
+
+// NewWalkForward 创建滚动前推分析，engine提供回测区间、策略和数据源
+func NewWalkForward(config WalkForwardConfig, engine *BacktestEngine) *WalkForward {
+	return &WalkForward{config: config, engine: engine}
+}
+
+// walkForwardSpan 单个窗口在K线序列中的下标范围，均为闭区间
+type walkForwardSpan struct {
+	inStart, inEnd, outStart, outEnd int
+}
+
+// Run 依次执行各窗口的样本内优化和样本外检验
+func (w *WalkForward) Run(ctx context.Context) (*WalkForwardResult, error) {
+	if w.engine == nil {
+		return nil, fmt.Errorf("backtest engine is nil")
+	}
+
+	w.engine.mu.RLock()
+	config := *w.engine.config
+	calendar := w.engine.calendar
+	w.engine.mu.RUnlock()
+
+	interval := config.BarInterval
+	if interval == "" {
+		interval = BarDaily
+	}
+	bars := calendar.Bars(config.StartDate, config.EndDate, interval)
+
+	spans, err := w.windows(len(bars))
+	if err != nil {
+		return nil, err
+	}
+
+	startTime := time.Now()
+	result := &WalkForwardResult{
+		Metric:      w.config.Search.Metric,
+		Windows:     make([]WalkForwardWindow, 0, len(spans)),
+		TotalReturn: 1,
+	}
+
+	log.Printf("Starting walk-forward analysis: %d windows over %d bars", len(spans), len(bars))
+
+	for i, span := range spans {
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("walk-forward cancelled: %v", ctx.Err())
+		default:
+		}
+
+		window := WalkForwardWindow{
+			Index:            i + 1,
+			InSampleStart:    bars[span.inStart],
+			InSampleEnd:      bars[span.inEnd],
+			OutOfSampleStart: bars[span.outStart],
+			OutOfSampleEnd:   bars[span.outEnd],
+		}
+
+		// 样本内搜索最优参数
+		search := NewParameterSearch(w.config.Search, w.engine.withPeriod(window.InSampleStart, window.InSampleEnd))
+		best, err := search.Optimize(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("window %d in-sample: %v", window.Index, err)
+		}
+		if best == nil {
+			return nil, fmt.Errorf("window %d in-sample: no successful parameter combination", window.Index)
+		}
+		window.Parameters = best.Parameters
+		window.InSampleMetric = best.Metric
+
+		// 最优参数在样本外区间检验
+		validation := NewParameterSearch(w.config.Search, w.engine.withPeriod(window.OutOfSampleStart, window.OutOfSampleEnd))
+		oos, err := validation.runBacktestWithParams(best.Parameters)
+		if err != nil {
+			return nil, fmt.Errorf("window %d out-of-sample: %v", window.Index, err)
+		}
+		window.OutOfSampleMetric, err = validation.calculateOptimizationMetric(oos)
+		if err != nil {
+			return nil, fmt.Errorf("window %d out-of-sample: %v", window.Index, err)
+		}
+		window.OutOfSample = oos.Summary
+
+		result.Windows = append(result.Windows, window)
+		result.MeanInSampleMetric += window.InSampleMetric
+		result.MeanOutOfSampleMetric += window.OutOfSampleMetric
+		result.TotalReturn *= 1 + oos.Summary.TotalReturn
+		result.TotalTrades += oos.Summary.TotalTrades
+		if oos.Summary.MaxDrawdown > result.MaxDrawdown {
+			result.MaxDrawdown = oos.Summary.MaxDrawdown
+		}
+
+		log.Printf("Walk-forward window %d/%d: in-sample=%.4f, out-of-sample=%.4f", window.Index, len(spans), window.InSampleMetric, window.OutOfSampleMetric)
+	}
+
+	result.MeanInSampleMetric /= float64(len(spans))
+	result.MeanOutOfSampleMetric /= float64(len(spans))
+	if result.MeanInSampleMetric != 0 {
+		result.Efficiency = result.MeanOutOfSampleMetric / result.MeanInSampleMetric
+	}
+	result.TotalReturn--
+	result.Duration = time.Since(startTime)
+
+	return result, nil
+}
+
+// windows 将barCount根K线划分为滚动窗口，相邻窗口的样本外区间首尾相接
+func (w *WalkForward) windows(barCount int) ([]walkForwardSpan, error) {
+	inSample, outOfSample := w.config.InSampleBars, w.config.OutOfSampleBars
+	windows := w.config.Windows
+
+	if inSample <= 0 || outOfSample <= 0 {
+		if windows <= 0 {
+			windows = defaultWalkForwardWindows
+		}
+		split := w.config.Search.ValidationSplit
+		if split <= 0 || split >= 1 {
+			split = defaultValidationSplit
+		}
+		// barCount = inSample + windows*outOfSample，且outOfSample/(inSample+outOfSample) = split
+		outOfSample = int(float64(barCount) / ((1-split)/split + float64(windows)))
+		inSample = barCount - windows*outOfSample
+	} else {
+		fit := (barCount - inSample) / outOfSample
+		if windows <= 0 || windows > fit {
+			windows = fit
+		}
+	}
+
+	if inSample < 2 || outOfSample < 2 || windows < 1 {
+		return nil, fmt.Errorf("%d bars are not enough for walk-forward windows (in-sample %d, out-of-sample %d)", barCount, inSample, outOfSample)
+	}
+
+	spans := make([]walkForwardSpan, windows)
+	for i := range spans {
+		outStart := inSample + i*outOfSample
+		spans[i] = walkForwardSpan{
+			inStart:  outStart - inSample,
+			inEnd:    outStart - 1,
+			outStart: outStart,
+			outEnd:   outStart + outOfSample - 1,
+		}
+	}
+	return spans, nil
+}
@@ -0,0 +1,120 @@
+package backtest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cloudquant/trading/strategies"
+)
+
+// thresholdStrategy 收盘价高于threshold参数时买入，否则卖出
+type thresholdStrategy struct {
+	*strategies.BaseStrategy
+}
+
+func (s *thresholdStrategy) GenerateSignal(ctx context.Context, data *strategies.MarketData) (*strategies.Signal, error) {
+	threshold, _ := s.GetParameters()["threshold"].(float64)
+	signalType := "sell"
+	if data.Close > threshold {
+		signalType = "buy"
+	}
+	return &strategies.Signal{
+		Symbol:     data.Symbol,
+		SignalType: signalType,
+		Price:      data.Close,
+		Timestamp:  data.Timestamp,
+		Metadata:   make(map[string]interface{}),
+	}, nil
+}
+
+func TestWalkForwardRollsOutOfSampleWindows(t *testing.T) {
+	start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	engine := NewBacktestEngine(BacktestConfig{
+		StartDate:      start,
+		EndDate:        start.AddDate(0, 6, 0),
+		InitialCapital: 100000,
+		Commission:     0.001,
+		Symbols:        []string{"sh600000"},
+	})
+	if err := engine.AddStrategy(&thresholdStrategy{strategies.NewBaseStrategy("threshold", 1)}); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	source := &countingDataSource{}
+	if err := engine.SetDataSource(source); err != nil {
+		t.Fatalf("SetDataSource: %v", err)
+	}
+
+	candidates := []interface{}{10.0, 10.3, 10.5}
+	walk := NewWalkForward(WalkForwardConfig{
+		Search: SearchConfig{
+			Method:        "grid_search",
+			Metric:        "total_return",
+			MaxIterations: 10,
+			Parameters: map[string]ParameterConfig{
+				"threshold.threshold": {Type: "float", Values: candidates},
+			},
+		},
+		Windows:         3,
+		InSampleBars:    40,
+		OutOfSampleBars: 20,
+	}, engine)
+
+	result, err := walk.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(result.Windows) != 3 {
+		t.Fatalf("got %d windows, want 3", len(result.Windows))
+	}
+
+	bars := DefaultTradingCalendar().Bars(start, start.AddDate(0, 6, 0), BarDaily)
+	var sumIn, sumOut float64
+	for i, window := range result.Windows {
+		outStart := 40 + i*20
+		if !window.InSampleStart.Equal(bars[outStart-40]) || !window.InSampleEnd.Equal(bars[outStart-1]) ||
+			!window.OutOfSampleStart.Equal(bars[outStart]) || !window.OutOfSampleEnd.Equal(bars[outStart+19]) {
+			t.Errorf("window %d spans %s..%s / %s..%s", window.Index,
+				window.InSampleStart.Format("2006-01-02"), window.InSampleEnd.Format("2006-01-02"),
+				window.OutOfSampleStart.Format("2006-01-02"), window.OutOfSampleEnd.Format("2006-01-02"))
+		}
+
+		threshold := window.Parameters["threshold.threshold"]
+		found := false
+		for _, candidate := range candidates {
+			found = found || candidate == threshold
+		}
+		if !found {
+			t.Errorf("window %d chose %v, not one of the candidates", window.Index, threshold)
+		}
+		if window.OutOfSample == nil || window.OutOfSampleMetric != window.OutOfSample.TotalReturn {
+			t.Errorf("window %d out-of-sample metric %v does not match summary %+v", window.Index, window.OutOfSampleMetric, window.OutOfSample)
+		}
+		sumIn += window.InSampleMetric
+		sumOut += window.OutOfSampleMetric
+	}
+	if result.MeanInSampleMetric != sumIn/3 || result.MeanOutOfSampleMetric != sumOut/3 {
+		t.Errorf("aggregate means = %v/%v, want %v/%v", result.MeanInSampleMetric, result.MeanOutOfSampleMetric, sumIn/3, sumOut/3)
+	}
+
+	// 数据源应被各窗口的回测沿用，而不是退回模拟数据
+	if len(source.dates) == 0 {
+		t.Error("walk-forward backtests did not use the engine's data source")
+	}
+}
+
+func TestWalkForwardDerivesWindowsFromValidationSplit(t *testing.T) {
+	walk := NewWalkForward(WalkForwardConfig{Search: SearchConfig{ValidationSplit: 0.2}, Windows: 4}, nil)
+	spans, err := walk.windows(120)
+	if err != nil {
+		t.Fatalf("windows: %v", err)
+	}
+	// 样本外15根、样本内60根：60 + 4*15 = 120
+	if len(spans) != 4 || spans[0].inEnd-spans[0].inStart+1 != 60 || spans[3].outEnd != 119 {
+		t.Errorf("unexpected spans: %+v", spans)
+	}
+
+	if _, err := walk.windows(5); err == nil {
+		t.Error("too few bars should fail")
+	}
+}
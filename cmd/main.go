@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+	"fmt"
 	"log"
 	"os"
 	"os/signal"
@@ -8,6 +10,7 @@ import (
 	"syscall"
 	"time"
 
+	qconfig "cloudquant/config"
 	"cloudquant/db"
 	qhttp "cloudquant/http"
 	"cloudquant/llm"
@@ -27,11 +30,13 @@ type Config struct {
 		Level string `yaml:"level"`
 	} `yaml:"log"`
 	LLM struct {
-		Provider  string        `yaml:"provider"`
-		APIKey    string        `yaml:"api_key"`
-		Model     string        `yaml:"model"`
-		Timeout   time.Duration `yaml:"timeout"`
-		MaxTokens int           `yaml:"max_tokens"`
+		Provider       string        `yaml:"provider"`
+		APIKey         string        `yaml:"api_key"`
+		Model          string        `yaml:"model"`
+		Timeout        time.Duration `yaml:"timeout"`
+		MaxTokens      int           `yaml:"max_tokens"`
+		MaxRetries     int           `yaml:"max_retries"`
+		RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
 	} `yaml:"llm"`
 	ML struct {
 		ModelType     string `yaml:"model_type"`
@@ -61,6 +66,9 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to load config: %v", err)
 	}
+	if err := config.Validate(); err != nil {
+		log.Fatalf("Invalid %s:\n%v", configPath, err)
+	}
 
 	// 2. Initialize database
 	// Adjust DB path if needed
@@ -109,14 +117,44 @@ func loadConfig(path string) (*Config, error) {
 	if err := yaml.NewDecoder(file).Decode(&config); err != nil {
 		return nil, err
 	}
+	if err := qconfig.ExpandEnv(&config); err != nil {
+		return nil, fmt.Errorf("%s:\n%w", path, err)
+	}
 	return &config, nil
 }
 
+// Validate 检查本服务用到的配置项，返回的错误逐行列出全部问题
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(cond bool, field, format string, args ...interface{}) {
+		if !cond {
+			errs = append(errs, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+		}
+	}
+
+	check(len(c.Symbols) > 0, "symbols", "at least one symbol is required")
+	check(c.Database.Path != "", "database.path", "must not be empty")
+	check(c.Http.Port > 0 && c.Http.Port <= 65535, "http.port", "must be in 1-65535, got %d", c.Http.Port)
+	check(c.LLM.Timeout >= 0 && c.LLM.RetryBaseDelay >= 0, "llm", "timeout and retry_base_delay must not be negative")
+	check(c.LLM.MaxTokens >= 0 && c.LLM.MaxRetries >= 0, "llm", "max_tokens and max_retries must not be negative")
+	check(c.ML.MaxTreeDepth >= 0, "ml.max_tree_depth", "must not be negative")
+	if c.ML.TrainInterval != "" {
+		d, err := time.ParseDuration(c.ML.TrainInterval)
+		check(err == nil && d > 0, "ml.train_interval", "invalid duration %q", c.ML.TrainInterval)
+	}
+	check(c.ML.Features.LookbackDays >= 0 && c.ML.Features.LookaheadDays >= 0, "ml.features", "days must not be negative")
+	check(c.ML.Training.MinDataPoints >= 0, "ml.training.min_data_points", "must not be negative")
+	check(c.ML.Training.TestRatio >= 0 && c.ML.Training.TestRatio < 1, "ml.training.test_ratio", "must be in [0, 1), got %.2f", c.ML.Training.TestRatio)
+
+	return errors.Join(errs...)
+}
+
 func initializeServices(config *Config) {
 	if config == nil {
 		return
 	}
-	analyzer := llm.NewDeepSeekAnalyzer(config.LLM.APIKey, config.LLM.Model, config.LLM.Timeout, config.LLM.MaxTokens)
+	analyzer := llm.NewDeepSeekAnalyzer(config.LLM.APIKey, config.LLM.Model, config.LLM.Timeout, config.LLM.MaxTokens,
+		llm.WithRetry(config.LLM.MaxRetries, config.LLM.RetryBaseDelay))
 	qhttp.SetAnalyzer(analyzer)
 
 	if config.ML.ModelType != "" && config.ML.ModelPath != "" {
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfigValidate(t *testing.T) {
+	config, err := loadConfig(filepath.Join("..", "config.yaml"))
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if err := config.Validate(); err != nil {
+		t.Fatalf("shipped config is invalid:\n%v", err)
+	}
+
+	config.Http.Port = 70000
+	config.ML.Training.TestRatio = 1
+	config.ML.TrainInterval = "weekly"
+	err = config.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted invalid config")
+	}
+	for _, field := range []string{"http.port", "ml.training.test_ratio", "ml.train_interval"} {
+		if !strings.Contains(err.Error(), field) {
+			t.Errorf("error does not mention %s:\n%v", field, err)
+		}
+	}
+}
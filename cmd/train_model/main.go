@@ -3,9 +3,11 @@ package main
 import (
 	"flag"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"text/tabwriter"
 
 	"cloudquant/market"
 	"cloudquant/ml"
@@ -15,8 +17,12 @@ func main() {
 	symbol := flag.String("symbol", "", "stock symbol")
 	days := flag.Int("days", 500, "number of days")
 	modelPath := flag.String("model_path", "./models/dt.model", "model output path")
+	modelType := flag.String("model", "decision_tree", "model type: decision_tree or random_forest")
 	maxDepth := flag.Int("max_depth", 10, "max tree depth")
+	numTrees := flag.Int("num_trees", 50, "number of trees (random_forest)")
+	featureSubsample := flag.Float64("feature_subsample", 0, "fraction of features per tree, 0 for sqrt (random_forest)")
 	testRatio := flag.Float64("test_ratio", 0.2, "test ratio")
+	cv := flag.Int("cv", 0, "walk-forward folds, 0 for a single time-ordered split")
 	flag.Parse()
 
 	if *symbol == "" {
@@ -28,15 +34,35 @@ func main() {
 		log.Fatalf("failed to build training data: %v", err)
 	}
 
+	newModel := func() (ml.MLModel, error) {
+		return ml.NewModel(*modelType, ml.ModelConfig{
+			MaxDepth:         *maxDepth,
+			NumTrees:         *numTrees,
+			FeatureSubsample: *featureSubsample,
+		})
+	}
+
+	if *cv > 0 {
+		result, err := ml.WalkForwardValidate(newModel, features, labels, *cv)
+		if err != nil {
+			log.Fatalf("walk-forward validation failed: %v", err)
+		}
+		printCrossValidation(os.Stdout, result)
+	}
+
 	trainX, trainY, testX, testY := splitDataset(features, labels, *testRatio)
 
-	model := ml.NewDecisionTree(*maxDepth)
+	model, err := newModel()
+	if err != nil {
+		log.Fatalf("failed to create model: %v", err)
+	}
 	if err := model.Train(trainX, trainY); err != nil {
 		log.Fatalf("failed to train model: %v", err)
 	}
 
-	accuracy, precision, recall := evaluateModel(model, testX, testY)
-	log.Printf("accuracy=%.2f precision=%.2f recall=%.2f", accuracy, precision, recall)
+	if len(testX) > 0 {
+		printReport(os.Stdout, ml.EvaluateClassifier(model, testX, testY, ml.ClassLabels))
+	}
 
 	if err := os.MkdirAll(filepath.Dir(*modelPath), 0o755); err != nil {
 		log.Fatalf("failed to create model dir: %v", err)
@@ -54,27 +80,7 @@ func buildTrainingData(symbol string, days int) ([][]float64, []int, error) {
 	if err != nil {
 		return nil, nil, err
 	}
-	features, err := ml.ExtractFeatures(klines)
-	if err != nil {
-		return nil, nil, err
-	}
-	labels, err := ml.GenerateLabels(klines, 3)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	featureVectors := make([][]float64, 0, len(features))
-	labelValues := make([]int, 0, len(features))
-	offset := len(klines) - len(features)
-	for i, feature := range features {
-		idx := i + offset
-		if idx >= len(labels) {
-			break
-		}
-		featureVectors = append(featureVectors, ml.FeatureVector(feature))
-		labelValues = append(labelValues, labels[idx])
-	}
-	return featureVectors, labelValues, nil
+	return ml.BuildDataset(klines, 3)
 }
 
 func splitDataset(features [][]float64, labels []int, testRatio float64) (trainX [][]float64, trainY []int, testX [][]float64, testY []int) {
@@ -95,41 +101,42 @@ func splitDataset(features [][]float64, labels []int, testRatio float64) (trainX
 	return trainX, trainY, testX, testY
 }
 
-func evaluateModel(model *ml.DecisionTree, testX [][]float64, testY []int) (accuracy, precision, recall float64) {
-	if len(testX) == 0 {
-		return 0, 0, 0
+// printCrossValidation 以表格输出各折的样本数与指标，以及准确率的均值和标准差
+func printCrossValidation(out io.Writer, result *ml.CrossValidationResult) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "fold\ttrain\ttest\taccuracy\tmacro f1\t")
+	for _, fold := range result.Folds {
+		fmt.Fprintf(w, "%d\t%d\t%d\t%.3f\t%.3f\t\n", fold.Fold, fold.TrainSize, fold.TestSize, fold.Report.Accuracy, fold.Report.MacroF1)
 	}
+	w.Flush()
 
-	var correct int
-	var truePositive int
-	var predictedPositive int
-	var actualPositive int
+	fmt.Fprintf(out, "walk-forward accuracy mean=%.3f stdev=%.3f over %d folds\n\n", result.MeanAccuracy, result.StdAccuracy, len(result.Folds))
+}
 
-	for i, feature := range testX {
-		label, _, err := model.Predict(feature)
-		if err != nil {
-			continue
-		}
-		if label == testY[i] {
-			correct++
-		}
-		if label == 2 {
-			predictedPositive++
-		}
-		if testY[i] == 2 {
-			actualPositive++
-			if label == 2 {
-				truePositive++
-			}
+// printReport 以表格输出混淆矩阵及各类别、宏平均指标
+func printReport(out io.Writer, report *ml.ClassificationReport) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	fmt.Fprint(w, "actual\\predicted\t")
+	for _, label := range report.Labels {
+		fmt.Fprintf(w, "%s\t", ml.LabelName(label))
+	}
+	fmt.Fprintln(w)
+	for i, label := range report.Labels {
+		fmt.Fprintf(w, "%s\t", ml.LabelName(label))
+		for _, count := range report.Confusion[i] {
+			fmt.Fprintf(w, "%d\t", count)
 		}
+		fmt.Fprintln(w)
 	}
+	fmt.Fprintln(w)
 
-	accuracy = float64(correct) / float64(len(testX))
-	if predictedPositive > 0 {
-		precision = float64(truePositive) / float64(predictedPositive)
-	}
-	if actualPositive > 0 {
-		recall = float64(truePositive) / float64(actualPositive)
+	fmt.Fprintln(w, "class\tprecision\trecall\tf1\tsupport\t")
+	for _, class := range report.Classes {
+		fmt.Fprintf(w, "%s\t%.3f\t%.3f\t%.3f\t%d\t\n", ml.LabelName(class.Label), class.Precision, class.Recall, class.F1, class.Support)
 	}
-	return accuracy, precision, recall
+	fmt.Fprintf(w, "macro avg\t%.3f\t%.3f\t%.3f\t%d\t\n", report.MacroPrecision, report.MacroRecall, report.MacroF1, report.Total)
+	w.Flush()
+
+	fmt.Fprintf(out, "accuracy=%.3f samples=%d failed=%d\n", report.Accuracy, report.Total, report.Failed)
 }
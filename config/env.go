@@ -0,0 +1,128 @@
+// Package config 提供配置文件的公共处理，如从环境变量读取密钥
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// EnvPrefix 整个取值来自环境变量的写法前缀，如 password: "env:BROKER_PASSWORD"
+const EnvPrefix = "env:"
+
+// envRef 匹配 ${NAME} 和带默认值的 ${NAME:-default}
+var envRef = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)(:-([^}]*))?\}`)
+
+// ErrEnvNotSet 配置引用的环境变量未设置
+var ErrEnvNotSet = fmt.Errorf("environment variable is not set")
+
+// ExpandEnv 展开cfg（结构体指针）中所有字符串字段的环境变量引用：
+//   - "env:NAME" 整个取值替换为环境变量NAME，适合密码等敏感字段
+//   - "${NAME}" 在字符串中替换为环境变量NAME
+//   - "${NAME:-default}" NAME未设置或为空时使用default，default可为空表示可选
+//
+// 引用的变量未设置且没有默认值时返回错误，错误逐行列出字段路径（按yaml标签）和变量名。
+// 不展开不带花括号的 $NAME，避免误改含 $ 的密码
+func ExpandEnv(cfg interface{}) error {
+	v := reflect.ValueOf(cfg)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return fmt.Errorf("config must be a non-nil pointer, got %T", cfg)
+	}
+
+	var errs []error
+	expandValue(v.Elem(), "", &errs)
+	return errors.Join(errs...)
+}
+
+// expandValue 递归展开v中的字符串，path为当前字段路径
+func expandValue(v reflect.Value, path string, errs *[]error) {
+	switch v.Kind() {
+	case reflect.Ptr, reflect.Interface:
+		if v.IsNil() {
+			return
+		}
+		if v.Kind() == reflect.Interface && v.Elem().Kind() == reflect.String {
+			if s, changed := resolve(v.Elem().String(), path, errs); changed && v.CanSet() {
+				v.Set(reflect.ValueOf(s))
+			}
+			return
+		}
+		expandValue(v.Elem(), path, errs)
+
+	case reflect.Struct:
+		t := v.Type()
+		for i := 0; i < v.NumField(); i++ {
+			field := t.Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			expandValue(v.Field(i), joinPath(path, fieldName(field)), errs)
+		}
+
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			expandValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), errs)
+		}
+
+	case reflect.Map:
+		for _, key := range v.MapKeys() {
+			// map元素不可寻址，展开副本后写回
+			elem := reflect.New(v.Type().Elem()).Elem()
+			elem.Set(v.MapIndex(key))
+			expandValue(elem, joinPath(path, fmt.Sprint(key.Interface())), errs)
+			v.SetMapIndex(key, elem)
+		}
+
+	case reflect.String:
+		if s, changed := resolve(v.String(), path, errs); changed && v.CanSet() {
+			v.SetString(s)
+		}
+	}
+}
+
+// resolve 展开单个字符串，未设置的变量记入errs
+func resolve(s, path string, errs *[]error) (string, bool) {
+	if name, ok := strings.CutPrefix(s, EnvPrefix); ok {
+		value, found := os.LookupEnv(name)
+		if !found {
+			*errs = append(*errs, fmt.Errorf("%s: %w: %s", path, ErrEnvNotSet, name))
+		}
+		return value, true
+	}
+	if !strings.Contains(s, "${") {
+		return s, false
+	}
+
+	expanded := envRef.ReplaceAllStringFunc(s, func(ref string) string {
+		m := envRef.FindStringSubmatch(ref)
+		name, hasDefault, def := m[1], m[2] != "", m[3]
+		value, found := os.LookupEnv(name)
+		switch {
+		case hasDefault && value == "":
+			return def
+		case !found:
+			*errs = append(*errs, fmt.Errorf("%s: %w: %s", path, ErrEnvNotSet, name))
+		}
+		return value
+	})
+	return expanded, true
+}
+
+// fieldName 字段在配置文件中的名称，取yaml标签，无标签时用字段名
+func fieldName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("yaml"), ",")
+	if name == "" || name == "-" {
+		return field.Name
+	}
+	return name
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type testConfig struct {
+	APIKey string `yaml:"api_key"`
+	Broker struct {
+		Password string `yaml:"password"`
+		URL      string `yaml:"service_url"`
+	} `yaml:"broker"`
+	Webhook    string                 `yaml:"webhook"`
+	Origins    []string               `yaml:"origins"`
+	Headers    map[string]string      `yaml:"headers"`
+	Parameters map[string]interface{} `yaml:"parameters"`
+	Port       int                    `yaml:"port"`
+}
+
+func TestExpandEnv(t *testing.T) {
+	t.Setenv("CQ_TEST_KEY", "secret")
+	t.Setenv("CQ_TEST_PASSWORD", "pa$$word")
+	t.Setenv("CQ_TEST_HOST", "broker.local")
+	t.Setenv("CQ_TEST_EMPTY", "")
+
+	var cfg testConfig
+	cfg.APIKey = "${CQ_TEST_KEY}"
+	cfg.Broker.Password = "env:CQ_TEST_PASSWORD"
+	cfg.Broker.URL = "http://${CQ_TEST_HOST}:${CQ_TEST_PORT:-8888}/api"
+	cfg.Webhook = "${CQ_TEST_EMPTY:-}"
+	cfg.Origins = []string{"https://${CQ_TEST_HOST}", "$HOME"}
+	cfg.Headers = map[string]string{"Authorization": "Bearer ${CQ_TEST_KEY}"}
+	cfg.Parameters = map[string]interface{}{"token": "env:CQ_TEST_KEY", "period": 14}
+
+	if err := ExpandEnv(&cfg); err != nil {
+		t.Fatalf("ExpandEnv: %v", err)
+	}
+
+	checks := map[string][2]string{
+		"api_key":     {cfg.APIKey, "secret"},
+		"password":    {cfg.Broker.Password, "pa$$word"},
+		"service_url": {cfg.Broker.URL, "http://broker.local:8888/api"},
+		"webhook":     {cfg.Webhook, ""},
+		"origins[0]":  {cfg.Origins[0], "https://broker.local"},
+		"origins[1]":  {cfg.Origins[1], "$HOME"},
+		"headers":     {cfg.Headers["Authorization"], "Bearer secret"},
+	}
+	for field, c := range checks {
+		if c[0] != c[1] {
+			t.Errorf("%s = %q, want %q", field, c[0], c[1])
+		}
+	}
+	if cfg.Parameters["token"] != "secret" || cfg.Parameters["period"] != 14 {
+		t.Errorf("parameters = %v", cfg.Parameters)
+	}
+}
+
+func TestExpandEnvReportsUnsetVariables(t *testing.T) {
+	var cfg testConfig
+	cfg.APIKey = "${CQ_TEST_MISSING_KEY}"
+	cfg.Broker.Password = "env:CQ_TEST_MISSING_PASSWORD"
+	cfg.Webhook = "${CQ_TEST_MISSING_HOOK:-}"
+
+	err := ExpandEnv(&cfg)
+	if !errors.Is(err, ErrEnvNotSet) {
+		t.Fatalf("err = %v, want ErrEnvNotSet", err)
+	}
+	for _, want := range []string{"api_key: environment variable is not set: CQ_TEST_MISSING_KEY",
+		"broker.password: environment variable is not set: CQ_TEST_MISSING_PASSWORD"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error does not contain %q:\n%v", want, err)
+		}
+	}
+	if strings.Contains(err.Error(), "CQ_TEST_MISSING_HOOK") {
+		t.Errorf("variable with default reported as missing:\n%v", err)
+	}
+
+	if err := ExpandEnv(cfg); err == nil {
+		t.Error("ExpandEnv accepted a non-pointer")
+	}
+}
@@ -0,0 +1,85 @@
+package db
+
+import (
+    "encoding/json"
+    "errors"
+
+    "cloudquant/monitoring"
+)
+
+// AlertStore 基于SQLite的告警存储，实现 monitoring.AlertStore
+type AlertStore struct{}
+
+// SaveAlert 保存告警
+func (AlertStore) SaveAlert(alert *monitoring.Alert) error {
+    return SaveAlert(alert)
+}
+
+// LoadActiveAlerts 读取未解决的告警
+func (AlertStore) LoadActiveAlerts() ([]*monitoring.Alert, error) {
+    return LoadActiveAlerts()
+}
+
+// SaveAlert saves an alert, replacing the stored copy with the same ID
+func SaveAlert(alert *monitoring.Alert) error {
+    if database == nil {
+        return errors.New("database not initialized")
+    }
+    if alert == nil || alert.ID == "" {
+        return errors.New("alert ID required")
+    }
+
+    alertJSON, err := json.Marshal(alert)
+    if err != nil {
+        return err
+    }
+
+    var resolvedAt interface{}
+    if alert.ResolvedAt != nil {
+        resolvedAt = alert.ResolvedAt.UTC()
+    }
+
+    // 已解决的告警保留在表中作为处理历史
+    _, err = database.Exec(`
+        INSERT OR REPLACE INTO alerts (
+            alert_id, level, source, symbol, resolved, timestamp, resolved_at, alert_json
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+        alert.ID,
+        string(alert.Level),
+        alert.Source,
+        alert.Symbol,
+        alert.Resolved,
+        alert.Timestamp.UTC(),
+        resolvedAt,
+        string(alertJSON),
+    )
+    return err
+}
+
+// LoadActiveAlerts loads all unresolved alerts, oldest first
+func LoadActiveAlerts() ([]*monitoring.Alert, error) {
+    if database == nil {
+        return nil, errors.New("database not initialized")
+    }
+
+    rows, err := database.Query(`SELECT alert_json FROM alerts WHERE resolved = 0 ORDER BY timestamp, id`)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var alerts []*monitoring.Alert
+    for rows.Next() {
+        var alertJSON string
+        if err := rows.Scan(&alertJSON); err != nil {
+            return nil, err
+        }
+        alert := &monitoring.Alert{}
+        if err := json.Unmarshal([]byte(alertJSON), alert); err != nil {
+            return nil, err
+        }
+        alerts = append(alerts, alert)
+    }
+
+    return alerts, rows.Err()
+}
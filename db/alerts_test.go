@@ -0,0 +1,63 @@
+package db
+
+import (
+    "path/filepath"
+    "testing"
+
+    "cloudquant/monitoring"
+)
+
+func TestAlertsSurviveRestart(t *testing.T) {
+    if err := InitDB(filepath.Join(t.TempDir(), "alerts.db")); err != nil {
+        t.Fatalf("InitDB: %v", err)
+    }
+    defer database.Close()
+
+    system, err := monitoring.NewAlertSystemWithStore(AlertStore{})
+    if err != nil {
+        t.Fatalf("NewAlertSystemWithStore: %v", err)
+    }
+    for _, alert := range []*monitoring.Alert{
+        {ID: "alert_open", Level: monitoring.Critical, Title: "drawdown", Symbol: "sh600000", Metadata: map[string]interface{}{"owner": "desk"}},
+        {ID: "alert_done", Level: monitoring.Warning, Title: "latency"},
+    } {
+        if _, err := system.SendAlert(alert); err != nil {
+            t.Fatalf("SendAlert %s: %v", alert.ID, err)
+        }
+    }
+    if err := system.ResolveAlert("alert_done"); err != nil {
+        t.Fatalf("ResolveAlert: %v", err)
+    }
+
+    // 模拟重启：新的告警系统只能从数据库恢复
+    restarted, err := monitoring.NewAlertSystemWithStore(AlertStore{})
+    if err != nil {
+        t.Fatalf("NewAlertSystemWithStore after restart: %v", err)
+    }
+    active := restarted.GetActiveAlerts()
+    if len(active) != 1 || active[0].ID != "alert_open" {
+        t.Fatalf("active alerts after restart = %+v, want only alert_open", active)
+    }
+    if active[0].Symbol != "sh600000" || active[0].Metadata["owner"] != "desk" || active[0].Delivery == nil {
+        t.Errorf("restored alert lost fields: %+v", active[0])
+    }
+
+    if err := restarted.ResolveAlert("alert_open"); err != nil {
+        t.Fatalf("ResolveAlert after restart: %v", err)
+    }
+    remaining, err := LoadActiveAlerts()
+    if err != nil {
+        t.Fatalf("LoadActiveAlerts: %v", err)
+    }
+    if len(remaining) != 0 {
+        t.Errorf("resolved alerts still active in store: %+v", remaining)
+    }
+
+    var stored int
+    if err := database.QueryRow(`SELECT COUNT(*) FROM alerts`).Scan(&stored); err != nil {
+        t.Fatalf("count alerts: %v", err)
+    }
+    if stored != 2 {
+        t.Errorf("stored %d alerts, want resolution history for 2", stored)
+    }
+}
@@ -0,0 +1,122 @@
+package db
+
+import (
+    "database/sql"
+    "encoding/json"
+    "errors"
+    "fmt"
+
+    "cloudquant/backtest"
+)
+
+// BacktestResultStore 基于SQLite的回测结果存储，实现 backtest.ResultStore
+type BacktestResultStore struct{}
+
+// SaveResults 保存一次回测结果
+func (BacktestResultStore) SaveResults(results *backtest.BacktestResults) error {
+    return SaveBacktestResults(results)
+}
+
+// LoadResults 按回测ID读取完整结果
+func (BacktestResultStore) LoadResults(id string) (*backtest.BacktestResults, error) {
+    return LoadBacktestResults(id)
+}
+
+// ListRuns 列出已保存的回测
+func (BacktestResultStore) ListRuns(configHash string) ([]backtest.RunSummary, error) {
+    return ListBacktestRuns(configHash)
+}
+
+// SaveBacktestResults saves backtest results, replacing any earlier run with the same ID
+func SaveBacktestResults(results *backtest.BacktestResults) error {
+    if database == nil {
+        return errors.New("database not initialized")
+    }
+    if results == nil || results.ID == "" {
+        return errors.New("backtest run ID required")
+    }
+
+    resultsJSON, err := json.Marshal(results)
+    if err != nil {
+        return err
+    }
+
+    // 主要指标单独成列，便于不解析JSON即可比较多次运行
+    summary := backtest.NewRunSummary(results)
+    _, err = database.Exec(`
+        INSERT OR REPLACE INTO backtest_runs (
+            run_id, config_hash, data_hash, total_return, sharpe_ratio, max_drawdown,
+            win_rate, total_trades, start_time, end_time, results_json
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+        summary.ID,
+        summary.ConfigHash,
+        summary.DataHash,
+        summary.TotalReturn,
+        summary.SharpeRatio,
+        summary.MaxDrawdown,
+        summary.WinRate,
+        summary.TotalTrades,
+        summary.StartTime.UTC(),
+        summary.EndTime.UTC(),
+        string(resultsJSON),
+    )
+    return err
+}
+
+// LoadBacktestResults loads the full results of a saved backtest run
+func LoadBacktestResults(id string) (*backtest.BacktestResults, error) {
+    if database == nil {
+        return nil, errors.New("database not initialized")
+    }
+
+    var resultsJSON string
+    err := database.QueryRow(`SELECT results_json FROM backtest_runs WHERE run_id = ?`, id).Scan(&resultsJSON)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, fmt.Errorf("%w: %s", backtest.ErrRunNotFound, id)
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    var results backtest.BacktestResults
+    if err := json.Unmarshal([]byte(resultsJSON), &results); err != nil {
+        return nil, err
+    }
+    return &results, nil
+}
+
+// ListBacktestRuns lists saved backtest runs newest first, optionally filtered by config hash
+func ListBacktestRuns(configHash string) ([]backtest.RunSummary, error) {
+    if database == nil {
+        return nil, errors.New("database not initialized")
+    }
+
+    query := `
+        SELECT run_id, config_hash, data_hash, total_return, sharpe_ratio, max_drawdown,
+            win_rate, total_trades, start_time, end_time
+        FROM backtest_runs`
+    var args []interface{}
+    if configHash != "" {
+        query += " WHERE config_hash = ?"
+        args = append(args, configHash)
+    }
+    query += " ORDER BY start_time DESC, id DESC"
+
+    rows, err := database.Query(query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    runs := make([]backtest.RunSummary, 0)
+    for rows.Next() {
+        var run backtest.RunSummary
+        if err := rows.Scan(&run.ID, &run.ConfigHash, &run.DataHash, &run.TotalReturn, &run.SharpeRatio,
+            &run.MaxDrawdown, &run.WinRate, &run.TotalTrades, &run.StartTime, &run.EndTime); err != nil {
+            return nil, err
+        }
+        runs = append(runs, run)
+    }
+
+    return runs, rows.Err()
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+    "errors"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "cloudquant/backtest"
+)
+
+func TestBacktestResultsSurviveRestart(t *testing.T) {
+    if err := InitDB(filepath.Join(t.TempDir(), "backtest.db")); err != nil {
+        t.Fatalf("InitDB: %v", err)
+    }
+    defer database.Close()
+
+    config := backtest.BacktestConfig{InitialCapital: 100000, Symbols: []string{"sh600000"}}
+    hash, err := config.Hash()
+    if err != nil {
+        t.Fatalf("Hash: %v", err)
+    }
+    config.Commission = 0.001
+    tunedHash, _ := config.Hash()
+    if tunedHash == hash {
+        t.Fatal("config hash should change when parameters change")
+    }
+
+    base := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+    store := BacktestResultStore{}
+    for i, configHash := range []string{hash, tunedHash, hash} {
+        err := store.SaveResults(&backtest.BacktestResults{
+            ID:          "bt_" + string(rune('a'+i)),
+            ConfigHash:  configHash,
+            Summary:     &backtest.BacktestSummary{TotalReturn: 0.01 * float64(i+1), SharpeRatio: 1.5, TotalTrades: i},
+            EquityCurve: []backtest.EquityPoint{{Timestamp: base, Value: 100000}},
+            StartTime:   base.Add(time.Duration(i) * time.Hour),
+            EndTime:     base.Add(time.Duration(i)*time.Hour + time.Minute),
+        })
+        if err != nil {
+            t.Fatalf("SaveResults: %v", err)
+        }
+    }
+
+    // 模拟重启：新实例只依赖数据库中的结果
+    restarted := BacktestResultStore{}
+    runs, err := restarted.ListRuns(hash)
+    if err != nil {
+        t.Fatalf("ListRuns: %v", err)
+    }
+    if len(runs) != 2 || runs[0].ID != "bt_c" || runs[1].ID != "bt_a" {
+        t.Fatalf("runs for config = %+v, want bt_c then bt_a", runs)
+    }
+    if runs[0].TotalReturn != 0.03 || runs[0].SharpeRatio != 1.5 || runs[0].TotalTrades != 2 {
+        t.Errorf("run metrics not restored: %+v", runs[0])
+    }
+
+    all, err := restarted.ListRuns("")
+    if err != nil || len(all) != 3 {
+        t.Fatalf("ListRuns all = %d runs, err %v; want 3", len(all), err)
+    }
+
+    results, err := restarted.LoadResults("bt_b")
+    if err != nil {
+        t.Fatalf("LoadResults: %v", err)
+    }
+    if results.ConfigHash != tunedHash || len(results.EquityCurve) != 1 || results.Summary.TotalReturn != 0.02 {
+        t.Errorf("results not restored: %+v", results)
+    }
+
+    if _, err := restarted.LoadResults("missing"); !errors.Is(err, backtest.ErrRunNotFound) {
+        t.Errorf("LoadResults missing = %v, want ErrRunNotFound", err)
+    }
+}
@@ -0,0 +1,137 @@
+package db
+
+import (
+    "encoding/json"
+    "errors"
+    "strings"
+
+    "cloudquant/trading/risk"
+)
+
+// RiskAnalysisStore 基于SQLite的AI风险分析历史存储，实现 risk.AnalysisStore
+type RiskAnalysisStore struct{}
+
+// SaveAnalysis 保存一次AI风险分析
+func (RiskAnalysisStore) SaveAnalysis(analysis risk.RiskAnalysis) error {
+    return SaveRiskAnalysis(analysis)
+}
+
+// QueryAnalyses 按条件查询AI风险分析历史
+func (RiskAnalysisStore) QueryAnalyses(query risk.AnalysisQuery) ([]risk.RiskAnalysis, error) {
+    return QueryRiskAnalyses(query)
+}
+
+// SaveRiskAnalysis saves an AI risk analysis with its score breakdown
+func SaveRiskAnalysis(analysis risk.RiskAnalysis) error {
+    if database == nil {
+        return errors.New("database not initialized")
+    }
+    if analysis.Symbol == "" {
+        return errors.New("symbol required")
+    }
+
+    var scoreJSON []byte
+    score := risk.RiskScore{}
+    if analysis.Score != nil {
+        score = *analysis.Score
+        var err error
+        scoreJSON, err = json.Marshal(analysis.Score)
+        if err != nil {
+            return err
+        }
+    }
+
+    // 统一使用UTC存储，保证按时间字符串比较的顺序正确
+    _, err := database.Exec(`
+        INSERT INTO ai_risk_analysis (
+            symbol, overall_score, market_risk, technical_risk, fundamental_risk,
+            volatility_risk, trend_risk, volume_risk, ai_confidence, risk_level,
+            score_json, raw_analysis, market_data, timestamp
+        ) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+        analysis.Symbol,
+        score.OverallScore,
+        score.MarketRisk,
+        score.TechnicalRisk,
+        score.FundamentalRisk,
+        score.VolatilityRisk,
+        score.TrendRisk,
+        score.VolumeRisk,
+        score.AIConfidence,
+        score.RiskLevel,
+        string(scoreJSON),
+        analysis.RawAnalysis,
+        string(analysis.MarketData),
+        analysis.Timestamp.UTC(),
+    )
+    return err
+}
+
+// QueryRiskAnalyses queries AI risk analyses by symbol and time range, newest first
+func QueryRiskAnalyses(query risk.AnalysisQuery) ([]risk.RiskAnalysis, error) {
+    if database == nil {
+        return nil, errors.New("database not initialized")
+    }
+
+    var conditions []string
+    var args []interface{}
+    if query.Symbol != "" {
+        conditions = append(conditions, "symbol = ?")
+        args = append(args, query.Symbol)
+    }
+    if !query.From.IsZero() {
+        conditions = append(conditions, "timestamp >= ?")
+        args = append(args, query.From.UTC())
+    }
+    if !query.To.IsZero() {
+        conditions = append(conditions, "timestamp <= ?")
+        args = append(args, query.To.UTC())
+    }
+
+    sqlQuery := `
+        SELECT symbol, score_json, raw_analysis, market_data, timestamp
+        FROM ai_risk_analysis`
+    if len(conditions) > 0 {
+        sqlQuery += " WHERE " + strings.Join(conditions, " AND ")
+    }
+    sqlQuery += " ORDER BY timestamp DESC, id DESC"
+
+    // SQLite 中 LIMIT -1 表示不限条数
+    limit := query.Limit
+    if limit <= 0 {
+        limit = -1
+    }
+    offset := query.Offset
+    if offset < 0 {
+        offset = 0
+    }
+    sqlQuery += " LIMIT ? OFFSET ?"
+    args = append(args, limit, offset)
+
+    rows, err := database.Query(sqlQuery, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    var analyses []risk.RiskAnalysis
+    for rows.Next() {
+        var a risk.RiskAnalysis
+        var scoreJSON, rawAnalysis, marketData string
+        if err := rows.Scan(&a.Symbol, &scoreJSON, &rawAnalysis, &marketData, &a.Timestamp); err != nil {
+            return nil, err
+        }
+        if scoreJSON != "" {
+            a.Score = &risk.RiskScore{}
+            if err := json.Unmarshal([]byte(scoreJSON), a.Score); err != nil {
+                return nil, err
+            }
+        }
+        a.RawAnalysis = rawAnalysis
+        if marketData != "" {
+            a.MarketData = json.RawMessage(marketData)
+        }
+        analyses = append(analyses, a)
+    }
+
+    return analyses, rows.Err()
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+    "encoding/json"
+    "path/filepath"
+    "testing"
+    "time"
+
+    "cloudquant/trading/risk"
+)
+
+func TestRiskAnalysisHistorySurvivesRestart(t *testing.T) {
+    if err := InitDB(filepath.Join(t.TempDir(), "risk.db")); err != nil {
+        t.Fatalf("InitDB: %v", err)
+    }
+    defer database.Close()
+
+    base := time.Date(2024, 3, 1, 10, 0, 0, 0, time.FixedZone("CST", 8*3600))
+    record := risk.NewAIRisk(risk.AIRiskConfig{PersistHistory: true}, nil, nil)
+    record.SetAnalysisStore(RiskAnalysisStore{})
+
+    for i := 0; i < 5; i++ {
+        for _, symbol := range []string{"sh600000", "sh600036"} {
+            ts := base.Add(time.Duration(i) * time.Hour)
+            err := record.RecordAnalysis(risk.RiskAnalysis{
+                Symbol: symbol,
+                Score: &risk.RiskScore{
+                    Symbol:          symbol,
+                    OverallScore:    0.1 * float64(i+1),
+                    MarketRisk:      0.3,
+                    VolatilityRisk:  0.6,
+                    RiskLevel:       "medium",
+                    Recommendations: []string{"减仓"},
+                    Timestamp:       ts,
+                },
+                RawAnalysis: "analysis " + symbol,
+                MarketData:  json.RawMessage(`{"price":10.5}`),
+                Timestamp:   ts,
+            })
+            if err != nil {
+                t.Fatalf("RecordAnalysis: %v", err)
+            }
+        }
+    }
+
+    // 模拟重启：新实例只依赖数据库中的历史
+    restarted := risk.NewAIRisk(risk.AIRiskConfig{PersistHistory: true}, nil, nil)
+    restarted.SetAnalysisStore(RiskAnalysisStore{})
+
+    history, err := restarted.GetAnalysisHistory(risk.AnalysisQuery{
+        Symbol: "sh600000",
+        From:   base.Add(time.Hour),
+        To:     base.Add(3 * time.Hour),
+    })
+    if err != nil {
+        t.Fatalf("GetAnalysisHistory: %v", err)
+    }
+    if len(history) != 3 {
+        t.Fatalf("got %d analyses, want 3", len(history))
+    }
+    for i, analysis := range history {
+        want := base.Add(time.Duration(3-i) * time.Hour)
+        if analysis.Symbol != "sh600000" || !analysis.Timestamp.Equal(want) {
+            t.Errorf("analysis %d = %s at %s, want sh600000 at %s", i, analysis.Symbol, analysis.Timestamp, want)
+        }
+    }
+
+    latest := history[0]
+    if latest.Score == nil || latest.Score.OverallScore != 0.4 || latest.Score.VolatilityRisk != 0.6 {
+        t.Errorf("score breakdown not restored: %+v", latest.Score)
+    }
+    if len(latest.Score.Recommendations) != 1 || latest.Score.Recommendations[0] != "减仓" {
+        t.Errorf("recommendations = %v", latest.Score.Recommendations)
+    }
+    if latest.RawAnalysis != "analysis sh600000" || string(latest.MarketData) != `{"price":10.5}` {
+        t.Errorf("raw analysis or market data not restored: %q %s", latest.RawAnalysis, latest.MarketData)
+    }
+
+    // 分页
+    page, err := restarted.GetAnalysisHistory(risk.AnalysisQuery{Symbol: "sh600000", Offset: 1, Limit: 2})
+    if err != nil {
+        t.Fatalf("GetAnalysisHistory page: %v", err)
+    }
+    if len(page) != 2 || !page[0].Timestamp.Equal(base.Add(3*time.Hour)) || !page[1].Timestamp.Equal(base.Add(2*time.Hour)) {
+        t.Errorf("unexpected page: %+v", page)
+    }
+}
@@ -12,6 +12,14 @@ import (
 
 var database *sql.DB
 
+// Close closes the SQLite database opened by InitDB
+func Close() error {
+    if database == nil {
+        return nil
+    }
+    return database.Close()
+}
+
 // InitDB initializes the SQLite database
 func InitDB(path string) error {
     var err error
@@ -126,6 +134,52 @@ func InitDB(path string) error {
         sell_count INTEGER DEFAULT 0,
         created_at DATETIME DEFAULT CURRENT_TIMESTAMP
     );
+    CREATE TABLE IF NOT EXISTS ai_risk_analysis (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        symbol VARCHAR(20) NOT NULL,
+        overall_score REAL,
+        market_risk REAL,
+        technical_risk REAL,
+        fundamental_risk REAL,
+        volatility_risk REAL,
+        trend_risk REAL,
+        volume_risk REAL,
+        ai_confidence REAL,
+        risk_level VARCHAR(20),
+        score_json TEXT,
+        raw_analysis TEXT,
+        market_data TEXT,
+        timestamp DATETIME NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS idx_ai_risk_analysis_symbol_time ON ai_risk_analysis(symbol, timestamp);
+    CREATE TABLE IF NOT EXISTS backtest_runs (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        run_id TEXT NOT NULL UNIQUE,
+        config_hash TEXT NOT NULL,
+        data_hash TEXT,
+        total_return REAL,
+        sharpe_ratio REAL,
+        max_drawdown REAL,
+        win_rate REAL,
+        total_trades INTEGER,
+        start_time DATETIME,
+        end_time DATETIME,
+        results_json TEXT NOT NULL,
+        created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+    );
+    CREATE INDEX IF NOT EXISTS idx_backtest_runs_config ON backtest_runs(config_hash, start_time);
+    CREATE TABLE IF NOT EXISTS alerts (
+        id INTEGER PRIMARY KEY AUTOINCREMENT,
+        alert_id TEXT NOT NULL UNIQUE,
+        level TEXT NOT NULL,
+        source TEXT,
+        symbol TEXT,
+        resolved BOOLEAN NOT NULL DEFAULT 0,
+        timestamp DATETIME NOT NULL,
+        resolved_at DATETIME,
+        alert_json TEXT NOT NULL
+    );
+    CREATE INDEX IF NOT EXISTS idx_alerts_resolved ON alerts(resolved, timestamp);
     `
 
     _, err = database.Exec(query)
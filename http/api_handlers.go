@@ -3,14 +3,20 @@ package http
 
 import (
 	"encoding/json"
+	"errors"
 	"log"
+	"math"
 	"net/http"
+	"sort"
 	"strconv"
 	"time"
 
+	"cloudquant/market"
 	"cloudquant/market/industry"
 	"cloudquant/monitoring"
+	"cloudquant/trading/portfolio"
 	"cloudquant/trading/risk"
+	"cloudquant/trading/risk/realtime"
 )
 
 // RegisterAPIHandlers 注册所有API处理器
@@ -32,15 +38,32 @@ func RegisterAPIHandlers(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/risk/factors", handleRiskFactors)
 	mux.HandleFunc("POST /api/risk/report", handleRiskReport)
 
+	// 实时风控API
+	mux.HandleFunc("GET /api/risk/limits", handleRiskLimits)
+	mux.HandleFunc("POST /api/risk/limits", requireAPIKey(handleUpdateRiskLimit))
+	mux.HandleFunc("GET /api/risk/events", handleRiskEvents)
+	mux.HandleFunc("GET /api/risk/exposure/{symbol}", handleRiskExposure)
+
+	// 交易冷却API
+	mux.HandleFunc("GET /api/risk/cooldown", handleCooldownStatus)
+	mux.HandleFunc("POST /api/risk/cooldown/clear", requireAPIKey(handleCooldownClear))
+
+	// 告警API
+	mux.HandleFunc("GET /api/alerts/active", handleActiveAlerts)
+	mux.HandleFunc("POST /api/alerts/{id}/ack", handleAcknowledgeAlert)
+
 	// 可视化API
 	mux.HandleFunc("GET /api/visualization/equity", handleVisualizationEquity)
 	mux.HandleFunc("GET /api/visualization/heatmap", handleVisualizationHeatmap)
+	mux.HandleFunc("GET /api/ws/dashboard", requireAPIKey(handleDashboardWebSocket))
 
 	// 回放API
 	mux.HandleFunc("POST /api/replay/start", handleReplayStart)
 	mux.HandleFunc("POST /api/replay/pause", handleReplayPause)
 	mux.HandleFunc("POST /api/replay/resume", handleReplayResume)
 	mux.HandleFunc("POST /api/replay/stop", handleReplayStop)
+	mux.HandleFunc("POST /api/replay/speed", handleReplaySpeed)
+	mux.HandleFunc("POST /api/replay/seek", handleReplaySeek)
 	mux.HandleFunc("GET /api/replay/{id}/status", handleReplayStatus)
 	mux.HandleFunc("GET /api/replay/list", handleReplayList)
 
@@ -48,12 +71,70 @@ func RegisterAPIHandlers(mux *http.ServeMux) {
 	mux.HandleFunc("GET /api/providers/status", handleProvidersStatus)
 	mux.HandleFunc("GET /api/providers/health", handleProvidersHealth)
 	mux.HandleFunc("GET /api/market/anomalies", handleMarketAnomalies)
-	mux.HandleFunc("POST /api/providers/switch", handleProviderSwitch)
+	mux.HandleFunc("POST /api/providers/switch", requireAPIKey(handleProviderSwitch))
 	mux.HandleFunc("GET /api/market/quality", handleMarketQuality)
+	mux.HandleFunc("GET /api/market/quote_cache", handleQuoteCacheStats)
+}
+
+var portfolioManager *portfolio.PortfolioManager
+
+// SetPortfolioManager 设置组合管理器，资金曲线优先使用其收益历史
+func SetPortfolioManager(pm *portfolio.PortfolioManager) {
+	portfolioManager = pm
 }
 
 // ============ 行业数据处理器 ============
 
+// portfolioWeights 按市值归一化的持仓权重，未配置持仓管理器时返回模拟持仓
+func portfolioWeights() map[string]float64 {
+	if positionManager == nil {
+		return map[string]float64{
+			"sh600000": 0.25,
+			"sh601398": 0.25,
+			"sh600519": 0.25,
+			"sh600036": 0.25,
+		}
+	}
+
+	positions := positionManager.GetAllPositions()
+	var total float64
+	for _, pos := range positions {
+		total += pos.MarketValue
+	}
+
+	weights := make(map[string]float64, len(positions))
+	if total <= 0 {
+		return weights
+	}
+	for _, pos := range positions {
+		weights[pos.Symbol] = pos.MarketValue / total
+	}
+	return weights
+}
+
+// heldIndustries 持仓涉及且有收益率数据的行业，无法确定时返回nil表示全部行业
+func heldIndustries(cache *industry.Cache, returns map[string][]float64) []string {
+	if positionManager == nil || cache == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	var industries []string
+	for symbol := range portfolioWeights() {
+		info, ok := cache.GetStockIndustry(symbol)
+		if !ok || seen[info.SWIndustry] {
+			continue
+		}
+		if _, ok := returns[info.SWIndustry]; !ok {
+			continue
+		}
+		seen[info.SWIndustry] = true
+		industries = append(industries, info.SWIndustry)
+	}
+	sort.Strings(industries)
+	return industries
+}
+
 func handleIndustryExposure(w http.ResponseWriter, r *http.Request) {
 	// 解析查询参数
 	benchmark := r.URL.Query().Get("benchmark")
@@ -68,16 +149,8 @@ func handleIndustryExposure(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// 构建模拟持仓（实际应从持仓管理器获取）
-	positions := map[string]float64{
-		"sh600000": 0.25,
-		"sh601398": 0.25,
-		"sh600519": 0.25,
-		"sh600036": 0.25,
-	}
-
 	analyzer := industry.NewAnalyzer(cache)
-	exposure := analyzer.CalculateExposure(positions, benchmark)
+	exposure := analyzer.CalculateExposure(portfolioWeights(), benchmark)
 
 	respondJSON(w, exposure)
 }
@@ -178,7 +251,7 @@ func handleIndustryCorrelation(w http.ResponseWriter, r *http.Request) {
 
 	cache, _ := industry.GetGlobalCache("./data/industries.json")
 	analyzer := industry.NewAnalyzer(cache)
-	correlation := analyzer.CalculateCorrelationMatrix(returns, nil)
+	correlation := analyzer.CalculateCorrelationMatrix(returns, heldIndustries(cache, returns))
 
 	respondJSON(w, correlation)
 }
@@ -228,26 +301,22 @@ func handleRiskCurve(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// 模拟资金曲线数据
-	curve := make([]map[string]interface{}, days)
-	equity := 100000.0
-	baseDate := time.Now().AddDate(0, 0, -days)
-
-	for i := 0; i < days; i++ {
-		change := (float64(i%10) - 5) / 1000
-		equity = equity * (1 + change)
-
+	points, source := equityCurve(days)
+	curve := make([]map[string]interface{}, len(points))
+	for i, point := range points {
 		curve[i] = map[string]interface{}{
-			"date":         baseDate.AddDate(0, 0, i).Format("2006-01-02"),
-			"equity":       equity,
-			"daily_return": change,
-			"drawdown":     float64(i%20) / 1000,
+			"date":         point.Time.Format("2006-01-02"),
+			"timestamp":    point.Time,
+			"equity":       point.Equity,
+			"daily_return": point.Return,
+			"drawdown":     point.Drawdown,
 		}
 	}
 
 	respondJSON(w, map[string]interface{}{
-		"curve": curve,
-		"days":  days,
+		"curve":  curve,
+		"days":   days,
+		"source": source,
 	})
 }
 
@@ -306,28 +375,100 @@ func handleRiskVaR(w http.ResponseWriter, r *http.Request) {
 
 	method := r.URL.Query().Get("method")
 	if method == "" {
-		method = "historical"
+		method = risk.VaRMethodHistorical
 	}
 
-	// 模拟VaR计算
-	var result struct {
-		Confidence float64   `json:"confidence"`
-		Method     string    `json:"method"`
-		VaR        float64   `json:"var"`
-		CVaR       float64   `json:"cvar"`
-		Timestamp  time.Time `json:"timestamp"`
+	days := 250
+	if d := r.URL.Query().Get("days"); d != "" {
+		if v, err := strconv.Atoi(d); err == nil && v > 0 {
+			days = v
+		}
 	}
-	result.Confidence = confidence
-	result.Method = method
-	result.VaR = 0.025
-	result.CVaR = 0.035
-	result.Timestamp = time.Now()
 
-	respondJSON(w, result)
+	returns, err := portfolioReturns(days)
+	if errors.Is(err, errNoReturnHistory) {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusServiceUnavailable)
+		return
+	}
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+	if len(returns) < 2 {
+		http.Error(w, `{"error":"insufficient portfolio return history"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	result, err := risk.EstimateVaR(returns, confidence, method)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"confidence":   result.Confidence,
+		"method":       result.Method,
+		"var":          result.VaR,
+		"cvar":         result.CVaR,
+		"observations": len(returns),
+		"timestamp":    result.Timestamp,
+	})
+}
+
+// errNoReturnHistory 未配置交易历史，无法取得组合收益率
+var errNoReturnHistory = errors.New("portfolio return history not available")
+
+// portfolioReturns 最近days个交易日的组合日收益率（按时间正序），未配置交易历史时返回errNoReturnHistory
+func portfolioReturns(days int) ([]float64, error) {
+	if tradeHistory == nil {
+		return nil, errNoReturnHistory
+	}
+
+	pnls, err := tradeHistory.GetDailyPnL(days)
+	if err != nil {
+		return nil, err
+	}
+	// GetDailyPnL按日期倒序返回
+	returns := make([]float64, len(pnls))
+	for i, pnl := range pnls {
+		returns[len(pnls)-1-i] = pnl.PnLPercent
+	}
+	return returns, nil
 }
 
+// factorLookbackDays 计算因子暴露使用的组合日收益率天数
+const factorLookbackDays = 20
+
 func handleRiskFactors(w http.ResponseWriter, r *http.Request) {
-	// 模拟因子暴露数据
+	if positionManager != nil {
+		// 收益类因子需要按日期排列的组合收益率，没有足够的日度盈亏记录时不返回估算值
+		returns, err := portfolioReturns(factorLookbackDays)
+		if errors.Is(err, errNoReturnHistory) {
+			http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusServiceUnavailable)
+			return
+		}
+		if err != nil {
+			http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+		if len(returns) < 2 {
+			http.Error(w, `{"error":"insufficient portfolio return history"}`, http.StatusServiceUnavailable)
+			return
+		}
+
+		weights := portfolioWeights()
+		exposure := make(map[string]interface{})
+		for factor, value := range risk.NewAttributionManager().CalculateFactorExposure(weights, returns, nil) {
+			exposure[factor] = value
+		}
+		exposure["positions"] = len(weights)
+		exposure["observations"] = len(returns)
+		exposure["timestamp"] = time.Now()
+		respondJSON(w, exposure)
+		return
+	}
+
+	// 未配置持仓管理器时返回模拟因子暴露数据
 	exposure := map[string]interface{}{
 		"market":     0.95,
 		"size":       0.82,
@@ -396,6 +537,22 @@ func handleRiskReport(w http.ResponseWriter, r *http.Request) {
 
 // ============ 可视化处理器 ============
 
+var realtimeMonitor *monitoring.RealtimeMonitor
+
+// SetRealtimeMonitor 设置实时监控器，仪表板WebSocket连接接入其WebSocket中心
+func SetRealtimeMonitor(monitor *monitoring.RealtimeMonitor) {
+	realtimeMonitor = monitor
+}
+
+func handleDashboardWebSocket(w http.ResponseWriter, r *http.Request) {
+	if realtimeMonitor == nil {
+		http.Error(w, `{"error":"realtime monitor not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	realtimeMonitor.GetWebSocketHub().HandleWebSocket(w, r)
+}
+
 func handleVisualizationEquity(w http.ResponseWriter, r *http.Request) {
 	days := 30
 	if d := r.URL.Query().Get("days"); d != "" {
@@ -404,8 +561,82 @@ func handleVisualizationEquity(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
-	// 模拟权益曲线
-	equityCurve := make([]map[string]interface{}, days)
+	points, source := equityCurve(days)
+	curve := make([]map[string]interface{}, len(points))
+	for i, point := range points {
+		curve[i] = map[string]interface{}{
+			"date":      point.Time.Format("2006-01-02"),
+			"timestamp": point.Time,
+			"equity":    point.Equity,
+			"return":    point.Return,
+		}
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"equity_curve": curve,
+		"days":         days,
+		"source":       source,
+	})
+}
+
+// equityCurvePoint 资金曲线上的一个点
+type equityCurvePoint struct {
+	Time     time.Time
+	Equity   float64
+	Return   float64
+	Drawdown float64
+}
+
+// equityCurve 最近days个点的资金曲线，依次取组合管理器的收益历史、最近一次回测的收益曲线，
+// 都没有时返回模拟曲线；source标明数据来源
+func equityCurve(days int) ([]equityCurvePoint, string) {
+	var points []equityCurvePoint
+	source := "mock"
+
+	if portfolioManager != nil {
+		history := portfolioManager.GetPerformance().ReturnHistory
+		var peak float64
+		for _, point := range history {
+			if point.Value > peak {
+				peak = point.Value
+			}
+			drawdown := 0.0
+			if peak > 0 {
+				drawdown = (peak - point.Value) / peak
+			}
+			points = append(points, equityCurvePoint{Time: point.Timestamp, Equity: point.Value, Return: point.Return, Drawdown: drawdown})
+		}
+		source = "portfolio"
+	}
+
+	if len(points) == 0 && backtestEngine != nil && !backtestEngine.IsRunning() {
+		if results := backtestEngine.GetResults(); results != nil {
+			for i, point := range results.EquityCurve {
+				ret := 0.0
+				if i > 0 && results.EquityCurve[i-1].Value > 0 {
+					ret = point.Value/results.EquityCurve[i-1].Value - 1
+				}
+				points = append(points, equityCurvePoint{Time: point.Timestamp, Equity: point.Value, Return: ret, Drawdown: point.Drawdown})
+			}
+			source = "backtest"
+		}
+	}
+
+	if len(points) == 0 {
+		return mockEquityCurve(days), "mock"
+	}
+	if days > 0 && len(points) > days {
+		points = points[len(points)-days:]
+	}
+	return points, source
+}
+
+// mockEquityCurve 无数据来源时的模拟资金曲线
+func mockEquityCurve(days int) []equityCurvePoint {
+	if days < 0 {
+		days = 0
+	}
+	points := make([]equityCurvePoint, days)
 	equity := 100000.0
 	baseDate := time.Now().AddDate(0, 0, -days)
 
@@ -413,17 +644,14 @@ func handleVisualizationEquity(w http.ResponseWriter, r *http.Request) {
 		change := (float64(i%10) - 5) / 1000
 		equity = equity * (1 + change)
 
-		equityCurve[i] = map[string]interface{}{
-			"date":   baseDate.AddDate(0, 0, i).Format("2006-01-02"),
-			"equity": equity,
-			"return": change,
+		points[i] = equityCurvePoint{
+			Time:     baseDate.AddDate(0, 0, i),
+			Equity:   equity,
+			Return:   change,
+			Drawdown: float64(i%20) / 1000,
 		}
 	}
-
-	respondJSON(w, map[string]interface{}{
-		"equity_curve": equityCurve,
-		"days":         days,
-	})
+	return points
 }
 
 func handleVisualizationHeatmap(w http.ResponseWriter, r *http.Request) {
@@ -555,6 +783,60 @@ func handleReplayStop(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, map[string]string{"status": "stopped"})
 }
 
+func handleReplaySpeed(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID    string  `json:"id"`
+		Speed float64 `json:"speed"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	if replayEngine == nil {
+		http.Error(w, `{"error":"replay engine not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := replayEngine.SetSpeed(req.ID, req.Speed); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{"status": "ok", "speed": req.Speed})
+}
+
+func handleReplaySeek(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		ID        string    `json:"id"`
+		Timestamp time.Time `json:"timestamp"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	if replayEngine == nil {
+		http.Error(w, `{"error":"replay engine not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := replayEngine.Seek(req.ID, req.Timestamp); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	session, err := replayEngine.GetSession(req.ID)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, session)
+}
+
 func handleReplayStatus(w http.ResponseWriter, r *http.Request) {
 	id := r.PathValue("id")
 	if id == "" {
@@ -586,39 +868,283 @@ func handleReplayList(w http.ResponseWriter, r *http.Request) {
 	respondJSON(w, sessions)
 }
 
+// ============ 实时风控处理器 ============
+
+var realtimeRiskMonitor *realtime.RealtimeRiskMonitor
+
+// SetRealtimeRiskMonitor 设置实时风控监控器
+func SetRealtimeRiskMonitor(monitor *realtime.RealtimeRiskMonitor) {
+	realtimeRiskMonitor = monitor
+}
+
+func handleRiskLimits(w http.ResponseWriter, r *http.Request) {
+	if realtimeRiskMonitor == nil {
+		http.Error(w, `{"error":"realtime risk monitor not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	respondJSON(w, realtimeRiskMonitor.GetRiskLimits())
+}
+
+func handleUpdateRiskLimit(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name              string  `json:"name"`
+		WarningThreshold  float64 `json:"warning_threshold"`
+		CriticalThreshold float64 `json:"critical_threshold"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	if req.Name == "" {
+		http.Error(w, `{"error":"name is required"}`, http.StatusBadRequest)
+		return
+	}
+	if req.WarningThreshold <= 0 || req.CriticalThreshold < req.WarningThreshold {
+		http.Error(w, `{"error":"thresholds must satisfy 0 < warning_threshold <= critical_threshold"}`, http.StatusBadRequest)
+		return
+	}
+
+	if realtimeRiskMonitor == nil {
+		http.Error(w, `{"error":"realtime risk monitor not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := realtimeRiskMonitor.UpdateRiskLimit(req.Name, req.WarningThreshold, req.CriticalThreshold); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, realtimeRiskMonitor.GetRiskLimits()[req.Name])
+}
+
+func handleRiskEvents(w http.ResponseWriter, r *http.Request) {
+	limit := 100
+	if l := r.URL.Query().Get("limit"); l != "" {
+		v, err := strconv.Atoi(l)
+		if err != nil || v <= 0 {
+			http.Error(w, `{"error":"limit must be a positive integer"}`, http.StatusBadRequest)
+			return
+		}
+		limit = v
+	}
+
+	if realtimeRiskMonitor == nil {
+		http.Error(w, `{"error":"realtime risk monitor not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	respondJSON(w, realtimeRiskMonitor.GetRiskEvents(limit))
+}
+
+func handleRiskExposure(w http.ResponseWriter, r *http.Request) {
+	symbol := r.PathValue("symbol")
+	if symbol == "" {
+		http.Error(w, `{"error":"symbol is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	if realtimeRiskMonitor == nil {
+		http.Error(w, `{"error":"realtime risk monitor not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	exposure, ok := realtimeRiskMonitor.GetExposure(symbol)
+	if !ok {
+		http.Error(w, `{"error":"no exposure recorded for `+symbol+`"}`, http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"symbol":   symbol,
+		"exposure": exposure,
+	})
+}
+
+// ============ 交易冷却处理器 ============
+
+var cooldownRisk *risk.CooldownRisk
+
+// SetCooldownRisk 设置交易冷却风险管理器
+func SetCooldownRisk(c *risk.CooldownRisk) {
+	cooldownRisk = c
+}
+
+// handleCooldownStatus 返回当前黑名单及各股票的冷却状态，指定symbol时附带是否允许交易
+func handleCooldownStatus(w http.ResponseWriter, r *http.Request) {
+	if cooldownRisk == nil {
+		http.Error(w, `{"error":"cooldown risk not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	response := map[string]interface{}{
+		"blacklist": cooldownRisk.GetBlacklist(),
+		"status":    cooldownRisk.GetAllCooldownStatus(),
+		"stats":     cooldownRisk.GetStats(),
+	}
+	if symbol := r.URL.Query().Get("symbol"); symbol != "" {
+		allowed, reason := cooldownRisk.IsAllowed(symbol)
+		response["symbol"] = symbol
+		response["allowed"] = allowed
+		response["reason"] = reason
+	}
+
+	respondJSON(w, response)
+}
+
+// handleCooldownClear 手动将股票移出黑名单
+func handleCooldownClear(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Symbol string `json:"symbol"`
+	}
+
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	if req.Symbol == "" {
+		http.Error(w, `{"error":"symbol is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	if cooldownRisk == nil {
+		http.Error(w, `{"error":"cooldown risk not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	if _, ok := cooldownRisk.GetBlacklist()[req.Symbol]; !ok {
+		http.Error(w, `{"error":"`+req.Symbol+` is not blacklisted"}`, http.StatusNotFound)
+		return
+	}
+	cooldownRisk.ClearBlacklist(req.Symbol)
+
+	allowed, reason := cooldownRisk.IsAllowed(req.Symbol)
+	respondJSON(w, map[string]interface{}{
+		"symbol":  req.Symbol,
+		"cleared": true,
+		"allowed": allowed,
+		"reason":  reason,
+	})
+}
+
+// ============ 告警处理器 ============
+
+var alertSystem *monitoring.AlertSystem
+
+// SetAlertSystem 设置告警系统
+func SetAlertSystem(system *monitoring.AlertSystem) {
+	alertSystem = system
+}
+
+// handleActiveAlerts 返回未解决的告警，已确认的告警通过acknowledged字段区分
+func handleActiveAlerts(w http.ResponseWriter, r *http.Request) {
+	if alertSystem == nil {
+		http.Error(w, `{"error":"alert system not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	alerts := alertSystem.GetActiveAlerts()
+	sort.Slice(alerts, func(i, j int) bool {
+		return alerts[i].Timestamp.After(alerts[j].Timestamp)
+	})
+	respondJSON(w, alerts)
+}
+
+// handleAcknowledgeAlert 确认告警正在处理
+func handleAcknowledgeAlert(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	var req struct {
+		User string `json:"user"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if req.User == "" {
+		http.Error(w, `{"error":"user is required"}`, http.StatusBadRequest)
+		return
+	}
+
+	if alertSystem == nil {
+		http.Error(w, `{"error":"alert system not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	if err := alertSystem.AcknowledgeAlert(id, req.User); err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, monitoring.ErrAlertNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, monitoring.ErrAlertResolved):
+			status = http.StatusConflict
+		}
+		http.Error(w, `{"error":"`+err.Error()+`"}`, status)
+		return
+	}
+
+	alert, _ := alertSystem.GetAlert(id)
+	respondJSON(w, alert)
+}
+
 // ============ 数据源处理器 ============
 
 func handleProvidersStatus(w http.ResponseWriter, r *http.Request) {
-	// 模拟数据源状态
-	providers := []map[string]interface{}{
-		{
-			"name":       "sina",
-			"healthy":    true,
-			"latency":    150,
-			"priority":   1,
-			"last_check": time.Now(),
-		},
-		{
-			"name":       "eastmoney",
-			"healthy":    true,
-			"latency":    200,
-			"priority":   2,
-			"last_check": time.Now(),
-		},
-		{
-			"name":       "tencent",
-			"healthy":    true,
-			"latency":    180,
-			"priority":   3,
-			"last_check": time.Now(),
-		},
+	respondJSON(w, providerStatusResponse(market.DefaultProviderRegistry().GetProviderStatuses()))
+}
+
+var marketProvider *market.MarketProvider
+
+// SetMarketProvider 设置带行情缓存的市场数据提供者
+func SetMarketProvider(provider *market.MarketProvider) {
+	marketProvider = provider
+}
+
+// handleQuoteCacheStats 返回行情缓存的命中统计，有效期以毫秒为单位
+func handleQuoteCacheStats(w http.ResponseWriter, r *http.Request) {
+	if marketProvider == nil {
+		http.Error(w, `{"error":"market provider not initialized"}`, http.StatusServiceUnavailable)
+		return
 	}
 
-	respondJSON(w, providers)
+	stats := marketProvider.QuoteCacheStats()
+	respondJSON(w, map[string]interface{}{
+		"ttl_ms":   stats.TTL.Milliseconds(),
+		"entries":  stats.Entries,
+		"hits":     stats.Hits,
+		"misses":   stats.Misses,
+		"hit_rate": stats.HitRate,
+	})
 }
 
+// handleProvidersHealth 立即对所有数据源执行健康检查
 func handleProvidersHealth(w http.ResponseWriter, r *http.Request) {
-	handleProvidersStatus(w, r)
+	respondJSON(w, providerStatusResponse(market.DefaultProviderRegistry().CheckHealth()))
+}
+
+// providerStatusResponse 数据源状态的JSON表示，延迟以毫秒为单位
+func providerStatusResponse(statuses []market.ProviderStatus) []map[string]interface{} {
+	result := make([]map[string]interface{}, 0, len(statuses))
+	for _, status := range statuses {
+		item := map[string]interface{}{
+			"name":     status.Name,
+			"healthy":  status.Healthy,
+			"active":   status.Active,
+			"latency":  status.Latency.Milliseconds(),
+			"priority": status.Priority,
+		}
+		if !status.LastCheck.IsZero() {
+			item["last_check"] = status.LastCheck
+		}
+		if status.LastError != "" {
+			item["last_error"] = status.LastError
+		}
+		result = append(result, item)
+	}
+	return result
 }
 
 func handleMarketAnomalies(w http.ResponseWriter, r *http.Request) {
@@ -646,21 +1172,84 @@ func handleProviderSwitch(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if err := market.SetActiveProvider(req.Provider); err != nil {
+		http.Error(w, `{"error":"unknown provider `+req.Provider+`"}`, http.StatusNotFound)
+		return
+	}
+
 	respondJSON(w, map[string]interface{}{
 		"status":   "success",
-		"provider": req.Provider,
+		"provider": market.ActiveProvider(),
 		"message":  "切换到 " + req.Provider,
 	})
 }
 
+// 行情质量评分参数：主数据源健康检查耗时不超过latencyGood记100分，达到latencyBad记0分，其间线性递减；
+// 准确性按最近qualityWindow内入库K线中没有数据质量问题的比例计算
+const (
+	latencyGood   = 200 * time.Millisecond
+	latencyBad    = 2 * time.Second
+	qualityWindow = 30 * 24 * time.Hour
+)
+
+// handleMarketQuality 由数据源健康检查和入库数据质量计算行情质量评分（0-100）：
+// latency_score取主数据源延迟，coverage_score取已检查数据源中健康的比例，accuracy_score取入库K线无质量问题的比例。
+// 缺少计算依据的评分不返回，overall_score为已有评分的平均值
 func handleMarketQuality(w http.ResponseWriter, r *http.Request) {
-	respondJSON(w, map[string]interface{}{
-		"overall_score":  95,
-		"latency_score":  90,
-		"accuracy_score": 98,
-		"coverage_score": 96,
-		"timestamp":      time.Now(),
-	})
+	now := time.Now()
+	result := map[string]interface{}{"timestamp": now}
+	var scores []float64
+	addScore := func(name string, score float64) {
+		score = math.Round(math.Max(0, math.Min(100, score))*10) / 10
+		result[name] = score
+		scores = append(scores, score)
+	}
+
+	var checked, healthy int
+	for _, status := range market.DefaultProviderRegistry().GetProviderStatuses() {
+		if status.LastCheck.IsZero() {
+			continue
+		}
+		checked++
+		if status.Healthy {
+			healthy++
+		}
+		if status.Active {
+			result["active_provider"] = status.Name
+			result["latency_ms"] = status.Latency.Milliseconds()
+			addScore("latency_score", 100*float64(latencyBad-status.Latency)/float64(latencyBad-latencyGood))
+		}
+	}
+	if checked > 0 {
+		result["healthy_providers"] = healthy
+		result["checked_providers"] = checked
+		addScore("coverage_score", 100*float64(healthy)/float64(checked))
+	}
+
+	if marketStorage != nil {
+		stats, err := marketStorage.GetQualityStats(r.Context(), now.Add(-qualityWindow), now)
+		if err != nil {
+			log.Printf("Query market data quality stats failed: %v", err)
+			http.Error(w, `{"error":"failed to query market data quality"}`, http.StatusInternalServerError)
+			return
+		}
+		result["bars"] = stats.Bars
+		result["flagged_bars"] = stats.FlaggedBars
+		if stats.Bars > 0 {
+			addScore("accuracy_score", 100*float64(stats.Bars-stats.FlaggedBars)/float64(stats.Bars))
+		}
+	}
+
+	if len(scores) == 0 {
+		http.Error(w, `{"error":"no provider health checks or stored market data to score"}`, http.StatusServiceUnavailable)
+		return
+	}
+	var total float64
+	for _, score := range scores {
+		total += score
+	}
+	result["overall_score"] = math.Round(total/float64(len(scores))*10) / 10
+	respondJSON(w, result)
 }
 
 // respondJSON 统一JSON响应
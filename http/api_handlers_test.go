@@ -0,0 +1,285 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudquant/backtest"
+	"cloudquant/market"
+	"cloudquant/market/providers"
+	"cloudquant/monitoring"
+	"cloudquant/pipeline"
+	"cloudquant/trading/strategies"
+)
+
+func TestEquityCurveFromBacktestResults(t *testing.T) {
+	savedEngine, savedPortfolio := backtestEngine, portfolioManager
+	defer func() { backtestEngine, portfolioManager = savedEngine, savedPortfolio }()
+	portfolioManager = nil
+
+	backtestEngine = nil
+	if points, source := equityCurve(5); source != "mock" || len(points) != 5 {
+		t.Errorf("without sources got %d %s points, want 5 mock points", len(points), source)
+	}
+
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	engine := backtest.NewBacktestEngine(backtest.BacktestConfig{
+		StartDate:      start,
+		EndDate:        start.AddDate(0, 0, 20),
+		InitialCapital: 100000,
+		Symbols:        []string{"sh600000"},
+	})
+	strategy := strategies.NewMLStrategy()
+	if err := strategy.Init(context.Background(), "sh600000", map[string]interface{}{"lookback_days": 10}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if err := engine.AddStrategy(strategy); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	if len(results.EquityCurve) < 10 {
+		t.Fatalf("backtest produced %d equity points, need at least 10", len(results.EquityCurve))
+	}
+	backtestEngine = engine
+
+	rr := httptest.NewRecorder()
+	handleRiskCurve(rr, httptest.NewRequest("GET", "/api/risk/curve?days=10", nil))
+	var body struct {
+		Curve []struct {
+			Equity float64 `json:"equity"`
+		} `json:"curve"`
+		Source string `json:"source"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.Source != "backtest" || len(body.Curve) != 10 {
+		t.Fatalf("got %d %s points, want the last 10 backtest points", len(body.Curve), body.Source)
+	}
+	last := results.EquityCurve[len(results.EquityCurve)-1].Value
+	if body.Curve[9].Equity != last {
+		t.Errorf("last point equity %.2f, want %.2f from the backtest", body.Curve[9].Equity, last)
+	}
+}
+
+func TestProviderSwitchChangesActiveProvider(t *testing.T) {
+	registry := providers.NewProviderManager()
+	registry.AddProvider(providers.NewMockProvider())
+	registry.AddProvider(providers.NewTencentProvider())
+	market.SetProviderRegistry(registry)
+	defer market.SetProviderRegistry(nil)
+
+	rr := httptest.NewRecorder()
+	handleProviderSwitch(rr, httptest.NewRequest("POST", "/api/providers/switch", strings.NewReader(`{"provider":"tencent"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("switch: got status %d: %s", rr.Code, rr.Body.String())
+	}
+	if active := market.ActiveProvider(); active != "tencent" {
+		t.Errorf("active provider %s after switch, want tencent", active)
+	}
+
+	rr = httptest.NewRecorder()
+	handleProvidersStatus(rr, httptest.NewRequest("GET", "/api/providers/status", nil))
+	var statuses []struct {
+		Name   string `json:"name"`
+		Active bool   `json:"active"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&statuses); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(statuses) != 2 {
+		t.Fatalf("got %d provider statuses, want 2", len(statuses))
+	}
+	for _, status := range statuses {
+		if status.Active != (status.Name == "tencent") {
+			t.Errorf("%s reported active=%v", status.Name, status.Active)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	handleProviderSwitch(rr, httptest.NewRequest("POST", "/api/providers/switch", strings.NewReader(`{"provider":"bloomberg"}`)))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown provider: got status %d, want %d", rr.Code, http.StatusNotFound)
+	}
+	if active := market.ActiveProvider(); active != "tencent" {
+		t.Errorf("active provider changed to %s by a failed switch", active)
+	}
+}
+
+// downProvider 健康检查始终失败的数据源
+type downProvider struct{ *providers.MockProvider }
+
+func (downProvider) Name() string       { return "down" }
+func (downProvider) Priority() int      { return 0 }
+func (downProvider) HealthCheck() error { return errors.New("connection refused") }
+
+func TestMarketQualityFromProvidersAndStorage(t *testing.T) {
+	savedStorage := marketStorage
+	defer SetMarketStorage(savedStorage)
+	SetMarketStorage(nil)
+
+	registry := providers.NewProviderManager()
+	registry.AddProvider(providers.NewMockProvider())
+	registry.AddProvider(downProvider{providers.NewMockProvider()})
+	market.SetProviderRegistry(registry)
+	defer market.SetProviderRegistry(nil)
+
+	// 尚未执行健康检查且没有入库数据时没有可计算的评分
+	rr := httptest.NewRecorder()
+	handleMarketQuality(rr, httptest.NewRequest("GET", "/api/market/quality", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("without data: got status %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	registry.CheckHealth()
+	storage, err := pipeline.NewOptimizedStorage(pipeline.StorageConfig{DBPath: filepath.Join(t.TempDir(), "market.db")})
+	if err != nil {
+		t.Fatalf("NewOptimizedStorage: %v", err)
+	}
+	defer storage.Close()
+	SetMarketStorage(storage)
+	ts := time.Now().Add(-time.Hour).Unix()
+	bars := make([]*pipeline.DataPoint, 0, 4)
+	for _, symbol := range []string{"sh600000", "sh600519", "sz000001"} {
+		bars = append(bars, &pipeline.DataPoint{Symbol: symbol, Timestamp: ts, Open: 10, High: 11, Low: 9, Close: 10, Volume: 100})
+	}
+	bars = append(bars, &pipeline.DataPoint{Symbol: "sz000002", Timestamp: ts, Open: 10, High: 9, Low: 11, Close: 10, Volume: 100})
+	if err := storage.SaveBatch(context.Background(), bars); err != nil {
+		t.Fatalf("SaveBatch: %v", err)
+	}
+
+	rr = httptest.NewRecorder()
+	handleMarketQuality(rr, httptest.NewRequest("GET", "/api/market/quality", nil))
+	var quality struct {
+		Overall  float64 `json:"overall_score"`
+		Latency  float64 `json:"latency_score"`
+		Coverage float64 `json:"coverage_score"`
+		Accuracy float64 `json:"accuracy_score"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&quality); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// 两个数据源一个健康；4根K线中1根有质量问题；模拟数据源健康检查无延迟
+	if quality.Coverage != 50 || quality.Accuracy != 75 || quality.Latency != 100 {
+		t.Errorf("scores = %+v, want coverage 50, accuracy 75, latency 100", quality)
+	}
+	if want := (50.0 + 75 + 100) / 3; math.Abs(quality.Overall-want) > 0.1 {
+		t.Errorf("overall = %.1f, want %.1f", quality.Overall, want)
+	}
+}
+
+func TestAcknowledgeAlertHandler(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterAPIHandlers(mux)
+
+	system := monitoring.NewAlertSystem()
+	SetAlertSystem(system)
+	defer SetAlertSystem(nil)
+
+	for _, id := range []string{"open", "closed"} {
+		if _, err := system.SendAlert(&monitoring.Alert{ID: id, Level: monitoring.Warning, Title: id}); err != nil {
+			t.Fatalf("SendAlert %s: %v", id, err)
+		}
+	}
+	if err := system.ResolveAlert("closed"); err != nil {
+		t.Fatalf("ResolveAlert: %v", err)
+	}
+
+	for _, tc := range []struct {
+		path, body string
+		want       int
+	}{
+		{"/api/alerts/open/ack", `{}`, http.StatusBadRequest},
+		{"/api/alerts/missing/ack", `{"user":"alice"}`, http.StatusNotFound},
+		{"/api/alerts/closed/ack", `{"user":"alice"}`, http.StatusConflict},
+		{"/api/alerts/open/ack", `{"user":"alice"}`, http.StatusOK},
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("POST", tc.path, strings.NewReader(tc.body)))
+		if rr.Code != tc.want {
+			t.Errorf("POST %s %s: got status %d, want %d", tc.path, tc.body, rr.Code, tc.want)
+		}
+	}
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/alerts/active", nil))
+	var active []monitoring.Alert
+	if err := json.NewDecoder(rr.Body).Decode(&active); err != nil {
+		t.Fatalf("decode active alerts: %v", err)
+	}
+	if len(active) != 1 || active[0].ID != "open" || !active[0].Acknowledged || active[0].AckedBy != "alice" || active[0].AckedAt == nil {
+		t.Errorf("active alerts = %+v, want acknowledged open alert", active)
+	}
+	if stats := system.GetStats(); stats.ActiveAlerts != 1 {
+		t.Errorf("acknowledged alert should stay active, stats report %d", stats.ActiveAlerts)
+	}
+}
+
+func TestBacktestMonthlyHandler(t *testing.T) {
+	savedEngine, savedStore := backtestEngine, backtestStore
+	defer func() { backtestEngine, backtestStore = savedEngine, savedStore }()
+	backtestEngine, backtestStore = nil, nil
+
+	mux := http.NewServeMux()
+	RegisterBacktestHandlers(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/backtest/monthly", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("without engine got status %d, want 503", rr.Code)
+	}
+
+	start := time.Date(2024, 3, 4, 0, 0, 0, 0, time.UTC)
+	engine := backtest.NewBacktestEngine(backtest.BacktestConfig{
+		StartDate:      start,
+		EndDate:        start.AddDate(0, 1, 0),
+		InitialCapital: 100000,
+		Symbols:        []string{"sh600000"},
+	})
+	strategy := strategies.NewMLStrategy()
+	if err := strategy.Init(context.Background(), "sh600000", map[string]interface{}{"lookback_days": 10}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if err := engine.AddStrategy(strategy); err != nil {
+		t.Fatalf("AddStrategy: %v", err)
+	}
+	results, err := engine.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+	backtestEngine = engine
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/backtest/monthly?id=missing", nil))
+	if rr.Code != http.StatusNotFound {
+		t.Errorf("unknown id got status %d, want 404", rr.Code)
+	}
+
+	for _, path := range []string{"/api/backtest/monthly", "/api/backtest/monthly?id=" + results.ID} {
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", path, nil))
+		var body struct {
+			ID             string                   `json:"id"`
+			MonthlyReturns []backtest.MonthlyReturn `json:"monthly_returns"`
+			Count          int                      `json:"count"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("GET %s: decode: %v", path, err)
+		}
+		if body.ID != results.ID || body.Count != 2 || len(body.MonthlyReturns) != 2 ||
+			body.MonthlyReturns[0].Month != "2024-03" || body.MonthlyReturns[1].Month != "2024-04" {
+			t.Errorf("GET %s = %+v, want March and April returns of %s", path, body, results.ID)
+		}
+	}
+}
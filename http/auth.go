@@ -0,0 +1,46 @@
+package http
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+)
+
+// apiKey 访问交易、风控、策略和数据源管理等写接口及WebSocket所需的API密钥，为空表示不校验
+var apiKey string
+
+// SetAPIKey 设置API密钥
+func SetAPIKey(key string) {
+	apiKey = key
+}
+
+// requireAPIKey 校验请求携带的API密钥，未通过返回401。密钥可通过 X-API-Key 头、
+// Authorization: Bearer 头或 api_key 查询参数（浏览器WebSocket无法设置请求头）传递
+func requireAPIKey(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if apiKey != "" && !validAPIKey(requestAPIKey(r)) {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		next(w, r)
+	}
+}
+
+// requestAPIKey 从请求中取出API密钥
+func requestAPIKey(r *http.Request) string {
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		parts := strings.SplitN(auth, " ", 2)
+		if len(parts) == 2 && strings.EqualFold(parts[0], "bearer") {
+			return parts[1]
+		}
+	}
+	return r.URL.Query().Get("api_key")
+}
+
+// validAPIKey 以常数时间比较密钥，避免通过响应时间猜测
+func validAPIKey(key string) bool {
+	return key != "" && subtle.ConstantTimeCompare([]byte(key), []byte(apiKey)) == 1
+}
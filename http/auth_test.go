@@ -0,0 +1,134 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudquant/monitoring"
+
+	"github.com/gorilla/websocket"
+)
+
+func TestTradingEndpointsRequireAPIKey(t *testing.T) {
+	savedKey := apiKey
+	defer SetAPIKey(savedKey)
+	SetAPIKey("secret")
+
+	mux := http.NewServeMux()
+	RegisterTradingHandlers(mux)
+
+	tests := []struct {
+		name   string
+		header string
+		value  string
+		query  string
+		want   int
+	}{
+		{name: "missing key", want: http.StatusUnauthorized},
+		{name: "wrong key", header: "X-API-Key", value: "guess", want: http.StatusUnauthorized},
+		// 通过认证后由处理器处理，交易组件未初始化返回503
+		{name: "x-api-key header", header: "X-API-Key", value: "secret", want: http.StatusServiceUnavailable},
+		{name: "bearer token", header: "Authorization", value: "Bearer secret", want: http.StatusServiceUnavailable},
+		{name: "query param", query: "?api_key=secret", want: http.StatusServiceUnavailable},
+	}
+	for _, path := range []string{"/api/trading/buy", "/api/trading/sell", "/api/trading/cancel"} {
+		for _, tt := range tests {
+			req := httptest.NewRequest("POST", path+tt.query, strings.NewReader(`{}`))
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.value)
+			}
+			rr := httptest.NewRecorder()
+			mux.ServeHTTP(rr, req)
+			if rr.Code != tt.want {
+				t.Errorf("%s %s: status %d, want %d", path, tt.name, rr.Code, tt.want)
+			}
+		}
+	}
+
+	for _, path := range []string{"/api/trading/auto_trade/start", "/api/trading/auto_trade/stop"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("POST", path, nil))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s without key: status %d, want 401", path, rr.Code)
+		}
+	}
+
+	// 只读接口不需要密钥
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/trading/orders", nil))
+	if rr.Code == http.StatusUnauthorized {
+		t.Error("read-only endpoint should not require API key")
+	}
+}
+
+func TestManagementEndpointsRequireAPIKey(t *testing.T) {
+	savedKey := apiKey
+	defer SetAPIKey(savedKey)
+	SetAPIKey("secret")
+
+	mux := http.NewServeMux()
+	RegisterAPIHandlers(mux)
+	RegisterStrategyHandlers(mux)
+
+	for _, path := range []string{
+		"/api/risk/limits",
+		"/api/risk/cooldown/clear",
+		"/api/providers/switch",
+		"/api/strategies/reload",
+		"/api/strategies/execute",
+		"/api/strategies/combination",
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("POST", path, strings.NewReader(`{}`)))
+		if rr.Code != http.StatusUnauthorized {
+			t.Errorf("%s without key: status %d, want 401", path, rr.Code)
+		}
+
+		req := httptest.NewRequest("POST", path, strings.NewReader(`{}`))
+		req.Header.Set("X-API-Key", "secret")
+		rr = httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		if rr.Code == http.StatusUnauthorized {
+			t.Errorf("%s with key: status 401", path)
+		}
+	}
+}
+
+func TestDashboardWebSocketRequiresAPIKey(t *testing.T) {
+	savedKey, savedMonitor := apiKey, realtimeMonitor
+	defer func() {
+		SetAPIKey(savedKey)
+		SetRealtimeMonitor(savedMonitor)
+	}()
+	SetAPIKey("secret")
+
+	monitor := monitoring.NewRealtimeMonitor(0)
+	if err := monitor.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer monitor.Stop()
+	SetRealtimeMonitor(monitor)
+
+	mux := http.NewServeMux()
+	RegisterAPIHandlers(mux)
+	// 经过日志中间件，确认包装后的ResponseWriter仍可升级
+	server := httptest.NewServer(LoggerMiddleware(mux))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + "/api/ws/dashboard"
+
+	_, resp, err := websocket.DefaultDialer.Dial(url, nil)
+	if err == nil {
+		t.Fatal("websocket without API key should be rejected")
+	}
+	if resp == nil || resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("websocket without API key: resp %v, want 401", resp)
+	}
+
+	conn, _, err := websocket.DefaultDialer.Dial(url+"?api_key=secret", nil)
+	if err != nil {
+		t.Fatalf("websocket with API key: %v", err)
+	}
+	conn.Close()
+}
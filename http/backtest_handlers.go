@@ -0,0 +1,224 @@
+// Package http 提供回测相关API处理器
+package http
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"cloudquant/backtest"
+)
+
+var (
+	backtestSnapshots *backtest.SnapshotStore
+	backtestEngine    *backtest.BacktestEngine
+	backtestStore     backtest.ResultStore
+)
+
+// SetBacktestSnapshotStore 设置回测数据快照存储
+func SetBacktestSnapshotStore(store *backtest.SnapshotStore) {
+	backtestSnapshots = store
+}
+
+// SetBacktestEngine 设置回测引擎，资金曲线在没有组合数据时使用其最近一次回测结果
+func SetBacktestEngine(engine *backtest.BacktestEngine) {
+	backtestEngine = engine
+}
+
+// SetBacktestResultStore 设置回测结果存储，用于查询历史回测
+func SetBacktestResultStore(store backtest.ResultStore) {
+	backtestStore = store
+}
+
+// RegisterBacktestHandlers 注册回测API处理器
+func RegisterBacktestHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/backtest/{id}/data_hash", handleBacktestDataHash)
+	mux.HandleFunc("GET /api/backtest/{id}/export", handleBacktestExport)
+	mux.HandleFunc("GET /api/backtest/{id}/results", handleBacktestResults)
+	mux.HandleFunc("GET /api/backtest/runs", handleBacktestRuns)
+	mux.HandleFunc("GET /api/backtest/progress", handleBacktestProgress)
+	mux.HandleFunc("GET /api/backtest/monthly", handleBacktestMonthly)
+}
+
+func handleBacktestDataHash(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	if backtestSnapshots == nil {
+		http.Error(w, `{"error":"backtest snapshots not enabled"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	snapshot, ok := backtestSnapshots.Get(id)
+	if !ok {
+		http.Error(w, `{"error":"snapshot not found"}`, http.StatusNotFound)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"id":         snapshot.ID,
+		"data_hash":  snapshot.Hash,
+		"symbols":    snapshot.Symbols,
+		"bar_count":  snapshot.BarCount(),
+		"created_at": snapshot.CreatedAt,
+	})
+}
+
+// handleBacktestExport 导出回测结果，section为空时导出全部部分
+func handleBacktestExport(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" {
+		http.Error(w, `{"error":"unsupported export format"}`, http.StatusBadRequest)
+		return
+	}
+
+	section := backtest.CSVSection(r.URL.Query().Get("section"))
+	if section != "" {
+		valid := false
+		for _, s := range backtest.CSVSections {
+			valid = valid || s == section
+		}
+		if !valid {
+			http.Error(w, `{"error":"unknown export section"}`, http.StatusBadRequest)
+			return
+		}
+	}
+
+	results, ok := findBacktestResults(w, id)
+	if !ok {
+		return
+	}
+
+	filename := fmt.Sprintf("backtest_%s.csv", id)
+	if section != "" {
+		filename = fmt.Sprintf("backtest_%s_%s.csv", id, section)
+	}
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+
+	var err error
+	if section == "" {
+		err = results.ExportCSV(w)
+	} else {
+		err = results.ExportSectionCSV(w, section)
+	}
+	if err != nil {
+		log.Printf("Export backtest %s failed: %v", id, err)
+	}
+}
+
+// handleBacktestProgress 返回当前回测的运行状态和进度，回测进行中也不会阻塞
+func handleBacktestProgress(w http.ResponseWriter, r *http.Request) {
+	if backtestEngine == nil {
+		http.Error(w, `{"error":"backtest engine not available"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"running":   backtestEngine.IsRunning(),
+		"progress":  backtestEngine.GetProgress(),
+		"timestamp": time.Now(),
+	})
+}
+
+func handleBacktestResults(w http.ResponseWriter, r *http.Request) {
+	results, ok := findBacktestResults(w, r.PathValue("id"))
+	if !ok {
+		return
+	}
+	respondJSON(w, results)
+}
+
+// handleBacktestMonthly 返回回测的月度收益，id为空时使用引擎最近一次已完成的回测
+func handleBacktestMonthly(w http.ResponseWriter, r *http.Request) {
+	var results *backtest.BacktestResults
+	if id := r.URL.Query().Get("id"); id != "" {
+		var ok bool
+		if results, ok = findBacktestResults(w, id); !ok {
+			return
+		}
+	} else {
+		if backtestEngine == nil {
+			http.Error(w, `{"error":"backtest engine not available"}`, http.StatusServiceUnavailable)
+			return
+		}
+		if !backtestEngine.IsRunning() {
+			results = backtestEngine.GetResults()
+		}
+		if results == nil {
+			http.Error(w, `{"error":"backtest results not found"}`, http.StatusNotFound)
+			return
+		}
+	}
+
+	monthly := results.MonthlyReturnSeries()
+	var positive int
+	for _, m := range monthly {
+		if m.Return > 0 {
+			positive++
+		}
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"id":              results.ID,
+		"monthly_returns": monthly,
+		"count":           len(monthly),
+		"positive_months": positive,
+	})
+}
+
+// handleBacktestRuns 列出已保存的回测，可按config_hash筛选同一组参数的多次运行
+func handleBacktestRuns(w http.ResponseWriter, r *http.Request) {
+	if backtestStore == nil {
+		http.Error(w, `{"error":"backtest result store not enabled"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	runs, err := backtestStore.ListRuns(r.URL.Query().Get("config_hash"))
+	if err != nil {
+		log.Printf("List backtest runs failed: %v", err)
+		http.Error(w, `{"error":"failed to list backtest runs"}`, http.StatusInternalServerError)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"runs":  runs,
+		"count": len(runs),
+	})
+}
+
+// findBacktestResults 查找回测结果：优先使用引擎最近一次已完成的回测，否则从结果存储读取；
+// 找不到时写入错误响应并返回false
+func findBacktestResults(w http.ResponseWriter, id string) (*backtest.BacktestResults, bool) {
+	if backtestEngine == nil && backtestStore == nil {
+		http.Error(w, `{"error":"backtest engine not available"}`, http.StatusServiceUnavailable)
+		return nil, false
+	}
+
+	if backtestEngine != nil && !backtestEngine.IsRunning() {
+		if results := backtestEngine.GetResults(); results != nil && results.ID == id {
+			return results, true
+		}
+	}
+
+	if backtestStore != nil {
+		results, err := backtestStore.LoadResults(id)
+		if err == nil {
+			return results, true
+		}
+		if !errors.Is(err, backtest.ErrRunNotFound) {
+			log.Printf("Load backtest results %s failed: %v", id, err)
+			http.Error(w, `{"error":"failed to load backtest results"}`, http.StatusInternalServerError)
+			return nil, false
+		}
+	}
+
+	http.Error(w, `{"error":"backtest results not found"}`, http.StatusNotFound)
+	return nil, false
+}
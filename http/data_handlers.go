@@ -0,0 +1,74 @@
+// Package http 提供行情数据存储相关API处理器
+package http
+
+import (
+	"log"
+	"net/http"
+	"time"
+
+	"cloudquant/pipeline"
+)
+
+var marketStorage *pipeline.OptimizedStorage
+
+// SetMarketStorage 设置行情数据存储
+func SetMarketStorage(storage *pipeline.OptimizedStorage) {
+	marketStorage = storage
+}
+
+// RegisterDataHandlers 注册行情数据API处理器
+func RegisterDataHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/data/quality", handleDataQuality)
+}
+
+// handleDataQuality 查询入库时记录的数据质量问题，start/end为YYYY-MM-DD（含当天），默认最近30天
+func handleDataQuality(w http.ResponseWriter, r *http.Request) {
+	if marketStorage == nil {
+		http.Error(w, `{"error":"market storage not enabled"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	query := r.URL.Query()
+	end := time.Now()
+	if v := query.Get("end"); v != "" {
+		date, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			http.Error(w, `{"error":"invalid end date, expected YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+		end = date.Add(24*time.Hour - time.Second)
+	}
+	start := end.AddDate(0, 0, -30)
+	if v := query.Get("start"); v != "" {
+		date, err := time.ParseInLocation("2006-01-02", v, time.Local)
+		if err != nil {
+			http.Error(w, `{"error":"invalid start date, expected YYYY-MM-DD"}`, http.StatusBadRequest)
+			return
+		}
+		start = date
+	}
+	if end.Before(start) {
+		http.Error(w, `{"error":"end date before start date"}`, http.StatusBadRequest)
+		return
+	}
+
+	issues, err := marketStorage.GetQualityIssues(r.Context(), query.Get("symbol"), start, end)
+	if err != nil {
+		log.Printf("Query data quality issues failed: %v", err)
+		http.Error(w, `{"error":"failed to query data quality issues"}`, http.StatusInternalServerError)
+		return
+	}
+
+	bySeverity := make(map[string]int)
+	for _, issue := range issues {
+		bySeverity[issue.Severity]++
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"issues":      issues,
+		"count":       len(issues),
+		"by_severity": bySeverity,
+		"start":       start,
+		"end":         end,
+	})
+}
@@ -0,0 +1,73 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloudquant/pipeline"
+)
+
+func TestDataQualityHandler(t *testing.T) {
+	saved := marketStorage
+	defer SetMarketStorage(saved)
+	SetMarketStorage(nil)
+
+	mux := http.NewServeMux()
+	RegisterDataHandlers(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/data/quality", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("without storage got status %d, want 503", rr.Code)
+	}
+
+	storage, err := pipeline.NewOptimizedStorage(pipeline.StorageConfig{DBPath: filepath.Join(t.TempDir(), "market.db")})
+	if err != nil {
+		t.Fatalf("NewOptimizedStorage: %v", err)
+	}
+	defer storage.Close()
+	SetMarketStorage(storage)
+
+	ts := time.Date(2024, 3, 5, 15, 0, 0, 0, time.Local).Unix()
+	if err := storage.SaveBatch(context.Background(), []*pipeline.DataPoint{
+		{Symbol: "sh600000", Timestamp: ts, Open: 10, High: 9, Low: 11, Close: 10, Volume: 100},
+		{Symbol: "sh600519", Timestamp: ts, Open: 1700, High: 1710, Low: 1690, Close: 1700, Volume: -1},
+	}); err != nil {
+		t.Fatalf("SaveBatch: %v", err)
+	}
+
+	for _, tc := range []struct {
+		query string
+		want  int
+	}{
+		{"?start=2024-03-05&end=2024-03-05", 2},
+		{"?symbol=sh600519&start=2024-03-01&end=2024-03-31", 1},
+		{"?start=2024-03-06&end=2024-03-31", 0},
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/data/quality"+tc.query, nil))
+		var body struct {
+			Issues []pipeline.QualityIssue `json:"issues"`
+			Count  int                     `json:"count"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("%s: decode: %v", tc.query, err)
+		}
+		if body.Count != tc.want || len(body.Issues) != tc.want {
+			t.Errorf("%s: got %d issues, want %d", tc.query, body.Count, tc.want)
+		}
+	}
+
+	for _, query := range []string{"?start=03/05/2024", "?start=2024-03-06&end=2024-03-05"} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/data/quality"+query, nil))
+		if rr.Code != http.StatusBadRequest {
+			t.Errorf("%s: got status %d, want 400", query, rr.Code)
+		}
+	}
+}
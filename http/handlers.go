@@ -277,12 +277,18 @@ func handleTrain(w http.ResponseWriter, r *http.Request) {
         http.Error(w, "training config not set", http.StatusServiceUnavailable)
         return
     }
-    if err := trainModel(trainingConfig); err != nil {
+    report, err := trainModel(trainingConfig)
+    if err != nil {
         http.Error(w, err.Error(), http.StatusInternalServerError)
         return
     }
 
-    response := map[string]string{"status": "training_completed"}
+    response := map[string]interface{}{"status": "training_completed"}
+    if report != nil {
+        log.Printf("Trained %s model on %s: held-out accuracy %.3f, macro F1 %.3f over %d samples",
+            trainingConfig.ModelType, trainingConfig.Symbol, report.Accuracy, report.MacroF1, report.Total)
+        response["evaluation"] = evaluationResponse(report)
+    }
     w.Header().Set("Content-Type", "application/json")
     if err := json.NewEncoder(w).Encode(response); err != nil {
         log.Printf("Failed to encode train response: %v", err)
@@ -329,6 +335,9 @@ func handlePredict(w http.ResponseWriter, r *http.Request) {
         "timestamp":    kline.Timestamp,
         "feature_size": len(features),
     }
+    if explainer, ok := mlModel.(interface{ FeatureImportance() map[int]float64 }); ok {
+        response["feature_importance"] = ml.NamedFeatureImportance(explainer.FeatureImportance(), ml.FeatureNames())
+    }
 
     w.Header().Set("Content-Type", "application/json")
     if err := json.NewEncoder(w).Encode(response); err != nil {
@@ -0,0 +1,146 @@
+package http
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "cloudquant"
+
+var registerMetricsOnce sync.Once
+
+// RegisterMetricsHandlers 在默认注册表注册业务指标并挂载 GET /metrics，
+// 供Prometheus抓取，同时包含client_golang默认的Go运行时和进程指标
+func RegisterMetricsHandlers(mux *http.ServeMux) {
+	registerMetricsOnce.Do(func() {
+		prometheus.MustRegister(newMetricsCollector())
+	})
+	mux.Handle("GET /metrics", promhttp.Handler())
+}
+
+// metricsCollector 在每次抓取时从各组件已有的统计接口读取指标，未初始化的组件不输出对应指标
+type metricsCollector struct {
+	ordersSubmitted *prometheus.Desc
+	ordersFilled    *prometheus.Desc
+	ordersFailed    *prometheus.Desc
+
+	wsClients          *prometheus.Desc
+	wsRejected         *prometheus.Desc
+	wsMessagesSent     *prometheus.Desc
+	wsMessagesReceived *prometheus.Desc
+
+	alerts       *prometheus.Desc
+	activeAlerts *prometheus.Desc
+
+	strategyLatency *prometheus.Desc
+	strategySlow    *prometheus.Desc
+
+	backtestRunning  *prometheus.Desc
+	backtestProgress *prometheus.Desc
+
+	riskEvents *prometheus.Desc
+}
+
+func newMetricsCollector() *metricsCollector {
+	desc := func(name, help string, labels ...string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(metricsNamespace, "", name), help, labels, nil)
+	}
+	return &metricsCollector{
+		ordersSubmitted: desc("orders_submitted_total", "Orders accepted by the broker."),
+		ordersFilled:    desc("orders_filled_total", "Orders with at least one synced fill."),
+		ordersFailed:    desc("orders_failed_total", "Orders rejected by risk checks, validation or the broker."),
+
+		wsClients:          desc("websocket_clients", "Connected WebSocket clients."),
+		wsRejected:         desc("websocket_rejected_total", "WebSocket connections rejected by the connection limit."),
+		wsMessagesSent:     desc("websocket_messages_sent_total", "Messages broadcast to WebSocket clients."),
+		wsMessagesReceived: desc("websocket_messages_received_total", "Messages received from WebSocket clients."),
+
+		alerts:       desc("alerts_total", "Alerts sent, by level.", "level"),
+		activeAlerts: desc("alerts_active", "Unresolved alerts."),
+
+		strategyLatency: desc("strategy_execution_seconds", "Strategy execution time over the latency window.", "strategy", "stat"),
+		strategySlow:    desc("strategy_slow", "Whether the strategy's average execution time exceeds the threshold.", "strategy"),
+
+		backtestRunning:  desc("backtest_running", "Whether a backtest is running."),
+		backtestProgress: desc("backtest_progress_percent", "Progress of the current or last backtest, 0 to 100."),
+
+		riskEvents: desc("risk_events_total", "Realtime risk events, by type and level.", "type", "level"),
+	}
+}
+
+// Describe 实现prometheus.Collector
+func (c *metricsCollector) Describe(ch chan<- *prometheus.Desc) {
+	for _, d := range []*prometheus.Desc{
+		c.ordersSubmitted, c.ordersFilled, c.ordersFailed,
+		c.wsClients, c.wsRejected, c.wsMessagesSent, c.wsMessagesReceived,
+		c.alerts, c.activeAlerts,
+		c.strategyLatency, c.strategySlow,
+		c.backtestRunning, c.backtestProgress,
+		c.riskEvents,
+	} {
+		ch <- d
+	}
+}
+
+// Collect 实现prometheus.Collector
+func (c *metricsCollector) Collect(ch chan<- prometheus.Metric) {
+	counter := func(d *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.CounterValue, v, labels...)
+	}
+	gauge := func(d *prometheus.Desc, v float64, labels ...string) {
+		ch <- prometheus.MustNewConstMetric(d, prometheus.GaugeValue, v, labels...)
+	}
+	boolGauge := func(d *prometheus.Desc, b bool, labels ...string) {
+		v := 0.0
+		if b {
+			v = 1
+		}
+		gauge(d, v, labels...)
+	}
+
+	if orderExecutor != nil {
+		stats := orderExecutor.GetOrderStats()
+		counter(c.ordersSubmitted, float64(stats.Submitted))
+		counter(c.ordersFilled, float64(stats.Filled))
+		counter(c.ordersFailed, float64(stats.Failed))
+	}
+
+	if realtimeMonitor != nil {
+		stats := realtimeMonitor.GetStats()
+		gauge(c.wsClients, float64(stats.ConnectedClients))
+		counter(c.wsRejected, float64(stats.RejectedClients))
+		counter(c.wsMessagesSent, float64(stats.MessagesSent))
+		counter(c.wsMessagesReceived, float64(stats.MessagesReceived))
+	}
+
+	if alertSystem != nil {
+		stats := alertSystem.GetStats()
+		for level, count := range stats.ByLevel {
+			counter(c.alerts, float64(count), string(level))
+		}
+		gauge(c.activeAlerts, float64(stats.ActiveAlerts))
+	}
+
+	if strategyManager != nil {
+		for _, latency := range strategyManager.GetLatencyStats() {
+			gauge(c.strategyLatency, latency.AvgMs/1000, latency.Strategy, "avg")
+			gauge(c.strategyLatency, latency.MaxMs/1000, latency.Strategy, "max")
+			gauge(c.strategyLatency, latency.LastMs/1000, latency.Strategy, "last")
+			boolGauge(c.strategySlow, latency.Slow, latency.Strategy)
+		}
+	}
+
+	if backtestEngine != nil {
+		boolGauge(c.backtestRunning, backtestEngine.IsRunning())
+		gauge(c.backtestProgress, backtestEngine.GetProgress())
+	}
+
+	if realtimeRiskMonitor != nil {
+		for _, events := range realtimeRiskMonitor.GetEventCounts() {
+			counter(c.riskEvents, float64(events.Count), events.Type, events.Level.String())
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package http
+
+import (
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudquant/monitoring"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+func TestMetricsCollectorExposesComponentStats(t *testing.T) {
+	savedMonitor, savedAlerts := realtimeMonitor, alertSystem
+	savedExecutor, savedStrategies, savedBacktest, savedRisk := orderExecutor, strategyManager, backtestEngine, realtimeRiskMonitor
+	defer func() {
+		realtimeMonitor, alertSystem = savedMonitor, savedAlerts
+		orderExecutor, strategyManager, backtestEngine, realtimeRiskMonitor = savedExecutor, savedStrategies, savedBacktest, savedRisk
+	}()
+	orderExecutor, strategyManager, backtestEngine, realtimeRiskMonitor = nil, nil, nil, nil
+
+	realtimeMonitor = monitoring.NewRealtimeMonitor(10)
+	alertSystem = monitoring.NewAlertSystem()
+	for _, level := range []monitoring.AlertLevel{monitoring.Warning, monitoring.Warning, monitoring.Critical} {
+		_, _ = alertSystem.SendAlert(&monitoring.Alert{Level: level, Title: "test", Message: "test"})
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(newMetricsCollector())
+	rr := httptest.NewRecorder()
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(rr, httptest.NewRequest("GET", "/metrics", nil))
+	body, _ := io.ReadAll(rr.Body)
+	text := string(body)
+
+	for _, want := range []string{
+		"cloudquant_websocket_clients 0",
+		`cloudquant_alerts_total{level="warning"} 2`,
+		`cloudquant_alerts_total{level="critical"} 1`,
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("metrics missing %q:\n%s", want, text)
+		}
+	}
+	// 未初始化的组件不输出指标
+	if strings.Contains(text, "cloudquant_orders_submitted_total") {
+		t.Errorf("order metrics exported without an order executor:\n%s", text)
+	}
+}
@@ -2,8 +2,11 @@
 package http
 
 import (
+	"bufio"
 	"context"
+	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"runtime"
 	"strings"
@@ -244,6 +247,17 @@ func (rw *responseWriter) Write(b []byte) (int, error) {
 	return rw.ResponseWriter.Write(b)
 }
 
+// Hijack 透传连接接管，使经过日志中间件的请求可以升级为WebSocket
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("response writer does not support hijacking")
+	}
+	rw.statusCode = http.StatusSwitchingProtocols
+	rw.written = true
+	return hijacker.Hijack()
+}
+
 // generateRequestID 生成请求ID
 func generateRequestID() string {
 	return time.Now().Format("20060102150405") + "-" + randomString(8)
@@ -0,0 +1,88 @@
+package http
+
+import (
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// rateWindow 单个客户端在当前时间窗口内的请求计数
+type rateWindow struct {
+	start time.Time
+	count int
+}
+
+// ipRateLimiter 按客户端IP的固定窗口限流器
+type ipRateLimiter struct {
+	mu      sync.Mutex
+	limit   int // 每个窗口允许的请求数，<=0表示不限制
+	window  time.Duration
+	clients map[string]*rateWindow
+}
+
+func newIPRateLimiter(limit int, window time.Duration) *ipRateLimiter {
+	return &ipRateLimiter{
+		limit:   limit,
+		window:  window,
+		clients: make(map[string]*rateWindow),
+	}
+}
+
+// allow 记录一次请求并返回是否允许；不允许时同时返回距窗口结束的时间
+func (l *ipRateLimiter) allow(ip string, now time.Time) (bool, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.limit <= 0 {
+		return true, 0
+	}
+
+	// 客户端较多时顺带清理已过期的窗口
+	if len(l.clients) > 1024 {
+		for key, w := range l.clients {
+			if now.Sub(w.start) >= l.window {
+				delete(l.clients, key)
+			}
+		}
+	}
+
+	w, ok := l.clients[ip]
+	if !ok || now.Sub(w.start) >= l.window {
+		l.clients[ip] = &rateWindow{start: now, count: 1}
+		return true, 0
+	}
+	if w.count >= l.limit {
+		return false, w.start.Add(l.window).Sub(now)
+	}
+	w.count++
+	return true, 0
+}
+
+// orderRateLimiter 买卖接口的限流器
+var orderRateLimiter = newIPRateLimiter(0, time.Minute)
+
+// SetOrderRateLimit 设置每个IP每分钟最多提交的买卖请求数，<=0表示不限制
+func SetOrderRateLimit(perMinute int) {
+	orderRateLimiter.mu.Lock()
+	defer orderRateLimiter.mu.Unlock()
+	orderRateLimiter.limit = perMinute
+	orderRateLimiter.clients = make(map[string]*rateWindow)
+}
+
+// limitOrderRate 按客户端IP限制下单频率，超出返回429
+func limitOrderRate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		ip, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			ip = r.RemoteAddr
+		}
+		if ok, retryAfter := orderRateLimiter.allow(ip, time.Now()); !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())+1))
+			http.Error(w, "请求过于频繁", http.StatusTooManyRequests)
+			return
+		}
+		next(w, r)
+	}
+}
@@ -0,0 +1,64 @@
+package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestIPRateLimiter(t *testing.T) {
+	limiter := newIPRateLimiter(2, time.Minute)
+	now := time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		if ok, _ := limiter.allow("10.0.0.1", now); !ok {
+			t.Fatalf("request %d within limit rejected", i+1)
+		}
+	}
+	ok, retryAfter := limiter.allow("10.0.0.1", now.Add(10*time.Second))
+	if ok || retryAfter != 50*time.Second {
+		t.Errorf("third request: allowed %v retry after %v, want rejected with 50s", ok, retryAfter)
+	}
+	// 各IP独立计数
+	if ok, _ := limiter.allow("10.0.0.2", now); !ok {
+		t.Error("other IP rejected")
+	}
+	// 新窗口重新计数
+	if ok, _ := limiter.allow("10.0.0.1", now.Add(time.Minute)); !ok {
+		t.Error("request in next window rejected")
+	}
+}
+
+func TestOrderEndpointsRateLimited(t *testing.T) {
+	savedKey := apiKey
+	defer func() {
+		SetAPIKey(savedKey)
+		SetOrderRateLimit(0)
+	}()
+	SetAPIKey("")
+	SetOrderRateLimit(1)
+
+	mux := http.NewServeMux()
+	RegisterTradingHandlers(mux)
+
+	send := func(remoteAddr string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest("POST", "/api/trading/buy", nil)
+		req.RemoteAddr = remoteAddr
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, req)
+		return rr
+	}
+
+	// 交易组件未初始化，通过限流后返回503
+	if rr := send("10.0.0.1:5000"); rr.Code != http.StatusServiceUnavailable {
+		t.Fatalf("first request status %d, want 503", rr.Code)
+	}
+	rr := send("10.0.0.1:5001")
+	if rr.Code != http.StatusTooManyRequests || rr.Header().Get("Retry-After") == "" {
+		t.Errorf("second request status %d Retry-After %q, want 429 with Retry-After", rr.Code, rr.Header().Get("Retry-After"))
+	}
+	if rr := send("10.0.0.2:5000"); rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("other IP status %d, want 503", rr.Code)
+	}
+}
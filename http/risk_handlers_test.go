@@ -0,0 +1,253 @@
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudquant/trading"
+	"cloudquant/trading/risk"
+	"cloudquant/trading/risk/realtime"
+)
+
+func TestRealtimeRiskHandlers(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterAPIHandlers(mux)
+
+	SetRealtimeRiskMonitor(nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/risk/limits", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("limits without monitor: got status %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	SetRealtimeRiskMonitor(realtime.NewRealtimeRiskMonitor(nil, nil, realtime.MonitorConfig{}))
+	defer SetRealtimeRiskMonitor(nil)
+
+	body := strings.NewReader(`{"name":"max_drawdown","warning_threshold":0.1,"critical_threshold":0.12}`)
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/risk/limits", body))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("update limit: got status %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/risk/limits", nil))
+	var limits map[string]realtime.RiskLimit
+	if err := json.NewDecoder(rr.Body).Decode(&limits); err != nil {
+		t.Fatalf("decode limits: %v", err)
+	}
+	if got := limits["max_drawdown"]; got.WarningThreshold != 0.1 || got.CriticalThreshold != 0.12 {
+		t.Errorf("max_drawdown limit %+v not updated", got)
+	}
+
+	for _, tc := range []struct {
+		method, path, body string
+		want               int
+	}{
+		{"POST", "/api/risk/limits", `{"name":"unknown","warning_threshold":0.1,"critical_threshold":0.2}`, http.StatusNotFound},
+		{"POST", "/api/risk/limits", `{"name":"max_drawdown","warning_threshold":0.2,"critical_threshold":0.1}`, http.StatusBadRequest},
+		{"GET", "/api/risk/events?limit=5", "", http.StatusOK},
+		{"GET", "/api/risk/events?limit=abc", "", http.StatusBadRequest},
+		{"GET", "/api/risk/exposure/sh600000", "", http.StatusNotFound},
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest(tc.method, tc.path, strings.NewReader(tc.body)))
+		if rr.Code != tc.want {
+			t.Errorf("%s %s: got status %d, want %d", tc.method, tc.path, rr.Code, tc.want)
+		}
+	}
+}
+
+func TestPortfolioWeightsFromPositionManager(t *testing.T) {
+	saved := positionManager
+	defer func() { positionManager = saved }()
+
+	positionManager = nil
+	if weights := portfolioWeights(); len(weights) != 4 {
+		t.Errorf("got %d mock weights without a position manager, want 4", len(weights))
+	}
+
+	connector, err := trading.NewBrokerConnector(trading.BrokerConfig{Type: "easytrader"})
+	if err != nil {
+		t.Fatalf("NewBrokerConnector: %v", err)
+	}
+	positionManager = trading.NewPositionManager(connector)
+	if weights := portfolioWeights(); len(weights) != 0 {
+		t.Errorf("got weights %v for an empty account, want none", weights)
+	}
+
+	for _, trade := range []trading.Trade{
+		{Symbol: "sh600000", Type: "buy", Price: 10, Amount: 1000},
+		{Symbol: "sh600519", Type: "buy", Price: 300, Amount: 100},
+	} {
+		if err := positionManager.UpdatePosition(trade); err != nil {
+			t.Fatalf("UpdatePosition: %v", err)
+		}
+	}
+
+	weights := portfolioWeights()
+	if len(weights) != 2 || math.Abs(weights["sh600000"]-0.25) > 1e-9 || math.Abs(weights["sh600519"]-0.75) > 1e-9 {
+		t.Errorf("got weights %v, want sh600000 0.25 and sh600519 0.75 by market value", weights)
+	}
+
+	savedHistory := tradeHistory
+	defer func() { tradeHistory = savedHistory }()
+
+	// 没有组合收益历史时不返回估算的因子暴露
+	tradeHistory = nil
+	rr := httptest.NewRecorder()
+	handleRiskFactors(rr, httptest.NewRequest("GET", "/api/risk/factors", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("factors without history: got status %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	history, err := trading.NewTradeHistory(filepath.Join(t.TempDir(), "trades.db"))
+	if err != nil {
+		t.Fatalf("NewTradeHistory: %v", err)
+	}
+	defer history.Close()
+	tradeHistory = history
+
+	rr = httptest.NewRecorder()
+	handleRiskFactors(rr, httptest.NewRequest("GET", "/api/risk/factors", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("factors with empty history: got status %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	for i, ret := range []float64{0.01, -0.02, 0.03} {
+		pnl := trading.DailyPnL{Date: fmt.Sprintf("2026-10-%02d", 12+i), PnLPercent: ret}
+		if err := history.SaveDailyPnL(pnl); err != nil {
+			t.Fatalf("SaveDailyPnL: %v", err)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	handleRiskFactors(rr, httptest.NewRequest("GET", "/api/risk/factors", nil))
+	var factors map[string]interface{}
+	if err := json.NewDecoder(rr.Body).Decode(&factors); err != nil {
+		t.Fatalf("decode factors: %v", err)
+	}
+	if factors["positions"] != float64(2) || factors["observations"] != float64(3) {
+		t.Errorf("factor exposure computed over %v positions and %v returns, want 2 and 3", factors["positions"], factors["observations"])
+	}
+	// 波动率为三个日收益率的总体标准差
+	if vol, _ := factors["volatility"].(float64); math.Abs(vol-0.020548) > 1e-5 {
+		t.Errorf("volatility = %v, want 0.020548 from the recorded daily returns", factors["volatility"])
+	}
+	if _, ok := factors["beta"]; ok {
+		t.Error("beta reported without benchmark returns")
+	}
+}
+
+func TestRiskVaRHandlerHonorsParameters(t *testing.T) {
+	saved := tradeHistory
+	tradeHistory = nil
+	defer func() { tradeHistory = saved }()
+
+	// 没有交易历史时不返回模拟收益率计算的VaR
+	rr := httptest.NewRecorder()
+	handleRiskVaR(rr, httptest.NewRequest("GET", "/api/risk/var", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("VaR without history: got status %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	history, err := trading.NewTradeHistory(filepath.Join(t.TempDir(), "trades.db"))
+	if err != nil {
+		t.Fatalf("NewTradeHistory: %v", err)
+	}
+	defer history.Close()
+	tradeHistory = history
+	start := time.Date(2026, 9, 1, 0, 0, 0, 0, time.Local)
+	for i := 0; i < 30; i++ {
+		pnl := trading.DailyPnL{Date: start.AddDate(0, 0, i).Format("2006-01-02"), PnLPercent: (float64(i%10) - 5) / 1000}
+		if err := history.SaveDailyPnL(pnl); err != nil {
+			t.Fatalf("SaveDailyPnL: %v", err)
+		}
+	}
+
+	results := make(map[string]float64)
+	for _, query := range []string{"method=historical&confidence=0.95", "method=parametric&confidence=0.95", "method=parametric&confidence=0.99"} {
+		rr = httptest.NewRecorder()
+		handleRiskVaR(rr, httptest.NewRequest("GET", "/api/risk/var?"+query, nil))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("%s: got status %d: %s", query, rr.Code, rr.Body.String())
+		}
+		var body struct {
+			VaR  float64 `json:"var"`
+			CVaR float64 `json:"cvar"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&body); err != nil {
+			t.Fatalf("decode: %v", err)
+		}
+		results[query] = body.VaR
+	}
+	if results["method=parametric&confidence=0.99"] >= results["method=parametric&confidence=0.95"] {
+		t.Errorf("99%% VaR %.4f not worse than 95%% VaR %.4f", results["method=parametric&confidence=0.99"], results["method=parametric&confidence=0.95"])
+	}
+	if results["method=historical&confidence=0.95"] == results["method=parametric&confidence=0.95"] {
+		t.Errorf("historical and parametric VaR identical (%.4f)", results["method=historical&confidence=0.95"])
+	}
+
+	rr = httptest.NewRecorder()
+	handleRiskVaR(rr, httptest.NewRequest("GET", "/api/risk/var?method=garch", nil))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown method: got status %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestCooldownHandlers(t *testing.T) {
+	mux := http.NewServeMux()
+	RegisterAPIHandlers(mux)
+
+	SetCooldownRisk(nil)
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/risk/cooldown", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("cooldown without component: got status %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	cooldown := risk.NewCooldownRisk(risk.CooldownRiskConfig{MaxDailyTrades: 10, MaxWeeklyTrades: 10, EnableCooldown: true}, nil)
+	cooldown.AddToBlacklist("sh600000", time.Hour)
+	SetCooldownRisk(cooldown)
+	defer SetCooldownRisk(nil)
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/risk/cooldown?symbol=sh600000", nil))
+	var status struct {
+		Blacklist map[string]time.Time `json:"blacklist"`
+		Allowed   bool                 `json:"allowed"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("decode cooldown status: %v", err)
+	}
+	if _, ok := status.Blacklist["sh600000"]; !ok || status.Allowed {
+		t.Errorf("status %+v, want sh600000 blacklisted and not allowed", status)
+	}
+
+	for _, tc := range []struct {
+		body string
+		want int
+	}{
+		{`{"symbol":""}`, http.StatusBadRequest},
+		{`{"symbol":"sz000001"}`, http.StatusNotFound},
+		{`{"symbol":"sh600000"}`, http.StatusOK},
+		{`{"symbol":"sh600000"}`, http.StatusNotFound},
+	} {
+		rr := httptest.NewRecorder()
+		mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/risk/cooldown/clear", strings.NewReader(tc.body)))
+		if rr.Code != tc.want {
+			t.Errorf("clear %s: got status %d, want %d", tc.body, rr.Code, tc.want)
+		}
+	}
+
+	if allowed, reason := cooldown.IsAllowed("sh600000"); !allowed {
+		t.Errorf("sh600000 still blocked after clear: %s", reason)
+	}
+}
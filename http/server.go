@@ -42,6 +42,10 @@ func NewServer(config ServerConfig) *Server {
 	RegisterTradingHandlers(mux)
 	RegisterDashboardRoutes(mux)
 	RegisterAPIHandlers(mux)
+	RegisterStrategyHandlers(mux)
+	RegisterBacktestHandlers(mux)
+	RegisterDataHandlers(mux)
+	RegisterMetricsHandlers(mux)
 
 	// 创建中间件链
 	chain := Chain(
@@ -80,11 +84,16 @@ func (s *Server) Start() error {
 	return nil
 }
 
-// Stop 停止服务器
+// Stop 停止服务器，最多等待5秒
 func (s *Server) Stop() error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
+	return s.Shutdown(ctx)
+}
+
+// Shutdown 停止接受新连接并等待处理中的请求完成，ctx到期时强制返回
+func (s *Server) Shutdown(ctx context.Context) error {
 	log.Println("Shutting down HTTP server...")
 
 	if err := s.server.Shutdown(ctx); err != nil {
@@ -0,0 +1,221 @@
+// Package http 提供策略相关API处理器
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"cloudquant/market"
+	"cloudquant/trading/strategies"
+)
+
+var (
+	strategyManager      *strategies.StrategyManager
+	strategyConfigSource func() ([]strategies.StrategyConfig, error)
+)
+
+// SetStrategyManager 设置策略管理器
+func SetStrategyManager(manager *strategies.StrategyManager) {
+	strategyManager = manager
+}
+
+// SetStrategyConfigSource 设置热更新请求未携带配置时读取策略配置的函数，通常为重新读取配置文件
+func SetStrategyConfigSource(source func() ([]strategies.StrategyConfig, error)) {
+	strategyConfigSource = source
+}
+
+// RegisterStrategyHandlers 注册策略API处理器
+func RegisterStrategyHandlers(mux *http.ServeMux) {
+	mux.HandleFunc("GET /api/strategies/latency", handleStrategyLatency)
+	mux.HandleFunc("POST /api/strategies/reload", requireAPIKey(handleStrategyReload))
+	mux.HandleFunc("POST /api/strategies/execute", requireAPIKey(handleStrategyExecute))
+	mux.HandleFunc("GET /api/strategies/stats", handleStrategyStats)
+	mux.HandleFunc("POST /api/strategies/combination", requireAPIKey(handleStrategyCombination))
+}
+
+func handleStrategyLatency(w http.ResponseWriter, r *http.Request) {
+	if strategyManager == nil {
+		http.Error(w, `{"error":"strategy manager not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	respondJSON(w, map[string]interface{}{
+		"threshold_ms": strategyManager.GetLatencyThreshold().Milliseconds(),
+		"strategies":   strategyManager.GetLatencyStats(),
+	})
+}
+
+// handleStrategyReload 热更新策略：请求体为{"strategies": [...]}，为空时从配置源重新读取
+func handleStrategyReload(w http.ResponseWriter, r *http.Request) {
+	if strategyManager == nil {
+		http.Error(w, `{"error":"strategy manager not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Strategies []strategies.StrategyConfig `json:"strategies"`
+	}
+	err := json.NewDecoder(r.Body).Decode(&req)
+	switch {
+	case errors.Is(err, io.EOF):
+		if strategyConfigSource == nil {
+			http.Error(w, `{"error":"strategies required"}`, http.StatusBadRequest)
+			return
+		}
+		configs, err := strategyConfigSource()
+		if err != nil {
+			http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusInternalServerError)
+			return
+		}
+		req.Strategies = configs
+	case err != nil:
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	if err := strategyManager.ReloadStrategies(req.Strategies); err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadRequest)
+		return
+	}
+
+	stats := strategyManager.GetStats()
+	respondJSON(w, map[string]interface{}{
+		"status":         "reloaded",
+		"strategy_count": stats["strategy_count"],
+		"enabled_count":  stats["enabled_count"],
+	})
+}
+
+// handleStrategyExecute 对一只股票试算所有启用的策略并返回合并后的信号，不会下单。
+// 请求体为MarketData，未提供收盘价时按symbol获取最新行情。试算使用按历史K线预热的策略副本，
+// 不影响实盘策略的状态和执行统计
+func handleStrategyExecute(w http.ResponseWriter, r *http.Request) {
+	if strategyManager == nil {
+		http.Error(w, `{"error":"strategy manager not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	var data strategies.MarketData
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+	if data.Symbol == "" {
+		http.Error(w, `{"error":"symbol required"}`, http.StatusBadRequest)
+		return
+	}
+
+	if data.Close <= 0 {
+		tick, err := market.FetchTick(data.Symbol)
+		if err != nil {
+			http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusBadGateway)
+			return
+		}
+		data.Open, data.High, data.Low, data.Close = tick.Open, tick.High, tick.Low, tick.Close
+		data.Volume = tick.Volume
+		data.Timestamp = tick.Timestamp
+	}
+	if data.Timestamp.IsZero() {
+		data.Timestamp = time.Now()
+	}
+
+	result, err := strategyManager.EvaluateStrategies(r.Context(), evaluationHistory(data.Symbol), &data)
+	if err != nil {
+		http.Error(w, `{"error":"`+err.Error()+`"}`, http.StatusInternalServerError)
+		return
+	}
+	respondJSON(w, executionResultResponse(result))
+}
+
+// evaluationHistory 按策略的数据需求获取试算用的历史K线，无法获取时返回nil，策略将在无预热状态下试算
+func evaluationHistory(symbol string) []*strategies.MarketData {
+	req := strategyManager.DataRequirements()
+	if marketProvider == nil || req.MinHistory <= 0 {
+		return nil
+	}
+
+	klines, err := marketProvider.GetHistoricalData(symbol, req.MinHistory)
+	if err != nil {
+		log.Printf("Failed to fetch history for strategy evaluation of %s: %v", symbol, err)
+		return nil
+	}
+	history := make([]*strategies.MarketData, 0, len(klines))
+	for _, k := range klines {
+		history = append(history, &strategies.MarketData{
+			Symbol:    symbol,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			Timestamp: k.Timestamp,
+			BarClosed: true,
+		})
+	}
+	return history
+}
+
+// handleStrategyStats 返回执行统计、当前组合方法和最近一次执行结果
+func handleStrategyStats(w http.ResponseWriter, r *http.Request) {
+	if strategyManager == nil {
+		http.Error(w, `{"error":"strategy manager not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	stats := strategyManager.GetStats()
+	var lastResult map[string]interface{}
+	if result := strategyManager.GetLastResult(); result != nil {
+		lastResult = executionResultResponse(result)
+	}
+	stats["last_result"] = lastResult
+	respondJSON(w, stats)
+}
+
+// handleStrategyCombination 运行时切换信号组合方法
+func handleStrategyCombination(w http.ResponseWriter, r *http.Request) {
+	if strategyManager == nil {
+		http.Error(w, `{"error":"strategy manager not initialized"}`, http.StatusServiceUnavailable)
+		return
+	}
+
+	var req struct {
+		Method strategies.SignalCombination `json:"method"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, `{"error":"invalid request body"}`, http.StatusBadRequest)
+		return
+	}
+
+	if err := strategyManager.SetCombinationMethod(req.Method); err != nil {
+		http.Error(w, `{"error":"method must be one of vote, weighted, priority, consensus"}`, http.StatusBadRequest)
+		return
+	}
+	respondJSON(w, map[string]interface{}{
+		"status": "updated",
+		"method": strategyManager.GetCombinationMethod(),
+	})
+}
+
+// executionResultResponse 将执行结果转换为响应，错误转为字符串以便序列化
+func executionResultResponse(result *strategies.StrategyExecutionResult) map[string]interface{} {
+	errs := make([]string, 0, len(result.Errors))
+	for _, err := range result.Errors {
+		errs = append(errs, err.Error())
+	}
+	response := map[string]interface{}{
+		"timestamp":      result.Timestamp,
+		"duration_ms":    result.Duration,
+		"signals":        result.Signals,
+		"strategy_count": result.StrategyCount,
+		"skipped_count":  result.SkippedCount,
+		"errors":         errs,
+	}
+	if result.Error != nil {
+		response["error"] = result.Error.Error()
+	}
+	return response
+}
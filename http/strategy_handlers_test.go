@@ -0,0 +1,165 @@
+package http
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"cloudquant/trading/strategies"
+)
+
+func TestStrategyReloadHandler(t *testing.T) {
+	savedManager, savedSource := strategyManager, strategyConfigSource
+	defer func() {
+		SetStrategyManager(savedManager)
+		SetStrategyConfigSource(savedSource)
+	}()
+	SetStrategyManager(nil)
+	SetStrategyConfigSource(nil)
+
+	mux := http.NewServeMux()
+	RegisterStrategyHandlers(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/strategies/reload", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("without manager got status %d, want 503", rr.Code)
+	}
+
+	loader := strategies.NewStrategyLoader()
+	if err := loader.LoadStrategies([]strategies.StrategyConfig{
+		{Name: "ma", Type: strategies.MAStrategyType, Enabled: true, Weight: 0.5},
+	}); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+	SetStrategyManager(strategies.NewStrategyManager(loader, strategies.WeightedCombination))
+
+	body := `{"strategies":[
+		{"name":"ma","type":"ma","enabled":true,"weight":0.6},
+		{"name":"rsi","type":"rsi","enabled":true,"weight":0.4,"close_only":true}
+	]}`
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/strategies/reload", strings.NewReader(body)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("reload got status %d: %s", rr.Code, rr.Body.String())
+	}
+	var resp struct {
+		StrategyCount int `json:"strategy_count"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decode response: %v", err)
+	}
+	if resp.StrategyCount != 2 || !loader.IsCloseOnly("rsi") {
+		t.Errorf("after reload got %d strategies (rsi close_only %v), want 2", resp.StrategyCount, loader.IsCloseOnly("rsi"))
+	}
+
+	// 请求体为空时从配置源读取
+	SetStrategyConfigSource(func() ([]strategies.StrategyConfig, error) {
+		return []strategies.StrategyConfig{{Name: "ma", Type: strategies.MAStrategyType, Enabled: true, Weight: 1}}, nil
+	})
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/strategies/reload", nil))
+	if rr.Code != http.StatusOK || loader.GetStrategyCount() != 1 {
+		t.Errorf("reload from config source got status %d and %d strategies", rr.Code, loader.GetStrategyCount())
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/strategies/reload", strings.NewReader(`{"strategies":[{"name":"x","type":"missing","weight":0.5}]}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("invalid config got status %d, want 400", rr.Code)
+	}
+}
+
+// buySignalStrategy 对任意K线发出买入信号
+type buySignalStrategy struct {
+	*strategies.BaseStrategy
+}
+
+func (b *buySignalStrategy) GenerateSignal(ctx context.Context, data *strategies.MarketData) (*strategies.Signal, error) {
+	return &strategies.Signal{
+		Symbol:     data.Symbol,
+		SignalType: "buy",
+		Strength:   0.8,
+		Price:      data.Close,
+		Timestamp:  data.Timestamp,
+		Metadata:   map[string]interface{}{},
+	}, nil
+}
+
+func TestStrategyExecuteStatsAndCombinationHandlers(t *testing.T) {
+	saved := strategyManager
+	defer SetStrategyManager(saved)
+
+	loader := strategies.NewStrategyLoader()
+	loader.RegisterFactory("buy", func() strategies.Strategy {
+		return &buySignalStrategy{BaseStrategy: strategies.NewBaseStrategy("buy", 0.5)}
+	})
+	if err := loader.LoadStrategies([]strategies.StrategyConfig{
+		{Name: "buy_a", Type: "buy", Enabled: true, Weight: 0.5},
+		{Name: "buy_b", Type: "buy", Enabled: true, Weight: 0.5},
+	}); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+	SetStrategyManager(strategies.NewStrategyManager(loader, strategies.WeightedCombination))
+
+	mux := http.NewServeMux()
+	RegisterStrategyHandlers(mux)
+
+	rr := httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/strategies/stats", nil))
+	if rr.Code != http.StatusOK || !strings.Contains(rr.Body.String(), `"last_result":null`) {
+		t.Errorf("stats before execution got %d: %s", rr.Code, rr.Body.String())
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/strategies/combination", strings.NewReader(`{"method":"best"}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("unknown method got status %d, want 400", rr.Code)
+	}
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/strategies/combination", strings.NewReader(`{"method":"vote"}`)))
+	if rr.Code != http.StatusOK || strategyManager.GetCombinationMethod() != strategies.VoteCombination {
+		t.Errorf("set combination got status %d, method %s", rr.Code, strategyManager.GetCombinationMethod())
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/strategies/execute", strings.NewReader(`{"close":10}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("execute without symbol got status %d, want 400", rr.Code)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("POST", "/api/strategies/execute",
+		strings.NewReader(`{"symbol":"sh600000","close":10.5,"bar_closed":true}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("execute got status %d: %s", rr.Code, rr.Body.String())
+	}
+	var result struct {
+		Signals       []strategies.Signal `json:"signals"`
+		StrategyCount int                 `json:"strategy_count"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &result); err != nil {
+		t.Fatalf("decode execute response: %v", err)
+	}
+	if result.StrategyCount != 2 || len(result.Signals) != 1 || result.Signals[0].SignalType != "buy" {
+		t.Errorf("execute got %d strategies and signals %+v, want one combined buy", result.StrategyCount, result.Signals)
+	}
+
+	rr = httptest.NewRecorder()
+	mux.ServeHTTP(rr, httptest.NewRequest("GET", "/api/strategies/stats", nil))
+	var stats struct {
+		ExecutionCount  int                    `json:"execution_count"`
+		CombinationType string                 `json:"combination_type"`
+		LastResult      map[string]interface{} `json:"last_result"`
+	}
+	if err := json.Unmarshal(rr.Body.Bytes(), &stats); err != nil {
+		t.Fatalf("decode stats response: %v", err)
+	}
+	// 试算不计入实盘策略的执行统计
+	if stats.ExecutionCount != 0 || stats.CombinationType != "vote" || stats.LastResult != nil {
+		t.Errorf("stats after evaluation = %+v", stats)
+	}
+}
@@ -3,10 +3,12 @@ package http
 import (
     "context"
     "encoding/json"
+    "errors"
     "fmt"
     "log"
     "net/http"
     "strconv"
+    "sync"
     "time"
 
     "cloudquant/trading"
@@ -19,8 +21,10 @@ var (
     positionManager   *trading.PositionManager
     orderExecutor     *trading.OrderExecutor
     signalHandler     *trading.SignalHandler
+    autoTradeMu       sync.Mutex
     autoTradeEnabled  bool
     autoTradeStopChan chan struct{}
+    autoTradeDone     chan struct{} // 自动交易协程退出时关闭
 )
 
 // SetTradingComponents 设置交易组件
@@ -34,20 +38,25 @@ func SetTradingComponents(th *trading.TradeHistory, bc *trading.BrokerConnector,
     signalHandler = sh
 }
 
-// RegisterTradingHandlers 注册交易相关的路由
+// RegisterTradingHandlers 注册交易相关的路由，下单、撤单和自动交易开关需要API密钥，买卖按IP限流
 func RegisterTradingHandlers(mux *http.ServeMux) {
     mux.HandleFunc("GET /api/trading/portfolio", handlePortfolio)
     mux.HandleFunc("GET /api/trading/balance", handleBalance)
-    mux.HandleFunc("POST /api/trading/buy", handleBuy)
-    mux.HandleFunc("POST /api/trading/sell", handleSell)
-    mux.HandleFunc("POST /api/trading/cancel", handleCancel)
+    mux.HandleFunc("POST /api/trading/buy", requireAPIKey(limitOrderRate(handleBuy)))
+    mux.HandleFunc("POST /api/trading/sell", requireAPIKey(limitOrderRate(handleSell)))
+    mux.HandleFunc("POST /api/trading/cancel", requireAPIKey(handleCancel))
+    mux.HandleFunc("POST /api/trading/bracket", requireAPIKey(limitOrderRate(handleBracket)))
+    mux.HandleFunc("GET /api/trading/bracket", handleBrackets)
+    mux.HandleFunc("GET /api/trading/bracket/{id}", handleBracketStatus)
     mux.HandleFunc("GET /api/trading/orders", handleOrders)
     mux.HandleFunc("GET /api/trading/trades", handleTrades)
     mux.HandleFunc("GET /api/trading/performance", handlePerformance)
     mux.HandleFunc("GET /api/trading/daily_pnl", handleDailyPnL)
     mux.HandleFunc("GET /api/trading/risk", handleRisk)
-    mux.HandleFunc("POST /api/trading/auto_trade/start", handleAutoTradeStart)
-    mux.HandleFunc("POST /api/trading/auto_trade/stop", handleAutoTradeStop)
+    mux.HandleFunc("GET /api/trading/exposure", handleExposure)
+    mux.HandleFunc("GET /api/trading/trailing_stops", handleTrailingStops)
+    mux.HandleFunc("POST /api/trading/auto_trade/start", requireAPIKey(handleAutoTradeStart))
+    mux.HandleFunc("POST /api/trading/auto_trade/stop", requireAPIKey(handleAutoTradeStop))
     mux.HandleFunc("GET /api/trading/auto_trade/status", handleAutoTradeStatus)
 }
 
@@ -97,7 +106,7 @@ func handleBalance(w http.ResponseWriter, r *http.Request) {
     }
 }
 
-// handleBuy 处理买入请求
+// handleBuy 处理买入请求，携带client_order_id时重复提交返回首次的订单ID
 func handleBuy(w http.ResponseWriter, r *http.Request) {
     if orderExecutor == nil {
         http.Error(w, "交易服务未初始化", http.StatusServiceUnavailable)
@@ -105,9 +114,10 @@ func handleBuy(w http.ResponseWriter, r *http.Request) {
     }
 
     var req struct {
-        Symbol string  `json:"symbol"`
-        Price  float64 `json:"price"`
-        Amount float64 `json:"amount"`
+        Symbol        string  `json:"symbol"`
+        Price         float64 `json:"price"`
+        Amount        float64 `json:"amount"`
+        ClientOrderID string  `json:"client_order_id"`
     }
 
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -123,22 +133,23 @@ func handleBuy(w http.ResponseWriter, r *http.Request) {
     ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
     defer cancel()
 
-    orderID, err := orderExecutor.ExecuteBuy(ctx, req.Symbol, req.Price, req.Amount)
+    orderID, duplicate, err := orderExecutor.ExecuteBuyOnce(ctx, req.ClientOrderID, req.Symbol, req.Price, req.Amount)
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        http.Error(w, err.Error(), orderErrorStatus(err))
         return
     }
 
     w.Header().Set("Content-Type", "application/json")
     if err := json.NewEncoder(w).Encode(map[string]interface{}{
-        "success":  true,
-        "order_id": orderID,
+        "success":   true,
+        "order_id":  orderID,
+        "duplicate": duplicate,
     }); err != nil {
         log.Printf("Failed to encode buy response: %v", err)
     }
 }
 
-// handleSell 处理卖出请求
+// handleSell 处理卖出请求，client_order_id的去重规则同买入
 func handleSell(w http.ResponseWriter, r *http.Request) {
     if orderExecutor == nil {
         http.Error(w, "交易服务未初始化", http.StatusServiceUnavailable)
@@ -146,9 +157,10 @@ func handleSell(w http.ResponseWriter, r *http.Request) {
     }
 
     var req struct {
-        Symbol   string  `json:"symbol"`
-        Price    float64 `json:"price"`
-        Quantity int     `json:"quantity"`
+        Symbol        string  `json:"symbol"`
+        Price         float64 `json:"price"`
+        Quantity      int     `json:"quantity"`
+        ClientOrderID string  `json:"client_order_id"`
     }
 
     if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -164,21 +176,117 @@ func handleSell(w http.ResponseWriter, r *http.Request) {
     ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
     defer cancel()
 
-    orderID, err := orderExecutor.ExecuteSell(ctx, req.Symbol, req.Price, req.Quantity)
+    orderID, duplicate, err := orderExecutor.ExecuteSellOnce(ctx, req.ClientOrderID, req.Symbol, req.Price, req.Quantity)
     if err != nil {
-        http.Error(w, err.Error(), http.StatusInternalServerError)
+        http.Error(w, err.Error(), orderErrorStatus(err))
         return
     }
 
     w.Header().Set("Content-Type", "application/json")
     if err := json.NewEncoder(w).Encode(map[string]interface{}{
-        "success":  true,
-        "order_id": orderID,
+        "success":   true,
+        "order_id":  orderID,
+        "duplicate": duplicate,
     }); err != nil {
         log.Printf("Failed to encode sell response: %v", err)
     }
 }
 
+// orderErrorStatus 下单错误对应的HTTP状态码
+func orderErrorStatus(err error) int {
+    if errors.Is(err, trading.ErrClientOrderConflict) {
+        return http.StatusConflict
+    }
+    return http.StatusInternalServerError
+}
+
+// handleBracket 处理括号单请求：限价买入，成交后挂止盈单并监控止损
+func handleBracket(w http.ResponseWriter, r *http.Request) {
+    if orderExecutor == nil {
+        http.Error(w, "交易服务未初始化", http.StatusServiceUnavailable)
+        return
+    }
+
+    var req struct {
+        Symbol     string  `json:"symbol"`
+        Price      float64 `json:"price"`
+        Quantity   int     `json:"quantity"`
+        TakeProfit float64 `json:"take_profit"`
+        StopLoss   float64 `json:"stop_loss"`
+    }
+
+    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+        http.Error(w, "无效的请求体", http.StatusBadRequest)
+        return
+    }
+
+    if req.Symbol == "" || req.Price <= 0 || req.Quantity <= 0 || req.TakeProfit <= 0 || req.StopLoss <= 0 {
+        http.Error(w, "缺少必要参数", http.StatusBadRequest)
+        return
+    }
+
+    ctx, cancel := context.WithTimeout(r.Context(), 30*time.Second)
+    defer cancel()
+
+    entry := trading.Order{
+        Symbol: req.Symbol,
+        Type:   trading.OrderTypeBuy,
+        Price:  req.Price,
+        Amount: req.Quantity,
+    }
+    bracketID, err := orderExecutor.ExecuteBracket(ctx, entry, req.TakeProfit, req.StopLoss)
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(map[string]interface{}{
+        "success":    true,
+        "bracket_id": bracketID,
+    }); err != nil {
+        log.Printf("Failed to encode bracket response: %v", err)
+    }
+}
+
+// handleBrackets 处理括号单列表请求
+func handleBrackets(w http.ResponseWriter, r *http.Request) {
+    if orderExecutor == nil {
+        http.Error(w, "交易服务未初始化", http.StatusServiceUnavailable)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(map[string]interface{}{
+        "success": true,
+        "data":    orderExecutor.ListBrackets(),
+    }); err != nil {
+        log.Printf("Failed to encode brackets response: %v", err)
+    }
+}
+
+// handleBracketStatus 处理括号单状态请求
+func handleBracketStatus(w http.ResponseWriter, r *http.Request) {
+    if orderExecutor == nil {
+        http.Error(w, "交易服务未初始化", http.StatusServiceUnavailable)
+        return
+    }
+
+    status, err := orderExecutor.GetBracketStatus(trading.BracketID(r.PathValue("id")))
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusNotFound)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(map[string]interface{}{
+        "success": true,
+        "data":    status,
+    }); err != nil {
+        log.Printf("Failed to encode bracket status response: %v", err)
+    }
+}
+
 // handleCancel 处理撤单请求
 func handleCancel(w http.ResponseWriter, r *http.Request) {
     if orderExecutor == nil {
@@ -371,18 +479,67 @@ func handleRisk(w http.ResponseWriter, r *http.Request) {
     }
 }
 
+// handleExposure 处理总敞口请求
+func handleExposure(w http.ResponseWriter, r *http.Request) {
+    if riskManager == nil {
+        http.Error(w, "交易服务未初始化", http.StatusServiceUnavailable)
+        return
+    }
+
+    exposure, err := riskManager.GetExposure()
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(map[string]interface{}{
+        "success": true,
+        "data":    exposure,
+    }); err != nil {
+        log.Printf("Failed to encode exposure response: %v", err)
+    }
+}
+
+// handleTrailingStops 处理移动止损状态请求
+func handleTrailingStops(w http.ResponseWriter, r *http.Request) {
+    if riskManager == nil {
+        http.Error(w, "交易服务未初始化", http.StatusServiceUnavailable)
+        return
+    }
+
+    statuses, err := riskManager.GetTrailingStopStatus()
+    if err != nil {
+        http.Error(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+
+    w.Header().Set("Content-Type", "application/json")
+    if err := json.NewEncoder(w).Encode(map[string]interface{}{
+        "success":               true,
+        "trailing_stop_percent": riskManager.GetConfig().TrailingStopPercent,
+        "data":                  statuses,
+    }); err != nil {
+        log.Printf("Failed to encode trailing stop response: %v", err)
+    }
+}
+
 // handleAutoTradeStart 处理启动自动交易
 func handleAutoTradeStart(w http.ResponseWriter, r *http.Request) {
+    autoTradeMu.Lock()
     if autoTradeEnabled {
+        autoTradeMu.Unlock()
         http.Error(w, "自动交易已在运行", http.StatusBadRequest)
         return
     }
 
     autoTradeStopChan = make(chan struct{})
+    autoTradeDone = make(chan struct{})
     autoTradeEnabled = true
 
     // 启动自动交易协程
-    go runAutoTrade()
+    go runAutoTrade(autoTradeStopChan, autoTradeDone)
+    autoTradeMu.Unlock()
 
     w.Header().Set("Content-Type", "application/json")
     if err := json.NewEncoder(w).Encode(map[string]interface{}{
@@ -395,14 +552,11 @@ func handleAutoTradeStart(w http.ResponseWriter, r *http.Request) {
 
 // handleAutoTradeStop 处理停止自动交易
 func handleAutoTradeStop(w http.ResponseWriter, r *http.Request) {
-    if !autoTradeEnabled {
+    if stopAutoTrade() == nil {
         http.Error(w, "自动交易未运行", http.StatusBadRequest)
         return
     }
 
-    close(autoTradeStopChan)
-    autoTradeEnabled = false
-
     w.Header().Set("Content-Type", "application/json")
     if err := json.NewEncoder(w).Encode(map[string]interface{}{
         "success": true,
@@ -415,16 +569,50 @@ func handleAutoTradeStop(w http.ResponseWriter, r *http.Request) {
 // handleAutoTradeStatus 处理自动交易状态
 func handleAutoTradeStatus(w http.ResponseWriter, r *http.Request) {
     w.Header().Set("Content-Type", "application/json")
+    autoTradeMu.Lock()
+    enabled := autoTradeEnabled
+    autoTradeMu.Unlock()
+
     if err := json.NewEncoder(w).Encode(map[string]interface{}{
         "success": true,
-        "enabled": autoTradeEnabled,
+        "enabled": enabled,
     }); err != nil {
         log.Printf("Failed to encode auto trade status response: %v", err)
     }
 }
 
+// stopAutoTrade 通知自动交易协程退出，返回其退出信号；未运行时返回nil
+func stopAutoTrade() <-chan struct{} {
+    autoTradeMu.Lock()
+    defer autoTradeMu.Unlock()
+
+    if !autoTradeEnabled {
+        return nil
+    }
+    close(autoTradeStopChan)
+    autoTradeEnabled = false
+    return autoTradeDone
+}
+
+// StopAutoTrade 停止自动交易并等待正在执行的交易周期完成，ctx到期时返回其错误
+func StopAutoTrade(ctx context.Context) error {
+    done := stopAutoTrade()
+    if done == nil {
+        return nil
+    }
+
+    select {
+    case <-done:
+        return nil
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
 // runAutoTrade 运行自动交易
-func runAutoTrade() {
+func runAutoTrade(stop <-chan struct{}, done chan<- struct{}) {
+    defer close(done)
+
     ticker := time.NewTicker(1 * time.Minute)
     defer ticker.Stop()
 
@@ -432,7 +620,7 @@ func runAutoTrade() {
         select {
         case <-ticker.C:
             executeAutoTradeCycle()
-        case <-autoTradeStopChan:
+        case <-stop:
             return
         }
     }
@@ -467,6 +655,35 @@ func executeAutoTradeCycle() {
         }
     }
 
+    // 检查移动止损
+    if riskManager != nil {
+        trailingSymbols, err := riskManager.CheckTrailingStops(ctx)
+        if err == nil {
+            for _, symbol := range trailingSymbols {
+                if pos, err := positionManager.GetPosition(symbol); err == nil {
+                    _ = orderExecutor.ExecuteStopLoss(ctx, symbol, pos.CurrentPrice)
+                }
+            }
+        }
+    }
+
+    // 检查止盈
+    if riskManager != nil {
+        takeProfitSymbols, err := riskManager.CheckPositionProfit(ctx)
+        if err == nil {
+            for _, symbol := range takeProfitSymbols {
+                if pos, err := positionManager.GetPosition(symbol); err == nil {
+                    _ = orderExecutor.ExecuteTakeProfit(ctx, symbol, pos.CurrentPrice)
+                }
+            }
+        }
+    }
+
+    // 推进括号单
+    if err := orderExecutor.UpdateBrackets(ctx); err != nil {
+        log.Printf("更新括号单失败: %v", err)
+    }
+
     // 3. 更新日度盈亏
     if riskManager != nil {
         _, _ = riskManager.UpdateDailyPnL(ctx)
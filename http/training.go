@@ -19,66 +19,84 @@ type TrainingConfig struct {
 	ModelPath    string
 	MaxTreeDepth int
 	TestRatio    float64
+	// NumTrees 和 FeatureSubsample 仅用于random_forest
+	NumTrees         int
+	FeatureSubsample float64
 }
 
-func trainModel(config TrainingConfig) error {
+// trainModel 在训练集上训练并保存模型，返回模型在留出测试集上的评估结果，测试集为空时返回nil
+func trainModel(config TrainingConfig) (*ml.ClassificationReport, error) {
 	if config.Symbol == "" {
-		return errors.New("symbol is required")
+		return nil, errors.New("symbol is required")
 	}
 	if config.Days <= 0 {
-		return errors.New("days must be positive")
+		return nil, errors.New("days must be positive")
 	}
 	if config.ModelPath == "" {
-		return errors.New("model path is required")
+		return nil, errors.New("model path is required")
 	}
 
-	preprocessor := &ml.DataPreprocessor{}
-	features, err := preprocessor.LoadHistoricalData(config.Symbol, config.Days)
-	if err != nil {
-		return err
-	}
-	if len(features) == 0 {
-		return errors.New("no features extracted")
-	}
 	klines, err := market.FetchHistoricalData(config.Symbol, config.Days)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	labels, err := ml.GenerateLabels(klines, 3)
+	featureVectors, labels, err := ml.BuildDataset(klines, 3)
 	if err != nil {
-		return err
-	}
-
-	if len(labels) != len(features) {
-		minLen := len(labels)
-		if len(features) < minLen {
-			minLen = len(features)
-		}
-		features = features[:minLen]
-		labels = labels[:minLen]
-	}
-
-	featureVectors := make([][]float64, len(features))
-	for i, feature := range features {
-		featureVectors[i] = ml.FeatureVector(feature)
+		return nil, err
 	}
 
 	trainX, trainY, testX, testY := splitDataset(featureVectors, labels, config.TestRatio)
 
-	model := ml.NewDecisionTree(config.MaxTreeDepth)
+	model, err := ml.NewModel(config.ModelType, ml.ModelConfig{
+		MaxDepth:         config.MaxTreeDepth,
+		NumTrees:         config.NumTrees,
+		FeatureSubsample: config.FeatureSubsample,
+	})
+	if err != nil {
+		return nil, err
+	}
 	if err := model.Train(trainX, trainY); err != nil {
-		return err
+		return nil, err
+	}
+
+	var report *ml.ClassificationReport
+	if len(testX) > 0 {
+		report = ml.EvaluateClassifier(model, testX, testY, ml.ClassLabels)
 	}
 
 	if err := os.MkdirAll(filepath.Dir(config.ModelPath), 0o755); err != nil {
-		return err
+		return nil, err
 	}
 	if err := model.Save(config.ModelPath); err != nil {
-		return err
+		return nil, err
 	}
+	return report, nil
+}
 
-	_, _ = testX, testY
-	return nil
+// evaluationResponse 将测试集评估结果转换为响应
+func evaluationResponse(report *ml.ClassificationReport) map[string]interface{} {
+	classes := make([]map[string]interface{}, 0, len(report.Classes))
+	for _, class := range report.Classes {
+		classes = append(classes, map[string]interface{}{
+			"label":     class.Label,
+			"signal":    ml.LabelName(class.Label),
+			"precision": class.Precision,
+			"recall":    class.Recall,
+			"f1":        class.F1,
+			"support":   class.Support,
+		})
+	}
+	return map[string]interface{}{
+		"test_samples":    report.Total,
+		"failed":          report.Failed,
+		"accuracy":        report.Accuracy,
+		"macro_precision": report.MacroPrecision,
+		"macro_recall":    report.MacroRecall,
+		"macro_f1":        report.MacroF1,
+		"labels":          report.Labels,
+		"confusion":       report.Confusion,
+		"classes":         classes,
+	}
 }
 
 func splitDataset(features [][]float64, labels []int, testRatio float64) (trainX [][]float64, trainY []int, testX [][]float64, testY []int) {
@@ -0,0 +1,82 @@
+package http
+
+import (
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloudquant/market"
+)
+
+func TestHandleTrainReportsHeldOutEvaluation(t *testing.T) {
+	market.SetHistoricalDataFetcher(func(symbol string, days int) ([]market.KLine, error) {
+		start := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+		klines := make([]market.KLine, days)
+		for i := range klines {
+			price := 10 + 2*math.Sin(float64(i)/5)
+			klines[i] = market.KLine{
+				Symbol:    symbol,
+				Open:      price,
+				High:      price * 1.01,
+				Low:       price * 0.99,
+				Close:     price,
+				Volume:    int64(1000 + 100*(i%7)),
+				Timestamp: start.AddDate(0, 0, i),
+			}
+		}
+		return klines, nil
+	})
+	defer market.SetHistoricalDataFetcher(nil)
+
+	SetTrainingConfig(TrainingConfig{
+		Symbol:       "sh600000",
+		Days:         200,
+		ModelType:    "decision_tree",
+		ModelPath:    filepath.Join(t.TempDir(), "model.json"),
+		MaxTreeDepth: 4,
+		TestRatio:    0.25,
+	})
+	defer SetTrainingConfig(TrainingConfig{})
+
+	w := httptest.NewRecorder()
+	handleTrain(w, httptest.NewRequest(http.MethodPost, "/api/train", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+
+	var payload struct {
+		Status     string `json:"status"`
+		Evaluation struct {
+			TestSamples int                     `json:"test_samples"`
+			Accuracy    float64                 `json:"accuracy"`
+			Confusion   [][]int                 `json:"confusion"`
+			Classes     []struct{ Support int } `json:"classes"`
+		} `json:"evaluation"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &payload); err != nil {
+		t.Fatalf("invalid json: %v", err)
+	}
+	eval := payload.Evaluation
+	if payload.Status != "training_completed" || eval.TestSamples == 0 {
+		t.Fatalf("response = %s, want evaluation on the held-out split", w.Body.String())
+	}
+
+	// 混淆矩阵和各类别样本数都只统计测试集
+	var confused, support int
+	for i, row := range eval.Confusion {
+		for _, count := range row {
+			confused += count
+		}
+		support += eval.Classes[i].Support
+	}
+	if confused != eval.TestSamples || support != eval.TestSamples {
+		t.Errorf("confusion total %d, support %d, want %d test samples", confused, support, eval.TestSamples)
+	}
+	if eval.Accuracy < 0 || eval.Accuracy > 1 {
+		t.Errorf("accuracy = %.3f, want within [0, 1]", eval.Accuracy)
+	}
+}
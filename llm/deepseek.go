@@ -1,13 +1,18 @@
 package llm
 
 import (
+    "bufio"
     "bytes"
     "context"
     "encoding/json"
     "errors"
     "fmt"
+    "io"
+    "math/rand"
     "net/http"
+    "strconv"
     "strings"
+    "sync"
     "time"
 
     "cloudquant/market"
@@ -17,8 +22,36 @@ type DeepSeekAnalyzer struct {
     apiKey    string
     model     string
     client    *http.Client
+    stream    *http.Client // 流式请求的客户端：timeout只限制等待响应头，读取回复由ctx控制
     baseURL   string
     maxTokens int
+
+    maxRetries int           // 429/5xx/网络错误的最大重试次数，0表示不重试
+    retryBase  time.Duration // 首次重试的退避时间，之后指数增长
+
+    streamMu   sync.Mutex
+    streamErrs map[<-chan string]error // 出错结束的流式通道及其错误，由StreamErr取出
+}
+
+// maxRetryDelay 单次重试等待的上限
+const maxRetryDelay = 30 * time.Second
+
+// Option DeepSeekAnalyzer的可选配置
+type Option func(*DeepSeekAnalyzer)
+
+// WithRetry 对429、5xx和网络错误最多重试max次，等待时间从base开始指数退避并加入随机抖动；
+// 429响应带Retry-After时以其为准
+func WithRetry(max int, base time.Duration) Option {
+    return func(d *DeepSeekAnalyzer) {
+        if max < 0 {
+            max = 0
+        }
+        if base <= 0 {
+            base = 500 * time.Millisecond
+        }
+        d.maxRetries = max
+        d.retryBase = base
+    }
 }
 
 type AnalysisResult struct {
@@ -29,17 +62,24 @@ type AnalysisResult struct {
     Reason string `json:"reason"`
 }
 
-func NewDeepSeekAnalyzer(apiKey, model string, timeout time.Duration, maxTokens int) *DeepSeekAnalyzer {
+func NewDeepSeekAnalyzer(apiKey, model string, timeout time.Duration, maxTokens int, opts ...Option) *DeepSeekAnalyzer {
     if timeout <= 0 {
         timeout = 10 * time.Second
     }
-    return &DeepSeekAnalyzer{
+    transport := http.DefaultTransport.(*http.Transport).Clone()
+    transport.ResponseHeaderTimeout = timeout
+    analyzer := &DeepSeekAnalyzer{
         apiKey:    apiKey,
         model:     model,
         client:    &http.Client{Timeout: timeout},
+        stream:    &http.Client{Transport: transport},
         baseURL:   "https://api.deepseek.com/chat/completions",
         maxTokens: maxTokens,
     }
+    for _, opt := range opts {
+        opt(analyzer)
+    }
+    return analyzer
 }
 
 func (d *DeepSeekAnalyzer) Analyze(ctx context.Context, kline market.KLine, indicator market.Indicator) (*AnalysisResult, error) {
@@ -83,11 +123,114 @@ func (d *DeepSeekAnalyzer) Analyze(ctx context.Context, kline market.KLine, indi
 }
 
 func (d *DeepSeekAnalyzer) AnalyzePrompt(ctx context.Context, prompt string) (string, error) {
-    if d == nil || d.client == nil {
-        return "", errors.New("deepseek analyzer not configured")
+    payload, err := d.buildPayload(prompt, false)
+    if err != nil {
+        return "", err
+    }
+
+    var content string
+    err = d.withRetry(ctx, func() (time.Duration, error) {
+        var retryAfter time.Duration
+        var err error
+        content, retryAfter, err = d.doRequest(ctx, payload)
+        return retryAfter, err
+    })
+    if err != nil {
+        return "", err
+    }
+    return content, nil
+}
+
+// AnalyzePromptStream 以SSE流式调用DeepSeek，按到达顺序逐段输出回复内容。
+// 建立连接失败时按重试配置重试并返回错误；连接建立后回复的读取时长只受ctx限制，
+// 流结束、出错或ctx取消时关闭通道，通道关闭后通过 StreamErr 获取结束原因
+func (d *DeepSeekAnalyzer) AnalyzePromptStream(ctx context.Context, prompt string) (<-chan string, error) {
+    payload, err := d.buildPayload(prompt, true)
+    if err != nil {
+        return nil, err
+    }
+
+    var resp *http.Response
+    err = d.withRetry(ctx, func() (time.Duration, error) {
+        var retryAfter time.Duration
+        var err error
+        resp, retryAfter, err = d.post(ctx, d.stream, payload)
+        return retryAfter, err
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    chunks := make(chan string, 16)
+    go func() {
+        err := readStream(ctx, resp.Body, chunks)
+        resp.Body.Close()
+        // 错误在关闭通道前记录，读完通道的调用方一定能取到
+        if err != nil {
+            d.streamMu.Lock()
+            if d.streamErrs == nil {
+                d.streamErrs = make(map[<-chan string]error)
+            }
+            d.streamErrs[chunks] = fmt.Errorf("deepseek stream: %w", err)
+            d.streamMu.Unlock()
+        }
+        close(chunks)
+    }()
+    return chunks, nil
+}
+
+// StreamErr 返回 AnalyzePromptStream 通道的结束原因：读取出错或ctx取消时返回错误，
+// 正常收到结束标记时返回nil。应在通道关闭后调用，错误取出后即清除
+func (d *DeepSeekAnalyzer) StreamErr(chunks <-chan string) error {
+    d.streamMu.Lock()
+    defer d.streamMu.Unlock()
+
+    err := d.streamErrs[chunks]
+    delete(d.streamErrs, chunks)
+    return err
+}
+
+// readStream 解析SSE数据行，将每个增量内容写入chunks，收到[DONE]时结束
+func readStream(ctx context.Context, body io.Reader, chunks chan<- string) error {
+    scanner := bufio.NewScanner(body)
+    scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        if !strings.HasPrefix(line, "data:") {
+            continue
+        }
+        data := strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+        if data == "[DONE]" {
+            return nil
+        }
+
+        var chunk deepSeekStreamChunk
+        if err := json.Unmarshal([]byte(data), &chunk); err != nil {
+            return fmt.Errorf("invalid stream chunk: %w", err)
+        }
+        if len(chunk.Choices) == 0 || chunk.Choices[0].Delta.Content == "" {
+            continue
+        }
+
+        select {
+        case chunks <- chunk.Choices[0].Delta.Content:
+        case <-ctx.Done():
+            return ctx.Err()
+        }
+    }
+    if err := scanner.Err(); err != nil {
+        return err
+    }
+    return io.ErrUnexpectedEOF
+}
+
+// buildPayload 校验配置并构建请求体
+func (d *DeepSeekAnalyzer) buildPayload(prompt string, stream bool) ([]byte, error) {
+    if d == nil || d.client == nil || d.stream == nil {
+        return nil, errors.New("deepseek analyzer not configured")
     }
     if d.apiKey == "" {
-        return "", errors.New("deepseek api key is required")
+        return nil, errors.New("deepseek api key is required")
     }
     if d.model == "" {
         d.model = "deepseek-chat"
@@ -101,42 +244,122 @@ func (d *DeepSeekAnalyzer) AnalyzePrompt(ctx context.Context, prompt string) (st
         }},
         MaxTokens:   d.maxTokens,
         Temperature: 0.2,
+        Stream:      stream,
     }
-    payload, err := json.Marshal(requestBody)
-    if err != nil {
-        return "", err
+    return json.Marshal(requestBody)
+}
+
+// withRetry 执行call，遇到可重试错误时按退避策略重试，等待期间响应ctx取消
+func (d *DeepSeekAnalyzer) withRetry(ctx context.Context, call func() (time.Duration, error)) error {
+    for attempt := 0; ; attempt++ {
+        retryAfter, err := call()
+        if err == nil {
+            return nil
+        }
+
+        var retryable *retryableError
+        if !errors.As(err, &retryable) || attempt >= d.maxRetries || ctx.Err() != nil {
+            if attempt > 0 {
+                return fmt.Errorf("%w (after %d retries)", err, attempt)
+            }
+            return err
+        }
+
+        delay := retryAfter
+        if delay <= 0 {
+            delay = d.backoff(attempt)
+        }
+        timer := time.NewTimer(delay)
+        select {
+        case <-ctx.Done():
+            timer.Stop()
+            return ctx.Err()
+        case <-timer.C:
+        }
     }
+}
+
+// retryableError 可重试的请求错误：限流、服务端错误或网络错误
+type retryableError struct {
+    err error
+}
 
+func (e *retryableError) Error() string { return e.err.Error() }
+
+func (e *retryableError) Unwrap() error { return e.err }
+
+// post 发送请求，成功时返回响应（调用方负责关闭），失败时返回服务端要求的重试等待时间和错误
+func (d *DeepSeekAnalyzer) post(ctx context.Context, client *http.Client, payload []byte) (*http.Response, time.Duration, error) {
     req, err := http.NewRequestWithContext(ctx, http.MethodPost, d.baseURL, bytes.NewReader(payload))
     if err != nil {
-        return "", err
+        return nil, 0, err
     }
     req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", d.apiKey))
     req.Header.Set("Content-Type", "application/json")
 
     // #nosec G107 -- External API call to DeepSeek is intentional and uses configured timeout
-    resp, err := d.client.Do(req)
+    resp, err := client.Do(req)
     if err != nil {
-        return "", err
+        if ctx.Err() != nil {
+            return nil, 0, err
+        }
+        return nil, 0, &retryableError{err: err}
     }
-    defer resp.Body.Close()
 
     if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+        defer resp.Body.Close()
+        err := fmt.Errorf("deepseek api returned status %d", resp.StatusCode)
         var apiErr deepSeekErrorResponse
-        if err := json.NewDecoder(resp.Body).Decode(&apiErr); err == nil && apiErr.Error.Message != "" {
-            return "", fmt.Errorf("deepseek api error: %s", apiErr.Error.Message)
+        if decodeErr := json.NewDecoder(resp.Body).Decode(&apiErr); decodeErr == nil && apiErr.Error.Message != "" {
+            err = fmt.Errorf("deepseek api error: %s", apiErr.Error.Message)
+        }
+        if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+            return nil, parseRetryAfter(resp.Header.Get("Retry-After")), &retryableError{err: err}
         }
-        return "", fmt.Errorf("deepseek api returned status %d", resp.StatusCode)
+        return nil, 0, err
+    }
+    return resp, 0, nil
+}
+
+// doRequest 发送一次非流式请求并解析回复内容
+func (d *DeepSeekAnalyzer) doRequest(ctx context.Context, payload []byte) (string, time.Duration, error) {
+    resp, retryAfter, err := d.post(ctx, d.client, payload)
+    if err != nil {
+        return "", retryAfter, err
     }
+    defer resp.Body.Close()
 
     var apiResp deepSeekResponse
     if err := json.NewDecoder(resp.Body).Decode(&apiResp); err != nil {
-        return "", err
+        return "", 0, err
     }
     if len(apiResp.Choices) == 0 {
-        return "", errors.New("deepseek api returned empty response")
+        return "", 0, errors.New("deepseek api returned empty response")
+    }
+    return cleanDeepSeekContent(apiResp.Choices[0].Message.Content), 0, nil
+}
+
+// backoff 第attempt次重试前的等待时间：retryBase*2^attempt，在[50%, 100%]间随机抖动
+func (d *DeepSeekAnalyzer) backoff(attempt int) time.Duration {
+    delay := d.retryBase << uint(attempt)
+    if delay <= 0 || delay > maxRetryDelay {
+        delay = maxRetryDelay
+    }
+    // #nosec G404 -- 抖动不涉及安全
+    return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// parseRetryAfter 解析Retry-After头（秒数），无法解析时返回0
+func parseRetryAfter(value string) time.Duration {
+    seconds, err := strconv.Atoi(strings.TrimSpace(value))
+    if err != nil || seconds <= 0 {
+        return 0
+    }
+    delay := time.Duration(seconds) * time.Second
+    if delay > maxRetryDelay {
+        delay = maxRetryDelay
     }
-    return cleanDeepSeekContent(apiResp.Choices[0].Message.Content), nil
+    return delay
 }
 
 type deepSeekMessage struct {
@@ -149,6 +372,7 @@ type deepSeekRequest struct {
     Messages    []deepSeekMessage `json:"messages"`
     MaxTokens   int               `json:"max_tokens,omitempty"`
     Temperature float64           `json:"temperature,omitempty"`
+    Stream      bool              `json:"stream,omitempty"`
 }
 
 type deepSeekResponse struct {
@@ -157,6 +381,12 @@ type deepSeekResponse struct {
     } `json:"choices"`
 }
 
+type deepSeekStreamChunk struct {
+    Choices []struct {
+        Delta deepSeekMessage `json:"delta"`
+    } `json:"choices"`
+}
+
 type deepSeekErrorResponse struct {
     Error struct {
         Message string `json:"message"`
@@ -172,10 +402,8 @@ func cleanDeepSeekContent(content string) string {
 }
 
 func parseAnalysisResult(content string) (*AnalysisResult, error) {
-    trimmed := cleanDeepSeekContent(content)
-
     var result AnalysisResult
-    if err := json.Unmarshal([]byte(trimmed), &result); err != nil {
+    if err := UnmarshalJSONResponse(content, &result); err != nil {
         return nil, err
     }
     return &result, nil
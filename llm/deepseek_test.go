@@ -0,0 +1,233 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// flakyServer 前failures次请求返回status，之后返回正常回复
+func flakyServer(t *testing.T, failures int32, status int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= failures {
+			w.WriteHeader(status)
+			json.NewEncoder(w).Encode(map[string]interface{}{"error": map[string]string{"message": "rate limited"}})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"choices": []map[string]interface{}{{"message": map[string]string{"role": "assistant", "content": "ok"}}},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server, &calls
+}
+
+func newTestAnalyzer(url string, opts ...Option) *DeepSeekAnalyzer {
+	analyzer := NewDeepSeekAnalyzer("key", "deepseek-chat", time.Second, 100, opts...)
+	analyzer.baseURL = url
+	return analyzer
+}
+
+func TestAnalyzePromptRetriesTransientErrors(t *testing.T) {
+	server, calls := flakyServer(t, 2, http.StatusTooManyRequests)
+
+	content, err := newTestAnalyzer(server.URL, WithRetry(3, time.Millisecond)).AnalyzePrompt(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("AnalyzePrompt: %v", err)
+	}
+	if content != "ok" || calls.Load() != 3 {
+		t.Errorf("got %q after %d calls, want ok after 3", content, calls.Load())
+	}
+}
+
+func TestAnalyzePromptGivesUpAfterMaxRetries(t *testing.T) {
+	server, calls := flakyServer(t, 10, http.StatusServiceUnavailable)
+
+	_, err := newTestAnalyzer(server.URL, WithRetry(2, time.Millisecond)).AnalyzePrompt(context.Background(), "prompt")
+	if err == nil || !strings.Contains(err.Error(), "after 2 retries") {
+		t.Fatalf("err = %v, want failure after 2 retries", err)
+	}
+	if calls.Load() != 3 {
+		t.Errorf("made %d calls, want 3", calls.Load())
+	}
+
+	// 未配置重试时只请求一次
+	server, calls = flakyServer(t, 10, http.StatusServiceUnavailable)
+	if _, err := newTestAnalyzer(server.URL).AnalyzePrompt(context.Background(), "prompt"); err == nil {
+		t.Fatal("expected error without retries")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("made %d calls without retries, want 1", calls.Load())
+	}
+}
+
+func TestAnalyzePromptDoesNotRetryClientErrors(t *testing.T) {
+	server, calls := flakyServer(t, 10, http.StatusUnauthorized)
+
+	if _, err := newTestAnalyzer(server.URL, WithRetry(3, time.Millisecond)).AnalyzePrompt(context.Background(), "prompt"); err == nil {
+		t.Fatal("expected error for 401")
+	}
+	if calls.Load() != 1 {
+		t.Errorf("made %d calls for a 401, want 1", calls.Load())
+	}
+}
+
+func TestAnalyzePromptStopsRetryingOnCancel(t *testing.T) {
+	server, calls := flakyServer(t, 10, http.StatusBadGateway)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := newTestAnalyzer(server.URL, WithRetry(5, time.Hour)).AnalyzePrompt(ctx, "prompt")
+	if err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want context.DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("cancellation took %s", elapsed)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("made %d calls, want 1 before the backoff was cancelled", calls.Load())
+	}
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	if got := parseRetryAfter("2"); got != 2*time.Second {
+		t.Errorf("parseRetryAfter(2) = %s, want 2s", got)
+	}
+	if got := parseRetryAfter("600"); got != maxRetryDelay {
+		t.Errorf("parseRetryAfter(600) = %s, want capped at %s", got, maxRetryDelay)
+	}
+	if got := parseRetryAfter("Wed, 21 Oct 2015 07:28:00 GMT"); got != 0 {
+		t.Errorf("parseRetryAfter(date) = %s, want 0", got)
+	}
+}
+
+func TestAnalyzePromptStreamEmitsChunks(t *testing.T) {
+	var streamed atomic.Bool
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		var body deepSeekRequest
+		if err := json.NewDecoder(r.Body).Decode(&body); err == nil && body.Stream {
+			streamed.Store(true)
+		}
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		flusher := w.(http.Flusher)
+		for _, piece := range []string{"市场", "", "趋势", "向上"} {
+			chunk, _ := json.Marshal(map[string]interface{}{
+				"choices": []map[string]interface{}{{"delta": map[string]string{"content": piece}}},
+			})
+			w.Write([]byte("data: " + string(chunk) + "\n\n"))
+			flusher.Flush()
+		}
+		w.Write([]byte(": keep-alive\n\ndata: [DONE]\n\n"))
+	}))
+	defer server.Close()
+
+	analyzer := newTestAnalyzer(server.URL, WithRetry(1, time.Millisecond))
+	chunks, err := analyzer.AnalyzePromptStream(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("AnalyzePromptStream: %v", err)
+	}
+
+	var got []string
+	for chunk := range chunks {
+		got = append(got, chunk)
+	}
+	if strings.Join(got, "|") != "市场|趋势|向上" {
+		t.Errorf("chunks = %q, want 市场|趋势|向上", got)
+	}
+	if err := analyzer.StreamErr(chunks); err != nil {
+		t.Errorf("stream error = %v, want nil", err)
+	}
+	if !streamed.Load() {
+		t.Error("request did not ask for a streamed response")
+	}
+	if calls.Load() != 2 {
+		t.Errorf("made %d calls, want the 429 retried once", calls.Load())
+	}
+}
+
+func TestAnalyzePromptStreamClosesOnCancel(t *testing.T) {
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`data: {"choices":[{"delta":{"content":"first"}}]}` + "\n\n"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-release:
+		case <-r.Context().Done():
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	analyzer := newTestAnalyzer(server.URL)
+	chunks, err := analyzer.AnalyzePromptStream(ctx, "prompt")
+	if err != nil {
+		t.Fatalf("AnalyzePromptStream: %v", err)
+	}
+	if chunk := <-chunks; chunk != "first" {
+		t.Fatalf("first chunk = %q", chunk)
+	}
+
+	cancel()
+	select {
+	case _, ok := <-chunks:
+		if ok {
+			t.Error("received a chunk after cancellation")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("stream channel not closed after cancellation")
+	}
+	if err := analyzer.StreamErr(chunks); !errors.Is(err, context.Canceled) {
+		t.Errorf("stream error = %v, want context.Canceled", err)
+	}
+}
+
+func TestAnalyzePromptStreamOutlivesRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, piece := range []string{"慢", "速"} {
+			w.Write([]byte(`data: {"choices":[{"delta":{"content":"` + piece + `"}}]}` + "\n\n"))
+			w.(http.Flusher).Flush()
+			time.Sleep(80 * time.Millisecond)
+		}
+		// 未发送[DONE]即断开
+	}))
+	defer server.Close()
+
+	analyzer := NewDeepSeekAnalyzer("key", "deepseek-chat", 50*time.Millisecond, 100)
+	analyzer.baseURL = server.URL
+	chunks, err := analyzer.AnalyzePromptStream(context.Background(), "prompt")
+	if err != nil {
+		t.Fatalf("AnalyzePromptStream: %v", err)
+	}
+
+	var got []string
+	for chunk := range chunks {
+		got = append(got, chunk)
+	}
+	if strings.Join(got, "") != "慢速" {
+		t.Errorf("chunks = %q, want both pieces despite the 50ms request timeout", got)
+	}
+	if err := analyzer.StreamErr(chunks); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("stream error = %v, want io.ErrUnexpectedEOF for a truncated stream", err)
+	}
+	if err := analyzer.StreamErr(chunks); err != nil {
+		t.Errorf("stream error not cleared after retrieval: %v", err)
+	}
+}
@@ -0,0 +1,86 @@
+package llm
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+)
+
+// ErrNoJSONObject LLM回复中没有找到完整的JSON对象
+var ErrNoJSONObject = errors.New("no JSON object found in LLM response")
+
+// ExtractJSONObject 从LLM回复中提取第一个括号配对完整的{...}对象。
+// 回复含markdown代码块时优先在代码块内查找，其次在全文中查找，对象前后的说明文字被忽略
+func ExtractJSONObject(text string) (string, bool) {
+	if fenced, ok := fencedBlock(text); ok {
+		if object, ok := firstBalancedObject(fenced); ok {
+			return object, true
+		}
+	}
+	return firstBalancedObject(text)
+}
+
+// UnmarshalJSONResponse 提取LLM回复中的JSON对象并解析到v
+func UnmarshalJSONResponse(text string, v interface{}) error {
+	object, ok := ExtractJSONObject(text)
+	if !ok {
+		return ErrNoJSONObject
+	}
+	return json.Unmarshal([]byte(object), v)
+}
+
+// fencedBlock 返回第一个```代码块的内容，忽略```json之类的语言标记
+func fencedBlock(text string) (string, bool) {
+	start := strings.Index(text, "```")
+	if start < 0 {
+		return "", false
+	}
+	body := text[start+3:]
+	// 跳过开头的语言标记行
+	if newline := strings.IndexByte(body, '\n'); newline >= 0 && !strings.Contains(body[:newline], "{") {
+		body = body[newline+1:]
+	}
+	end := strings.Index(body, "```")
+	if end < 0 {
+		return body, true
+	}
+	return body[:end], true
+}
+
+// firstBalancedObject 查找第一个{并返回到与之配对的}为止的内容，字符串中的括号和转义字符不参与配对
+func firstBalancedObject(text string) (string, bool) {
+	start := strings.IndexByte(text, '{')
+	if start < 0 {
+		return "", false
+	}
+
+	depth := 0
+	inString := false
+	escaped := false
+	for i := start; i < len(text); i++ {
+		c := text[i]
+		if inString {
+			switch {
+			case escaped:
+				escaped = false
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+		switch c {
+		case '"':
+			inString = true
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return text[start : i+1], true
+			}
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,47 @@
+package llm
+
+import "testing"
+
+func TestExtractJSONObject(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+		ok   bool
+	}{
+		{name: "plain", text: `{"signal": "buy"}`, want: `{"signal": "buy"}`, ok: true},
+		{name: "json fence", text: "```json\n{\"signal\": \"buy\", \"confidence\": 0.8}\n```", want: `{"signal": "buy", "confidence": 0.8}`, ok: true},
+		{name: "bare fence", text: "```\n{\"signal\": \"sell\"}\n```", want: `{"signal": "sell"}`, ok: true},
+		{name: "prefixed", text: "根据分析，结果如下：\n{\"signal\": \"hold\"}\n以上仅供参考", want: `{"signal": "hold"}`, ok: true},
+		{name: "prose braces before fence", text: "说明{见下}：\n```json\n{\"signal\": \"buy\"}\n```", want: `{"signal": "buy"}`, ok: true},
+		{name: "nested and braces in strings", text: "结果: {\"reason\": \"突破{关键}位 \\\"}\\\"\", \"detail\": {\"rsi\": 55}} 完毕", want: `{"reason": "突破{关键}位 \"}\"", "detail": {"rsi": 55}}`, ok: true},
+		{name: "unterminated", text: "```json\n{\"signal\": \"buy\"", ok: false},
+		{name: "no object", text: "建议买入，置信度较高", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ExtractJSONObject(tt.text)
+			if ok != tt.ok || got != tt.want {
+				t.Errorf("ExtractJSONObject(%q) = %q, %v; want %q, %v", tt.text, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSONResponse(t *testing.T) {
+	var result struct {
+		Signal     string  `json:"signal"`
+		Confidence float64 `json:"confidence"`
+	}
+	if err := UnmarshalJSONResponse("好的，以下是分析结果：\n```json\n{\"signal\": \"buy\", \"confidence\": 0.85}\n```", &result); err != nil {
+		t.Fatalf("UnmarshalJSONResponse: %v", err)
+	}
+	if result.Signal != "buy" || result.Confidence != 0.85 {
+		t.Errorf("got %+v, want buy/0.85", result)
+	}
+
+	if err := UnmarshalJSONResponse("建议持有", &result); err != ErrNoJSONObject {
+		t.Errorf("err = %v, want ErrNoJSONObject", err)
+	}
+}
@@ -0,0 +1,13 @@
+package llm
+
+import "strings"
+
+// ContainsAnyKeyword 判断LLM返回文本中是否包含任一关键词
+func ContainsAnyKeyword(text string, keywords []string) bool {
+	for _, keyword := range keywords {
+		if keyword != "" && strings.Contains(text, keyword) {
+			return true
+		}
+	}
+	return false
+}
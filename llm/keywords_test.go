@@ -0,0 +1,26 @@
+package llm
+
+import "testing"
+
+func TestContainsAnyKeyword(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		keywords []string
+		want     bool
+	}{
+		{name: "chinese match", text: "当前属于高风险区域，建议谨慎", keywords: []string{"低风险", "高风险"}, want: true},
+		{name: "chinese no match", text: "走势稳健，可逢低布局", keywords: []string{"卖出", "做空"}, want: false},
+		{name: "partial rune prefix", text: "风险", keywords: []string{"风险较大"}, want: false},
+		{name: "mixed text", text: "AI建议: 买入 (confidence 0.8)", keywords: []string{"买入"}, want: true},
+		{name: "empty keyword ignored", text: "任何文本", keywords: []string{""}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContainsAnyKeyword(tt.text, tt.keywords); got != tt.want {
+				t.Errorf("ContainsAnyKeyword(%q, %v) = %v, want %v", tt.text, tt.keywords, got, tt.want)
+			}
+		})
+	}
+}
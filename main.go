@@ -2,6 +2,8 @@ package main
 
 import (
     "context"
+    "errors"
+    "fmt"
     "log"
     "os"
     "os/signal"
@@ -9,14 +11,20 @@ import (
     "time"
 
     "cloudquant/backtest"
+    cqconfig "cloudquant/config"
     "cloudquant/db"
     cqhttp "cloudquant/http"
     "cloudquant/llm"
+    "cloudquant/market"
     "cloudquant/market/industry"
     "cloudquant/ml"
     "cloudquant/monitoring"
+    "cloudquant/pipeline"
     "cloudquant/trading"
+    "cloudquant/trading/order"
+    "cloudquant/trading/portfolio"
     "cloudquant/trading/risk"
+    "cloudquant/trading/risk/realtime"
     "cloudquant/trading/scheduler"
     "cloudquant/trading/strategies"
     "gopkg.in/yaml.v2"
@@ -30,22 +38,44 @@ type Config struct {
     Http struct {
         Port int `yaml:"port"`
     } `yaml:"http"`
+    Server struct {
+        Auth struct {
+            APIKey string `yaml:"api_key"`
+        } `yaml:"auth"`
+        OrderRateLimit int           `yaml:"order_rate_limit"`
+        ClientOrderTTL time.Duration `yaml:"client_order_ttl"`
+    } `yaml:"server"`
     Log struct {
         Level string `yaml:"level"`
     } `yaml:"log"`
+    Market struct {
+        Storage struct {
+            Enabled        bool          `yaml:"enabled"`
+            Path           string        `yaml:"path"`
+            EnableWAL      bool          `yaml:"enable_wal"`
+            MaxGap         time.Duration `yaml:"max_gap"`
+            IngestInterval time.Duration `yaml:"ingest_interval"` // 从行情数据源摄取日K线写入存储的间隔
+        } `yaml:"storage"`
+        QuoteCacheTTL time.Duration           `yaml:"quote_cache_ttl"`
+        Calendar      backtest.CalendarConfig `yaml:"calendar"`
+    } `yaml:"market"`
     LLM struct {
-        Provider  string        `yaml:"provider"`
-        APIKey    string        `yaml:"api_key"`
-        Model     string        `yaml:"model"`
-        Timeout   time.Duration `yaml:"timeout"`
-        MaxTokens int           `yaml:"max_tokens"`
+        Provider       string        `yaml:"provider"`
+        APIKey         string        `yaml:"api_key"`
+        Model          string        `yaml:"model"`
+        Timeout        time.Duration `yaml:"timeout"`
+        MaxTokens      int           `yaml:"max_tokens"`
+        MaxRetries     int           `yaml:"max_retries"`
+        RetryBaseDelay time.Duration `yaml:"retry_base_delay"`
     } `yaml:"llm"`
     ML struct {
-        ModelType     string `yaml:"model_type"`
-        ModelPath     string `yaml:"model_path"`
-        MaxTreeDepth  int    `yaml:"max_tree_depth"`
-        TrainInterval string `yaml:"train_interval"`
-        Features      struct {
+        ModelType        string  `yaml:"model_type"`
+        ModelPath        string  `yaml:"model_path"`
+        MaxTreeDepth     int     `yaml:"max_tree_depth"`
+        NumTrees         int     `yaml:"num_trees"`
+        FeatureSubsample float64 `yaml:"feature_subsample"`
+        TrainInterval    string  `yaml:"train_interval"`
+        Features         struct {
             LookbackDays  int `yaml:"lookback_days"`
             LookaheadDays int `yaml:"lookahead_days"`
         } `yaml:"features"`
@@ -56,32 +86,49 @@ type Config struct {
     } `yaml:"ml"`
     Trading struct {
         Broker struct {
-            Type     string `yaml:"type"`
-            Service  string `yaml:"service_url"`
-            Broker   string `yaml:"broker_type"`
-            Username string `yaml:"username"`
-            Password string `yaml:"password"`
-            ExePath  string `yaml:"exe_path"`
+            Type        string  `yaml:"type"`
+            Service     string  `yaml:"service_url"`
+            Broker      string  `yaml:"broker_type"`
+            Username    string  `yaml:"username"`
+            Password    string  `yaml:"password"`
+            ExePath     string  `yaml:"exe_path"`
+            InitialCash float64 `yaml:"initial_cash"`
         } `yaml:"broker"`
         Risk struct {
-            InitialCapital    float64 `yaml:"initial_capital"`
-            MaxSinglePosition float64 `yaml:"max_single_position"`
-            MaxPositions      int     `yaml:"max_positions"`
-            MaxDailyLoss      float64 `yaml:"max_daily_loss"`
-            MinOrderAmount    float64 `yaml:"min_order_amount"`
-            StopLossPercent   float64 `yaml:"stop_loss_percent"`
+            InitialCapital      float64 `yaml:"initial_capital"`
+            MaxSinglePosition   float64 `yaml:"max_single_position"`
+            MaxPositions        int     `yaml:"max_positions"`
+            MaxDailyLoss        float64 `yaml:"max_daily_loss"`
+            MinOrderAmount      float64 `yaml:"min_order_amount"`
+            StopLossPercent     float64 `yaml:"stop_loss_percent"`
+            TakeProfitPercent   float64 `yaml:"take_profit_percent"`
+            TrailingStopPercent float64 `yaml:"trailing_stop_percent"`
+            MaxGrossExposure    float64 `yaml:"max_gross_exposure"`
         } `yaml:"risk"`
         AutoTrade struct {
-            Enabled       bool    `yaml:"enabled"`
-            CheckInterval string  `yaml:"check_interval"`
-            AIThreshold   float64 `yaml:"ai_threshold"`
-            MLConfidence  float64 `yaml:"ml_confidence"`
+            Enabled        bool    `yaml:"enabled"`
+            CheckInterval  string  `yaml:"check_interval"`
+            AIThreshold    float64 `yaml:"ai_threshold"`
+            MLConfidence   float64 `yaml:"ml_confidence"`
+            SizingMethod   string  `yaml:"sizing_method"`
+            SizingFraction float64 `yaml:"sizing_fraction"`
+            WinLossRatio   float64 `yaml:"win_loss_ratio"`
         } `yaml:"auto_trade"`
         Strategies []StrategyConfig `yaml:"strategies"`
+        StrategyLatency struct {
+            WindowSize int           `yaml:"window_size"`
+            Threshold  time.Duration `yaml:"threshold"`
+        } `yaml:"strategy_latency"`
+        PerformanceWeighting strategies.PerformanceWeightingConfig `yaml:"performance_weighting"`
+        SignalCombination    string                                `yaml:"signal_combination"`
+        ConsensusThreshold   float64                               `yaml:"consensus_threshold"`
+        ExecutionDrain       order.DrainConfig                     `yaml:"execution_drain"`
         Scheduler  struct {
-            Enabled        bool   `yaml:"enabled"`
-            Interval       string `yaml:"interval"`
-            CronExpression string `yaml:"cron_expression"`
+            Enabled        bool    `yaml:"enabled"`
+            Interval       string  `yaml:"interval"`
+            CronExpression string  `yaml:"cron_expression"`
+            DryRun         bool    `yaml:"dry_run"`
+            OrderAmount    float64 `yaml:"order_amount"`
         } `yaml:"scheduler"`
         PortfolioRisk struct {
             MaxIndustryExposure float64 `yaml:"max_industry_exposure"`
@@ -106,12 +153,14 @@ type Config struct {
         AIRisk struct {
             Enabled           bool          `yaml:"enabled"`
             AnalysisInterval  time.Duration `yaml:"analysis_interval"`
+            MinGlobalInterval time.Duration `yaml:"min_global_interval"`
             CacheExpiry       time.Duration `yaml:"cache_expiry"`
             RiskThreshold     float64       `yaml:"risk_threshold"`
             AutoAlert         bool          `yaml:"auto_alert"`
             DeepLearning      bool          `yaml:"deep_learning"`
             SentimentAnalysis bool          `yaml:"sentiment_analysis"`
             NewsAnalysis      bool          `yaml:"news_analysis"`
+            PersistHistory    bool          `yaml:"persist_history"`
         } `yaml:"ai_risk"`
         Portfolio struct {
             RebalanceFrequency time.Duration `yaml:"rebalance_frequency"`
@@ -120,6 +169,10 @@ type Config struct {
             MaxPositionWeight  float64       `yaml:"max_position_weight"`
             TargetReturn       float64       `yaml:"target_return"`
             RiskFreeRate       float64       `yaml:"risk_free_rate"`
+            InitialCapital     float64       `yaml:"initial_capital"`
+            LotSize            int64         `yaml:"lot_size"`
+            DryRun             bool          `yaml:"dry_run"`
+            HistoryDays        int           `yaml:"history_days"`
         } `yaml:"portfolio"`
         Optimizer struct {
             Method          string  `yaml:"method"`
@@ -132,20 +185,33 @@ type Config struct {
     } `yaml:"trading"`
     Monitoring struct {
         WebSocket struct {
-            Enabled        bool `yaml:"enabled"`
-            Port           int  `yaml:"port"`
-            MaxConnections int  `yaml:"max_connections"`
+            Enabled        bool          `yaml:"enabled"`
+            Port           int           `yaml:"port"`
+            MaxConnections int           `yaml:"max_connections"`
+            SessionTTL     time.Duration `yaml:"session_ttl"`
+            AllowedOrigins []string      `yaml:"allowed_origins"`
         } `yaml:"websocket"`
         Alerts struct {
             Enabled  bool `yaml:"enabled"`
+            Persist  bool `yaml:"persist"`
+            Retry    struct {
+                MaxRetries     int           `yaml:"max_retries"`
+                InitialBackoff time.Duration `yaml:"initial_backoff"`
+                MaxBackoff     time.Duration `yaml:"max_backoff"`
+            } `yaml:"retry"`
             Channels struct {
                 Email struct {
-                    Enabled  bool   `yaml:"enabled"`
-                    SMTPHost string `yaml:"smtp_host"`
-                    SMTPPort int    `yaml:"smtp_port"`
-                    Username string `yaml:"username"`
-                    Password string `yaml:"password"`
-                    To       string `yaml:"to"`
+                    Enabled   bool   `yaml:"enabled"`
+                    SMTPHost  string `yaml:"smtp_host"`
+                    SMTPPort  int    `yaml:"smtp_port"`
+                    Username  string `yaml:"username"`
+                    Password  string `yaml:"password"`
+                    To        string `yaml:"to"`
+                    RateLimit struct {
+                        MaxPerHour int           `yaml:"max_per_hour"`
+                        MaxPerDay  int           `yaml:"max_per_day"`
+                        Cooldown   time.Duration `yaml:"cooldown"`
+                    } `yaml:"rate_limit"`
                 } `yaml:"email"`
                 Feishu struct {
                     Enabled   bool   `yaml:"enabled"`
@@ -165,20 +231,56 @@ type Config struct {
                         Cooldown   time.Duration `yaml:"cooldown"`
                     } `yaml:"rate_limit"`
                 } `yaml:"dingding"`
+                Slack struct {
+                    Enabled   bool     `yaml:"enabled"`
+                    Webhook   string   `yaml:"webhook"`
+                    Fields    []string `yaml:"fields"`
+                    RateLimit struct {
+                        MaxPerHour int           `yaml:"max_per_hour"`
+                        MaxPerDay  int           `yaml:"max_per_day"`
+                        Cooldown   time.Duration `yaml:"cooldown"`
+                    } `yaml:"rate_limit"`
+                } `yaml:"slack"`
+                Webhook struct {
+                    Enabled         bool              `yaml:"enabled"`
+                    URL             string            `yaml:"url"`
+                    PayloadTemplate string            `yaml:"payload_template"`
+                    FieldMap        map[string]string `yaml:"field_map"`
+                    RateLimit       struct {
+                        MaxPerHour int           `yaml:"max_per_hour"`
+                        MaxPerDay  int           `yaml:"max_per_day"`
+                        Cooldown   time.Duration `yaml:"cooldown"`
+                    } `yaml:"rate_limit"`
+                } `yaml:"webhook"`
             } `yaml:"channels"`
         } `yaml:"alerts"`
     } `yaml:"monitoring"`
     Backtest struct {
-        Enabled       bool `yaml:"enabled"`
-        DefaultConfig struct {
+        Enabled        bool `yaml:"enabled"`
+        PersistResults bool `yaml:"persist_results"`
+        MaxSnapshots   int  `yaml:"max_snapshots"`
+        DefaultConfig  struct {
             StartDate        time.Time `yaml:"start_date"`
             EndDate          time.Time `yaml:"end_date"`
             InitialCapital   float64   `yaml:"initial_capital"`
             Commission       float64   `yaml:"commission"`
+            CommissionModel  string    `yaml:"commission_model"`
+            MinCommission    float64   `yaml:"min_commission"`
+            StampTax         float64   `yaml:"stamp_tax"`
+            TransferFee      float64   `yaml:"transfer_fee"`
             Slippage         float64   `yaml:"slippage"`
+            SlippageModel    string    `yaml:"slippage_model"`
+            SlippageImpact   float64   `yaml:"slippage_impact"`
+            MaxSlippage      float64   `yaml:"max_slippage"`
             RiskFreeRate     float64   `yaml:"risk_free_rate"`
             MaxDrawdownLimit float64   `yaml:"max_drawdown_limit"`
+            HaltOnDrawdown   bool      `yaml:"halt_on_drawdown"`
             Realtime         bool      `yaml:"realtime"`
+            SnapshotData     bool      `yaml:"snapshot_data"`
+            BarInterval      string    `yaml:"bar_interval"`
+            RiskWindow       int       `yaml:"risk_window"`
+            LimitOrders      bool      `yaml:"limit_orders"`
+            LimitOrderTTL    int       `yaml:"limit_order_ttl"`
         } `yaml:"default_config"`
         ParameterSearch struct {
             Method        string `yaml:"method"`
@@ -200,6 +302,7 @@ type StrategyConfig struct {
     Enabled    bool                   `yaml:"enabled"`
     Weight     float64                `yaml:"weight"`
     Priority   int                    `yaml:"priority"`
+    CloseOnly  bool                   `yaml:"close_only"`
     Parameters map[string]interface{} `yaml:"parameters"`
 }
 
@@ -212,6 +315,10 @@ var (
     alertSystem      *monitoring.AlertSystem
     backtestEngine   *backtest.BacktestEngine
     llmAnalyzer      *llm.DeepSeekAnalyzer
+    replayEngine     *monitoring.ReplayEngine
+    marketStorage    *pipeline.OptimizedStorage
+    marketIngester   *pipeline.DataIngester
+    marketProvider   *market.MarketProvider
 
     // 传统交易组件
     tradeHistory    *trading.TradeHistory
@@ -221,17 +328,35 @@ var (
     orderExecutor   *trading.OrderExecutor
     signalHandler   *trading.SignalHandler
 
+    // 算法拆单执行组件
+    orderManager    *order.OrderManager
+    executionEngine *order.ExecutionEngine
+
+    // 组合管理组件
+    portfolioManager *portfolio.PortfolioManager
+
     // 风险管理组件
-    aiRisk *risk.AIRisk
+    aiRisk       *risk.AIRisk
+    realtimeRisk *realtime.RealtimeRiskMonitor
+    cooldownRisk *risk.CooldownRisk
 
+    // 风控巡检循环的停止信号和退出信号
+    riskMonitorStop chan struct{}
+    riskMonitorDone chan struct{}
 )
 
+// shutdownTimeout 关闭流程的总超时，超时后剩余步骤以已取消的ctx执行
+const shutdownTimeout = 30 * time.Second
+
 func main() {
     // 1. Load config
     config, err := loadConfig("config.yaml")
     if err != nil {
         log.Fatalf("Failed to load config: %v", err)
     }
+    if err := config.Validate(); err != nil {
+        log.Fatalf("Invalid config.yaml:\n%v", err)
+    }
 
     // 2. Initialize database
     if err := db.InitDB(config.Database.Path); err != nil {
@@ -247,6 +372,14 @@ func main() {
     serverConfig.Timeout = 30 * time.Second
     serverConfig.AllowedOrigins = []string{"*"}
 
+    // 下单等交易写接口和WebSocket需要API密钥
+    if key := config.Server.Auth.APIKey; key != "" {
+        cqhttp.SetAPIKey(key)
+    } else {
+        log.Println("WARNING: server.auth.api_key not set, trading endpoints and WebSocket are unauthenticated")
+    }
+    cqhttp.SetOrderRateLimit(config.Server.OrderRateLimit)
+
     server := cqhttp.NewServer(serverConfig)
     go func() {
         log.Printf("Starting server on http://localhost%s", server.Addr())
@@ -261,14 +394,138 @@ func main() {
     <-quit
     log.Println("Shutting down...")
 
-    // 停止HTTP服务器
-    if err := server.Stop(); err != nil {
-        log.Printf("Server forced to shutdown: %v", err)
+    ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+    defer cancel()
+    if err := shutdownSequenceFor(server).Shutdown(ctx); err != nil {
+        log.Printf("Shutdown completed with errors: %v", err)
     }
 
     log.Println("Exiting")
 }
 
+// shutdownStep 关闭流程中的一步
+type shutdownStep struct {
+    name string
+    fn   func(ctx context.Context) error
+}
+
+// shutdownSequence 按注册顺序依次关闭组件
+type shutdownSequence struct {
+    steps []shutdownStep
+}
+
+// Add 追加一个关闭步骤
+func (s *shutdownSequence) Add(name string, fn func(ctx context.Context) error) {
+    s.steps = append(s.steps, shutdownStep{name: name, fn: fn})
+}
+
+// Shutdown 依次执行各关闭步骤，单步失败不影响后续步骤，返回汇总的错误
+func (s *shutdownSequence) Shutdown(ctx context.Context) error {
+    var errs []error
+    for _, step := range s.steps {
+        start := time.Now()
+        if err := step.fn(ctx); err != nil {
+            log.Printf("Shutdown %s failed: %v", step.name, err)
+            errs = append(errs, fmt.Errorf("%s: %w", step.name, err))
+            continue
+        }
+        log.Printf("Shutdown %s done in %s", step.name, time.Since(start).Round(time.Millisecond))
+    }
+    return errors.Join(errs...)
+}
+
+// shutdownSequenceFor 按依赖关系排列已启动组件的关闭顺序：先停止接收请求，
+// 再停止产生交易的后台任务并等待进行中的周期完成，然后关闭推送和告警，最后断开券商并落盘存储
+func shutdownSequenceFor(server *cqhttp.Server) *shutdownSequence {
+    seq := &shutdownSequence{}
+
+    seq.Add("http server", server.Shutdown)
+    seq.Add("auto trade", cqhttp.StopAutoTrade)
+    if riskMonitorStop != nil {
+        seq.Add("risk monitor loop", func(ctx context.Context) error {
+            close(riskMonitorStop)
+            return waitDone(ctx, riskMonitorDone)
+        })
+    }
+    if taskScheduler != nil {
+        seq.Add("strategy scheduler", taskScheduler.Shutdown)
+    }
+    if executionEngine != nil {
+        seq.Add("execution engine", func(ctx context.Context) error {
+            _, err := executionEngine.Shutdown(ctx)
+            return err
+        })
+    }
+    if orderManager != nil {
+        seq.Add("order manager", func(ctx context.Context) error {
+            done := make(chan struct{})
+            go func() {
+                orderManager.Stop()
+                close(done)
+            }()
+            return waitDone(ctx, done)
+        })
+    }
+    if realtimeRisk != nil {
+        seq.Add("realtime risk monitor", func(ctx context.Context) error {
+            done := make(chan struct{})
+            go func() {
+                realtimeRisk.Stop()
+                close(done)
+            }()
+            return waitDone(ctx, done)
+        })
+    }
+    if monitor != nil {
+        seq.Add("realtime monitor", monitor.Shutdown)
+    }
+    if alertSystem != nil {
+        seq.Add("alert system", func(ctx context.Context) error {
+            return alertSystem.Stop()
+        })
+    }
+    seq.Add("provider health checks", func(ctx context.Context) error {
+        market.DefaultProviderRegistry().StopHealthChecks()
+        return nil
+    })
+    if brokerConnector != nil {
+        seq.Add("broker", func(ctx context.Context) error {
+            return brokerConnector.Disconnect()
+        })
+    }
+    if marketIngester != nil {
+        seq.Add("market data ingestion", func(ctx context.Context) error {
+            marketIngester.Stop()
+            return nil
+        })
+    }
+    if marketStorage != nil {
+        seq.Add("market storage", func(ctx context.Context) error {
+            return marketStorage.Close()
+        })
+    }
+    if tradeHistory != nil {
+        seq.Add("trade history", func(ctx context.Context) error {
+            return tradeHistory.Close()
+        })
+    }
+    seq.Add("database", func(ctx context.Context) error {
+        return db.Close()
+    })
+
+    return seq
+}
+
+// waitDone 等待done关闭，ctx到期时返回其错误
+func waitDone(ctx context.Context, done <-chan struct{}) error {
+    select {
+    case <-done:
+        return nil
+    case <-ctx.Done():
+        return ctx.Err()
+    }
+}
+
 func loadConfig(path string) (*Config, error) {
     file, err := os.Open(path)
     if err != nil {
@@ -280,16 +537,200 @@ func loadConfig(path string) (*Config, error) {
     if err := yaml.NewDecoder(file).Decode(&config); err != nil {
         return nil, err
     }
+    // 密钥等字段可写为 ${VAR}、${VAR:-default} 或 env:VAR，从环境变量读取
+    if err := cqconfig.ExpandEnv(&config); err != nil {
+        return nil, fmt.Errorf("%s:\n%w", path, err)
+    }
     return &config, nil
 }
 
+// configProblems 收集配置校验中发现的问题
+type configProblems []error
+
+// check cond不成立时记录一条问题，field为YAML中的字段路径
+func (p *configProblems) check(cond bool, field, format string, args ...interface{}) {
+    if !cond {
+        *p = append(*p, fmt.Errorf("%s: %s", field, fmt.Sprintf(format, args...)))
+    }
+}
+
+// add 记录子配置自身校验返回的错误
+func (p *configProblems) add(field string, err error) {
+    if err != nil {
+        *p = append(*p, fmt.Errorf("%s: %w", field, err))
+    }
+}
+
+// inUnit 0 <= v <= 1
+func inUnit(v float64) bool {
+    return v >= 0 && v <= 1
+}
+
+// validInterval 空字符串或可解析的正时长
+func validInterval(s string) bool {
+    if s == "" {
+        return true
+    }
+    d, err := time.ParseDuration(s)
+    return err == nil && d > 0
+}
+
+// feasibleWeights n个标的的权重在[min, max]内且总和为1是否可行
+func feasibleWeights(min, max float64, n int) bool {
+    return n == 0 || (min*float64(n) <= 1 && max*float64(n) >= 1)
+}
+
+// Validate 检查取值范围和字段间约束，启动时尽早暴露错误配置；
+// 返回的错误逐行列出全部问题。0通常表示使用默认值或不启用，因此只拒绝负数
+func (c *Config) Validate() error {
+    var p configProblems
+
+    p.check(len(c.Symbols) > 0, "symbols", "at least one symbol is required")
+    for i, symbol := range c.Symbols {
+        p.check(symbol != "", fmt.Sprintf("symbols[%d]", i), "must not be empty")
+    }
+    p.check(c.Database.Path != "", "database.path", "must not be empty")
+    p.check(c.Http.Port > 0 && c.Http.Port <= 65535, "http.port", "must be in 1-65535, got %d", c.Http.Port)
+    p.check(c.Server.OrderRateLimit >= 0, "server.order_rate_limit", "must not be negative")
+    p.check(c.Server.ClientOrderTTL >= 0, "server.client_order_ttl", "must not be negative")
+
+    p.check(c.Market.QuoteCacheTTL >= 0, "market.quote_cache_ttl", "must not be negative")
+    if c.Market.Storage.Enabled {
+        p.check(c.Market.Storage.Path != "", "market.storage.path", "required when storage is enabled")
+    }
+    p.check(c.Market.Storage.MaxGap >= 0, "market.storage.max_gap", "must not be negative")
+    p.check(c.Market.Storage.IngestInterval >= 0, "market.storage.ingest_interval", "must not be negative")
+    if _, err := backtest.NewTradingCalendar(c.Market.Calendar); err != nil {
+        p.add("market.calendar", err)
+    }
+
+    p.check(c.ML.Training.TestRatio >= 0 && c.ML.Training.TestRatio < 1, "ml.training.test_ratio", "must be in [0, 1), got %.2f", c.ML.Training.TestRatio)
+    p.check(inUnit(c.ML.FeatureSubsample), "ml.feature_subsample", "must be in [0, 1], got %.2f", c.ML.FeatureSubsample)
+    p.check(validInterval(c.ML.TrainInterval), "ml.train_interval", "invalid duration %q", c.ML.TrainInterval)
+
+    // 风控阈值
+    r := c.Trading.Risk
+    p.check(r.InitialCapital > 0, "trading.risk.initial_capital", "must be positive, got %.2f", r.InitialCapital)
+    p.check(r.MaxSinglePosition > 0 && r.MaxSinglePosition <= 1, "trading.risk.max_single_position", "must be in (0, 1], got %.2f", r.MaxSinglePosition)
+    p.check(r.MaxPositions > 0, "trading.risk.max_positions", "must be positive, got %d", r.MaxPositions)
+    p.check(inUnit(r.MaxDailyLoss), "trading.risk.max_daily_loss", "must be in [0, 1], got %.2f", r.MaxDailyLoss)
+    p.check(r.MinOrderAmount >= 0, "trading.risk.min_order_amount", "must not be negative")
+    p.check(r.MinOrderAmount <= r.InitialCapital*r.MaxSinglePosition || r.InitialCapital <= 0, "trading.risk.min_order_amount",
+        "%.2f exceeds the largest allowed position %.2f", r.MinOrderAmount, r.InitialCapital*r.MaxSinglePosition)
+    p.check(r.StopLossPercent >= 0 && r.StopLossPercent < 1, "trading.risk.stop_loss_percent", "must be in [0, 1), got %.2f", r.StopLossPercent)
+    p.check(r.TakeProfitPercent >= 0, "trading.risk.take_profit_percent", "must not be negative")
+    p.check(r.TrailingStopPercent >= 0 && r.TrailingStopPercent < 1, "trading.risk.trailing_stop_percent", "must be in [0, 1), got %.2f", r.TrailingStopPercent)
+    p.check(r.MaxGrossExposure >= 0, "trading.risk.max_gross_exposure", "must not be negative")
+    p.check(c.Trading.Broker.InitialCash >= 0, "trading.broker.initial_cash", "must not be negative")
+
+    a := c.Trading.AutoTrade
+    p.check(validInterval(a.CheckInterval), "trading.auto_trade.check_interval", "invalid duration %q", a.CheckInterval)
+    p.check(inUnit(a.AIThreshold), "trading.auto_trade.ai_threshold", "must be in [0, 1], got %.2f", a.AIThreshold)
+    p.check(inUnit(a.MLConfidence), "trading.auto_trade.ml_confidence", "must be in [0, 1], got %.2f", a.MLConfidence)
+    if a.SizingMethod != "" {
+        p.add("trading.auto_trade", trading.SizingConfig{
+            Method:       trading.SizingMethod(a.SizingMethod),
+            Fraction:     a.SizingFraction,
+            WinLossRatio: a.WinLossRatio,
+        }.Validate())
+    }
+
+    // 策略权重为相对值，合并信号时按启用策略的权重之和归一化
+    names := make(map[string]bool)
+    enabledWeight := 0.0
+    enabled := 0
+    for i, s := range c.Trading.Strategies {
+        field := fmt.Sprintf("trading.strategies[%d]", i)
+        p.check(s.Name != "", field+".name", "must not be empty")
+        p.check(!names[s.Name], field+".name", "duplicate strategy name %q", s.Name)
+        names[s.Name] = true
+        p.check(s.Type != "", field+".type", "must not be empty")
+        p.check(s.Weight >= 0, field+".weight", "must not be negative, got %.2f", s.Weight)
+        if s.Enabled {
+            enabled++
+            enabledWeight += s.Weight
+        }
+    }
+    p.check(enabled == 0 || enabledWeight > 0, "trading.strategies", "enabled strategies have zero total weight")
+
+    if combination := strategies.SignalCombination(c.Trading.SignalCombination); combination != "" {
+        p.check(combination.Valid(), "trading.signal_combination", "unknown combination %q", c.Trading.SignalCombination)
+    }
+    p.check(inUnit(c.Trading.ConsensusThreshold), "trading.consensus_threshold", "must be in [0, 1], got %.2f", c.Trading.ConsensusThreshold)
+    if pw := c.Trading.PerformanceWeighting; pw.Enabled {
+        p.add("trading.performance_weighting", pw.Validate())
+        p.check(feasibleWeights(pw.MinWeight, pw.MaxWeight, enabled), "trading.performance_weighting",
+            "weights in [%.2f, %.2f] cannot sum to 1 across %d enabled strategies", pw.MinWeight, pw.MaxWeight, enabled)
+    }
+    p.check(c.Trading.StrategyLatency.WindowSize >= 0, "trading.strategy_latency.window_size", "must not be negative")
+    p.check(c.Trading.ExecutionDrain.Timeout >= 0, "trading.execution_drain.timeout", "must not be negative")
+
+    if c.Trading.Scheduler.Enabled && c.Trading.Scheduler.CronExpression == "" {
+        p.check(c.Trading.Scheduler.Interval != "", "trading.scheduler.interval", "required when scheduler is enabled without cron_expression")
+    }
+    if c.Trading.Scheduler.CronExpression != "" {
+        p.add("trading.scheduler.cron_expression", scheduler.ValidateCronExpression(c.Trading.Scheduler.CronExpression))
+    }
+    p.check(validInterval(c.Trading.Scheduler.Interval), "trading.scheduler.interval", "invalid duration %q", c.Trading.Scheduler.Interval)
+    p.check(c.Trading.Scheduler.OrderAmount >= 0, "trading.scheduler.order_amount", "must not be negative, got %.2f", c.Trading.Scheduler.OrderAmount)
+
+    pr := c.Trading.PortfolioRisk
+    p.check(inUnit(pr.MaxIndustryExposure), "trading.portfolio_risk.max_industry_exposure", "must be in [0, 1], got %.2f", pr.MaxIndustryExposure)
+    p.check(inUnit(pr.MaxSectorExposure), "trading.portfolio_risk.max_sector_exposure", "must be in [0, 1], got %.2f", pr.MaxSectorExposure)
+    p.check(inUnit(pr.MaxSymbolExposure), "trading.portfolio_risk.max_symbol_exposure", "must be in [0, 1], got %.2f", pr.MaxSymbolExposure)
+
+    cd := c.Trading.CooldownRisk
+    p.check(cd.MinTradeInterval >= 0 && cd.MinOrderInterval >= 0 && cd.BlacklistDuration >= 0, "trading.cooldown_risk", "intervals must not be negative")
+    p.check(cd.MaxDailyTrades >= 0 && cd.MaxWeeklyTrades >= 0, "trading.cooldown_risk", "trade limits must not be negative")
+    p.check(cd.MaxWeeklyTrades == 0 || cd.MaxDailyTrades <= cd.MaxWeeklyTrades, "trading.cooldown_risk.max_daily_trades",
+        "%d exceeds max_weekly_trades %d", cd.MaxDailyTrades, cd.MaxWeeklyTrades)
+
+    // 组合优化器和调仓的权重上下限须允许权重之和为1
+    pf := c.Trading.Portfolio
+    p.check(pf.MinPositionWeight >= 0 && pf.MinPositionWeight <= pf.MaxPositionWeight && pf.MaxPositionWeight <= 1, "trading.portfolio",
+        "need 0 <= min_position_weight <= max_position_weight <= 1, got [%.2f, %.2f]", pf.MinPositionWeight, pf.MaxPositionWeight)
+    p.check(feasibleWeights(pf.MinPositionWeight, pf.MaxPositionWeight, len(c.Symbols)), "trading.portfolio",
+        "position weights in [%.2f, %.2f] cannot sum to 1 across %d symbols", pf.MinPositionWeight, pf.MaxPositionWeight, len(c.Symbols))
+    p.check(inUnit(pf.MaxTurnover), "trading.portfolio.max_turnover", "must be in [0, 1], got %.2f", pf.MaxTurnover)
+    p.check(pf.InitialCapital >= 0, "trading.portfolio.initial_capital", "must not be negative")
+    p.check(pf.LotSize >= 0, "trading.portfolio.lot_size", "must not be negative")
+    p.check(pf.RebalanceFrequency >= 0, "trading.portfolio.rebalance_frequency", "must not be negative")
+    p.check(pf.HistoryDays >= 0, "trading.portfolio.history_days", "must not be negative")
+
+    o := c.Trading.Optimizer
+    p.check(o.MinWeight >= 0 && o.MinWeight <= o.MaxWeight && o.MaxWeight <= 1, "trading.optimizer",
+        "need 0 <= min_weight <= max_weight <= 1, got [%.2f, %.2f]", o.MinWeight, o.MaxWeight)
+    p.check(feasibleWeights(o.MinWeight, o.MaxWeight, len(c.Symbols)), "trading.optimizer",
+        "weights in [%.2f, %.2f] cannot sum to 1 across %d symbols", o.MinWeight, o.MaxWeight, len(c.Symbols))
+    p.check(o.LookbackPeriod >= 0 && o.RebalancePeriod >= 0, "trading.optimizer", "periods must not be negative")
+
+    p.check(c.Monitoring.WebSocket.MaxConnections >= 0, "monitoring.websocket.max_connections", "must not be negative")
+    p.check(c.Monitoring.WebSocket.SessionTTL >= 0, "monitoring.websocket.session_ttl", "must not be negative")
+    rt := c.Monitoring.Alerts.Retry
+    p.check(rt.MaxRetries >= 0, "monitoring.alerts.retry.max_retries", "must not be negative")
+    p.check(rt.MaxBackoff == 0 || rt.InitialBackoff <= rt.MaxBackoff, "monitoring.alerts.retry",
+        "initial_backoff %s exceeds max_backoff %s", rt.InitialBackoff, rt.MaxBackoff)
+
+    if c.Backtest.Enabled {
+        p.check(c.Backtest.MaxSnapshots >= 0, "backtest.max_snapshots", "must not be negative")
+        bt := c.Backtest.DefaultConfig
+        p.check(bt.InitialCapital > 0, "backtest.default_config.initial_capital", "must be positive, got %.2f", bt.InitialCapital)
+        p.check(bt.Commission >= 0 && bt.MinCommission >= 0 && bt.StampTax >= 0 && bt.TransferFee >= 0 && bt.Slippage >= 0,
+            "backtest.default_config", "fees and slippage must not be negative")
+        p.check(bt.EndDate.IsZero() || !bt.EndDate.Before(bt.StartDate), "backtest.default_config.end_date", "is before start_date")
+    }
+
+    return errors.Join(p...)
+}
+
 func initializeServices(config *Config) {
     if config == nil {
         return
     }
 
     // 1. 初始化基础服务
-    llmAnalyzer = llm.NewDeepSeekAnalyzer(config.LLM.APIKey, config.LLM.Model, config.LLM.Timeout, config.LLM.MaxTokens)
+    llmAnalyzer = llm.NewDeepSeekAnalyzer(config.LLM.APIKey, config.LLM.Model, config.LLM.Timeout, config.LLM.MaxTokens,
+        llm.WithRetry(config.LLM.MaxRetries, config.LLM.RetryBaseDelay))
     cqhttp.SetAnalyzer(llmAnalyzer)
 
     if config.ML.ModelType != "" && config.ML.ModelPath != "" {
@@ -299,14 +740,23 @@ func initializeServices(config *Config) {
     }
 
     cqhttp.SetTrainingConfig(cqhttp.TrainingConfig{
-        Symbol:       firstSymbol(config.Symbols),
-        Days:         500,
-        ModelType:    config.ML.ModelType,
-        ModelPath:    config.ML.ModelPath,
-        MaxTreeDepth: config.ML.MaxTreeDepth,
-        TestRatio:    config.ML.Training.TestRatio,
+        Symbol:           firstSymbol(config.Symbols),
+        Days:             500,
+        ModelType:        config.ML.ModelType,
+        ModelPath:        config.ML.ModelPath,
+        MaxTreeDepth:     config.ML.MaxTreeDepth,
+        TestRatio:        config.ML.Training.TestRatio,
+        NumTrees:         config.ML.NumTrees,
+        FeatureSubsample: config.ML.FeatureSubsample,
     })
 
+    // 行情数据源健康检查，启动时先检查一次以便尽早给出延迟和健康状态
+    go market.DefaultProviderRegistry().CheckHealth()
+    market.DefaultProviderRegistry().StartHealthChecks()
+    marketProvider = market.NewMarketProvider(config.Market.QuoteCacheTTL)
+    cqhttp.SetMarketProvider(marketProvider)
+    initializeMarketStorage(config)
+
     // 2. 初始化行业数据缓存
     initializeIndustryCache()
 
@@ -329,6 +779,37 @@ func initializeServices(config *Config) {
     initializeBacktestSystem(config)
 }
 
+// initializeMarketStorage 初始化行情数据存储
+func initializeMarketStorage(config *Config) {
+    if !config.Market.Storage.Enabled || config.Market.Storage.Path == "" {
+        log.Println("Market data storage disabled")
+        return
+    }
+
+    storage, err := pipeline.NewOptimizedStorage(pipeline.StorageConfig{
+        DBPath:    config.Market.Storage.Path,
+        EnableWAL: config.Market.Storage.EnableWAL,
+        MaxGap:    config.Market.Storage.MaxGap,
+    })
+    if err != nil {
+        log.Printf("Failed to initialize market data storage: %v", err)
+        return
+    }
+    marketStorage = storage
+    cqhttp.SetMarketStorage(marketStorage)
+    log.Printf("Market data storage initialized at %s", config.Market.Storage.Path)
+
+    // 定时把关注股票的日K线写入存储，供回放、回测和数据质量检查使用
+    marketIngester = pipeline.NewDataIngester(pipeline.IngestionConfig{
+        CheckInterval:     config.Market.Storage.IngestInterval,
+        EnableIncremental: true,
+    }, marketProvider, marketStorage)
+    if err := marketIngester.Start(config.Symbols); err != nil {
+        log.Printf("Failed to start market data ingestion: %v", err)
+        marketIngester = nil
+    }
+}
+
 // initializeIndustryCache 初始化行业数据缓存
 func initializeIndustryCache() {
     log.Println("Initializing industry cache...")
@@ -348,9 +829,16 @@ func initializeIndustryCache() {
 func initializeReplayEngine() {
     log.Println("Initializing replay engine...")
 
-    // 使用Mock数据提供者作为默认
-    dataProvider := monitoring.NewMockReplayDataProvider()
-    replayEngine := monitoring.NewReplayEngine(dataProvider)
+    // 配置了行情存储时回放真实历史K线，存储中没有该区间的K线时从行情数据源获取，否则使用Mock数据
+    var dataProvider monitoring.ReplayDataProvider = monitoring.NewMockReplayDataProvider()
+    if marketStorage != nil {
+        dataProvider = monitoring.NewFallbackReplayDataProvider(
+            monitoring.NewStorageReplayDataProvider(marketStorage),
+            monitoring.NewFetcherReplayDataProvider(marketProvider),
+        )
+        log.Println("Replay engine using stored market data")
+    }
+    replayEngine = monitoring.NewReplayEngine(dataProvider)
     cqhttp.SetReplayEngine(replayEngine)
 
     log.Println("Replay engine initialized")
@@ -363,16 +851,10 @@ func firstSymbol(symbols []string) string {
     return symbols[0]
 }
 
-// initializeMultiStrategySystem 初始化多策略框架
-func initializeMultiStrategySystem(config *Config) {
-    log.Println("Initializing multi-strategy system...")
-
-    // 1. 创建策略加载器
-    strategyLoader = strategies.NewStrategyLoader()
-
-    // 2. 转换配置格式
+// toStrategyConfigs 将配置文件中的策略配置转换为策略加载器的格式
+func toStrategyConfigs(configs []StrategyConfig) []strategies.StrategyConfig {
     var strategyConfigs []strategies.StrategyConfig
-    for _, config := range config.Trading.Strategies {
+    for _, config := range configs {
         strategyConfigs = append(strategyConfigs, strategies.StrategyConfig{
             Name:       config.Name,
             Type:       strategies.StrategyType(config.Type),
@@ -380,24 +862,78 @@ func initializeMultiStrategySystem(config *Config) {
             Weight:     config.Weight,
             Parameters: config.Parameters,
             Priority:   config.Priority,
+            CloseOnly:  config.CloseOnly,
         })
     }
+    return strategyConfigs
+}
+
+// initializeMultiStrategySystem 初始化多策略框架
+func initializeMultiStrategySystem(config *Config) {
+    log.Println("Initializing multi-strategy system...")
+
+    // 1. 创建策略加载器
+    strategyLoader = strategies.NewStrategyLoader()
+
+    // 2. 转换配置格式
+    strategyConfigs := toStrategyConfigs(config.Trading.Strategies)
 
-    // 3. 加载策略
+    // 3. 加载策略，配置不合法的策略被跳过，其余策略照常运行
     if err := strategyLoader.LoadStrategies(strategyConfigs); err != nil {
-        log.Printf("Failed to load strategies: %v", err)
-        return
+        log.Printf("Some strategies were skipped: %v", err)
     }
 
     // 4. 创建策略管理器
-    strategyManager = strategies.NewStrategyManager(strategyLoader, strategies.WeightedCombination)
+    combination := strategies.WeightedCombination
+    if config.Trading.SignalCombination != "" {
+        combination = strategies.SignalCombination(config.Trading.SignalCombination)
+    }
+    strategyManager = strategies.NewStrategyManager(strategyLoader, combination)
+    if config.Trading.ConsensusThreshold > 0 {
+        if err := strategyManager.SetConsensusThreshold(config.Trading.ConsensusThreshold); err != nil {
+            log.Printf("Invalid consensus threshold, using default: %v", err)
+        }
+    }
+    strategyManager.SetLatencyConfig(strategies.LatencyConfig{
+        WindowSize: config.Trading.StrategyLatency.WindowSize,
+        Threshold:  config.Trading.StrategyLatency.Threshold,
+    })
+    if err := strategyManager.SetPerformanceWeighting(config.Trading.PerformanceWeighting); err != nil {
+        log.Printf("Invalid performance weighting config, keeping fixed weights: %v", err)
+    }
+    if err := strategyManager.SetExecutionConfig(strategies.ExecutionConfig{
+        DryRun:      config.Trading.Scheduler.DryRun,
+        OrderAmount: config.Trading.Scheduler.OrderAmount,
+    }); err != nil {
+        log.Printf("Invalid strategy execution config, running dry: %v", err)
+    }
+    cqhttp.SetStrategyManager(strategyManager)
+    // 热更新时重新读取配置文件中的策略
+    cqhttp.SetStrategyConfigSource(func() ([]strategies.StrategyConfig, error) {
+        reloaded, err := loadConfig("config.yaml")
+        if err != nil {
+            return nil, err
+        }
+        if err := reloaded.Validate(); err != nil {
+            return nil, err
+        }
+        return toStrategyConfigs(reloaded.Trading.Strategies), nil
+    })
 
-    // 5. 创建调度器
-    if s, err := scheduler.NewScheduler(config.Trading.Scheduler.Interval); err != nil {
+    // 5. 创建调度器，配置了cron表达式时按表达式在交易日执行，否则按固定间隔
+    newScheduler := func() (*scheduler.Scheduler, error) {
+        if expr := config.Trading.Scheduler.CronExpression; expr != "" {
+            return scheduler.NewCronScheduler(expr)
+        }
+        return scheduler.NewScheduler(config.Trading.Scheduler.Interval)
+    }
+    if s, err := newScheduler(); err != nil {
         log.Printf("Failed to create scheduler: %v", err)
     } else {
         taskScheduler = s
+        taskScheduler.SetTradingCalendar(tradingCalendar(config))
         taskScheduler.SetStrategyManager(strategyManager)
+        taskScheduler.SetMarketProvider(marketProvider)
         taskScheduler.SetSymbols(config.Symbols)
 
         // 如果启用调度器，启动它
@@ -413,38 +949,140 @@ func initializeMultiStrategySystem(config *Config) {
     log.Println("Multi-strategy system initialized")
 }
 
+// tradingCalendar 按配置的休市日期和交易时段创建交易日历，配置无效时使用默认A股日历
+func tradingCalendar(config *Config) *backtest.TradingCalendar {
+    calendar, err := backtest.NewTradingCalendar(config.Market.Calendar)
+    if err != nil {
+        log.Printf("Invalid market calendar, using default sessions without holidays: %v", err)
+        return backtest.DefaultTradingCalendar()
+    }
+    return calendar
+}
+
 // initializeMonitoringSystem 初始化监控系统
 func initializeMonitoringSystem(config *Config) {
     log.Println("Initializing monitoring system...")
 
     // 1. 创建实时监控器
-    monitor = monitoring.NewRealtimeMonitor()
+    monitor = monitoring.NewRealtimeMonitor(config.Monitoring.WebSocket.MaxConnections)
+    if ttl := config.Monitoring.WebSocket.SessionTTL; ttl > 0 {
+        monitor.GetWebSocketHub().EnableSessionPersistence(ttl)
+    }
+    monitor.GetWebSocketHub().SetAllowedOrigins(config.Monitoring.WebSocket.AllowedOrigins)
+    monitor.SetDebugLogging(config.Log.Level == "debug")
     if err := monitor.Start(); err != nil {
         log.Printf("Failed to start monitor: %v", err)
         return
     }
+    cqhttp.SetRealtimeMonitor(monitor)
 
     // 2. 创建告警系统
     alertSystem = monitoring.NewAlertSystem()
+    if config.Monitoring.Alerts.Persist {
+        persisted, err := monitoring.NewAlertSystemWithStore(db.AlertStore{})
+        if err != nil {
+            log.Printf("Failed to restore alerts, falling back to in-memory alerts: %v", err)
+        } else {
+            alertSystem = persisted
+        }
+    }
     if err := alertSystem.Start(); err != nil {
         log.Printf("Failed to start alert system: %v", err)
     }
 
     // 3. 配置告警渠道
     configureAlertChannels(config)
+    if retry := config.Monitoring.Alerts.Retry; retry.InitialBackoff > 0 {
+        alertSystem.SetRetryPolicy(monitoring.RetryPolicy{
+            MaxRetries:     retry.MaxRetries,
+            InitialBackoff: retry.InitialBackoff,
+            MaxBackoff:     retry.MaxBackoff,
+        })
+    }
 
     // 4. 设置告警系统到监控器
     monitor.SetAlertSystem(alertSystem)
+    cqhttp.SetAlertSystem(alertSystem)
+
+    // 回放K线通过WebSocket推送
+    if replayEngine != nil {
+        replayEngine.SetMonitor(monitor)
+    }
+
+    // 5. 慢策略告警
+    if strategyManager != nil {
+        strategyManager.SetLatencyAlertCallback(func(alert strategies.SlowStrategyAlert) {
+            alertSystem.SendAlert(&monitoring.Alert{
+                Level:     monitoring.Warning,
+                Title:     "策略执行过慢",
+                Message:   fmt.Sprintf("策略 %s 平均执行时间 %v 超过阈值 %v", alert.Strategy, alert.Average, alert.Threshold),
+                Value:     float64(alert.Average.Milliseconds()),
+                Threshold: float64(alert.Threshold.Milliseconds()),
+                Source:    "strategy_latency",
+                Metadata: map[string]interface{}{
+                    "strategy": alert.Strategy,
+                },
+            })
+        })
+    }
 
     log.Println("Monitoring system initialized")
 }
 
+// defaultEmailRateLimit 未配置rate_limit时邮件告警的限流：每小时5封、每天50封、间隔30分钟
+var defaultEmailRateLimit = monitoring.RateLimit{
+    MaxPerHour: 5,
+    MaxPerDay:  50,
+    Cooldown:   30 * time.Minute,
+}
+
+// withRateLimitDefaults 未配置（为0）的限流项取默认值
+func withRateLimitDefaults(limit, defaults monitoring.RateLimit) monitoring.RateLimit {
+    if limit.MaxPerHour == 0 {
+        limit.MaxPerHour = defaults.MaxPerHour
+    }
+    if limit.MaxPerDay == 0 {
+        limit.MaxPerDay = defaults.MaxPerDay
+    }
+    if limit.Cooldown == 0 {
+        limit.Cooldown = defaults.Cooldown
+    }
+    return limit
+}
+
 // configureAlertChannels 配置告警渠道
 func configureAlertChannels(config *Config) {
     if !config.Monitoring.Alerts.Enabled {
         return
     }
 
+    // 配置邮件
+    if email := config.Monitoring.Alerts.Channels.Email; email.Enabled {
+        channel := &monitoring.AlertChannel{
+            Type:    "email",
+            Enabled: true,
+            Settings: map[string]interface{}{
+                "smtp_host": email.SMTPHost,
+                "smtp_port": email.SMTPPort,
+                "username":  email.Username,
+                "password":  email.Password,
+                "to":        email.To,
+            },
+            Filters: []monitoring.AlertFilter{
+                {Field: "level", Operator: "equals", Value: "critical"},
+            },
+            RateLimit: withRateLimitDefaults(monitoring.RateLimit{
+                MaxPerHour: email.RateLimit.MaxPerHour,
+                MaxPerDay:  email.RateLimit.MaxPerDay,
+                Cooldown:   email.RateLimit.Cooldown,
+            }, defaultEmailRateLimit),
+        }
+
+        if err := alertSystem.AddChannel("email", channel); err != nil {
+            log.Printf("Failed to add email channel: %v", err)
+        }
+    }
+
     // 配置飞书
     if config.Monitoring.Alerts.Channels.Feishu.Enabled {
         channel := &monitoring.AlertChannel{
@@ -493,6 +1131,59 @@ func configureAlertChannels(config *Config) {
             log.Printf("Failed to add dingding channel: %v", err)
         }
     }
+
+    // 配置Slack
+    if slack := config.Monitoring.Alerts.Channels.Slack; slack.Enabled {
+        channel := &monitoring.AlertChannel{
+            Type:    "slack",
+            Enabled: true,
+            Settings: map[string]interface{}{
+                "webhook": slack.Webhook,
+                "fields":  slack.Fields,
+            },
+            Filters: []monitoring.AlertFilter{
+                {Field: "level", Operator: "equals", Value: "warning"},
+                {Field: "level", Operator: "equals", Value: "error"},
+                {Field: "level", Operator: "equals", Value: "critical"},
+            },
+            RateLimit: monitoring.RateLimit{
+                MaxPerHour: slack.RateLimit.MaxPerHour,
+                MaxPerDay:  slack.RateLimit.MaxPerDay,
+                Cooldown:   slack.RateLimit.Cooldown,
+            },
+        }
+
+        if err := alertSystem.AddChannel("slack", channel); err != nil {
+            log.Printf("Failed to add slack channel: %v", err)
+        }
+    }
+
+    // 配置通用Webhook
+    if webhook := config.Monitoring.Alerts.Channels.Webhook; webhook.Enabled {
+        channel := &monitoring.AlertChannel{
+            Type:    "webhook",
+            Enabled: true,
+            Settings: map[string]interface{}{
+                "url":              webhook.URL,
+                "payload_template": webhook.PayloadTemplate,
+                "field_map":        webhook.FieldMap,
+            },
+            Filters: []monitoring.AlertFilter{
+                {Field: "level", Operator: "equals", Value: "warning"},
+                {Field: "level", Operator: "equals", Value: "error"},
+                {Field: "level", Operator: "equals", Value: "critical"},
+            },
+            RateLimit: monitoring.RateLimit{
+                MaxPerHour: webhook.RateLimit.MaxPerHour,
+                MaxPerDay:  webhook.RateLimit.MaxPerDay,
+                Cooldown:   webhook.RateLimit.Cooldown,
+            },
+        }
+
+        if err := alertSystem.AddChannel("webhook", channel); err != nil {
+            log.Printf("Failed to add webhook channel: %v", err)
+        }
+    }
 }
 
 // initializePortfolioSystem 初始化组合管理系统
@@ -502,18 +1193,53 @@ func initializePortfolioSystem(config *Config) {
     if positionManager == nil || riskManager == nil {
         log.Println("Trading components not configured, skipping position-based portfolio managers")
     } else {
+        // 4. 创建组合管理器，现金余额从初始资金开始
+        portfolioManager = portfolio.NewPortfolioManager(portfolio.PortfolioConfig{
+            RebalanceFrequency: config.Trading.Portfolio.RebalanceFrequency,
+            MaxTurnover:        config.Trading.Portfolio.MaxTurnover,
+            MinPositionWeight:  config.Trading.Portfolio.MinPositionWeight,
+            MaxPositionWeight:  config.Trading.Portfolio.MaxPositionWeight,
+            TargetReturn:       config.Trading.Portfolio.TargetReturn,
+            RiskFreeRate:       config.Trading.Portfolio.RiskFreeRate,
+            InitialCapital:     config.Trading.Portfolio.InitialCapital,
+            LotSize:            config.Trading.Portfolio.LotSize,
+            DryRun:             config.Trading.Portfolio.DryRun,
+        }, positionManager, riskManager)
+        if orderExecutor != nil {
+            portfolioManager.SetOrderExecutor(orderExecutor)
+            orderExecutor.AddFillHandler(portfolioManager.OnFill)
+        }
+        portfolioManager.SetPriceProvider(func(symbol string) (float64, error) {
+            tick, err := marketProvider.GetQuoteCached(symbol)
+            if err != nil {
+                return 0, err
+            }
+            return tick.Close, nil
+        })
+        if tradeHistory != nil && config.Trading.Portfolio.HistoryDays > 0 {
+            if err := portfolioManager.SyncFromTradeHistory(context.Background(), tradeHistory, config.Trading.Portfolio.HistoryDays); err != nil {
+                log.Printf("Failed to sync portfolio returns from trade history: %v", err)
+            }
+        }
+        cqhttp.SetPortfolioManager(portfolioManager)
+
         // 5. 创建AI风险管理器
         aiRiskConfig := risk.AIRiskConfig{
             Enabled:           config.Trading.AIRisk.Enabled,
             AnalysisInterval:  config.Trading.AIRisk.AnalysisInterval,
+            MinGlobalInterval: config.Trading.AIRisk.MinGlobalInterval,
             CacheExpiry:       config.Trading.AIRisk.CacheExpiry,
             RiskThreshold:     config.Trading.AIRisk.RiskThreshold,
             AutoAlert:         config.Trading.AIRisk.AutoAlert,
             DeepLearning:      config.Trading.AIRisk.DeepLearning,
             SentimentAnalysis: config.Trading.AIRisk.SentimentAnalysis,
             NewsAnalysis:      config.Trading.AIRisk.NewsAnalysis,
+            PersistHistory:    config.Trading.AIRisk.PersistHistory,
         }
         aiRisk = risk.NewAIRisk(aiRiskConfig, llmAnalyzer, positionManager)
+        if aiRiskConfig.PersistHistory {
+            aiRisk.SetAnalysisStore(db.RiskAnalysisStore{})
+        }
     }
 
     log.Println("Portfolio management system initialized")
@@ -534,11 +1260,24 @@ func initializeBacktestSystem(config *Config) {
         EndDate:          config.Backtest.DefaultConfig.EndDate,
         InitialCapital:   config.Backtest.DefaultConfig.InitialCapital,
         Commission:       config.Backtest.DefaultConfig.Commission,
+        CommissionModel:  backtest.CommissionModelType(config.Backtest.DefaultConfig.CommissionModel),
+        MinCommission:    config.Backtest.DefaultConfig.MinCommission,
+        StampTax:         config.Backtest.DefaultConfig.StampTax,
+        TransferFee:      config.Backtest.DefaultConfig.TransferFee,
         Slippage:         config.Backtest.DefaultConfig.Slippage,
+        SlippageModel:    backtest.SlippageModelType(config.Backtest.DefaultConfig.SlippageModel),
+        SlippageImpact:   config.Backtest.DefaultConfig.SlippageImpact,
+        MaxSlippage:      config.Backtest.DefaultConfig.MaxSlippage,
         Symbols:          config.Symbols,
         RiskFreeRate:     config.Backtest.DefaultConfig.RiskFreeRate,
         MaxDrawdownLimit: config.Backtest.DefaultConfig.MaxDrawdownLimit,
+        HaltOnDrawdown:   config.Backtest.DefaultConfig.HaltOnDrawdown,
         Realtime:         config.Backtest.DefaultConfig.Realtime,
+        SnapshotData:     config.Backtest.DefaultConfig.SnapshotData,
+        BarInterval:      backtest.BarInterval(config.Backtest.DefaultConfig.BarInterval),
+        RiskWindow:       config.Backtest.DefaultConfig.RiskWindow,
+        LimitOrders:      config.Backtest.DefaultConfig.LimitOrders,
+        LimitOrderTTL:    config.Backtest.DefaultConfig.LimitOrderTTL,
     }
 
     // 转换策略配置
@@ -553,14 +1292,31 @@ func initializeBacktestSystem(config *Config) {
     }
 
     backtestEngine = backtest.NewBacktestEngine(backtestConfig)
+    if err := backtestEngine.SetCalendar(tradingCalendar(config)); err != nil {
+        log.Printf("Failed to set backtest calendar: %v", err)
+    }
+
+    // 2. 数据快照存储
+    snapshotStore := backtest.NewSnapshotStore(config.Backtest.MaxSnapshots)
+    backtestEngine.SetSnapshotStore(snapshotStore)
+    cqhttp.SetBacktestSnapshotStore(snapshotStore)
+    cqhttp.SetBacktestEngine(backtestEngine)
+
+    // 3. 回测结果持久化，便于跨会话比较
+    if config.Backtest.PersistResults {
+        resultStore := db.BacktestResultStore{}
+        backtestEngine.SetResultStore(resultStore)
+        cqhttp.SetBacktestResultStore(resultStore)
+    }
 
     log.Println("Backtest system initialized")
 }
 
 // initializeLegacyTradingSystem 初始化传统交易系统（保持向后兼容）
 func initializeLegacyTradingSystem(config *Config) {
-    // 如果配置了券商，则初始化交易系统
-    if config.Trading.Broker.Type != "" && config.Trading.Broker.Service != "" {
+    // 如果配置了券商，则初始化交易系统；模拟盘不需要服务地址
+    paperTrading := config.Trading.Broker.Type == trading.BrokerTypePaper
+    if config.Trading.Broker.Type != "" && (config.Trading.Broker.Service != "" || paperTrading) {
         log.Println("Initializing legacy trading system...")
         var err error
 
@@ -573,12 +1329,13 @@ func initializeLegacyTradingSystem(config *Config) {
 
         // 2. 创建券商连接器
         brokerConfig := trading.BrokerConfig{
-            Type:     config.Trading.Broker.Type,
-            Service:  config.Trading.Broker.Service,
-            Broker:   config.Trading.Broker.Broker,
-            Username: config.Trading.Broker.Username,
-            Password: config.Trading.Broker.Password,
-            ExePath:  config.Trading.Broker.ExePath,
+            Type:        config.Trading.Broker.Type,
+            Service:     config.Trading.Broker.Service,
+            Broker:      config.Trading.Broker.Broker,
+            Username:    config.Trading.Broker.Username,
+            Password:    config.Trading.Broker.Password,
+            ExePath:     config.Trading.Broker.ExePath,
+            InitialCash: config.Trading.Broker.InitialCash,
         }
 
         brokerConnector, err = trading.NewBrokerConnector(brokerConfig)
@@ -587,8 +1344,23 @@ func initializeLegacyTradingSystem(config *Config) {
             return
         }
 
+        // 模拟盘按实时行情撮合，成交写入交易历史并在重启时恢复
+        if paper, ok := brokerConnector.GetBroker().(*trading.PaperBroker); ok {
+            paper.SetPriceProvider(func(symbol string) (float64, error) {
+                quote, err := marketProvider.GetQuoteCached(symbol)
+                if err != nil {
+                    return 0, err
+                }
+                return quote.Close, nil
+            })
+            if err := paper.SetTradeHistory(tradeHistory); err != nil {
+                log.Printf("Failed to restore paper trading state: %v", err)
+            }
+            log.Println("Paper trading enabled: orders are simulated against live quotes")
+        }
+
         // 3. 尝试连接券商
-        if config.Trading.Broker.Username != "" && config.Trading.Broker.Password != "" {
+        if paperTrading || (config.Trading.Broker.Username != "" && config.Trading.Broker.Password != "") {
             if err := brokerConnector.Connect(); err != nil {
                 log.Printf("Failed to connect to broker: %v (trading will be disabled)", err)
             } else {
@@ -600,12 +1372,15 @@ func initializeLegacyTradingSystem(config *Config) {
 
         // 4. 创建风险管理器
         riskConfig := trading.RiskConfig{
-            InitialCapital:    config.Trading.Risk.InitialCapital,
-            MaxSinglePosition: config.Trading.Risk.MaxSinglePosition,
-            MaxPositions:      config.Trading.Risk.MaxPositions,
-            MaxDailyLoss:      config.Trading.Risk.MaxDailyLoss,
-            MinOrderAmount:    config.Trading.Risk.MinOrderAmount,
-            StopLossPercent:   config.Trading.Risk.StopLossPercent,
+            InitialCapital:      config.Trading.Risk.InitialCapital,
+            MaxSinglePosition:   config.Trading.Risk.MaxSinglePosition,
+            MaxPositions:        config.Trading.Risk.MaxPositions,
+            MaxDailyLoss:        config.Trading.Risk.MaxDailyLoss,
+            MinOrderAmount:      config.Trading.Risk.MinOrderAmount,
+            StopLossPercent:     config.Trading.Risk.StopLossPercent,
+            TakeProfitPercent:   config.Trading.Risk.TakeProfitPercent,
+            TrailingStopPercent: config.Trading.Risk.TrailingStopPercent,
+            MaxGrossExposure:    config.Trading.Risk.MaxGrossExposure,
         }
         riskManager = trading.NewRiskManager(riskConfig, brokerConnector, tradeHistory)
 
@@ -614,6 +1389,27 @@ func initializeLegacyTradingSystem(config *Config) {
 
         // 6. 创建订单执行器
         orderExecutor = trading.NewOrderExecutor(brokerConnector, riskManager, positionManager, tradeHistory)
+        if ttl := config.Server.ClientOrderTTL; ttl > 0 {
+            orderExecutor.SetClientOrderTTL(ttl)
+        }
+
+        // 算法拆单执行引擎，关闭时排空；启动时报告上次关闭未完成的母单以便对账
+        orderManager = order.NewOrderManager(brokerConnector, orderExecutor, riskManager, positionManager, order.ManagerConfig{})
+        if err := orderManager.Start(); err != nil {
+            log.Printf("Failed to start order manager: %v", err)
+        }
+        executionEngine = order.NewExecutionEngine(orderManager, nil)
+        executionEngine.SetDrainConfig(config.Trading.ExecutionDrain)
+        if stateFile := config.Trading.ExecutionDrain.StateFile; stateFile != "" {
+            partials, err := order.LoadPartialExecutions(stateFile)
+            if err != nil {
+                log.Printf("Failed to load partial executions from %s: %v", stateFile, err)
+            }
+            for _, p := range partials {
+                log.Printf("Unfinished %s execution %s for %s from last shutdown: filled %.0f of %.0f, %.0f remaining",
+                    p.Algorithm, p.OrderID, p.Symbol, p.FilledQuantity, p.Quantity, p.RemainingQuantity)
+            }
+        }
 
         // 7. 创建信号处理器
         signalHandler = trading.NewSignalHandler(
@@ -623,13 +1419,43 @@ func initializeLegacyTradingSystem(config *Config) {
             positionManager,
             orderExecutor,
         )
+        if method := config.Trading.AutoTrade.SizingMethod; method != "" {
+            if err := signalHandler.SetSizingConfig(trading.SizingConfig{
+                Method:       trading.SizingMethod(method),
+                Fraction:     config.Trading.AutoTrade.SizingFraction,
+                WinLossRatio: config.Trading.AutoTrade.WinLossRatio,
+            }); err != nil {
+                log.Printf("Invalid position sizing config, using fixed amounts: %v", err)
+            }
+        }
 
         // 8. 设置HTTP处理器
         cqhttp.SetTradingComponents(tradeHistory, brokerConnector, riskManager, positionManager, orderExecutor, signalHandler)
 
+        // 实时风控监控
+        realtimeRisk = realtime.NewRealtimeRiskMonitor(riskManager, positionManager, realtime.MonitorConfig{})
+        realtimeRisk.SetStopLossExecutor(orderExecutor)
+        if err := realtimeRisk.Start(); err != nil {
+            log.Printf("Failed to start realtime risk monitor: %v", err)
+        }
+        cqhttp.SetRealtimeRiskMonitor(realtimeRisk)
+
+        // 交易冷却风控
+        cooldownRisk = risk.NewCooldownRisk(risk.CooldownRiskConfig{
+            MinTradeInterval:  config.Trading.CooldownRisk.MinTradeInterval,
+            MaxDailyTrades:    config.Trading.CooldownRisk.MaxDailyTrades,
+            MinOrderInterval:  config.Trading.CooldownRisk.MinOrderInterval,
+            MaxWeeklyTrades:   config.Trading.CooldownRisk.MaxWeeklyTrades,
+            BlacklistDuration: config.Trading.CooldownRisk.BlacklistDuration,
+            EnableCooldown:    config.Trading.CooldownRisk.EnableCooldown,
+        }, tradeHistory)
+        orderExecutor.SetTradeCooldown(cooldownRisk)
+        cqhttp.SetCooldownRisk(cooldownRisk)
+
         // 9. 连接多策略系统到传统交易系统
         if strategyManager != nil {
             strategyManager.SetTradingComponents(riskManager, positionManager, orderExecutor, signalHandler)
+            orderExecutor.AddFillHandler(strategyManager.OnFill)
         }
 
         log.Println("Legacy trading system initialized")
@@ -637,7 +1463,9 @@ func initializeLegacyTradingSystem(config *Config) {
         // 10. 启动自动交易（如果启用）
         if config.Trading.AutoTrade.Enabled && brokerConnector.IsConnected() {
             log.Println("Auto trading enabled, starting monitor...")
-            go startRiskMonitor(riskManager, orderExecutor)
+            riskMonitorStop = make(chan struct{})
+            riskMonitorDone = make(chan struct{})
+            go startRiskMonitor(riskManager, orderExecutor, riskMonitorStop, riskMonitorDone)
         }
     }
 }
@@ -648,11 +1476,19 @@ func initializeTradingSystem(config *Config) {
     initializeLegacyTradingSystem(config)
 }
 
-func startRiskMonitor(riskManager *trading.RiskManager, orderExecutor *trading.OrderExecutor) {
+func startRiskMonitor(riskManager *trading.RiskManager, orderExecutor *trading.OrderExecutor, stop <-chan struct{}, done chan<- struct{}) {
+    defer close(done)
+
     ticker := time.NewTicker(1 * time.Minute)
     defer ticker.Stop()
 
-    for range ticker.C {
+    for {
+        select {
+        case <-ticker.C:
+        case <-stop:
+            return
+        }
+
         // 检查止损
         ctx, cancel := contextWithTimeout(30 * time.Second)
         stopLossSymbols, err := riskManager.CheckPositionLoss(ctx)
@@ -666,6 +1502,43 @@ func startRiskMonitor(riskManager *trading.RiskManager, orderExecutor *trading.O
                 cancel()
             }
         }
+
+        // 检查移动止损
+        ctx, cancel = contextWithTimeout(30 * time.Second)
+        trailingSymbols, err := riskManager.CheckTrailingStops(ctx)
+        if err == nil && len(trailingSymbols) > 0 {
+            log.Printf("Trailing stop triggered for %d symbols", len(trailingSymbols))
+            for _, symbol := range trailingSymbols {
+                if pos, err := positionManager.GetPosition(symbol); err == nil {
+                    if err := orderExecutor.ExecuteStopLoss(ctx, symbol, pos.CurrentPrice); err != nil {
+                        log.Printf("Trailing stop failed for %s: %v", symbol, err)
+                    }
+                }
+            }
+        }
+        cancel()
+
+        // 检查止盈
+        ctx, cancel = contextWithTimeout(30 * time.Second)
+        takeProfitSymbols, err := riskManager.CheckPositionProfit(ctx)
+        if err == nil && len(takeProfitSymbols) > 0 {
+            log.Printf("Take profit triggered for %d symbols", len(takeProfitSymbols))
+            for _, symbol := range takeProfitSymbols {
+                if pos, err := positionManager.GetPosition(symbol); err == nil {
+                    if err := orderExecutor.ExecuteTakeProfit(ctx, symbol, pos.CurrentPrice); err != nil {
+                        log.Printf("Take profit failed for %s: %v", symbol, err)
+                    }
+                }
+            }
+        }
+        cancel()
+
+        // 推进括号单
+        ctx, cancel = contextWithTimeout(30 * time.Second)
+        if err := orderExecutor.UpdateBrackets(ctx); err != nil {
+            log.Printf("Bracket update failed: %v", err)
+        }
+        cancel()
     }
 }
 
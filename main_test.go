@@ -0,0 +1,135 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudquant/monitoring"
+)
+
+func loadValidConfig(t *testing.T) *Config {
+	t.Helper()
+	config, err := loadConfig("config.yaml")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	return config
+}
+
+func TestShippedConfigsAreValid(t *testing.T) {
+	// 生产配置要求的密钥
+	for _, name := range []string{"CLOUDQUANT_API_KEY", "DEEPSEEK_API_KEY", "BROKER_USERNAME", "BROKER_PASSWORD"} {
+		t.Setenv(name, "test")
+	}
+	for _, path := range []string{"config.yaml", "config/config.production.yaml"} {
+		config, err := loadConfig(path)
+		if err != nil {
+			t.Fatalf("loadConfig(%s): %v", path, err)
+		}
+		if err := config.Validate(); err != nil {
+			t.Errorf("%s is invalid:\n%v", path, err)
+		}
+	}
+}
+
+func TestConfigValidateRejectsBadValues(t *testing.T) {
+	tests := []struct {
+		name   string
+		mutate func(c *Config)
+		want   string
+	}{
+		{"empty symbols", func(c *Config) { c.Symbols = nil }, "symbols: at least one symbol"},
+		{"negative capital", func(c *Config) { c.Trading.Risk.InitialCapital = -1 }, "trading.risk.initial_capital"},
+		{"stop loss of 100%", func(c *Config) { c.Trading.Risk.StopLossPercent = 1 }, "trading.risk.stop_loss_percent"},
+		{"optimizer bounds inverted", func(c *Config) {
+			c.Trading.Optimizer.MinWeight, c.Trading.Optimizer.MaxWeight = 0.4, 0.1
+		}, "min_weight <= max_weight"},
+		{"optimizer weights cannot reach 1", func(c *Config) {
+			c.Symbols = []string{"sh600000", "sh600036"}
+			c.Trading.Optimizer.MinWeight, c.Trading.Optimizer.MaxWeight = 0.1, 0.3
+		}, "cannot sum to 1 across 2 symbols"},
+		{"zero strategy weights", func(c *Config) {
+			for i := range c.Trading.Strategies {
+				c.Trading.Strategies[i].Weight = 0
+			}
+		}, "zero total weight"},
+		{"duplicate strategy", func(c *Config) {
+			c.Trading.Strategies = append(c.Trading.Strategies, c.Trading.Strategies[0])
+		}, "duplicate strategy name"},
+		{"bad scheduler interval", func(c *Config) { c.Trading.Scheduler.Interval = "-1m" }, "trading.scheduler.interval"},
+		{"bad market session", func(c *Config) { c.Market.Calendar.Sessions = []string{"15:00-13:00"} }, "market.calendar"},
+		{"bad cron expression", func(c *Config) { c.Trading.Scheduler.CronExpression = "0 9 * *" }, "trading.scheduler.cron_expression"},
+		{"unknown combination", func(c *Config) { c.Trading.SignalCombination = "majority" }, "unknown combination"},
+		{"bad sizing", func(c *Config) {
+			c.Trading.AutoTrade.SizingMethod = "kelly"
+			c.Trading.AutoTrade.SizingFraction = 2
+		}, "trading.auto_trade: sizing fraction"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := loadValidConfig(t)
+			tt.mutate(config)
+			err := config.Validate()
+			if err == nil {
+				t.Fatal("Validate accepted invalid config")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error %q does not mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidateReportsAllProblems(t *testing.T) {
+	config := loadValidConfig(t)
+	config.Http.Port = 0
+	config.Trading.Risk.MaxPositions = 0
+	config.Trading.Portfolio.MaxTurnover = 1.5
+
+	err := config.Validate()
+	if err == nil {
+		t.Fatal("Validate accepted invalid config")
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("got %d problems, want 3:\n%v", len(lines), err)
+	}
+}
+
+func TestLoadConfigRequiresProductionSecrets(t *testing.T) {
+	t.Setenv("CLOUDQUANT_API_KEY", "key")
+	t.Setenv("DEEPSEEK_API_KEY", "key")
+	t.Setenv("BROKER_USERNAME", "user")
+	t.Setenv("BROKER_PASSWORD", "")
+	os.Unsetenv("BROKER_PASSWORD")
+
+	_, err := loadConfig("config/config.production.yaml")
+	if err == nil || !strings.Contains(err.Error(), "trading.broker.password: environment variable is not set: BROKER_PASSWORD") {
+		t.Fatalf("err = %v, want unset BROKER_PASSWORD", err)
+	}
+
+	t.Setenv("BROKER_PASSWORD", "pa$$")
+	config, err := loadConfig("config/config.production.yaml")
+	if err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	if config.Trading.Broker.Password != "pa$$" || config.Server.Auth.APIKey != "key" {
+		t.Errorf("secrets not expanded: password=%q api_key=%q", config.Trading.Broker.Password, config.Server.Auth.APIKey)
+	}
+}
+
+func TestEmailRateLimitDefaults(t *testing.T) {
+	// 省略rate_limit时沿用原有的邮件限流，而不是不限流
+	if got := withRateLimitDefaults(monitoring.RateLimit{}, defaultEmailRateLimit); got != defaultEmailRateLimit {
+		t.Errorf("empty rate limit = %+v, want defaults %+v", got, defaultEmailRateLimit)
+	}
+
+	partial := monitoring.RateLimit{MaxPerHour: 2}
+	want := monitoring.RateLimit{MaxPerHour: 2, MaxPerDay: 50, Cooldown: 30 * time.Minute}
+	if got := withRateLimitDefaults(partial, defaultEmailRateLimit); got != want {
+		t.Errorf("partial rate limit = %+v, want %+v", got, want)
+	}
+}
@@ -1,69 +1,60 @@
 package market
 
 import (
+    "context"
     "encoding/json"
     "fmt"
-    "io"
     "net/http"
     "strconv"
-    "strings"
     "time"
 
-    "golang.org/x/text/encoding/simplifiedchinese"
-    "golang.org/x/text/transform"
+    "cloudquant/market/providers"
 )
 
-// FetchTick fetches the latest price for a single stock symbol from Sina API
+// FetchTick fetches the latest price for a single stock symbol from the active
+// data provider, falling back to other healthy providers on failure
 func FetchTick(symbol string) (*Tick, error) {
-    url := fmt.Sprintf("http://hq.sinajs.cn/list=%s", symbol)
-    req, _ := http.NewRequest("GET", url, nil)
-    req.Header.Set("Referer", "http://finance.sina.com.cn")
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
 
-    // #nosec G107 -- External API call to Sina Finance is intentional
-    resp, err := http.DefaultClient.Do(req)
+    tick, err := DefaultProviderRegistry().FetchTick(ctx, symbol)
     if err != nil {
         return nil, err
     }
-    defer resp.Body.Close()
 
-    utf8Reader := transform.NewReader(resp.Body, simplifiedchinese.GBK.NewDecoder())
-    // #nosec G110 -- Limited response size from trusted Sina Finance API
-    body, err := io.ReadAll(utf8Reader)
+    return convertProviderTick(symbol, tick), nil
+}
+
+// FetchQuotes fetches the latest prices for many symbols at once, using the
+// active provider's multi-symbol endpoint when it has one and bounded
+// concurrent per-symbol requests otherwise. Symbols that could not be fetched
+// are missing from the result; an error is returned only if all of them failed
+func FetchQuotes(symbols []string) (map[string]Quote, error) {
+    ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    ticks, err := DefaultProviderRegistry().FetchTicks(ctx, symbols)
     if err != nil {
         return nil, err
     }
 
-    line := string(body)
-    parts := strings.Split(line, "\"")
-    if len(parts) < 2 {
-        return nil, fmt.Errorf("invalid response from sina api")
-    }
-
-    data := strings.Split(parts[1], ",")
-    if len(data) < 30 {
-        return nil, fmt.Errorf("unexpected data format from sina api")
+    quotes := make(map[string]Quote, len(ticks))
+    for symbol, tick := range ticks {
+        quotes[symbol] = *convertProviderTick(symbol, tick)
     }
+    return quotes, nil
+}
 
-    open, _ := strconv.ParseFloat(data[1], 64)
-    high, _ := strconv.ParseFloat(data[4], 64)
-    low, _ := strconv.ParseFloat(data[5], 64)
-    curr, _ := strconv.ParseFloat(data[3], 64)
-    volume, _ := strconv.ParseInt(data[8], 10, 64)
-
-    date := data[30]
-    timeStr := data[31]
-
-    timestamp, _ := time.ParseInLocation("2006-01-02 15:04:05", date+" "+timeStr, time.Local)
-
+func convertProviderTick(symbol string, tick *providers.Tick) *Tick {
     return &Tick{
         Symbol:    symbol,
-        Open:      open,
-        High:      high,
-        Low:       low,
-        Close:     curr,
-        Volume:    volume,
-        Timestamp: timestamp,
-    }, nil
+        Open:      tick.Open,
+        High:      tick.High,
+        Low:       tick.Low,
+        Close:     tick.Price,
+        Volume:    tick.Volume,
+        Timestamp: tick.Time,
+    }
 }
 
 type sinaKLine struct {
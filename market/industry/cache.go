@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -21,8 +22,12 @@ type Cache struct {
 	filePath    string
 	autoReload  bool
 	stopReload  chan struct{}
+	reloading   int32 // 过期异步重载进行中标记，避免并发重复加载
 }
 
+// readFile 读取数据文件，测试中可替换以统计读取次数
+var readFile = os.ReadFile
+
 // NewCache 创建行业信息缓存
 func NewCache(filePath string) *Cache {
 	return &Cache{
@@ -40,7 +45,7 @@ func (c *Cache) Load() error {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	data, err := os.ReadFile(c.filePath)
+	data, err := readFile(c.filePath)
 	if err != nil {
 		return fmt.Errorf("读取行业数据文件失败: %w", err)
 	}
@@ -105,6 +110,11 @@ func (c *Cache) Reload() error {
 func (c *Cache) IsExpired() bool {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
+	return c.isExpiredLocked()
+}
+
+// isExpiredLocked 检查缓存是否过期，调用方需持有锁
+func (c *Cache) isExpiredLocked() bool {
 	return time.Since(c.lastLoad) > c.ttl
 }
 
@@ -113,9 +123,10 @@ func (c *Cache) GetStockIndustry(symbol string) (*IndustryInfo, bool) {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
 
-	// 检查缓存过期，异步重载
-	if c.IsExpired() && !c.autoReload {
+	// 检查缓存过期，异步重载（同一时间只允许一个重载）
+	if c.isExpiredLocked() && !c.autoReload && atomic.CompareAndSwapInt32(&c.reloading, 0, 1) {
 		go func() {
+			defer atomic.StoreInt32(&c.reloading, 0)
 			_ = c.Reload()
 		}()
 	}
@@ -246,28 +257,50 @@ func (c *Cache) GetStats() map[string]interface{} {
 		"total_industries": len(c.industryMap),
 		"total_sectors":    len(c.sectorMap),
 		"last_load":        c.lastLoad,
-		"is_expired":       c.IsExpired(),
+		"is_expired":       c.isExpiredLocked(),
 	}
 }
 
-// 全局缓存实例
+// globalCacheEntry 单个数据文件对应的全局缓存
+type globalCacheEntry struct {
+	once  sync.Once
+	cache *Cache
+	err   error
+}
+
+// 全局缓存实例，按文件路径区分
 var (
-	globalCache *Cache
-	cacheOnce   sync.Once
-	cacheErr    error
+	globalCaches   = make(map[string]*globalCacheEntry)
+	globalCachesMu sync.Mutex
 )
 
-// GetGlobalCache 获取全局行业缓存（单例）
+// GetGlobalCache 获取全局行业缓存（按文件路径单例）。
+// 首次调用时加载文件，之后只通过Reload或自动重载刷新，并发调用共享同一实例。
 func GetGlobalCache(filePath string) (*Cache, error) {
-	cacheOnce.Do(func() {
-		globalCache = NewCache(filePath)
-		cacheErr = globalCache.Load()
+	globalCachesMu.Lock()
+	entry, ok := globalCaches[filePath]
+	if !ok {
+		entry = &globalCacheEntry{}
+		globalCaches[filePath] = entry
+	}
+	globalCachesMu.Unlock()
+
+	entry.once.Do(func() {
+		entry.cache = NewCache(filePath)
+		entry.err = entry.cache.Load()
 	})
-	return globalCache, cacheErr
+	return entry.cache, entry.err
 }
 
 // ResetGlobalCache 重置全局缓存（用于测试）
 func ResetGlobalCache() {
-	globalCache = nil
-	cacheOnce = sync.Once{}
+	globalCachesMu.Lock()
+	defer globalCachesMu.Unlock()
+
+	for _, entry := range globalCaches {
+		if entry.cache != nil {
+			entry.cache.StopAutoReload()
+		}
+	}
+	globalCaches = make(map[string]*globalCacheEntry)
 }
@@ -0,0 +1,82 @@
+package industry
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestGetGlobalCacheLoadsOncePerPath(t *testing.T) {
+	dir := t.TempDir()
+	pathA := filepath.Join(dir, "a.json")
+	pathB := filepath.Join(dir, "b.json")
+	data := []byte(`{"data":[{"symbol":"sh600000","sw_industry":"银行","sw_sector":"股份制银行"}],"industry_list":["银行"]}`)
+	for _, path := range []string{pathA, pathB} {
+		if err := os.WriteFile(path, data, 0o644); err != nil {
+			t.Fatalf("write %s: %v", path, err)
+		}
+	}
+
+	var reads int32
+	origRead := readFile
+	readFile = func(name string) ([]byte, error) {
+		atomic.AddInt32(&reads, 1)
+		return origRead(name)
+	}
+	ResetGlobalCache()
+	t.Cleanup(func() {
+		readFile = origRead
+		ResetGlobalCache()
+	})
+
+	const workers = 50
+	caches := make([]*Cache, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			cache, err := GetGlobalCache(pathA)
+			if err != nil {
+				t.Errorf("GetGlobalCache: %v", err)
+				return
+			}
+			if _, ok := cache.GetStockIndustry("sh600000"); !ok {
+				t.Error("expected sh600000 in cache")
+			}
+			caches[i] = cache
+		}(i)
+	}
+	wg.Wait()
+
+	if n := atomic.LoadInt32(&reads); n != 1 {
+		t.Fatalf("expected data file to be read once, got %d", n)
+	}
+	for i, cache := range caches {
+		if cache != caches[0] {
+			t.Fatalf("worker %d got a different cache instance", i)
+		}
+	}
+
+	// 不同路径各自维护独立实例
+	other, err := GetGlobalCache(pathB)
+	if err != nil {
+		t.Fatalf("GetGlobalCache(%s): %v", pathB, err)
+	}
+	if other == caches[0] {
+		t.Fatal("expected distinct cache instance for a different path")
+	}
+	if n := atomic.LoadInt32(&reads); n != 2 {
+		t.Fatalf("expected second path to trigger one more read, got %d reads", n)
+	}
+
+	// 刷新只通过Reload进行
+	if err := caches[0].Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if n := atomic.LoadInt32(&reads); n != 3 {
+		t.Fatalf("expected Reload to read the file, got %d reads", n)
+	}
+}
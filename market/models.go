@@ -1,6 +1,9 @@
 package market
 
-import "time"
+import (
+	"sync"
+	"time"
+)
 
 type Tick struct {
 	Symbol    string    `json:"symbol"`
@@ -31,9 +34,16 @@ type KLine struct {
 	Indicators Indicator `json:"indicators"`
 }
 
-// MarketProvider 市场数据提供者
+// Quote 批量获取的最新行情
+type Quote = Tick
+
+// MarketProvider 市场数据提供者。零值不缓存行情，NewMarketProvider创建带行情缓存的实例
 type MarketProvider struct {
-	// 可以添加缓存、连接池等
+	cacheMu  sync.Mutex
+	cacheTTL time.Duration           // 行情缓存有效期，0表示不缓存
+	quotes   map[string]*cachedQuote // 按股票代码缓存的最新行情
+	hits     uint64
+	misses   uint64
 }
 
 // GetMarketData 获取市场数据（简化实现）
@@ -41,6 +51,16 @@ func (p *MarketProvider) GetMarketData(symbol string) (*Tick, error) {
 	return FetchTick(symbol)
 }
 
+// FetchQuotes 批量获取多只股票的最新行情，避免逐只串行请求；结果写入行情缓存
+func (p *MarketProvider) FetchQuotes(symbols []string) (map[string]Quote, error) {
+	quotes, err := FetchQuotes(symbols)
+	if err != nil {
+		return nil, err
+	}
+	p.storeQuotes(quotes)
+	return quotes, nil
+}
+
 // GetHistoricalData 获取历史数据
 func (p *MarketProvider) GetHistoricalData(symbol string, days int) ([]KLine, error) {
 	return FetchHistoricalData(symbol, days)
@@ -3,6 +3,7 @@ package providers
 import (
 	"context"
 	"log"
+	"sort"
 	"sync"
 	"time"
 )
@@ -16,6 +17,15 @@ type DataProvider interface {
 	Priority() int
 }
 
+// BatchTickProvider 支持一次请求获取多只股票行情的数据源
+type BatchTickProvider interface {
+	// FetchTicks 批量获取行情，返回以传入代码为键的结果，未返回数据的股票不在结果中
+	FetchTicks(ctx context.Context, symbols []string) (map[string]*Tick, error)
+}
+
+// DefaultQuoteConcurrency 逐只获取行情时的默认最大并发请求数
+const DefaultQuoteConcurrency = 8
+
 // Tick 实时行情数据
 type Tick struct {
 	Symbol    string
@@ -48,13 +58,26 @@ type KLine struct {
 	ChangePct float64
 }
 
+// ProviderStatus 数据源状态
+type ProviderStatus struct {
+	Name      string        `json:"name"`
+	Priority  int           `json:"priority"`
+	Healthy   bool          `json:"healthy"`
+	Active    bool          `json:"active"`  // 是否为当前主数据源
+	Latency   time.Duration `json:"latency"` // 最近一次健康检查耗时
+	LastCheck time.Time     `json:"last_check"`
+	LastError string        `json:"last_error,omitempty"`
+}
+
 // ProviderManager 数据源管理器
 type ProviderManager struct {
 	providers           []DataProvider
 	primary             DataProvider
 	health              map[string]bool
+	checks              map[string]ProviderStatus // 最近一次健康检查结果
 	healthMu            sync.RWMutex
 	healthCheckInterval time.Duration
+	quoteConcurrency    int // FetchTicks逐只获取时的最大并发数
 	stopChan            chan struct{}
 	mu                  sync.RWMutex
 }
@@ -64,7 +87,9 @@ func NewProviderManager() *ProviderManager {
 	return &ProviderManager{
 		providers:           make([]DataProvider, 0),
 		health:              make(map[string]bool),
+		checks:              make(map[string]ProviderStatus),
 		healthCheckInterval: 30 * time.Second,
+		quoteConcurrency:    DefaultQuoteConcurrency,
 		stopChan:            make(chan struct{}),
 	}
 }
@@ -75,7 +100,9 @@ func (pm *ProviderManager) AddProvider(provider DataProvider) {
 	defer pm.mu.Unlock()
 
 	pm.providers = append(pm.providers, provider)
+	pm.healthMu.Lock()
 	pm.health[provider.Name()] = true
+	pm.healthMu.Unlock()
 
 	if pm.primary == nil || provider.Priority() > pm.primary.Priority() {
 		pm.primary = provider
@@ -131,6 +158,82 @@ func (pm *ProviderManager) FetchTick(ctx context.Context, symbol string) (*Tick,
 	return nil, ErrAllProvidersFailed
 }
 
+// SetQuoteConcurrency 设置FetchTicks逐只获取行情时的最大并发数，小于1时按1处理
+func (pm *ProviderManager) SetQuoteConcurrency(n int) {
+	if n < 1 {
+		n = 1
+	}
+	pm.mu.Lock()
+	defer pm.mu.Unlock()
+	pm.quoteConcurrency = n
+}
+
+// FetchTicks 批量获取实时行情：主数据源支持批量接口时一次请求获取，
+// 批量失败或缺失的股票再按FetchTick逐只获取（自动切换数据源），并发数受quoteConcurrency限制。
+// 未能获取的股票不在结果中，全部失败时返回ErrAllProvidersFailed
+func (pm *ProviderManager) FetchTicks(ctx context.Context, symbols []string) (map[string]*Tick, error) {
+	pm.mu.RLock()
+	primary := pm.primary
+	concurrency := pm.quoteConcurrency
+	pm.mu.RUnlock()
+
+	ticks := make(map[string]*Tick, len(symbols))
+	if len(symbols) == 0 {
+		return ticks, nil
+	}
+
+	if batch, ok := primary.(BatchTickProvider); ok {
+		batched, err := batch.FetchTicks(ctx, symbols)
+		if err != nil {
+			log.Printf("Primary provider %s batch fetch failed: %v, fetching symbols individually", primary.Name(), err)
+		}
+		for symbol, tick := range batched {
+			ticks[symbol] = tick
+		}
+	}
+
+	var missing []string
+	seen := make(map[string]bool, len(symbols))
+	for _, symbol := range symbols {
+		if seen[symbol] {
+			continue
+		}
+		seen[symbol] = true
+		if _, ok := ticks[symbol]; !ok {
+			missing = append(missing, symbol)
+		}
+	}
+
+	var (
+		wg  sync.WaitGroup
+		mu  sync.Mutex
+		sem = make(chan struct{}, concurrency)
+	)
+	for _, symbol := range missing {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(symbol string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			tick, err := pm.FetchTick(ctx, symbol)
+			if err != nil {
+				log.Printf("Failed to fetch tick for %s: %v", symbol, err)
+				return
+			}
+			mu.Lock()
+			ticks[symbol] = tick
+			mu.Unlock()
+		}(symbol)
+	}
+	wg.Wait()
+
+	if len(ticks) == 0 {
+		return nil, ErrAllProvidersFailed
+	}
+	return ticks, nil
+}
+
 // FetchKLines 获取K线数据（自动切换数据源）
 func (pm *ProviderManager) FetchKLines(ctx context.Context, symbol string, days int) ([]KLine, error) {
 	pm.mu.RLock()
@@ -192,15 +295,7 @@ func (pm *ProviderManager) monitorProvider(provider DataProvider) {
 	for {
 		select {
 		case <-ticker.C:
-			err := provider.HealthCheck()
-			pm.healthMu.Lock()
-			if err != nil {
-				pm.health[provider.Name()] = false
-				log.Printf("Provider %s health check failed: %v", provider.Name(), err)
-			} else {
-				pm.health[provider.Name()] = true
-			}
-			pm.healthMu.Unlock()
+			pm.checkProvider(provider)
 
 		case <-pm.stopChan:
 			return
@@ -208,6 +303,75 @@ func (pm *ProviderManager) monitorProvider(provider DataProvider) {
 	}
 }
 
+// checkProvider 执行一次健康检查并记录结果和耗时
+func (pm *ProviderManager) checkProvider(provider DataProvider) {
+	start := time.Now()
+	err := provider.HealthCheck()
+	status := ProviderStatus{
+		Name:      provider.Name(),
+		Priority:  provider.Priority(),
+		Healthy:   err == nil,
+		Latency:   time.Since(start),
+		LastCheck: start,
+	}
+	if err != nil {
+		status.LastError = err.Error()
+		log.Printf("Provider %s health check failed: %v", provider.Name(), err)
+	}
+
+	pm.healthMu.Lock()
+	pm.health[provider.Name()] = status.Healthy
+	pm.checks[provider.Name()] = status
+	pm.healthMu.Unlock()
+}
+
+// CheckHealth 立即并发检查所有数据源并返回最新状态
+func (pm *ProviderManager) CheckHealth() []ProviderStatus {
+	pm.mu.RLock()
+	providers := make([]DataProvider, len(pm.providers))
+	copy(providers, pm.providers)
+	pm.mu.RUnlock()
+
+	var wg sync.WaitGroup
+	for _, provider := range providers {
+		wg.Add(1)
+		go func(provider DataProvider) {
+			defer wg.Done()
+			pm.checkProvider(provider)
+		}(provider)
+	}
+	wg.Wait()
+
+	return pm.GetProviderStatuses()
+}
+
+// GetProviderStatuses 获取所有数据源的健康、延迟和优先级，按优先级从高到低排列
+func (pm *ProviderManager) GetProviderStatuses() []ProviderStatus {
+	pm.mu.RLock()
+	providers := make([]DataProvider, len(pm.providers))
+	copy(providers, pm.providers)
+	primary := pm.primary
+	pm.mu.RUnlock()
+
+	pm.healthMu.RLock()
+	statuses := make([]ProviderStatus, 0, len(providers))
+	for _, provider := range providers {
+		status, checked := pm.checks[provider.Name()]
+		if !checked {
+			status = ProviderStatus{Name: provider.Name(), Priority: provider.Priority()}
+		}
+		status.Healthy = pm.health[provider.Name()]
+		status.Active = provider == primary
+		statuses = append(statuses, status)
+	}
+	pm.healthMu.RUnlock()
+
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Priority > statuses[j].Priority
+	})
+	return statuses
+}
+
 // StopHealthChecks 停止健康检查
 func (pm *ProviderManager) StopHealthChecks() {
 	close(pm.stopChan)
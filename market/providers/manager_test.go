@@ -0,0 +1,157 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// stubProvider 可控制健康状态和延迟的数据源
+type stubProvider struct {
+	name     string
+	priority int
+	delay    time.Duration
+	err      error
+}
+
+func (s *stubProvider) Name() string  { return s.name }
+func (s *stubProvider) Priority() int { return s.priority }
+
+func (s *stubProvider) FetchTick(ctx context.Context, symbol string) (*Tick, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Tick{Symbol: symbol, Name: s.name, Price: 10}, nil
+}
+
+func (s *stubProvider) FetchKLines(ctx context.Context, symbol string, days int) ([]KLine, error) {
+	return nil, s.err
+}
+
+func (s *stubProvider) HealthCheck() error {
+	time.Sleep(s.delay)
+	return s.err
+}
+
+func TestProviderManagerHealthAndSwitch(t *testing.T) {
+	manager := NewProviderManager()
+	slow := &stubProvider{name: "slow", priority: 1, delay: 20 * time.Millisecond}
+	broken := &stubProvider{name: "broken", priority: 3, err: errors.New("connection refused")}
+	fast := &stubProvider{name: "fast", priority: 2}
+	manager.AddProvider(slow)
+	manager.AddProvider(broken)
+	manager.AddProvider(fast)
+
+	if primary := manager.GetPrimaryProvider(); primary != "broken" {
+		t.Fatalf("primary %s, want highest priority provider", primary)
+	}
+
+	statuses := manager.CheckHealth()
+	if len(statuses) != 3 || statuses[0].Name != "broken" || statuses[1].Name != "fast" || statuses[2].Name != "slow" {
+		t.Fatalf("statuses %+v not ordered by priority", statuses)
+	}
+	if statuses[0].Healthy || statuses[0].LastError == "" || !statuses[0].Active {
+		t.Errorf("broken provider status %+v, want unhealthy active with error", statuses[0])
+	}
+	if !statuses[2].Healthy || statuses[2].Latency < slow.delay || statuses[2].LastCheck.IsZero() {
+		t.Errorf("slow provider status %+v, want healthy with measured latency", statuses[2])
+	}
+
+	// 主数据源失败时回退到健康的数据源
+	tick, err := manager.FetchTick(context.Background(), "sh600000")
+	if err != nil || tick.Name == "broken" {
+		t.Fatalf("FetchTick = %+v, %v; want fallback provider", tick, err)
+	}
+
+	if err := manager.SetPrimaryProvider("fast"); err != nil {
+		t.Fatalf("SetPrimaryProvider: %v", err)
+	}
+	if tick, _ := manager.FetchTick(context.Background(), "sh600000"); tick == nil || tick.Name != "fast" {
+		t.Errorf("tick served by %+v after switching to fast", tick)
+	}
+	for _, status := range manager.GetProviderStatuses() {
+		if status.Active != (status.Name == "fast") {
+			t.Errorf("%s active=%v after switching to fast", status.Name, status.Active)
+		}
+	}
+
+	if err := manager.SetPrimaryProvider("unknown"); !errors.Is(err, ErrProviderNotFound) {
+		t.Errorf("unknown provider: got %v, want ErrProviderNotFound", err)
+	}
+}
+
+// batchProvider 批量接口只返回known中的股票，逐只请求记录最大并发数
+type batchProvider struct {
+	stubProvider
+	known    map[string]bool
+	batches  int
+	mu       sync.Mutex
+	inflight int
+	peak     int
+	single   []string
+}
+
+func (b *batchProvider) FetchTicks(ctx context.Context, symbols []string) (map[string]*Tick, error) {
+	b.batches++
+	ticks := make(map[string]*Tick)
+	for _, symbol := range symbols {
+		if b.known[symbol] {
+			ticks[symbol] = &Tick{Symbol: symbol, Name: "batch", Price: 10}
+		}
+	}
+	return ticks, nil
+}
+
+func (b *batchProvider) FetchTick(ctx context.Context, symbol string) (*Tick, error) {
+	b.mu.Lock()
+	b.inflight++
+	if b.inflight > b.peak {
+		b.peak = b.inflight
+	}
+	b.single = append(b.single, symbol)
+	b.mu.Unlock()
+
+	time.Sleep(5 * time.Millisecond)
+
+	b.mu.Lock()
+	b.inflight--
+	b.mu.Unlock()
+	return &Tick{Symbol: symbol, Name: "single", Price: 10}, nil
+}
+
+func TestProviderManagerFetchTicks(t *testing.T) {
+	manager := NewProviderManager()
+	provider := &batchProvider{
+		stubProvider: stubProvider{name: "batch", priority: 1},
+		known:        map[string]bool{"sh600000": true, "sz000001": true},
+	}
+	manager.AddProvider(provider)
+	manager.SetQuoteConcurrency(2)
+
+	symbols := []string{"sh600000", "sz000001", "sh600001", "sh600002", "sh600003", "sh600004", "sh600001"}
+	ticks, err := manager.FetchTicks(context.Background(), symbols)
+	if err != nil {
+		t.Fatalf("FetchTicks: %v", err)
+	}
+	if len(ticks) != 6 {
+		t.Fatalf("got %d ticks, want 6", len(ticks))
+	}
+	if provider.batches != 1 || ticks["sh600000"].Name != "batch" {
+		t.Errorf("batches = %d, sh600000 from %s; want one batch request", provider.batches, ticks["sh600000"].Name)
+	}
+	// 批量接口缺失的4只股票逐只获取，重复代码只请求一次，并发不超过2
+	if len(provider.single) != 4 || ticks["sh600004"].Name != "single" {
+		t.Errorf("per-symbol fetches = %v, want the 4 missing symbols", provider.single)
+	}
+	if provider.peak > 2 {
+		t.Errorf("peak concurrency = %d, want <= 2", provider.peak)
+	}
+
+	broken := NewProviderManager()
+	broken.AddProvider(&stubProvider{name: "broken", priority: 1, err: errors.New("timeout")})
+	if _, err := broken.FetchTicks(context.Background(), []string{"sh600000"}); !errors.Is(err, ErrAllProvidersFailed) {
+		t.Errorf("all failed: got %v, want ErrAllProvidersFailed", err)
+	}
+}
@@ -32,74 +32,130 @@ func (sp *SinaProvider) Priority() int {
 }
 
 func (sp *SinaProvider) FetchTick(ctx context.Context, symbol string) (*Tick, error) {
-    sinaSymbol := convertToSinaSymbol(symbol)
-    url := fmt.Sprintf("https://hq.sinajs.cn/list=%s", sinaSymbol)
+    ticks, err := sp.FetchTicks(ctx, []string{symbol})
+    if err != nil {
+        return nil, err
+    }
+    tick, ok := ticks[symbol]
+    if !ok {
+        return nil, fmt.Errorf("failed to parse tick data")
+    }
+    return tick, nil
+}
+
+// sinaBatchSize 单次请求的最大股票数，避免URL过长
+const sinaBatchSize = 100
+
+// FetchTicks 通过list参数一次请求多只股票的行情
+func (sp *SinaProvider) FetchTicks(ctx context.Context, symbols []string) (map[string]*Tick, error) {
+    ticks := make(map[string]*Tick, len(symbols))
+    for start := 0; start < len(symbols); start += sinaBatchSize {
+        end := start + sinaBatchSize
+        if end > len(symbols) {
+            end = len(symbols)
+        }
+        if err := sp.fetchTickBatch(ctx, symbols[start:end], ticks); err != nil {
+            return ticks, err
+        }
+    }
+    return ticks, nil
+}
+
+// fetchTickBatch 请求一批股票的行情并写入ticks
+func (sp *SinaProvider) fetchTickBatch(ctx context.Context, symbols []string, ticks map[string]*Tick) error {
+    requested := make(map[string]string, len(symbols))
+    list := make([]string, 0, len(symbols))
+    for _, symbol := range symbols {
+        sinaSymbol := convertToSinaSymbol(symbol)
+        if _, ok := requested[sinaSymbol]; !ok {
+            list = append(list, sinaSymbol)
+        }
+        requested[sinaSymbol] = symbol
+    }
+    url := fmt.Sprintf("https://hq.sinajs.cn/list=%s", strings.Join(list, ","))
 
     req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
     if err != nil {
-        return nil, err
+        return err
     }
+    // 新浪行情接口要求Referer，否则拒绝请求
+    req.Header.Set("Referer", "https://finance.sina.com.cn")
 
     // #nosec G107 -- External API call to Sina Finance is intentional
     resp, err := sp.client.Do(req)
     if err != nil {
-        return nil, err
+        return err
     }
     defer resp.Body.Close()
 
     // #nosec G110 -- Limited response size from trusted Sina Finance API
     body, err := io.ReadAll(resp.Body)
     if err != nil {
-        return nil, err
+        return err
     }
 
-    dataStr := string(body)
-    if strings.Contains(dataStr, "var hq_str_") {
-        start := strings.Index(dataStr, "=") + 2
-        end := strings.Index(dataStr, ";")
-        if start >= 2 && end > start {
-            data := dataStr[start:end]
-            parts := strings.Split(data, ",")
-
-            if len(parts) >= 32 {
-                price, _ := strconv.ParseFloat(parts[3], 64)
-                preClose, _ := strconv.ParseFloat(parts[2], 64)
-                open, _ := strconv.ParseFloat(parts[1], 64)
-                high, _ := strconv.ParseFloat(parts[4], 64)
-                low, _ := strconv.ParseFloat(parts[5], 64)
-                volume, _ := strconv.ParseInt(parts[8], 10, 64)
-                bid, _ := strconv.ParseFloat(parts[6], 64)
-                ask, _ := strconv.ParseFloat(parts[7], 64)
-                dateStr := parts[30] + " " + parts[31]
-                date, _ := time.ParseInLocation("2006-01-02 15:04:05", dateStr, time.Local)
-
-                change := price - preClose
-                changePct := 0.0
-                if preClose > 0 {
-                    changePct = (change / preClose) * 100
-                }
-
-                return &Tick{
-                    Symbol:    symbol,
-                    Name:      strings.TrimSpace(parts[0]),
-                    Price:     price,
-                    Bid:       bid,
-                    Ask:       ask,
-                    Volume:    volume,
-                    High:      high,
-                    Low:       low,
-                    Open:      open,
-                    PreClose:  preClose,
-                    Turnover:  change * float64(volume),
-                    Time:      date,
-                    Change:    change,
-                    ChangePct: changePct,
-                }, nil
-            }
+    // 每只股票一行: var hq_str_sh600000="名称,今开,昨收,...";
+    for _, line := range strings.Split(string(body), "\n") {
+        line = strings.TrimSpace(line)
+        if !strings.HasPrefix(line, "var hq_str_") {
+            continue
+        }
+        eq := strings.Index(line, "=")
+        if eq < 0 {
+            continue
+        }
+        symbol, ok := requested[line[len("var hq_str_"):eq]]
+        if !ok {
+            continue
         }
+        data := strings.TrimSuffix(strings.TrimSpace(line[eq+1:]), ";")
+        if tick := parseSinaTick(symbol, strings.Trim(data, "\"")); tick != nil {
+            ticks[symbol] = tick
+        }
+    }
+    return nil
+}
+
+// parseSinaTick 解析新浪行情字段，停牌或代码无效时数据为空，返回nil
+func parseSinaTick(symbol, data string) *Tick {
+    parts := strings.Split(data, ",")
+    if len(parts) < 32 {
+        return nil
     }
 
-    return nil, fmt.Errorf("failed to parse tick data")
+    price, _ := strconv.ParseFloat(parts[3], 64)
+    preClose, _ := strconv.ParseFloat(parts[2], 64)
+    open, _ := strconv.ParseFloat(parts[1], 64)
+    high, _ := strconv.ParseFloat(parts[4], 64)
+    low, _ := strconv.ParseFloat(parts[5], 64)
+    volume, _ := strconv.ParseInt(parts[8], 10, 64)
+    bid, _ := strconv.ParseFloat(parts[6], 64)
+    ask, _ := strconv.ParseFloat(parts[7], 64)
+    dateStr := parts[30] + " " + parts[31]
+    date, _ := time.ParseInLocation("2006-01-02 15:04:05", dateStr, time.Local)
+
+    change := price - preClose
+    changePct := 0.0
+    if preClose > 0 {
+        changePct = (change / preClose) * 100
+    }
+
+    return &Tick{
+        Symbol:    symbol,
+        Name:      strings.TrimSpace(parts[0]),
+        Price:     price,
+        Bid:       bid,
+        Ask:       ask,
+        Volume:    volume,
+        High:      high,
+        Low:       low,
+        Open:      open,
+        PreClose:  preClose,
+        Turnover:  change * float64(volume),
+        Time:      date,
+        Change:    change,
+        ChangePct: changePct,
+    }
 }
 
 func (sp *SinaProvider) FetchKLines(ctx context.Context, symbol string, days int) ([]KLine, error) {
@@ -31,73 +31,126 @@ func (tp *TencentProvider) Priority() int {
 }
 
 func (tp *TencentProvider) FetchTick(ctx context.Context, symbol string) (*Tick, error) {
-    tencentSymbol := convertToTencentSymbol(symbol)
-    url := fmt.Sprintf("https://qt.gtimg.cn/q=%s", tencentSymbol)
+    ticks, err := tp.FetchTicks(ctx, []string{symbol})
+    if err != nil {
+        return nil, err
+    }
+    tick, ok := ticks[symbol]
+    if !ok {
+        return nil, fmt.Errorf("failed to parse tick data")
+    }
+    return tick, nil
+}
+
+// tencentBatchSize 单次请求的最大股票数，避免URL过长
+const tencentBatchSize = 60
+
+// FetchTicks 通过q参数一次请求多只股票的行情
+func (tp *TencentProvider) FetchTicks(ctx context.Context, symbols []string) (map[string]*Tick, error) {
+    ticks := make(map[string]*Tick, len(symbols))
+    for start := 0; start < len(symbols); start += tencentBatchSize {
+        end := start + tencentBatchSize
+        if end > len(symbols) {
+            end = len(symbols)
+        }
+        if err := tp.fetchTickBatch(ctx, symbols[start:end], ticks); err != nil {
+            return ticks, err
+        }
+    }
+    return ticks, nil
+}
+
+// fetchTickBatch 请求一批股票的行情并写入ticks
+func (tp *TencentProvider) fetchTickBatch(ctx context.Context, symbols []string, ticks map[string]*Tick) error {
+    requested := make(map[string]string, len(symbols))
+    list := make([]string, 0, len(symbols))
+    for _, symbol := range symbols {
+        tencentSymbol := convertToTencentSymbol(symbol)
+        if _, ok := requested[tencentSymbol]; !ok {
+            list = append(list, tencentSymbol)
+        }
+        requested[tencentSymbol] = symbol
+    }
+    url := fmt.Sprintf("https://qt.gtimg.cn/q=%s", strings.Join(list, ","))
 
     req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
     if err != nil {
-        return nil, err
+        return err
     }
 
     // #nosec G107 -- External API call to Tencent Finance is intentional
     resp, err := tp.client.Do(req)
     if err != nil {
-        return nil, err
+        return err
     }
     defer resp.Body.Close()
 
     // #nosec G110 -- Limited response size from trusted Tencent Finance API
     body, err := io.ReadAll(resp.Body)
     if err != nil {
-        return nil, err
+        return err
     }
 
-    dataStr := string(body)
-    if strings.Contains(dataStr, "v_") {
-        start := strings.Index(dataStr, "\"") + 1
-        end := strings.LastIndex(dataStr, "\"")
-        if start > 0 && end > start {
-            data := dataStr[start:end]
-            parts := strings.Split(data, "~")
-
-            if len(parts) >= 40 {
-                name := strings.TrimSpace(parts[1])
-                price, _ := strconv.ParseFloat(parts[3], 64)
-                preClose, _ := strconv.ParseFloat(parts[4], 64)
-                open, _ := strconv.ParseFloat(parts[5], 64)
-                volume, _ := strconv.ParseInt(parts[6], 10, 64)
-                bid, _ := strconv.ParseFloat(parts[9], 64)
-                ask, _ := strconv.ParseFloat(parts[19], 64)
-                high, _ := strconv.ParseFloat(parts[33], 64)
-                low, _ := strconv.ParseFloat(parts[34], 64)
-
-                change := price - preClose
-                changePct := 0.0
-                if preClose > 0 {
-                    changePct = (change / preClose) * 100
-                }
-
-                return &Tick{
-                    Symbol:    symbol,
-                    Name:      name,
-                    Price:     price,
-                    Bid:       bid,
-                    Ask:       ask,
-                    Volume:    volume,
-                    Turnover:  change * float64(volume),
-                    High:      high,
-                    Low:       low,
-                    Open:      open,
-                    PreClose:  preClose,
-                    Time:      time.Now(),
-                    Change:    change,
-                    ChangePct: changePct,
-                }, nil
-            }
+    // 每只股票一行: v_sh600000="1~名称~代码~现价~...";
+    for _, line := range strings.Split(string(body), ";") {
+        line = strings.TrimSpace(line)
+        if !strings.HasPrefix(line, "v_") {
+            continue
+        }
+        eq := strings.Index(line, "=")
+        if eq < 0 {
+            continue
+        }
+        symbol, ok := requested[line[len("v_"):eq]]
+        if !ok {
+            continue
         }
+        if tick := parseTencentTick(symbol, strings.Trim(line[eq+1:], "\"")); tick != nil {
+            ticks[symbol] = tick
+        }
+    }
+    return nil
+}
+
+// parseTencentTick 解析腾讯行情字段，代码无效时数据不完整，返回nil
+func parseTencentTick(symbol, data string) *Tick {
+    parts := strings.Split(data, "~")
+    if len(parts) < 40 {
+        return nil
     }
 
-    return nil, fmt.Errorf("failed to parse tick data")
+    name := strings.TrimSpace(parts[1])
+    price, _ := strconv.ParseFloat(parts[3], 64)
+    preClose, _ := strconv.ParseFloat(parts[4], 64)
+    open, _ := strconv.ParseFloat(parts[5], 64)
+    volume, _ := strconv.ParseInt(parts[6], 10, 64)
+    bid, _ := strconv.ParseFloat(parts[9], 64)
+    ask, _ := strconv.ParseFloat(parts[19], 64)
+    high, _ := strconv.ParseFloat(parts[33], 64)
+    low, _ := strconv.ParseFloat(parts[34], 64)
+
+    change := price - preClose
+    changePct := 0.0
+    if preClose > 0 {
+        changePct = (change / preClose) * 100
+    }
+
+    return &Tick{
+        Symbol:    symbol,
+        Name:      name,
+        Price:     price,
+        Bid:       bid,
+        Ask:       ask,
+        Volume:    volume,
+        Turnover:  change * float64(volume),
+        High:      high,
+        Low:       low,
+        Open:      open,
+        PreClose:  preClose,
+        Time:      time.Now(),
+        Change:    change,
+        ChangePct: changePct,
+    }
 }
 
 func (tp *TencentProvider) FetchKLines(ctx context.Context, symbol string, days int) ([]KLine, error) {
@@ -0,0 +1,160 @@
+package market
+
+import "time"
+
+// DefaultQuoteCacheTTL 行情缓存的默认有效期
+const DefaultQuoteCacheTTL = time.Second
+
+// quoteFetcher GetQuoteCached未命中时获取行情，测试中替换
+var quoteFetcher = FetchTick
+
+// cachedQuote 缓存的一条行情；请求进行中时done未关闭，同一股票的并发请求等待其结果
+type cachedQuote struct {
+	done      chan struct{}
+	quote     *Quote
+	err       error
+	fetchedAt time.Time
+}
+
+// QuoteCacheStats 行情缓存命中统计
+type QuoteCacheStats struct {
+	TTL     time.Duration `json:"ttl"`
+	Entries int           `json:"entries"`
+	Hits    uint64        `json:"hits"`
+	Misses  uint64        `json:"misses"`
+	HitRate float64       `json:"hit_rate"`
+}
+
+// NewMarketProvider 创建市场数据提供者，同一股票在ttl内的重复行情请求只访问一次数据源
+func NewMarketProvider(ttl time.Duration) *MarketProvider {
+	return &MarketProvider{
+		cacheTTL: ttl,
+		quotes:   make(map[string]*cachedQuote),
+	}
+}
+
+// SetQuoteCacheTTL 设置行情缓存有效期，0表示不缓存
+func (p *MarketProvider) SetQuoteCacheTTL(ttl time.Duration) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+	p.cacheTTL = ttl
+}
+
+// GetQuoteCached 获取最新行情，有效期内直接返回缓存；缓存过期时同一股票的并发请求
+// 合并为一次数据源调用。获取失败的结果不缓存
+func (p *MarketProvider) GetQuoteCached(symbol string) (*Quote, error) {
+	p.cacheMu.Lock()
+	if p.cacheTTL <= 0 {
+		p.misses++
+		p.cacheMu.Unlock()
+		return quoteFetcher(symbol)
+	}
+	if p.quotes == nil {
+		p.quotes = make(map[string]*cachedQuote)
+	}
+
+	now := time.Now()
+	if entry, ok := p.quotes[symbol]; ok {
+		select {
+		case <-entry.done:
+			if now.Sub(entry.fetchedAt) < p.cacheTTL {
+				p.hits++
+				p.cacheMu.Unlock()
+				return copyQuote(entry.quote), nil
+			}
+		default:
+			// 其他调用方正在获取该股票，等待其结果
+			p.hits++
+			p.cacheMu.Unlock()
+			<-entry.done
+			if entry.err != nil {
+				return nil, entry.err
+			}
+			return copyQuote(entry.quote), nil
+		}
+	}
+
+	entry := &cachedQuote{done: make(chan struct{})}
+	p.quotes[symbol] = entry
+	p.misses++
+	p.cacheMu.Unlock()
+
+	entry.quote, entry.err = quoteFetcher(symbol)
+	entry.fetchedAt = time.Now()
+
+	p.cacheMu.Lock()
+	if entry.err != nil && p.quotes[symbol] == entry {
+		delete(p.quotes, symbol)
+	}
+	p.cacheMu.Unlock()
+	close(entry.done)
+
+	if entry.err != nil {
+		return nil, entry.err
+	}
+	return copyQuote(entry.quote), nil
+}
+
+// storeQuotes 将批量获取的行情写入缓存
+func (p *MarketProvider) storeQuotes(quotes map[string]Quote) {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+	if p.cacheTTL <= 0 {
+		return
+	}
+	if p.quotes == nil {
+		p.quotes = make(map[string]*cachedQuote)
+	}
+
+	now := time.Now()
+	for symbol, quote := range quotes {
+		if entry, ok := p.quotes[symbol]; ok {
+			select {
+			case <-entry.done:
+			default:
+				continue // 单只请求进行中，由其写入结果
+			}
+		}
+		quote := quote
+		entry := &cachedQuote{done: make(chan struct{}), quote: &quote, fetchedAt: now}
+		close(entry.done)
+		p.quotes[symbol] = entry
+	}
+}
+
+// QuoteCacheStats 获取行情缓存命中统计，并清理已过期的缓存
+func (p *MarketProvider) QuoteCacheStats() QuoteCacheStats {
+	p.cacheMu.Lock()
+	defer p.cacheMu.Unlock()
+
+	now := time.Now()
+	for symbol, entry := range p.quotes {
+		select {
+		case <-entry.done:
+			if now.Sub(entry.fetchedAt) >= p.cacheTTL {
+				delete(p.quotes, symbol)
+			}
+		default:
+		}
+	}
+
+	stats := QuoteCacheStats{
+		TTL:     p.cacheTTL,
+		Entries: len(p.quotes),
+		Hits:    p.hits,
+		Misses:  p.misses,
+	}
+	if total := p.hits + p.misses; total > 0 {
+		stats.HitRate = float64(p.hits) / float64(total)
+	}
+	return stats
+}
+
+// copyQuote 返回行情副本，避免调用方修改缓存内容
+func copyQuote(quote *Quote) *Quote {
+	if quote == nil {
+		return nil
+	}
+	copied := *quote
+	return &copied
+}
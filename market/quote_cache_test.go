@@ -0,0 +1,78 @@
+package market
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestMarketProviderQuoteCache(t *testing.T) {
+	var calls int32
+	fail := false
+	quoteFetcher = func(symbol string) (*Tick, error) {
+		atomic.AddInt32(&calls, 1)
+		time.Sleep(5 * time.Millisecond)
+		if fail {
+			return nil, errors.New("rate limited")
+		}
+		return &Tick{Symbol: symbol, Close: 10}, nil
+	}
+	defer func() { quoteFetcher = FetchTick }()
+
+	provider := NewMarketProvider(50 * time.Millisecond)
+
+	// 并发请求同一股票只访问一次数据源
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if quote, err := provider.GetQuoteCached("sh600000"); err != nil || quote.Close != 10 {
+				t.Errorf("GetQuoteCached = %+v, %v", quote, err)
+			}
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Fatalf("fetched %d times, want 1", calls)
+	}
+
+	// 修改返回值不影响缓存
+	quote, _ := provider.GetQuoteCached("sh600000")
+	quote.Close = 0
+	if quote, _ := provider.GetQuoteCached("sh600000"); quote.Close != 10 || calls != 1 {
+		t.Fatalf("cached quote = %+v after %d fetches", quote, calls)
+	}
+
+	stats := provider.QuoteCacheStats()
+	if stats.Hits != 6 || stats.Misses != 1 || stats.Entries != 1 || stats.HitRate != 6.0/7 {
+		t.Fatalf("stats = %+v, want 6 hits 1 miss", stats)
+	}
+
+	// 过期后重新获取，失败结果不缓存
+	time.Sleep(60 * time.Millisecond)
+	fail = true
+	if _, err := provider.GetQuoteCached("sh600000"); err == nil {
+		t.Fatal("expected fetch error after expiry")
+	}
+	fail = false
+	if _, err := provider.GetQuoteCached("sh600000"); err != nil || calls != 3 {
+		t.Fatalf("GetQuoteCached after failure: %v, %d fetches", err, calls)
+	}
+
+	// 批量获取的行情写入缓存
+	provider.storeQuotes(map[string]Quote{"sz000001": {Symbol: "sz000001", Close: 12}})
+	if quote, err := provider.GetQuoteCached("sz000001"); err != nil || quote.Close != 12 || calls != 3 {
+		t.Fatalf("batched quote = %+v, %v after %d fetches", quote, err, calls)
+	}
+
+	// 零值不缓存
+	var uncached MarketProvider
+	uncached.GetQuoteCached("sh600000")
+	uncached.GetQuoteCached("sh600000")
+	if calls != 5 {
+		t.Fatalf("zero value provider fetched %d times in total, want 5", calls)
+	}
+}
@@ -0,0 +1,55 @@
+package market
+
+import (
+	"sync"
+
+	"cloudquant/market/providers"
+)
+
+// ProviderRegistry 行情数据源注册表：按优先级选择主数据源，定期健康检查并记录延迟，
+// 主数据源失败时自动切换到其他健康的数据源
+type ProviderRegistry = providers.ProviderManager
+
+// ProviderStatus 数据源状态
+type ProviderStatus = providers.ProviderStatus
+
+var (
+	registryMu       sync.RWMutex
+	providerRegistry = NewDefaultProviderRegistry()
+)
+
+// NewDefaultProviderRegistry 创建包含新浪、东方财富、腾讯数据源的注册表
+func NewDefaultProviderRegistry() *ProviderRegistry {
+	registry := providers.NewProviderManager()
+	registry.AddProvider(providers.NewSinaProvider())
+	registry.AddProvider(providers.NewEastmoneyProvider())
+	registry.AddProvider(providers.NewTencentProvider())
+	return registry
+}
+
+// DefaultProviderRegistry 获取FetchTick使用的数据源注册表
+func DefaultProviderRegistry() *ProviderRegistry {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	return providerRegistry
+}
+
+// SetProviderRegistry 替换数据源注册表，传nil恢复默认注册表
+func SetProviderRegistry(registry *ProviderRegistry) {
+	if registry == nil {
+		registry = NewDefaultProviderRegistry()
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	providerRegistry = registry
+}
+
+// SetActiveProvider 切换主数据源，名称未注册时返回providers.ErrProviderNotFound
+func SetActiveProvider(name string) error {
+	return DefaultProviderRegistry().SetPrimaryProvider(name)
+}
+
+// ActiveProvider 当前主数据源名称
+func ActiveProvider() string {
+	return DefaultProviderRegistry().GetPrimaryProvider()
+}
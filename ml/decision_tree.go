@@ -1,10 +1,9 @@
 package ml
 
 import (
-	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
-	"os"
 )
 
 type DecisionTree struct {
@@ -23,6 +22,8 @@ type TreeNode struct {
 	RightChild int     `json:"right_child"`
 	ClassLabel int     `json:"class_label"`
 	IsLeaf     bool    `json:"is_leaf"`
+	// ImpurityDecrease 该分裂带来的Gini不纯度下降，按节点样本数加权
+	ImpurityDecrease float64 `json:"impurity_decrease,omitempty"`
 }
 
 func (dt *DecisionTree) Train(features [][]float64, labels []int) error {
@@ -67,25 +68,42 @@ func (dt *DecisionTree) Predict(features []float64) (int, float64, error) {
 	}
 }
 
+// FeatureImportance 按各特征在所有分裂上的不纯度下降总和计算重要性，归一化后之和为1。
+// 模型未训练或没有任何分裂时返回空map
+func (dt *DecisionTree) FeatureImportance() map[int]float64 {
+	importance := make(map[int]float64)
+	var total float64
+	for _, node := range dt.nodes {
+		if node.IsLeaf || node.ImpurityDecrease <= 0 {
+			continue
+		}
+		importance[node.FeatureIdx] += node.ImpurityDecrease
+		total += node.ImpurityDecrease
+	}
+	for idx := range importance {
+		importance[idx] /= total
+	}
+	return importance
+}
+
 func (dt *DecisionTree) Save(path string) error {
 	if len(dt.nodes) == 0 {
 		return errors.New("model not trained")
 	}
-	payload, err := json.Marshal(dt.nodes)
-	if err != nil {
-		return err
-	}
-	return os.WriteFile(path, payload, 0o600)
+	return writeModelFile(path, "decision_tree", dt.nodes)
 }
 
 func (dt *DecisionTree) Load(path string) error {
-	payload, err := os.ReadFile(path)
+	var nodes []TreeNode
+	version, err := readModelFile(path, "decision_tree", &nodes)
 	if err != nil {
 		return err
 	}
-	var nodes []TreeNode
-	if err := json.Unmarshal(payload, &nodes); err != nil {
-		return err
+	if version == legacyFormatVersion {
+		absoluteChildIndices(nodes)
+	}
+	if err := validateNodes(nodes); err != nil {
+		return fmt.Errorf("%w: %s: %v", ErrModelFormat, path, err)
 	}
 	dt.nodes = nodes
 	return nil
@@ -132,18 +150,31 @@ func (dt *DecisionTree) buildNode(features [][]float64, labels []int, depth int,
 	rightNodes := dt.buildNode(rightFeatures, rightLabels, depth+1, maxDepth)
 
 	root := TreeNode{
-		FeatureIdx: bestFeature,
-		Threshold:  threshold,
-		LeftChild:  1,
-		RightChild: 1 + len(leftNodes),
-		ClassLabel: label,
-		IsLeaf:     false,
+		FeatureIdx:       bestFeature,
+		Threshold:        threshold,
+		LeftChild:        1,
+		RightChild:       1 + len(leftNodes),
+		ClassLabel:       label,
+		IsLeaf:           false,
+		ImpurityDecrease: float64(len(labels)) * (gini(labels) - weightedGini(leftLabels, rightLabels)),
 	}
 
 	nodes := make([]TreeNode, 0, 1+len(leftNodes)+len(rightNodes))
 	nodes = append(nodes, root)
-	nodes = append(nodes, leftNodes...)
-	nodes = append(nodes, rightNodes...)
+	nodes = append(nodes, offsetNodes(leftNodes, root.LeftChild)...)
+	nodes = append(nodes, offsetNodes(rightNodes, root.RightChild)...)
+	return nodes
+}
+
+// offsetNodes 子树拼接到父节点数组时，将其子节点下标平移到绝对位置
+func offsetNodes(nodes []TreeNode, offset int) []TreeNode {
+	for i := range nodes {
+		if nodes[i].IsLeaf {
+			continue
+		}
+		nodes[i].LeftChild += offset
+		nodes[i].RightChild += offset
+	}
 	return nodes
 }
 
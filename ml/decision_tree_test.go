@@ -1,6 +1,11 @@
 package ml
 
-import "testing"
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+)
 
 func TestDecisionTreeTrainPredict(t *testing.T) {
 	features := [][]float64{
@@ -26,3 +31,70 @@ func TestDecisionTreeTrainPredict(t *testing.T) {
 		t.Fatalf("expected confidence > 0")
 	}
 }
+
+func TestDecisionTreeFeatureImportanceDominantFeature(t *testing.T) {
+	// 标签只由第0个特征决定，其余特征为与标签无关的循环噪声
+	var features [][]float64
+	var labels []int
+	for i := 0; i < 40; i++ {
+		signal := float64(i) / 40
+		label := 0
+		if signal > 0.5 {
+			label = 2
+		}
+		features = append(features, []float64{signal, float64(i%3) / 3, float64(i%5) / 5})
+		labels = append(labels, label)
+	}
+
+	model := NewDecisionTree(4)
+	if importance := model.FeatureImportance(); len(importance) != 0 {
+		t.Fatalf("untrained model importance = %v, want empty", importance)
+	}
+	if err := model.Train(features, labels); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	importance := model.FeatureImportance()
+	var total float64
+	for _, value := range importance {
+		total += value
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Fatalf("importance sums to %v, want 1: %v", total, importance)
+	}
+	if importance[0] < 0.9 {
+		t.Fatalf("dominant feature importance = %v, want >= 0.9: %v", importance[0], importance)
+	}
+
+	// 保存后重新加载仍可计算重要性
+	path := filepath.Join(t.TempDir(), "tree.json")
+	if err := model.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	loaded := &DecisionTree{}
+	if err := loaded.Load(path); err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+	if got := loaded.FeatureImportance()[0]; math.Abs(got-importance[0]) > 1e-9 {
+		t.Fatalf("loaded importance = %v, want %v", got, importance[0])
+	}
+
+	names := []string{"momentum", "noise_a", "noise_b"}
+	provider := NewTreeModelProvider(model, names)
+	result, err := provider.Predict(context.Background(), map[string]float64{"momentum": 0.9, "noise_a": 0, "noise_b": 0})
+	if err != nil {
+		t.Fatalf("provider predict failed: %v", err)
+	}
+	prediction := result.(map[string]interface{})
+	if prediction["signal"] != "buy" {
+		t.Errorf("signal = %v, want buy", prediction["signal"])
+	}
+	named := prediction["feature_importance"].(map[string]float64)
+	if named["momentum"] != importance[0] {
+		t.Errorf("named importance = %v, want momentum %v", named, importance[0])
+	}
+
+	if _, err := provider.Predict(context.Background(), map[string]float64{"momentum": 0.9}); err == nil {
+		t.Error("expected error for missing feature")
+	}
+}
@@ -0,0 +1,174 @@
+package ml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+// ClassLabels GenerateLabels产生的全部标签：0卖出、1持有、2买入
+var ClassLabels = []int{0, 1, 2}
+
+// LabelName 标签对应的信号名称
+func LabelName(label int) string {
+	if name, ok := labelSignals[label]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ClassMetrics 单个类别的评估指标
+type ClassMetrics struct {
+	Label     int
+	Precision float64
+	Recall    float64
+	F1        float64
+	Support   int // 该类别的真实样本数
+}
+
+// ClassificationReport 多分类评估结果
+type ClassificationReport struct {
+	Labels []int
+	// Confusion[i][j] 为真实标签Labels[i]被预测为Labels[j]的样本数
+	Confusion      [][]int
+	Classes        []ClassMetrics
+	Accuracy       float64
+	MacroPrecision float64
+	MacroRecall    float64
+	MacroF1        float64
+	Total          int // 参与评估的样本数
+	Failed         int // 预测出错或标签不在Labels中的样本数
+}
+
+// EvaluateClassifier 在测试集上计算混淆矩阵、各类别及宏平均的precision/recall/F1
+func EvaluateClassifier(model MLModel, testX [][]float64, testY []int, labels []int) *ClassificationReport {
+	index := make(map[int]int, len(labels))
+	for i, label := range labels {
+		index[label] = i
+	}
+
+	report := &ClassificationReport{
+		Labels:    labels,
+		Confusion: make([][]int, len(labels)),
+		Classes:   make([]ClassMetrics, len(labels)),
+	}
+	for i := range report.Confusion {
+		report.Confusion[i] = make([]int, len(labels))
+	}
+
+	var correct int
+	for i, feature := range testX {
+		predicted, _, err := model.Predict(feature)
+		actualIdx, actualOK := index[testY[i]]
+		predictedIdx, predictedOK := index[predicted]
+		if err != nil || !actualOK || !predictedOK {
+			report.Failed++
+			continue
+		}
+		report.Confusion[actualIdx][predictedIdx]++
+		report.Total++
+		if actualIdx == predictedIdx {
+			correct++
+		}
+	}
+	if report.Total > 0 {
+		report.Accuracy = float64(correct) / float64(report.Total)
+	}
+
+	for i, label := range labels {
+		var predicted, actual int
+		for j := range labels {
+			predicted += report.Confusion[j][i]
+			actual += report.Confusion[i][j]
+		}
+		truePositive := report.Confusion[i][i]
+
+		metrics := ClassMetrics{Label: label, Support: actual}
+		if predicted > 0 {
+			metrics.Precision = float64(truePositive) / float64(predicted)
+		}
+		if actual > 0 {
+			metrics.Recall = float64(truePositive) / float64(actual)
+		}
+		if metrics.Precision+metrics.Recall > 0 {
+			metrics.F1 = 2 * metrics.Precision * metrics.Recall / (metrics.Precision + metrics.Recall)
+		}
+		report.Classes[i] = metrics
+
+		report.MacroPrecision += metrics.Precision
+		report.MacroRecall += metrics.Recall
+		report.MacroF1 += metrics.F1
+	}
+	if len(labels) > 0 {
+		report.MacroPrecision /= float64(len(labels))
+		report.MacroRecall /= float64(len(labels))
+		report.MacroF1 /= float64(len(labels))
+	}
+
+	return report
+}
+
+// FoldResult 单个验证折的评估结果
+type FoldResult struct {
+	Fold      int
+	TrainSize int
+	TestSize  int
+	Report    *ClassificationReport
+}
+
+// CrossValidationResult 滚动前推交叉验证结果
+type CrossValidationResult struct {
+	Folds        []FoldResult
+	MeanAccuracy float64
+	StdAccuracy  float64
+}
+
+// WalkForwardValidate 滚动前推交叉验证：将按时间排序的样本等分为folds+1段，
+// 第k折在前k段（逐步扩大的窗口）上训练并在第k+1段上测试，避免用未来数据训练
+func WalkForwardValidate(newModel func() (MLModel, error), features [][]float64, labels []int, folds int) (*CrossValidationResult, error) {
+	if folds < 2 {
+		return nil, errors.New("folds must be at least 2")
+	}
+	if len(features) != len(labels) {
+		return nil, errors.New("features and labels size mismatch")
+	}
+	segment := len(features) / (folds + 1)
+	if segment == 0 {
+		return nil, fmt.Errorf("%d samples are not enough for %d folds", len(features), folds)
+	}
+
+	result := &CrossValidationResult{Folds: make([]FoldResult, 0, folds)}
+	for k := 1; k <= folds; k++ {
+		trainEnd := k * segment
+		testEnd := trainEnd + segment
+		if k == folds {
+			testEnd = len(features)
+		}
+
+		model, err := newModel()
+		if err != nil {
+			return nil, err
+		}
+		if err := model.Train(features[:trainEnd], labels[:trainEnd]); err != nil {
+			return nil, fmt.Errorf("fold %d: %w", k, err)
+		}
+
+		report := EvaluateClassifier(model, features[trainEnd:testEnd], labels[trainEnd:testEnd], ClassLabels)
+		result.Folds = append(result.Folds, FoldResult{
+			Fold:      k,
+			TrainSize: trainEnd,
+			TestSize:  testEnd - trainEnd,
+			Report:    report,
+		})
+		result.MeanAccuracy += report.Accuracy
+	}
+	result.MeanAccuracy /= float64(folds)
+
+	var variance float64
+	for _, fold := range result.Folds {
+		variance += (fold.Report.Accuracy - result.MeanAccuracy) * (fold.Report.Accuracy - result.MeanAccuracy)
+	}
+	result.StdAccuracy = math.Sqrt(variance / float64(folds))
+
+	return result, nil
+}
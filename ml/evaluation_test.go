@@ -0,0 +1,112 @@
+package ml
+
+import (
+	"math"
+	"testing"
+)
+
+// fixedModel 按特征第0位直接返回标签
+type fixedModel struct{ DecisionTree }
+
+func (f *fixedModel) Predict(features []float64) (int, float64, error) {
+	return int(features[0]), 1, nil
+}
+
+func TestEvaluateClassifierPerClassMetrics(t *testing.T) {
+	// 模型从不预测卖出：实际卖出的2个样本被判为持有
+	predicted := []int{1, 1, 1, 1, 2, 2, 2, 1}
+	actual := []int{0, 0, 1, 1, 2, 2, 2, 2}
+	testX := make([][]float64, len(predicted))
+	for i, label := range predicted {
+		testX[i] = []float64{float64(label)}
+	}
+
+	report := EvaluateClassifier(&fixedModel{}, testX, actual, ClassLabels)
+
+	wantConfusion := [][]int{{0, 2, 0}, {0, 2, 0}, {0, 1, 3}}
+	for i := range wantConfusion {
+		for j := range wantConfusion[i] {
+			if report.Confusion[i][j] != wantConfusion[i][j] {
+				t.Fatalf("confusion = %v, want %v", report.Confusion, wantConfusion)
+			}
+		}
+	}
+
+	sell := report.Classes[0]
+	if sell.Precision != 0 || sell.Recall != 0 || sell.F1 != 0 || sell.Support != 2 {
+		t.Errorf("sell metrics = %+v, want zeros with support 2", sell)
+	}
+	buy := report.Classes[2]
+	if buy.Precision != 1 || buy.Recall != 0.75 {
+		t.Errorf("buy metrics = %+v, want precision 1 recall 0.75", buy)
+	}
+
+	// hold: precision 2/5, recall 1
+	wantMacroRecall := (0 + 1 + 0.75) / 3
+	wantMacroPrecision := (0 + 0.4 + 1) / 3
+	if math.Abs(report.MacroRecall-wantMacroRecall) > 1e-9 || math.Abs(report.MacroPrecision-wantMacroPrecision) > 1e-9 {
+		t.Errorf("macro precision/recall = %.4f/%.4f, want %.4f/%.4f", report.MacroPrecision, report.MacroRecall, wantMacroPrecision, wantMacroRecall)
+	}
+	if report.Accuracy != 5.0/8 || report.Total != 8 {
+		t.Errorf("accuracy = %v over %d samples, want 0.625 over 8", report.Accuracy, report.Total)
+	}
+}
+
+// trainCountModel 记录训练样本数，预测标签为训练集中第0个特征的最大值
+type trainCountModel struct {
+	DecisionTree
+	trained *[]int
+	label   int
+}
+
+func (m *trainCountModel) Train(features [][]float64, labels []int) error {
+	*m.trained = append(*m.trained, len(features))
+	for _, feature := range features {
+		if int(feature[0]) > m.label {
+			m.label = int(feature[0])
+		}
+	}
+	return nil
+}
+
+func (m *trainCountModel) Predict(features []float64) (int, float64, error) {
+	return m.label, 1, nil
+}
+
+func TestWalkForwardValidateExpandingWindow(t *testing.T) {
+	// 前一半样本标签为1，后一半为2：只用过去数据训练的模型在后段折上失准
+	features := make([][]float64, 12)
+	labels := make([]int, 12)
+	for i := range features {
+		labels[i] = 1
+		if i >= 6 {
+			labels[i] = 2
+		}
+		features[i] = []float64{float64(labels[i])}
+	}
+
+	var trained []int
+	newModel := func() (MLModel, error) { return &trainCountModel{trained: &trained}, nil }
+
+	result, err := WalkForwardValidate(newModel, features, labels, 3)
+	if err != nil {
+		t.Fatalf("WalkForwardValidate: %v", err)
+	}
+
+	if want := []int{3, 6, 9}; len(trained) != 3 || trained[0] != want[0] || trained[1] != want[1] || trained[2] != want[2] {
+		t.Fatalf("training window sizes = %v, want expanding %v", trained, want)
+	}
+	wantAccuracy := []float64{1, 0, 1}
+	for i, fold := range result.Folds {
+		if fold.TestSize != 3 || fold.Report.Accuracy != wantAccuracy[i] {
+			t.Errorf("fold %d: test size %d accuracy %.2f, want 3 and %.2f", fold.Fold, fold.TestSize, fold.Report.Accuracy, wantAccuracy[i])
+		}
+	}
+	if math.Abs(result.MeanAccuracy-2.0/3) > 1e-9 || math.Abs(result.StdAccuracy-math.Sqrt(2.0/9)) > 1e-9 {
+		t.Errorf("mean/std accuracy = %.4f/%.4f, want 0.6667/0.4714", result.MeanAccuracy, result.StdAccuracy)
+	}
+
+	if _, err := WalkForwardValidate(newModel, features[:2], labels[:2], 3); err == nil {
+		t.Error("expected error when there are fewer samples than folds")
+	}
+}
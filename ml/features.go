@@ -2,6 +2,7 @@ package ml
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"cloudquant/market"
@@ -31,12 +32,17 @@ type MLFeatures struct {
 	Timestamp time.Time
 }
 
+// FeatureWarmup 计算首个特征所需的K线数量（MA60）。ExtractFeatures返回的第j个特征对应第j+FeatureWarmup-1根K线
+const FeatureWarmup = 60
+
 func ExtractFeatures(klines []market.KLine) ([]MLFeatures, error) {
 	if len(klines) == 0 {
 		return nil, errors.New("klines is empty")
 	}
+	if len(klines) < FeatureWarmup {
+		return nil, fmt.Errorf("insufficient klines: got %d, need at least %d", len(klines), FeatureWarmup)
+	}
 
-	maxPeriod := 60
 	features := make([]MLFeatures, 0, len(klines))
 	closes := make([]float64, len(klines))
 	volumes := make([]int64, len(klines))
@@ -47,7 +53,7 @@ func ExtractFeatures(klines []market.KLine) ([]MLFeatures, error) {
 
 	var prevRSI float64
 	for i := range klines {
-		if i+1 < maxPeriod {
+		if i+1 < FeatureWarmup {
 			continue
 		}
 		window := closes[:i+1]
@@ -1,6 +1,9 @@
 package ml
 
-import "context"
+import (
+	"context"
+	"fmt"
+)
 
 type MLModel interface {
 	Train(features [][]float64, labels []int) error
@@ -12,3 +15,72 @@ type MLModel interface {
 type ModelProvider interface {
 	Predict(ctx context.Context, features map[string]float64) (interface{}, error)
 }
+
+// labelSignals 训练标签到交易信号的映射，与GenerateLabels一致
+var labelSignals = map[int]string{0: "sell", 1: "hold", 2: "buy"}
+
+// ExplainableModel 可给出特征重要性的模型，DecisionTree和RandomForest均实现
+type ExplainableModel interface {
+	Predict(features []float64) (int, float64, error)
+	FeatureImportance() map[int]float64
+}
+
+// TreeModelProvider 将树模型适配为按特征名输入的ModelProvider
+type TreeModelProvider struct {
+	tree         ExplainableModel
+	featureNames []string
+}
+
+// NewTreeModelProvider 创建树模型提供者，featureNames为训练时特征向量的顺序，为空时使用FeatureNames
+func NewTreeModelProvider(tree ExplainableModel, featureNames []string) *TreeModelProvider {
+	if len(featureNames) == 0 {
+		featureNames = FeatureNames()
+	}
+	return &TreeModelProvider{tree: tree, featureNames: featureNames}
+}
+
+// Predict 按特征名组装特征向量并预测，结果包含signal、confidence、probability和feature_importance
+func (p *TreeModelProvider) Predict(ctx context.Context, features map[string]float64) (interface{}, error) {
+	vector := make([]float64, len(p.featureNames))
+	for i, name := range p.featureNames {
+		value, ok := features[name]
+		if !ok {
+			return nil, fmt.Errorf("missing feature %s", name)
+		}
+		vector[i] = value
+	}
+
+	label, confidence, err := p.tree.Predict(vector)
+	if err != nil {
+		return nil, err
+	}
+	signal, ok := labelSignals[label]
+	if !ok {
+		signal = "hold"
+	}
+
+	return map[string]interface{}{
+		"signal":             signal,
+		"confidence":         confidence,
+		"probability":        confidence,
+		"feature_importance": p.FeatureImportance(),
+	}, nil
+}
+
+// FeatureImportance 以特征名为键的特征重要性
+func (p *TreeModelProvider) FeatureImportance() map[string]float64 {
+	return NamedFeatureImportance(p.tree.FeatureImportance(), p.featureNames)
+}
+
+// NamedFeatureImportance 将按特征下标的重要性转换为按特征名，越界下标以feature_<idx>命名
+func NamedFeatureImportance(importance map[int]float64, names []string) map[string]float64 {
+	named := make(map[string]float64, len(importance))
+	for idx, value := range importance {
+		if idx >= 0 && idx < len(names) {
+			named[names[idx]] = value
+		} else {
+			named[fmt.Sprintf("feature_%d", idx)] = value
+		}
+	}
+	return named
+}
@@ -0,0 +1,114 @@
+package ml
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"os"
+)
+
+// modelMagic 模型文件头的魔数
+const modelMagic = "CQMODEL"
+
+// ModelFormatVersion 当前模型文件格式版本，序列化结构变化时递增
+const ModelFormatVersion = 1
+
+// legacyFormatVersion 无文件头的旧模型文件：只有JSON载荷，决策树子节点下标相对于节点自身，
+// 加载时由absoluteChildIndices转换为当前的绝对下标
+const legacyFormatVersion = 0
+
+var (
+	// ErrModelFormat 模型文件不是可识别的格式或内容已损坏
+	ErrModelFormat = errors.New("invalid model file")
+	// ErrModelVersion 模型文件由不兼容的格式版本写入
+	ErrModelVersion = errors.New("unsupported model format version")
+)
+
+// writeModelFile 写入模型文件：首行为"魔数 版本 模型类型 载荷长度 CRC32"，其后为JSON载荷
+func writeModelFile(path, modelType string, model interface{}) error {
+	payload, err := json.Marshal(model)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %d %s %d %08x\n", modelMagic, ModelFormatVersion, modelType, len(payload), crc32.ChecksumIEEE(payload))
+	buf.Write(payload)
+	return os.WriteFile(path, buf.Bytes(), 0o600)
+}
+
+// readModelFile 读取并校验模型文件，返回文件的格式版本。魔数、版本、模型类型、长度或校验和不符时返回描述性错误；
+// 没有文件头但内容为完整JSON的旧文件按legacyFormatVersion解析
+func readModelFile(path, modelType string, model interface{}) (int, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	header, _ := bufio.NewReader(bytes.NewReader(data)).ReadString('\n')
+
+	var magic, fileType string
+	var version, length int
+	var checksum uint32
+	if n, _ := fmt.Sscanf(header, "%s %d %s %d %x\n", &magic, &version, &fileType, &length, &checksum); magic != modelMagic || n != 5 {
+		if len(bytes.TrimSpace(data)) == 0 || !json.Valid(data) {
+			return 0, fmt.Errorf("%w: %s: unrecognized header", ErrModelFormat, path)
+		}
+		if err := json.Unmarshal(data, model); err != nil {
+			return 0, fmt.Errorf("%w: %s: legacy model: %v", ErrModelFormat, path, err)
+		}
+		return legacyFormatVersion, nil
+	}
+	if version != ModelFormatVersion {
+		return 0, fmt.Errorf("%w: %s: file version %d, supported version %d", ErrModelVersion, path, version, ModelFormatVersion)
+	}
+	if fileType != modelType {
+		return 0, fmt.Errorf("%w: %s: contains a %s model, expected %s", ErrModelFormat, path, fileType, modelType)
+	}
+
+	payload := data[len(header):]
+	if len(payload) != length {
+		return 0, fmt.Errorf("%w: %s: payload is %d bytes, header declares %d (truncated?)", ErrModelFormat, path, len(payload), length)
+	}
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return 0, fmt.Errorf("%w: %s: checksum mismatch", ErrModelFormat, path)
+	}
+	if err := json.Unmarshal(payload, model); err != nil {
+		return 0, fmt.Errorf("%w: %s: %v", ErrModelFormat, path, err)
+	}
+	return version, nil
+}
+
+// absoluteChildIndices 将旧格式中相对于节点自身的子节点下标转换为绝对下标
+func absoluteChildIndices(nodes []TreeNode) {
+	for i := range nodes {
+		if nodes[i].IsLeaf {
+			continue
+		}
+		nodes[i].LeftChild += i
+		nodes[i].RightChild += i
+	}
+}
+
+// validateNodes 校验决策树结构：子节点下标必须在范围内且位于父节点之后，避免加载出无法预测的树
+func validateNodes(nodes []TreeNode) error {
+	if len(nodes) == 0 {
+		return errors.New("tree has no nodes")
+	}
+	for i, node := range nodes {
+		if node.IsLeaf {
+			continue
+		}
+		if node.FeatureIdx < 0 {
+			return fmt.Errorf("node %d: negative feature index", i)
+		}
+		for _, child := range []int{node.LeftChild, node.RightChild} {
+			if child <= i || child >= len(nodes) {
+				return fmt.Errorf("node %d: child index %d out of range", i, child)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,124 @@
+package ml
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func trainedTestTree(t *testing.T) *DecisionTree {
+	t.Helper()
+	model := NewDecisionTree(2)
+	if err := model.Train([][]float64{{0.1, 0.2}, {0.2, 0.1}, {0.9, 0.8}, {0.8, 0.9}}, []int{0, 0, 2, 2}); err != nil {
+		t.Fatalf("train failed: %v", err)
+	}
+	return model
+}
+
+func TestLoadModelRejectsTruncatedFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tree.model")
+	if err := trainedTestTree(t).Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	if _, err := LoadModel("decision_tree", path); err != nil {
+		t.Fatalf("load of intact file failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+	for _, size := range []int{len(data) - 1, len(data) / 2, 4, 0} {
+		truncated := filepath.Join(dir, "truncated.model")
+		if err := os.WriteFile(truncated, data[:size], 0o600); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+		model, err := LoadModel("decision_tree", truncated)
+		if !errors.Is(err, ErrModelFormat) {
+			t.Errorf("truncated to %d bytes: err = %v, want ErrModelFormat", size, err)
+		}
+		if model != nil {
+			t.Errorf("truncated to %d bytes: got a model, want nil", size)
+		}
+	}
+}
+
+func TestLoadModelRejectsVersionAndTypeMismatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tree.model")
+	if err := trainedTestTree(t).Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+
+	future := filepath.Join(dir, "future.model")
+	if err := os.WriteFile(future, []byte(strings.Replace(string(data), modelMagic+" 1 ", modelMagic+" 99 ", 1)), 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, err := LoadModel("decision_tree", future); !errors.Is(err, ErrModelVersion) || !strings.Contains(err.Error(), "99") {
+		t.Errorf("err = %v, want ErrModelVersion mentioning version 99", err)
+	}
+
+	if _, err := LoadModel("random_forest", path); !errors.Is(err, ErrModelFormat) {
+		t.Errorf("loading a decision tree as a forest: err = %v, want ErrModelFormat", err)
+	}
+
+	garbage := filepath.Join(dir, "garbage.model")
+	if err := os.WriteFile(garbage, []byte("not a model\n"), 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if _, err := LoadModel("decision_tree", garbage); !errors.Is(err, ErrModelFormat) {
+		t.Errorf("garbage file: err = %v, want ErrModelFormat", err)
+	}
+}
+
+func TestLoadModelConvertsLegacyChildIndices(t *testing.T) {
+	// 无版本头的旧格式：节点1的子节点下标相对于自身，即绝对下标2和3
+	const legacyTree = `[` +
+		`{"feature_idx":0,"threshold":0.5,"left_child":1,"right_child":4},` +
+		`{"feature_idx":1,"threshold":0.5,"left_child":1,"right_child":2},` +
+		`{"feature_idx":-1,"left_child":-1,"right_child":-1,"class_label":0,"is_leaf":true},` +
+		`{"feature_idx":-1,"left_child":-1,"right_child":-1,"class_label":1,"is_leaf":true},` +
+		`{"feature_idx":-1,"left_child":-1,"right_child":-1,"class_label":2,"is_leaf":true}]`
+	dir := t.TempDir()
+
+	tree := filepath.Join(dir, "legacy_tree.model")
+	if err := os.WriteFile(tree, []byte(legacyTree), 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	forest := filepath.Join(dir, "legacy_forest.model")
+	if err := os.WriteFile(forest, []byte(`{"config":{"max_depth":2},"trees":[{"features":[0,1],"nodes":`+legacyTree+`}]}`), 0o600); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	for modelType, path := range map[string]string{"decision_tree": tree, "random_forest": forest} {
+		model, err := LoadModel(modelType, path)
+		if err != nil {
+			t.Fatalf("%s: load legacy file: %v", modelType, err)
+		}
+		for _, c := range []struct {
+			features []float64
+			want     int
+		}{{[]float64{0.1, 0.1}, 0}, {[]float64{0.1, 0.9}, 1}, {[]float64{0.9, 0.1}, 2}} {
+			if got, _, err := model.Predict(c.features); err != nil || got != c.want {
+				t.Errorf("%s: Predict(%v) = %d, %v; want %d", modelType, c.features, got, err, c.want)
+			}
+		}
+	}
+}
+
+func TestValidateNodesRejectsBrokenTree(t *testing.T) {
+	nodes := []TreeNode{
+		{FeatureIdx: 0, Threshold: 0.5, LeftChild: 1, RightChild: 5},
+		{FeatureIdx: -1, LeftChild: -1, RightChild: -1, IsLeaf: true},
+	}
+	if err := validateNodes(nodes); err == nil {
+		t.Error("expected error for out-of-range child index")
+	}
+	if err := validateNodes(nil); err == nil {
+		t.Error("expected error for empty tree")
+	}
+}
@@ -4,6 +4,29 @@ import (
 	"errors"
 )
 
+// ModelConfig 创建模型的参数，随机森林相关字段对决策树无效
+type ModelConfig struct {
+	MaxDepth         int
+	NumTrees         int
+	FeatureSubsample float64
+}
+
+// NewModel 按模型类型创建未训练的模型
+func NewModel(modelType string, config ModelConfig) (MLModel, error) {
+	switch modelType {
+	case "", "decision_tree":
+		return NewDecisionTree(config.MaxDepth), nil
+	case "random_forest":
+		return NewRandomForest(RandomForestConfig{
+			NumTrees:         config.NumTrees,
+			MaxDepth:         config.MaxDepth,
+			FeatureSubsample: config.FeatureSubsample,
+		}), nil
+	default:
+		return nil, errors.New("unsupported model type")
+	}
+}
+
 func LoadModel(modelType, path string) (MLModel, error) {
 	switch modelType {
 	case "decision_tree":
@@ -12,6 +35,12 @@ func LoadModel(modelType, path string) (MLModel, error) {
 			return nil, err
 		}
 		return model, nil
+	case "random_forest":
+		model := &RandomForest{}
+		if err := model.Load(path); err != nil {
+			return nil, err
+		}
+		return model, nil
 	default:
 		return nil, errors.New("unsupported model type")
 	}
@@ -0,0 +1,225 @@
+package ml
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+)
+
+// RandomForestConfig 随机森林配置
+type RandomForestConfig struct {
+	NumTrees         int     `json:"num_trees"`         // 树的数量
+	MaxDepth         int     `json:"max_depth"`         // 单棵树最大深度
+	FeatureSubsample float64 `json:"feature_subsample"` // 每棵树使用的特征比例，<=0时取sqrt(特征数)
+	Seed             int64   `json:"seed"`              // 随机种子，0表示按当前时间
+}
+
+// DefaultRandomForestConfig 默认随机森林配置
+func DefaultRandomForestConfig() RandomForestConfig {
+	return RandomForestConfig{
+		NumTrees: 50,
+		MaxDepth: 6,
+	}
+}
+
+// RandomForest 通过自助采样和特征子采样训练多棵决策树，按多数投票预测
+type RandomForest struct {
+	config RandomForestConfig
+	trees  []forestTree
+}
+
+// forestTree 森林中的单棵树及其使用的原始特征下标
+type forestTree struct {
+	Features []int
+	Tree     *DecisionTree
+}
+
+// forestModel 随机森林的序列化格式
+type forestModel struct {
+	Config RandomForestConfig `json:"config"`
+	Trees  []forestTreeModel  `json:"trees"`
+}
+
+type forestTreeModel struct {
+	Features []int      `json:"features"`
+	Nodes    []TreeNode `json:"nodes"`
+}
+
+// NewRandomForest 创建随机森林
+func NewRandomForest(config RandomForestConfig) *RandomForest {
+	defaults := DefaultRandomForestConfig()
+	if config.NumTrees <= 0 {
+		config.NumTrees = defaults.NumTrees
+	}
+	if config.MaxDepth <= 0 {
+		config.MaxDepth = defaults.MaxDepth
+	}
+	return &RandomForest{config: config}
+}
+
+func (rf *RandomForest) Train(features [][]float64, labels []int) error {
+	if len(features) == 0 || len(labels) == 0 {
+		return errors.New("features or labels empty")
+	}
+	if len(features) != len(labels) {
+		return errors.New("features and labels size mismatch")
+	}
+
+	seed := rf.config.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+	// #nosec G404 -- 采样随机性不涉及安全
+	rng := rand.New(rand.NewSource(seed))
+
+	featureCount := len(features[0])
+	subsetSize := rf.subsetSize(featureCount)
+
+	trees := make([]forestTree, 0, rf.config.NumTrees)
+	for t := 0; t < rf.config.NumTrees; t++ {
+		subset := rng.Perm(featureCount)[:subsetSize]
+		sort.Ints(subset)
+
+		// 自助采样：有放回地抽取与训练集等量的样本
+		sampleX := make([][]float64, len(features))
+		sampleY := make([]int, len(features))
+		for i := range sampleX {
+			idx := rng.Intn(len(features))
+			sampleX[i] = project(features[idx], subset)
+			sampleY[i] = labels[idx]
+		}
+
+		tree := NewDecisionTree(rf.config.MaxDepth)
+		if err := tree.Train(sampleX, sampleY); err != nil {
+			return err
+		}
+		trees = append(trees, forestTree{Features: subset, Tree: tree})
+	}
+
+	rf.trees = trees
+	return nil
+}
+
+// Predict 各树投票，置信度为多数票所占比例
+func (rf *RandomForest) Predict(features []float64) (int, float64, error) {
+	if len(rf.trees) == 0 {
+		return 0, 0, errors.New("model not trained")
+	}
+
+	votes := make(map[int]int)
+	var total int
+	for _, tree := range rf.trees {
+		for _, idx := range tree.Features {
+			if idx < 0 || idx >= len(features) {
+				return 0, 0, errors.New("feature index out of range")
+			}
+		}
+		label, _, err := tree.Tree.Predict(project(features, tree.Features))
+		if err != nil {
+			return 0, 0, err
+		}
+		votes[label]++
+		total++
+	}
+
+	bestLabel, bestVotes := 0, -1
+	for label, count := range votes {
+		if count > bestVotes || (count == bestVotes && label < bestLabel) {
+			bestLabel, bestVotes = label, count
+		}
+	}
+	return bestLabel, float64(bestVotes) / float64(total), nil
+}
+
+// FeatureImportance 各树特征重要性映射回原始特征下标后取平均，之和为1
+func (rf *RandomForest) FeatureImportance() map[int]float64 {
+	importance := make(map[int]float64)
+	var total float64
+	for _, tree := range rf.trees {
+		for idx, value := range tree.Tree.FeatureImportance() {
+			if idx < 0 || idx >= len(tree.Features) {
+				continue
+			}
+			importance[tree.Features[idx]] += value
+			total += value
+		}
+	}
+	for idx := range importance {
+		importance[idx] /= total
+	}
+	return importance
+}
+
+func (rf *RandomForest) Save(path string) error {
+	if len(rf.trees) == 0 {
+		return errors.New("model not trained")
+	}
+	model := forestModel{Config: rf.config, Trees: make([]forestTreeModel, len(rf.trees))}
+	for i, tree := range rf.trees {
+		model.Trees[i] = forestTreeModel{Features: tree.Features, Nodes: tree.Tree.nodes}
+	}
+	return writeModelFile(path, "random_forest", model)
+}
+
+func (rf *RandomForest) Load(path string) error {
+	var model forestModel
+	version, err := readModelFile(path, "random_forest", &model)
+	if err != nil {
+		return err
+	}
+	if len(model.Trees) == 0 {
+		return fmt.Errorf("%w: %s: random forest has no trees", ErrModelFormat, path)
+	}
+
+	trees := make([]forestTree, len(model.Trees))
+	for i, tree := range model.Trees {
+		if len(tree.Features) == 0 {
+			return fmt.Errorf("%w: %s: tree %d has no features", ErrModelFormat, path, i)
+		}
+		if version == legacyFormatVersion {
+			absoluteChildIndices(tree.Nodes)
+		}
+		if err := validateNodes(tree.Nodes); err != nil {
+			return fmt.Errorf("%w: %s: tree %d: %v", ErrModelFormat, path, i, err)
+		}
+		for _, node := range tree.Nodes {
+			if !node.IsLeaf && node.FeatureIdx >= len(tree.Features) {
+				return fmt.Errorf("%w: %s: tree %d: feature index %d exceeds its %d features", ErrModelFormat, path, i, node.FeatureIdx, len(tree.Features))
+			}
+		}
+		trees[i] = forestTree{
+			Features: tree.Features,
+			Tree:     &DecisionTree{nodes: tree.Nodes, maxDepth: model.Config.MaxDepth},
+		}
+	}
+	rf.config = model.Config
+	rf.trees = trees
+	return nil
+}
+
+// subsetSize 每棵树使用的特征数量
+func (rf *RandomForest) subsetSize(featureCount int) int {
+	size := int(math.Round(math.Sqrt(float64(featureCount))))
+	if rf.config.FeatureSubsample > 0 {
+		size = int(math.Round(rf.config.FeatureSubsample * float64(featureCount)))
+	}
+	if size < 1 {
+		size = 1
+	}
+	if size > featureCount {
+		size = featureCount
+	}
+	return size
+}
+
+// project 取出特征向量中subset对应的分量
+func project(features []float64, subset []int) []float64 {
+	projected := make([]float64, len(subset))
+	for i, idx := range subset {
+		projected[i] = features[idx]
+	}
+	return projected
+}
@@ -0,0 +1,102 @@
+package ml
+
+import (
+	"math/rand"
+	"path/filepath"
+	"testing"
+)
+
+// noisyDataset 标签由第0个特征决定，其中约10%的标签被随机翻转
+func noisyDataset(n int, seed int64) ([][]float64, []int) {
+	rng := rand.New(rand.NewSource(seed))
+	features := make([][]float64, n)
+	labels := make([]int, n)
+	for i := range features {
+		signal := rng.Float64()
+		features[i] = []float64{signal, rng.Float64(), rng.Float64(), rng.Float64()}
+		labels[i] = 0
+		if signal > 0.5 {
+			labels[i] = 2
+		}
+		if rng.Float64() < 0.1 {
+			labels[i] = 2 - labels[i]
+		}
+	}
+	return features, labels
+}
+
+func TestRandomForestTrainPredictSaveLoad(t *testing.T) {
+	trainX, trainY := noisyDataset(300, 1)
+	testX, _ := noisyDataset(100, 2)
+
+	forest := NewRandomForest(RandomForestConfig{NumTrees: 25, MaxDepth: 4, FeatureSubsample: 0.5, Seed: 7})
+	if _, _, err := forest.Predict(testX[0]); err == nil {
+		t.Fatal("expected error predicting with an untrained forest")
+	}
+	if err := forest.Train(trainX, trainY); err != nil {
+		t.Fatalf("train failed: %v", err)
+	}
+	if len(forest.trees) != 25 {
+		t.Fatalf("trained %d trees, want 25", len(forest.trees))
+	}
+	for _, tree := range forest.trees {
+		if len(tree.Features) != 2 {
+			t.Fatalf("tree uses %d features, want 2", len(tree.Features))
+		}
+	}
+
+	var correct int
+	for _, x := range testX {
+		label, confidence, err := forest.Predict(x)
+		if err != nil {
+			t.Fatalf("predict failed: %v", err)
+		}
+		if confidence <= 0 || confidence > 1 {
+			t.Fatalf("confidence %v outside (0, 1]", confidence)
+		}
+		want := 0
+		if x[0] > 0.5 {
+			want = 2
+		}
+		if label == want {
+			correct++
+		}
+	}
+	if accuracy := float64(correct) / float64(len(testX)); accuracy < 0.8 {
+		t.Errorf("accuracy = %.2f, want >= 0.8", accuracy)
+	}
+
+	importance := forest.FeatureImportance()
+	for idx := 1; idx < 4; idx++ {
+		if importance[idx] >= importance[0] {
+			t.Errorf("noise feature %d importance %.3f >= signal %.3f", idx, importance[idx], importance[0])
+		}
+	}
+
+	path := filepath.Join(t.TempDir(), "forest.json")
+	if err := forest.Save(path); err != nil {
+		t.Fatalf("save failed: %v", err)
+	}
+	loaded, err := LoadModel("random_forest", path)
+	if err != nil {
+		t.Fatalf("LoadModel failed: %v", err)
+	}
+	for _, x := range testX {
+		wantLabel, wantConfidence, _ := forest.Predict(x)
+		label, confidence, err := loaded.Predict(x)
+		if err != nil || label != wantLabel || confidence != wantConfidence {
+			t.Fatalf("loaded forest predicted (%d, %v, %v), want (%d, %v)", label, confidence, err, wantLabel, wantConfidence)
+		}
+	}
+}
+
+func TestNewModelTypes(t *testing.T) {
+	if model, err := NewModel("random_forest", ModelConfig{MaxDepth: 3}); err != nil {
+		t.Fatalf("NewModel random_forest: %v", err)
+	} else if _, ok := model.(*RandomForest); !ok {
+		t.Errorf("got %T, want *RandomForest", model)
+	}
+	if _, err := NewModel("svm", ModelConfig{}); err == nil {
+		t.Error("expected error for unsupported model type")
+	}
+}
@@ -2,6 +2,7 @@ package ml
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
 	"cloudquant/market"
@@ -39,6 +40,37 @@ func GenerateLabels(klines []market.KLine, lookAhead int) ([]int, error) {
 	return labels, nil
 }
 
+// BuildDataset 由按时间升序的K线构建特征与标签对齐的训练矩阵。
+// 第i行对应第t=i+FeatureWarmup-1根K线：X[i]为该K线收盘时可得的特征向量，
+// y[i]为从该K线收盘到之后第lookahead根K线收盘的收益率标签（见GenerateLabels）。
+// 预热期不足以计算特征的K线，以及末尾不足lookahead根未来K线的K线都不产生行。
+func BuildDataset(klines []market.KLine, lookahead int) (X [][]float64, y []int, err error) {
+	if lookahead <= 0 {
+		return nil, nil, errors.New("lookahead must be positive")
+	}
+	features, err := ExtractFeatures(klines)
+	if err != nil {
+		return nil, nil, err
+	}
+	labels, err := GenerateLabels(klines, lookahead)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rows := len(klines) - lookahead - (FeatureWarmup - 1)
+	if rows <= 0 {
+		return nil, nil, fmt.Errorf("insufficient klines: got %d, need more than %d", len(klines), FeatureWarmup-1+lookahead)
+	}
+
+	X = make([][]float64, rows)
+	y = make([]int, rows)
+	for i := 0; i < rows; i++ {
+		X[i] = FeatureVector(features[i])
+		y[i] = labels[i+FeatureWarmup-1]
+	}
+	return X, y, nil
+}
+
 func BuildTrainingSet(symbol string, startDate, endDate time.Time) (features [][]float64, labels []int, err error) {
 	if symbol == "" {
 		return nil, nil, errors.New("symbol is required")
@@ -57,26 +89,5 @@ func BuildTrainingSet(symbol string, startDate, endDate time.Time) (features [][
 	if err != nil {
 		return nil, nil, err
 	}
-	featuresStruct, err := ExtractFeatures(klines)
-	if err != nil {
-		return nil, nil, err
-	}
-	labels, err = GenerateLabels(klines, 3)
-	if err != nil {
-		return nil, nil, err
-	}
-
-	featureVectors := make([][]float64, 0, len(featuresStruct))
-	labelValues := make([]int, 0, len(featuresStruct))
-	offset := len(klines) - len(featuresStruct)
-	for i, feature := range featuresStruct {
-		labelIndex := i + offset
-		if labelIndex >= len(labels) {
-			break
-		}
-		featureVectors = append(featureVectors, FeatureVector(feature))
-		labelValues = append(labelValues, labels[labelIndex])
-	}
-
-	return featureVectors, labelValues, nil
+	return BuildDataset(klines, 3)
 }
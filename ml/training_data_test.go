@@ -2,6 +2,7 @@ package ml
 
 import (
 	"testing"
+	"time"
 
 	"cloudquant/market"
 )
@@ -21,3 +22,48 @@ func TestGenerateLabels(t *testing.T) {
 		t.Fatalf("expected label 0, got %d", labels[0])
 	}
 }
+
+func TestBuildDatasetAlignsFeaturesWithLabels(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	klines := make([]market.KLine, 80)
+	for i := range klines {
+		// 价格周期性波动，使各类标签都会出现
+		klines[i] = market.KLine{
+			Symbol:    "sh600000",
+			Close:     10 + float64(i%7)*0.3,
+			Volume:    int64(1000 + i*10),
+			Timestamp: start.AddDate(0, 0, i),
+		}
+	}
+
+	const lookahead = 3
+	X, y, err := BuildDataset(klines, lookahead)
+	if err != nil {
+		t.Fatalf("BuildDataset: %v", err)
+	}
+	if want := len(klines) - (FeatureWarmup - 1) - lookahead; len(X) != want || len(y) != want {
+		t.Fatalf("got %d rows and %d labels, want %d", len(X), len(y), want)
+	}
+
+	features, _ := ExtractFeatures(klines)
+	labels, _ := GenerateLabels(klines, lookahead)
+	for i := range X {
+		bar := i + FeatureWarmup - 1
+		if !features[i].Timestamp.Equal(klines[bar].Timestamp) {
+			t.Fatalf("row %d features from %s, want bar %d at %s", i, features[i].Timestamp, bar, klines[bar].Timestamp)
+		}
+		if y[i] != labels[bar] {
+			t.Fatalf("row %d label %d, want label of bar %d (%d)", i, y[i], bar, labels[bar])
+		}
+	}
+
+	// 最后一行的标签必须基于真实的未来K线
+	last := len(X) - 1 + FeatureWarmup - 1
+	if last+lookahead != len(klines)-1 {
+		t.Errorf("last row uses bar %d, whose horizon ends at %d, want %d", last, last+lookahead, len(klines)-1)
+	}
+
+	if _, _, err := BuildDataset(klines[:FeatureWarmup+lookahead-1], lookahead); err == nil {
+		t.Error("expected error when no bar has both features and a full lookahead")
+	}
+}
@@ -0,0 +1,159 @@
+package monitoring
+
+import (
+	"bufio"
+	"net"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeSMTPServer 最简SMTP服务器，记录收件人和邮件内容
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	from     string
+	rcpts    []string
+	data     string
+	done     chan struct{}
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	s := &fakeSMTPServer{listener: ln, done: make(chan struct{})}
+	go s.serve()
+	t.Cleanup(func() { ln.Close() })
+	return s
+}
+
+func (s *fakeSMTPServer) serve() {
+	defer close(s.done)
+	conn, err := s.listener.Accept()
+	if err != nil {
+		return
+	}
+	defer conn.Close()
+
+	r := bufio.NewReader(conn)
+	write := func(line string) { conn.Write([]byte(line + "\r\n")) }
+	write("220 localhost ESMTP")
+
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		cmd := strings.TrimSpace(line)
+		upper := strings.ToUpper(cmd)
+
+		switch {
+		case strings.HasPrefix(upper, "EHLO"), strings.HasPrefix(upper, "HELO"):
+			write("250-localhost")
+			write("250 AUTH PLAIN")
+		case strings.HasPrefix(upper, "AUTH"):
+			write("235 Authentication successful")
+		case strings.HasPrefix(upper, "MAIL FROM:"):
+			s.mu.Lock()
+			s.from = strings.Trim(cmd[len("MAIL FROM:"):], "<> ")
+			s.mu.Unlock()
+			write("250 OK")
+		case strings.HasPrefix(upper, "RCPT TO:"):
+			s.mu.Lock()
+			s.rcpts = append(s.rcpts, strings.Trim(cmd[len("RCPT TO:"):], "<> "))
+			s.mu.Unlock()
+			write("250 OK")
+		case upper == "DATA":
+			write("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil {
+					return
+				}
+				if l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.mu.Lock()
+			s.data = data.String()
+			s.mu.Unlock()
+			write("250 OK")
+		case upper == "QUIT":
+			write("221 Bye")
+			return
+		default:
+			write("250 OK")
+		}
+	}
+}
+
+func TestSendEmailAlertDeliversToAllRecipients(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	host, port, _ := net.SplitHostPort(server.listener.Addr().String())
+
+	system := NewAlertSystem()
+	channel := &AlertChannel{
+		Type:    "email",
+		Enabled: true,
+		Settings: map[string]interface{}{
+			"smtp_host": host,
+			"smtp_port": port,
+			"username":  "bot@example.com",
+			"password":  "secret",
+			"to":        "ops@example.com, risk@example.com",
+		},
+	}
+
+	alert := &Alert{Level: Critical, Title: "回撤超限", Message: "组合回撤 12%", Symbol: "sh600000"}
+	if err := system.sendEmailAlert(channel, alert); err != nil {
+		t.Fatalf("sendEmailAlert: %v", err)
+	}
+	<-server.done
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+
+	if server.from != "bot@example.com" {
+		t.Errorf("MAIL FROM = %q, want bot@example.com", server.from)
+	}
+	if len(server.rcpts) != 2 || server.rcpts[0] != "ops@example.com" || server.rcpts[1] != "risk@example.com" {
+		t.Errorf("RCPT TO = %v, want both recipients", server.rcpts)
+	}
+	for _, want := range []string{"级别: critical", "消息: 组合回撤 12%", "股票: sh600000"} {
+		if !strings.Contains(server.data, want) {
+			t.Errorf("email body missing %q:\n%s", want, server.data)
+		}
+	}
+	if strings.Contains(server.data, "{{") {
+		t.Errorf("email body contains unrendered template:\n%s", server.data)
+	}
+}
+
+func TestSendEmailAlertConnectionFailure(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	host, port, _ := net.SplitHostPort(addr)
+
+	system := NewAlertSystem()
+	err = system.sendEmailAlert(&AlertChannel{
+		Type: "email",
+		Settings: map[string]interface{}{
+			"smtp_host": host,
+			"smtp_port": port,
+			"username":  "bot@example.com",
+			"to":        "ops@example.com",
+		},
+	}, &Alert{Level: Critical, Title: "test"})
+	if err == nil {
+		t.Fatal("expected connection error")
+	}
+}
@@ -2,12 +2,21 @@ package monitoring
 
 import (
 	"bytes"
+	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"mime"
+	"net"
 	"net/http"
+	"net/smtp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"text/template"
 	"time"
 )
 
@@ -21,20 +30,93 @@ const (
 	Critical AlertLevel = "critical"
 )
 
+var (
+	// ErrAlertNotFound 告警不存在
+	ErrAlertNotFound = errors.New("alert not found")
+	// ErrAlertResolved 告警已解决，不能再确认
+	ErrAlertResolved = errors.New("alert already resolved")
+)
+
 // Alert 告警结构
 type Alert struct {
-	ID         string                 `json:"id"`
-	Level      AlertLevel             `json:"level"`
-	Title      string                 `json:"title"`
-	Message    string                 `json:"message"`
-	Symbol     string                 `json:"symbol,omitempty"`
-	Value      float64                `json:"value,omitempty"`
-	Threshold  float64                `json:"threshold,omitempty"`
-	Source     string                 `json:"source"`
-	Timestamp  time.Time              `json:"timestamp"`
-	Resolved   bool                   `json:"resolved"`
-	ResolvedAt *time.Time             `json:"resolved_at,omitempty"`
-	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	ID           string                      `json:"id"`
+	Level        AlertLevel                  `json:"level"`
+	Title        string                      `json:"title"`
+	Message      string                      `json:"message"`
+	Symbol       string                      `json:"symbol,omitempty"`
+	Value        float64                     `json:"value,omitempty"`
+	Threshold    float64                     `json:"threshold,omitempty"`
+	Source       string                      `json:"source"`
+	Timestamp    time.Time                   `json:"timestamp"`
+	Resolved     bool                        `json:"resolved"`
+	ResolvedAt   *time.Time                  `json:"resolved_at,omitempty"`
+	Acknowledged bool                        `json:"acknowledged"` // 已确认有人处理，未解决前仍为活跃告警
+	AckedBy      string                      `json:"acked_by,omitempty"`
+	AckedAt      *time.Time                  `json:"acked_at,omitempty"`
+	Metadata     map[string]interface{}      `json:"metadata,omitempty"`
+	Delivery     map[string]*ChannelDelivery `json:"delivery,omitempty"` // 各渠道投递状态
+}
+
+// DeliveryStatus 渠道投递状态
+type DeliveryStatus string
+
+const (
+	DeliverySucceeded DeliveryStatus = "succeeded"
+	DeliveryFailed    DeliveryStatus = "failed"
+	DeliverySkipped   DeliveryStatus = "skipped"
+)
+
+// ChannelDelivery 单个渠道的投递结果
+type ChannelDelivery struct {
+	Channel  string         `json:"channel"`
+	Status   DeliveryStatus `json:"status"`
+	Attempts int            `json:"attempts"`
+	Error    string         `json:"error,omitempty"`
+	Reason   string         `json:"reason,omitempty"` // 跳过原因
+	Time     time.Time      `json:"time"`
+}
+
+// DeliveryResult 告警投递结果
+type DeliveryResult struct {
+	AlertID  string                      `json:"alert_id"`
+	Channels map[string]*ChannelDelivery `json:"channels"`
+}
+
+// Failed 投递失败的渠道，按名称排序
+func (r *DeliveryResult) Failed() []string {
+	return r.withStatus(DeliveryFailed)
+}
+
+// Succeeded 投递成功的渠道，按名称排序
+func (r *DeliveryResult) Succeeded() []string {
+	return r.withStatus(DeliverySucceeded)
+}
+
+func (r *DeliveryResult) withStatus(status DeliveryStatus) []string {
+	var names []string
+	for name, delivery := range r.Channels {
+		if delivery.Status == status {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// RetryPolicy 失败渠道的重试策略
+type RetryPolicy struct {
+	MaxRetries     int           `json:"max_retries"`     // 最大重试次数，0表示不重试
+	InitialBackoff time.Duration `json:"initial_backoff"` // 首次重试等待时间，之后每次翻倍
+	MaxBackoff     time.Duration `json:"max_backoff"`     // 最长等待时间
+}
+
+// DefaultRetryPolicy 默认重试策略
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxRetries:     2,
+		InitialBackoff: 500 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+	}
 }
 
 // AlertChannel 告警渠道配置
@@ -67,9 +149,23 @@ type AlertSystem struct {
 	alerts     map[string]*Alert        // 告警ID -> 告警
 	channels   map[string]*AlertChannel // 渠道名称 -> 渠道配置
 	httpClient *http.Client
-	templates  map[string]string      // 模板名称 -> 模板内容
-	rateLimits map[string]RateTracker // 限流追踪
+	templates  map[string]*template.Template // 模板名称 -> 已解析模板
+	rateLimits map[string]*RateTracker       // 渠道名称 -> 限流追踪
 	stats      *AlertStats
+	retry      RetryPolicy
+	store      AlertStore // 创建后不再修改，为nil时告警只保存在内存中
+
+	retries  sync.WaitGroup // 后台重试失败渠道的协程
+	stopping chan struct{}  // Stop时关闭，放弃尚未进行的重试
+	stopOnce sync.Once
+}
+
+// AlertStore 告警持久化存储，内存中的告警作为其缓存
+type AlertStore interface {
+	// SaveAlert 保存告警，ID已存在时覆盖
+	SaveAlert(alert *Alert) error
+	// LoadActiveAlerts 读取所有未解决的告警
+	LoadActiveAlerts() ([]*Alert, error)
 }
 
 // AlertStats 告警统计
@@ -88,8 +184,8 @@ type RateTracker struct {
 	hourCount int
 	dayCount  int
 	lastSent  time.Time
-	hourReset time.Time
-	dayReset  time.Time
+	hourStart time.Time // 当前小时窗口起点
+	dayStart  time.Time // 当前自然日窗口起点
 }
 
 // NewAlertSystem 创建告警系统
@@ -98,8 +194,10 @@ func NewAlertSystem() *AlertSystem {
 		alerts:     make(map[string]*Alert),
 		channels:   make(map[string]*AlertChannel),
 		httpClient: &http.Client{Timeout: 30 * time.Second},
-		templates:  make(map[string]string),
-		rateLimits: make(map[string]RateTracker),
+		templates:  make(map[string]*template.Template),
+		rateLimits: make(map[string]*RateTracker),
+		retry:      DefaultRetryPolicy(),
+		stopping:   make(chan struct{}),
 		stats: &AlertStats{
 			ByLevel:   make(map[AlertLevel]int64),
 			ByChannel: make(map[string]int64),
@@ -112,6 +210,27 @@ func NewAlertSystem() *AlertSystem {
 	return system
 }
 
+// NewAlertSystemWithStore 创建持久化的告警系统，并从存储中恢复未解决的告警
+func NewAlertSystemWithStore(store AlertStore) (*AlertSystem, error) {
+	system := NewAlertSystem()
+	if store == nil {
+		return system, nil
+	}
+
+	active, err := store.LoadActiveAlerts()
+	if err != nil {
+		return nil, fmt.Errorf("load active alerts: %w", err)
+	}
+	for _, alert := range active {
+		system.alerts[alert.ID] = alert
+		system.updateStats(alert)
+	}
+	system.store = store
+
+	log.Printf("Alert system restored %d active alerts", len(active))
+	return system, nil
+}
+
 // Start 启动告警系统
 func (a *AlertSystem) Start() error {
 	// 初始化默认配置
@@ -119,17 +238,28 @@ func (a *AlertSystem) Start() error {
 	return nil
 }
 
-// Stop 停止告警系统
+// Stop 停止告警系统，放弃尚在等待退避的重试并等待进行中的投递结束
 func (a *AlertSystem) Stop() error {
-	// 清理资源
+	a.mu.Lock()
+	a.stopOnce.Do(func() { close(a.stopping) })
+	a.mu.Unlock()
+	a.retries.Wait()
 	log.Printf("Alert system stopped")
 	return nil
 }
 
-// SendAlert 发送告警
-func (a *AlertSystem) SendAlert(alert *Alert) error {
+// SetRetryPolicy 设置失败渠道的重试策略
+func (a *AlertSystem) SetRetryPolicy(policy RetryPolicy) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.retry = policy
+}
+
+// SendAlert 发送告警，返回各渠道首次投递的结果，有渠道失败时返回错误。
+// 失败的渠道在后台按重试策略退避重试，结果更新到告警的Delivery中，调用方不必等待
+func (a *AlertSystem) SendAlert(alert *Alert) (*DeliveryResult, error) {
 	if alert == nil {
-		return fmt.Errorf("alert is nil")
+		return nil, fmt.Errorf("alert is nil")
 	}
 
 	if alert.ID == "" {
@@ -141,41 +271,54 @@ func (a *AlertSystem) SendAlert(alert *Alert) error {
 
 	a.mu.Lock()
 	a.alerts[alert.ID] = alert
-	a.mu.Unlock()
-
 	// 更新统计
 	a.updateStats(alert)
+	// 发送过程不持锁，使用副本避免与ResolveAlert等并发修改冲突
+	outgoing := *alert
+	a.mu.Unlock()
 
-	// 检查是否需要发送
-	if !a.shouldSendAlert(alert) {
-		log.Printf("Alert %s filtered or rate limited", alert.ID)
-		return nil
-	}
+	// 先落盘再发送，发送期间重启也不会丢失告警
+	a.persist(&outgoing)
 
 	// 发送到各个渠道
-	return a.broadcastAlert(alert)
-}
+	result := a.broadcastAlert(&outgoing)
+	a.recordDelivery(alert.ID, result)
 
-// shouldSendAlert 检查是否应该发送告警
-func (a *AlertSystem) shouldSendAlert(alert *Alert) bool {
-	// 检查过滤器
-	for _, channel := range a.channels {
-		if !channel.Enabled {
-			continue
+	if failed := result.Failed(); len(failed) > 0 {
+		a.startRetry(&outgoing, result)
+
+		errs := make([]string, 0, len(failed))
+		for _, name := range failed {
+			errs = append(errs, fmt.Sprintf("%s failed: %s", name, result.Channels[name].Error))
 		}
+		return result, fmt.Errorf("some channels failed: %s", strings.Join(errs, "; "))
+	}
+
+	if len(result.Succeeded()) == 0 {
+		log.Printf("Alert %s filtered or rate limited", alert.ID)
+	} else {
+		log.Printf("Alert %s broadcasted successfully", alert.ID)
+	}
+	return result, nil
+}
 
-		for _, filter := range channel.Filters {
-			if a.matchesFilter(alert, filter) {
-				// 检查限流
-				if !a.checkRateLimit(channel.Type) {
-					return false
-				}
-				return true
+// skipReason 返回渠道不发送该告警的原因，为空表示需要发送
+func (a *AlertSystem) skipReason(channelName string, channel *AlertChannel, alert *Alert) string {
+	if !channel.Enabled {
+		return "disabled"
+	}
+
+	for _, filter := range channel.Filters {
+		if a.matchesFilter(alert, filter) {
+			// 检查限流
+			if !a.checkRateLimit(channelName, channel.RateLimit, time.Now()) {
+				return "rate limited"
 			}
+			return ""
 		}
 	}
 
-	return false
+	return "filtered"
 }
 
 // matchesFilter 检查告警是否匹配过滤器
@@ -211,40 +354,38 @@ func (a *AlertSystem) matchesFilter(alert *Alert, filter AlertFilter) bool {
 	return false
 }
 
-// checkRateLimit 检查限流
-func (a *AlertSystem) checkRateLimit(channelType string) bool {
-	tracker, exists := a.rateLimits[channelType]
+// checkRateLimit 检查渠道限流，未超限时计入一次发送，调用方需持有锁。
+// 小时和自然日窗口按本地时间对齐，跨越窗口边界时计数清零。
+func (a *AlertSystem) checkRateLimit(channelName string, limit RateLimit, now time.Time) bool {
+	hourStart := time.Date(now.Year(), now.Month(), now.Day(), now.Hour(), 0, 0, 0, now.Location())
+	dayStart := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	tracker, exists := a.rateLimits[channelName]
 	if !exists {
-		tracker = RateTracker{
-			hourReset: time.Now().Truncate(time.Hour),
-			dayReset:  time.Now().Truncate(24 * time.Hour),
-		}
-		a.rateLimits[channelType] = tracker
+		tracker = &RateTracker{hourStart: hourStart, dayStart: dayStart}
+		a.rateLimits[channelName] = tracker
 	}
 
-	now := time.Now()
-
-	// 重置计数器
-	if now.Hour() != tracker.hourReset.Hour() {
+	// 进入新窗口时重置计数器
+	if !tracker.hourStart.Equal(hourStart) {
 		tracker.hourCount = 0
-		tracker.hourReset = now.Truncate(time.Hour)
+		tracker.hourStart = hourStart
 	}
-	if now.Day() != tracker.dayReset.Day() {
+	if !tracker.dayStart.Equal(dayStart) {
 		tracker.dayCount = 0
-		tracker.dayReset = now.Truncate(24 * time.Hour)
+		tracker.dayStart = dayStart
 	}
 
 	// 检查限流
-	channel := a.channels[channelType]
-	if channel.RateLimit.MaxPerHour > 0 && tracker.hourCount >= channel.RateLimit.MaxPerHour {
+	if limit.MaxPerHour > 0 && tracker.hourCount >= limit.MaxPerHour {
 		return false
 	}
-	if channel.RateLimit.MaxPerDay > 0 && tracker.dayCount >= channel.RateLimit.MaxPerDay {
+	if limit.MaxPerDay > 0 && tracker.dayCount >= limit.MaxPerDay {
 		return false
 	}
 
 	// 检查冷却时间
-	if channel.RateLimit.Cooldown > 0 && now.Sub(tracker.lastSent) < channel.RateLimit.Cooldown {
+	if limit.Cooldown > 0 && now.Sub(tracker.lastSent) < limit.Cooldown {
 		return false
 	}
 
@@ -252,66 +393,329 @@ func (a *AlertSystem) checkRateLimit(channelType string) bool {
 	tracker.hourCount++
 	tracker.dayCount++
 	tracker.lastSent = now
-	a.rateLimits[channelType] = tracker
 
 	return true
 }
 
-// broadcastAlert 广播告警到所有渠道
-func (a *AlertSystem) broadcastAlert(alert *Alert) error {
-	var errors []string
+// broadcastAlert 广播告警到所有渠道，每个渠道独立记录投递结果
+func (a *AlertSystem) broadcastAlert(alert *Alert) *DeliveryResult {
+	result := &DeliveryResult{
+		AlertID:  alert.ID,
+		Channels: make(map[string]*ChannelDelivery),
+	}
 
+	// 在锁内确定需要发送的渠道，发送过程不持锁
+	targets := make(map[string]*AlertChannel)
+	a.mu.Lock()
 	for channelName, channel := range a.channels {
-		if !channel.Enabled {
+		if reason := a.skipReason(channelName, channel, alert); reason != "" {
+			result.Channels[channelName] = &ChannelDelivery{
+				Channel: channelName,
+				Status:  DeliverySkipped,
+				Reason:  reason,
+				Time:    time.Now(),
+			}
 			continue
 		}
+		targets[channelName] = channel
+	}
+	a.mu.Unlock()
 
-		switch channel.Type {
-		case "email":
-			if err := a.sendEmailAlert(channel, alert); err != nil {
-				errors = append(errors, fmt.Sprintf("email failed: %v", err))
-			} else {
-				a.stats.ByChannel[channelName]++
-			}
-		case "feishu":
-			if err := a.sendFeishuAlert(channel, alert); err != nil {
-				errors = append(errors, fmt.Sprintf("feishu failed: %v", err))
-			} else {
-				a.stats.ByChannel[channelName]++
-			}
-		case "dingding":
-			if err := a.sendDingdingAlert(channel, alert); err != nil {
-				errors = append(errors, fmt.Sprintf("dingding failed: %v", err))
-			} else {
-				a.stats.ByChannel[channelName]++
+	for channelName, channel := range targets {
+		delivery := &ChannelDelivery{Channel: channelName}
+		a.attemptDelivery(channelName, channel, alert, delivery)
+		result.Channels[channelName] = delivery
+	}
+
+	return result
+}
+
+// recordDelivery 将投递结果的副本记录到告警上并落盘
+func (a *AlertSystem) recordDelivery(alertID string, result *DeliveryResult) {
+	a.mu.Lock()
+	alert, ok := a.alerts[alertID]
+	if !ok {
+		a.mu.Unlock()
+		return
+	}
+	alert.Delivery = result.clone().Channels
+	delivered := *alert
+	a.mu.Unlock()
+	a.persist(&delivered)
+}
+
+// clone 复制投递结果，后台重试修改副本，不影响已返回给调用方的结果
+func (r *DeliveryResult) clone() *DeliveryResult {
+	channels := make(map[string]*ChannelDelivery, len(r.Channels))
+	for name, delivery := range r.Channels {
+		d := *delivery
+		channels[name] = &d
+	}
+	return &DeliveryResult{AlertID: r.AlertID, Channels: channels}
+}
+
+// startRetry 按重试策略在后台重试失败的渠道，未配置重试或告警系统已停止时不处理
+func (a *AlertSystem) startRetry(alert *Alert, result *DeliveryResult) {
+	// 在锁内登记协程，保证Stop等待时不会再有新的重试开始
+	a.mu.Lock()
+	policy := a.retry
+	select {
+	case <-a.stopping:
+		a.mu.Unlock()
+		return
+	default:
+	}
+	if policy.MaxRetries <= 0 {
+		a.mu.Unlock()
+		return
+	}
+	a.retries.Add(1)
+	a.mu.Unlock()
+
+	go func() {
+		defer a.retries.Done()
+		a.retryFailedChannels(alert, result.clone(), policy)
+	}()
+}
+
+// retryFailedChannels 按退避策略重试失败的渠道，每轮重试后更新告警的投递状态
+func (a *AlertSystem) retryFailedChannels(alert *Alert, result *DeliveryResult, policy RetryPolicy) {
+	backoff := policy.InitialBackoff
+	for retry := 0; retry < policy.MaxRetries; retry++ {
+		failed := result.Failed()
+		if len(failed) == 0 {
+			return
+		}
+
+		timer := time.NewTimer(backoff)
+		select {
+		case <-a.stopping:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		backoff *= 2
+		if policy.MaxBackoff > 0 && backoff > policy.MaxBackoff {
+			backoff = policy.MaxBackoff
+		}
+
+		for _, channelName := range failed {
+			a.mu.RLock()
+			channel, ok := a.channels[channelName]
+			a.mu.RUnlock()
+			if !ok {
+				continue
 			}
+			a.attemptDelivery(channelName, channel, alert, result.Channels[channelName])
 		}
+		a.recordDelivery(alert.ID, result)
+	}
+	if failed := result.Failed(); len(failed) > 0 {
+		log.Printf("Alert %s still undelivered to %s after %d retries", alert.ID, strings.Join(failed, ", "), policy.MaxRetries)
 	}
+}
+
+// attemptDelivery 向单个渠道发送一次并更新投递结果
+func (a *AlertSystem) attemptDelivery(channelName string, channel *AlertChannel, alert *Alert, delivery *ChannelDelivery) {
+	delivery.Attempts++
+	delivery.Time = time.Now()
 
-	if len(errors) > 0 {
-		return fmt.Errorf("some channels failed: %s", strings.Join(errors, "; "))
+	if err := a.deliver(channel, alert); err != nil {
+		delivery.Status = DeliveryFailed
+		delivery.Error = err.Error()
+		log.Printf("Alert %s delivery to %s failed (attempt %d): %v", alert.ID, channelName, delivery.Attempts, err)
+		return
 	}
 
-	log.Printf("Alert %s broadcasted successfully", alert.ID)
-	return nil
+	delivery.Status = DeliverySucceeded
+	delivery.Error = ""
+
+	a.mu.Lock()
+	a.stats.ByChannel[channelName]++
+	a.mu.Unlock()
 }
 
-// sendEmailAlert 发送邮件告警
+// deliver 按渠道类型发送告警
+func (a *AlertSystem) deliver(channel *AlertChannel, alert *Alert) error {
+	switch channel.Type {
+	case "email":
+		return a.sendEmailAlert(channel, alert)
+	case "feishu":
+		return a.sendFeishuAlert(channel, alert)
+	case "dingding":
+		return a.sendDingdingAlert(channel, alert)
+	case "slack":
+		return a.sendSlackAlert(channel, alert)
+	case "webhook":
+		return a.sendWebhookAlert(channel, alert)
+	default:
+		return fmt.Errorf("unsupported channel type: %s", channel.Type)
+	}
+}
+
+// sendEmailAlert 通过SMTP发送邮件告警，服务器支持时启用STARTTLS，配置了用户名时进行AUTH
 func (a *AlertSystem) sendEmailAlert(channel *AlertChannel, alert *Alert) error {
-	// 简化的邮件发送实现
-	// 在实际环境中，需要集成真实的邮件服务
+	host := settingString(channel.Settings, "smtp_host")
+	if host == "" {
+		return fmt.Errorf("smtp host not configured")
+	}
+	port := settingInt(channel.Settings, "smtp_port", 587)
+	username := settingString(channel.Settings, "username")
+	password := settingString(channel.Settings, "password")
+	from := settingString(channel.Settings, "from")
+	if from == "" {
+		from = username
+	}
+	if from == "" {
+		return fmt.Errorf("email sender not configured")
+	}
+
+	recipients := splitRecipients(settingString(channel.Settings, "to"))
+	if len(recipients) == 0 {
+		return fmt.Errorf("email recipients not configured")
+	}
+
+	body := a.formatTemplate(a.getTemplate("email"), alert)
+	subject := fmt.Sprintf("[CloudQuantBot][%s] %s", alert.Level, alert.Title)
+	msg := buildEmailMessage(from, recipients, subject, body)
+
+	addr := net.JoinHostPort(host, strconv.Itoa(port))
+	conn, err := net.DialTimeout("tcp", addr, a.httpClient.Timeout)
+	if err != nil {
+		return fmt.Errorf("smtp connect %s failed: %v", addr, err)
+	}
+	conn.SetDeadline(time.Now().Add(a.httpClient.Timeout))
+
+	client, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %v", err)
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: host}); err != nil {
+			return fmt.Errorf("smtp starttls failed: %v", err)
+		}
+	}
 
-	template := a.getTemplate("email")
-	subject := a.formatTemplate(template, alert)
+	if username != "" {
+		if err := client.Auth(smtp.PlainAuth("", username, password, host)); err != nil {
+			return fmt.Errorf("smtp auth failed: %v", err)
+		}
+	}
 
-	log.Printf("EMAIL ALERT - %s: %s", alert.Level, subject)
+	if err := client.Mail(from); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %v", err)
+	}
+	for _, rcpt := range recipients {
+		if err := client.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %v", rcpt, err)
+		}
+	}
 
-	// 这里应该集成真实的邮件服务
-	// 例如: AWS SES, SendGrid, 或其他SMTP服务
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %v", err)
+	}
+	if _, err := w.Write(msg); err != nil {
+		return fmt.Errorf("smtp write failed: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp send failed: %v", err)
+	}
+
+	return client.Quit()
+}
 
+// buildEmailMessage 构建邮件内容
+func buildEmailMessage(from string, to []string, subject, body string) []byte {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", strings.Join(to, ", "))
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", subject))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	buf.WriteString("\r\n")
+	return buf.Bytes()
+}
+
+// splitRecipients 解析逗号分隔的收件人列表
+func splitRecipients(to string) []string {
+	var recipients []string
+	for _, rcpt := range strings.Split(to, ",") {
+		if rcpt = strings.TrimSpace(rcpt); rcpt != "" {
+			recipients = append(recipients, rcpt)
+		}
+	}
+	return recipients
+}
+
+// settingString 读取字符串类型的渠道配置
+func settingString(settings map[string]interface{}, key string) string {
+	if v, ok := settings[key].(string); ok {
+		return v
+	}
+	return ""
+}
+
+// settingStrings 读取字符串列表类型的渠道配置，兼容逗号分隔的字符串
+func settingStrings(settings map[string]interface{}, key string) []string {
+	switch v := settings[key].(type) {
+	case []string:
+		return v
+	case []interface{}:
+		values := make([]string, 0, len(v))
+		for _, item := range v {
+			values = append(values, fmt.Sprint(item))
+		}
+		return values
+	case string:
+		return splitRecipients(v)
+	}
 	return nil
 }
 
+// settingStringMap 读取字符串映射类型的渠道配置
+func settingStringMap(settings map[string]interface{}, key string) map[string]string {
+	switch v := settings[key].(type) {
+	case map[string]string:
+		return v
+	case map[string]interface{}:
+		values := make(map[string]string, len(v))
+		for k, item := range v {
+			values[k] = fmt.Sprint(item)
+		}
+		return values
+	case map[interface{}]interface{}:
+		values := make(map[string]string, len(v))
+		for k, item := range v {
+			values[fmt.Sprint(k)] = fmt.Sprint(item)
+		}
+		return values
+	}
+	return nil
+}
+
+// settingInt 读取整数类型的渠道配置，兼容JSON解码后的float64和字符串
+func settingInt(settings map[string]interface{}, key string, def int) int {
+	switch v := settings[key].(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case string:
+		if n, err := strconv.Atoi(v); err == nil {
+			return n
+		}
+	}
+	return def
+}
+
 // sendFeishuAlert 发送飞书告警
 func (a *AlertSystem) sendFeishuAlert(channel *AlertChannel, alert *Alert) error {
 	webhook, ok := channel.Settings["webhook"].(string)
@@ -323,9 +727,7 @@ func (a *AlertSystem) sendFeishuAlert(channel *AlertChannel, alert *Alert) error
 	message := map[string]interface{}{
 		"msg_type": "text",
 		"content": map[string]interface{}{
-			"text": fmt.Sprintf("🚨 告警通知\n\n级别: %s\n标题: %s\n内容: %s\n时间: %s\n股票: %s",
-				alert.Level, alert.Title, alert.Message,
-				alert.Timestamp.Format("2006-01-02 15:04:05"), alert.Symbol),
+			"text": a.formatTemplate(a.getTemplate("feishu"), alert),
 		},
 	}
 
@@ -343,15 +745,155 @@ func (a *AlertSystem) sendDingdingAlert(channel *AlertChannel, alert *Alert) err
 	message := map[string]interface{}{
 		"msgtype": "text",
 		"text": map[string]interface{}{
-			"content": fmt.Sprintf("🚨 告警通知\n\n级别: %s\n标题: %s\n内容: %s\n时间: %s\n股票: %s",
-				alert.Level, alert.Title, alert.Message,
-				alert.Timestamp.Format("2006-01-02 15:04:05"), alert.Symbol),
+			"content": a.formatTemplate(a.getTemplate("dingding"), alert),
+		},
+	}
+
+	return a.sendWebhookRequest(webhook, message)
+}
+
+// slackColors 告警级别对应的Slack附件颜色
+var slackColors = map[AlertLevel]string{
+	Info:     "#439FE0",
+	Warning:  "warning",
+	Error:    "danger",
+	Critical: "danger",
+}
+
+// defaultSlackFields Slack附件默认展示的告警字段
+var defaultSlackFields = []string{"level", "symbol", "source"}
+
+// sendSlackAlert 发送Slack告警，按attachment格式展示，fields设置决定附件中展示的告警字段
+func (a *AlertSystem) sendSlackAlert(channel *AlertChannel, alert *Alert) error {
+	webhook := settingString(channel.Settings, "webhook")
+	if webhook == "" {
+		return fmt.Errorf("slack webhook not configured")
+	}
+
+	names := settingStrings(channel.Settings, "fields")
+	if len(names) == 0 {
+		names = defaultSlackFields
+	}
+
+	fields := make([]map[string]interface{}, 0, len(names))
+	for _, name := range names {
+		value, ok := alertField(alert, name)
+		if !ok || value == "" {
+			continue
+		}
+		fields = append(fields, map[string]interface{}{
+			"title": name,
+			"value": fmt.Sprint(value),
+			"short": true,
+		})
+	}
+
+	summary := fmt.Sprintf("[%s] %s", alert.Level, alert.Title)
+	message := map[string]interface{}{
+		"text": summary,
+		"attachments": []map[string]interface{}{
+			{
+				"fallback": summary,
+				"color":    slackColors[alert.Level],
+				"title":    alert.Title,
+				"text":     a.formatTemplate(a.getTemplate("slack"), alert),
+				"fields":   fields,
+				"ts":       alert.Timestamp.Unix(),
+			},
 		},
 	}
 
 	return a.sendWebhookRequest(webhook, message)
 }
 
+// webhookPayloadData 通用Webhook负载模板数据，Text为按webhook模板渲染的消息文本
+type webhookPayloadData struct {
+	*Alert
+	Text string
+}
+
+// sendWebhookAlert 发送通用Webhook告警。
+// 配置payload_template时按模板渲染JSON请求体；配置field_map时按映射挑选告警字段；都未配置时发送完整告警。
+func (a *AlertSystem) sendWebhookAlert(channel *AlertChannel, alert *Alert) error {
+	url := settingString(channel.Settings, "url")
+	if url == "" {
+		return fmt.Errorf("webhook url not configured")
+	}
+
+	text := a.formatTemplate(a.getTemplate("webhook"), alert)
+
+	if tmpl := settingString(channel.Settings, "payload_template"); tmpl != "" {
+		payload, err := renderPayload(tmpl, webhookPayloadData{Alert: alert, Text: text})
+		if err != nil {
+			return err
+		}
+		return a.sendWebhookRequest(url, payload)
+	}
+
+	if fieldMap := settingStringMap(channel.Settings, "field_map"); len(fieldMap) > 0 {
+		payload := make(map[string]interface{}, len(fieldMap))
+		for key, name := range fieldMap {
+			if name == "text" {
+				payload[key] = text
+				continue
+			}
+			if value, ok := alertField(alert, name); ok {
+				payload[key] = value
+			}
+		}
+		return a.sendWebhookRequest(url, payload)
+	}
+
+	return a.sendWebhookRequest(url, map[string]interface{}{
+		"text":  text,
+		"alert": alert,
+	})
+}
+
+// renderPayload 渲染Webhook负载模板，结果必须是合法JSON
+func renderPayload(tmpl string, data webhookPayloadData) (json.RawMessage, error) {
+	t, err := template.New("payload").Funcs(templateFuncs).Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload template: %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render payload template: %v", err)
+	}
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("payload template rendered invalid JSON: %s", buf.String())
+	}
+	return json.RawMessage(buf.Bytes()), nil
+}
+
+// alertField 按名称读取告警字段，未知名称从Metadata中查找
+func alertField(alert *Alert, name string) (interface{}, bool) {
+	switch name {
+	case "id":
+		return alert.ID, true
+	case "level":
+		return string(alert.Level), true
+	case "title":
+		return alert.Title, true
+	case "message":
+		return alert.Message, true
+	case "symbol":
+		return alert.Symbol, true
+	case "value":
+		return alert.Value, true
+	case "threshold":
+		return alert.Threshold, true
+	case "source":
+		return alert.Source, true
+	case "timestamp":
+		return alert.Timestamp.Format(time.RFC3339), true
+	default:
+		value, ok := alert.Metadata[name]
+		return value, ok
+	}
+}
+
 // sendWebhookRequest 发送Webhook请求
 func (a *AlertSystem) sendWebhookRequest(url string, payload interface{}) error {
 	data, err := json.Marshal(payload)
@@ -379,27 +921,63 @@ func (a *AlertSystem) sendWebhookRequest(url string, payload interface{}) error
 	return nil
 }
 
-// getTemplate 获取模板
-func (a *AlertSystem) getTemplate(name string) string {
-	if template, ok := a.templates[name]; ok {
-		return template
+// templateFuncs 告警模板可用的函数
+var templateFuncs = template.FuncMap{
+	"formatTime": func(t time.Time) string {
+		return t.Format("2006-01-02 15:04:05")
+	},
+	// json 将值编码为JSON，用于在负载模板中安全嵌入字符串
+	"json": func(v interface{}) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+}
+
+// defaultTemplate 未注册渠道模板时使用的通用模板
+var defaultTemplate = template.Must(template.New("default").Funcs(templateFuncs).Parse(
+	"Alert: {{.Level}} - {{.Title}}\n{{.Message}}\n{{formatTime .Timestamp}}"))
+
+// AddTemplate 注册告警模板，模板按渠道类型命名，以Alert为数据渲染。
+// 注册时解析并试渲染一次，语法错误或引用不存在的字段都会返回错误。
+func (a *AlertSystem) AddTemplate(name, tmpl string) error {
+	if name == "" {
+		return fmt.Errorf("template name cannot be empty")
 	}
-	return fmt.Sprintf("Alert: {{.Level}} - {{.Title}}\n{{.Message}}\n{{.Timestamp}}")
+
+	t, err := template.New(name).Funcs(templateFuncs).Option("missingkey=zero").Parse(tmpl)
+	if err != nil {
+		return fmt.Errorf("invalid template %s: %v", name, err)
+	}
+	sample := &Alert{Level: Info, Timestamp: time.Now(), Metadata: map[string]interface{}{}}
+	if err := t.Execute(io.Discard, sample); err != nil {
+		return fmt.Errorf("invalid template %s: %v", name, err)
+	}
+
+	a.mu.Lock()
+	a.templates[name] = t
+	a.mu.Unlock()
+	return nil
 }
 
-// formatTemplate 格式化模板
-func (a *AlertSystem) formatTemplate(template string, alert *Alert) string {
-	// 简单的模板格式化
-	// 在实际环境中可以使用Go的text/template包
+// getTemplate 获取模板，未注册时返回通用模板
+func (a *AlertSystem) getTemplate(name string) *template.Template {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
 
-	result := template
-	result = strings.ReplaceAll(result, "{{.Level}}", string(alert.Level))
-	result = strings.ReplaceAll(result, "{{.Title}}", alert.Title)
-	result = strings.ReplaceAll(result, "{{.Message}}", alert.Message)
-	result = strings.ReplaceAll(result, "{{.Symbol}}", alert.Symbol)
-	result = strings.ReplaceAll(result, "{{.Timestamp}}", alert.Timestamp.Format("2006-01-02 15:04:05"))
+	if t, ok := a.templates[name]; ok {
+		return t
+	}
+	return defaultTemplate
+}
 
-	return result
+// formatTemplate 以告警为数据渲染模板，渲染失败时退回纯文本摘要
+func (a *AlertSystem) formatTemplate(t *template.Template, alert *Alert) string {
+	var buf bytes.Buffer
+	if err := t.Execute(&buf, alert); err != nil {
+		log.Printf("Failed to render alert template %s: %v", t.Name(), err)
+		return fmt.Sprintf("Alert: %s - %s\n%s", alert.Level, alert.Title, alert.Message)
+	}
+	return buf.String()
 }
 
 // AddChannel 添加告警渠道
@@ -410,8 +988,17 @@ func (a *AlertSystem) AddChannel(name string, channel *AlertChannel) error {
 	if channel.Type == "" {
 		return fmt.Errorf("channel type cannot be empty")
 	}
+	if channel.Type == "webhook" {
+		if tmpl := settingString(channel.Settings, "payload_template"); tmpl != "" {
+			if _, err := renderPayload(tmpl, webhookPayloadData{Alert: &Alert{Level: Info, Timestamp: time.Now()}}); err != nil {
+				return fmt.Errorf("invalid payload_template for channel %s: %v", name, err)
+			}
+		}
+	}
 
-	a.channels[name] = channel
+	// 保存副本，调用方之后修改原配置不影响发送中的告警
+	stored := *channel
+	a.channels[name] = &stored
 	log.Printf("Added alert channel: %s (%s)", name, channel.Type)
 	return nil
 }
@@ -430,28 +1017,33 @@ func (a *AlertSystem) RemoveChannel(name string) error {
 	return nil
 }
 
-// GetAlert 获取告警
+// GetAlert 获取告警副本
 func (a *AlertSystem) GetAlert(id string) (*Alert, bool) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	alert, exists := a.alerts[id]
-	return alert, exists
+	if !exists {
+		return nil, false
+	}
+	snapshot := *alert
+	return &snapshot, true
 }
 
-// GetAllAlerts 获取所有告警
+// GetAllAlerts 获取所有告警副本
 func (a *AlertSystem) GetAllAlerts() map[string]*Alert {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	result := make(map[string]*Alert)
 	for id, alert := range a.alerts {
-		result[id] = alert
+		snapshot := *alert
+		result[id] = &snapshot
 	}
 	return result
 }
 
-// GetActiveAlerts 获取活跃告警
+// GetActiveAlerts 获取活跃告警副本，包含已确认但未解决的告警
 func (a *AlertSystem) GetActiveAlerts() []*Alert {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
@@ -459,7 +1051,8 @@ func (a *AlertSystem) GetActiveAlerts() []*Alert {
 	var active []*Alert
 	for _, alert := range a.alerts {
 		if !alert.Resolved {
-			active = append(active, alert)
+			snapshot := *alert
+			active = append(active, &snapshot)
 		}
 	}
 	return active
@@ -468,73 +1061,157 @@ func (a *AlertSystem) GetActiveAlerts() []*Alert {
 // ResolveAlert 解决告警
 func (a *AlertSystem) ResolveAlert(id string) error {
 	a.mu.Lock()
-	defer a.mu.Unlock()
-
 	alert, exists := a.alerts[id]
 	if !exists {
-		return fmt.Errorf("alert %s not found", id)
+		a.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrAlertNotFound, id)
+	}
+	if alert.Resolved {
+		// 重复解决不改变首次解决时间
+		a.mu.Unlock()
+		return nil
 	}
 
 	alert.Resolved = true
 	now := time.Now()
 	alert.ResolvedAt = &now
+	resolved := *alert
+	a.mu.Unlock()
+
+	if a.store != nil {
+		if err := a.store.SaveAlert(&resolved); err != nil {
+			return fmt.Errorf("alert %s resolved but not persisted: %w", id, err)
+		}
+	}
 
 	log.Printf("Alert %s resolved", id)
 	return nil
 }
 
+// AcknowledgeAlert 确认告警，表示user正在处理；确认后告警仍保持活跃直到被解决
+func (a *AlertSystem) AcknowledgeAlert(id, user string) error {
+	if user == "" {
+		return fmt.Errorf("acknowledging user required")
+	}
+
+	a.mu.Lock()
+	alert, exists := a.alerts[id]
+	if !exists {
+		a.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrAlertNotFound, id)
+	}
+	if alert.Resolved {
+		a.mu.Unlock()
+		return fmt.Errorf("%w: %s", ErrAlertResolved, id)
+	}
+
+	alert.Acknowledged = true
+	alert.AckedBy = user
+	now := time.Now()
+	alert.AckedAt = &now
+	acked := *alert
+	a.mu.Unlock()
+
+	if a.store != nil {
+		if err := a.store.SaveAlert(&acked); err != nil {
+			return fmt.Errorf("alert %s acknowledged but not persisted: %w", id, err)
+		}
+	}
+
+	log.Printf("Alert %s acknowledged by %s", id, user)
+	return nil
+}
+
+// persist 将告警快照写入存储，失败只记录日志不影响告警发送
+func (a *AlertSystem) persist(alert *Alert) {
+	if a.store == nil {
+		return
+	}
+
+	if err := a.store.SaveAlert(alert); err != nil {
+		log.Printf("Failed to persist alert %s: %v", alert.ID, err)
+	}
+}
+
 // GetStats 获取统计信息
 func (a *AlertSystem) GetStats() *AlertStats {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
 	stats := *a.stats
-	stats.ActiveAlerts = int64(len(a.GetActiveAlerts()))
-	stats.LastAlert = time.Now()
+	stats.ByLevel = make(map[AlertLevel]int64, len(a.stats.ByLevel))
+	for level, count := range a.stats.ByLevel {
+		stats.ByLevel[level] = count
+	}
+	stats.ByChannel = make(map[string]int64, len(a.stats.ByChannel))
+	for channel, count := range a.stats.ByChannel {
+		stats.ByChannel[channel] = count
+	}
+
+	// 活跃与已解决数量以告警当前状态为准，在同一把锁内统计，
+	// 不调用GetActiveAlerts以免重入读锁
+	stats.ActiveAlerts = 0
+	stats.ResolvedAlerts = 0
+	for _, alert := range a.alerts {
+		if alert.Resolved {
+			stats.ResolvedAlerts++
+		} else {
+			stats.ActiveAlerts++
+		}
+	}
 
 	return &stats
 }
 
-// updateStats 更新统计信息
+// updateStats 记录新告警的累计统计，调用方需持有锁
 func (a *AlertSystem) updateStats(alert *Alert) {
 	a.stats.TotalAlerts++
-	if !alert.Resolved {
-		// 活跃告警计数已在GetActiveAlerts中计算
-	} else {
-		a.stats.ResolvedAlerts++
-	}
 	a.stats.ByLevel[alert.Level]++
-	a.stats.LastAlert = alert.Timestamp
+	if alert.Timestamp.After(a.stats.LastAlert) {
+		a.stats.LastAlert = alert.Timestamp
+	}
 }
 
-// initDefaultTemplates 初始化默认模板
-func (a *AlertSystem) initDefaultTemplates() {
-	a.templates = map[string]string{
-		"email": `CloudQuantBot 告警通知
+// defaultTemplates 各渠道默认模板
+var defaultTemplates = map[string]string{
+	"email": `CloudQuantBot 告警通知
 
 级别: {{.Level}}
 标题: {{.Title}}
 消息: {{.Message}}
-时间: {{.Timestamp}}
+时间: {{formatTime .Timestamp}}
 {{if .Symbol}}股票: {{.Symbol}}{{end}}
 
 请及时处理相关问题。`,
 
-		"feishu": `🚨 CloudQuantBot 告警
+	"feishu": `🚨 CloudQuantBot 告警
 
 级别: {{.Level}}
 标题: {{.Title}}
 消息: {{.Message}}
-时间: {{.Timestamp}}
+时间: {{formatTime .Timestamp}}
 {{if .Symbol}}股票: {{.Symbol}}{{end}}`,
 
-		"dingding": `🚨 CloudQuantBot 告警
+	"dingding": `🚨 CloudQuantBot 告警
 
 级别: {{.Level}}
 标题: {{.Title}}
 消息: {{.Message}}
-时间: {{.Timestamp}}
+时间: {{formatTime .Timestamp}}
 {{if .Symbol}}股票: {{.Symbol}}{{end}}`,
+
+	"slack": `{{.Message}}
+时间: {{formatTime .Timestamp}}`,
+
+	"webhook": `[{{.Level}}] {{.Title}}: {{.Message}}`,
+}
+
+// initDefaultTemplates 初始化默认模板
+func (a *AlertSystem) initDefaultTemplates() {
+	for name, tmpl := range defaultTemplates {
+		if err := a.AddTemplate(name, tmpl); err != nil {
+			panic(err)
+		}
 	}
 }
 
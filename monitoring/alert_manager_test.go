@@ -0,0 +1,484 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newWebhookServer(status int, hits *int32) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		w.WriteHeader(status)
+	}))
+}
+
+func TestAlertSystemRetriesOnlyFailedChannels(t *testing.T) {
+	var feishuHits, dingdingHits int32
+	failing := newWebhookServer(http.StatusInternalServerError, &feishuHits)
+	defer failing.Close()
+	healthy := newWebhookServer(http.StatusOK, &dingdingHits)
+	defer healthy.Close()
+
+	system := NewAlertSystem()
+	system.SetRetryPolicy(RetryPolicy{MaxRetries: 2, InitialBackoff: time.Millisecond})
+
+	filters := []AlertFilter{{Field: "level", Operator: "equals", Value: "error"}}
+	if err := system.AddChannel("feishu", &AlertChannel{
+		Type:     "feishu",
+		Enabled:  true,
+		Settings: map[string]interface{}{"webhook": failing.URL},
+		Filters:  filters,
+	}); err != nil {
+		t.Fatalf("AddChannel feishu: %v", err)
+	}
+	if err := system.AddChannel("dingding", &AlertChannel{
+		Type:     "dingding",
+		Enabled:  true,
+		Settings: map[string]interface{}{"webhook": healthy.URL},
+		Filters:  filters,
+	}); err != nil {
+		t.Fatalf("AddChannel dingding: %v", err)
+	}
+
+	alert := &Alert{Level: Error, Title: "test", Message: "channel failure"}
+	result, err := system.SendAlert(alert)
+	if err == nil {
+		t.Fatal("expected error when a channel fails")
+	}
+	if result == nil {
+		t.Fatal("expected delivery result alongside error")
+	}
+
+	// 返回的是首次投递结果，失败渠道在后台重试
+	feishu := result.Channels["feishu"]
+	if feishu == nil || feishu.Status != DeliveryFailed {
+		t.Fatalf("feishu delivery = %+v, want failed", feishu)
+	}
+	if feishu.Attempts != 1 || feishu.Error == "" {
+		t.Errorf("feishu attempts = %d error = %q, want 1 attempt with error", feishu.Attempts, feishu.Error)
+	}
+	system.retries.Wait()
+
+	dingding := result.Channels["dingding"]
+	if dingding == nil || dingding.Status != DeliverySucceeded {
+		t.Fatalf("dingding delivery = %+v, want succeeded", dingding)
+	}
+	if dingding.Attempts != 1 {
+		t.Errorf("dingding attempts = %d, want 1", dingding.Attempts)
+	}
+
+	email := result.Channels["email"]
+	if email == nil || email.Status != DeliverySkipped || email.Reason != "disabled" {
+		t.Errorf("email delivery = %+v, want skipped (disabled)", email)
+	}
+
+	if n := atomic.LoadInt32(&feishuHits); n != 3 {
+		t.Errorf("failing webhook received %d requests, want 3", n)
+	}
+	if n := atomic.LoadInt32(&dingdingHits); n != 1 {
+		t.Errorf("healthy webhook received %d requests, want 1 (no retry)", n)
+	}
+
+	stored, ok := system.GetAlert(alert.ID)
+	if !ok {
+		t.Fatal("alert not stored")
+	}
+	if stored.Delivery["feishu"].Status != DeliveryFailed || stored.Delivery["dingding"].Status != DeliverySucceeded {
+		t.Errorf("delivery status not recorded on alert: %+v", stored.Delivery)
+	}
+	if attempts := stored.Delivery["feishu"].Attempts; attempts != 3 {
+		t.Errorf("recorded feishu attempts = %d, want 3 after background retries", attempts)
+	}
+}
+
+func TestAlertSystemRetriesInBackground(t *testing.T) {
+	var hits int32
+	failing := newWebhookServer(http.StatusInternalServerError, &hits)
+	defer failing.Close()
+
+	system := NewAlertSystem()
+	system.SetRetryPolicy(RetryPolicy{MaxRetries: 3, InitialBackoff: time.Hour})
+	if err := system.AddChannel("feishu", &AlertChannel{
+		Type:     "feishu",
+		Enabled:  true,
+		Settings: map[string]interface{}{"webhook": failing.URL},
+		Filters:  []AlertFilter{{Field: "level", Operator: "equals", Value: "error"}},
+	}); err != nil {
+		t.Fatalf("AddChannel: %v", err)
+	}
+
+	// 退避等待不阻塞SendAlert，Stop放弃尚未进行的重试
+	start := time.Now()
+	if _, err := system.SendAlert(&Alert{Level: Error, Title: "slow retry"}); err == nil {
+		t.Fatal("expected error for the failed channel")
+	}
+	if err := system.Stop(); err != nil {
+		t.Fatalf("Stop: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("SendAlert and Stop took %v, want them not to wait for the backoff", elapsed)
+	}
+	if n := atomic.LoadInt32(&hits); n != 1 {
+		t.Errorf("webhook received %d requests, want only the first attempt", n)
+	}
+}
+
+func TestAlertSystemSkipsFilteredChannels(t *testing.T) {
+	var hits int32
+	server := newWebhookServer(http.StatusOK, &hits)
+	defer server.Close()
+
+	system := NewAlertSystem()
+	if err := system.AddChannel("feishu", &AlertChannel{
+		Type:     "feishu",
+		Enabled:  true,
+		Settings: map[string]interface{}{"webhook": server.URL},
+		Filters:  []AlertFilter{{Field: "level", Operator: "equals", Value: "critical"}},
+	}); err != nil {
+		t.Fatalf("AddChannel: %v", err)
+	}
+
+	result, err := system.SendAlert(&Alert{Level: Info, Title: "info"})
+	if err != nil {
+		t.Fatalf("SendAlert: %v", err)
+	}
+	if d := result.Channels["feishu"]; d == nil || d.Status != DeliverySkipped || d.Reason != "filtered" {
+		t.Errorf("feishu delivery = %+v, want skipped (filtered)", d)
+	}
+	if atomic.LoadInt32(&hits) != 0 {
+		t.Error("filtered alert should not be delivered")
+	}
+}
+
+func TestAlertTemplatesEvaluateConditionals(t *testing.T) {
+	system := NewAlertSystem()
+	alert := &Alert{
+		Level:     Warning,
+		Title:     "回撤告警",
+		Message:   "组合回撤超过阈值",
+		Timestamp: time.Date(2024, 3, 1, 14, 30, 0, 0, time.Local),
+	}
+
+	body := system.formatTemplate(system.getTemplate("feishu"), alert)
+	if strings.Contains(body, "{{") || strings.Contains(body, "股票:") {
+		t.Errorf("template without symbol rendered:\n%s", body)
+	}
+	if !strings.Contains(body, "时间: 2024-03-01 14:30:00") {
+		t.Errorf("timestamp not formatted:\n%s", body)
+	}
+
+	alert.Symbol = "sh600000"
+	body = system.formatTemplate(system.getTemplate("feishu"), alert)
+	if !strings.Contains(body, "股票: sh600000") {
+		t.Errorf("template with symbol missing symbol line:\n%s", body)
+	}
+}
+
+func TestAddTemplateValidates(t *testing.T) {
+	system := NewAlertSystem()
+
+	if err := system.AddTemplate("feishu", "{{if .Symbol}}unterminated"); err == nil {
+		t.Error("expected parse error for unterminated action")
+	}
+	if err := system.AddTemplate("feishu", "{{.NoSuchField}}"); err == nil {
+		t.Error("expected error for unknown alert field")
+	}
+	if err := system.AddTemplate("feishu", "[{{.Level}}] {{.Title}}{{with .Metadata.strategy}} ({{.}}){{end}}"); err != nil {
+		t.Fatalf("AddTemplate: %v", err)
+	}
+
+	body := system.formatTemplate(system.getTemplate("feishu"), &Alert{
+		Level:    Error,
+		Title:    "策略异常",
+		Metadata: map[string]interface{}{"strategy": "ma_cross"},
+	})
+	if body != "[error] 策略异常 (ma_cross)" {
+		t.Errorf("custom template rendered %q", body)
+	}
+}
+
+func TestWebhookUsesChannelTemplate(t *testing.T) {
+	var payload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewDecoder(r.Body).Decode(&payload)
+	}))
+	defer server.Close()
+
+	system := NewAlertSystem()
+	if err := system.AddTemplate("dingding", "{{.Title}}: {{.Message}}"); err != nil {
+		t.Fatalf("AddTemplate: %v", err)
+	}
+
+	channel := &AlertChannel{Type: "dingding", Settings: map[string]interface{}{"webhook": server.URL}}
+	if err := system.sendDingdingAlert(channel, &Alert{Title: "止损", Message: "sh600000 触发止损"}); err != nil {
+		t.Fatalf("sendDingdingAlert: %v", err)
+	}
+
+	text, _ := payload["text"].(map[string]interface{})
+	if got := text["content"]; got != "止损: sh600000 触发止损" {
+		t.Errorf("webhook content = %v", got)
+	}
+}
+
+func TestCheckRateLimitResetsAtWindowBoundaries(t *testing.T) {
+	system := NewAlertSystem()
+	limit := RateLimit{MaxPerHour: 2, MaxPerDay: 3}
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, 3, day, hour, minute, 0, 0, time.Local)
+	}
+
+	steps := []struct {
+		now  time.Time
+		want bool
+	}{
+		{at(1, 10, 10), true},
+		{at(1, 10, 20), true},
+		{at(1, 10, 30), false}, // 小时上限
+		{at(1, 11, 5), true},   // 跨小时重置
+		{at(1, 11, 10), false}, // 日上限，小时计数不应再次清零
+		{at(2, 11, 5), true},   // 次日同一时钟小时也要重置
+		{at(2, 11, 6), true},
+		{at(2, 11, 7), false}, // 新窗口内计数持续累加
+	}
+
+	for i, step := range steps {
+		if got := system.checkRateLimit("feishu", limit, step.now); got != step.want {
+			t.Errorf("step %d at %s: allowed = %v, want %v", i, step.now.Format("01-02 15:04"), got, step.want)
+		}
+	}
+
+	tracker := system.rateLimits["feishu"]
+	if tracker.hourCount != 2 || tracker.dayCount != 2 {
+		t.Errorf("tracker counts hour=%d day=%d, want 2 and 2", tracker.hourCount, tracker.dayCount)
+	}
+}
+
+func TestAlertSystemConcurrentSendIsRaceFree(t *testing.T) {
+	var hits int32
+	server := newWebhookServer(http.StatusOK, &hits)
+	defer server.Close()
+
+	system := NewAlertSystem()
+	system.SetRetryPolicy(RetryPolicy{MaxRetries: 1, InitialBackoff: time.Millisecond})
+	filters := []AlertFilter{{Field: "level", Operator: "equals", Value: "warning"}}
+
+	// 渠道增删和统计读取持续进行，直到所有告警发送完成
+	done := make(chan struct{})
+	var background sync.WaitGroup
+	background.Add(2)
+	go func() {
+		defer background.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			channel := &AlertChannel{
+				Type:     "dingding",
+				Enabled:  true,
+				Settings: map[string]interface{}{"webhook": server.URL},
+				Filters:  filters,
+			}
+			system.AddChannel("dingding", channel)
+			channel.Enabled = false
+			system.RemoveChannel("dingding")
+		}
+	}()
+	go func() {
+		defer background.Done()
+		delivered := 0
+		for {
+			select {
+			case <-done:
+				t.Logf("observed %d deliveries", delivered)
+				return
+			default:
+			}
+			stats := system.GetStats()
+			delivered += int(stats.ByChannel["dingding"])
+			for _, alert := range system.GetActiveAlerts() {
+				delivered += len(alert.Delivery)
+			}
+		}
+	}()
+
+	var senders sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		senders.Add(1)
+		go func() {
+			defer senders.Done()
+			for j := 0; j < 20; j++ {
+				alert := &Alert{Level: Warning, Title: "concurrent", Message: "test"}
+				if _, err := system.SendAlert(alert); err != nil {
+					t.Errorf("SendAlert: %v", err)
+				}
+				if j%5 == 0 {
+					system.ResolveAlert(alert.ID)
+				}
+			}
+		}()
+	}
+
+	senders.Wait()
+	close(done)
+	background.Wait()
+
+	if stats := system.GetStats(); stats.TotalAlerts != 160 {
+		t.Errorf("total alerts = %d, want 160", stats.TotalAlerts)
+	}
+}
+
+// captureServer 记录最近一次收到的JSON请求体
+func captureServer(t *testing.T, body *map[string]interface{}) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Errorf("decode request body: %v", err)
+		}
+	}))
+}
+
+func TestWebhookChannelPayloads(t *testing.T) {
+	var body map[string]interface{}
+	server := captureServer(t, &body)
+	defer server.Close()
+
+	alert := &Alert{
+		Level:     Error,
+		Title:     "止损触发",
+		Message:   `价格跌破 "9.50"`,
+		Symbol:    "sh600000",
+		Value:     9.48,
+		Timestamp: time.Date(2024, 3, 1, 14, 30, 0, 0, time.UTC),
+		Metadata:  map[string]interface{}{"strategy": "ma_cross"},
+	}
+	system := NewAlertSystem()
+
+	// 负载模板
+	channel := &AlertChannel{Type: "webhook", Settings: map[string]interface{}{
+		"url":              server.URL,
+		"payload_template": `{"msg": {{json .Text}}, "sev": {{json .Level}}, "px": {{.Value}}}`,
+	}}
+	if err := system.sendWebhookAlert(channel, alert); err != nil {
+		t.Fatalf("sendWebhookAlert (template): %v", err)
+	}
+	if body["msg"] != `[error] 止损触发: 价格跌破 "9.50"` || body["sev"] != "error" || body["px"] != 9.48 {
+		t.Errorf("templated payload = %v", body)
+	}
+
+	// 字段映射
+	body = nil
+	channel.Settings = map[string]interface{}{
+		"url":       server.URL,
+		"field_map": map[string]interface{}{"stock": "symbol", "strategy": "strategy", "summary": "text"},
+	}
+	if err := system.sendWebhookAlert(channel, alert); err != nil {
+		t.Fatalf("sendWebhookAlert (field map): %v", err)
+	}
+	if len(body) != 3 || body["stock"] != "sh600000" || body["strategy"] != "ma_cross" || body["summary"] == "" {
+		t.Errorf("mapped payload = %v", body)
+	}
+
+	// 默认负载包含完整告警
+	body = nil
+	channel.Settings = map[string]interface{}{"url": server.URL}
+	if err := system.sendWebhookAlert(channel, alert); err != nil {
+		t.Fatalf("sendWebhookAlert (default): %v", err)
+	}
+	if full, _ := body["alert"].(map[string]interface{}); full["symbol"] != "sh600000" {
+		t.Errorf("default payload = %v", body)
+	}
+}
+
+func TestAddChannelRejectsInvalidPayloadTemplate(t *testing.T) {
+	system := NewAlertSystem()
+	err := system.AddChannel("hook", &AlertChannel{Type: "webhook", Settings: map[string]interface{}{
+		"url":              "http://localhost",
+		"payload_template": `{"msg": {{.Text}}}`,
+	}})
+	if err == nil {
+		t.Fatal("expected error for payload template rendering invalid JSON")
+	}
+}
+
+func TestSlackChannelAttachment(t *testing.T) {
+	var body map[string]interface{}
+	server := captureServer(t, &body)
+	defer server.Close()
+
+	system := NewAlertSystem()
+	channel := &AlertChannel{Type: "slack", Settings: map[string]interface{}{
+		"webhook": server.URL,
+		"fields":  []interface{}{"level", "symbol", "strategy"},
+	}}
+	alert := &Alert{
+		Level:     Warning,
+		Title:     "回撤告警",
+		Message:   "组合回撤 8%",
+		Timestamp: time.Unix(1709274600, 0),
+		Metadata:  map[string]interface{}{"strategy": "rsi"},
+	}
+	if err := system.sendSlackAlert(channel, alert); err != nil {
+		t.Fatalf("sendSlackAlert: %v", err)
+	}
+
+	attachments, _ := body["attachments"].([]interface{})
+	if len(attachments) != 1 {
+		t.Fatalf("attachments = %v", body["attachments"])
+	}
+	attachment := attachments[0].(map[string]interface{})
+	if attachment["color"] != "warning" || attachment["title"] != "回撤告警" || attachment["ts"] != float64(1709274600) {
+		t.Errorf("attachment = %v", attachment)
+	}
+	if text, _ := attachment["text"].(string); !strings.Contains(text, "组合回撤 8%") {
+		t.Errorf("attachment text = %q", text)
+	}
+
+	// 空的symbol字段不展示
+	fields, _ := attachment["fields"].([]interface{})
+	if len(fields) != 2 {
+		t.Fatalf("fields = %v, want level and strategy", fields)
+	}
+	if f := fields[1].(map[string]interface{}); f["title"] != "strategy" || f["value"] != "rsi" {
+		t.Errorf("strategy field = %v", f)
+	}
+}
+
+func TestAlertStatsAfterResolve(t *testing.T) {
+	system := NewAlertSystem()
+	sent := time.Date(2024, 3, 1, 10, 0, 0, 0, time.UTC)
+	for _, id := range []string{"a1", "a2", "a3"} {
+		if _, err := system.SendAlert(&Alert{ID: id, Level: Warning, Title: id, Timestamp: sent}); err != nil {
+			t.Fatalf("SendAlert %s: %v", id, err)
+		}
+	}
+
+	if err := system.ResolveAlert("a2"); err != nil {
+		t.Fatalf("ResolveAlert: %v", err)
+	}
+	// 重复解决不重复计数
+	if err := system.ResolveAlert("a2"); err != nil {
+		t.Fatalf("ResolveAlert again: %v", err)
+	}
+
+	stats := system.GetStats()
+	if stats.TotalAlerts != 3 || stats.ActiveAlerts != 2 || stats.ResolvedAlerts != 1 {
+		t.Errorf("stats total=%d active=%d resolved=%d, want 3/2/1", stats.TotalAlerts, stats.ActiveAlerts, stats.ResolvedAlerts)
+	}
+	if stats.ByLevel[Warning] != 3 {
+		t.Errorf("ByLevel[warning] = %d, want 3", stats.ByLevel[Warning])
+	}
+	if !stats.LastAlert.Equal(sent) {
+		t.Errorf("LastAlert = %v, want timestamp of the latest alert %v", stats.LastAlert, sent)
+	}
+	if active := system.GetActiveAlerts(); int64(len(active)) != stats.ActiveAlerts {
+		t.Errorf("GetActiveAlerts returned %d alerts, stats report %d", len(active), stats.ActiveAlerts)
+	}
+}
@@ -6,7 +6,11 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"net/url"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gorilla/websocket"
@@ -37,19 +41,52 @@ type Client struct {
 	conn          *websocket.Conn
 	send          chan []byte
 	clientID      string
+	sessionToken  string          // 客户端提供的会话令牌，用于断线重连后恢复订阅
 	subscriptions map[string]bool // 订阅的消息类型
+	subMu         sync.RWMutex
+	admitted      chan bool // 注册结果，连接数已满时为false
+}
+
+// hubMessage 待广播的消息，msgType为空表示发送给所有客户端
+type hubMessage struct {
+	msgType MessageType
+	data    []byte
+}
+
+// clientSession 持久化的客户端会话
+type clientSession struct {
+	subscriptions map[string]bool
+	active        int // 当前使用该会话的连接数
+	lastSeen      time.Time
 }
 
 // WebSocketHub WebSocket中心
 type WebSocketHub struct {
 	clients    map[*Client]bool
-	broadcast  chan []byte
+	broadcast  chan hubMessage
 	register   chan *Client
 	unregister chan *Client
 	mu         sync.RWMutex
 	upgrader   websocket.Upgrader
 	ctx        context.Context
 	cancel     context.CancelFunc
+
+	sessions   map[string]*clientSession
+	sessionTTL time.Duration // 0表示不持久化订阅
+	sessionsMu sync.Mutex
+
+	maxConnections int   // 最大连接数，<=0表示不限制
+	rejected       int64 // 因连接数已满被拒绝的连接数
+
+	allowedOrigins []string // 允许的跨域Origin，"*"表示全部允许；为空时只允许同源
+	originsMu      sync.RWMutex
+
+	received     atomic.Int64 // 已解析的客户端消息数
+	lastReceived atomic.Int64 // 最近一次收到客户端消息的时间（UnixNano）
+
+	pumpsMu sync.Mutex
+	pumps   sync.WaitGroup // 客户端读写协程，关闭时等待其退出
+	waiting bool           // Wait已调用，不再启动新的读写协程
 }
 
 // RealtimeMonitor 实时监控器
@@ -61,11 +98,14 @@ type RealtimeMonitor struct {
 	running     bool
 	stats       *MonitorStats
 	alertSystem *AlertSystem
+	debug       bool // 开启后逐条记录推送的行情消息
 }
 
 // MonitorStats 监控统计
 type MonitorStats struct {
 	ConnectedClients int64         `json:"connected_clients"`
+	MaxConnections   int           `json:"max_connections"`
+	RejectedClients  int64         `json:"rejected_clients"`
 	MessagesSent     int64         `json:"messages_sent"`
 	MessagesReceived int64         `json:"messages_received"`
 	StartTime        time.Time     `json:"start_time"`
@@ -73,26 +113,64 @@ type MonitorStats struct {
 	Uptime           time.Duration `json:"uptime"`
 }
 
-// NewWebSocketHub 创建WebSocket中心
-func NewWebSocketHub() *WebSocketHub {
+// NewWebSocketHub 创建WebSocket中心，maxConnections<=0表示不限制连接数
+func NewWebSocketHub(maxConnections int) *WebSocketHub {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &WebSocketHub{
-		clients:    make(map[*Client]bool),
-		broadcast:  make(chan []byte, 256),
-		register:   make(chan *Client),
-		unregister: make(chan *Client),
-		upgrader: websocket.Upgrader{
-			// #nosec G402 -- Intentionally allowing all origins for internal WebSocket connections
-			CheckOrigin: func(r *http.Request) bool {
-				return true // 在生产环境中应该设置更严格的origin检查
-			},
-			ReadBufferSize:  1024,
-			WriteBufferSize: 1024,
-		},
-		ctx:    ctx,
-		cancel: cancel,
+	hub := &WebSocketHub{
+		clients:        make(map[*Client]bool),
+		broadcast:      make(chan hubMessage, 256),
+		register:       make(chan *Client),
+		unregister:     make(chan *Client),
+		sessions:       make(map[string]*clientSession),
+		maxConnections: maxConnections,
+		ctx:            ctx,
+		cancel:         cancel,
 	}
+	hub.upgrader = websocket.Upgrader{
+		CheckOrigin:     hub.checkOrigin,
+		ReadBufferSize:  1024,
+		WriteBufferSize: 1024,
+	}
+	return hub
+}
+
+// SetAllowedOrigins 设置允许建立WebSocket连接的跨域Origin（如 https://dash.example.com），
+// 包含"*"时允许任意来源；未设置时只允许与请求Host相同的Origin
+func (h *WebSocketHub) SetAllowedOrigins(origins []string) {
+	h.originsMu.Lock()
+	defer h.originsMu.Unlock()
+	h.allowedOrigins = append([]string(nil), origins...)
+}
+
+// checkOrigin 校验握手请求的Origin：没有Origin头的非浏览器客户端放行，
+// 其余需与请求同源或在允许列表中
+func (h *WebSocketHub) checkOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+
+	h.originsMu.RLock()
+	allowed := h.allowedOrigins
+	h.originsMu.RUnlock()
+
+	for _, candidate := range allowed {
+		if candidate == "*" || strings.EqualFold(strings.TrimRight(candidate, "/"), origin) {
+			return true
+		}
+	}
+
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	if strings.EqualFold(u.Host, r.Host) {
+		return true
+	}
+
+	log.Printf("WebSocket connection from origin %s rejected", origin)
+	return false
 }
 
 // Start 启动WebSocket中心
@@ -104,10 +182,22 @@ func (h *WebSocketHub) Start() {
 	for {
 		select {
 		case client := <-h.register:
+			h.mu.Lock()
+			if h.maxConnections > 0 && len(h.clients) >= h.maxConnections {
+				h.rejected++
+				h.mu.Unlock()
+				client.admit(false)
+				log.Printf("Client rejected: %s (max connections %d reached)", client.clientID, h.maxConnections)
+				continue
+			}
+			h.mu.Unlock()
+
+			h.restoreSession(client)
 			h.mu.Lock()
 			h.clients[client] = true
 			h.mu.Unlock()
-			log.Printf("Client connected: %s (total: %d)", client.clientID, len(h.clients))
+			client.admit(true)
+			log.Printf("Client connected: %s (total: %d)", client.clientID, h.clientCount())
 
 		case client := <-h.unregister:
 			h.mu.Lock()
@@ -116,13 +206,18 @@ func (h *WebSocketHub) Start() {
 				close(client.send)
 			}
 			h.mu.Unlock()
+			h.releaseSession(client)
 			log.Printf("Client disconnected: %s (total: %d)", client.clientID, len(h.clients))
 
 		case message := <-h.broadcast:
 			h.mu.Lock()
 			for client := range h.clients {
+				// 只发送给订阅了该消息类型的客户端
+				if message.msgType != "" && !client.IsSubscribed(string(message.msgType)) {
+					continue
+				}
 				select {
-				case client.send <- message:
+				case client.send <- message.data:
 				default:
 					close(client.send)
 					delete(h.clients, client)
@@ -161,18 +256,200 @@ func (h *WebSocketHub) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 		conn:          conn,
 		send:          make(chan []byte, 256),
 		clientID:      clientID,
+		sessionToken:  r.URL.Query().Get("session"),
 		subscriptions: make(map[string]bool),
+		admitted:      make(chan bool, 1),
 	}
 
-	h.register <- client
+	select {
+	case h.register <- client:
+	case <-h.ctx.Done():
+		// 中心已停止，不再接受连接
+		conn.Close()
+		return
+	}
+	if !<-client.admitted {
+		// 连接数已满，以策略违规关闭连接
+		closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too many connections")
+		if err := conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(time.Second)); err != nil {
+			log.Printf("Failed to send close frame: %v", err)
+		}
+		conn.Close()
+		return
+	}
 
 	// 启动客户端协程
-	go client.writePump()
-	go client.readPump(h)
+	h.pumpsMu.Lock()
+	if h.waiting {
+		h.pumpsMu.Unlock()
+		conn.Close()
+		return
+	}
+	h.pumps.Add(2)
+	h.pumpsMu.Unlock()
+	go func() {
+		defer h.pumps.Done()
+		client.writePump()
+	}()
+	go func() {
+		defer h.pumps.Done()
+		client.readPump(h)
+	}()
+}
+
+// Wait 等待所有客户端连接在Stop后发送关闭帧并退出，ctx到期时返回其错误
+func (h *WebSocketHub) Wait(ctx context.Context) error {
+	h.pumpsMu.Lock()
+	h.waiting = true
+	h.pumpsMu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		h.pumps.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
 }
 
-// Broadcast 广播消息
+// EnableSessionPersistence 启用订阅持久化，客户端断开超过ttl后会话过期
+func (h *WebSocketHub) EnableSessionPersistence(ttl time.Duration) {
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+	h.sessionTTL = ttl
+}
+
+// restoreSession 按会话令牌恢复客户端的订阅
+func (h *WebSocketHub) restoreSession(client *Client) {
+	if client.sessionToken == "" {
+		return
+	}
+
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+
+	if h.sessionTTL <= 0 {
+		return
+	}
+	h.purgeExpiredSessionsLocked()
+
+	session, ok := h.sessions[client.sessionToken]
+	if !ok {
+		session = &clientSession{subscriptions: make(map[string]bool)}
+		h.sessions[client.sessionToken] = session
+	}
+	session.active++
+	session.lastSeen = time.Now()
+
+	client.subMu.Lock()
+	for topic := range session.subscriptions {
+		client.subscriptions[topic] = true
+	}
+	client.subMu.Unlock()
+
+	if len(session.subscriptions) > 0 {
+		log.Printf("Restored %d subscriptions for client %s", len(session.subscriptions), client.clientID)
+	}
+}
+
+// releaseSession 客户端断开后开始计算会话过期时间
+func (h *WebSocketHub) releaseSession(client *Client) {
+	if client.sessionToken == "" {
+		return
+	}
+
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+
+	if session, ok := h.sessions[client.sessionToken]; ok {
+		if session.active > 0 {
+			session.active--
+		}
+		session.lastSeen = time.Now()
+	}
+}
+
+// saveSubscriptions 将客户端当前订阅写入会话
+func (h *WebSocketHub) saveSubscriptions(client *Client) {
+	if client.sessionToken == "" {
+		return
+	}
+
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+
+	session, ok := h.sessions[client.sessionToken]
+	if !ok {
+		return
+	}
+
+	session.subscriptions = client.Subscriptions()
+	session.lastSeen = time.Now()
+}
+
+// purgeExpiredSessionsLocked 清理过期会话，调用方需持有sessionsMu
+func (h *WebSocketHub) purgeExpiredSessionsLocked() {
+	for token, session := range h.sessions {
+		if session.active == 0 && time.Since(session.lastSeen) > h.sessionTTL {
+			delete(h.sessions, token)
+		}
+	}
+}
+
+// SessionSubscriptions 获取会话中保存的订阅
+func (h *WebSocketHub) SessionSubscriptions(token string) []string {
+	h.sessionsMu.Lock()
+	defer h.sessionsMu.Unlock()
+
+	session, ok := h.sessions[token]
+	if !ok {
+		return nil
+	}
+
+	topics := make([]string, 0, len(session.subscriptions))
+	for topic := range session.subscriptions {
+		topics = append(topics, topic)
+	}
+	sort.Strings(topics)
+	return topics
+}
+
+// Subscriptions 获取客户端订阅的副本
+func (c *Client) Subscriptions() map[string]bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+
+	subscriptions := make(map[string]bool, len(c.subscriptions))
+	for topic := range c.subscriptions {
+		subscriptions[topic] = true
+	}
+	return subscriptions
+}
+
+// IsSubscribed 检查客户端是否订阅了指定消息类型
+func (c *Client) IsSubscribed(topic string) bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	return c.subscriptions[topic]
+}
+
+// Broadcast 广播消息给所有客户端，不考虑订阅
 func (h *WebSocketHub) Broadcast(message []byte) {
+	h.enqueue(hubMessage{data: message})
+}
+
+// BroadcastTyped 广播消息给订阅了msgType的客户端
+func (h *WebSocketHub) BroadcastTyped(msgType MessageType, data []byte) {
+	h.enqueue(hubMessage{msgType: msgType, data: data})
+}
+
+// enqueue 将消息放入广播队列，队列满时丢弃
+func (h *WebSocketHub) enqueue(message hubMessage) {
 	select {
 	case h.broadcast <- message:
 	default:
@@ -180,6 +457,42 @@ func (h *WebSocketHub) Broadcast(message []byte) {
 	}
 }
 
+// admit 通知注册结果
+func (c *Client) admit(ok bool) {
+	if c.admitted != nil {
+		c.admitted <- ok
+	}
+}
+
+// recordReceived 记录一条已解析的客户端消息
+func (h *WebSocketHub) recordReceived() {
+	h.received.Add(1)
+	h.lastReceived.Store(time.Now().UnixNano())
+}
+
+// receivedStats 获取已接收的客户端消息数和最近接收时间
+func (h *WebSocketHub) receivedStats() (int64, time.Time) {
+	var last time.Time
+	if nanos := h.lastReceived.Load(); nanos > 0 {
+		last = time.Unix(0, nanos)
+	}
+	return h.received.Load(), last
+}
+
+// rejectedCount 获取被拒绝的连接数
+func (h *WebSocketHub) rejectedCount() int64 {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return h.rejected
+}
+
+// clientCount 获取当前连接数
+func (h *WebSocketHub) clientCount() int {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return len(h.clients)
+}
+
 // SendToClient 发送消息给特定客户端
 func (h *WebSocketHub) SendToClient(clientID string, message []byte) {
 	h.mu.RLock()
@@ -238,7 +551,11 @@ func (c *Client) writePump() {
 // readPump WebSocket读取泵
 func (c *Client) readPump(h *WebSocketHub) {
 	defer func() {
-		h.unregister <- c
+		select {
+		case h.unregister <- c:
+		case <-h.ctx.Done():
+			// 中心已停止并关闭了所有客户端的发送通道
+		}
 		c.conn.Close()
 	}()
 
@@ -258,7 +575,11 @@ func (c *Client) readPump(h *WebSocketHub) {
 			continue
 		}
 
+		h.recordReceived()
 		c.handleClientMessage(clientMsg)
+		if clientMsg.Type == "subscribe" || clientMsg.Type == "unsubscribe" {
+			h.saveSubscriptions(c)
+		}
 	}
 }
 
@@ -266,10 +587,14 @@ func (c *Client) readPump(h *WebSocketHub) {
 func (c *Client) handleClientMessage(msg ClientMessage) {
 	switch msg.Type {
 	case "subscribe":
+		c.subMu.Lock()
 		c.subscriptions[msg.Topic] = true
+		c.subMu.Unlock()
 		log.Printf("Client %s subscribed to %s", c.clientID, msg.Topic)
 	case "unsubscribe":
+		c.subMu.Lock()
 		delete(c.subscriptions, msg.Topic)
+		c.subMu.Unlock()
 		log.Printf("Client %s unsubscribed from %s", c.clientID, msg.Topic)
 	case "ping":
 		// 处理ping消息
@@ -277,12 +602,12 @@ func (c *Client) handleClientMessage(msg ClientMessage) {
 	}
 }
 
-// NewRealtimeMonitor 创建实时监控器
-func NewRealtimeMonitor() *RealtimeMonitor {
+// NewRealtimeMonitor 创建实时监控器，maxConnections<=0表示不限制WebSocket连接数
+func NewRealtimeMonitor(maxConnections int) *RealtimeMonitor {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	monitor := &RealtimeMonitor{
-		hub:    NewWebSocketHub(),
+		hub:    NewWebSocketHub(maxConnections),
 		ctx:    ctx,
 		cancel: cancel,
 		stats: &MonitorStats{
@@ -329,6 +654,21 @@ func (m *RealtimeMonitor) Stop() error {
 	return nil
 }
 
+// Shutdown 停止监控器并等待WebSocket连接关闭，未运行时直接返回
+func (m *RealtimeMonitor) Shutdown(ctx context.Context) error {
+	m.mu.RLock()
+	running := m.running
+	m.mu.RUnlock()
+	if !running {
+		return nil
+	}
+
+	if err := m.Stop(); err != nil {
+		return err
+	}
+	return m.hub.Wait(ctx)
+}
+
 // SendMarketData 发送市场数据
 func (m *RealtimeMonitor) SendMarketData(data MarketDataMessage) error {
 	if !m.running {
@@ -352,10 +692,15 @@ func (m *RealtimeMonitor) SendMarketData(data MarketDataMessage) error {
 		return fmt.Errorf("failed to marshal message: %v", err)
 	}
 
-	m.hub.Broadcast(messageBytes)
+	m.hub.BroadcastTyped(msg.Type, messageBytes)
 	m.updateStats(len(messageBytes), 0)
 
-	log.Printf("Sent market data for %s", data.Symbol)
+	m.mu.RLock()
+	debug := m.debug
+	m.mu.RUnlock()
+	if debug {
+		log.Printf("[DEBUG] Sent market data for %s", data.Symbol)
+	}
 	return nil
 }
 
@@ -382,7 +727,7 @@ func (m *RealtimeMonitor) SendStrategySignal(signal StrategySignalMessage) error
 		return fmt.Errorf("failed to marshal message: %v", err)
 	}
 
-	m.hub.Broadcast(messageBytes)
+	m.hub.BroadcastTyped(msg.Type, messageBytes)
 	m.updateStats(len(messageBytes), 0)
 
 	log.Printf("Sent strategy signal: %s %s (strength: %.2f)", signal.Symbol, signal.SignalType, signal.Strength)
@@ -412,7 +757,7 @@ func (m *RealtimeMonitor) SendTradeEvent(event TradeEventMessage) error {
 		return fmt.Errorf("failed to marshal message: %v", err)
 	}
 
-	m.hub.Broadcast(messageBytes)
+	m.hub.BroadcastTyped(msg.Type, messageBytes)
 	m.updateStats(len(messageBytes), 0)
 
 	log.Printf("Sent trade event: %s %s %d shares", event.Symbol, event.Action, event.Quantity)
@@ -442,7 +787,7 @@ func (m *RealtimeMonitor) SendRiskAlert(alert RiskAlertMessage) error {
 		return fmt.Errorf("failed to marshal message: %v", err)
 	}
 
-	m.hub.Broadcast(messageBytes)
+	m.hub.BroadcastTyped(msg.Type, messageBytes)
 	m.updateStats(len(messageBytes), 0)
 
 	log.Printf("Sent risk alert: %s - %s", alert.Level, alert.Message)
@@ -472,7 +817,7 @@ func (m *RealtimeMonitor) SendSystemStatus(status SystemStatusMessage) error {
 		return fmt.Errorf("failed to marshal message: %v", err)
 	}
 
-	m.hub.Broadcast(messageBytes)
+	m.hub.BroadcastTyped(msg.Type, messageBytes)
 	m.updateStats(len(messageBytes), 0)
 
 	return nil
@@ -506,6 +851,7 @@ func (m *RealtimeMonitor) SendHeartbeat() error {
 		return fmt.Errorf("failed to marshal message: %v", err)
 	}
 
+	// 心跳用于连接保活，发送给所有客户端
 	m.hub.Broadcast(messageBytes)
 	m.updateStats(len(messageBytes), 0)
 
@@ -521,8 +867,16 @@ func (m *RealtimeMonitor) GetStats() *MonitorStats {
 	if m.running {
 		stats.Uptime = time.Since(m.stats.StartTime)
 	}
-	stats.ConnectedClients = int64(len(m.hub.clients))
-	stats.LastMessageTime = time.Now()
+	stats.ConnectedClients = int64(m.hub.clientCount())
+	stats.MaxConnections = m.hub.maxConnections
+	stats.RejectedClients = m.hub.rejectedCount()
+
+	// 最近消息时间取发送和接收中较晚的一次
+	received, lastReceived := m.hub.receivedStats()
+	stats.MessagesReceived = received
+	if lastReceived.After(stats.LastMessageTime) {
+		stats.LastMessageTime = lastReceived
+	}
 
 	return &stats
 }
@@ -541,6 +895,13 @@ func (m *RealtimeMonitor) updateStats(bytesSent int, bytesReceived int) {
 	m.stats.LastMessageTime = time.Now()
 }
 
+// SetDebugLogging 设置是否逐条记录推送的行情消息，回放和实时行情每根K线都会推送
+func (m *RealtimeMonitor) SetDebugLogging(enabled bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.debug = enabled
+}
+
 // SetAlertSystem 设置告警系统
 func (m *RealtimeMonitor) SetAlertSystem(alertSystem *AlertSystem) {
 	m.mu.Lock()
@@ -552,6 +913,7 @@ func (m *RealtimeMonitor) SetAlertSystem(alertSystem *AlertSystem) {
 
 // MarketDataMessage 市场数据消息
 type MarketDataMessage struct {
+	SessionID     string    `json:"session_id,omitempty"` // 回放会话ID，实时行情为空
 	Symbol        string    `json:"symbol"`
 	Open          float64   `json:"open"`
 	High          float64   `json:"high"`
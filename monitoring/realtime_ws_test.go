@@ -0,0 +1,318 @@
+package monitoring
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// waitFor 轮询直到条件满足或超时
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(2 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out waiting for condition")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func dialHub(t *testing.T, server *httptest.Server, query string) *websocket.Conn {
+	t.Helper()
+	url := "ws" + strings.TrimPrefix(server.URL, "http") + query
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	return conn
+}
+
+func TestWebSocketHubRestoresSessionSubscriptions(t *testing.T) {
+	hub := NewWebSocketHub(0)
+	hub.EnableSessionPersistence(time.Minute)
+	go hub.Start()
+	defer hub.Stop()
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer server.Close()
+
+	conn := dialHub(t, server, "?session=dashboard-1")
+	if err := conn.WriteJSON(ClientMessage{Type: "subscribe", Topic: "market_data"}); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	if err := conn.WriteJSON(ClientMessage{Type: "subscribe", Topic: "risk_alert"}); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	waitFor(t, func() bool { return len(hub.SessionSubscriptions("dashboard-1")) == 2 })
+
+	conn.Close()
+	waitFor(t, func() bool { return hub.clientCount() == 0 })
+
+	// 使用相同令牌重连，无需重新订阅
+	conn = dialHub(t, server, "?session=dashboard-1")
+	defer conn.Close()
+	waitFor(t, func() bool { return hub.clientCount() == 1 })
+
+	hub.mu.RLock()
+	defer hub.mu.RUnlock()
+	for client := range hub.clients {
+		subscriptions := client.Subscriptions()
+		if !subscriptions["market_data"] || !subscriptions["risk_alert"] {
+			t.Fatalf("expected subscriptions restored, got %v", subscriptions)
+		}
+	}
+}
+
+func TestWebSocketHubExpiresSessions(t *testing.T) {
+	hub := NewWebSocketHub(0)
+	hub.EnableSessionPersistence(time.Millisecond)
+
+	hub.sessions["stale"] = &clientSession{
+		subscriptions: map[string]bool{"market_data": true},
+		lastSeen:      time.Now().Add(-time.Second),
+	}
+
+	client := &Client{clientID: "client_new", sessionToken: "stale", subscriptions: make(map[string]bool)}
+	hub.restoreSession(client)
+
+	if len(client.Subscriptions()) != 0 {
+		t.Fatalf("expected expired session not to be restored, got %v", client.Subscriptions())
+	}
+}
+
+func TestWebSocketHubBroadcastTypedHonorsSubscriptions(t *testing.T) {
+	hub := NewWebSocketHub(0)
+	go hub.Start()
+	defer hub.Stop()
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer server.Close()
+
+	alerts := dialHub(t, server, "")
+	defer alerts.Close()
+	market := dialHub(t, server, "")
+	defer market.Close()
+
+	if err := alerts.WriteJSON(ClientMessage{Type: "subscribe", Topic: string(RiskAlert)}); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	if err := market.WriteJSON(ClientMessage{Type: "subscribe", Topic: string(MarketData)}); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	waitFor(t, func() bool {
+		hub.mu.RLock()
+		defer hub.mu.RUnlock()
+		subscribed := 0
+		for client := range hub.clients {
+			subscribed += len(client.Subscriptions())
+		}
+		return subscribed == 2
+	})
+
+	hub.BroadcastTyped(MarketData, []byte("market-1"))
+	hub.BroadcastTyped(RiskAlert, []byte("alert-1"))
+	hub.Broadcast([]byte("all-1"))
+
+	readAll := func(conn *websocket.Conn, n int) []string {
+		t.Helper()
+		var got []string
+		for i := 0; i < n; i++ {
+			if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+				t.Fatalf("set deadline: %v", err)
+			}
+			_, data, err := conn.ReadMessage()
+			if err != nil {
+				t.Fatalf("read failed after %v: %v", got, err)
+			}
+			got = append(got, string(data))
+		}
+		return got
+	}
+
+	// 消息按顺序投递，未订阅的类型被跳过
+	if got := readAll(alerts, 2); got[0] != "alert-1" || got[1] != "all-1" {
+		t.Errorf("risk_alert subscriber received %v, want [alert-1 all-1]", got)
+	}
+	if got := readAll(market, 2); got[0] != "market-1" || got[1] != "all-1" {
+		t.Errorf("market_data subscriber received %v, want [market-1 all-1]", got)
+	}
+}
+
+func TestWebSocketHubRejectsConnectionsBeyondLimit(t *testing.T) {
+	hub := NewWebSocketHub(2)
+	go hub.Start()
+	defer hub.Stop()
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer server.Close()
+
+	first := dialHub(t, server, "")
+	defer first.Close()
+	second := dialHub(t, server, "")
+	defer second.Close()
+	waitFor(t, func() bool { return hub.clientCount() == 2 })
+
+	// 第三个连接收到策略违规关闭帧
+	third := dialHub(t, server, "")
+	defer third.Close()
+	if err := third.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("set deadline: %v", err)
+	}
+	_, _, err := third.ReadMessage()
+	if !websocket.IsCloseError(err, websocket.ClosePolicyViolation) {
+		t.Fatalf("expected policy violation close, got %v", err)
+	}
+	if hub.clientCount() != 2 || hub.rejectedCount() != 1 {
+		t.Errorf("clients=%d rejected=%d, want 2 and 1", hub.clientCount(), hub.rejectedCount())
+	}
+
+	// 断开一个连接后可以重新接入
+	first.Close()
+	waitFor(t, func() bool { return hub.clientCount() == 1 })
+	fourth := dialHub(t, server, "")
+	defer fourth.Close()
+	waitFor(t, func() bool { return hub.clientCount() == 2 })
+}
+
+func TestRealtimeMonitorCountsReceivedMessages(t *testing.T) {
+	monitor := NewRealtimeMonitor(0)
+	if err := monitor.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer monitor.Stop()
+
+	if stats := monitor.GetStats(); !stats.LastMessageTime.IsZero() || stats.MessagesReceived != 0 {
+		t.Fatalf("fresh monitor stats = %+v, want no messages", stats)
+	}
+
+	server := httptest.NewServer(http.HandlerFunc(monitor.GetWebSocketHub().HandleWebSocket))
+	defer server.Close()
+
+	conn := dialHub(t, server, "")
+	defer conn.Close()
+
+	before := time.Now()
+	for _, msg := range []ClientMessage{
+		{Type: "subscribe", Topic: string(MarketData)},
+		{Type: "ping"},
+		{Type: "unsubscribe", Topic: string(MarketData)},
+	} {
+		if err := conn.WriteJSON(msg); err != nil {
+			t.Fatalf("write failed: %v", err)
+		}
+	}
+	// 无法解析的消息不计数
+	if err := conn.WriteMessage(websocket.TextMessage, []byte("not json")); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+	if err := conn.WriteJSON(ClientMessage{Type: "ping"}); err != nil {
+		t.Fatalf("write failed: %v", err)
+	}
+
+	waitFor(t, func() bool { return monitor.GetStats().MessagesReceived == 4 })
+
+	stats := monitor.GetStats()
+	if stats.LastMessageTime.Before(before) || stats.LastMessageTime.After(time.Now()) {
+		t.Errorf("last message time %s not within the test window", stats.LastMessageTime)
+	}
+
+	// 查询统计不应刷新最近消息时间
+	time.Sleep(10 * time.Millisecond)
+	if again := monitor.GetStats(); !again.LastMessageTime.Equal(stats.LastMessageTime) {
+		t.Errorf("last message time moved from %s to %s without new messages", stats.LastMessageTime, again.LastMessageTime)
+	}
+}
+
+func TestWebSocketHubChecksOrigin(t *testing.T) {
+	hub := NewWebSocketHub(0)
+	go hub.Start()
+	defer hub.Stop()
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	dial := func(origin string) error {
+		header := http.Header{}
+		if origin != "" {
+			header.Set("Origin", origin)
+		}
+		conn, _, err := websocket.DefaultDialer.Dial(url, header)
+		if err == nil {
+			conn.Close()
+		}
+		return err
+	}
+
+	// 默认只允许同源和不带Origin的客户端
+	if err := dial(server.URL); err != nil {
+		t.Errorf("same origin rejected: %v", err)
+	}
+	if err := dial(""); err != nil {
+		t.Errorf("client without origin rejected: %v", err)
+	}
+	if err := dial("https://evil.example.com"); err == nil {
+		t.Error("cross origin accepted by default")
+	}
+
+	hub.SetAllowedOrigins([]string{"https://dash.example.com/"})
+	if err := dial("https://dash.example.com"); err != nil {
+		t.Errorf("allowed origin rejected: %v", err)
+	}
+	if err := dial("https://evil.example.com"); err == nil {
+		t.Error("origin outside allowlist accepted")
+	}
+
+	hub.SetAllowedOrigins([]string{"*"})
+	if err := dial("https://evil.example.com"); err != nil {
+		t.Errorf("wildcard should allow any origin: %v", err)
+	}
+}
+
+func TestRealtimeMonitorShutdownClosesConnections(t *testing.T) {
+	monitor := NewRealtimeMonitor(0)
+	if err := monitor.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	hub := monitor.GetWebSocketHub()
+
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer server.Close()
+
+	conn := dialHub(t, server, "")
+	defer conn.Close()
+	waitFor(t, func() bool { return hub.clientCount() == 1 })
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := monitor.Shutdown(ctx); err != nil {
+		t.Fatalf("Shutdown: %v", err)
+	}
+
+	// 客户端收到关闭帧而非连接被直接断开
+	if err := conn.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("set deadline: %v", err)
+	}
+	if _, _, err := conn.ReadMessage(); !websocket.IsCloseError(err, websocket.CloseNoStatusReceived) {
+		t.Fatalf("expected close frame, got %v", err)
+	}
+
+	// 停止后的新连接被拒绝，重复关闭无副作用
+	late := dialHub(t, server, "")
+	defer late.Close()
+	if err := late.SetReadDeadline(time.Now().Add(2 * time.Second)); err != nil {
+		t.Fatalf("set deadline: %v", err)
+	}
+	if _, _, err := late.ReadMessage(); err == nil {
+		t.Fatal("connection accepted after shutdown")
+	}
+	if err := monitor.Shutdown(ctx); err != nil {
+		t.Fatalf("second Shutdown: %v", err)
+	}
+}
@@ -4,6 +4,8 @@ package monitoring
 import (
 	"encoding/json"
 	"fmt"
+	"log"
+	"sort"
 	"sync"
 	"time"
 )
@@ -56,9 +58,10 @@ type ReplayEngine struct {
 	sessions     map[string]*ReplaySession
 	sessionsMu   sync.RWMutex
 	dataProvider ReplayDataProvider
-	stopChan     map[string]chan struct{}
-	pauseChan    map[string]chan struct{}
+	data         map[string][]ReplayDataPoint // 回放中会话的数据，会话停止后释放
+	stopChan     map[string]chan struct{}     // 回放中会话的停止信号，会话停止后释放
 	mu           sync.Mutex
+	monitor      *RealtimeMonitor // 设置后回放K线推送到WebSocket
 }
 
 // ReplayDataProvider 回放数据源接口
@@ -81,14 +84,18 @@ type ReplayDataPoint struct {
 func NewReplayEngine(dataProvider ReplayDataProvider) *ReplayEngine {
 	return &ReplayEngine{
 		sessions:     make(map[string]*ReplaySession),
+		data:         make(map[string][]ReplayDataPoint),
 		stopChan:     make(map[string]chan struct{}),
-		pauseChan:    make(map[string]chan struct{}),
 		dataProvider: dataProvider,
 	}
 }
 
 // StartSession 开始回放会话
 func (re *ReplayEngine) StartSession(symbol string, startDate, endDate time.Time, speed float64) (*ReplaySession, error) {
+	if speed <= 0 || speed > 100 {
+		return nil, fmt.Errorf("无效的速度值")
+	}
+
 	// 获取历史数据
 	data, err := re.dataProvider.FetchData(symbol, startDate, endDate)
 	if err != nil {
@@ -117,26 +124,45 @@ func (re *ReplayEngine) StartSession(symbol string, startDate, endDate time.Time
 
 	re.sessionsMu.Lock()
 	re.sessions[session.ID] = session
-	re.stopChan[session.ID] = make(chan struct{})
-	re.pauseChan[session.ID] = make(chan struct{})
+	re.data[session.ID] = data
+	stopChan := make(chan struct{})
+	re.stopChan[session.ID] = stopChan
 	re.sessionsMu.Unlock()
 
 	// 启动回放
-	go re.runReplay(session, data)
+	go re.runReplay(session, data, stopChan)
 
 	return session, nil
 }
 
-// runReplay 运行回放
-func (re *ReplayEngine) runReplay(session *ReplaySession, data []ReplayDataPoint) {
-	ticker := time.NewTicker(time.Second / time.Duration(session.Speed))
+// runReplay 运行回放，回放结束或停止后释放会话的数据和停止信号
+func (re *ReplayEngine) runReplay(session *ReplaySession, data []ReplayDataPoint, stopChan <-chan struct{}) {
+	re.sessionsMu.RLock()
+	speed := session.Speed
+	re.sessionsMu.RUnlock()
+	defer re.releaseSession(session.ID)
+
+	ticker := time.NewTicker(replayInterval(speed))
 	defer ticker.Stop()
 
 	for {
 		select {
 		case <-ticker.C:
+			re.sessionsMu.Lock()
+			// 速度在回放过程中可能被调整
+			if session.Speed != speed {
+				speed = session.Speed
+				ticker.Reset(replayInterval(speed))
+			}
+
+			if session.Status == ReplayPaused {
+				re.sessionsMu.Unlock()
+				continue
+			}
+
 			if session.CurrentIndex >= len(data) {
 				session.Status = ReplayStopped
+				re.sessionsMu.Unlock()
 				re.addEvent(session, ReplayEvent{
 					Timestamp: time.Now(),
 					Type:      "complete",
@@ -146,14 +172,16 @@ func (re *ReplayEngine) runReplay(session *ReplaySession, data []ReplayDataPoint
 			}
 
 			// 更新当前数据点
-			point := data[session.CurrentIndex]
+			index := session.CurrentIndex
+			point := data[index]
 			session.CurrentTime = point.Timestamp
 			session.CurrentIndex++
 			session.Progress = float64(session.CurrentIndex) / float64(len(data)) * 100
+			session.Signals = append(session.Signals, point.Signals...)
+			re.sessionsMu.Unlock()
 
 			// 处理信号
 			for _, signal := range point.Signals {
-				session.Signals = append(session.Signals, signal)
 				re.addEvent(session, ReplayEvent{
 					Timestamp: point.Timestamp,
 					Type:      "signal",
@@ -162,26 +190,33 @@ func (re *ReplayEngine) runReplay(session *ReplaySession, data []ReplayDataPoint
 				})
 			}
 
-			// 广播当前状态
-			re.broadcastState(session)
-
-		case <-re.pauseChan[session.ID]:
-			// 等待恢复
-			<-re.pauseChan[session.ID]
+			// 推送当前K线
+			prevClose := point.Open
+			if index > 0 {
+				prevClose = data[index-1].Close
+			}
+			re.broadcastState(session, point, prevClose)
 
-		case <-re.stopChan[session.ID]:
+		case <-stopChan:
+			re.sessionsMu.Lock()
 			session.Status = ReplayStopped
+			re.sessionsMu.Unlock()
 			return
 		}
 	}
 }
 
+// replayInterval 根据回放速度计算每根K线的推进间隔
+func replayInterval(speed float64) time.Duration {
+	return time.Duration(float64(time.Second) / speed)
+}
+
 // PauseSession 暂停回放
 func (re *ReplayEngine) PauseSession(sessionID string) error {
-	re.sessionsMu.RLock()
-	session, exists := re.sessions[sessionID]
-	re.sessionsMu.RUnlock()
+	re.sessionsMu.Lock()
+	defer re.sessionsMu.Unlock()
 
+	session, exists := re.sessions[sessionID]
 	if !exists {
 		return fmt.Errorf("会话不存在")
 	}
@@ -191,7 +226,7 @@ func (re *ReplayEngine) PauseSession(sessionID string) error {
 	}
 
 	session.Status = ReplayPaused
-	re.addEvent(session, ReplayEvent{
+	session.Events = append(session.Events, ReplayEvent{
 		Timestamp: time.Now(),
 		Type:      "pause",
 		Message:   "回放已暂停",
@@ -202,10 +237,10 @@ func (re *ReplayEngine) PauseSession(sessionID string) error {
 
 // ResumeSession 恢复回放
 func (re *ReplayEngine) ResumeSession(sessionID string) error {
-	re.sessionsMu.RLock()
-	session, exists := re.sessions[sessionID]
-	re.sessionsMu.RUnlock()
+	re.sessionsMu.Lock()
+	defer re.sessionsMu.Unlock()
 
+	session, exists := re.sessions[sessionID]
 	if !exists {
 		return fmt.Errorf("会话不存在")
 	}
@@ -215,7 +250,7 @@ func (re *ReplayEngine) ResumeSession(sessionID string) error {
 	}
 
 	session.Status = ReplayPlaying
-	re.addEvent(session, ReplayEvent{
+	session.Events = append(session.Events, ReplayEvent{
 		Timestamp: time.Now(),
 		Type:      "resume",
 		Message:   "回放已恢复",
@@ -226,39 +261,51 @@ func (re *ReplayEngine) ResumeSession(sessionID string) error {
 
 // StopSession 停止回放
 func (re *ReplayEngine) StopSession(sessionID string) error {
-	re.sessionsMu.RLock()
-	_, exists := re.sessions[sessionID]
-	re.sessionsMu.RUnlock()
+	re.sessionsMu.Lock()
+	defer re.sessionsMu.Unlock()
 
-	if !exists {
+	if _, exists := re.sessions[sessionID]; !exists {
 		return fmt.Errorf("会话不存在")
 	}
 
-	re.mu.Lock()
+	// 已结束的会话没有停止信号，重复停止不做处理
 	if stopChan, ok := re.stopChan[sessionID]; ok {
 		close(stopChan)
+		delete(re.stopChan, sessionID)
 	}
-	re.mu.Unlock()
 
 	return nil
 }
 
-// SetSpeed 设置回放速度
+// releaseSession 释放已停止会话的回放数据和停止信号，会话本身保留以便查询结果
+func (re *ReplayEngine) releaseSession(sessionID string) {
+	re.sessionsMu.Lock()
+	defer re.sessionsMu.Unlock()
+
+	delete(re.data, sessionID)
+	delete(re.stopChan, sessionID)
+}
+
+// SetSpeed 设置回放速度，回放过程中调整立即生效
 func (re *ReplayEngine) SetSpeed(sessionID string, speed float64) error {
-	re.sessionsMu.RLock()
-	session, exists := re.sessions[sessionID]
-	re.sessionsMu.RUnlock()
+	if speed <= 0 || speed > 100 {
+		return fmt.Errorf("无效的速度值")
+	}
+
+	re.sessionsMu.Lock()
+	defer re.sessionsMu.Unlock()
 
+	session, exists := re.sessions[sessionID]
 	if !exists {
 		return fmt.Errorf("会话不存在")
 	}
 
-	if speed <= 0 || speed > 100 {
-		return fmt.Errorf("无效的速度值")
+	if session.Status == ReplayStopped {
+		return fmt.Errorf("会话已停止")
 	}
 
 	session.Speed = speed
-	re.addEvent(session, ReplayEvent{
+	session.Events = append(session.Events, ReplayEvent{
 		Timestamp: time.Now(),
 		Type:      "speed",
 		Data:      speed,
@@ -268,6 +315,82 @@ func (re *ReplayEngine) SetSpeed(sessionID string, speed float64) error {
 	return nil
 }
 
+// Seek 跳转到指定时间，后续回放从该时间点（含）之后的第一根K线开始，不改变播放/暂停状态
+func (re *ReplayEngine) Seek(sessionID string, t time.Time) error {
+	re.sessionsMu.Lock()
+	defer re.sessionsMu.Unlock()
+
+	_, err := re.seekLocked(sessionID, t)
+	return err
+}
+
+// SeekSession 跳转到指定时间并从该处继续推送，暂停中的会话会被恢复播放
+func (re *ReplayEngine) SeekSession(sessionID string, to time.Time) error {
+	re.sessionsMu.Lock()
+	defer re.sessionsMu.Unlock()
+
+	session, err := re.seekLocked(sessionID, to)
+	if err != nil {
+		return err
+	}
+
+	if session.Status == ReplayPaused {
+		session.Status = ReplayPlaying
+		session.Events = append(session.Events, ReplayEvent{
+			Timestamp: time.Now(),
+			Type:      "resume",
+			Message:   "回放已恢复",
+		})
+	}
+
+	return nil
+}
+
+// seekLocked 移动会话游标，已回放的信号截断到跳转位置之前，调用方需持有sessionsMu
+func (re *ReplayEngine) seekLocked(sessionID string, t time.Time) (*ReplaySession, error) {
+	session, exists := re.sessions[sessionID]
+	if !exists {
+		return nil, fmt.Errorf("会话不存在")
+	}
+
+	if session.Status == ReplayStopped {
+		return nil, fmt.Errorf("会话已停止")
+	}
+
+	if t.Before(session.StartDate) || t.After(session.EndDate) {
+		return nil, fmt.Errorf("跳转时间超出回放区间")
+	}
+
+	data := re.data[sessionID]
+	index := sort.Search(len(data), func(i int) bool {
+		return !data[i].Timestamp.Before(t)
+	})
+	if index >= len(data) {
+		return nil, fmt.Errorf("跳转时间之后无回放数据")
+	}
+
+	// 只保留目标K线之前实际回放过的信号，向前跳过的K线不补发信号
+	signals := make([]ReplaySignal, 0, len(session.Signals))
+	for _, signal := range session.Signals {
+		if signal.Timestamp.Before(data[index].Timestamp) {
+			signals = append(signals, signal)
+		}
+	}
+
+	session.CurrentIndex = index
+	session.CurrentTime = data[index].Timestamp
+	session.Progress = float64(index) / float64(len(data)) * 100
+	session.Signals = signals
+	session.Events = append(session.Events, ReplayEvent{
+		Timestamp: time.Now(),
+		Type:      "seek",
+		Data:      t,
+		Message:   fmt.Sprintf("回放跳转至 %s", data[index].Timestamp.Format("2006-01-02 15:04:05")),
+	})
+
+	return session, nil
+}
+
 // GetSession 获取回放会话
 func (re *ReplayEngine) GetSession(sessionID string) (*ReplaySession, error) {
 	re.sessionsMu.RLock()
@@ -307,15 +430,13 @@ func (re *ReplayEngine) DeleteSession(sessionID string) error {
 	}
 
 	// 停止回放
-	re.mu.Lock()
 	if stopChan, ok := re.stopChan[sessionID]; ok {
 		close(stopChan)
 	}
 	delete(re.stopChan, sessionID)
-	delete(re.pauseChan, sessionID)
-	re.mu.Unlock()
 
 	delete(re.sessions, sessionID)
+	delete(re.data, sessionID)
 	return nil
 }
 
@@ -327,11 +448,40 @@ func (re *ReplayEngine) addEvent(session *ReplaySession, event ReplayEvent) {
 	session.Events = append(session.Events, event)
 }
 
-// broadcastState 广播回放状态
-func (re *ReplayEngine) broadcastState(session *ReplaySession) {
-	// 通过WebSocket广播
-	// 使用现有的WebSocketHub进行广播
-	// 这里简化处理，实际应注入WebSocketHub实例
+// SetMonitor 设置实时监控器，回放的每根K线以MarketData消息推送给WebSocket客户端
+func (re *ReplayEngine) SetMonitor(monitor *RealtimeMonitor) {
+	re.mu.Lock()
+	defer re.mu.Unlock()
+	re.monitor = monitor
+}
+
+// broadcastState 将回放到的K线以带会话ID的MarketData消息推送
+func (re *ReplayEngine) broadcastState(session *ReplaySession, point ReplayDataPoint, prevClose float64) {
+	re.mu.Lock()
+	monitor := re.monitor
+	re.mu.Unlock()
+	if monitor == nil {
+		return
+	}
+
+	message := MarketDataMessage{
+		SessionID: session.ID,
+		Symbol:    session.Symbol,
+		Open:      point.Open,
+		High:      point.High,
+		Low:       point.Low,
+		Close:     point.Close,
+		Volume:    point.Volume,
+		Change:    point.Close - prevClose,
+		Timestamp: point.Timestamp,
+	}
+	if prevClose != 0 {
+		message.ChangePercent = message.Change / prevClose * 100
+	}
+
+	if err := monitor.SendMarketData(message); err != nil {
+		log.Printf("Failed to stream replay bar for session %s: %v", session.ID, err)
+	}
 }
 
 // generateSessionID 生成会话ID
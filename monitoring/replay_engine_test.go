@@ -0,0 +1,286 @@
+package monitoring
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestReplayEngineSeek(t *testing.T) {
+	engine := NewReplayEngine(NewMockReplayDataProvider())
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+
+	session, err := engine.StartSession("sh600000", start, end, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer engine.DeleteSession(session.ID)
+
+	if err := engine.PauseSession(session.ID); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+
+	if err := engine.Seek(session.ID, end.Add(time.Hour)); err == nil {
+		t.Fatal("expected error for seek target outside session window")
+	}
+
+	target := start.Add(2 * time.Hour)
+	if err := engine.Seek(session.ID, target); err != nil {
+		t.Fatalf("seek failed: %v", err)
+	}
+
+	sought, err := engine.GetSession(session.ID)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !sought.CurrentTime.Equal(target) {
+		t.Fatalf("expected current time %v, got %v", target, sought.CurrentTime)
+	}
+
+	if err := engine.ResumeSession(session.ID); err != nil {
+		t.Fatalf("resume failed: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		current, _ := engine.GetSession(session.ID)
+		if current.CurrentIndex > sought.CurrentIndex {
+			if current.CurrentTime.Before(target) {
+				t.Fatalf("expected bars from %v onwards, got %v", target, current.CurrentTime)
+			}
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("replay did not advance after seek")
+}
+
+func TestReplayEngineSeekSessionResumes(t *testing.T) {
+	engine := NewReplayEngine(NewMockReplayDataProvider())
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+
+	session, err := engine.StartSession("sh600000", start, end, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer engine.DeleteSession(session.ID)
+
+	if err := engine.PauseSession(session.ID); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if err := engine.SeekSession(session.ID, start.Add(-time.Minute)); err == nil {
+		t.Fatal("expected error for seek target before session start")
+	}
+	if err := engine.SeekSession("missing", start); err == nil {
+		t.Fatal("expected error for unknown session")
+	}
+
+	target := start.Add(time.Hour)
+	if err := engine.SeekSession(session.ID, target); err != nil {
+		t.Fatalf("seek failed: %v", err)
+	}
+
+	sought, _ := engine.GetSession(session.ID)
+	if sought.Status != ReplayPlaying {
+		t.Fatalf("expected session to resume playing, got %s", sought.Status)
+	}
+	// 信号只保留跳转位置之前已回放的部分
+	for _, signal := range sought.Signals {
+		if !signal.Timestamp.Before(target) {
+			t.Fatalf("signal at %v kept after seeking to %v", signal.Timestamp, target)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		current, _ := engine.GetSession(session.ID)
+		if current.CurrentIndex > sought.CurrentIndex {
+			if current.CurrentTime.Before(target) {
+				t.Fatalf("expected bars from %v onwards, got %v", target, current.CurrentTime)
+			}
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("replay did not stream after seek")
+}
+
+func TestReplayEngineSeekKeepsPauseState(t *testing.T) {
+	engine := NewReplayEngine(NewMockReplayDataProvider())
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	end := start.Add(4 * time.Hour)
+
+	session, err := engine.StartSession("sh600000", start, end, 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer engine.DeleteSession(session.ID)
+
+	if err := engine.PauseSession(session.ID); err != nil {
+		t.Fatalf("pause failed: %v", err)
+	}
+	if err := engine.Seek(session.ID, start.Add(-time.Minute)); err == nil {
+		t.Fatal("expected error for seek target before session start")
+	}
+	if err := engine.Seek("missing", start); err == nil {
+		t.Fatal("expected error for unknown session")
+	}
+
+	paused, _ := engine.GetSession(session.ID)
+	target := start.Add(time.Hour)
+	if err := engine.Seek(session.ID, target); err != nil {
+		t.Fatalf("seek failed: %v", err)
+	}
+
+	sought, _ := engine.GetSession(session.ID)
+	if sought.Status != ReplayPaused {
+		t.Fatalf("expected session to stay paused, got %s", sought.Status)
+	}
+	// 向前跳转跳过的K线不产生信号
+	if len(sought.Signals) != len(paused.Signals) {
+		t.Fatalf("expected %d replayed signals after forward seek, got %d", len(paused.Signals), len(sought.Signals))
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	current, _ := engine.GetSession(session.ID)
+	if current.CurrentIndex != sought.CurrentIndex {
+		t.Fatalf("paused session advanced from %d to %d after seek", sought.CurrentIndex, current.CurrentIndex)
+	}
+
+	// 向后跳转截断目标位置之后的信号
+	if err := engine.Seek(session.ID, start); err != nil {
+		t.Fatalf("seek back failed: %v", err)
+	}
+	rewound, _ := engine.GetSession(session.ID)
+	if len(rewound.Signals) != 0 || rewound.CurrentIndex != 0 {
+		t.Fatalf("expected rewind to clear signals, got index %d with %d signals", rewound.CurrentIndex, len(rewound.Signals))
+	}
+}
+
+func TestReplayEngineSetSpeed(t *testing.T) {
+	engine := NewReplayEngine(NewMockReplayDataProvider())
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+
+	session, err := engine.StartSession("sh600000", start, start.Add(time.Hour), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer engine.DeleteSession(session.ID)
+
+	if err := engine.SetSpeed(session.ID, 0); err == nil {
+		t.Fatal("expected error for invalid speed")
+	}
+	if err := engine.SetSpeed(session.ID, 0.5); err != nil {
+		t.Fatalf("set speed failed: %v", err)
+	}
+
+	current, _ := engine.GetSession(session.ID)
+	if current.Speed != 0.5 {
+		t.Fatalf("expected speed 0.5, got %v", current.Speed)
+	}
+}
+
+func TestReplayEngineStreamsBarsToMonitor(t *testing.T) {
+	monitor := NewRealtimeMonitor(0)
+	if err := monitor.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer monitor.Stop()
+
+	hub := monitor.GetWebSocketHub()
+	server := httptest.NewServer(http.HandlerFunc(hub.HandleWebSocket))
+	defer server.Close()
+
+	conn := dialHub(t, server, "")
+	defer conn.Close()
+	if err := conn.WriteJSON(ClientMessage{Type: "subscribe", Topic: string(MarketData)}); err != nil {
+		t.Fatalf("subscribe failed: %v", err)
+	}
+	waitFor(t, func() bool {
+		hub.mu.RLock()
+		defer hub.mu.RUnlock()
+		for client := range hub.clients {
+			return client.IsSubscribed(string(MarketData))
+		}
+		return false
+	})
+
+	engine := NewReplayEngine(NewMockReplayDataProvider())
+	engine.SetMonitor(monitor)
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+	session, err := engine.StartSession("sh600000", start, start.Add(time.Hour), 100)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	defer engine.DeleteSession(session.ID)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	var bars []MarketDataMessage
+	for len(bars) < 2 {
+		var msg Message
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("read failed after %d bars: %v", len(bars), err)
+		}
+		if msg.Type != MarketData {
+			continue
+		}
+		var bar MarketDataMessage
+		if err := json.Unmarshal(msg.Data, &bar); err != nil {
+			t.Fatalf("decode market data: %v", err)
+		}
+		bars = append(bars, bar)
+	}
+
+	for _, bar := range bars {
+		if bar.SessionID != session.ID || bar.Symbol != "sh600000" {
+			t.Errorf("bar tagged %q/%q, want %q/sh600000", bar.SessionID, bar.Symbol, session.ID)
+		}
+	}
+	if !bars[1].Timestamp.After(bars[0].Timestamp) {
+		t.Errorf("bars out of order: %s then %s", bars[0].Timestamp, bars[1].Timestamp)
+	}
+	if want := bars[1].Close - bars[0].Close; bars[1].Change-want > 1e-9 || want-bars[1].Change > 1e-9 {
+		t.Errorf("change = %v, want %v", bars[1].Change, want)
+	}
+}
+
+func TestReplayEngineReleasesStoppedSessions(t *testing.T) {
+	engine := NewReplayEngine(NewMockReplayDataProvider())
+	start := time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC)
+
+	session, err := engine.StartSession("sh600000", start, start.Add(4*time.Hour), 100)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := engine.StopSession(session.ID); err != nil {
+		t.Fatalf("stop failed: %v", err)
+	}
+	// 重复停止不应因关闭已关闭的通道而panic
+	if err := engine.StopSession(session.ID); err != nil {
+		t.Fatalf("second stop failed: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for {
+		engine.sessionsMu.RLock()
+		_, hasData := engine.data[session.ID]
+		_, hasStop := engine.stopChan[session.ID]
+		engine.sessionsMu.RUnlock()
+		if !hasData && !hasStop {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("stopped session still holds data=%v stop channel=%v", hasData, hasStop)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	stopped, err := engine.GetSession(session.ID)
+	if err != nil || stopped.Status != ReplayStopped {
+		t.Errorf("stopped session = %+v, %v; want it kept with status stopped", stopped, err)
+	}
+}
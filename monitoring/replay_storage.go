@@ -0,0 +1,135 @@
+package monitoring
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"cloudquant/market"
+)
+
+// KlineLoader 按时间区间读取历史K线，pipeline.OptimizedStorage 实现了该接口
+type KlineLoader interface {
+	LoadKlines(ctx context.Context, symbol string, start, end time.Time) ([]market.KLine, error)
+}
+
+// StorageReplayDataProvider 从本地行情存储读取真实历史K线作为回放数据
+type StorageReplayDataProvider struct {
+	loader KlineLoader
+}
+
+// NewStorageReplayDataProvider 创建基于行情存储的回放数据提供者
+func NewStorageReplayDataProvider(loader KlineLoader) *StorageReplayDataProvider {
+	return &StorageReplayDataProvider{loader: loader}
+}
+
+// FetchData 读取[start, end]区间内的K线，按时间升序返回
+func (s *StorageReplayDataProvider) FetchData(symbol string, start, end time.Time) ([]ReplayDataPoint, error) {
+	if s.loader == nil {
+		return nil, fmt.Errorf("行情存储未配置")
+	}
+
+	klines, err := s.loader.LoadKlines(context.Background(), symbol, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]ReplayDataPoint, 0, len(klines))
+	for _, kline := range klines {
+		data = append(data, ReplayDataPoint{
+			Timestamp: kline.Timestamp,
+			Open:      kline.Open,
+			High:      kline.High,
+			Low:       kline.Low,
+			Close:     kline.Close,
+			Volume:    kline.Volume,
+		})
+	}
+	// 回放游标和Seek依赖时间顺序
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].Timestamp.Before(data[j].Timestamp)
+	})
+
+	return data, nil
+}
+
+// HistoryFetcher 按天数获取最近的日K线，market.MarketProvider 实现了该接口
+type HistoryFetcher interface {
+	GetHistoricalData(symbol string, days int) ([]market.KLine, error)
+}
+
+// FetcherReplayDataProvider 通过行情数据源实时获取日K线作为回放数据
+type FetcherReplayDataProvider struct {
+	fetcher HistoryFetcher
+	now     func() time.Time
+}
+
+// NewFetcherReplayDataProvider 创建基于行情数据源的回放数据提供者
+func NewFetcherReplayDataProvider(fetcher HistoryFetcher) *FetcherReplayDataProvider {
+	return &FetcherReplayDataProvider{fetcher: fetcher, now: time.Now}
+}
+
+// FetchData 获取覆盖start至今的日K线，只返回[start, end]区间内的部分
+func (f *FetcherReplayDataProvider) FetchData(symbol string, start, end time.Time) ([]ReplayDataPoint, error) {
+	if f.fetcher == nil {
+		return nil, fmt.Errorf("行情数据源未配置")
+	}
+
+	days := int(f.now().Sub(start).Hours()/24) + 1
+	if days <= 0 {
+		return nil, nil
+	}
+	klines, err := f.fetcher.GetHistoricalData(symbol, days)
+	if err != nil {
+		return nil, err
+	}
+
+	data := make([]ReplayDataPoint, 0, len(klines))
+	for _, kline := range klines {
+		if kline.Timestamp.Before(start) || kline.Timestamp.After(end) {
+			continue
+		}
+		data = append(data, ReplayDataPoint{
+			Timestamp: kline.Timestamp,
+			Open:      kline.Open,
+			High:      kline.High,
+			Low:       kline.Low,
+			Close:     kline.Close,
+			Volume:    kline.Volume,
+		})
+	}
+	sort.SliceStable(data, func(i, j int) bool {
+		return data[i].Timestamp.Before(data[j].Timestamp)
+	})
+
+	return data, nil
+}
+
+// FallbackReplayDataProvider 依次尝试多个数据提供者，返回第一个在区间内有数据的结果，
+// 用于行情存储尚无该区间K线时回退到数据源
+type FallbackReplayDataProvider struct {
+	providers []ReplayDataProvider
+}
+
+// NewFallbackReplayDataProvider 按优先级创建回退数据提供者
+func NewFallbackReplayDataProvider(providers ...ReplayDataProvider) *FallbackReplayDataProvider {
+	return &FallbackReplayDataProvider{providers: providers}
+}
+
+// FetchData 返回第一个非空结果，全部为空时返回各提供者的错误
+func (f *FallbackReplayDataProvider) FetchData(symbol string, start, end time.Time) ([]ReplayDataPoint, error) {
+	var errs []error
+	for _, provider := range f.providers {
+		data, err := provider.FetchData(symbol, start, end)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if len(data) > 0 {
+			return data, nil
+		}
+	}
+	return nil, errors.Join(errs...)
+}
@@ -0,0 +1,122 @@
+package monitoring
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"cloudquant/market"
+	"cloudquant/pipeline"
+)
+
+func TestStorageReplayDataProviderReturnsChronologicalBars(t *testing.T) {
+	storage, err := pipeline.NewOptimizedStorage(pipeline.StorageConfig{
+		DBPath: filepath.Join(t.TempDir(), "market.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewOptimizedStorage: %v", err)
+	}
+	defer storage.Close()
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 15, 0, 0, 0, time.Local) }
+	// 乱序写入，另一只股票的数据不应混入
+	points := []*pipeline.DataPoint{
+		{Symbol: "sh600000", Timestamp: day(6).Unix(), Open: 10.4, High: 10.6, Low: 10.3, Close: 10.5, Volume: 3000},
+		{Symbol: "sh600000", Timestamp: day(4).Unix(), Open: 10, High: 10.2, Low: 9.9, Close: 10.1, Volume: 1000},
+		{Symbol: "sh600519", Timestamp: day(5).Unix(), Open: 1700, High: 1710, Low: 1690, Close: 1705, Volume: 500},
+		{Symbol: "sh600000", Timestamp: day(5).Unix(), Open: 10.1, High: 10.5, Low: 10, Close: 10.4, Volume: 2000},
+		{Symbol: "sh600000", Timestamp: day(9).Unix(), Open: 10.5, High: 10.8, Low: 10.5, Close: 10.7, Volume: 1500},
+	}
+	if err := storage.SaveBatch(context.Background(), points); err != nil {
+		t.Fatalf("SaveBatch: %v", err)
+	}
+
+	provider := NewStorageReplayDataProvider(storage)
+	data, err := provider.FetchData("sh600000", day(4), day(8))
+	if err != nil {
+		t.Fatalf("FetchData: %v", err)
+	}
+
+	want := []time.Time{day(4), day(5), day(6)}
+	if len(data) != len(want) {
+		t.Fatalf("got %d bars, want %d", len(data), len(want))
+	}
+	for i, ts := range want {
+		if !data[i].Timestamp.Equal(ts) {
+			t.Errorf("bar %d at %s, want %s", i, data[i].Timestamp, ts)
+		}
+	}
+	if data[1].Close != 10.4 || data[1].Volume != 2000 {
+		t.Errorf("bar values = %+v", data[1])
+	}
+
+	// 回放引擎使用存储中的真实K线
+	engine := NewReplayEngine(provider)
+	session, err := engine.StartSession("sh600000", day(4), day(8), 1)
+	if err != nil {
+		t.Fatalf("StartSession: %v", err)
+	}
+	defer engine.DeleteSession(session.ID)
+	if session.TotalData != 3 || !session.CurrentTime.Equal(day(4)) {
+		t.Errorf("session total %d starting %s, want 3 bars from %s", session.TotalData, session.CurrentTime, day(4))
+	}
+
+	if _, err := engine.StartSession("sz000001", day(4), day(8), 1); err == nil {
+		t.Error("symbol without stored bars should fail to start")
+	}
+}
+
+// stubHistoryFetcher 返回固定日K线并记录请求的天数
+type stubHistoryFetcher struct {
+	klines []market.KLine
+	days   int
+}
+
+func (s *stubHistoryFetcher) GetHistoricalData(symbol string, days int) ([]market.KLine, error) {
+	s.days = days
+	return s.klines, nil
+}
+
+func TestFallbackReplayDataProviderUsesFetcherWhenStoreEmpty(t *testing.T) {
+	storage, err := pipeline.NewOptimizedStorage(pipeline.StorageConfig{
+		DBPath: filepath.Join(t.TempDir(), "market.db"),
+	})
+	if err != nil {
+		t.Fatalf("NewOptimizedStorage: %v", err)
+	}
+	defer storage.Close()
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 15, 0, 0, 0, time.Local) }
+	fetcher := &stubHistoryFetcher{klines: []market.KLine{
+		{Timestamp: day(1), Close: 9.8},
+		{Timestamp: day(5), Close: 10.4},
+		{Timestamp: day(4), Close: 10.1},
+	}}
+	fetched := NewFetcherReplayDataProvider(fetcher)
+	fetched.now = func() time.Time { return day(11) }
+
+	provider := NewFallbackReplayDataProvider(NewStorageReplayDataProvider(storage), fetched)
+	data, err := provider.FetchData("sh600000", day(4), day(8))
+	if err != nil {
+		t.Fatalf("FetchData: %v", err)
+	}
+	if len(data) != 2 || !data[0].Timestamp.Equal(day(4)) || data[1].Close != 10.4 {
+		t.Fatalf("fallback bars = %+v, want fetched bars of 4th and 5th in order", data)
+	}
+	if fetcher.days != 8 {
+		t.Errorf("fetched %d days of history, want 8 to cover the range start", fetcher.days)
+	}
+
+	// 存储中有数据时不访问数据源
+	fetcher.days = 0
+	if err := storage.SaveBatch(context.Background(), []*pipeline.DataPoint{
+		{Symbol: "sh600000", Timestamp: day(6).Unix(), Open: 10.4, High: 10.6, Low: 10.3, Close: 10.5, Volume: 3000},
+	}); err != nil {
+		t.Fatalf("SaveBatch: %v", err)
+	}
+	data, err = provider.FetchData("sh600000", day(4), day(8))
+	if err != nil || len(data) != 1 || fetcher.days != 0 {
+		t.Errorf("stored bars = %+v (err %v), fetcher called for %d days", data, err, fetcher.days)
+	}
+}
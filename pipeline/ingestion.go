@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"sync"
 	"time"
 
@@ -31,12 +32,13 @@ type IngestionConfig struct {
 	CheckInterval     time.Duration `json:"check_interval"`
 	EnableIncremental bool          `json:"enable_incremental"`
 	MaxRetries        int           `json:"max_retries"`
+	HistoryDays       int           `json:"history_days"` // 首次摄取时获取的日K线天数
 }
 
 // DataIngester 数据摄取器
 type DataIngester struct {
 	config   IngestionConfig
-	provider *market.MarketProvider
+	provider HistoryProvider
 	storage  DataStorage
 
 	batchBuffer map[string][]*DataPoint
@@ -67,8 +69,13 @@ type DataStorage interface {
 	GetLastTimestamp(ctx context.Context, symbol string) (int64, error)
 }
 
+// HistoryProvider 按天数获取最近的日K线，market.MarketProvider 实现了该接口
+type HistoryProvider interface {
+	GetHistoricalData(symbol string, days int) ([]market.KLine, error)
+}
+
 // NewDataIngester 创建数据摄取器
-func NewDataIngester(config IngestionConfig, provider *market.MarketProvider, storage DataStorage) *DataIngester {
+func NewDataIngester(config IngestionConfig, provider HistoryProvider, storage DataStorage) *DataIngester {
 	if config.BatchSize == 0 {
 		config.BatchSize = 1000
 	}
@@ -81,6 +88,9 @@ func NewDataIngester(config IngestionConfig, provider *market.MarketProvider, st
 	if config.MaxRetries == 0 {
 		config.MaxRetries = 3
 	}
+	if config.HistoryDays == 0 {
+		config.HistoryDays = 250
+	}
 
 	return &DataIngester{
 		config:      config,
@@ -137,16 +147,20 @@ func (di *DataIngester) runIngestionLoop(symbols []string) {
 	ticker := time.NewTicker(di.config.CheckInterval)
 	defer ticker.Stop()
 
+	// 启动时立即摄取一次，之后按检查间隔增量摄取
 	for {
+		for _, symbol := range symbols {
+			if err := di.ingestSymbol(context.Background(), symbol); err != nil {
+				log.Printf("Failed to ingest %s: %v", symbol, err)
+			}
+		}
+		// 日K线每轮只有少量新数据，达不到批量大小时也在本轮结束后写入
+		di.flushAllBatches(context.Background())
+
 		select {
 		case <-di.stopChan:
 			return
 		case <-ticker.C:
-			for _, symbol := range symbols {
-				if err := di.ingestSymbol(context.Background(), symbol); err != nil {
-					log.Printf("Failed to ingest %s: %v", symbol, err)
-				}
-			}
 		}
 	}
 }
@@ -184,50 +198,44 @@ func (di *DataIngester) ingestSymbol(ctx context.Context, symbol string) error {
 	return nil
 }
 
-// fetchData 获取数据
+// fetchData 从行情数据源获取since之后的日K线，since为0时获取HistoryDays天的历史数据
 func (di *DataIngester) fetchData(ctx context.Context, symbol string, since int64) ([]*DataPoint, error) {
-	// 这里应该调用实际的市场数据API
-	// 简化版：生成模拟数据
-
-	var points []*DataPoint
-	now := time.Now().Unix()
+	if di.provider == nil {
+		return nil, fmt.Errorf("market provider not set")
+	}
 
-	// 如果有最后时间戳，获取增量数据
+	days := di.config.HistoryDays
 	if since > 0 {
-		// 获取最近的数据点
-		for i := 0; i < 10; i++ {
-			timestamp := now - int64(i)*60
-			if timestamp <= since {
-				continue
-			}
-			points = append(points, di.generateDataPoint(symbol, timestamp))
-		}
-	} else {
-		// 获取历史数据（这里简化为最近100个点）
-		for i := 0; i < 100; i++ {
-			timestamp := now - int64(i)*60
-			points = append(points, di.generateDataPoint(symbol, timestamp))
-		}
+		days = int(time.Since(time.Unix(since, 0)).Hours()/24) + 1
 	}
 
-	return points, nil
-}
+	klines, err := di.provider.GetHistoricalData(symbol, days)
+	if err != nil {
+		return nil, err
+	}
 
-// generateDataPoint 生成数据点（模拟）
-func (di *DataIngester) generateDataPoint(symbol string, timestamp int64) *DataPoint {
-	basePrice := 100.0
-	price := basePrice + float64(timestamp%100)/10.0
-
-	return &DataPoint{
-		Symbol:    symbol,
-		Timestamp: timestamp,
-		Open:      price * 0.99,
-		High:      price * 1.01,
-		Low:       price * 0.98,
-		Close:     price,
-		Volume:    1000000.0 + float64(timestamp%10000)*100,
-		Amount:    (1000000.0 + float64(timestamp%10000)*100) * price,
+	points := make([]*DataPoint, 0, len(klines))
+	for _, kline := range klines {
+		timestamp := kline.Timestamp.Unix()
+		if timestamp <= since {
+			continue
+		}
+		points = append(points, &DataPoint{
+			Symbol:    symbol,
+			Timestamp: timestamp,
+			Open:      kline.Open,
+			High:      kline.High,
+			Low:       kline.Low,
+			Close:     kline.Close,
+			Volume:    float64(kline.Volume),
+		})
 	}
+	// 进度取最后一个数据点的时间戳，需按时间升序写入
+	sort.Slice(points, func(i, j int) bool {
+		return points[i].Timestamp < points[j].Timestamp
+	})
+
+	return points, nil
 }
 
 // addToBuffer 添加到缓冲区
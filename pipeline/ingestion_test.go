@@ -0,0 +1,72 @@
+package pipeline
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"cloudquant/market"
+)
+
+// stubHistoryProvider 返回固定的日K线并记录每次请求的天数
+type stubHistoryProvider struct {
+	mu     sync.Mutex
+	klines []market.KLine
+	days   []int
+}
+
+func (s *stubHistoryProvider) GetHistoricalData(symbol string, days int) ([]market.KLine, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.days = append(s.days, days)
+	return s.klines, nil
+}
+
+func TestDataIngesterPersistsFetchedBars(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 15, 0, 0, 0, time.Local) }
+	provider := &stubHistoryProvider{klines: []market.KLine{
+		{Symbol: "sh600000", Timestamp: day(5), Open: 10.1, High: 10.5, Low: 10, Close: 10.4, Volume: 2000},
+		{Symbol: "sh600000", Timestamp: day(4), Open: 10, High: 10.2, Low: 9.9, Close: 10.1, Volume: 1000},
+	}}
+
+	ingester := NewDataIngester(IngestionConfig{EnableIncremental: true, HistoryDays: 30, CheckInterval: time.Hour}, provider, storage)
+	if err := ingester.Start([]string{"sh600000"}); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	// 启动后立即摄取，不足一个批次也写入存储
+	deadline := time.Now().Add(2 * time.Second)
+	var klines []market.KLine
+	for time.Now().Before(deadline) {
+		var err error
+		klines, err = storage.LoadKlines(ctx, "sh600000", day(1), day(31))
+		if err != nil {
+			t.Fatalf("LoadKlines: %v", err)
+		}
+		if len(klines) == 2 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	ingester.Stop()
+
+	if len(klines) != 2 || klines[0].Close != 10.1 || klines[1].Volume != 2000 {
+		t.Fatalf("stored klines = %+v, want the two fetched bars", klines)
+	}
+	if progress, err := ingester.GetProgress("sh600000"); err != nil || progress != day(5).Unix() {
+		t.Errorf("progress = %d, %v; want the latest bar", progress, err)
+	}
+	if len(provider.days) == 0 || provider.days[0] != 30 {
+		t.Errorf("requested days = %v, want 30 on first ingestion", provider.days)
+	}
+
+	// 增量摄取跳过已入库的K线
+	points, err := ingester.fetchData(ctx, "sh600000", day(4).Unix())
+	if err != nil || len(points) != 1 || points[0].Timestamp != day(5).Unix() {
+		t.Errorf("incremental points = %+v, %v; want only the 5th", points, err)
+	}
+}
@@ -0,0 +1,153 @@
+package pipeline
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// 入库时检查出的数据质量问题类型
+const (
+	IssueInvalidPrice    = "invalid_price"      // 价格为0或负数
+	IssueHighBelowLow    = "high_below_low"     // 最高价低于最低价
+	IssueCloseOutOfRange = "close_out_of_range" // 收盘价不在[最低价, 最高价]内
+	IssueNegativeVolume  = "negative_volume"    // 成交量为负
+	IssueTimestampGap    = "timestamp_gap"      // 与上一根K线间隔超过MaxGap
+)
+
+// checkBar 检查单根K线的价格和成交量，返回发现的问题，Timestamp为K线时间
+func checkBar(point *DataPoint) []QualityIssue {
+	var issues []QualityIssue
+	add := func(issueType, severity, format string, args ...interface{}) {
+		issues = append(issues, QualityIssue{
+			Type:      issueType,
+			Severity:  severity,
+			Message:   fmt.Sprintf(format, args...),
+			Timestamp: time.Unix(point.Timestamp, 0),
+			Symbol:    point.Symbol,
+		})
+	}
+
+	for _, price := range []struct {
+		name  string
+		value float64
+	}{{"open", point.Open}, {"high", point.High}, {"low", point.Low}, {"close", point.Close}} {
+		if price.value <= 0 {
+			add(IssueInvalidPrice, "high", "%s price %.4f is not positive", price.name, price.value)
+		}
+	}
+	if point.High < point.Low {
+		add(IssueHighBelowLow, "high", "high price %.4f less than low price %.4f", point.High, point.Low)
+	} else if point.Close < point.Low || point.Close > point.High {
+		add(IssueCloseOutOfRange, "medium", "close price %.4f outside range [%.4f, %.4f]", point.Close, point.Low, point.High)
+	}
+	if point.Volume < 0 {
+		add(IssueNegativeVolume, "high", "volume %.2f is negative", point.Volume)
+	}
+	return issues
+}
+
+// checkGaps 按时间顺序检查同一批次内各标的相邻K线的间隔，previous为各标的入库前的上一根K线时间戳
+func checkGaps(points []*DataPoint, previous map[string]int64, maxGap time.Duration) []QualityIssue {
+	sorted := append([]*DataPoint(nil), points...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+
+	last := make(map[string]int64, len(previous))
+	for symbol, ts := range previous {
+		last[symbol] = ts
+	}
+
+	var issues []QualityIssue
+	for _, point := range sorted {
+		prev, ok := last[point.Symbol]
+		if ok && prev > 0 && point.Timestamp > prev {
+			if gap := time.Duration(point.Timestamp-prev) * time.Second; gap > maxGap {
+				issues = append(issues, QualityIssue{
+					Type:      IssueTimestampGap,
+					Severity:  "low",
+					Message:   fmt.Sprintf("%s since previous bar at %s exceeds %s", gap, time.Unix(prev, 0).Format(time.RFC3339), maxGap),
+					Timestamp: time.Unix(point.Timestamp, 0),
+					Symbol:    point.Symbol,
+				})
+			}
+		}
+		if point.Timestamp > prev {
+			last[point.Symbol] = point.Timestamp
+		}
+	}
+	return issues
+}
+
+// previousTimestamps 查询各标的早于本批次最早K线的最后一个已入库时间戳
+func previousTimestamps(ctx context.Context, tx *sql.Tx, points []*DataPoint) (map[string]int64, error) {
+	earliest := make(map[string]int64)
+	for _, point := range points {
+		if ts, ok := earliest[point.Symbol]; !ok || point.Timestamp < ts {
+			earliest[point.Symbol] = point.Timestamp
+		}
+	}
+
+	previous := make(map[string]int64, len(earliest))
+	for symbol, ts := range earliest {
+		var prev sql.NullInt64
+		if err := tx.QueryRowContext(ctx, `SELECT MAX(timestamp) FROM market_data WHERE symbol = ? AND timestamp < ?`, symbol, ts).Scan(&prev); err != nil {
+			return nil, err
+		}
+		if prev.Valid {
+			previous[symbol] = prev.Int64
+		}
+	}
+	return previous, nil
+}
+
+// GetQualityIssues 查询[start, end]区间内记录的数据质量问题，symbol为空时返回全部标的
+func (os *OptimizedStorage) GetQualityIssues(ctx context.Context, symbol string, start, end time.Time) ([]QualityIssue, error) {
+	query := `SELECT symbol, timestamp, issue_type, severity, COALESCE(message, '')
+        FROM data_quality
+        WHERE timestamp >= ? AND timestamp <= ?`
+	args := []interface{}{start.Unix(), end.Unix()}
+	if symbol != "" {
+		query += ` AND symbol = ?`
+		args = append(args, symbol)
+	}
+	query += ` ORDER BY timestamp, id`
+
+	rows, err := os.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	issues := make([]QualityIssue, 0)
+	for rows.Next() {
+		var issue QualityIssue
+		var timestamp int64
+		if err := rows.Scan(&issue.Symbol, &timestamp, &issue.Type, &issue.Severity, &issue.Message); err != nil {
+			return nil, err
+		}
+		issue.Timestamp = time.Unix(timestamp, 0)
+		issues = append(issues, issue)
+	}
+	return issues, rows.Err()
+}
+
+// QualityStats 区间内入库的K线数及其中记录了数据质量问题的K线数
+type QualityStats struct {
+	Bars        int `json:"bars"`
+	FlaggedBars int `json:"flagged_bars"`
+}
+
+// GetQualityStats 统计[start, end]区间内入库的K线数和存在数据质量问题的K线数，同一根K线的多个问题只计一次
+func (os *OptimizedStorage) GetQualityStats(ctx context.Context, start, end time.Time) (QualityStats, error) {
+	var stats QualityStats
+	if err := os.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM market_data WHERE timestamp >= ? AND timestamp <= ?`,
+		start.Unix(), end.Unix()).Scan(&stats.Bars); err != nil {
+		return stats, err
+	}
+	err := os.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM (
+            SELECT DISTINCT symbol, timestamp FROM data_quality WHERE timestamp >= ? AND timestamp <= ?
+        )`, start.Unix(), end.Unix()).Scan(&stats.FlaggedBars)
+	return stats, err
+}
@@ -0,0 +1,224 @@
+package pipeline
+
+import (
+	"sort"
+	"time"
+)
+
+// FillMode 重采样时缺失K线的填充方式
+type FillMode string
+
+const (
+	FillForward     FillMode = "forward"     // 以上一根K线的收盘价填充，成交量为0
+	FillDrop        FillMode = "drop"        // 不填充，只输出有数据的K线
+	FillInterpolate FillMode = "interpolate" // 按时间在前后两根K线的收盘价之间线性插值，成交量为0
+)
+
+// dailyInterval 日线周期
+const dailyInterval = 24 * time.Hour
+
+// Resample 将数据点按interval对齐为等间隔序列，非交易日按周末判断，见ResampleWithCalendar
+func Resample(points []*DataPoint, interval time.Duration, fill FillMode) []*DataPoint {
+	return ResampleWithCalendar(points, interval, fill, isWeekday)
+}
+
+// ResampleWithCalendar 将数据点按interval对齐为等间隔序列，isTradingDay判断日期是否为交易日，
+// 可传入backtest.TradingCalendar的IsTradingDay以跳过节假日。
+//
+// 同一周期内的多个数据点合并为一根K线（首个开盘价、最高价、最低价、最后收盘价、成交量和成交额求和）。
+// interval不小于一天时按本地时区的交易日对齐为日线，时间轴跳过非交易日；日内周期只在同一天已有数据的
+// 时间范围内补齐，不跨越隔夜。多个标的共用同一时间轴，标的首根K线之前的缺口不会被填充，插值模式下
+// 最后一根K线之后的缺口也不填充。填充的K线在Extra中标记filled=true。结果按标的首次出现的顺序分组，
+// 组内按时间升序。
+func ResampleWithCalendar(points []*DataPoint, interval time.Duration, fill FillMode, isTradingDay func(time.Time) bool) []*DataPoint {
+	if len(points) == 0 || interval <= 0 {
+		return points
+	}
+	if interval > dailyInterval {
+		interval = dailyInterval
+	}
+
+	// 按标的分组并合并同一周期内的数据点
+	var symbols []string
+	bars := make(map[string]map[int64]*DataPoint)
+	sorted := append([]*DataPoint(nil), points...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Timestamp < sorted[j].Timestamp })
+	for _, point := range sorted {
+		bucket := bucketStart(point.Timestamp, interval)
+		symbolBars, ok := bars[point.Symbol]
+		if !ok {
+			symbolBars = make(map[int64]*DataPoint)
+			bars[point.Symbol] = symbolBars
+			symbols = append(symbols, point.Symbol)
+		}
+		if bar, ok := symbolBars[bucket]; ok {
+			mergeInto(bar, point)
+		} else {
+			symbolBars[bucket] = newBar(point, bucket)
+		}
+	}
+
+	grid := buildGrid(bars, interval, isTradingDay)
+
+	var result []*DataPoint
+	for _, symbol := range symbols {
+		result = append(result, fillSeries(bars[symbol], grid, fill)...)
+	}
+	return result
+}
+
+// bucketStart 数据点所属周期的起始时间戳：日线为当天零点，日内周期从当天零点起按interval对齐
+func bucketStart(timestamp int64, interval time.Duration) int64 {
+	t := time.Unix(timestamp, 0)
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if interval >= dailyInterval {
+		return midnight.Unix()
+	}
+	return midnight.Add(t.Sub(midnight) / interval * interval).Unix()
+}
+
+// buildGrid 生成所有标的共用的时间轴：日线为首尾之间的交易日（以及有数据的非交易日），
+// 日内周期为每个有数据的日期中首尾K线之间的全部周期
+func buildGrid(bars map[string]map[int64]*DataPoint, interval time.Duration, isTradingDay func(time.Time) bool) []int64 {
+	observed := make(map[int64]bool)
+	for _, symbolBars := range bars {
+		for bucket := range symbolBars {
+			observed[bucket] = true
+		}
+	}
+
+	var grid []int64
+	if interval >= dailyInterval {
+		var first, last time.Time
+		for bucket := range observed {
+			t := time.Unix(bucket, 0)
+			if first.IsZero() || t.Before(first) {
+				first = t
+			}
+			if t.After(last) {
+				last = t
+			}
+		}
+		for d := first; !d.After(last); d = d.AddDate(0, 0, 1) {
+			if observed[d.Unix()] || isTradingDay(d) {
+				grid = append(grid, d.Unix())
+			}
+		}
+		return grid
+	}
+
+	// 日内周期：按日期记录首尾周期
+	type span struct{ first, last int64 }
+	spans := make(map[int64]*span)
+	for bucket := range observed {
+		date := bucketStart(bucket, dailyInterval)
+		if s, ok := spans[date]; ok {
+			if bucket < s.first {
+				s.first = bucket
+			}
+			if bucket > s.last {
+				s.last = bucket
+			}
+		} else {
+			spans[date] = &span{first: bucket, last: bucket}
+		}
+	}
+	for date, s := range spans {
+		if !isTradingDay(time.Unix(date, 0)) {
+			// 非交易日只保留有数据的周期
+			for bucket := range observed {
+				if bucketStart(bucket, dailyInterval) == date {
+					grid = append(grid, bucket)
+				}
+			}
+			continue
+		}
+		for bucket := s.first; bucket <= s.last; bucket += int64(interval / time.Second) {
+			grid = append(grid, bucket)
+		}
+	}
+	sort.Slice(grid, func(i, j int) bool { return grid[i] < grid[j] })
+	return grid
+}
+
+// fillSeries 按时间轴输出单个标的的K线，缺失的周期按fill填充
+func fillSeries(bars map[int64]*DataPoint, grid []int64, fill FillMode) []*DataPoint {
+	// 每个位置之后最近的有数据K线，用于插值
+	next := make([]*DataPoint, len(grid))
+	var upcoming *DataPoint
+	for i := len(grid) - 1; i >= 0; i-- {
+		if bar, ok := bars[grid[i]]; ok {
+			upcoming = bar
+		}
+		next[i] = upcoming
+	}
+
+	var series []*DataPoint
+	var prev *DataPoint
+	for i, bucket := range grid {
+		if bar, ok := bars[bucket]; ok {
+			series = append(series, bar)
+			prev = bar
+			continue
+		}
+		if prev == nil {
+			continue
+		}
+
+		switch fill {
+		case FillForward:
+			series = append(series, filledBar(prev, bucket, prev.Close))
+		case FillInterpolate:
+			if after := next[i]; after != nil {
+				ratio := float64(bucket-prev.Timestamp) / float64(after.Timestamp-prev.Timestamp)
+				series = append(series, filledBar(prev, bucket, prev.Close+(after.Close-prev.Close)*ratio))
+			}
+		}
+	}
+	return series
+}
+
+// newBar 以数据点创建周期起始时间为bucket的K线
+func newBar(point *DataPoint, bucket int64) *DataPoint {
+	bar := *point
+	bar.Timestamp = bucket
+	bar.Extra = make(map[string]interface{}, len(point.Extra))
+	for key, value := range point.Extra {
+		bar.Extra[key] = value
+	}
+	return &bar
+}
+
+// mergeInto 将同一周期内更晚的数据点合并到K线
+func mergeInto(bar, point *DataPoint) {
+	if point.High > bar.High {
+		bar.High = point.High
+	}
+	if point.Low < bar.Low {
+		bar.Low = point.Low
+	}
+	bar.Close = point.Close
+	bar.Volume += point.Volume
+	bar.Amount += point.Amount
+	for key, value := range point.Extra {
+		bar.Extra[key] = value
+	}
+}
+
+// filledBar 填充的K线：开高低收均为price，没有成交
+func filledBar(prev *DataPoint, bucket int64, price float64) *DataPoint {
+	return &DataPoint{
+		Symbol:    prev.Symbol,
+		Timestamp: bucket,
+		Open:      price,
+		High:      price,
+		Low:       price,
+		Close:     price,
+		Extra:     map[string]interface{}{"filled": true},
+	}
+}
+
+// isWeekday 默认交易日判断：周一至周五
+func isWeekday(t time.Time) bool {
+	return t.Weekday() != time.Saturday && t.Weekday() != time.Sunday
+}
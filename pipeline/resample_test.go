@@ -0,0 +1,135 @@
+package pipeline
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// 2024年3月1日为周五
+func resampleDay(d int, hour int) int64 {
+	return time.Date(2024, 3, d, hour, 0, 0, 0, time.Local).Unix()
+}
+
+func closeBar(symbol string, ts int64, close float64) *DataPoint {
+	return &DataPoint{Symbol: symbol, Timestamp: ts, Open: close, High: close, Low: close, Close: close, Volume: 100}
+}
+
+// seriesOf 按标的取出重采样结果中的日期和收盘价
+func seriesOf(points []*DataPoint, symbol string) (days []int, closes []float64) {
+	for _, point := range points {
+		if point.Symbol == symbol {
+			days = append(days, time.Unix(point.Timestamp, 0).Day())
+			closes = append(closes, point.Close)
+		}
+	}
+	return days, closes
+}
+
+func equalInts(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestResampleDailyFillModes(t *testing.T) {
+	// sh600000：3月1日、3月6日；sh600519从3月5日开始，首部缺口不应填充
+	points := []*DataPoint{
+		closeBar("sh600000", resampleDay(6, 15), 13),
+		closeBar("sh600000", resampleDay(1, 15), 10),
+		closeBar("sh600519", resampleDay(5, 15), 1700),
+	}
+
+	tests := []struct {
+		fill       FillMode
+		wantDays   []int
+		wantCloses []float64
+		otherDays  []int
+	}{
+		{FillDrop, []int{1, 6}, []float64{10, 13}, []int{5}},
+		{FillForward, []int{1, 4, 5, 6}, []float64{10, 10, 10, 13}, []int{5, 6}},
+		{FillInterpolate, []int{1, 4, 5, 6}, []float64{10, 11.8, 12.4, 13}, []int{5}},
+	}
+	for _, tc := range tests {
+		result := Resample(points, 24*time.Hour, tc.fill)
+
+		days, closes := seriesOf(result, "sh600000")
+		if !equalInts(days, tc.wantDays) {
+			t.Errorf("%s: sh600000 days = %v, want %v (weekend skipped)", tc.fill, days, tc.wantDays)
+			continue
+		}
+		for i := range closes {
+			if math.Abs(closes[i]-tc.wantCloses[i]) > 1e-9 {
+				t.Errorf("%s: close on day %d = %v, want %v", tc.fill, days[i], closes[i], tc.wantCloses[i])
+			}
+		}
+		if days, _ := seriesOf(result, "sh600519"); !equalInts(days, tc.otherDays) {
+			t.Errorf("%s: sh600519 days = %v, want %v", tc.fill, days, tc.otherDays)
+		}
+		if result[0].Symbol != "sh600000" {
+			t.Errorf("%s: results should be grouped by first appearance of each symbol", tc.fill)
+		}
+
+		for _, point := range result {
+			_, filled := point.Extra["filled"]
+			observed := point.Volume > 0
+			if filled == observed {
+				t.Errorf("%s: %s day %d filled=%v volume=%v", tc.fill, point.Symbol, time.Unix(point.Timestamp, 0).Day(), filled, point.Volume)
+			}
+		}
+	}
+}
+
+func TestResampleMergesBarsAndRespectsHolidays(t *testing.T) {
+	points := []*DataPoint{
+		{Symbol: "sh600000", Timestamp: resampleDay(1, 10), Open: 10, High: 10.5, Low: 9.8, Close: 10.2, Volume: 100, Amount: 1000},
+		{Symbol: "sh600000", Timestamp: resampleDay(1, 14), Open: 10.2, High: 10.8, Low: 10.1, Close: 10.6, Volume: 200, Amount: 2100},
+		closeBar("sh600000", resampleDay(6, 15), 11),
+	}
+	holidays := func(t time.Time) bool { return isWeekday(t) && t.Day() != 4 }
+
+	result := ResampleWithCalendar(points, 24*time.Hour, FillForward, holidays)
+	days, _ := seriesOf(result, "sh600000")
+	if !equalInts(days, []int{1, 5, 6}) {
+		t.Fatalf("days = %v, want 3月4日 skipped as a holiday", days)
+	}
+
+	merged := result[0]
+	if merged.Timestamp != resampleDay(1, 0) || merged.Open != 10 || merged.High != 10.8 || merged.Low != 9.8 ||
+		merged.Close != 10.6 || merged.Volume != 300 || merged.Amount != 3100 {
+		t.Errorf("merged daily bar = %+v", merged)
+	}
+	if points[0].Timestamp != resampleDay(1, 10) || points[0].Close != 10.2 {
+		t.Error("Resample must not modify its input")
+	}
+}
+
+func TestResampleIntradayDoesNotFillOvernight(t *testing.T) {
+	points := []*DataPoint{
+		closeBar("sh600000", resampleDay(1, 10), 10),
+		closeBar("sh600000", resampleDay(1, 13)+30*60, 13),
+		closeBar("sh600000", resampleDay(4, 10), 12),
+	}
+
+	result := Resample(points, time.Hour, FillInterpolate)
+	var hours []int
+	for _, point := range result {
+		hours = append(hours, time.Unix(point.Timestamp, 0).Hour())
+	}
+	if !equalInts(hours, []int{10, 11, 12, 13, 10}) {
+		t.Fatalf("hours = %v, want gaps filled within 3月1日 only", hours)
+	}
+	if math.Abs(result[1].Close-11) > 1e-9 || math.Abs(result[2].Close-12) > 1e-9 {
+		t.Errorf("interpolated closes = %v, %v; want 11 and 12", result[1].Close, result[2].Close)
+	}
+
+	if got := Resample(points, 0, FillForward); len(got) != len(points) {
+		t.Errorf("non-positive interval should return input unchanged, got %d points", len(got))
+	}
+}
@@ -1,15 +1,20 @@
 package pipeline
 
 import (
+	"compress/gzip"
 	"context"
 	"database/sql"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log"
+	"os"
 	"path/filepath"
 	"sync"
 	"time"
 
+	"cloudquant/market"
+
 	_ "github.com/mattn/go-sqlite3"
 )
 
@@ -21,7 +26,9 @@ type StorageConfig struct {
 	EnableArchiving   bool          `json:"enable_archiving"`
 	ArchiveInterval   time.Duration `json:"archive_interval"`
 	ArchiveAfterDays  int           `json:"archive_after_days"`
+	ArchiveDir        string        `json:"archive_dir"` // 归档文件目录，为空时使用数据库所在目录下的archive
 	BatchSize         int           `json:"batch_size"`
+	MaxGap            time.Duration `json:"max_gap"` // 相邻K线最大间隔，超过时记录数据质量问题，0表示不检查
 }
 
 // OptimizedStorage 优化的存储
@@ -33,16 +40,26 @@ type OptimizedStorage struct {
 	preparedStmts map[string]*sql.Stmt
 	stmtLock      sync.RWMutex
 
-	archiveChan chan *DataPoint
+	archiveStop chan struct{}
 	archiveWg   sync.WaitGroup
 }
 
 // NewOptimizedStorage 创建优化的存储
 func NewOptimizedStorage(config StorageConfig) (*OptimizedStorage, error) {
+	if config.ArchiveInterval <= 0 {
+		config.ArchiveInterval = 24 * time.Hour
+	}
+	if config.ArchiveAfterDays <= 0 {
+		config.ArchiveAfterDays = 90
+	}
+	if config.ArchiveDir == "" {
+		config.ArchiveDir = filepath.Join(filepath.Dir(config.DBPath), "archive")
+	}
+
 	storage := &OptimizedStorage{
 		config:        config,
 		preparedStmts: make(map[string]*sql.Stmt),
-		archiveChan:   make(chan *DataPoint, 10000),
+		archiveStop:   make(chan struct{}),
 	}
 
 	if err := storage.initDB(); err != nil {
@@ -167,7 +184,8 @@ func (os *OptimizedStorage) createIndexes() error {
 	return nil
 }
 
-// SaveBatch 批量保存数据
+// SaveBatch 批量保存数据，同时检查每根K线的数据质量并在同一事务中写入data_quality；
+// 有问题的K线仍会保存，以便保留原始记录供排查
 func (os *OptimizedStorage) SaveBatch(ctx context.Context, points []*DataPoint) error {
 	if len(points) == 0 {
 		return nil
@@ -190,6 +208,19 @@ func (os *OptimizedStorage) SaveBatch(ctx context.Context, points []*DataPoint)
 		_ = tx.Rollback()
 	}()
 
+	// 检查数据质量，间隔需要与已入库的上一根K线比较，须在插入前查询
+	var issues []QualityIssue
+	for _, point := range points {
+		issues = append(issues, checkBar(point)...)
+	}
+	if os.config.MaxGap > 0 {
+		previous, err := previousTimestamps(ctx, tx, points)
+		if err != nil {
+			return fmt.Errorf("query previous timestamps failed: %w", err)
+		}
+		issues = append(issues, checkGaps(points, previous, os.config.MaxGap)...)
+	}
+
 	// 批量插入
 	for _, point := range points {
 		var extraJSON string
@@ -215,11 +246,22 @@ func (os *OptimizedStorage) SaveBatch(ctx context.Context, points []*DataPoint)
 		}
 	}
 
+	for _, issue := range issues {
+		if _, err := tx.ExecContext(ctx, `INSERT INTO data_quality (symbol, timestamp, issue_type, severity, message)
+            VALUES (?, ?, ?, ?, ?)`,
+			issue.Symbol, issue.Timestamp.Unix(), issue.Type, issue.Severity, issue.Message); err != nil {
+			return fmt.Errorf("insert quality issue failed: %w", err)
+		}
+	}
+
 	// 提交事务
 	if err := tx.Commit(); err != nil {
 		return err
 	}
 
+	if len(issues) > 0 {
+		log.Printf("Recorded %d data quality issues in batch of %d points", len(issues), len(points))
+	}
 	return nil
 }
 
@@ -254,6 +296,11 @@ func (os *OptimizedStorage) GetRange(ctx context.Context, symbol string, start,
 	if err != nil {
 		return nil, err
 	}
+	return scanDataPoints(rows)
+}
+
+// scanDataPoints 读取market_data查询结果并关闭rows，无法解析的extra记录日志后忽略
+func scanDataPoints(rows *sql.Rows) ([]*DataPoint, error) {
 	defer rows.Close()
 
 	var points []*DataPoint
@@ -279,13 +326,65 @@ func (os *OptimizedStorage) GetRange(ctx context.Context, symbol string, start,
 		}
 
 		if extraJSON.Valid && extraJSON.String != "" {
-			_ = json.Unmarshal([]byte(extraJSON.String), &point.Extra)
+			if err := json.Unmarshal([]byte(extraJSON.String), &point.Extra); err != nil {
+				log.Printf("Ignoring malformed extra data for %s at %d: %v", point.Symbol, point.Timestamp, err)
+				point.Extra = make(map[string]interface{})
+			}
 		}
 
 		points = append(points, point)
 	}
 
-	return points, nil
+	return points, rows.Err()
+}
+
+// LoadKlines 读取[start, end]区间内缓存的行情并转换为K线，供回测和模型训练使用本地历史数据而不必请求网络
+func (os *OptimizedStorage) LoadKlines(ctx context.Context, symbol string, start, end time.Time) ([]market.KLine, error) {
+	if end.Before(start) {
+		return nil, fmt.Errorf("invalid range: end %s before start %s", end.Format(time.RFC3339), start.Format(time.RFC3339))
+	}
+
+	points, err := os.GetRange(ctx, symbol, start.Unix(), end.Unix(), 0)
+	if err != nil {
+		return nil, fmt.Errorf("load %s klines failed: %w", symbol, err)
+	}
+
+	klines := make([]market.KLine, 0, len(points))
+	for _, point := range points {
+		klines = append(klines, point.ToKLine())
+	}
+	return klines, nil
+}
+
+// ToKLine 转换为K线，extra中的ma5、ma20、rsi、macd还原为技术指标
+func (dp *DataPoint) ToKLine() market.KLine {
+	timestamp := time.Unix(dp.Timestamp, 0)
+	kline := market.KLine{
+		Symbol:    dp.Symbol,
+		Open:      dp.Open,
+		High:      dp.High,
+		Low:       dp.Low,
+		Close:     dp.Close,
+		Volume:    int64(dp.Volume),
+		Timestamp: timestamp,
+	}
+
+	var found bool
+	for key, target := range map[string]*float64{
+		"ma5":  &kline.Indicators.MA5,
+		"ma20": &kline.Indicators.MA20,
+		"rsi":  &kline.Indicators.RSI,
+		"macd": &kline.Indicators.MACD,
+	} {
+		if value, ok := dp.Extra[key].(float64); ok {
+			*target = value
+			found = true
+		}
+	}
+	if found {
+		kline.Indicators.Timestamp = timestamp
+	}
+	return kline
 }
 
 // SaveQualityIssue 保存质量问题
@@ -331,19 +430,17 @@ func (os *OptimizedStorage) getPreparedStmt(query string) (*sql.Stmt, error) {
 func (os *OptimizedStorage) runArchive() {
 	defer os.archiveWg.Done()
 
-	if os.config.ArchiveInterval == 0 {
-		os.config.ArchiveInterval = 24 * time.Hour
-	}
-	if os.config.ArchiveAfterDays == 0 {
-		os.config.ArchiveAfterDays = 90
-	}
-
 	ticker := time.NewTicker(os.config.ArchiveInterval)
 	defer ticker.Stop()
 
-	for range ticker.C {
-		if err := os.archiveOldData(); err != nil {
-			log.Printf("Archive failed: %v", err)
+	for {
+		select {
+		case <-os.archiveStop:
+			return
+		case <-ticker.C:
+			if err := os.archiveOldData(); err != nil {
+				log.Printf("Archive failed: %v", err)
+			}
 		}
 	}
 }
@@ -368,6 +465,7 @@ func (os *OptimizedStorage) archiveOldData() error {
 		}
 		symbols = append(symbols, symbol)
 	}
+	rows.Close()
 
 	// 归档每个标的
 	for _, symbol := range symbols {
@@ -379,34 +477,72 @@ func (os *OptimizedStorage) archiveOldData() error {
 	return nil
 }
 
-// archiveSymbol 归档单个标的
+// archiveSymbol 将单个标的早于cutoff的数据写入归档文件，并在同一事务中记录归档元数据、删除原始数据；
+// 事务失败时删除已写入的归档文件，数据仍保留在数据库中
 func (os *OptimizedStorage) archiveSymbol(symbol string, cutoff int64) error {
-	// 获取数据
 	ctx := context.Background()
-	points, err := os.GetRange(ctx, symbol, 0, cutoff, 0)
+	tx, err := os.db.BeginTx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		_ = tx.Rollback()
+	}()
 
+	rows, err := tx.QueryContext(ctx, `SELECT symbol, timestamp, open, high, low, close, volume, amount, extra
+        FROM market_data
+        WHERE symbol = ? AND timestamp < ?
+        ORDER BY timestamp`, symbol, cutoff)
+	if err != nil {
+		return err
+	}
+	points, err := scanDataPoints(rows)
+	if err != nil {
+		return err
+	}
 	if len(points) == 0 {
 		return nil
 	}
 
-	// TODO: 实际归档到文件
-	log.Printf("Archived %d points for %s", len(points), symbol)
+	start, end := points[0].Timestamp, points[len(points)-1].Timestamp
+	path := filepath.Join(os.config.ArchiveDir, fmt.Sprintf("%s_%d_%d.jsonl", symbol, start, end))
+	if os.config.EnableCompression {
+		path += ".gz"
+	}
+	if err := writeArchiveFile(path, points, os.config.EnableCompression); err != nil {
+		return fmt.Errorf("write archive file failed: %w", err)
+	}
 
-	// 删除已归档的数据
-	deleteQuery := `DELETE FROM market_data WHERE symbol = ? AND timestamp < ?`
-	_, err = os.db.Exec(deleteQuery, symbol, cutoff)
+	committed := false
+	defer func() {
+		if !committed {
+			removeFile(path)
+		}
+	}()
 
-	return err
+	if _, err := tx.ExecContext(ctx, `INSERT INTO archive_metadata
+        (symbol, start_date, end_date, record_count, file_path, compressed)
+        VALUES (?, ?, ?, ?, ?, ?)`,
+		symbol, start, end, len(points), path, os.config.EnableCompression); err != nil {
+		return fmt.Errorf("record archive metadata failed: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM market_data WHERE symbol = ? AND timestamp <= ?`, symbol, end); err != nil {
+		return fmt.Errorf("delete archived rows failed: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	committed = true
+
+	log.Printf("Archived %d points for %s to %s", len(points), symbol, path)
+	return nil
 }
 
 // Close 关闭存储
 func (os *OptimizedStorage) Close() error {
 	// 关闭归档
 	if os.config.EnableArchiving {
-		close(os.archiveChan)
+		close(os.archiveStop)
 		os.archiveWg.Wait()
 	}
 
@@ -465,5 +601,55 @@ func (os *OptimizedStorage) GetStorageStats() (map[string]interface{}, error) {
 
 // ensureDir 确保目录存在
 func ensureDir(dir string) error {
-	return nil
+	return os.MkdirAll(dir, 0o755)
+}
+
+// writeArchiveFile 将数据点逐行写为JSON，compress为true时使用gzip压缩；先写临时文件再改名，避免留下不完整的归档
+func writeArchiveFile(path string, points []*DataPoint, compress bool) error {
+	if err := ensureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+
+	tmp := path + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0o600)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	var w io.Writer = file
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(file)
+		w = gz
+	}
+
+	encoder := json.NewEncoder(w)
+	for _, point := range points {
+		if err := encoder.Encode(point); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			file.Close()
+			return err
+		}
+	}
+	if err := file.Sync(); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// removeFile 删除文件，失败时只记录日志
+func removeFile(path string) {
+	if err := os.Remove(path); err != nil {
+		log.Printf("Failed to remove archive file %s: %v", path, err)
+	}
 }
@@ -0,0 +1,264 @@
+package pipeline
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestStorage(t *testing.T) *OptimizedStorage {
+	t.Helper()
+	return newTestStorageWithConfig(t, StorageConfig{})
+}
+
+func newTestStorageWithConfig(t *testing.T, config StorageConfig) *OptimizedStorage {
+	t.Helper()
+	config.DBPath = filepath.Join(t.TempDir(), "market.db")
+	storage, err := NewOptimizedStorage(config)
+	if err != nil {
+		t.Fatalf("NewOptimizedStorage: %v", err)
+	}
+	t.Cleanup(func() { storage.Close() })
+	return storage
+}
+
+func TestLoadKlines(t *testing.T) {
+	storage := newTestStorage(t)
+	ctx := context.Background()
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 15, 0, 0, 0, time.Local) }
+	points := []*DataPoint{
+		{Symbol: "sh600000", Timestamp: day(5).Unix(), Open: 10.1, High: 10.5, Low: 10, Close: 10.4, Volume: 2000,
+			Extra: map[string]interface{}{"ma5": 10.2, "rsi": 55.0, "source": "sina"}},
+		{Symbol: "sh600000", Timestamp: day(4).Unix(), Open: 10, High: 10.2, Low: 9.9, Close: 10.1, Volume: 1000},
+		{Symbol: "sh600000", Timestamp: day(8).Unix(), Open: 10.4, High: 10.6, Low: 10.3, Close: 10.5, Volume: 3000},
+		{Symbol: "sh600519", Timestamp: day(5).Unix(), Open: 1700, High: 1710, Low: 1690, Close: 1705, Volume: 500},
+	}
+	if err := storage.SaveBatch(ctx, points); err != nil {
+		t.Fatalf("SaveBatch: %v", err)
+	}
+	// 损坏的extra不应导致整段读取失败
+	if _, err := storage.db.Exec(`INSERT INTO market_data (symbol, timestamp, open, high, low, close, volume, amount, extra)
+        VALUES ('sh600000', ?, 10.5, 10.7, 10.4, 10.6, 4000, 0, '{not json')`, day(6).Unix()); err != nil {
+		t.Fatalf("insert malformed row: %v", err)
+	}
+
+	klines, err := storage.LoadKlines(ctx, "sh600000", day(4), day(6))
+	if err != nil {
+		t.Fatalf("LoadKlines: %v", err)
+	}
+	if len(klines) != 3 {
+		t.Fatalf("got %d klines, want 3 within range", len(klines))
+	}
+	for i, want := range []time.Time{day(4), day(5), day(6)} {
+		if !klines[i].Timestamp.Equal(want) || klines[i].Symbol != "sh600000" {
+			t.Errorf("kline %d = %s %s, want sh600000 %s", i, klines[i].Symbol, klines[i].Timestamp, want)
+		}
+	}
+
+	withExtra := klines[1]
+	if withExtra.Close != 10.4 || withExtra.Volume != 2000 {
+		t.Errorf("kline values = %+v", withExtra)
+	}
+	if withExtra.Indicators.MA5 != 10.2 || withExtra.Indicators.RSI != 55 || !withExtra.Indicators.Timestamp.Equal(day(5)) {
+		t.Errorf("indicators = %+v, want ma5 and rsi restored from extra", withExtra.Indicators)
+	}
+	if !klines[0].Indicators.Timestamp.IsZero() || klines[2].Indicators != (klines[0].Indicators) {
+		t.Errorf("rows without usable extra should have empty indicators: %+v %+v", klines[0].Indicators, klines[2].Indicators)
+	}
+
+	if _, err := storage.LoadKlines(ctx, "sh600000", day(6), day(4)); err == nil {
+		t.Error("reversed range should be rejected")
+	}
+	if klines, err := storage.LoadKlines(ctx, "sz000001", day(1), day(31)); err != nil || len(klines) != 0 {
+		t.Errorf("unknown symbol = %d klines, %v; want none", len(klines), err)
+	}
+}
+
+func TestArchiveOldDataMovesRowsToArchiveFiles(t *testing.T) {
+	storage := newTestStorageWithConfig(t, StorageConfig{ArchiveAfterDays: 30, EnableCompression: true})
+	ctx := context.Background()
+
+	now := time.Now()
+	var points []*DataPoint
+	for _, symbol := range []string{"sh600000", "sh600519"} {
+		for _, age := range []int{60, 45, 31, 10, 1} {
+			points = append(points, &DataPoint{
+				Symbol: symbol, Timestamp: now.AddDate(0, 0, -age).Unix(),
+				Open: 10, High: 11, Low: 9, Close: 10.5, Volume: float64(age),
+				Extra: map[string]interface{}{"age": float64(age)},
+			})
+		}
+	}
+	if err := storage.SaveBatch(ctx, points); err != nil {
+		t.Fatalf("SaveBatch: %v", err)
+	}
+
+	if err := storage.archiveOldData(); err != nil {
+		t.Fatalf("archiveOldData: %v", err)
+	}
+
+	var remaining int
+	if err := storage.db.QueryRow(`SELECT COUNT(*) FROM market_data`).Scan(&remaining); err != nil {
+		t.Fatalf("count market_data: %v", err)
+	}
+	if remaining != 4 {
+		t.Errorf("%d rows left in market_data, want the 4 recent rows", remaining)
+	}
+
+	rows, err := storage.db.Query(`SELECT symbol, start_date, end_date, record_count, file_path, compressed FROM archive_metadata ORDER BY symbol`)
+	if err != nil {
+		t.Fatalf("query archive_metadata: %v", err)
+	}
+	defer rows.Close()
+
+	var archives int
+	for rows.Next() {
+		var symbol, path string
+		var start, end int64
+		var count int
+		var compressed bool
+		if err := rows.Scan(&symbol, &start, &end, &count, &path, &compressed); err != nil {
+			t.Fatalf("scan archive_metadata: %v", err)
+		}
+		archives++
+		if count != 3 || !compressed || start != now.AddDate(0, 0, -60).Unix() || end != now.AddDate(0, 0, -31).Unix() {
+			t.Errorf("%s archive metadata: count=%d compressed=%v range=%d-%d", symbol, count, compressed, start, end)
+		}
+
+		archived := readArchive(t, path)
+		if len(archived) != 3 || archived[0].Symbol != symbol || archived[0].Extra["age"] != 60.0 || archived[2].Volume != 31 {
+			t.Errorf("%s archive file holds %+v", symbol, archived)
+		}
+	}
+	if archives != 2 {
+		t.Errorf("got %d archive_metadata rows, want 2", archives)
+	}
+
+	// 再次归档时没有需要移动的数据
+	if err := storage.archiveOldData(); err != nil {
+		t.Fatalf("second archiveOldData: %v", err)
+	}
+	if err := storage.db.QueryRow(`SELECT COUNT(*) FROM archive_metadata`).Scan(&archives); err != nil || archives != 2 {
+		t.Errorf("archive_metadata rows after second sweep = %d, %v; want 2", archives, err)
+	}
+}
+
+func TestCloseStopsArchiveLoop(t *testing.T) {
+	storage, err := NewOptimizedStorage(StorageConfig{
+		DBPath:          filepath.Join(t.TempDir(), "market.db"),
+		EnableArchiving: true,
+		ArchiveInterval: time.Hour,
+	})
+	if err != nil {
+		t.Fatalf("NewOptimizedStorage: %v", err)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- storage.Close() }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Close: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Close blocked on the archive goroutine")
+	}
+}
+
+func readArchive(t *testing.T, path string) []*DataPoint {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("open archive: %v", err)
+	}
+	defer file.Close()
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		t.Fatalf("gzip reader: %v", err)
+	}
+
+	var points []*DataPoint
+	scanner := bufio.NewScanner(gz)
+	for scanner.Scan() {
+		var point DataPoint
+		if err := json.Unmarshal(scanner.Bytes(), &point); err != nil {
+			t.Fatalf("decode archived point: %v", err)
+		}
+		points = append(points, &point)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("read archive: %v", err)
+	}
+	return points
+}
+
+func TestSaveBatchRecordsQualityIssues(t *testing.T) {
+	storage := newTestStorageWithConfig(t, StorageConfig{MaxGap: 96 * time.Hour})
+	ctx := context.Background()
+
+	day := func(d int) time.Time { return time.Date(2024, 3, d, 15, 0, 0, 0, time.Local) }
+	bar := func(symbol string, d int, open, high, low, close, volume float64) *DataPoint {
+		return &DataPoint{Symbol: symbol, Timestamp: day(d).Unix(), Open: open, High: high, Low: low, Close: close, Volume: volume}
+	}
+
+	if err := storage.SaveBatch(ctx, []*DataPoint{bar("sh600000", 1, 10, 10.5, 9.8, 10.2, 1000)}); err != nil {
+		t.Fatalf("SaveBatch: %v", err)
+	}
+	// 3月4日与上一批次入库的3月1日相隔3天，未超过阈值；3月11日距3月5日6天，记为缺口
+	err := storage.SaveBatch(ctx, []*DataPoint{
+		bar("sh600000", 11, 10, 10.5, 9.8, 10.2, 1000),
+		bar("sh600000", 4, 10, 10.5, 9.8, 10.2, 1000),
+		bar("sh600000", 5, 0, 10.5, 9.8, 10.2, -5),
+		bar("sh600519", 4, 1700, 1690, 1710, 1700, 100),
+		bar("sh600519", 5, 1700, 1710, 1690, 1720, 100),
+	})
+	if err != nil {
+		t.Fatalf("SaveBatch: %v", err)
+	}
+
+	var stored int
+	if err := storage.db.QueryRow(`SELECT COUNT(*) FROM market_data`).Scan(&stored); err != nil || stored != 6 {
+		t.Errorf("stored %d bars, %v; bars with issues should still be saved", stored, err)
+	}
+
+	issues, err := storage.GetQualityIssues(ctx, "", day(1), day(31))
+	if err != nil {
+		t.Fatalf("GetQualityIssues: %v", err)
+	}
+	got := make(map[string]string)
+	for _, issue := range issues {
+		got[issue.Symbol+" "+issue.Timestamp.Format("01-02")+" "+issue.Type] = issue.Severity
+	}
+	want := map[string]string{
+		"sh600000 03-05 " + IssueInvalidPrice:    "high",
+		"sh600000 03-05 " + IssueNegativeVolume:  "high",
+		"sh600000 03-11 " + IssueTimestampGap:    "low",
+		"sh600519 03-04 " + IssueHighBelowLow:    "high",
+		"sh600519 03-05 " + IssueCloseOutOfRange: "medium",
+	}
+	if len(got) != len(want) || len(issues) != len(want) {
+		t.Errorf("issues = %v, want %v", got, want)
+	}
+	for key, severity := range want {
+		if got[key] != severity {
+			t.Errorf("issue %q severity = %q, want %q", key, got[key], severity)
+		}
+	}
+
+	issues, err = storage.GetQualityIssues(ctx, "sh600519", day(5), day(5))
+	if err != nil || len(issues) != 1 || issues[0].Type != IssueCloseOutOfRange || issues[0].Message == "" {
+		t.Errorf("filtered issues = %+v, %v; want the sh600519 close_out_of_range issue", issues, err)
+	}
+
+	// sh600000 3月5日的两个问题只计一根K线
+	stats, err := storage.GetQualityStats(ctx, day(1), day(31))
+	if err != nil || stats.Bars != 6 || stats.FlaggedBars != 4 {
+		t.Errorf("quality stats = %+v, %v; want 6 bars with 4 flagged", stats, err)
+	}
+}
@@ -0,0 +1,328 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// BracketID 括号单编号
+type BracketID string
+
+// BracketState 括号单状态
+type BracketState string
+
+const (
+	BracketPending    BracketState = "pending"     // 入场单等待成交
+	BracketActive     BracketState = "active"      // 已持仓，止盈单挂出或等待可卖，止损监控中
+	BracketStopping   BracketState = "stopping"    // 止损已触发，止盈单已撤，止损卖出进行中
+	BracketTakeProfit BracketState = "take_profit" // 止盈成交，结束
+	BracketStopLoss   BracketState = "stop_loss"   // 止损成交，结束
+	BracketCancelled  BracketState = "cancelled"   // 入场单未成交即被撤销或拒绝，结束
+)
+
+// ErrBracketNotFound 括号单不存在
+var ErrBracketNotFound = fmt.Errorf("括号单不存在")
+
+// Done 是否为终止状态
+func (s BracketState) Done() bool {
+	return s == BracketTakeProfit || s == BracketStopLoss || s == BracketCancelled
+}
+
+// BracketStatus 括号单状态快照
+type BracketStatus struct {
+	ID                BracketID    `json:"id"`
+	Symbol            string       `json:"symbol"`
+	State             BracketState `json:"state"`
+	EntryPrice        float64      `json:"entry_price"`
+	TakeProfit        float64      `json:"take_profit"`
+	StopLoss          float64      `json:"stop_loss"`
+	Quantity          int          `json:"quantity"`           // 括号单管理的持仓数量，入场成交前为委托数量
+	TakeProfitFilled  int          `json:"take_profit_filled"` // 止盈单已成交数量
+	EntryOrderID      string       `json:"entry_order_id"`
+	TakeProfitOrderID string       `json:"take_profit_order_id,omitempty"`
+	StopLossOrderID   string       `json:"stop_loss_order_id,omitempty"`
+	Message           string       `json:"message,omitempty"`
+	CreatedAt         time.Time    `json:"created_at"`
+	UpdatedAt         time.Time    `json:"updated_at"`
+}
+
+// bracketBook 括号单登记簿
+type bracketBook struct {
+	mu       sync.Mutex
+	seq      int
+	brackets map[BracketID]*BracketStatus
+}
+
+func newBracketBook() *bracketBook {
+	return &bracketBook{brackets: make(map[BracketID]*BracketStatus)}
+}
+
+// ExecuteBracket 提交括号单：entry为限价买入开仓（Amount为股数，须为整手），
+// 入场成交后挂出takeProfit限价卖单，同时监控stopLoss。券商接口不支持条件单，
+// 止损在本地触发：现价跌至stopLoss时先撤止盈单，再按现价卖出剩余持仓。
+// 止盈与止损任一成交后另一侧即失效。状态由UpdateBrackets推进
+func (oe *OrderExecutor) ExecuteBracket(ctx context.Context, entry Order, takeProfit, stopLoss float64) (BracketID, error) {
+	if entry.Type != "" && entry.Type != OrderTypeBuy {
+		return "", fmt.Errorf("括号单仅支持买入开仓")
+	}
+	if entry.Symbol == "" || entry.Price <= 0 {
+		return "", fmt.Errorf("括号单缺少股票代码或委托价格")
+	}
+	if entry.Amount <= 0 || entry.Amount%100 != 0 {
+		return "", fmt.Errorf("括号单数量须为100股的整数倍: %d", entry.Amount)
+	}
+	if stopLoss <= 0 || !(stopLoss < entry.Price && entry.Price < takeProfit) {
+		return "", fmt.Errorf("止损价须低于委托价且止盈价须高于委托价: 止损 %.2f, 委托 %.2f, 止盈 %.2f",
+			stopLoss, entry.Price, takeProfit)
+	}
+
+	orderReq := OrderRequest{
+		Type:   OrderTypeBuy,
+		Symbol: entry.Symbol,
+		Price:  entry.Price,
+		Amount: int(math.Round(entry.Price * float64(entry.Amount))),
+	}
+	if err := oe.riskManager.CheckBeforeOrder(ctx, orderReq); err != nil {
+		return "", oe.orderFailed(fmt.Errorf("风险检查失败: %w", err))
+	}
+	if err := oe.CheckCooldown(entry.Symbol); err != nil {
+		return "", oe.orderFailed(err)
+	}
+
+	orderID, err := oe.placeBuy(ctx, entry.Symbol, entry.Price, entry.Amount)
+	if err != nil {
+		return "", err
+	}
+
+	book := oe.brackets
+	book.mu.Lock()
+	defer book.mu.Unlock()
+
+	book.seq++
+	id := BracketID(fmt.Sprintf("bracket_%d", book.seq))
+	now := time.Now()
+	book.brackets[id] = &BracketStatus{
+		ID:           id,
+		Symbol:       entry.Symbol,
+		State:        BracketPending,
+		EntryPrice:   entry.Price,
+		TakeProfit:   takeProfit,
+		StopLoss:     stopLoss,
+		Quantity:     entry.Amount,
+		EntryOrderID: orderID,
+		CreatedAt:    now,
+		UpdatedAt:    now,
+	}
+
+	log.Printf("括号单提交: %s %s, 委托 %.2f x %d, 止盈 %.2f, 止损 %.2f", id, entry.Symbol, entry.Price, entry.Amount, takeProfit, stopLoss)
+	return id, nil
+}
+
+// GetBracketStatus 获取括号单状态
+func (oe *OrderExecutor) GetBracketStatus(id BracketID) (*BracketStatus, error) {
+	book := oe.brackets
+	book.mu.Lock()
+	defer book.mu.Unlock()
+
+	bracket, ok := book.brackets[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrBracketNotFound, id)
+	}
+	status := *bracket
+	return &status, nil
+}
+
+// ListBrackets 获取全部括号单状态，按创建时间排序
+func (oe *OrderExecutor) ListBrackets() []BracketStatus {
+	book := oe.brackets
+	book.mu.Lock()
+	defer book.mu.Unlock()
+
+	statuses := make([]BracketStatus, 0, len(book.brackets))
+	for _, bracket := range book.brackets {
+		statuses = append(statuses, *bracket)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].CreatedAt.Before(statuses[j].CreatedAt) })
+	return statuses
+}
+
+// UpdateBrackets 按券商委托和持仓推进未结束的括号单，应在持仓同步后周期性调用。
+// 单个括号单的下单失败（如T+1尚不可卖）记入Message，下次调用时重试
+func (oe *OrderExecutor) UpdateBrackets(ctx context.Context) error {
+	book := oe.brackets
+	book.mu.Lock()
+	defer book.mu.Unlock()
+
+	open := false
+	for _, bracket := range book.brackets {
+		if !bracket.State.Done() {
+			open = true
+			break
+		}
+	}
+	if !open {
+		return nil
+	}
+
+	orders, err := oe.connector.GetBroker().GetOrders(ctx)
+	if err != nil {
+		return fmt.Errorf("获取委托失败: %w", err)
+	}
+	byID := make(map[string]Order, len(orders))
+	for _, order := range orders {
+		byID[order.OrderID] = order
+	}
+
+	for _, bracket := range book.brackets {
+		if bracket.State.Done() {
+			continue
+		}
+		before := bracket.State
+		oe.advanceBracket(ctx, bracket, byID)
+		if bracket.State != before {
+			bracket.UpdatedAt = time.Now()
+			log.Printf("括号单 %s %s: %s -> %s %s", bracket.ID, bracket.Symbol, before, bracket.State, bracket.Message)
+		}
+	}
+	return nil
+}
+
+// advanceBracket 推进单个括号单的状态
+func (oe *OrderExecutor) advanceBracket(ctx context.Context, bracket *BracketStatus, orders map[string]Order) {
+	switch bracket.State {
+	case BracketPending:
+		entry, ok := orders[bracket.EntryOrderID]
+		if !ok {
+			return
+		}
+		switch {
+		case orderFilled(entry):
+			if entry.FilledAmount > 0 {
+				bracket.Quantity = entry.FilledAmount
+			}
+			bracket.State = BracketActive
+		case orderDead(entry) && entry.FilledAmount > 0:
+			bracket.Quantity = entry.FilledAmount
+			bracket.State = BracketActive
+			bracket.Message = "入场单部分成交后撤销"
+		case orderDead(entry):
+			bracket.State = BracketCancelled
+			bracket.Message = "入场单未成交: " + entry.Status
+		}
+		if bracket.State == BracketActive {
+			// 入场成交后立即尝试挂出止盈单
+			oe.advanceBracket(ctx, bracket, orders)
+		}
+
+	case BracketActive:
+		if bracket.TakeProfitOrderID != "" {
+			tp, ok := orders[bracket.TakeProfitOrderID]
+			if ok {
+				bracket.TakeProfitFilled = tp.FilledAmount
+				switch {
+				case orderFilled(tp):
+					bracket.State = BracketTakeProfit
+					bracket.Message = ""
+					return
+				case orderDead(tp):
+					// 止盈单被外部撤销，剩余部分重新挂单
+					bracket.Quantity -= tp.FilledAmount
+					bracket.TakeProfitFilled = 0
+					bracket.TakeProfitOrderID = ""
+				}
+			}
+		}
+
+		pos, err := oe.positionMgr.GetPosition(bracket.Symbol)
+		if err != nil {
+			return
+		}
+
+		if pos.CurrentPrice > 0 && pos.CurrentPrice <= bracket.StopLoss {
+			if bracket.TakeProfitOrderID != "" {
+				if err := oe.ExecuteCancel(ctx, bracket.TakeProfitOrderID); err != nil {
+					bracket.Message = err.Error()
+					return
+				}
+			}
+			bracket.State = BracketStopping
+			bracket.Message = fmt.Sprintf("止损触发: 现价 %.2f", pos.CurrentPrice)
+			oe.placeBracketStop(ctx, bracket, pos.CurrentPrice)
+			return
+		}
+
+		if bracket.TakeProfitOrderID == "" && pos.Available >= bracket.Quantity {
+			orderID, err := oe.placeSell(ctx, bracket.Symbol, bracket.TakeProfit, bracket.Quantity)
+			if err != nil {
+				bracket.Message = err.Error()
+				return
+			}
+			bracket.TakeProfitOrderID = orderID
+			bracket.Message = ""
+		}
+
+	case BracketStopping:
+		if tp, ok := orders[bracket.TakeProfitOrderID]; ok {
+			// 撤单前止盈单可能又有成交
+			bracket.TakeProfitFilled = tp.FilledAmount
+		}
+		if bracket.StopLossOrderID != "" {
+			sl, ok := orders[bracket.StopLossOrderID]
+			if !ok {
+				return
+			}
+			switch {
+			case orderFilled(sl):
+				bracket.State = BracketStopLoss
+				bracket.Message = ""
+			case orderDead(sl):
+				// 止损单未成交即被撤销，下次按新现价重新卖出
+				bracket.StopLossOrderID = ""
+			}
+			return
+		}
+
+		pos, err := oe.positionMgr.GetPosition(bracket.Symbol)
+		if err != nil {
+			return
+		}
+		oe.placeBracketStop(ctx, bracket, pos.CurrentPrice)
+	}
+}
+
+// placeBracketStop 按现价卖出止盈单未成交的剩余数量
+func (oe *OrderExecutor) placeBracketStop(ctx context.Context, bracket *BracketStatus, price float64) {
+	remaining := bracket.Quantity - bracket.TakeProfitFilled
+	if remaining <= 0 {
+		bracket.State = BracketTakeProfit
+		return
+	}
+	orderID, err := oe.placeSell(ctx, bracket.Symbol, price, remaining)
+	if err != nil {
+		bracket.Message = err.Error()
+		return
+	}
+	bracket.StopLossOrderID = orderID
+	if cooldown := oe.tradeCooldown(); cooldown != nil {
+		cooldown.BlacklistAfterStopLoss(bracket.Symbol)
+	}
+}
+
+// orderFilled 委托是否已全部成交
+func orderFilled(order Order) bool {
+	return order.Status == "已成交"
+}
+
+// orderDead 委托是否已结束且不会再成交
+func orderDead(order Order) bool {
+	switch order.Status {
+	case "已撤", "部撤", "废单":
+		return true
+	}
+	return false
+}
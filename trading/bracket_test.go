@@ -0,0 +1,179 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// orderBookBroker 在fakeBroker基础上记录委托，测试可直接修改委托状态
+type orderBookBroker struct {
+	fakeBroker
+	seq       int
+	orders    []Order
+	cancelled []string
+}
+
+func (b *orderBookBroker) place(side, symbol string, price float64, amount int) string {
+	b.seq++
+	id := fmt.Sprintf("%s_%d", side, b.seq)
+	b.orders = append(b.orders, Order{OrderID: id, Symbol: symbol, Type: side, Price: price, Amount: amount, Status: "已报"})
+	return id
+}
+
+func (b *orderBookBroker) Buy(ctx context.Context, symbol string, price float64, amount int) (string, error) {
+	return b.place(OrderTypeBuy, symbol, price, amount), nil
+}
+
+func (b *orderBookBroker) Sell(ctx context.Context, symbol string, price float64, amount int) (string, error) {
+	return b.place(OrderTypeSell, symbol, price, amount), nil
+}
+
+func (b *orderBookBroker) Cancel(ctx context.Context, orderID string) error {
+	b.cancelled = append(b.cancelled, orderID)
+	b.setStatus(orderID, "已撤", -1)
+	return nil
+}
+
+func (b *orderBookBroker) GetOrders(ctx context.Context) ([]Order, error) {
+	return append([]Order(nil), b.orders...), nil
+}
+
+// setStatus 修改委托状态，filled为负时保留原成交数量
+func (b *orderBookBroker) setStatus(orderID, status string, filled int) {
+	for i := range b.orders {
+		if b.orders[i].OrderID == orderID {
+			b.orders[i].Status = status
+			if filled >= 0 {
+				b.orders[i].FilledAmount = filled
+			}
+		}
+	}
+}
+
+func newBracketTestExecutor(t *testing.T) (*OrderExecutor, *orderBookBroker, *PositionManager) {
+	t.Helper()
+	broker := &orderBookBroker{}
+	broker.balance = Balance{TotalAssets: 100000, AvailableCash: 100000}
+	connector := &BrokerConnector{broker: broker}
+
+	config := DefaultRiskConfig
+	config.InitialCapital = 100000
+	rm := NewRiskManager(config, connector, nil)
+	pm := NewPositionManager(connector)
+	return NewOrderExecutor(connector, rm, pm, nil), broker, pm
+}
+
+func bracketState(t *testing.T, oe *OrderExecutor, id BracketID) *BracketStatus {
+	t.Helper()
+	if err := oe.UpdateBrackets(context.Background()); err != nil {
+		t.Fatalf("UpdateBrackets: %v", err)
+	}
+	status, err := oe.GetBracketStatus(id)
+	if err != nil {
+		t.Fatalf("GetBracketStatus: %v", err)
+	}
+	return status
+}
+
+func TestBracketTakeProfitFills(t *testing.T) {
+	oe, broker, pm := newBracketTestExecutor(t)
+	ctx := context.Background()
+
+	entry := Order{Symbol: "sh600000", Type: OrderTypeBuy, Price: 10, Amount: 1000}
+	id, err := oe.ExecuteBracket(ctx, entry, 11, 9.5)
+	if err != nil {
+		t.Fatalf("ExecuteBracket: %v", err)
+	}
+	if status := bracketState(t, oe, id); status.State != BracketPending {
+		t.Fatalf("state = %s, want pending", status.State)
+	}
+
+	// 入场成交，当日买入T+1不可卖，止盈单暂不挂出
+	broker.setStatus("buy_1", "已成交", 1000)
+	broker.positions = []Position{{Symbol: "sh600000", Amount: 1000, Available: 0, CostPrice: 10, CurrentPrice: 10.2}}
+	pm.SyncPositions()
+	status := bracketState(t, oe, id)
+	if status.State != BracketActive || status.TakeProfitOrderID != "" {
+		t.Fatalf("status = %+v, want active without take profit order", status)
+	}
+
+	// 次日可卖，挂出止盈单
+	broker.positions[0].Available = 1000
+	pm.SyncPositions()
+	status = bracketState(t, oe, id)
+	if status.TakeProfitOrderID != "sell_2" {
+		t.Fatalf("take profit order = %q, want sell_2", status.TakeProfitOrderID)
+	}
+	if broker.orders[1].Price != 11 || broker.orders[1].Amount != 1000 {
+		t.Fatalf("take profit order = %+v, want 1000 @ 11", broker.orders[1])
+	}
+
+	broker.setStatus("sell_2", "已成交", 1000)
+	status = bracketState(t, oe, id)
+	if status.State != BracketTakeProfit {
+		t.Fatalf("state = %s, want take_profit", status.State)
+	}
+	if len(broker.cancelled) != 0 {
+		t.Fatalf("cancelled = %v, want none", broker.cancelled)
+	}
+}
+
+func TestBracketStopLossCancelsTakeProfit(t *testing.T) {
+	oe, broker, pm := newBracketTestExecutor(t)
+	ctx := context.Background()
+
+	entry := Order{Symbol: "sh600000", Type: OrderTypeBuy, Price: 10, Amount: 1000}
+	id, err := oe.ExecuteBracket(ctx, entry, 11, 9.5)
+	if err != nil {
+		t.Fatalf("ExecuteBracket: %v", err)
+	}
+
+	broker.setStatus("buy_1", "已成交", 1000)
+	broker.positions = []Position{{Symbol: "sh600000", Amount: 1000, Available: 1000, CostPrice: 10, CurrentPrice: 10}}
+	pm.SyncPositions()
+	if status := bracketState(t, oe, id); status.TakeProfitOrderID != "sell_2" {
+		t.Fatalf("take profit order = %q, want sell_2", status.TakeProfitOrderID)
+	}
+
+	// 止盈单成交200股后价格跌破止损：撤止盈单，卖出剩余800股
+	broker.setStatus("sell_2", "部分成交", 200)
+	broker.positions[0] = Position{Symbol: "sh600000", Amount: 800, Available: 800, CostPrice: 10, CurrentPrice: 9.4}
+	pm.SyncPositions()
+	status := bracketState(t, oe, id)
+	if status.State != BracketStopping {
+		t.Fatalf("state = %s, want stopping", status.State)
+	}
+	if len(broker.cancelled) != 1 || broker.cancelled[0] != "sell_2" {
+		t.Fatalf("cancelled = %v, want [sell_2]", broker.cancelled)
+	}
+	if status.StopLossOrderID != "sell_3" || broker.orders[2].Amount != 800 || broker.orders[2].Price != 9.4 {
+		t.Fatalf("stop loss order = %q %+v, want 800 @ 9.4", status.StopLossOrderID, broker.orders[2])
+	}
+
+	broker.setStatus("sell_3", "已成交", 800)
+	if status := bracketState(t, oe, id); status.State != BracketStopLoss {
+		t.Fatalf("state = %s, want stop_loss", status.State)
+	}
+}
+
+func TestBracketEntryCancelled(t *testing.T) {
+	oe, broker, _ := newBracketTestExecutor(t)
+	ctx := context.Background()
+
+	if _, err := oe.ExecuteBracket(ctx, Order{Symbol: "sh600000", Price: 10, Amount: 1000}, 9, 9.5); err == nil {
+		t.Fatal("ExecuteBracket accepted take profit below entry")
+	}
+
+	id, err := oe.ExecuteBracket(ctx, Order{Symbol: "sh600000", Price: 10, Amount: 1000}, 11, 9.5)
+	if err != nil {
+		t.Fatalf("ExecuteBracket: %v", err)
+	}
+	broker.setStatus("buy_1", "已撤", 0)
+	if status := bracketState(t, oe, id); status.State != BracketCancelled {
+		t.Fatalf("state = %s, want cancelled", status.State)
+	}
+	if _, err := oe.GetBracketStatus("bracket_missing"); err == nil {
+		t.Fatal("GetBracketStatus found unknown bracket")
+	}
+}
@@ -29,12 +29,13 @@ type BrokerConnector struct {
 
 // BrokerConfig 券商配置
 type BrokerConfig struct {
-	Type     string `yaml:"type" json:"type"`         // 券商类型: easytrader
-	Service  string `yaml:"service" json:"service"`   // 服务地址
-	Broker   string `yaml:"broker" json:"broker"`     // 具体券商: ht, yh, yjb
-	Username string `yaml:"username" json:"username"` // 用户名
-	Password string `yaml:"password" json:"password"` // 密码
-	ExePath  string `yaml:"exe_path" json:"exe_path"` // 客户端路径
+	Type        string  `yaml:"type" json:"type"`                 // 券商类型: easytrader, paper
+	Service     string  `yaml:"service" json:"service"`           // 服务地址
+	Broker      string  `yaml:"broker" json:"broker"`             // 具体券商: ht, yh, yjb
+	Username    string  `yaml:"username" json:"username"`         // 用户名
+	Password    string  `yaml:"password" json:"password"`         // 密码
+	ExePath     string  `yaml:"exe_path" json:"exe_path"`         // 客户端路径
+	InitialCash float64 `yaml:"initial_cash" json:"initial_cash"` // 模拟盘初始资金
 }
 
 // RetryConfig 重试配置
@@ -75,6 +76,12 @@ func (bc *BrokerConnector) initBroker() error {
 		bc.broker = broker
 		bc.mu.Unlock()
 		return nil
+	case BrokerTypePaper:
+		broker := NewPaperBroker(bc.config.InitialCash)
+		bc.mu.Lock()
+		bc.broker = broker
+		bc.mu.Unlock()
+		return nil
 	default:
 		return fmt.Errorf("%w: %s", ErrInvalidBrokerType, bc.config.Type)
 	}
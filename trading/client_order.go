@@ -0,0 +1,121 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DefaultClientOrderTTL 客户端订单ID的默认去重窗口
+const DefaultClientOrderTTL = 10 * time.Minute
+
+// clientOrderPlaceTimeout 按客户端订单ID下单的超时时间，下单不随请求取消而中断
+const clientOrderPlaceTimeout = 30 * time.Second
+
+// ErrClientOrderConflict 同一客户端订单ID被用于不同的订单
+var ErrClientOrderConflict = fmt.Errorf("客户端订单ID已用于其他订单")
+
+// clientOrderEntry 一次按客户端订单ID提交的订单
+type clientOrderEntry struct {
+	fingerprint string        // 方向、股票代码、价格和数量，用于识别ID被复用于不同订单
+	done        chan struct{} // 首次提交完成后关闭
+	orderID     string
+	err         error
+	expires     time.Time
+}
+
+// clientOrderCache 按客户端订单ID对下单去重，防止客户端超时重试造成重复成交
+type clientOrderCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]*clientOrderEntry
+}
+
+func newClientOrderCache(ttl time.Duration) *clientOrderCache {
+	return &clientOrderCache{
+		ttl:     ttl,
+		entries: make(map[string]*clientOrderEntry),
+	}
+}
+
+// submit 去重提交：窗口内重复的ID等待首次提交完成并返回其订单ID，duplicate为true；
+// 首次提交失败的ID不保留，客户端可用同一ID重试。下单超时时券商可能已经受理，
+// 结果未知的ID仍然保留，重试返回同一错误而不会重复下单
+func (c *clientOrderCache) submit(clientOrderID, fingerprint string, place func() (string, error)) (orderID string, duplicate bool, err error) {
+	c.mu.Lock()
+	now := time.Now()
+	for id, entry := range c.entries {
+		if !entry.expires.IsZero() && now.After(entry.expires) {
+			delete(c.entries, id)
+		}
+	}
+
+	if entry, ok := c.entries[clientOrderID]; ok {
+		c.mu.Unlock()
+		if entry.fingerprint != fingerprint {
+			return "", false, ErrClientOrderConflict
+		}
+		<-entry.done
+		return entry.orderID, true, entry.err
+	}
+
+	entry := &clientOrderEntry{fingerprint: fingerprint, done: make(chan struct{})}
+	c.entries[clientOrderID] = entry
+	c.mu.Unlock()
+
+	entry.orderID, entry.err = place()
+
+	c.mu.Lock()
+	if entry.err != nil && !errors.Is(entry.err, context.DeadlineExceeded) && !errors.Is(entry.err, context.Canceled) {
+		delete(c.entries, clientOrderID)
+	} else {
+		entry.expires = time.Now().Add(c.ttl)
+	}
+	c.mu.Unlock()
+	close(entry.done)
+
+	return entry.orderID, false, entry.err
+}
+
+// SetClientOrderTTL 设置客户端订单ID的去重窗口
+func (oe *OrderExecutor) SetClientOrderTTL(ttl time.Duration) {
+	oe.clientOrders.mu.Lock()
+	defer oe.clientOrders.mu.Unlock()
+	oe.clientOrders.ttl = ttl
+}
+
+// ExecuteBuyOnce 按客户端订单ID幂等地执行买入，clientOrderID为空时等同ExecuteBuy。
+// 去重窗口内重复提交不再下单，返回首次的订单ID且duplicate为true
+func (oe *OrderExecutor) ExecuteBuyOnce(ctx context.Context, clientOrderID, symbol string, price float64, amount float64) (orderID string, duplicate bool, err error) {
+	if clientOrderID == "" {
+		orderID, err = oe.ExecuteBuy(ctx, symbol, price, amount)
+		return orderID, false, err
+	}
+	fingerprint := fmt.Sprintf("buy %s %.4f %.2f", symbol, price, amount)
+	return oe.clientOrders.submit(clientOrderID, fingerprint, func() (string, error) {
+		ctx, cancel := detachedOrderContext(ctx)
+		defer cancel()
+		return oe.ExecuteBuy(ctx, symbol, price, amount)
+	})
+}
+
+// ExecuteSellOnce 按客户端订单ID幂等地执行卖出，规则同ExecuteBuyOnce
+func (oe *OrderExecutor) ExecuteSellOnce(ctx context.Context, clientOrderID, symbol string, price float64, quantity int) (orderID string, duplicate bool, err error) {
+	if clientOrderID == "" {
+		orderID, err = oe.ExecuteSell(ctx, symbol, price, quantity)
+		return orderID, false, err
+	}
+	fingerprint := fmt.Sprintf("sell %s %.4f %d", symbol, price, quantity)
+	return oe.clientOrders.submit(clientOrderID, fingerprint, func() (string, error) {
+		ctx, cancel := detachedOrderContext(ctx)
+		defer cancel()
+		return oe.ExecuteSell(ctx, symbol, price, quantity)
+	})
+}
+
+// detachedOrderContext 下单使用的上下文：客户端断开不会中断已开始的下单，避免券商已受理而本地记为失败
+func detachedOrderContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(context.WithoutCancel(ctx), clientOrderPlaceTimeout)
+}
@@ -0,0 +1,134 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestExecuteSellOnceDeduplicatesClientOrderID(t *testing.T) {
+	broker := &fakeBroker{
+		balance: Balance{TotalAssets: 100000, AvailableCash: 50000},
+		positions: []Position{
+			{Symbol: "sh600000", Amount: 1000, Available: 1000, CurrentPrice: 12},
+		},
+	}
+	connector := &BrokerConnector{broker: broker}
+	positions := NewPositionManager(connector)
+	if err := positions.SyncPositions(); err != nil {
+		t.Fatalf("SyncPositions: %v", err)
+	}
+	executor := NewOrderExecutor(connector, NewRiskManager(DefaultRiskConfig, connector, nil), positions, nil)
+	ctx := context.Background()
+
+	// 首次提交失败不占用ID，修正后可用同一ID重试
+	if _, _, err := executor.ExecuteSellOnce(ctx, "retry-1", "sh600000", 12, 5000); err == nil {
+		t.Fatal("sell above available should fail")
+	}
+
+	// 模拟网络重试：并发的重复提交只下一次单
+	var wg sync.WaitGroup
+	results := make([]string, 5)
+	duplicates := make([]bool, 5)
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			orderID, duplicate, err := executor.ExecuteSellOnce(ctx, "retry-1", "sh600000", 12, 100)
+			if err != nil {
+				t.Errorf("ExecuteSellOnce: %v", err)
+			}
+			results[i], duplicates[i] = orderID, duplicate
+		}(i)
+	}
+	wg.Wait()
+
+	if broker.sold["sh600000"] != 100 {
+		t.Fatalf("sold %d shares, want a single 100-share order", broker.sold["sh600000"])
+	}
+	original := 0
+	for i := range results {
+		if results[i] != "sell_sh600000" {
+			t.Errorf("attempt %d got order %q, want the original order ID", i, results[i])
+		}
+		if !duplicates[i] {
+			original++
+		}
+	}
+	if original != 1 {
+		t.Errorf("%d attempts reported as original submissions, want 1", original)
+	}
+
+	if _, _, err := executor.ExecuteBuyOnce(ctx, "retry-1", "sh600000", 12, 10000); !errors.Is(err, ErrClientOrderConflict) {
+		t.Errorf("reusing ID for a buy: err = %v, want ErrClientOrderConflict", err)
+	}
+
+	// 不带ID的请求不去重
+	for i := 0; i < 2; i++ {
+		if _, duplicate, err := executor.ExecuteSellOnce(ctx, "", "sh600000", 12, 100); err != nil || duplicate {
+			t.Fatalf("sell without client order ID: duplicate %v, err %v", duplicate, err)
+		}
+	}
+	if broker.sold["sh600000"] != 300 {
+		t.Errorf("sold %d shares, want 300", broker.sold["sh600000"])
+	}
+
+	// 去重窗口过后同一ID视为新订单
+	executor.SetClientOrderTTL(time.Millisecond)
+	if _, _, err := executor.ExecuteSellOnce(ctx, "retry-2", "sh600000", 12, 100); err != nil {
+		t.Fatalf("ExecuteSellOnce: %v", err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if _, duplicate, err := executor.ExecuteSellOnce(ctx, "retry-2", "sh600000", 12, 100); err != nil || duplicate {
+		t.Errorf("after ttl: duplicate %v, err %v; want a new order", duplicate, err)
+	}
+}
+
+// ctxCheckingBroker 请求上下文已取消时拒绝下单
+type ctxCheckingBroker struct {
+	fakeBroker
+}
+
+func (b *ctxCheckingBroker) Sell(ctx context.Context, symbol string, price float64, amount int) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return b.fakeBroker.Sell(ctx, symbol, price, amount)
+}
+
+func TestExecuteSellOnceSurvivesClientDisconnect(t *testing.T) {
+	broker := &ctxCheckingBroker{fakeBroker{
+		balance:   Balance{TotalAssets: 100000, AvailableCash: 50000},
+		positions: []Position{{Symbol: "sh600000", Amount: 1000, Available: 1000, CurrentPrice: 12}},
+	}}
+	connector := &BrokerConnector{broker: broker}
+	positions := NewPositionManager(connector)
+	if err := positions.SyncPositions(); err != nil {
+		t.Fatalf("SyncPositions: %v", err)
+	}
+	executor := NewOrderExecutor(connector, NewRiskManager(DefaultRiskConfig, connector, nil), positions, nil)
+
+	// 客户端已断开的请求仍完成下单，重试返回同一订单而不重复下单
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	orderID, _, err := executor.ExecuteSellOnce(ctx, "disconnect-1", "sh600000", 12, 100)
+	if err != nil || orderID != "sell_sh600000" {
+		t.Fatalf("ExecuteSellOnce with cancelled context: order %q, err %v", orderID, err)
+	}
+	if _, duplicate, err := executor.ExecuteSellOnce(context.Background(), "disconnect-1", "sh600000", 12, 100); err != nil || !duplicate {
+		t.Errorf("retry: duplicate %v, err %v; want the original order", duplicate, err)
+	}
+	if broker.sold["sh600000"] != 100 {
+		t.Errorf("sold %d shares, want a single 100-share order", broker.sold["sh600000"])
+	}
+
+	// 同一ID换了价格或数量视为不同订单
+	if _, _, err := executor.ExecuteSellOnce(context.Background(), "disconnect-1", "sh600000", 12.5, 100); !errors.Is(err, ErrClientOrderConflict) {
+		t.Errorf("reusing ID with another price: err = %v, want ErrClientOrderConflict", err)
+	}
+	if _, _, err := executor.ExecuteSellOnce(context.Background(), "disconnect-1", "sh600000", 12, 200); !errors.Is(err, ErrClientOrderConflict) {
+		t.Errorf("reusing ID with another quantity: err = %v, want ErrClientOrderConflict", err)
+	}
+}
@@ -0,0 +1,262 @@
+package order
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// ErrDrainTimeout 关闭时算法未能在超时内全部停止
+var ErrDrainTimeout = fmt.Errorf("execution drain timed out")
+
+// DrainConfig 关闭时排空执行算法的配置
+type DrainConfig struct {
+	Timeout   time.Duration `yaml:"timeout" json:"timeout"`       // 等待算法退出的最长时间，0表示一直等待
+	StateFile string        `yaml:"state_file" json:"state_file"` // 部分执行状态的保存路径，为空时只保留在内存；没有未完成母单时写入空列表
+}
+
+// DefaultDrainConfig 默认排空配置
+func DefaultDrainConfig() DrainConfig {
+	return DrainConfig{
+		Timeout: 10 * time.Second,
+	}
+}
+
+// PartialExecution 关闭时未完成的母单执行状态，用于重启后对账
+type PartialExecution struct {
+	OrderID           string             `json:"order_id"`
+	Symbol            string             `json:"symbol"`
+	Side              OrderSide          `json:"side"`
+	Algorithm         ExecutionAlgorithm `json:"algorithm"`
+	Quantity          float64            `json:"quantity"`
+	SubmittedQuantity float64            `json:"submitted_quantity"` // 已提交子单数量
+	FilledQuantity    float64            `json:"filled_quantity"`    // 已成交数量
+	RemainingQuantity float64            `json:"remaining_quantity"` // 未成交数量
+	CancelledChildren []string           `json:"cancelled_children,omitempty"`
+	Slices            []SliceExecution   `json:"slices"`
+	StartedAt         time.Time          `json:"started_at"`
+	RecordedAt        time.Time          `json:"recorded_at"`
+}
+
+// runningExecution 执行中的母单
+type runningExecution struct {
+	order     *Order
+	algorithm ExecutionAlgorithm
+	startedAt time.Time
+}
+
+// SetDrainConfig 设置关闭排空配置
+func (e *ExecutionEngine) SetDrainConfig(config DrainConfig) {
+	e.ordersLock.Lock()
+	defer e.ordersLock.Unlock()
+	e.drain = config
+}
+
+// beginExecution 登记执行中的母单，返回执行结束时的清理函数。
+// 执行结束时母单已成交、取消或失败，同时清除其取消信号
+func (e *ExecutionEngine) beginExecution(order *Order, config AlgoConfig) (func(), error) {
+	e.ordersLock.Lock()
+	defer e.ordersLock.Unlock()
+
+	if e.closing {
+		return nil, ErrEngineShuttingDown
+	}
+
+	// 市价单直接提交，不产生需要对账的分片
+	if config.Type != AlgoMarket {
+		e.running[order.ID] = &runningExecution{
+			order:     order,
+			algorithm: config.Type,
+			startedAt: time.Now(),
+		}
+	}
+	e.wg.Add(1)
+
+	return func() {
+		e.ordersLock.Lock()
+		delete(e.running, order.ID)
+		delete(e.cancels, order.ID)
+		e.ordersLock.Unlock()
+		e.wg.Done()
+	}, nil
+}
+
+// Shutdown 排空执行引擎：拒绝新的执行，通过上下文停止算法循环，
+// 撤销未成交的子单并记录部分执行状态。等待时间受ctx和 DrainConfig.Timeout 限制，
+// 超时时仍会撤单并记录状态，返回 ErrDrainTimeout。
+func (e *ExecutionEngine) Shutdown(ctx context.Context) ([]PartialExecution, error) {
+	e.ordersLock.Lock()
+	if e.closing {
+		e.ordersLock.Unlock()
+		return nil, fmt.Errorf("execution engine already shut down")
+	}
+	e.closing = true
+	config := e.drain
+	inflight := make([]*runningExecution, 0, len(e.running))
+	for _, run := range e.running {
+		inflight = append(inflight, run)
+	}
+	e.ordersLock.Unlock()
+
+	sort.Slice(inflight, func(i, j int) bool {
+		return inflight[i].startedAt.Before(inflight[j].startedAt)
+	})
+	log.Printf("Draining %d in-flight executions...", len(inflight))
+
+	// 停止算法循环
+	e.stop()
+
+	if config.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, config.Timeout)
+		defer cancel()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(stopped)
+	}()
+
+	var drainErr error
+	select {
+	case <-stopped:
+	case <-ctx.Done():
+		drainErr = fmt.Errorf("%w: %v", ErrDrainTimeout, ctx.Err())
+	}
+
+	// 算法停止后再撤单，避免撤单后又提交新的分片
+	partials := make([]PartialExecution, 0, len(inflight))
+	for _, run := range inflight {
+		partials = append(partials, e.recordPartial(run))
+	}
+
+	e.ordersLock.Lock()
+	e.partials = append(e.partials, partials...)
+	e.ordersLock.Unlock()
+
+	// 没有未完成的母单时也写入，清除上次关闭留下的状态
+	if config.StateFile != "" {
+		if err := savePartialExecutions(config.StateFile, partials); err != nil {
+			drainErr = errors.Join(drainErr, err)
+		}
+	}
+
+	log.Printf("Execution engine drained: %d partial executions recorded", len(partials))
+	return partials, drainErr
+}
+
+// recordPartial 停止母单执行、撤销未成交子单并生成部分执行记录
+func (e *ExecutionEngine) recordPartial(run *runningExecution) PartialExecution {
+	orderID := run.order.ID
+
+	e.ordersLock.Lock()
+	if cancel, ok := e.cancels[orderID]; ok {
+		select {
+		case <-cancel:
+		default:
+			close(cancel)
+		}
+	}
+	slices := make([]*SliceExecution, len(e.slices[orderID]))
+	copy(slices, e.slices[orderID])
+	e.ordersLock.Unlock()
+
+	cancelled, failed := e.cancelChildOrders(orderID, slices)
+	if failed > 0 {
+		log.Printf("Failed to cancel %d child orders of %s during shutdown", failed, orderID)
+	}
+
+	partial := PartialExecution{
+		OrderID:           orderID,
+		Symbol:            run.order.Symbol,
+		Side:              run.order.Side,
+		Algorithm:         run.algorithm,
+		Quantity:          run.order.Quantity,
+		CancelledChildren: cancelled,
+		StartedAt:         run.startedAt,
+		RecordedAt:        time.Now(),
+	}
+
+	e.ordersLock.RLock()
+	for _, slice := range slices {
+		partial.Slices = append(partial.Slices, *slice)
+		if slice.Status != OrderStatusFailed {
+			partial.SubmittedQuantity += slice.Quantity
+		}
+	}
+	e.ordersLock.RUnlock()
+
+	for _, slice := range slices {
+		if slice.ChildOrderID == "" {
+			continue
+		}
+		if child, err := e.orderMgr.GetOrder(slice.ChildOrderID); err == nil {
+			partial.FilledQuantity += child.FilledQuantity
+		}
+	}
+	partial.RemainingQuantity = partial.Quantity - partial.FilledQuantity
+
+	e.ordersLock.Lock()
+	if partial.RemainingQuantity > 0 {
+		if partial.FilledQuantity > 0 {
+			run.order.Status = OrderStatusPartial
+		} else {
+			run.order.Status = OrderStatusCancelled
+		}
+	}
+	run.order.FilledQuantity = partial.FilledQuantity
+	run.order.UpdateTime = partial.RecordedAt
+	e.ordersLock.Unlock()
+
+	return partial
+}
+
+// PartialExecutions 获取关闭时记录的部分执行状态
+func (e *ExecutionEngine) PartialExecutions() []PartialExecution {
+	e.ordersLock.RLock()
+	defer e.ordersLock.RUnlock()
+
+	result := make([]PartialExecution, len(e.partials))
+	copy(result, e.partials)
+	return result
+}
+
+// savePartialExecutions 将部分执行状态写入文件
+func savePartialExecutions(path string, partials []PartialExecution) error {
+	data, err := json.MarshalIndent(partials, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode partial executions: %w", err)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0750); err != nil {
+		return fmt.Errorf("failed to create state directory: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return fmt.Errorf("failed to write partial executions: %w", err)
+	}
+	return nil
+}
+
+// LoadPartialExecutions 读取上次关闭时保存的部分执行状态，文件不存在时返回空
+func LoadPartialExecutions(path string) ([]PartialExecution, error) {
+	// #nosec G304 -- State file path is configured by administrator, not user input
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var partials []PartialExecution
+	if err := json.Unmarshal(data, &partials); err != nil {
+		return nil, fmt.Errorf("failed to decode partial executions: %w", err)
+	}
+	return partials, nil
+}
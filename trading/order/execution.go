@@ -27,11 +27,23 @@ type AlgoConfig struct {
 	SliceCount    int                `json:"slice_count"`
 	Participation float64            `json:"participation"` // 0-1
 	MinSliceSize  float64            `json:"min_slice_size"`
+	VolumeProfile []float64          `json:"volume_profile,omitempty"` // VWAP各时间桶的成交量占比，见 executeVWAP
+	Deadline      time.Duration      `json:"deadline"`                 // 最长执行时间，0表示使用预估执行时间
 }
 
+var (
+	// ErrExecutionCancelled 母单算法执行被取消
+	ErrExecutionCancelled = fmt.Errorf("execution cancelled")
+	// ErrExecutionDeadline 算法执行超过最长执行时间
+	ErrExecutionDeadline = fmt.Errorf("execution deadline exceeded")
+	// ErrEngineShuttingDown 执行引擎正在关闭，不再接受新的执行
+	ErrEngineShuttingDown = fmt.Errorf("execution engine shutting down")
+)
+
 // SliceExecution 执行分片
 type SliceExecution struct {
 	OrderID      string      `json:"order_id"`
+	ChildOrderID string      `json:"child_order_id,omitempty"`
 	SliceIndex   int         `json:"slice_index"`
 	TotalSlices  int         `json:"total_slices"`
 	Quantity     float64     `json:"quantity"`
@@ -45,24 +57,51 @@ type SliceExecution struct {
 type ExecutionEngine struct {
 	orders     map[string]*Order
 	slices     map[string][]*SliceExecution
+	cancels    map[string]chan struct{}
 	ordersLock sync.RWMutex
 
 	orderMgr   *OrderManager
 	marketData func(symbol string) (*LiquidityInfo, error)
+
+	// 关闭排空
+	ctx      context.Context
+	stop     context.CancelFunc
+	running  map[string]*runningExecution
+	wg       sync.WaitGroup
+	closing  bool
+	drain    DrainConfig
+	partials []PartialExecution
 }
 
 // NewExecutionEngine 创建执行引擎
 func NewExecutionEngine(orderMgr *OrderManager, marketData func(string) (*LiquidityInfo, error)) *ExecutionEngine {
+	ctx, stop := context.WithCancel(context.Background())
 	return &ExecutionEngine{
 		orders:     make(map[string]*Order),
 		slices:     make(map[string][]*SliceExecution),
+		cancels:    make(map[string]chan struct{}),
 		orderMgr:   orderMgr,
 		marketData: marketData,
+		ctx:        ctx,
+		stop:       stop,
+		running:    make(map[string]*runningExecution),
+		drain:      DefaultDrainConfig(),
 	}
 }
 
-// ExecuteWithAlgorithm 使用算法执行订单
+// ExecuteWithAlgorithm 使用算法执行订单，引擎关闭时算法通过上下文停止
 func (e *ExecutionEngine) ExecuteWithAlgorithm(ctx context.Context, order *Order, config AlgoConfig) error {
+	done, err := e.beginExecution(order, config)
+	if err != nil {
+		return err
+	}
+	defer done()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stopOnShutdown := context.AfterFunc(e.ctx, cancel)
+	defer stopOnShutdown()
+
 	switch config.Type {
 	case AlgoMarket:
 		return e.executeMarket(ctx, order)
@@ -101,15 +140,16 @@ func (e *ExecutionEngine) executeTWAP(ctx context.Context, order *Order, config
 	sliceInterval := config.Duration / time.Duration(config.SliceCount)
 
 	// 保存原始订单
-	e.ordersLock.Lock()
-	e.orders[order.ID] = order
-	e.ordersLock.Unlock()
+	cancel := e.trackOrder(order)
 
 	// 执行分片
 	for i := 0; i < config.SliceCount; i++ {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-cancel:
+			log.Printf("Execution of order %s cancelled", order.ID)
+			return ErrExecutionCancelled
 		default:
 			sliceOrder := *order
 			sliceOrder.ID = generateOrderID()
@@ -123,12 +163,13 @@ func (e *ExecutionEngine) executeTWAP(ctx context.Context, order *Order, config
 
 			// 记录分片
 			slice := &SliceExecution{
-				OrderID:     order.ID,
-				SliceIndex:  i,
-				TotalSlices: config.SliceCount,
-				Quantity:    sliceOrder.Quantity,
-				Status:      OrderStatusPending,
-				ExecuteTime: time.Now(),
+				OrderID:      order.ID,
+				ChildOrderID: sliceOrder.ID,
+				SliceIndex:   i,
+				TotalSlices:  config.SliceCount,
+				Quantity:     sliceOrder.Quantity,
+				Status:       OrderStatusPending,
+				ExecuteTime:  time.Now(),
 			}
 
 			e.ordersLock.Lock()
@@ -138,14 +179,16 @@ func (e *ExecutionEngine) executeTWAP(ctx context.Context, order *Order, config
 			// 提交分片订单
 			if _, err := e.orderMgr.SubmitOrder(ctx, &sliceOrder); err != nil {
 				log.Printf("Failed to submit slice %d: %v", i, err)
-				slice.Status = OrderStatusFailed
+				e.setSliceStatus(slice, OrderStatusFailed)
 			} else {
-				slice.Status = OrderStatusSubmitted
+				e.setSliceStatus(slice, OrderStatusSubmitted)
 			}
 
 			// 等待下一个分片
 			if i < config.SliceCount-1 {
-				time.Sleep(sliceInterval)
+				if err := waitSlice(ctx, cancel, sliceInterval); err != nil {
+					return err
+				}
 			}
 		}
 	}
@@ -155,13 +198,15 @@ func (e *ExecutionEngine) executeTWAP(ctx context.Context, order *Order, config
 }
 
 // executeVWAP 成交量加权平均价执行
+//
+// 执行窗口（Duration）等分为 len(VolumeProfile) 个时间桶，VolumeProfile[i]
+// 为第i个桶预期成交量占整个窗口的比例，各元素之和应为1（否则自动归一化），
+// 为空时使用 DefaultVolumeProfile(SliceCount) 的U型分布。每个桶提交一笔分片，
+// 数量按该桶占比分配；若实时盘口成交量落后于预期，分片按比例缩小，
+// 未完成部分顺延到后续桶，最后一个桶补齐剩余数量。
 func (e *ExecutionEngine) executeVWAP(ctx context.Context, order *Order, config AlgoConfig) error {
 	log.Printf("Executing order %s with VWAP algorithm", order.ID)
 
-	if config.SliceCount == 0 {
-		config.SliceCount = 10
-	}
-
 	// 获取流动性信息
 	liq, err := e.getLiquidity(order.Symbol)
 	if err != nil {
@@ -170,53 +215,82 @@ func (e *ExecutionEngine) executeVWAP(ctx context.Context, order *Order, config
 	}
 
 	// 保存原始订单
-	e.ordersLock.Lock()
-	e.orders[order.ID] = order
-	e.ordersLock.Unlock()
+	cancel := e.trackOrder(order)
+
+	profile := buildVolumeProfile(config)
+	sliceCount := len(profile)
+	sliceInterval := 500 * time.Millisecond
+	if config.Duration > 0 {
+		sliceInterval = config.Duration / time.Duration(sliceCount)
+	}
 
-	// 根据流动性分配分片大小
-	_ = liq
+	// 以第一个桶的盘口成交量估算整个执行窗口的市场成交量
+	var expectedTotal float64
+	if profile[0] > 0 {
+		expectedTotal = sideVolume(liq, order.Side) / profile[0]
+	}
 
-	sliceCount := config.SliceCount
-	sliceQuantity := order.Quantity / float64(sliceCount)
+	var cumWeight, submitted float64
 
 	// 执行分片
 	for i := 0; i < sliceCount; i++ {
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-cancel:
+			log.Printf("Execution of order %s cancelled", order.ID)
+			return ErrExecutionCancelled
 		default:
-			sliceOrder := *order
-			sliceOrder.ID = generateOrderID()
-			sliceOrder.ParentOrderID = order.ID
-			sliceOrder.Quantity = sliceQuantity
+			cumWeight += profile[i]
+			quantity := cumWeight*order.Quantity - submitted
+
+			// 根据实时流动性调整分片大小和价格
+			sliceLiq := liq
+			if i > 0 {
+				if newLiq, err := e.getLiquidity(order.Symbol); err == nil {
+					sliceLiq = newLiq
+				}
+				expected := expectedTotal * profile[i]
+				if realized := sideVolume(sliceLiq, order.Side); expected > 0 && realized < expected {
+					quantity *= math.Max(realized/expected, minVWAPFillRatio)
+				}
+			}
 
-			// 最后一笔调整数量
+			// 最后一笔补齐剩余数量
 			if i == sliceCount-1 {
-				sliceOrder.Quantity = order.Quantity - (float64(sliceCount-1) * sliceQuantity)
+				quantity = order.Quantity - submitted
 			}
 
-			// 根据流动性调整价格
-			if order.Type == OrderTypeLimit && e.marketData != nil {
-				newLiq, err := e.getLiquidity(order.Symbol)
-				if err == nil {
-					if order.Side == OrderSideBuy {
-						sliceOrder.Price = newLiq.AskPrice
-					} else {
-						sliceOrder.Price = newLiq.BidPrice
-					}
+			if quantity <= 0 {
+				if err := waitSlice(ctx, cancel, sliceInterval); err != nil {
+					return err
+				}
+				continue
+			}
+
+			sliceOrder := *order
+			sliceOrder.ID = generateOrderID()
+			sliceOrder.ParentOrderID = order.ID
+			sliceOrder.Quantity = quantity
+
+			if order.Type == OrderTypeLimit {
+				if order.Side == OrderSideBuy {
+					sliceOrder.Price = sliceLiq.AskPrice
+				} else {
+					sliceOrder.Price = sliceLiq.BidPrice
 				}
 			}
 
 			// 记录分片
 			slice := &SliceExecution{
-				OrderID:     order.ID,
-				SliceIndex:  i,
-				TotalSlices: sliceCount,
-				Quantity:    sliceOrder.Quantity,
-				Price:       sliceOrder.Price,
-				Status:      OrderStatusPending,
-				ExecuteTime: time.Now(),
+				OrderID:      order.ID,
+				ChildOrderID: sliceOrder.ID,
+				SliceIndex:   i,
+				TotalSlices:  sliceCount,
+				Quantity:     sliceOrder.Quantity,
+				Price:        sliceOrder.Price,
+				Status:       OrderStatusPending,
+				ExecuteTime:  time.Now(),
 			}
 
 			e.ordersLock.Lock()
@@ -226,13 +300,18 @@ func (e *ExecutionEngine) executeVWAP(ctx context.Context, order *Order, config
 			// 提交分片订单
 			if _, err := e.orderMgr.SubmitOrder(ctx, &sliceOrder); err != nil {
 				log.Printf("Failed to submit VWAP slice %d: %v", i, err)
-				slice.Status = OrderStatusFailed
+				e.setSliceStatus(slice, OrderStatusFailed)
 			} else {
-				slice.Status = OrderStatusSubmitted
+				e.setSliceStatus(slice, OrderStatusSubmitted)
+				submitted += quantity
 			}
 
-			// 等待一段时间再执行下一笔
-			time.Sleep(500 * time.Millisecond)
+			// 等待下一个时间桶
+			if i < sliceCount-1 {
+				if err := waitSlice(ctx, cancel, sliceInterval); err != nil {
+					return err
+				}
+			}
 		}
 	}
 
@@ -240,6 +319,60 @@ func (e *ExecutionEngine) executeVWAP(ctx context.Context, order *Order, config
 	return nil
 }
 
+// minVWAPFillRatio 盘口成交量落后时分片的最小缩减比例
+const minVWAPFillRatio = 0.25
+
+// buildVolumeProfile 生成归一化的成交量分布。
+// 配置了 VolumeProfile 时按其归一化；否则按 SliceCount（默认10）生成U型分布，
+// 开盘和收盘附近的桶权重约为午间的3倍。
+func buildVolumeProfile(config AlgoConfig) []float64 {
+	var total float64
+	for _, w := range config.VolumeProfile {
+		if w > 0 {
+			total += w
+		}
+	}
+
+	if total > 0 {
+		profile := make([]float64, len(config.VolumeProfile))
+		for i, w := range config.VolumeProfile {
+			if w > 0 {
+				profile[i] = w / total
+			}
+		}
+		return profile
+	}
+
+	return DefaultVolumeProfile(config.SliceCount)
+}
+
+// DefaultVolumeProfile 生成包含 buckets 个时间桶的U型日内成交量分布，各桶之和为1
+func DefaultVolumeProfile(buckets int) []float64 {
+	if buckets <= 0 {
+		buckets = 10
+	}
+
+	profile := make([]float64, buckets)
+	var total float64
+	for i := range profile {
+		x := 2*(float64(i)+0.5)/float64(buckets) - 1 // 映射到[-1, 1]
+		profile[i] = 1 + 2*x*x
+		total += profile[i]
+	}
+	for i := range profile {
+		profile[i] /= total
+	}
+	return profile
+}
+
+// sideVolume 获取订单方向对应的盘口成交量
+func sideVolume(liq *LiquidityInfo, side OrderSide) float64 {
+	if side == OrderSideBuy {
+		return liq.AskVolume
+	}
+	return liq.BidVolume
+}
+
 // executeIceberg 冰山算法执行
 func (e *ExecutionEngine) executeIceberg(ctx context.Context, order *Order, config AlgoConfig) error {
 	log.Printf("Executing order %s with iceberg algorithm", order.ID)
@@ -258,9 +391,7 @@ func (e *ExecutionEngine) executeIceberg(ctx context.Context, order *Order, conf
 	}
 
 	// 保存原始订单
-	e.ordersLock.Lock()
-	e.orders[order.ID] = order
-	e.ordersLock.Unlock()
+	cancel := e.trackOrder(order)
 
 	// 执行分片
 	remaining := order.Quantity
@@ -268,6 +399,9 @@ func (e *ExecutionEngine) executeIceberg(ctx context.Context, order *Order, conf
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-cancel:
+			log.Printf("Execution of order %s cancelled", order.ID)
+			return ErrExecutionCancelled
 		default:
 			currentSlice := sliceQuantity
 			if remaining < currentSlice*2 {
@@ -287,13 +421,14 @@ func (e *ExecutionEngine) executeIceberg(ctx context.Context, order *Order, conf
 
 			// 记录分片
 			slice := &SliceExecution{
-				OrderID:     order.ID,
-				SliceIndex:  i,
-				TotalSlices: -1, // 未知总数
-				Quantity:    currentSlice,
-				Price:       sliceOrder.Price,
-				Status:      OrderStatusPending,
-				ExecuteTime: time.Now(),
+				OrderID:      order.ID,
+				ChildOrderID: sliceOrder.ID,
+				SliceIndex:   i,
+				TotalSlices:  -1, // 未知总数
+				Quantity:     currentSlice,
+				Price:        sliceOrder.Price,
+				Status:       OrderStatusPending,
+				ExecuteTime:  time.Now(),
 			}
 
 			e.ordersLock.Lock()
@@ -303,14 +438,16 @@ func (e *ExecutionEngine) executeIceberg(ctx context.Context, order *Order, conf
 			// 提交分片订单
 			if _, err := e.orderMgr.SubmitOrder(ctx, &sliceOrder); err != nil {
 				log.Printf("Failed to submit iceberg slice %d: %v", i, err)
-				slice.Status = OrderStatusFailed
+				e.setSliceStatus(slice, OrderStatusFailed)
 			} else {
-				slice.Status = OrderStatusSubmitted
+				e.setSliceStatus(slice, OrderStatusSubmitted)
 				remaining -= currentSlice
 			}
 
 			// 等待执行完成
-			time.Sleep(1 * time.Second)
+			if err := waitSlice(ctx, cancel, 1*time.Second); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -327,23 +464,39 @@ func (e *ExecutionEngine) executePOV(ctx context.Context, order *Order, config A
 		config.Participation = 0.1 // 默认10%
 	}
 
+	// 流动性不足时避免无限等待
+	if config.Deadline <= 0 {
+		config.Deadline = e.EstimateExecutionTime(order, config)
+	}
+	deadline := time.Now().Add(config.Deadline)
+
 	// 保存原始订单
-	e.ordersLock.Lock()
-	e.orders[order.ID] = order
-	e.ordersLock.Unlock()
+	cancel := e.trackOrder(order)
 
-	// 持续执行直到完成或取消
+	// 持续执行直到完成、取消或超时
 	remaining := order.Quantity
 	for remaining > 0 {
+		if !time.Now().Before(deadline) {
+			log.Printf("POV execution for order %s exceeded deadline %v, %.2f of %.2f left unfilled",
+				order.ID, config.Deadline, remaining, order.Quantity)
+			return fmt.Errorf("%w: order %s left %.2f of %.2f unfilled",
+				ErrExecutionDeadline, order.ID, remaining, order.Quantity)
+		}
+
 		select {
 		case <-ctx.Done():
 			return ctx.Err()
+		case <-cancel:
+			log.Printf("Execution of order %s cancelled", order.ID)
+			return ErrExecutionCancelled
 		default:
 			// 获取当前市场成交量
 			liq, err := e.getLiquidity(order.Symbol)
 			if err != nil {
 				log.Printf("Failed to get liquidity: %v", err)
-				time.Sleep(1 * time.Second)
+				if err := waitSlice(ctx, cancel, povWait(deadline)); err != nil {
+					return err
+				}
 				continue
 			}
 
@@ -361,7 +514,9 @@ func (e *ExecutionEngine) executePOV(ctx context.Context, order *Order, config A
 			}
 
 			if sliceQuantity < 100 { // 最小交易量
-				time.Sleep(1 * time.Second)
+				if err := waitSlice(ctx, cancel, povWait(deadline)); err != nil {
+					return err
+				}
 				continue
 			}
 
@@ -372,8 +527,7 @@ func (e *ExecutionEngine) executePOV(ctx context.Context, order *Order, config A
 			sliceOrder.Quantity = sliceQuantity
 
 			// 设置价格
-			if e.marketData != nil {
-				newLiq, _ := e.getLiquidity(order.Symbol)
+			if newLiq, err := e.getLiquidity(order.Symbol); err == nil {
 				if order.Side == OrderSideBuy {
 					sliceOrder.Price = newLiq.AskPrice
 				} else {
@@ -383,13 +537,14 @@ func (e *ExecutionEngine) executePOV(ctx context.Context, order *Order, config A
 
 			// 记录分片
 			slice := &SliceExecution{
-				OrderID:     order.ID,
-				SliceIndex:  len(e.slices[order.ID]),
-				TotalSlices: -1,
-				Quantity:    sliceQuantity,
-				Price:       sliceOrder.Price,
-				Status:      OrderStatusPending,
-				ExecuteTime: time.Now(),
+				OrderID:      order.ID,
+				ChildOrderID: sliceOrder.ID,
+				SliceIndex:   len(e.slices[order.ID]),
+				TotalSlices:  -1,
+				Quantity:     sliceQuantity,
+				Price:        sliceOrder.Price,
+				Status:       OrderStatusPending,
+				ExecuteTime:  time.Now(),
 			}
 
 			e.ordersLock.Lock()
@@ -400,13 +555,15 @@ func (e *ExecutionEngine) executePOV(ctx context.Context, order *Order, config A
 			_, err = e.orderMgr.SubmitOrder(ctx, &sliceOrder)
 			if err != nil {
 				log.Printf("Failed to submit POV slice: %v", err)
-				slice.Status = OrderStatusFailed
+				e.setSliceStatus(slice, OrderStatusFailed)
 			} else {
-				slice.Status = OrderStatusSubmitted
+				e.setSliceStatus(slice, OrderStatusSubmitted)
 				remaining -= sliceQuantity
 			}
 
-			time.Sleep(1 * time.Second)
+			if err := waitSlice(ctx, cancel, povWait(deadline)); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -414,6 +571,122 @@ func (e *ExecutionEngine) executePOV(ctx context.Context, order *Order, config A
 	return nil
 }
 
+// povWait 计算POV下一轮等待时间，不超过剩余执行时间
+func povWait(deadline time.Time) time.Duration {
+	wait := time.Until(deadline)
+	if wait > 1*time.Second {
+		wait = 1 * time.Second
+	}
+	if wait < 0 {
+		wait = 0
+	}
+	return wait
+}
+
+// CancelExecution 取消母单的算法执行，停止提交剩余分片并撤销未成交的子单
+func (e *ExecutionEngine) CancelExecution(orderID string) error {
+	e.ordersLock.Lock()
+	cancel, ok := e.cancels[orderID]
+	if !ok {
+		e.ordersLock.Unlock()
+		return fmt.Errorf("no execution found for order %s", orderID)
+	}
+
+	select {
+	case <-cancel:
+		e.ordersLock.Unlock()
+		return fmt.Errorf("execution of order %s already cancelled", orderID)
+	default:
+		close(cancel)
+	}
+
+	if order, ok := e.orders[orderID]; ok {
+		order.Status = OrderStatusCancelled
+		order.UpdateTime = time.Now()
+	}
+	slices := make([]*SliceExecution, len(e.slices[orderID]))
+	copy(slices, e.slices[orderID])
+	e.ordersLock.Unlock()
+
+	// 撤销尚未成交的子单
+	_, failed := e.cancelChildOrders(orderID, slices)
+
+	log.Printf("Execution of order %s cancelled", orderID)
+
+	if failed > 0 {
+		return fmt.Errorf("failed to cancel %d child orders of %s", failed, orderID)
+	}
+	return nil
+}
+
+// cancelChildOrders 撤销分片中尚未成交的子单，返回已撤销的子单ID和撤销失败数
+func (e *ExecutionEngine) cancelChildOrders(orderID string, slices []*SliceExecution) ([]string, int) {
+	var cancelled []string
+	var failed int
+	for _, slice := range slices {
+		if slice.ChildOrderID == "" {
+			continue
+		}
+
+		child, err := e.orderMgr.GetOrder(slice.ChildOrderID)
+		if err != nil {
+			// 子单未成功提交
+			continue
+		}
+
+		switch child.Status {
+		case OrderStatusFilled, OrderStatusCancelled, OrderStatusRejected, OrderStatusFailed:
+			continue
+		}
+
+		if err := e.orderMgr.CancelOrder(context.Background(), slice.ChildOrderID); err != nil {
+			log.Printf("Failed to cancel child order %s of %s: %v", slice.ChildOrderID, orderID, err)
+			failed++
+			continue
+		}
+		e.setSliceStatus(slice, OrderStatusCancelled)
+		cancelled = append(cancelled, slice.ChildOrderID)
+	}
+	return cancelled, failed
+}
+
+// trackOrder 保存母单并登记取消信号
+func (e *ExecutionEngine) trackOrder(order *Order) <-chan struct{} {
+	e.ordersLock.Lock()
+	defer e.ordersLock.Unlock()
+
+	cancel := make(chan struct{})
+	e.orders[order.ID] = order
+	e.cancels[order.ID] = cancel
+	return cancel
+}
+
+// setSliceStatus 更新分片状态
+func (e *ExecutionEngine) setSliceStatus(slice *SliceExecution, status OrderStatus) {
+	e.ordersLock.Lock()
+	defer e.ordersLock.Unlock()
+
+	slice.Status = status
+	if status == OrderStatusFilled || status == OrderStatusCancelled {
+		slice.CompleteTime = time.Now()
+	}
+}
+
+// waitSlice 等待下一个分片，期间响应上下文取消和母单取消
+func waitSlice(ctx context.Context, cancel <-chan struct{}, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-cancel:
+		return ErrExecutionCancelled
+	case <-timer.C:
+		return nil
+	}
+}
+
 // getLiquidity 获取流动性
 func (e *ExecutionEngine) getLiquidity(symbol string) (*LiquidityInfo, error) {
 	if e.marketData == nil {
@@ -0,0 +1,297 @@
+package order
+
+import (
+	"context"
+	"errors"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestExecutionEngine_CancelExecution(t *testing.T) {
+	mgr := NewOrderManager(nil, nil, nil, nil, ManagerConfig{})
+	engine := NewExecutionEngine(mgr, nil)
+
+	parent := &Order{
+		ID:       "parent_twap",
+		Symbol:   "sh600000",
+		Side:     OrderSideBuy,
+		Type:     OrderTypeLimit,
+		Quantity: 1000,
+		Price:    10.50,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.ExecuteWithAlgorithm(context.Background(), parent, AlgoConfig{
+			Type:       AlgoTWAP,
+			Duration:   10 * time.Second,
+			SliceCount: 10,
+		})
+	}()
+
+	// 等待第一个分片提交
+	deadline := time.Now().Add(time.Second)
+	for {
+		if slices, err := engine.GetExecutionStatus(parent.ID); err == nil && len(slices) > 0 && slices[0].Status == OrderStatusSubmitted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("first slice was not submitted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	if err := engine.CancelExecution(parent.ID); err != nil {
+		t.Fatalf("CancelExecution failed: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != ErrExecutionCancelled {
+			t.Fatalf("expected ErrExecutionCancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("execution did not stop after cancel")
+	}
+
+	slices, err := engine.GetExecutionStatus(parent.ID)
+	if err != nil {
+		t.Fatalf("GetExecutionStatus failed: %v", err)
+	}
+	if len(slices) != 1 {
+		t.Fatalf("expected 1 slice submitted before cancel, got %d", len(slices))
+	}
+
+	child, err := mgr.GetOrder(slices[0].ChildOrderID)
+	if err != nil {
+		t.Fatalf("GetOrder failed: %v", err)
+	}
+	if child.Status != OrderStatusCancelled {
+		t.Errorf("expected child order cancelled, got %s", child.Status)
+	}
+
+	if err := engine.CancelExecution(parent.ID); err == nil {
+		t.Error("expected error cancelling an already cancelled execution")
+	}
+	if n := trackedCancels(engine); n != 0 {
+		t.Errorf("expected cancel signal released after execution ended, %d remain", n)
+	}
+}
+
+// trackedCancels 返回仍登记取消信号的母单数
+func trackedCancels(engine *ExecutionEngine) int {
+	engine.ordersLock.RLock()
+	defer engine.ordersLock.RUnlock()
+	return len(engine.cancels)
+}
+
+func TestExecutionEngine_POVDeadline(t *testing.T) {
+	mgr := NewOrderManager(nil, nil, nil, nil, ManagerConfig{})
+	thinMarket := func(symbol string) (*LiquidityInfo, error) {
+		return &LiquidityInfo{Symbol: symbol, AskPrice: 10, AskVolume: 200, BidPrice: 9.9, BidVolume: 200}, nil
+	}
+	engine := NewExecutionEngine(mgr, thinMarket)
+
+	parent := &Order{
+		ID:       "parent_pov",
+		Symbol:   "sh600000",
+		Side:     OrderSideBuy,
+		Type:     OrderTypeMarket,
+		Quantity: 10000,
+	}
+
+	start := time.Now()
+	err := engine.ExecuteWithAlgorithm(context.Background(), parent, AlgoConfig{
+		Type:          AlgoPOV,
+		Participation: 0.1,
+		Deadline:      50 * time.Millisecond,
+	})
+	if !errors.Is(err, ErrExecutionDeadline) {
+		t.Fatalf("expected ErrExecutionDeadline, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected POV to stop near its deadline, took %v", elapsed)
+	}
+	if n := trackedCancels(engine); n != 0 {
+		t.Errorf("expected cancel signal released after execution ended, %d remain", n)
+	}
+}
+
+func TestExecutionEngine_VWAPVolumeProfile(t *testing.T) {
+	mgr := NewOrderManager(nil, nil, nil, nil, ManagerConfig{})
+	// 第一次调用用于校准窗口总成交量（2000/0.2=10000），之后每个桶调用一次
+	volumes := []float64{2000, 1500, 5000}
+	calls := 0
+	marketData := func(symbol string) (*LiquidityInfo, error) {
+		volume := volumes[calls]
+		calls++
+		return &LiquidityInfo{Symbol: symbol, AskPrice: 10, AskVolume: volume}, nil
+	}
+	engine := NewExecutionEngine(mgr, marketData)
+
+	parent := &Order{ID: "parent_vwap", Symbol: "sh600000", Side: OrderSideBuy, Type: OrderTypeMarket, Quantity: 1000}
+	err := engine.ExecuteWithAlgorithm(context.Background(), parent, AlgoConfig{
+		Type:          AlgoVWAP,
+		Duration:      30 * time.Millisecond,
+		VolumeProfile: []float64{0.2, 0.3, 0.5},
+	})
+	if err != nil {
+		t.Fatalf("VWAP execution failed: %v", err)
+	}
+
+	slices, err := engine.GetExecutionStatus(parent.ID)
+	if err != nil {
+		t.Fatalf("GetExecutionStatus failed: %v", err)
+	}
+	if len(slices) != 3 {
+		t.Fatalf("expected 3 slices, got %d", len(slices))
+	}
+
+	// 第二个桶盘口成交量只有预期(3000)的一半，分片减半，缺口由最后一笔补齐
+	want := []float64{200, 150, 650}
+	for i, slice := range slices {
+		if math.Abs(slice.Quantity-want[i]) > 1e-9 {
+			t.Errorf("slice %d: expected quantity %.2f, got %.2f", i, want[i], slice.Quantity)
+		}
+	}
+}
+
+func TestDefaultVolumeProfile(t *testing.T) {
+	profile := DefaultVolumeProfile(8)
+	var total float64
+	for _, w := range profile {
+		total += w
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Fatalf("expected profile to sum to 1, got %f", total)
+	}
+	if profile[0] <= profile[3] || profile[7] <= profile[4] {
+		t.Fatalf("expected U-shaped profile, got %v", profile)
+	}
+}
+
+func TestExecutionEngine_ShutdownDrainsTWAP(t *testing.T) {
+	mgr := NewOrderManager(nil, nil, nil, nil, ManagerConfig{})
+	engine := NewExecutionEngine(mgr, nil)
+	stateFile := filepath.Join(t.TempDir(), "state", "partial_executions.json")
+	engine.SetDrainConfig(DrainConfig{Timeout: time.Second, StateFile: stateFile})
+
+	parent := &Order{
+		ID:       "parent_twap_shutdown",
+		Symbol:   "sh600000",
+		Side:     OrderSideBuy,
+		Type:     OrderTypeLimit,
+		Quantity: 1000,
+		Price:    10.50,
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- engine.ExecuteWithAlgorithm(context.Background(), parent, AlgoConfig{
+			Type:       AlgoTWAP,
+			Duration:   time.Second,
+			SliceCount: 10,
+		})
+	}()
+
+	// 等待前两个分片提交
+	deadline := time.Now().Add(2 * time.Second)
+	for {
+		if slices, err := engine.GetExecutionStatus(parent.ID); err == nil && len(slices) >= 2 && slices[1].Status == OrderStatusSubmitted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("slices were not submitted")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	partials, err := engine.Shutdown(context.Background())
+	if err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected algorithm to stop with context.Canceled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("algorithm did not stop after shutdown")
+	}
+
+	slices, err := engine.GetExecutionStatus(parent.ID)
+	if err != nil {
+		t.Fatalf("GetExecutionStatus failed: %v", err)
+	}
+	for _, slice := range slices {
+		child, err := mgr.GetOrder(slice.ChildOrderID)
+		if err != nil {
+			t.Fatalf("GetOrder failed: %v", err)
+		}
+		if child.Status != OrderStatusCancelled {
+			t.Errorf("child %s status %s, want cancelled", child.ID, child.Status)
+		}
+	}
+
+	if len(partials) != 1 {
+		t.Fatalf("expected 1 partial execution, got %d", len(partials))
+	}
+	partial := partials[0]
+	if partial.OrderID != parent.ID || partial.Algorithm != AlgoTWAP {
+		t.Errorf("unexpected partial execution %+v", partial)
+	}
+	if len(partial.Slices) != len(slices) || len(partial.CancelledChildren) != len(slices) {
+		t.Errorf("partial has %d slices and %d cancelled children, want %d", len(partial.Slices), len(partial.CancelledChildren), len(slices))
+	}
+	if want := float64(len(slices)) * 100; math.Abs(partial.SubmittedQuantity-want) > 1e-9 {
+		t.Errorf("submitted quantity %.2f, want %.2f", partial.SubmittedQuantity, want)
+	}
+	if partial.RemainingQuantity != 1000 {
+		t.Errorf("remaining quantity %.2f, want 1000", partial.RemainingQuantity)
+	}
+
+	// 重启后可以读取部分执行状态
+	saved, err := LoadPartialExecutions(stateFile)
+	if err != nil {
+		t.Fatalf("LoadPartialExecutions failed: %v", err)
+	}
+	if len(saved) != 1 || saved[0].OrderID != parent.ID || saved[0].RemainingQuantity != partial.RemainingQuantity {
+		t.Errorf("saved state %+v does not match %+v", saved, partial)
+	}
+
+	// 关闭后不再提交分片，也不接受新的执行
+	time.Sleep(250 * time.Millisecond)
+	if after, _ := engine.GetExecutionStatus(parent.ID); len(after) != len(slices) {
+		t.Errorf("slices submitted after shutdown: %d -> %d", len(slices), len(after))
+	}
+	err = engine.ExecuteWithAlgorithm(context.Background(), &Order{ID: "late", Symbol: "sh600000", Side: OrderSideBuy, Type: OrderTypeMarket, Quantity: 100}, AlgoConfig{Type: AlgoMarket})
+	if !errors.Is(err, ErrEngineShuttingDown) {
+		t.Errorf("expected ErrEngineShuttingDown, got %v", err)
+	}
+}
+
+func TestExecutionEngine_ShutdownClearsSavedState(t *testing.T) {
+	stateFile := filepath.Join(t.TempDir(), "partial_executions.json")
+	previous := []PartialExecution{{OrderID: "old_parent", Symbol: "sh600000", Quantity: 1000, RemainingQuantity: 600}}
+	if err := savePartialExecutions(stateFile, previous); err != nil {
+		t.Fatalf("savePartialExecutions failed: %v", err)
+	}
+
+	engine := NewExecutionEngine(NewOrderManager(nil, nil, nil, nil, ManagerConfig{}), nil)
+	engine.SetDrainConfig(DrainConfig{Timeout: time.Second, StateFile: stateFile})
+	if _, err := engine.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown failed: %v", err)
+	}
+
+	// 没有执行中的母单时关闭，重启后不再读到上次的部分执行
+	saved, err := LoadPartialExecutions(stateFile)
+	if err != nil {
+		t.Fatalf("LoadPartialExecutions failed: %v", err)
+	}
+	if len(saved) != 0 {
+		t.Errorf("expected cleared state, got %+v", saved)
+	}
+}
@@ -4,15 +4,38 @@ import (
     "context"
     "fmt"
     "log"
+    "sync"
     "time"
 )
 
+// ErrTradeCooldown 股票处于交易冷却期或黑名单中
+var ErrTradeCooldown = fmt.Errorf("交易冷却中")
+
+// TradeCooldown 限制同一股票交易频率的冷却检查，由risk.CooldownRisk实现
+type TradeCooldown interface {
+    // IsAllowed 判断股票当前是否允许交易，不允许时返回原因
+    IsAllowed(symbol string) (bool, string)
+    // RecordTrade 记录一次已提交的交易
+    RecordTrade(symbol string, tradeType string, price float64)
+    // BlacklistAfterStopLoss 止损后将股票加入黑名单，冷却期内不再开仓
+    BlacklistAfterStopLoss(symbol string)
+}
+
 // OrderExecutor 订单执行引擎
 type OrderExecutor struct {
     connector    *BrokerConnector
     riskManager  *RiskManager
     positionMgr  *PositionManager
     tradeHistory *TradeHistory
+    clientOrders *clientOrderCache // 按客户端订单ID去重
+    brackets     *bracketBook      // 括号单（OCO止盈止损）
+    counters     orderCounters     // 委托提交、成交、失败计数
+
+    fillMu       sync.Mutex
+    fillHandlers []func(Trade)       // 新成交回调
+    cooldown     TradeCooldown       // 交易冷却检查，为nil时不限制
+    syncedDay    string              // syncedTrades对应的交易日，换日后清空
+    syncedTrades map[string]struct{} // 当日已同步的成交编号，重复返回的成交不重复处理
 }
 
 // NewOrderExecutor 创建订单执行器
@@ -27,6 +50,8 @@ func NewOrderExecutor(
         riskManager:  riskManager,
         positionMgr:  positionMgr,
         tradeHistory: tradeHistory,
+        clientOrders: newClientOrderCache(DefaultClientOrderTTL),
+        brackets:     newBracketBook(),
     }
 }
 
@@ -41,25 +66,35 @@ func (oe *OrderExecutor) ExecuteBuy(ctx context.Context, symbol string, price fl
     }
 
     if err := oe.riskManager.CheckBeforeOrder(ctx, orderReq); err != nil {
-        return "", fmt.Errorf("风险检查失败: %w", err)
+        return "", oe.orderFailed(fmt.Errorf("风险检查失败: %w", err))
+    }
+    if err := oe.CheckCooldown(symbol); err != nil {
+        return "", oe.orderFailed(err)
     }
 
     // 2. 计算下单数量（按手数）
     quantity := orderReq.CalculateQuantity()
     if quantity <= 0 {
-        return "", fmt.Errorf("下单数量不足: 金额 %.2f, 价格 %.2f", amount, price)
+        return "", oe.orderFailed(fmt.Errorf("下单数量不足: 金额 %.2f, 价格 %.2f", amount, price))
     }
 
     // 3. 下单
+    return oe.placeBuy(ctx, symbol, price, quantity)
+}
+
+// placeBuy 按股数提交买入委托并记录订单，调用方负责风险检查
+func (oe *OrderExecutor) placeBuy(ctx context.Context, symbol string, price float64, quantity int) (string, error) {
     broker := oe.connector.GetBroker()
     orderID, err := broker.Buy(ctx, symbol, price, quantity)
     if err != nil {
-        return "", fmt.Errorf("买入失败: %w", err)
+        return "", oe.orderFailed(fmt.Errorf("买入失败: %w", err))
     }
+    oe.counters.submitted.Add(1)
 
     log.Printf("买入订单提交: %s, 价格: %.2f, 数量: %d, 订单ID: %s", symbol, price, quantity, orderID)
+    oe.recordCooldownTrade(symbol, OrderTypeBuy, price)
 
-    // 4. 记录订单
+    // 记录订单
     if oe.tradeHistory != nil {
         oe.recordOrder(Order{
             OrderID:   orderID,
@@ -75,26 +110,71 @@ func (oe *OrderExecutor) ExecuteBuy(ctx context.Context, symbol string, price fl
     return orderID, nil
 }
 
-// ExecuteSell 执行卖出
+// SetTradeCooldown 设置交易冷却检查
+func (oe *OrderExecutor) SetTradeCooldown(cooldown TradeCooldown) {
+    oe.fillMu.Lock()
+    defer oe.fillMu.Unlock()
+
+    oe.cooldown = cooldown
+}
+
+// tradeCooldown 获取交易冷却检查
+func (oe *OrderExecutor) tradeCooldown() TradeCooldown {
+    oe.fillMu.Lock()
+    defer oe.fillMu.Unlock()
+
+    return oe.cooldown
+}
+
+// CheckCooldown 检查股票是否处于交易冷却期，未设置冷却检查时总是通过
+func (oe *OrderExecutor) CheckCooldown(symbol string) error {
+    cooldown := oe.tradeCooldown()
+    if cooldown == nil {
+        return nil
+    }
+    if allowed, reason := cooldown.IsAllowed(symbol); !allowed {
+        return fmt.Errorf("%w: %s %s", ErrTradeCooldown, symbol, reason)
+    }
+    return nil
+}
+
+// recordCooldownTrade 向交易冷却登记已提交的委托
+func (oe *OrderExecutor) recordCooldownTrade(symbol, tradeType string, price float64) {
+    if cooldown := oe.tradeCooldown(); cooldown != nil {
+        cooldown.RecordTrade(symbol, tradeType, price)
+    }
+}
+
+// ExecuteSell 执行卖出，受交易冷却限制
 func (oe *OrderExecutor) ExecuteSell(ctx context.Context, symbol string, price float64, quantity int) (string, error) {
+    if err := oe.CheckCooldown(symbol); err != nil {
+        return "", oe.orderFailed(err)
+    }
+    return oe.placeSell(ctx, symbol, price, quantity)
+}
+
+// placeSell 检查可用持仓后提交卖出委托并记录订单，止损止盈等保护性卖出直接调用，不受交易冷却限制
+func (oe *OrderExecutor) placeSell(ctx context.Context, symbol string, price float64, quantity int) (string, error) {
     // 1. 检查持仓
     posState, err := oe.positionMgr.GetPosition(symbol)
     if err != nil {
-        return "", fmt.Errorf("未找到持仓: %w", err)
+        return "", oe.orderFailed(fmt.Errorf("未找到持仓: %w", err))
     }
 
     if quantity > posState.Available {
-        return "", fmt.Errorf("可用持仓不足: 持有 %d, 可用 %d, 卖出 %d", posState.Amount, posState.Available, quantity)
+        return "", oe.orderFailed(fmt.Errorf("可用持仓不足: 持有 %d, 可用 %d, 卖出 %d", posState.Amount, posState.Available, quantity))
     }
 
     // 2. 下单
     broker := oe.connector.GetBroker()
     orderID, err := broker.Sell(ctx, symbol, price, quantity)
     if err != nil {
-        return "", fmt.Errorf("卖出失败: %w", err)
+        return "", oe.orderFailed(fmt.Errorf("卖出失败: %w", err))
     }
+    oe.counters.submitted.Add(1)
 
     log.Printf("卖出订单提交: %s, 价格: %.2f, 数量: %d, 订单ID: %s", symbol, price, quantity, orderID)
+    oe.recordCooldownTrade(symbol, OrderTypeSell, price)
 
     // 3. 记录订单
     if oe.tradeHistory != nil {
@@ -142,15 +222,39 @@ func (oe *OrderExecutor) ExecuteStopLoss(ctx context.Context, symbol string, cur
     }
 
     // 全部卖出止损
-    _, err = oe.ExecuteSell(ctx, symbol, currentPrice, posState.Amount)
+    _, err = oe.placeSell(ctx, symbol, currentPrice, posState.Amount)
     if err != nil {
         return fmt.Errorf("止损卖出失败: %w", err)
     }
+    if cooldown := oe.tradeCooldown(); cooldown != nil {
+        cooldown.BlacklistAfterStopLoss(symbol)
+    }
 
     log.Printf("止损执行成功: %s, 价格: %.2f, 数量: %d", symbol, currentPrice, posState.Amount)
     return nil
 }
 
+// ExecuteTakeProfit 执行止盈
+func (oe *OrderExecutor) ExecuteTakeProfit(ctx context.Context, symbol string, currentPrice float64) error {
+    // 获取持仓
+    posState, err := oe.positionMgr.GetPosition(symbol)
+    if err != nil {
+        return fmt.Errorf("未找到持仓: %w", err)
+    }
+
+    // 卖出全部可用持仓锁定收益，当日买入的部分受T+1限制无法卖出
+    if posState.Available <= 0 {
+        return fmt.Errorf("止盈卖出失败: %s 无可用持仓", symbol)
+    }
+    _, err = oe.placeSell(ctx, symbol, currentPrice, posState.Available)
+    if err != nil {
+        return fmt.Errorf("止盈卖出失败: %w", err)
+    }
+
+    log.Printf("止盈执行成功: %s, 价格: %.2f, 数量: %d", symbol, currentPrice, posState.Available)
+    return nil
+}
+
 // recordOrder 记录订单
 func (oe *OrderExecutor) recordOrder(order Order) {
     // 这里简单记录，实际应该保存到数据库
@@ -174,16 +278,54 @@ func (oe *OrderExecutor) CheckOrderStatus(ctx context.Context, orderID string) (
     return nil, fmt.Errorf("未找到订单: %s", orderID)
 }
 
-// SyncTrades 同步成交记录
+// AddFillHandler 注册成交回调，SyncTrades对每条新同步到的成交按注册顺序各调用一次
+func (oe *OrderExecutor) AddFillHandler(handler func(Trade)) {
+    oe.fillMu.Lock()
+    defer oe.fillMu.Unlock()
+
+    oe.fillHandlers = append(oe.fillHandlers, handler)
+}
+
+// newTrades 过滤掉当日已同步过的成交，券商每次返回当日全部成交
+func (oe *OrderExecutor) newTrades(trades []Trade) ([]Trade, []func(Trade)) {
+    oe.fillMu.Lock()
+    defer oe.fillMu.Unlock()
+
+    var fresh []Trade
+    for _, trade := range trades {
+        day := trade.TradeTime.Format("2006-01-02")
+        if oe.syncedDay != day || oe.syncedTrades == nil {
+            oe.syncedDay = day
+            oe.syncedTrades = make(map[string]struct{})
+        }
+        key := trade.TradeID
+        if key == "" {
+            key = trade.OrderID + "@" + trade.TradeTime.String()
+        }
+        if _, ok := oe.syncedTrades[key]; ok {
+            continue
+        }
+        oe.syncedTrades[key] = struct{}{}
+        fresh = append(fresh, trade)
+    }
+    handlers := make([]func(Trade), len(oe.fillHandlers))
+    copy(handlers, oe.fillHandlers)
+    return fresh, handlers
+}
+
+// SyncTrades 同步成交记录，只处理上次同步之后的新成交
 func (oe *OrderExecutor) SyncTrades(ctx context.Context) error {
     broker := oe.connector.GetBroker()
     trades, err := broker.GetTodayTrades(ctx)
     if err != nil {
         return err
     }
+    trades, handlers := oe.newTrades(trades)
 
     // 更新持仓和记录交易
     for _, trade := range trades {
+        oe.counters.recordFill(trade)
+
         // 更新持仓
         _ = oe.positionMgr.UpdatePosition(trade)
 
@@ -200,6 +342,10 @@ func (oe *OrderExecutor) SyncTrades(ctx context.Context) error {
                 Commission: trade.Commission,
             })
         }
+
+        for _, onFill := range handlers {
+            onFill(trade)
+        }
     }
 
     log.Printf("同步 %d 条成交记录", len(trades))
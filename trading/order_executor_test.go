@@ -0,0 +1,63 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// stubCooldown 记录调用的交易冷却检查，blocked中的股票不允许交易
+type stubCooldown struct {
+	blocked     map[string]bool
+	recorded    []string
+	blacklisted []string
+}
+
+func (c *stubCooldown) IsAllowed(symbol string) (bool, string) {
+	if c.blocked[symbol] {
+		return false, "cooling down"
+	}
+	return true, ""
+}
+
+func (c *stubCooldown) RecordTrade(symbol string, tradeType string, price float64) {
+	c.recorded = append(c.recorded, tradeType+" "+symbol)
+}
+
+func (c *stubCooldown) BlacklistAfterStopLoss(symbol string) {
+	c.blacklisted = append(c.blacklisted, symbol)
+}
+
+func TestOrderExecutorTradeCooldown(t *testing.T) {
+	oe, broker, pm := newBracketTestExecutor(t)
+	broker.positions = []Position{{Symbol: "sh600000", Amount: 1000, Available: 1000, CostPrice: 10, CurrentPrice: 9}}
+	pm.SyncPositions()
+	cooldown := &stubCooldown{blocked: map[string]bool{"sh600000": true}}
+	oe.SetTradeCooldown(cooldown)
+	ctx := context.Background()
+
+	if _, err := oe.ExecuteBuy(ctx, "sh600000", 10, 5000); !errors.Is(err, ErrTradeCooldown) {
+		t.Errorf("buy during cooldown: err = %v, want ErrTradeCooldown", err)
+	}
+	if _, err := oe.ExecuteSell(ctx, "sh600000", 10, 100); !errors.Is(err, ErrTradeCooldown) {
+		t.Errorf("sell during cooldown: err = %v, want ErrTradeCooldown", err)
+	}
+	if len(broker.orders) != 0 {
+		t.Fatalf("cooldown let %d orders through", len(broker.orders))
+	}
+
+	// 止损不受冷却限制，成交后将股票加入黑名单
+	if err := oe.ExecuteStopLoss(ctx, "sh600000", 9); err != nil {
+		t.Fatalf("ExecuteStopLoss: %v", err)
+	}
+	if len(broker.orders) != 1 || len(cooldown.blacklisted) != 1 || cooldown.blacklisted[0] != "sh600000" {
+		t.Errorf("stop loss orders = %+v, blacklisted = %v", broker.orders, cooldown.blacklisted)
+	}
+
+	if _, err := oe.ExecuteBuy(ctx, "sz000001", 10, 5000); err != nil {
+		t.Fatalf("ExecuteBuy: %v", err)
+	}
+	if want := []string{"sell sh600000", "buy sz000001"}; len(cooldown.recorded) != 2 || cooldown.recorded[0] != want[0] || cooldown.recorded[1] != want[1] {
+		t.Errorf("recorded trades = %v, want %v", cooldown.recorded, want)
+	}
+}
@@ -0,0 +1,57 @@
+package trading
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// OrderStats 进程启动以来的委托计数
+type OrderStats struct {
+	Submitted int64 `json:"submitted"` // 成功提交到券商的委托
+	Filled    int64 `json:"filled"`    // 同步到成交的委托，按订单ID去重
+	Failed    int64 `json:"failed"`    // 风险检查未通过、参数不合法或券商拒绝的委托
+}
+
+// orderCounters 订单执行计数器
+type orderCounters struct {
+	submitted atomic.Int64
+	filled    atomic.Int64
+	failed    atomic.Int64
+
+	mu           sync.Mutex
+	filledDay    string              // filledOrders对应的交易日，换日后清空
+	filledOrders map[string]struct{} // 当日已计入成交的订单ID，同步成交时重复返回的记录不重复计数
+}
+
+// recordFill 记录一条成交，同一订单只计一次
+func (c *orderCounters) recordFill(trade Trade) {
+	day := trade.TradeTime.Format("2006-01-02")
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.filledDay != day || c.filledOrders == nil {
+		c.filledDay = day
+		c.filledOrders = make(map[string]struct{})
+	}
+	if _, ok := c.filledOrders[trade.OrderID]; ok {
+		return
+	}
+	c.filledOrders[trade.OrderID] = struct{}{}
+	c.filled.Add(1)
+}
+
+// orderFailed 记录一次下单失败并原样返回err
+func (oe *OrderExecutor) orderFailed(err error) error {
+	oe.counters.failed.Add(1)
+	return err
+}
+
+// GetOrderStats 获取委托计数
+func (oe *OrderExecutor) GetOrderStats() OrderStats {
+	return OrderStats{
+		Submitted: oe.counters.submitted.Load(),
+		Filled:    oe.counters.filled.Load(),
+		Failed:    oe.counters.failed.Load(),
+	}
+}
@@ -0,0 +1,87 @@
+package trading
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// tradesBroker 返回固定的当日成交
+type tradesBroker struct {
+	fakeBroker
+	trades []Trade
+}
+
+func (b *tradesBroker) GetTodayTrades(ctx context.Context) ([]Trade, error) {
+	return b.trades, nil
+}
+
+func TestOrderExecutorCountsOrders(t *testing.T) {
+	broker := &tradesBroker{}
+	broker.balance = Balance{TotalAssets: 100000, AvailableCash: 100000}
+	connector := &BrokerConnector{broker: broker}
+
+	config := DefaultRiskConfig
+	config.InitialCapital = 100000
+	rm := NewRiskManager(config, connector, nil)
+	pm := NewPositionManager(connector)
+	oe := NewOrderExecutor(connector, rm, pm, nil)
+	ctx := context.Background()
+
+	if _, err := oe.ExecuteBuy(ctx, "sh600000", 10, 10000); err != nil {
+		t.Fatalf("ExecuteBuy: %v", err)
+	}
+	// 无持仓卖出失败
+	if _, err := oe.ExecuteSell(ctx, "sh600036", 10, 100); err == nil {
+		t.Fatal("ExecuteSell without position succeeded")
+	}
+
+	// 同一订单的两笔成交及重复同步只计一次
+	now := time.Now()
+	broker.trades = []Trade{
+		{TradeID: "t1", OrderID: "buy_sh600000", Symbol: "sh600000", Type: OrderTypeBuy, Price: 10, Amount: 500, TradeTime: now},
+		{TradeID: "t2", OrderID: "buy_sh600000", Symbol: "sh600000", Type: OrderTypeBuy, Price: 10, Amount: 500, TradeTime: now},
+	}
+	for i := 0; i < 2; i++ {
+		if err := oe.SyncTrades(ctx); err != nil {
+			t.Fatalf("SyncTrades: %v", err)
+		}
+	}
+
+	want := OrderStats{Submitted: 1, Filled: 1, Failed: 1}
+	if got := oe.GetOrderStats(); got != want {
+		t.Fatalf("stats = %+v, want %+v", got, want)
+	}
+}
+
+func TestSyncTradesReportsEachFillOnce(t *testing.T) {
+	broker := &tradesBroker{}
+	connector := &BrokerConnector{broker: broker}
+	pm := NewPositionManager(connector)
+	oe := NewOrderExecutor(connector, NewRiskManager(DefaultRiskConfig, connector, nil), pm, nil)
+	ctx := context.Background()
+
+	var fills []string
+	oe.AddFillHandler(func(trade Trade) { fills = append(fills, trade.TradeID) })
+
+	now := time.Now()
+	broker.trades = []Trade{
+		{TradeID: "t1", OrderID: "o1", Symbol: "sh600000", Type: OrderTypeBuy, Price: 10, Amount: 500, TradeTime: now},
+	}
+	if err := oe.SyncTrades(ctx); err != nil {
+		t.Fatalf("SyncTrades: %v", err)
+	}
+	broker.trades = append(broker.trades,
+		Trade{TradeID: "t2", OrderID: "o1", Symbol: "sh600000", Type: OrderTypeBuy, Price: 10, Amount: 300, TradeTime: now})
+	if err := oe.SyncTrades(ctx); err != nil {
+		t.Fatalf("SyncTrades: %v", err)
+	}
+
+	if len(fills) != 2 || fills[0] != "t1" || fills[1] != "t2" {
+		t.Errorf("fills = %v, want t1 then t2 once each", fills)
+	}
+	// 重复返回的成交不重复计入持仓
+	if pos, err := pm.GetPosition("sh600000"); err != nil || pos.Amount != 800 {
+		t.Errorf("position = %+v, %v; want 800 shares", pos, err)
+	}
+}
@@ -0,0 +1,514 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// BrokerTypePaper 模拟盘券商类型
+const BrokerTypePaper = "paper"
+
+const (
+	// DefaultPaperInitialCash 模拟盘默认初始资金
+	DefaultPaperInitialCash = 100000.0
+	// paperCommissionRate 模拟盘佣金费率
+	paperCommissionRate = 0.0003
+	// paperMinCommission 模拟盘每笔最低佣金
+	paperMinCommission = 5.0
+	// paperStampTax 模拟盘卖出印花税率
+	paperStampTax = 0.0005
+	// paperIDPrefix 模拟盘委托和成交编号前缀，恢复状态时用于区分实盘记录
+	paperIDPrefix = "paper_"
+)
+
+// PriceFunc 获取股票最新价格
+type PriceFunc func(symbol string) (float64, error)
+
+// paperPosition 模拟盘持仓
+type paperPosition struct {
+	amount      int
+	costPrice   float64
+	lastPrice   float64
+	boughtDay   string // 最近买入日期，当日买入部分受T+1限制
+	boughtToday int
+	frozen      int // 卖出委托冻结的数量
+}
+
+// PaperBroker 模拟盘券商：以虚拟资金按实时行情撮合限价委托，
+// 买入委托在行情价不高于委托价时按行情价成交，卖出反之；撮合在查询委托、持仓、余额时进行，
+// 获取行情期间不持有锁。成交和委托写入TradeHistory，重启后按历史成交恢复资金和持仓
+type PaperBroker struct {
+	mu           sync.Mutex
+	connected    bool
+	initialCash  float64
+	cash         float64 // 可用资金，不含冻结
+	frozenCash   float64 // 买入委托冻结的资金
+	positions    map[string]*paperPosition
+	orders       []*Order
+	trades       []Trade
+	seq          int
+	price        PriceFunc
+	tradeHistory *TradeHistory
+	now          func() time.Time
+}
+
+// NewPaperBroker 创建模拟盘券商，initialCash不大于0时使用DefaultPaperInitialCash
+func NewPaperBroker(initialCash float64) *PaperBroker {
+	if initialCash <= 0 {
+		initialCash = DefaultPaperInitialCash
+	}
+	return &PaperBroker{
+		initialCash: initialCash,
+		cash:        initialCash,
+		positions:   make(map[string]*paperPosition),
+		now:         time.Now,
+	}
+}
+
+// SetPriceProvider 设置撮合使用的行情价格来源
+func (pb *PaperBroker) SetPriceProvider(price PriceFunc) {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.price = price
+}
+
+// SetTradeHistory 设置交易历史，并按其中的模拟盘成交恢复资金和持仓
+func (pb *PaperBroker) SetTradeHistory(th *TradeHistory) error {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	pb.tradeHistory = th
+	if th == nil {
+		return nil
+	}
+
+	records, err := th.GetTrades(math.MaxInt32)
+	if err != nil {
+		return fmt.Errorf("加载模拟盘成交记录失败: %w", err)
+	}
+
+	// 记录按成交时间倒序返回
+	restored := 0
+	for i := len(records) - 1; i >= 0; i-- {
+		record := records[i]
+		if !strings.HasPrefix(record.TradeID, paperIDPrefix) {
+			continue
+		}
+		pb.applyFill(record.Symbol, record.Type, record.Price, int(record.Volume), record.Commission, record.TradeTime)
+		restored++
+	}
+	// 委托编号从历史最大编号继续，避免覆盖已有记录
+	orders, err := th.GetOrders(math.MaxInt32)
+	if err != nil {
+		return fmt.Errorf("加载模拟盘委托记录失败: %w", err)
+	}
+	for _, order := range orders {
+		var seq int
+		if _, err := fmt.Sscanf(order.OrderID, paperIDPrefix+"%d", &seq); err == nil && seq > pb.seq {
+			pb.seq = seq
+		}
+	}
+	if restored > 0 {
+		log.Printf("模拟盘已恢复 %d 条成交，可用资金 %.2f，持仓 %d 只", restored, pb.cash, len(pb.positions))
+	}
+	return nil
+}
+
+// Login 模拟盘无需登录，总是成功
+func (pb *PaperBroker) Login(ctx context.Context, username, password, exePath string) error {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.connected = true
+	return nil
+}
+
+// Logout 登出
+func (pb *PaperBroker) Logout(ctx context.Context) error {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.connected = false
+	return nil
+}
+
+// IsConnected 检查连接状态
+func (pb *PaperBroker) IsConnected() bool {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	return pb.connected
+}
+
+// Buy 提交限价买入委托，冻结委托金额和预估佣金
+func (pb *PaperBroker) Buy(ctx context.Context, symbol string, price float64, amount int) (string, error) {
+	if err := validatePaperOrder(price, amount); err != nil {
+		return "", err
+	}
+	if amount%100 != 0 {
+		return "", fmt.Errorf("买入数量须为100股的整数倍: %d", amount)
+	}
+	prices := pb.fetchPrices([]string{symbol})
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	required := price*float64(amount) + paperCommission(OrderTypeBuy, price, amount)
+	if required > pb.cash {
+		return "", fmt.Errorf("%w: 需要 %.2f, 可用 %.2f", ErrInsufficientCash, required, pb.cash)
+	}
+	pb.cash -= required
+	pb.frozenCash += required
+
+	order := pb.newOrder(symbol, OrderTypeBuy, price, amount)
+	pb.matchOrder(order, prices)
+	return order.OrderID, nil
+}
+
+// Sell 提交限价卖出委托，冻结可用持仓
+func (pb *PaperBroker) Sell(ctx context.Context, symbol string, price float64, amount int) (string, error) {
+	if err := validatePaperOrder(price, amount); err != nil {
+		return "", err
+	}
+	prices := pb.fetchPrices([]string{symbol})
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	pos, ok := pb.positions[symbol]
+	if !ok {
+		return "", fmt.Errorf("未持有 %s", symbol)
+	}
+	if available := pb.available(pos); amount > available {
+		return "", fmt.Errorf("可用持仓不足: 可用 %d, 卖出 %d", available, amount)
+	}
+	pos.frozen += amount
+
+	order := pb.newOrder(symbol, OrderTypeSell, price, amount)
+	pb.matchOrder(order, prices)
+	return order.OrderID, nil
+}
+
+// Cancel 撤销未成交委托，释放冻结的资金或持仓
+func (pb *PaperBroker) Cancel(ctx context.Context, orderID string) error {
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	for _, order := range pb.orders {
+		if order.OrderID != orderID {
+			continue
+		}
+		if order.Status != "已报" {
+			return fmt.Errorf("委托 %s 当前状态为%s，无法撤销", orderID, order.Status)
+		}
+		pb.release(order)
+		order.Status = "已撤"
+		pb.saveOrder(order)
+		return nil
+	}
+	return fmt.Errorf("未找到委托: %s", orderID)
+}
+
+// GetBalance 获取账户余额，按最新行情计算持仓市值
+func (pb *PaperBroker) GetBalance(ctx context.Context) (*Balance, error) {
+	pb.refresh()
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	marketValue := 0.0
+	for _, pos := range pb.positions {
+		marketValue += float64(pos.amount) * pos.lastPrice
+	}
+	total := pb.cash + pb.frozenCash + marketValue
+	return &Balance{
+		TotalAssets:   total,
+		Cash:          pb.cash,
+		MarketValue:   marketValue,
+		TotalProfit:   total - pb.initialCash,
+		AvailableCash: pb.cash,
+		FrozenCash:    pb.frozenCash,
+		UpdateTime:    pb.now().Format("2006-01-02 15:04:05"),
+	}, nil
+}
+
+// GetPositions 获取持仓，按股票代码排序
+func (pb *PaperBroker) GetPositions(ctx context.Context) ([]Position, error) {
+	pb.refresh()
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	positions := make([]Position, 0, len(pb.positions))
+	updateTime := pb.now().Format("2006-01-02 15:04:05")
+	for symbol, pos := range pb.positions {
+		marketValue := float64(pos.amount) * pos.lastPrice
+		profit := (pos.lastPrice - pos.costPrice) * float64(pos.amount)
+		profitPercent := 0.0
+		if pos.costPrice > 0 {
+			profitPercent = (pos.lastPrice/pos.costPrice - 1) * 100
+		}
+		positions = append(positions, Position{
+			Symbol:        symbol,
+			Amount:        pos.amount,
+			Available:     pb.available(pos),
+			CostPrice:     pos.costPrice,
+			CurrentPrice:  pos.lastPrice,
+			MarketValue:   marketValue,
+			Profit:        profit,
+			ProfitPercent: profitPercent,
+			UpdateTime:    updateTime,
+		})
+	}
+	sort.Slice(positions, func(i, j int) bool { return positions[i].Symbol < positions[j].Symbol })
+	return positions, nil
+}
+
+// GetOrders 获取当日委托
+func (pb *PaperBroker) GetOrders(ctx context.Context) ([]Order, error) {
+	pb.refresh()
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	today := pb.now().Format("2006-01-02")
+	var orders []Order
+	for _, order := range pb.orders {
+		if order.OrderTime.Format("2006-01-02") == today {
+			orders = append(orders, *order)
+		}
+	}
+	return orders, nil
+}
+
+// GetTodayTrades 获取当日成交
+func (pb *PaperBroker) GetTodayTrades(ctx context.Context) ([]Trade, error) {
+	pb.refresh()
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+
+	today := pb.now().Format("2006-01-02")
+	var trades []Trade
+	for _, trade := range pb.trades {
+		if trade.TradeTime.Format("2006-01-02") == today {
+			trades = append(trades, trade)
+		}
+	}
+	return trades, nil
+}
+
+// newOrder 登记新委托
+func (pb *PaperBroker) newOrder(symbol, side string, price float64, amount int) *Order {
+	pb.seq++
+	order := &Order{
+		OrderID:   fmt.Sprintf("%s%d", paperIDPrefix, pb.seq),
+		Symbol:    symbol,
+		Type:      side,
+		Price:     price,
+		Amount:    amount,
+		Status:    "已报",
+		OrderTime: pb.now(),
+	}
+	pb.orders = append(pb.orders, order)
+	pb.saveOrder(order)
+	return order
+}
+
+// fetchPrices 获取股票最新行情，调用时不能持有锁；无行情或获取失败的股票不在结果中
+func (pb *PaperBroker) fetchPrices(symbols []string) map[string]float64 {
+	pb.mu.Lock()
+	price := pb.price
+	pb.mu.Unlock()
+
+	prices := make(map[string]float64, len(symbols))
+	if price == nil {
+		return prices
+	}
+	for _, symbol := range symbols {
+		if _, ok := prices[symbol]; ok {
+			continue
+		}
+		if p, err := price(symbol); err == nil && p > 0 {
+			prices[symbol] = p
+		}
+	}
+	return prices
+}
+
+// refresh 获取有未成交委托或持仓的股票行情，再加锁撮合委托并刷新持仓现价
+func (pb *PaperBroker) refresh() {
+	pb.mu.Lock()
+	var symbols []string
+	for _, order := range pb.orders {
+		if order.Status == "已报" {
+			symbols = append(symbols, order.Symbol)
+		}
+	}
+	for symbol := range pb.positions {
+		symbols = append(symbols, symbol)
+	}
+	pb.mu.Unlock()
+
+	prices := pb.fetchPrices(symbols)
+
+	pb.mu.Lock()
+	defer pb.mu.Unlock()
+	pb.matchPending(prices)
+}
+
+// matchPending 按给定行情撮合所有未成交委托，调用方需持有锁
+func (pb *PaperBroker) matchPending(prices map[string]float64) {
+	for _, order := range pb.orders {
+		if order.Status == "已报" {
+			pb.matchOrder(order, prices)
+		}
+	}
+	// 刷新持仓现价
+	for symbol, pos := range pb.positions {
+		if price, ok := prices[symbol]; ok {
+			pos.lastPrice = price
+		}
+	}
+}
+
+// matchOrder 行情价满足委托价时按行情价全部成交，无行情时保持未成交
+func (pb *PaperBroker) matchOrder(order *Order, prices map[string]float64) {
+	market, ok := prices[order.Symbol]
+	if !ok {
+		return
+	}
+	if order.Type == OrderTypeBuy && market > order.Price {
+		return
+	}
+	if order.Type == OrderTypeSell && market < order.Price {
+		return
+	}
+
+	// 解冻后按实际成交价结算
+	pb.release(order)
+	commission := paperCommission(order.Type, market, order.Amount)
+	now := pb.now()
+	pb.applyFill(order.Symbol, order.Type, market, order.Amount, commission, now)
+
+	order.FilledAmount = order.Amount
+	order.Status = "已成交"
+	pb.saveOrder(order)
+
+	trade := Trade{
+		TradeID:    order.OrderID + "_t",
+		OrderID:    order.OrderID,
+		Symbol:     order.Symbol,
+		Type:       order.Type,
+		Price:      market,
+		Amount:     order.Amount,
+		TradeTime:  now,
+		Commission: commission,
+	}
+	pb.trades = append(pb.trades, trade)
+	if pb.tradeHistory != nil {
+		if err := pb.tradeHistory.SaveTrade(TradeRecord{
+			TradeID:    trade.TradeID,
+			OrderID:    trade.OrderID,
+			Symbol:     trade.Symbol,
+			Type:       trade.Type,
+			Price:      trade.Price,
+			Volume:     int64(trade.Amount),
+			Commission: trade.Commission,
+			TradeTime:  trade.TradeTime,
+		}); err != nil {
+			log.Printf("保存模拟盘成交失败: %v", err)
+		}
+	}
+	log.Printf("模拟盘成交: %s %s %d @ %.2f, 佣金 %.2f", order.Type, order.Symbol, order.Amount, market, commission)
+}
+
+// release 释放未成交委托冻结的资金或持仓
+func (pb *PaperBroker) release(order *Order) {
+	switch order.Type {
+	case OrderTypeBuy:
+		frozen := order.Price*float64(order.Amount) + paperCommission(OrderTypeBuy, order.Price, order.Amount)
+		pb.frozenCash -= frozen
+		pb.cash += frozen
+	case OrderTypeSell:
+		if pos, ok := pb.positions[order.Symbol]; ok {
+			pos.frozen -= order.Amount
+		}
+	}
+}
+
+// applyFill 按成交更新资金和持仓
+func (pb *PaperBroker) applyFill(symbol, side string, price float64, amount int, commission float64, at time.Time) {
+	day := at.Format("2006-01-02")
+	pos, ok := pb.positions[symbol]
+	if !ok {
+		pos = &paperPosition{}
+		pb.positions[symbol] = pos
+	}
+	pos.lastPrice = price
+
+	switch side {
+	case OrderTypeBuy:
+		cost := price*float64(amount) + commission
+		pb.cash -= cost
+		pos.costPrice = (pos.costPrice*float64(pos.amount) + cost) / float64(pos.amount+amount)
+		pos.amount += amount
+		if pos.boughtDay != day {
+			pos.boughtDay = day
+			pos.boughtToday = 0
+		}
+		pos.boughtToday += amount
+	case OrderTypeSell:
+		pb.cash += price*float64(amount) - commission
+		pos.amount -= amount
+		if pos.amount <= 0 {
+			delete(pb.positions, symbol)
+		}
+	}
+}
+
+// available 可卖数量：扣除当日买入和卖出委托冻结的部分
+func (pb *PaperBroker) available(pos *paperPosition) int {
+	available := pos.amount - pos.frozen
+	if pos.boughtDay == pb.now().Format("2006-01-02") {
+		available -= pos.boughtToday
+	}
+	if available < 0 {
+		return 0
+	}
+	return available
+}
+
+// saveOrder 将委托写入交易历史
+func (pb *PaperBroker) saveOrder(order *Order) {
+	if pb.tradeHistory == nil {
+		return
+	}
+	if err := pb.tradeHistory.SaveOrder(*order); err != nil {
+		log.Printf("保存模拟盘委托失败: %v", err)
+	}
+}
+
+// paperCommission 模拟盘交易费用：佣金不低于最低收费，卖出另收印花税
+func paperCommission(side string, price float64, amount int) float64 {
+	value := price * float64(amount)
+	fee := math.Max(value*paperCommissionRate, paperMinCommission)
+	if side == OrderTypeSell {
+		fee += value * paperStampTax
+	}
+	return fee
+}
+
+// validatePaperOrder 检查委托价格和数量
+func validatePaperOrder(price float64, amount int) error {
+	if price <= 0 {
+		return fmt.Errorf("委托价格无效: %.2f", price)
+	}
+	if amount <= 0 {
+		return fmt.Errorf("委托数量无效: %d", amount)
+	}
+	return nil
+}
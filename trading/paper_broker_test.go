@@ -0,0 +1,144 @@
+package trading
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestPaperBrokerFillsAgainstQuotes(t *testing.T) {
+	th, err := NewTradeHistory(filepath.Join(t.TempDir(), "trades.db"))
+	if err != nil {
+		t.Fatalf("NewTradeHistory: %v", err)
+	}
+	defer th.Close()
+
+	connector, err := NewBrokerConnector(BrokerConfig{Type: BrokerTypePaper, InitialCash: 100000})
+	if err != nil {
+		t.Fatalf("NewBrokerConnector: %v", err)
+	}
+	if err := connector.Connect(); err != nil {
+		t.Fatalf("Connect: %v", err)
+	}
+	defer connector.Disconnect()
+
+	paper := connector.GetBroker().(*PaperBroker)
+	price := 10.0
+	paper.SetPriceProvider(func(symbol string) (float64, error) { return price, nil })
+	if err := paper.SetTradeHistory(th); err != nil {
+		t.Fatalf("SetTradeHistory: %v", err)
+	}
+	day := time.Date(2026, 3, 2, 10, 0, 0, 0, time.Local)
+	paper.now = func() time.Time { return day }
+
+	config := DefaultRiskConfig
+	config.InitialCapital = 100000
+	rm := NewRiskManager(config, connector, th)
+	pm := NewPositionManager(connector)
+	oe := NewOrderExecutor(connector, rm, pm, th)
+	ctx := context.Background()
+
+	// 行情价不高于委托价，买入立即按行情价成交
+	price = 9.9
+	if _, err := oe.ExecuteBuy(ctx, "sh600000", 10, 10000); err != nil {
+		t.Fatalf("ExecuteBuy: %v", err)
+	}
+	balance, _ := paper.GetBalance(ctx)
+	if want := 100000 - 9900 - 5.0; math.Abs(balance.Cash-want) > 1e-6 {
+		t.Fatalf("cash = %.2f, want %.2f", balance.Cash, want)
+	}
+	positions, _ := paper.GetPositions(ctx)
+	if len(positions) != 1 || positions[0].Amount != 1000 || positions[0].Available != 0 {
+		t.Fatalf("positions = %+v, want 1000 shares unavailable until T+1", positions)
+	}
+
+	// 次日卖出，行情未到委托价时挂单，价格上涨后在查询时成交
+	day = day.AddDate(0, 0, 1)
+	if err := pm.SyncPositions(); err != nil {
+		t.Fatalf("SyncPositions: %v", err)
+	}
+	orderID, err := oe.ExecuteSell(ctx, "sh600000", 11, 1000)
+	if err != nil {
+		t.Fatalf("ExecuteSell: %v", err)
+	}
+	if _, err := paper.Sell(ctx, "sh600000", 11, 100); err == nil {
+		t.Fatal("Sell accepted shares frozen by a pending order")
+	}
+	price = 11.2
+	order, err := oe.CheckOrderStatus(ctx, orderID)
+	if err != nil || order.Status != "已成交" {
+		t.Fatalf("sell order = %+v, %v; want filled", order, err)
+	}
+	trades, _ := paper.GetTodayTrades(ctx)
+	if len(trades) != 1 || trades[0].Price != 11.2 {
+		t.Fatalf("today trades = %+v, want one sell at 11.2", trades)
+	}
+	sellCommission := 5 + 11200*0.0005 // 佣金不足最低收费按5元计
+	wantCash := 100000 - 9900 - 5.0 + 11200 - sellCommission
+	balance, _ = paper.GetBalance(ctx)
+	if math.Abs(balance.Cash-wantCash) > 1e-6 || balance.MarketValue != 0 {
+		t.Fatalf("balance = %+v, want cash %.2f and no positions", balance, wantCash)
+	}
+
+	// 未成交买单撤销后释放冻结资金
+	price = 12
+	buyID, err := paper.Buy(ctx, "sz000001", 11, 1000)
+	if err != nil {
+		t.Fatalf("Buy: %v", err)
+	}
+	if err := paper.Cancel(ctx, buyID); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+	if balance, _ := paper.GetBalance(ctx); math.Abs(balance.Cash-wantCash) > 1e-6 || balance.FrozenCash > 1e-6 {
+		t.Fatalf("balance after cancel = %+v", balance)
+	}
+
+	// 重启后按交易历史恢复资金，委托编号继续递增
+	restored := NewPaperBroker(100000)
+	restored.SetPriceProvider(func(symbol string) (float64, error) { return price, nil })
+	if err := restored.SetTradeHistory(th); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+	if balance, _ := restored.GetBalance(ctx); math.Abs(balance.Cash-wantCash) > 1e-6 {
+		t.Fatalf("restored cash = %.2f, want %.2f", balance.Cash, wantCash)
+	}
+	if id, _ := restored.Buy(ctx, "sh600000", 12, 100); id != "paper_4" {
+		t.Fatalf("order id after restore = %s, want paper_4", id)
+	}
+}
+
+func TestPaperBrokerFetchesQuotesOutsideLock(t *testing.T) {
+	paper := NewPaperBroker(100000)
+	// 行情来源回调券商自身，撮合时持有锁会导致死锁
+	paper.SetPriceProvider(func(symbol string) (float64, error) {
+		paper.IsConnected()
+		return 10, nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		ctx := context.Background()
+		if _, err := paper.Buy(ctx, "sh600000", 9.5, 100); err != nil {
+			done <- err
+			return
+		}
+		_, err := paper.GetOrders(ctx)
+		done <- err
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("order flow: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("price provider called while holding the broker lock")
+	}
+
+	orders, _ := paper.GetOrders(context.Background())
+	if len(orders) != 1 || orders[0].Status != "已报" {
+		t.Errorf("orders = %+v, want one resting buy below the market", orders)
+	}
+}
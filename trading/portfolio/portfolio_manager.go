@@ -5,12 +5,19 @@ import (
 	"fmt"
 	"log"
 	"math"
+	"sort"
 	"sync"
 	"time"
 
 	"cloudquant/trading"
 )
 
+// DefaultLotSize A股交易单位（一手）的股数
+const DefaultLotSize = 100
+
+// PriceProvider 获取股票最新价格，用于将调仓金额换算为股数
+type PriceProvider func(symbol string) (float64, error)
+
 // PortfolioManager 组合管理器
 type PortfolioManager struct {
 	mu              sync.RWMutex
@@ -18,6 +25,11 @@ type PortfolioManager struct {
 	positions       map[string]*PortfolioPosition // 持仓信息
 	strategyWeights map[string]float64            // 策略权重
 	performance     *PortfolioPerformance         // 组合表现
+	cash            float64                       // 现金余额，调仓订单成交时调整
+	priceProvider   PriceProvider                 // 无持仓价格时获取最新价格
+	executor        RebalanceExecutor             // 调仓订单执行器
+	executions      []ExecutionResult             // 调仓执行记录
+	pendingOrders   map[string]int64              // 已提交调仓订单ID -> 尚未成交的股数
 	positionManager *trading.PositionManager
 	riskManager     *trading.RiskManager
 	createdAt       time.Time
@@ -32,6 +44,9 @@ type PortfolioConfig struct {
 	MaxPositionWeight  float64       `yaml:"max_position_weight"` // 最大持仓权重
 	TargetReturn       float64       `yaml:"target_return"`       // 目标收益率
 	RiskFreeRate       float64       `yaml:"risk_free_rate"`      // 无风险利率
+	InitialCapital     float64       `yaml:"initial_capital"`     // 初始资金，同时作为初始现金余额
+	LotSize            int64         `yaml:"lot_size"`            // 每手股数，订单数量向下取整到整手，0表示100
+	DryRun             bool          `yaml:"dry_run"`             // 调仓只记录日志，不向券商提交订单
 }
 
 // PortfolioPosition 组合持仓
@@ -92,7 +107,9 @@ func NewPortfolioManager(config PortfolioConfig, positionManager *trading.Positi
 		config:          &config,
 		positions:       make(map[string]*PortfolioPosition),
 		strategyWeights: make(map[string]float64),
-		performance:     &PortfolioPerformance{},
+		pendingOrders:   make(map[string]int64),
+		performance:     &PortfolioPerformance{TotalValue: config.InitialCapital},
+		cash:            config.InitialCapital,
 		positionManager: positionManager,
 		riskManager:     riskManager,
 		createdAt:       time.Now(),
@@ -112,8 +129,8 @@ func (p *PortfolioManager) UpdatePositions(ctx context.Context) error {
 	// 获取所有持仓
 	positions := p.positionManager.GetAllPositions()
 
-	// 计算总市值
-	totalValue := 0.0
+	// 计算总价值，包含未投资的现金
+	totalValue := p.cash
 	for _, pos := range positions {
 		totalValue += pos.MarketValue
 	}
@@ -176,9 +193,13 @@ func (p *PortfolioManager) updatePerformance(totalValue float64) {
 		p.performance.TotalReturn = (totalValue - initialValue) / initialValue
 	}
 
-	// 计算年化收益率
+	// 计算年化收益率，收益历史早于组合创建时（如从交易历史同步）从最早记录起算
 	if len(p.performance.ReturnHistory) > 0 {
-		days := time.Since(p.createdAt).Hours() / 24
+		start := p.createdAt
+		if first := p.performance.ReturnHistory[0].Timestamp; first.Before(start) {
+			start = first
+		}
+		days := time.Since(start).Hours() / 24
 		if days > 0 {
 			p.performance.AnnualizedReturn = math.Pow(1+p.performance.TotalReturn, 365/days) - 1
 		}
@@ -194,6 +215,53 @@ func (p *PortfolioManager) updatePerformance(totalValue float64) {
 	p.performance.LastUpdate = time.Now()
 }
 
+// SyncFromTradeHistory 用交易历史中最近days天的日度盈亏重建收益历史，使夏普比率、最大回撤和年化收益
+// 反映实际交易结果；总价值取最近一日的收盘权益。可重复调用以刷新，已有收益历史会被替换
+func (p *PortfolioManager) SyncFromTradeHistory(ctx context.Context, th *trading.TradeHistory, days int) error {
+	if th == nil {
+		return fmt.Errorf("trade history not configured")
+	}
+	if days <= 0 {
+		return fmt.Errorf("days must be positive: %d", days)
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	pnls, err := th.GetDailyPnL(days)
+	if err != nil {
+		return fmt.Errorf("failed to load daily pnl: %w", err)
+	}
+
+	// GetDailyPnL按日期倒序返回，收益历史按时间升序
+	history := make([]ReturnPoint, 0, len(pnls))
+	for i := len(pnls) - 1; i >= 0; i-- {
+		pnl := pnls[i]
+		date, err := time.ParseInLocation("2006-01-02", pnl.Date, time.Local)
+		if err != nil {
+			return fmt.Errorf("invalid daily pnl date %q: %w", pnl.Date, err)
+		}
+		dailyReturn := pnl.PnLPercent
+		if pnl.OpenEquity > 0 {
+			dailyReturn = pnl.PnL / pnl.OpenEquity
+		}
+		history = append(history, ReturnPoint{Timestamp: date, Value: pnl.CloseEquity, Return: dailyReturn})
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.performance.ReturnHistory = history
+	totalValue := p.performance.TotalValue
+	if len(history) > 0 {
+		totalValue = history[len(history)-1].Value
+	}
+	p.updatePerformance(totalValue)
+
+	log.Printf("Portfolio return history synced from trade history: %d days", len(history))
+	return nil
+}
+
 // calculateMaxDrawdown 计算最大回撤
 func (p *PortfolioManager) calculateMaxDrawdown() float64 {
 	if len(p.performance.ReturnHistory) == 0 {
@@ -256,10 +324,11 @@ func (p *PortfolioManager) calculateSharpeRatio() float64 {
 	return annualizedExcessReturn / annualizedStdDev
 }
 
-// getInitialValue 获取初始价值
+// getInitialValue 获取初始价值，未配置初始资金时使用最早的收益记录
 func (p *PortfolioManager) getInitialValue() float64 {
-	// 简化实现：使用组合创建时的总价值
-	// 实际应用中应该从配置或数据库获取
+	if p.config.InitialCapital > 0 {
+		return p.config.InitialCapital
+	}
 	if len(p.performance.ReturnHistory) > 0 {
 		return p.performance.ReturnHistory[0].Value
 	}
@@ -285,7 +354,7 @@ func (p *PortfolioManager) Rebalance(ctx context.Context, strategyWeights map[st
 	}
 
 	// 生成调仓订单
-	orders := p.generateRebalanceOrders(targetPositions)
+	orders := p.fitOrdersToCash(p.sizeOrders(p.generateRebalanceOrders(targetPositions)))
 
 	// 更新调仓时间
 	p.lastRebalance = time.Now()
@@ -352,12 +421,11 @@ func (p *PortfolioManager) generateRebalanceOrders(targetPositions map[string]fl
 		if currentPosition == nil {
 			// 新建持仓
 			orders = append(orders, &RebalanceOrder{
-				Symbol:        symbol,
-				Action:        "buy",
-				TargetValue:   targetValue,
-				CurrentValue:  0,
-				OrderValue:    targetValue,
-				OrderQuantity: 0, // 实际计算数量
+				Symbol:       symbol,
+				Action:       "buy",
+				TargetValue:  targetValue,
+				CurrentValue: 0,
+				OrderValue:   targetValue,
 			})
 			continue
 		}
@@ -381,18 +449,108 @@ func (p *PortfolioManager) generateRebalanceOrders(targetPositions map[string]fl
 		}
 
 		orders = append(orders, &RebalanceOrder{
-			Symbol:        symbol,
-			Action:        action,
-			TargetValue:   targetValue,
-			CurrentValue:  currentValue,
-			OrderValue:    orderValue,
-			OrderQuantity: 0, // 实际计算数量
+			Symbol:       symbol,
+			Action:       action,
+			TargetValue:  targetValue,
+			CurrentValue: currentValue,
+			OrderValue:   orderValue,
 		})
 	}
 
 	return orders
 }
 
+// sizeOrders 按最新价格将订单金额换算为整手股数，订单金额随之取整；
+// 金额占组合比例低于最小持仓权重、无法获取价格或不足一手的订单被移除
+func (p *PortfolioManager) sizeOrders(orders []*RebalanceOrder) []*RebalanceOrder {
+	kept := orders[:0]
+	for _, order := range orders {
+		if p.performance.TotalValue > 0 && order.OrderValue/p.performance.TotalValue < p.config.MinPositionWeight {
+			continue
+		}
+
+		price, err := p.latestPrice(order.Symbol)
+		if err != nil {
+			log.Printf("Skip rebalance order for %s: %v", order.Symbol, err)
+			continue
+		}
+
+		quantity := p.roundToLot(order.OrderValue / price)
+		if order.Action == "sell" {
+			if position := p.positions[order.Symbol]; position != nil && quantity > position.Quantity {
+				quantity = position.Quantity
+			}
+		}
+		if quantity <= 0 {
+			continue
+		}
+
+		order.Price = price
+		order.OrderQuantity = quantity
+		order.OrderValue = float64(quantity) * price
+		kept = append(kept, order)
+	}
+	return kept
+}
+
+// latestPrice 股票最新价格：优先使用持仓的市值与数量，没有持仓时使用价格提供者
+func (p *PortfolioManager) latestPrice(symbol string) (float64, error) {
+	if position := p.positions[symbol]; position != nil && position.Quantity > 0 && position.MarketValue > 0 {
+		return position.MarketValue / float64(position.Quantity), nil
+	}
+	if p.priceProvider == nil {
+		return 0, fmt.Errorf("no price for %s: price provider not configured", symbol)
+	}
+	price, err := p.priceProvider(symbol)
+	if err != nil {
+		return 0, err
+	}
+	if price <= 0 {
+		return 0, fmt.Errorf("invalid price for %s: %.4f", symbol, price)
+	}
+	return price, nil
+}
+
+// roundToLot 将股数向下取整到整手
+func (p *PortfolioManager) roundToLot(shares float64) int64 {
+	lot := p.config.LotSize
+	if lot <= 0 {
+		lot = DefaultLotSize
+	}
+	return int64(shares) / lot * lot
+}
+
+// fitOrdersToCash 按可用现金缩减买单：先计入卖出所得，买入累计不超过现金余额，
+// 现金不足一手的买单被移除。现金余额本身在订单成交时才调整，见OnFill
+func (p *PortfolioManager) fitOrdersToCash(orders []*RebalanceOrder) []*RebalanceOrder {
+	sort.Slice(orders, func(i, j int) bool {
+		if orders[i].Action != orders[j].Action {
+			return orders[i].Action == "sell"
+		}
+		return orders[i].Symbol < orders[j].Symbol
+	})
+
+	available := p.cash
+	kept := orders[:0]
+	for _, order := range orders {
+		if order.Action == "sell" {
+			available += order.OrderValue
+			kept = append(kept, order)
+			continue
+		}
+		if order.OrderValue > available {
+			order.OrderQuantity = p.roundToLot(available / order.Price)
+			order.OrderValue = float64(order.OrderQuantity) * order.Price
+		}
+		if order.OrderQuantity <= 0 {
+			continue
+		}
+		available -= order.OrderValue
+		kept = append(kept, order)
+	}
+	return kept
+}
+
 // getStrategySymbols 获取策略推荐的股票
 func (p *PortfolioManager) getStrategySymbols(strategyName string) []string {
 	// 简化的策略股票映射
@@ -424,7 +582,7 @@ func (p *PortfolioManager) GetPortfolioOverview() *PortfolioOverview {
 		MaxDrawdown:      p.performance.MaxDrawdown,
 		SharpeRatio:      p.performance.SharpeRatio,
 		PositionCount:    len(p.positions),
-		CashBalance:      0.0, // 简化实现
+		CashBalance:      p.cash,
 		CreatedAt:        p.createdAt,
 		LastRebalance:    p.lastRebalance,
 		NextRebalance:    p.lastRebalance.Add(p.config.RebalanceFrequency),
@@ -448,8 +606,9 @@ func (p *PortfolioManager) calculatePositionDistribution() map[string]float64 {
 		return distribution
 	}
 
+	// 以含现金的总价值为分母，未满仓时各持仓权重之和小于1
 	for _, position := range p.positions {
-		distribution[position.Symbol] = position.Weight
+		distribution[position.Symbol] = position.MarketValue / totalValue
 	}
 
 	return distribution
@@ -676,6 +835,44 @@ func (p *PortfolioManager) estimateRebalanceCost(drifts map[string]float64) floa
 	return p.performance.TotalValue * turnover * 0.001
 }
 
+// SetCashBalance 设置现金余额，例如与券商账户对账后，并按新的总价值重算持仓权重
+func (p *PortfolioManager) SetCashBalance(cash float64) error {
+	if cash < 0 {
+		return fmt.Errorf("cash balance must not be negative: %.2f", cash)
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.cash = cash
+	totalValue := cash
+	for _, position := range p.positions {
+		totalValue += position.MarketValue
+	}
+	for _, position := range p.positions {
+		position.Weight = 0
+		if totalValue > 0 {
+			position.Weight = position.MarketValue / totalValue
+		}
+	}
+	p.updatePerformance(totalValue)
+	return nil
+}
+
+// SetPriceProvider 设置价格提供者，用于计算没有持仓的股票的买入数量
+func (p *PortfolioManager) SetPriceProvider(provider PriceProvider) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.priceProvider = provider
+}
+
+// GetCashBalance 获取现金余额
+func (p *PortfolioManager) GetCashBalance() float64 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.cash
+}
+
 // SetConfig 更新配置
 func (p *PortfolioManager) SetConfig(config PortfolioConfig) {
 	p.mu.Lock()
@@ -713,7 +910,8 @@ type RebalanceOrder struct {
 	TargetValue   float64 `json:"target_value"`   // 目标价值
 	CurrentValue  float64 `json:"current_value"`  // 当前价值
 	OrderValue    float64 `json:"order_value"`    // 订单价值
-	OrderQuantity int64   `json:"order_quantity"` // 订单数量
+	OrderQuantity int64   `json:"order_quantity"` // 订单数量，整手
+	Price         float64 `json:"price"`          // 计算数量所用价格
 	Priority      int     `json:"priority"`       // 优先级
 }
 
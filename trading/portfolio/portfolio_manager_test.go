@@ -0,0 +1,177 @@
+package portfolio
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"testing"
+
+	"cloudquant/trading"
+)
+
+func newTestPortfolioManager(t *testing.T, capital float64, trades ...trading.Trade) *PortfolioManager {
+	t.Helper()
+	connector, err := trading.NewBrokerConnector(trading.BrokerConfig{Type: "easytrader"})
+	if err != nil {
+		t.Fatalf("NewBrokerConnector: %v", err)
+	}
+	positions := trading.NewPositionManager(connector)
+	for _, trade := range trades {
+		if err := positions.UpdatePosition(trade); err != nil {
+			t.Fatalf("UpdatePosition: %v", err)
+		}
+	}
+	return NewPortfolioManager(PortfolioConfig{MaxTurnover: 1, InitialCapital: capital}, positions, nil)
+}
+
+func TestCashIncludedInTotalValueAndWeights(t *testing.T) {
+	pm := newTestPortfolioManager(t, 50000,
+		trading.Trade{Symbol: "sh600000", Type: "buy", Price: 10, Amount: 3000},
+		trading.Trade{Symbol: "sh600519", Type: "buy", Price: 200, Amount: 100},
+	)
+	if err := pm.UpdatePositions(context.Background()); err != nil {
+		t.Fatalf("UpdatePositions: %v", err)
+	}
+
+	// 持仓市值30000+20000，现金50000
+	overview := pm.GetPortfolioOverview()
+	if overview.TotalValue != 100000 || overview.CashBalance != 50000 {
+		t.Fatalf("total=%.2f cash=%.2f, want 100000 and 50000", overview.TotalValue, overview.CashBalance)
+	}
+	if w := overview.PositionDistribution["sh600000"]; math.Abs(w-0.3) > 1e-9 {
+		t.Errorf("sh600000 weight = %.4f, want 0.3", w)
+	}
+	if w := overview.PositionDistribution["sh600519"]; math.Abs(w-0.2) > 1e-9 {
+		t.Errorf("sh600519 weight = %.4f, want 0.2", w)
+	}
+
+	if err := pm.SetCashBalance(0); err != nil {
+		t.Fatalf("SetCashBalance: %v", err)
+	}
+	if pm.GetCashBalance() != 0 || pm.GetPerformance().TotalValue != 50000 {
+		t.Errorf("after clearing cash: cash=%.2f total=%.2f", pm.GetCashBalance(), pm.GetPerformance().TotalValue)
+	}
+	if position, _ := pm.GetPositionDetails("sh600000"); math.Abs(position.Weight-0.6) > 1e-9 {
+		t.Errorf("sh600000 weight after clearing cash = %.4f, want 0.6", position.Weight)
+	}
+	if err := pm.SetCashBalance(-1); err == nil {
+		t.Error("negative cash balance should be rejected")
+	}
+}
+
+func TestRebalanceSizesOrdersInLots(t *testing.T) {
+	pm := newTestPortfolioManager(t, 100000)
+	if err := pm.UpdatePositions(context.Background()); err != nil {
+		t.Fatalf("UpdatePositions: %v", err)
+	}
+
+	// 未设置价格提供者时无法换算股数，不生成订单
+	orders, err := pm.Rebalance(context.Background(), map[string]float64{"ma_strategy": 1})
+	if err != nil {
+		t.Fatalf("Rebalance: %v", err)
+	}
+	if len(orders) != 0 || pm.GetCashBalance() != 100000 {
+		t.Fatalf("got %d orders and cash %.2f without prices, want none and untouched cash", len(orders), pm.GetCashBalance())
+	}
+
+	prices := map[string]float64{"sh600000": 7.3, "sh600519": 1700}
+	pm.SetPriceProvider(func(symbol string) (float64, error) {
+		return prices[symbol], nil
+	})
+
+	// ma_strategy 对应 sh600000 和 sh600519，各分配50000
+	orders, err = pm.Rebalance(context.Background(), map[string]float64{"ma_strategy": 1})
+	if err != nil {
+		t.Fatalf("Rebalance: %v", err)
+	}
+	want := map[string]int64{"sh600000": 6800, "sh600519": 0}
+	var spent float64
+	for _, order := range orders {
+		if order.Action != "buy" || order.OrderQuantity%DefaultLotSize != 0 {
+			t.Errorf("order %+v is not a whole-lot buy", order)
+		}
+		if order.OrderQuantity != want[order.Symbol] {
+			t.Errorf("%s quantity = %d, want %d", order.Symbol, order.OrderQuantity, want[order.Symbol])
+		}
+		if math.Abs(order.OrderValue-float64(order.OrderQuantity)*order.Price) > 1e-6 {
+			t.Errorf("%s order value %.2f does not match quantity at price %.2f", order.Symbol, order.OrderValue, order.Price)
+		}
+		spent += order.OrderValue
+	}
+	// 50000元不足以买入一手1700元的股票；生成订单不改变现金，成交后才扣减
+	if len(orders) != 1 || spent > 100000 || pm.GetCashBalance() != 100000 {
+		t.Errorf("%d orders spending %.2f, cash left %.2f", len(orders), spent, pm.GetCashBalance())
+	}
+}
+
+func TestRebalanceRejectsOrdersBelowMinWeight(t *testing.T) {
+	pm := newTestPortfolioManager(t, 100000)
+	pm.SetConfig(PortfolioConfig{MaxTurnover: 1, MinPositionWeight: 0.3, InitialCapital: 100000})
+	pm.SetPriceProvider(func(symbol string) (float64, error) { return 10, nil })
+	if err := pm.UpdatePositions(context.Background()); err != nil {
+		t.Fatalf("UpdatePositions: %v", err)
+	}
+
+	// ma_strategy的两只股票各占25%，低于30%的最小权重
+	orders, err := pm.Rebalance(context.Background(), map[string]float64{"ma_strategy": 0.5, "rsi_strategy": 0.5})
+	if err != nil {
+		t.Fatalf("Rebalance: %v", err)
+	}
+	if len(orders) != 0 {
+		t.Errorf("got %d orders below the minimum weight, want none", len(orders))
+	}
+}
+
+func TestSyncFromTradeHistory(t *testing.T) {
+	history, err := trading.NewTradeHistory(filepath.Join(t.TempDir(), "trades.db"))
+	if err != nil {
+		t.Fatalf("NewTradeHistory: %v", err)
+	}
+	defer history.Close()
+
+	// 100000 -> 110000 -> 99000 -> 104000
+	for _, pnl := range []trading.DailyPnL{
+		{Date: "2024-03-04", OpenEquity: 100000, CloseEquity: 110000, PnL: 10000},
+		{Date: "2024-03-05", OpenEquity: 110000, CloseEquity: 99000, PnL: -11000},
+		{Date: "2024-03-06", OpenEquity: 99000, CloseEquity: 104000, PnL: 5000},
+	} {
+		if err := history.SaveDailyPnL(pnl); err != nil {
+			t.Fatalf("SaveDailyPnL: %v", err)
+		}
+	}
+
+	pm := newTestPortfolioManager(t, 100000)
+	if err := pm.SyncFromTradeHistory(context.Background(), history, 30); err != nil {
+		t.Fatalf("SyncFromTradeHistory: %v", err)
+	}
+
+	performance := pm.GetPerformance()
+	points := performance.ReturnHistory
+	if len(points) != 3 || points[0].Timestamp.Format("2006-01-02") != "2024-03-04" || points[2].Value != 104000 {
+		t.Fatalf("return history = %+v, want three days in date order", points)
+	}
+	if math.Abs(points[1].Return+0.1) > 1e-9 {
+		t.Errorf("second day return = %v, want -0.1", points[1].Return)
+	}
+	if performance.TotalValue != 104000 || math.Abs(performance.TotalReturn-0.04) > 1e-9 {
+		t.Errorf("total value %.2f return %.4f, want 104000 and 0.04", performance.TotalValue, performance.TotalReturn)
+	}
+	if math.Abs(performance.MaxDrawdown-0.1) > 1e-9 {
+		t.Errorf("max drawdown = %v, want 0.1", performance.MaxDrawdown)
+	}
+	if performance.SharpeRatio == 0 || performance.DailyReturn == 0 {
+		t.Errorf("sharpe %v daily return %v, want both derived from synced history", performance.SharpeRatio, performance.DailyReturn)
+	}
+
+	// 只同步最近两天时替换已有历史
+	if err := pm.SyncFromTradeHistory(context.Background(), history, 2); err != nil {
+		t.Fatalf("SyncFromTradeHistory: %v", err)
+	}
+	if points := pm.GetPerformance().ReturnHistory; len(points) != 2 || points[0].Value != 99000 {
+		t.Errorf("refreshed history = %+v, want last two days", points)
+	}
+
+	if err := pm.SyncFromTradeHistory(context.Background(), nil, 30); err == nil {
+		t.Error("nil trade history should be rejected")
+	}
+}
@@ -71,6 +71,12 @@ func (p *PortfolioOptimizer) Optimize(symbols []string, historicalData map[strin
 		return nil, fmt.Errorf("no symbols provided for optimization")
 	}
 
+	switch p.config.Method {
+	case "equal_weight", "risk_parity", "max_sharpe":
+	default:
+		return nil, fmt.Errorf("unsupported optimization method: %s", p.config.Method)
+	}
+
 	// 验证数据
 	if err := p.validateData(symbols, historicalData); err != nil {
 		return nil, fmt.Errorf("invalid data: %v", err)
@@ -86,15 +92,15 @@ func (p *PortfolioOptimizer) Optimize(symbols []string, historicalData map[strin
 	var weights map[string]float64
 	var metrics OptimizationMetrics
 
-	switch p.config.Method {
-	case "equal_weight":
+	switch {
+	case len(assetData) == 1:
+		weights, metrics = p.singleAssetOptimization(assetData[0])
+	case p.config.Method == "equal_weight":
 		weights, metrics = p.equalWeightOptimization(symbols)
-	case "risk_parity":
+	case p.config.Method == "risk_parity":
 		weights, metrics = p.riskParityOptimization(assetData)
-	case "max_sharpe":
+	case p.config.Method == "max_sharpe":
 		weights, metrics = p.maxSharpeOptimization(assetData)
-	default:
-		return nil, fmt.Errorf("unsupported optimization method: %s", p.config.Method)
 	}
 
 	result := &OptimizationResult{
@@ -172,6 +178,13 @@ func (p *PortfolioOptimizer) calculateAssetData(symbols []string, historicalData
 	return assetData, nil
 }
 
+// singleAssetOptimization 单一资产组合：不受权重上下限约束，全部仓位分配给该资产
+func (p *PortfolioOptimizer) singleAssetOptimization(asset *AssetData) (map[string]float64, OptimizationMetrics) {
+	weights := map[string]float64{asset.Symbol: 1.0}
+	metrics := p.calculatePortfolioMetrics(weights, []*AssetData{asset})
+	return weights, metrics
+}
+
 // equalWeightOptimization 等权重优化
 func (p *PortfolioOptimizer) equalWeightOptimization(symbols []string) (map[string]float64, OptimizationMetrics) {
 	weights := make(map[string]float64)
@@ -409,6 +422,9 @@ func (p *PortfolioOptimizer) estimateAssetMaxDrawdown(returns []float64) float64
 		if cumulative > peak {
 			peak = cumulative
 		}
+		if peak <= 0 {
+			continue
+		}
 
 		drawdown := (peak - cumulative) / peak
 		if drawdown > maxDrawdown {
@@ -493,6 +509,16 @@ func (p *PortfolioOptimizer) GetCorrelationMatrix(assetData []*AssetData) (*Corr
 		return nil, fmt.Errorf("no asset data provided")
 	}
 
+	// 单一资产只有自身方差，相关性恒为1
+	if n == 1 {
+		variance := assetData[0].Volatility * assetData[0].Volatility
+		return &CorrelationMatrix{
+			Assets:      []string{assetData[0].Symbol},
+			Covariance:  [][]float64{{variance}},
+			Correlation: [][]float64{{1.0}},
+		}, nil
+	}
+
 	// 初始化矩阵
 	covariance := make([][]float64, n)
 	correlation := make([][]float64, n)
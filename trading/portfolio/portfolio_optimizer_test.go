@@ -0,0 +1,126 @@
+package portfolio
+
+import (
+	"math"
+	"testing"
+)
+
+var optimizerMethods = []string{"equal_weight", "risk_parity", "max_sharpe"}
+
+func newTestOptimizer(method string) *PortfolioOptimizer {
+	return NewPortfolioOptimizer(OptimizerConfig{
+		Method:       method,
+		RiskFreeRate: 0.03,
+		MinWeight:    0.05,
+		MaxWeight:    0.4,
+	})
+}
+
+func testReturns(base, step float64) []float64 {
+	returns := make([]float64, 20)
+	for i := range returns {
+		sign := 1.0
+		if i%2 == 1 {
+			sign = -1.0
+		}
+		returns[i] = base + sign*step*float64(i%5)
+	}
+	return returns
+}
+
+func assertSaneResult(t *testing.T, method string, result *OptimizationResult, symbols []string) {
+	t.Helper()
+
+	var total float64
+	for _, symbol := range symbols {
+		weight, ok := result.Weights[symbol]
+		if !ok {
+			t.Errorf("%s: missing weight for %s", method, symbol)
+		}
+		if math.IsNaN(weight) || math.IsInf(weight, 0) || weight < 0 || weight > 1 {
+			t.Errorf("%s: invalid weight %v for %s", method, weight, symbol)
+		}
+		total += weight
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("%s: weights sum to %v, want 1", method, total)
+	}
+
+	for name, v := range map[string]float64{
+		"return":       result.Return,
+		"risk":         result.Risk,
+		"sharpe_ratio": result.SharpeRatio,
+		"max_drawdown": result.MaxDrawdown,
+	} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			t.Errorf("%s: %s is %v", method, name, v)
+		}
+	}
+}
+
+func TestOptimizeEmptyUniverse(t *testing.T) {
+	for _, method := range optimizerMethods {
+		if _, err := newTestOptimizer(method).Optimize(nil, map[string][]float64{}); err == nil {
+			t.Errorf("%s: expected error for empty universe", method)
+		}
+	}
+}
+
+func TestOptimizeSingleSymbol(t *testing.T) {
+	data := map[string][]float64{"sh600000": testReturns(0.001, 0.01)}
+
+	for _, method := range optimizerMethods {
+		result, err := newTestOptimizer(method).Optimize([]string{"sh600000"}, data)
+		if err != nil {
+			t.Fatalf("%s: Optimize: %v", method, err)
+		}
+		assertSaneResult(t, method, result, []string{"sh600000"})
+		if result.Weights["sh600000"] != 1.0 {
+			t.Errorf("%s: single symbol weight = %v, want 1", method, result.Weights["sh600000"])
+		}
+	}
+
+	// 波动率为0的单一资产也不应产生NaN
+	flat := map[string][]float64{"sh600000": make([]float64, 20)}
+	for _, method := range optimizerMethods {
+		result, err := newTestOptimizer(method).Optimize([]string{"sh600000"}, flat)
+		if err != nil {
+			t.Fatalf("%s: Optimize flat: %v", method, err)
+		}
+		assertSaneResult(t, method, result, []string{"sh600000"})
+	}
+}
+
+func TestOptimizeTwoSymbols(t *testing.T) {
+	symbols := []string{"sh600000", "sh600036"}
+	data := map[string][]float64{
+		"sh600000": testReturns(0.002, 0.01),
+		"sh600036": testReturns(0.001, 0.02),
+	}
+
+	for _, method := range optimizerMethods {
+		result, err := newTestOptimizer(method).Optimize(symbols, data)
+		if err != nil {
+			t.Fatalf("%s: Optimize: %v", method, err)
+		}
+		assertSaneResult(t, method, result, symbols)
+	}
+}
+
+func TestCorrelationMatrixSingleAsset(t *testing.T) {
+	optimizer := newTestOptimizer("risk_parity")
+	matrix, err := optimizer.GetCorrelationMatrix([]*AssetData{{Symbol: "sh600000", Volatility: 0.02}})
+	if err != nil {
+		t.Fatalf("GetCorrelationMatrix: %v", err)
+	}
+	if len(matrix.Correlation) != 1 || len(matrix.Correlation[0]) != 1 || matrix.Correlation[0][0] != 1 {
+		t.Errorf("correlation = %v, want [[1]]", matrix.Correlation)
+	}
+	if math.Abs(matrix.Covariance[0][0]-0.0004) > 1e-12 {
+		t.Errorf("covariance = %v, want [[0.0004]]", matrix.Covariance)
+	}
+
+	if _, err := optimizer.GetCorrelationMatrix(nil); err == nil {
+		t.Error("expected error for empty asset data")
+	}
+}
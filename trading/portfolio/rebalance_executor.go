@@ -0,0 +1,181 @@
+package portfolio
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"cloudquant/trading"
+)
+
+// maxExecutionHistory 保留的调仓执行记录条数
+const maxExecutionHistory = 200
+
+// 调仓订单执行状态
+const (
+	ExecutionSubmitted = "submitted" // 已提交券商
+	ExecutionDryRun    = "dry_run"   // 模拟执行，仅记录日志
+	ExecutionSkipped   = "skipped"   // 超过换手率限制未提交
+	ExecutionFailed    = "failed"    // 提交失败
+)
+
+// RebalanceExecutor 提交调仓订单，*trading.OrderExecutor 满足该接口
+type RebalanceExecutor interface {
+	ExecuteBuy(ctx context.Context, symbol string, price float64, amount float64) (string, error)
+	ExecuteSell(ctx context.Context, symbol string, price float64, quantity int) (string, error)
+}
+
+var _ RebalanceExecutor = (*trading.OrderExecutor)(nil)
+
+// ExecutionResult 单笔调仓订单的执行结果
+type ExecutionResult struct {
+	Symbol    string    `json:"symbol"`
+	Action    string    `json:"action"`
+	Quantity  int64     `json:"quantity"`
+	Price     float64   `json:"price"`
+	Value     float64   `json:"value"`
+	OrderID   string    `json:"order_id,omitempty"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// SetOrderExecutor 设置调仓订单执行器
+func (p *PortfolioManager) SetOrderExecutor(executor RebalanceExecutor) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.executor = executor
+}
+
+// ExecuteRebalance 依次提交调仓订单：先卖后买以释放现金，累计成交额超过MaxTurnover的订单跳过。
+// DryRun时只记录日志不提交。已提交订单的成交经OnFill调整现金，
+// 有订单提交后从持仓管理器同步持仓
+func (p *PortfolioManager) ExecuteRebalance(ctx context.Context, orders []*RebalanceOrder) ([]ExecutionResult, error) {
+	p.mu.RLock()
+	executor := p.executor
+	dryRun := p.config.DryRun
+	turnoverLimit := p.performance.TotalValue * p.config.MaxTurnover
+	p.mu.RUnlock()
+
+	if executor == nil && !dryRun {
+		return nil, fmt.Errorf("order executor not configured")
+	}
+
+	ordered := make([]*RebalanceOrder, len(orders))
+	copy(ordered, orders)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return ordered[i].Action == "sell" && ordered[j].Action != "sell"
+	})
+
+	results := make([]ExecutionResult, 0, len(ordered))
+	var traded float64
+	submitted := 0
+	for _, order := range ordered {
+		result := ExecutionResult{
+			Symbol:    order.Symbol,
+			Action:    order.Action,
+			Quantity:  order.OrderQuantity,
+			Price:     order.Price,
+			Value:     order.OrderValue,
+			Timestamp: time.Now(),
+		}
+
+		switch {
+		case turnoverLimit > 0 && traded+order.OrderValue > turnoverLimit+1e-6:
+			result.Status = ExecutionSkipped
+			result.Error = fmt.Sprintf("turnover limit %.2f exceeded", turnoverLimit)
+		case dryRun:
+			result.Status = ExecutionDryRun
+			log.Printf("Rebalance dry run: %s %s %d @ %.2f (%.2f)", order.Action, order.Symbol, order.OrderQuantity, order.Price, order.OrderValue)
+		default:
+			var err error
+			if order.Action == "sell" {
+				result.OrderID, err = executor.ExecuteSell(ctx, order.Symbol, order.Price, int(order.OrderQuantity))
+			} else {
+				result.OrderID, err = executor.ExecuteBuy(ctx, order.Symbol, order.Price, order.OrderValue)
+			}
+			if err != nil {
+				result.Status = ExecutionFailed
+				result.Error = err.Error()
+				log.Printf("Rebalance order %s %s failed: %v", order.Action, order.Symbol, err)
+			} else {
+				result.Status = ExecutionSubmitted
+				submitted++
+			}
+		}
+
+		if result.Status == ExecutionSubmitted || result.Status == ExecutionDryRun {
+			traded += order.OrderValue
+		}
+		results = append(results, result)
+	}
+
+	p.recordExecutions(results)
+
+	if submitted > 0 && p.positionManager != nil {
+		if err := p.positionManager.SyncPositions(); err != nil {
+			log.Printf("Sync positions after rebalance failed: %v", err)
+		}
+		if err := p.UpdatePositions(ctx); err != nil {
+			log.Printf("Update portfolio after rebalance failed: %v", err)
+		}
+	}
+
+	log.Printf("Rebalance executed: %d orders, %d submitted, turnover %.2f", len(results), submitted, traded)
+	return results, nil
+}
+
+// recordExecutions 保存执行记录，并登记已提交的订单以便成交时调整现金
+func (p *PortfolioManager) recordExecutions(results []ExecutionResult) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for _, result := range results {
+		if result.Status == ExecutionSubmitted && result.OrderID != "" {
+			p.pendingOrders[result.OrderID] = result.Quantity
+		}
+	}
+
+	p.executions = append(p.executions, results...)
+	if len(p.executions) > maxExecutionHistory {
+		p.executions = p.executions[len(p.executions)-maxExecutionHistory:]
+	}
+}
+
+// OnFill 处理券商成交，由OrderExecutor.AddFillHandler注册：调仓订单的成交按成交金额和佣金调整现金，
+// 其他订单的成交忽略
+func (p *PortfolioManager) OnFill(trade trading.Trade) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	remaining, ok := p.pendingOrders[trade.OrderID]
+	if !ok {
+		return
+	}
+
+	value := trade.Price * float64(trade.Amount)
+	if trade.Type == trading.OrderTypeSell {
+		p.cash += value - trade.Commission
+	} else {
+		p.cash -= value + trade.Commission
+	}
+
+	remaining -= int64(trade.Amount)
+	if remaining <= 0 {
+		delete(p.pendingOrders, trade.OrderID)
+	} else {
+		p.pendingOrders[trade.OrderID] = remaining
+	}
+}
+
+// GetExecutionHistory 获取调仓执行记录，按时间升序
+func (p *PortfolioManager) GetExecutionHistory() []ExecutionResult {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	history := make([]ExecutionResult, len(p.executions))
+	copy(history, p.executions)
+	return history
+}
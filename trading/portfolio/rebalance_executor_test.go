@@ -0,0 +1,132 @@
+package portfolio
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"cloudquant/trading"
+)
+
+// recordingExecutor 记录提交顺序，对failSymbol返回错误
+type recordingExecutor struct {
+	calls      []string
+	failSymbol string
+}
+
+func (r *recordingExecutor) ExecuteBuy(ctx context.Context, symbol string, price float64, amount float64) (string, error) {
+	r.calls = append(r.calls, "buy "+symbol)
+	if symbol == r.failSymbol {
+		return "", errors.New("rejected")
+	}
+	return "B-" + symbol, nil
+}
+
+func (r *recordingExecutor) ExecuteSell(ctx context.Context, symbol string, price float64, quantity int) (string, error) {
+	r.calls = append(r.calls, "sell "+symbol)
+	return "S-" + symbol, nil
+}
+
+func testRebalanceOrders() []*RebalanceOrder {
+	return []*RebalanceOrder{
+		{Symbol: "sh600519", Action: "buy", OrderQuantity: 100, Price: 100, OrderValue: 10000},
+		{Symbol: "sh600036", Action: "buy", OrderQuantity: 500, Price: 30, OrderValue: 15000},
+		{Symbol: "sh600000", Action: "sell", OrderQuantity: 1000, Price: 10, OrderValue: 10000},
+	}
+}
+
+func TestExecuteRebalanceSellsFirstAndHonorsTurnover(t *testing.T) {
+	pm := newTestPortfolioManager(t, 100000)
+	pm.SetConfig(PortfolioConfig{MaxTurnover: 0.3, InitialCapital: 100000})
+	if err := pm.UpdatePositions(context.Background()); err != nil {
+		t.Fatalf("UpdatePositions: %v", err)
+	}
+	executor := &recordingExecutor{failSymbol: "sh600519"}
+	pm.SetOrderExecutor(executor)
+
+	results, err := pm.ExecuteRebalance(context.Background(), testRebalanceOrders())
+	if err != nil {
+		t.Fatalf("ExecuteRebalance: %v", err)
+	}
+
+	// 换手上限30000：卖出10000、买入10000（失败不计入）、买入15000
+	wantCalls := []string{"sell sh600000", "buy sh600519", "buy sh600036"}
+	if len(executor.calls) != len(wantCalls) {
+		t.Fatalf("calls = %v, want %v", executor.calls, wantCalls)
+	}
+	for i, call := range wantCalls {
+		if executor.calls[i] != call {
+			t.Errorf("call %d = %s, want %s", i, executor.calls[i], call)
+		}
+	}
+
+	wantStatus := map[string]string{"sh600000": ExecutionSubmitted, "sh600519": ExecutionFailed, "sh600036": ExecutionSubmitted}
+	for _, result := range results {
+		if result.Status != wantStatus[result.Symbol] {
+			t.Errorf("%s status = %s, want %s", result.Symbol, result.Status, wantStatus[result.Symbol])
+		}
+	}
+	if results[1].Error == "" || results[0].OrderID != "S-sh600000" {
+		t.Errorf("unexpected results: %+v", results)
+	}
+
+	// 提交订单不改变现金，成交回报到达后才调整；非调仓订单的成交忽略
+	if cash := pm.GetCashBalance(); cash != 100000 {
+		t.Errorf("cash = %.2f before fills, want 100000", cash)
+	}
+	pm.OnFill(trading.Trade{OrderID: "S-sh600000", Type: trading.OrderTypeSell, Price: 10, Amount: 1000, Commission: 5})
+	pm.OnFill(trading.Trade{OrderID: "B-sh600036", Type: trading.OrderTypeBuy, Price: 30, Amount: 200, Commission: 5})
+	pm.OnFill(trading.Trade{OrderID: "B-sh600036", Type: trading.OrderTypeBuy, Price: 30, Amount: 300, Commission: 5})
+	pm.OnFill(trading.Trade{OrderID: "manual", Type: trading.OrderTypeBuy, Price: 10, Amount: 100})
+	if cash, want := pm.GetCashBalance(), 100000.0+9995-15010; cash != want {
+		t.Errorf("cash = %.2f after fills, want %.2f", cash, want)
+	}
+	pm.OnFill(trading.Trade{OrderID: "B-sh600036", Type: trading.OrderTypeBuy, Price: 30, Amount: 100})
+	if cash, want := pm.GetCashBalance(), 100000.0+9995-15010; cash != want {
+		t.Errorf("cash = %.2f after a fill beyond the order quantity, want %.2f", cash, want)
+	}
+	if history := pm.GetExecutionHistory(); len(history) != 3 {
+		t.Errorf("execution history has %d entries, want 3", len(history))
+	}
+
+	// 超过换手率上限的订单被跳过
+	pm.SetConfig(PortfolioConfig{MaxTurnover: 0.1, InitialCapital: 100000})
+	executor.calls, executor.failSymbol = nil, ""
+	results, err = pm.ExecuteRebalance(context.Background(), testRebalanceOrders())
+	if err != nil {
+		t.Fatalf("ExecuteRebalance: %v", err)
+	}
+	if len(executor.calls) != 1 || results[1].Status != ExecutionSkipped || results[2].Status != ExecutionSkipped {
+		t.Errorf("calls %v, results %+v; want only the sell submitted", executor.calls, results)
+	}
+}
+
+func TestExecuteRebalanceDryRun(t *testing.T) {
+	pm := newTestPortfolioManager(t, 100000)
+	pm.SetConfig(PortfolioConfig{MaxTurnover: 1, InitialCapital: 100000, DryRun: true})
+
+	if _, err := pm.ExecuteRebalance(context.Background(), testRebalanceOrders()); err != nil {
+		t.Fatalf("dry run without executor: %v", err)
+	}
+
+	executor := &recordingExecutor{}
+	pm.SetOrderExecutor(executor)
+	results, err := pm.ExecuteRebalance(context.Background(), testRebalanceOrders())
+	if err != nil {
+		t.Fatalf("ExecuteRebalance: %v", err)
+	}
+	if len(executor.calls) != 0 {
+		t.Errorf("dry run submitted %v", executor.calls)
+	}
+	for _, result := range results {
+		if result.Status != ExecutionDryRun {
+			t.Errorf("%s status = %s, want dry_run", result.Symbol, result.Status)
+		}
+	}
+
+	pm.SetConfig(PortfolioConfig{MaxTurnover: 1})
+	pm.SetOrderExecutor(nil)
+	if _, err := pm.ExecuteRebalance(context.Background(), testRebalanceOrders()); err == nil {
+		t.Error("executing without an executor should fail")
+	}
+}
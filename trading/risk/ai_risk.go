@@ -5,6 +5,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,9 +21,11 @@ type AIRisk struct {
 	config          *AIRiskConfig
 	llmAnalyzer     *llm.DeepSeekAnalyzer
 	scoreCache      map[string]*RiskScore // 风险评分缓存
-	analysisHistory []RiskAnalysis        // 分析历史
+	analysisHistory []RiskAnalysis        // 最近的分析历史缓存
+	store           AnalysisStore         // 分析历史持久化存储，nil表示仅保存在内存
 	positionManager *trading.PositionManager
-	lastAnalysis    time.Time
+	lastAnalysis    time.Time            // 最近一次分析时间（任意股票）
+	symbolAnalysis  map[string]time.Time // 各股票最近一次分析时间
 }
 
 // RiskScore AI风险评分
@@ -51,14 +56,34 @@ type RiskAnalysis struct {
 
 // AIRiskConfig AI风险配置
 type AIRiskConfig struct {
-	Enabled           bool          `yaml:"enabled"`            // 是否启用
-	AnalysisInterval  time.Duration `yaml:"analysis_interval"`  // 分析间隔
-	CacheExpiry       time.Duration `yaml:"cache_expiry"`       // 缓存过期时间
-	RiskThreshold     float64       `yaml:"risk_threshold"`     // 风险阈值
-	AutoAlert         bool          `yaml:"auto_alert"`         // 自动告警
-	DeepLearning      bool          `yaml:"deep_learning"`      // 深度学习分析
-	SentimentAnalysis bool          `yaml:"sentiment_analysis"` // 情绪分析
-	NewsAnalysis      bool          `yaml:"news_analysis"`      // 新闻分析
+	Enabled           bool          `yaml:"enabled"`             // 是否启用
+	AnalysisInterval  time.Duration `yaml:"analysis_interval"`   // 同一股票的分析间隔
+	MinGlobalInterval time.Duration `yaml:"min_global_interval"` // 任意两次分析的最小间隔，0表示不限制
+	CacheExpiry       time.Duration `yaml:"cache_expiry"`        // 缓存过期时间
+	RiskThreshold     float64       `yaml:"risk_threshold"`      // 风险阈值
+	AutoAlert         bool          `yaml:"auto_alert"`          // 自动告警
+	DeepLearning      bool          `yaml:"deep_learning"`       // 深度学习分析
+	SentimentAnalysis bool          `yaml:"sentiment_analysis"`  // 情绪分析
+	NewsAnalysis      bool          `yaml:"news_analysis"`       // 新闻分析
+	PersistHistory    bool          `yaml:"persist_history"`     // 持久化分析历史
+}
+
+// maxHistoryCache 内存中保留的分析历史条数
+const maxHistoryCache = 1000
+
+// AnalysisQuery 分析历史查询条件
+type AnalysisQuery struct {
+	Symbol string    // 股票代码，为空表示全部
+	From   time.Time // 起始时间（含），零值表示不限
+	To     time.Time // 结束时间（含），零值表示不限
+	Offset int       // 跳过的条数
+	Limit  int       // 返回条数，<=0表示不限
+}
+
+// AnalysisStore 分析历史持久化存储，查询结果按时间倒序
+type AnalysisStore interface {
+	SaveAnalysis(analysis RiskAnalysis) error
+	QueryAnalyses(query AnalysisQuery) ([]RiskAnalysis, error)
 }
 
 // NewAIRisk 创建AI风险评分器
@@ -69,6 +94,7 @@ func NewAIRisk(config AIRiskConfig, llmAnalyzer *llm.DeepSeekAnalyzer, positionM
 		scoreCache:      make(map[string]*RiskScore),
 		analysisHistory: make([]RiskAnalysis, 0, 100),
 		positionManager: positionManager,
+		symbolAnalysis:  make(map[string]time.Time),
 	}
 }
 
@@ -88,13 +114,13 @@ func (a *AIRisk) AnalyzeRisk(ctx context.Context, symbol string, marketData map[
 	}
 
 	// 检查分析间隔
-	if a.shouldSkipAnalysis() {
+	if a.shouldSkipAnalysis(symbol) {
 		log.Printf("Skipping AI analysis for %s due to rate limiting", symbol)
 		return a.generateDefaultScore(symbol), nil
 	}
 
 	// 执行AI分析
-	score, err := a.performAIRiskAnalysis(ctx, symbol, marketData)
+	score, rawAnalysis, err := a.performAIRiskAnalysis(ctx, symbol, marketData)
 	if err != nil {
 		log.Printf("AI risk analysis failed for %s: %v", symbol, err)
 		return a.generateDefaultScore(symbol), nil
@@ -102,16 +128,19 @@ func (a *AIRisk) AnalyzeRisk(ctx context.Context, symbol string, marketData map[
 
 	// 缓存结果
 	a.scoreCache[symbol] = score
-	a.lastAnalysis = time.Now()
+	a.markAnalyzed(symbol, time.Now())
 
 	// 添加到历史
 	analysis := RiskAnalysis{
-		Symbol:     symbol,
-		Score:      score,
-		MarketData: a.serializeMarketData(marketData),
-		Timestamp:  time.Now(),
+		Symbol:      symbol,
+		Score:       score,
+		RawAnalysis: rawAnalysis,
+		MarketData:  a.serializeMarketData(marketData),
+		Timestamp:   time.Now(),
+	}
+	if err := a.recordAnalysis(analysis); err != nil {
+		log.Printf("Failed to persist AI risk analysis for %s: %v", symbol, err)
 	}
-	a.addToHistory(analysis)
 
 	// 检查是否需要告警
 	if a.config.AutoAlert && score.OverallScore > a.config.RiskThreshold {
@@ -124,10 +153,10 @@ func (a *AIRisk) AnalyzeRisk(ctx context.Context, symbol string, marketData map[
 	return score, nil
 }
 
-// performAIRiskAnalysis 执行具体的AI风险分析
-func (a *AIRisk) performAIRiskAnalysis(ctx context.Context, symbol string, marketData map[string]interface{}) (*RiskScore, error) {
+// performAIRiskAnalysis 执行具体的AI风险分析，返回评分和AI原始分析文本
+func (a *AIRisk) performAIRiskAnalysis(ctx context.Context, symbol string, marketData map[string]interface{}) (*RiskScore, string, error) {
 	if a.llmAnalyzer == nil {
-		return nil, fmt.Errorf("LLM analyzer not initialized")
+		return nil, "", fmt.Errorf("LLM analyzer not initialized")
 	}
 
 	// 构建分析提示
@@ -136,16 +165,16 @@ func (a *AIRisk) performAIRiskAnalysis(ctx context.Context, symbol string, marke
 	// 调用AI分析
 	response, err := a.llmAnalyzer.AnalyzePrompt(ctx, prompt)
 	if err != nil {
-		return nil, fmt.Errorf("AI analysis failed: %v", err)
+		return nil, "", fmt.Errorf("AI analysis failed: %v", err)
 	}
 
 	// 解析AI响应
 	score, err := a.parseRiskScoreResponse(response, symbol)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse AI response: %v", err)
+		return nil, "", fmt.Errorf("failed to parse AI response: %v", err)
 	}
 
-	return score, nil
+	return score, response, nil
 }
 
 // buildRiskAnalysisPrompt 构建风险分析提示
@@ -157,18 +186,7 @@ func (a *AIRisk) buildRiskAnalysisPrompt(symbol string, marketData map[string]in
 `, symbol, symbol)
 
 	// 添加市场数据
-	if data, ok := marketData["price"].(float64); ok {
-		prompt += fmt.Sprintf("- 当前价格: %.2f\n", data)
-	}
-	if data, ok := marketData["volume"].(int64); ok {
-		prompt += fmt.Sprintf("- 成交量: %d\n", data)
-	}
-	if data, ok := marketData["change_percent"].(float64); ok {
-		prompt += fmt.Sprintf("- 涨跌幅: %.2f%%\n", data)
-	}
-	if data, ok := marketData["pe_ratio"].(float64); ok {
-		prompt += fmt.Sprintf("- PE比率: %.2f\n", data)
-	}
+	prompt += formatMarketData(marketData)
 
 	prompt += `
 请从以下维度进行风险评估（每个维度0-1分，分数越高风险越大）：
@@ -201,6 +219,94 @@ func (a *AIRisk) buildRiskAnalysisPrompt(symbol string, marketData map[string]in
 	return prompt
 }
 
+// marketDataFields 常用市场数据字段的展示名称和格式，按此顺序优先输出
+var marketDataFields = []struct {
+	key    string
+	label  string
+	format string
+}{
+	{"price", "当前价格", "%.2f"},
+	{"volume", "成交量", "%.0f"},
+	{"change_percent", "涨跌幅", "%.2f%%"},
+	{"pe_ratio", "PE比率", "%.2f"},
+}
+
+// formatMarketData 将市场数据格式化为提示行，已知字段在前，其余字段按键名排序
+func formatMarketData(marketData map[string]interface{}) string {
+	var b strings.Builder
+	known := make(map[string]bool, len(marketDataFields))
+
+	for _, field := range marketDataFields {
+		known[field.key] = true
+		value, ok := marketData[field.key]
+		if !ok || value == nil {
+			continue
+		}
+		if f, ok := toFloat(value); ok {
+			fmt.Fprintf(&b, "- %s: "+field.format+"\n", field.label, f)
+		} else {
+			fmt.Fprintf(&b, "- %s: %v\n", field.label, value)
+		}
+	}
+
+	keys := make([]string, 0, len(marketData))
+	for key := range marketData {
+		if !known[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := marketData[key]
+		if value == nil {
+			continue
+		}
+		if f, ok := toFloat(value); ok {
+			fmt.Fprintf(&b, "- %s: %s\n", key, strconv.FormatFloat(f, 'f', -1, 64))
+		} else {
+			fmt.Fprintf(&b, "- %s: %v\n", key, value)
+		}
+	}
+
+	return b.String()
+}
+
+// toFloat 将各种数值类型转换为float64
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case float32:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case int8:
+		return float64(n), true
+	case int16:
+		return float64(n), true
+	case int32:
+		return float64(n), true
+	case int64:
+		return float64(n), true
+	case uint:
+		return float64(n), true
+	case uint8:
+		return float64(n), true
+	case uint16:
+		return float64(n), true
+	case uint32:
+		return float64(n), true
+	case uint64:
+		return float64(n), true
+	case json.Number:
+		f, err := n.Float64()
+		return f, err == nil
+	default:
+		return 0, false
+	}
+}
+
 // parseRiskScoreResponse 解析AI风险评分响应
 func (a *AIRisk) parseRiskScoreResponse(response string, symbol string) (*RiskScore, error) {
 	var aiData struct {
@@ -214,8 +320,8 @@ func (a *AIRisk) parseRiskScoreResponse(response string, symbol string) (*RiskSc
 		Recommendations []string `json:"recommendations"`
 	}
 
-	// 尝试解析JSON
-	if err := json.Unmarshal([]byte(response), &aiData); err != nil {
+	// 尝试解析JSON，兼容代码块包裹和前后说明文字
+	if err := llm.UnmarshalJSONResponse(response, &aiData); err != nil {
 		// 如果不是JSON，尝试提取关键信息
 		return a.extractRiskInfoFromText(response, symbol), nil
 	}
@@ -268,17 +374,17 @@ func (a *AIRisk) extractRiskInfoFromText(text string, symbol string) *RiskScore
 	text = fmt.Sprintf(" %s ", text)
 
 	// 检测风险关键词
-	if containsAny(text, []string{"高风险", "高风险", "风险较大", "注意风险", "谨慎", "避免"}) {
+	if llm.ContainsAnyKeyword(text, []string{"高风险", "风险较大", "注意风险", "谨慎", "避免"}) {
 		score.OverallScore = 0.7
 		score.RiskLevel = "high"
 	}
 
-	if containsAny(text, []string{"低风险", "风险较低", "安全", "稳健", "推荐"}) {
+	if llm.ContainsAnyKeyword(text, []string{"低风险", "风险较低", "安全", "稳健", "推荐"}) {
 		score.OverallScore = 0.3
 		score.RiskLevel = "low"
 	}
 
-	if containsAny(text, []string{"极高风险", "极高风险", "非常危险", "强烈不建议", "避免投资"}) {
+	if llm.ContainsAnyKeyword(text, []string{"极高风险", "非常危险", "强烈不建议", "避免投资"}) {
 		score.OverallScore = 0.9
 		score.RiskLevel = "extreme"
 	}
@@ -315,13 +421,49 @@ func (a *AIRisk) getCachedScore(symbol string) *RiskScore {
 	return score
 }
 
-// shouldSkipAnalysis 检查是否应该跳过分析（基于频率限制）
-func (a *AIRisk) shouldSkipAnalysis() bool {
-	if a.lastAnalysis.IsZero() {
+// shouldSkipAnalysis 检查是否应该跳过分析（基于频率限制）。
+// 每只股票按AnalysisInterval独立限频，MinGlobalInterval限制所有股票的总体频率。
+func (a *AIRisk) shouldSkipAnalysis(symbol string) bool {
+	if a.config.MinGlobalInterval > 0 && !a.lastAnalysis.IsZero() &&
+		time.Since(a.lastAnalysis) < a.config.MinGlobalInterval {
+		return true
+	}
+
+	last, ok := a.symbolAnalysis[symbol]
+	if !ok {
 		return false
 	}
 
-	return time.Since(a.lastAnalysis) < a.config.AnalysisInterval
+	return time.Since(last) < a.config.AnalysisInterval
+}
+
+// markAnalyzed 记录股票的分析时间
+func (a *AIRisk) markAnalyzed(symbol string, at time.Time) {
+	a.symbolAnalysis[symbol] = at
+	a.lastAnalysis = at
+}
+
+// SetAnalysisStore 设置分析历史持久化存储
+func (a *AIRisk) SetAnalysisStore(store AnalysisStore) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.store = store
+}
+
+// RecordAnalysis 记录一次分析结果：写入内存缓存，设置了存储时同时持久化
+func (a *AIRisk) RecordAnalysis(analysis RiskAnalysis) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.recordAnalysis(analysis)
+}
+
+// recordAnalysis 记录分析结果，调用方需持有锁
+func (a *AIRisk) recordAnalysis(analysis RiskAnalysis) error {
+	a.addToHistory(analysis)
+	if a.store == nil {
+		return nil
+	}
+	return a.store.SaveAnalysis(analysis)
 }
 
 // addToHistory 添加到分析历史
@@ -329,7 +471,7 @@ func (a *AIRisk) addToHistory(analysis RiskAnalysis) {
 	a.analysisHistory = append(a.analysisHistory, analysis)
 
 	// 限制历史长度
-	if len(a.analysisHistory) > 1000 {
+	if len(a.analysisHistory) > maxHistoryCache {
 		a.analysisHistory = a.analysisHistory[1:]
 	}
 }
@@ -377,8 +519,9 @@ func (a *AIRisk) triggerRiskAlert(symbol string, score *RiskScore) {
 
 // GetRiskScore 获取指定股票的风险评分
 func (a *AIRisk) GetRiskScore(symbol string) (*RiskScore, bool) {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
+	// getCachedScore会删除过期评分，需要写锁
+	a.mu.Lock()
+	defer a.mu.Unlock()
 
 	score := a.getCachedScore(symbol)
 	return score, score != nil
@@ -397,19 +540,48 @@ func (a *AIRisk) GetAllRiskScores() map[string]*RiskScore {
 	return result
 }
 
-// GetAnalysisHistory 获取分析历史
-func (a *AIRisk) GetAnalysisHistory(symbol string, limit int) []RiskAnalysis {
+// GetAnalysisHistory 按时间范围分页查询分析历史，按时间倒序。
+// 设置了持久化存储时从存储查询，否则只查询内存中最近的记录。
+func (a *AIRisk) GetAnalysisHistory(query AnalysisQuery) ([]RiskAnalysis, error) {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
 
+	if a.store != nil {
+		return a.store.QueryAnalyses(query)
+	}
+
 	var history []RiskAnalysis
-	for i := len(a.analysisHistory) - 1; i >= 0 && len(history) < limit; i-- {
-		if a.analysisHistory[i].Symbol == symbol {
-			history = append(history, a.analysisHistory[i])
+	skipped := 0
+	for i := len(a.analysisHistory) - 1; i >= 0; i-- {
+		analysis := a.analysisHistory[i]
+		if !query.Matches(analysis) {
+			continue
+		}
+		if skipped < query.Offset {
+			skipped++
+			continue
+		}
+		history = append(history, analysis)
+		if query.Limit > 0 && len(history) >= query.Limit {
+			break
 		}
 	}
 
-	return history
+	return history, nil
+}
+
+// Matches 检查分析记录是否满足股票和时间范围条件
+func (q AnalysisQuery) Matches(analysis RiskAnalysis) bool {
+	if q.Symbol != "" && analysis.Symbol != q.Symbol {
+		return false
+	}
+	if !q.From.IsZero() && analysis.Timestamp.Before(q.From) {
+		return false
+	}
+	if !q.To.IsZero() && analysis.Timestamp.After(q.To) {
+		return false
+	}
+	return true
 }
 
 // GetPortfolioRiskScore 获取组合风险评分
@@ -430,9 +602,11 @@ func (a *AIRisk) GetPortfolioRiskScore(ctx context.Context) (*PortfolioAIRiskSco
 	var totalValue float64
 	highRiskCount := 0
 
+	// 读取缓存时可能淘汰过期评分，遍历期间持有写锁
+	a.mu.Lock()
 	for _, pos := range positions {
-		score, exists := a.GetRiskScore(pos.Symbol)
-		if !exists {
+		score := a.getCachedScore(pos.Symbol)
+		if score == nil {
 			// 如果没有评分，使用默认值
 			score = a.generateDefaultScore(pos.Symbol)
 		}
@@ -444,6 +618,17 @@ func (a *AIRisk) GetPortfolioRiskScore(ctx context.Context) (*PortfolioAIRiskSco
 			highRiskCount++
 		}
 	}
+	a.mu.Unlock()
+
+	// 刚同步的账户价格可能尚未更新，市值为零时无法加权
+	if totalValue <= 0 {
+		return &PortfolioAIRiskScore{
+			OverallScore: 0.0,
+			RiskLevel:    "low",
+			Message:      fmt.Sprintf("组合包含 %d 只股票，但持仓市值为零，无法评估风险", len(positions)),
+			Timestamp:    time.Now(),
+		}, nil
+	}
 
 	portfolioRisk := totalRisk / totalValue
 
@@ -543,22 +728,3 @@ type AIRiskStats struct {
 	LastAnalysis    time.Time `json:"last_analysis"`
 	Enabled         bool      `json:"enabled"`
 }
-
-// 工具函数
-func containsAny(text string, keywords []string) bool {
-	for _, keyword := range keywords {
-		if len(keyword) > 0 && contains(text, keyword) {
-			return true
-		}
-	}
-	return false
-}
-
-func contains(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
@@ -0,0 +1,173 @@
+package risk
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"cloudquant/trading"
+)
+
+func TestBuildRiskAnalysisPromptMixedNumericTypes(t *testing.T) {
+	a := &AIRisk{}
+
+	prompt := a.buildRiskAnalysisPrompt("sh600000", map[string]interface{}{
+		"price":          json.Number("12.345"),
+		"volume":         int(1500000),
+		"change_percent": float32(-1.5),
+		"pe_ratio":       int32(8),
+		"turnover_rate":  json.Number("2.5"),
+		"amount":         uint64(18500000),
+		"industry":       "银行",
+		"ignored":        nil,
+	})
+
+	want := []string{
+		"- 当前价格: 12.35\n",
+		"- 成交量: 1500000\n",
+		"- 涨跌幅: -1.50%\n",
+		"- PE比率: 8.00\n",
+		"- amount: 18500000\n",
+		"- industry: 银行\n",
+		"- turnover_rate: 2.5\n",
+	}
+	for _, line := range want {
+		if !strings.Contains(prompt, line) {
+			t.Errorf("prompt missing %q\n%s", line, prompt)
+		}
+	}
+	if strings.Contains(prompt, "ignored") {
+		t.Errorf("prompt should skip nil values\n%s", prompt)
+	}
+
+	// 已知字段在前，其余字段按键名排序
+	if strings.Index(prompt, "PE比率") > strings.Index(prompt, "amount") ||
+		strings.Index(prompt, "amount") > strings.Index(prompt, "industry") ||
+		strings.Index(prompt, "industry") > strings.Index(prompt, "turnover_rate") {
+		t.Errorf("unexpected field order\n%s", prompt)
+	}
+}
+
+func TestToFloat(t *testing.T) {
+	tests := []struct {
+		in   interface{}
+		want float64
+		ok   bool
+	}{
+		{float64(1.5), 1.5, true},
+		{float32(2.5), 2.5, true},
+		{int(3), 3, true},
+		{int64(4), 4, true},
+		{uint32(5), 5, true},
+		{json.Number("6.25"), 6.25, true},
+		{json.Number("abc"), 0, false},
+		{"7", 0, false},
+		{nil, 0, false},
+	}
+
+	for _, tt := range tests {
+		got, ok := toFloat(tt.in)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("toFloat(%#v) = %v, %v; want %v, %v", tt.in, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestAIRiskThrottlesPerSymbol(t *testing.T) {
+	a := NewAIRisk(AIRiskConfig{
+		Enabled:          true,
+		AnalysisInterval: time.Hour,
+	}, nil, nil)
+
+	if a.shouldSkipAnalysis("sh600000") {
+		t.Fatal("first analysis of a symbol should not be skipped")
+	}
+
+	a.markAnalyzed("sh600000", time.Now())
+
+	if !a.shouldSkipAnalysis("sh600000") {
+		t.Error("expected sh600000 to be throttled within the analysis interval")
+	}
+	if a.shouldSkipAnalysis("sh600519") {
+		t.Error("analyzing sh600000 should not throttle sh600519")
+	}
+
+	a.markAnalyzed("sh600000", time.Now().Add(-2*time.Hour))
+	if a.shouldSkipAnalysis("sh600000") {
+		t.Error("expected sh600000 to be analyzable after the interval elapsed")
+	}
+}
+
+func TestAIRiskMinGlobalInterval(t *testing.T) {
+	a := NewAIRisk(AIRiskConfig{
+		Enabled:           true,
+		AnalysisInterval:  time.Hour,
+		MinGlobalInterval: time.Minute,
+	}, nil, nil)
+
+	a.markAnalyzed("sh600000", time.Now())
+	if !a.shouldSkipAnalysis("sh600519") {
+		t.Error("expected global minimum interval to throttle other symbols")
+	}
+
+	a.markAnalyzed("sh600000", time.Now().Add(-2*time.Minute))
+	if a.shouldSkipAnalysis("sh600519") {
+		t.Error("expected sh600519 to be analyzable after the global interval elapsed")
+	}
+}
+
+func TestParseRiskScoreResponseFencedJSON(t *testing.T) {
+	a := NewAIRisk(AIRiskConfig{}, nil, nil)
+	response := "以下是风险评估结果：\n```json\n" +
+		`{"market_risk": 0.6, "technical_risk": 0.4, "fundamental_risk": 0.5, "volatility_risk": 0.8, "trend_risk": 0.3, "volume_risk": 0.4, "ai_confidence": 0.9, "recommendations": ["控制仓位"]}` +
+		"\n```\n请注意风险。"
+
+	score, err := a.parseRiskScoreResponse(response, "sh600000")
+	if err != nil {
+		t.Fatalf("parseRiskScoreResponse: %v", err)
+	}
+	if score.ModelVersion != "deepseek-v1" {
+		t.Fatalf("fenced JSON fell back to text extraction (model version %q)", score.ModelVersion)
+	}
+	if score.VolatilityRisk != 0.8 || score.AIConfidence != 0.9 || len(score.Recommendations) != 1 {
+		t.Errorf("parsed score = %+v", score)
+	}
+}
+
+func TestPortfolioRiskScoreZeroValuePositions(t *testing.T) {
+	connector, err := trading.NewBrokerConnector(trading.BrokerConfig{Type: "easytrader"})
+	if err != nil {
+		t.Fatalf("NewBrokerConnector: %v", err)
+	}
+	positions := trading.NewPositionManager(connector)
+	// 价格未同步的持仓市值为零
+	for _, symbol := range []string{"sh600000", "sh600519"} {
+		if err := positions.UpdatePosition(trading.Trade{Symbol: symbol, Type: "buy", Price: 0, Amount: 100}); err != nil {
+			t.Fatalf("UpdatePosition: %v", err)
+		}
+	}
+
+	a := NewAIRisk(AIRiskConfig{CacheExpiry: time.Hour}, nil, positions)
+	score, err := a.GetPortfolioRiskScore(context.Background())
+	if err != nil {
+		t.Fatalf("GetPortfolioRiskScore: %v", err)
+	}
+	if math.IsNaN(score.OverallScore) || score.OverallScore != 0 || score.RiskLevel != "low" {
+		t.Errorf("zero-value portfolio score = %v (%s), want 0 (low)", score.OverallScore, score.RiskLevel)
+	}
+
+	// 有市值后按市值加权，未分析的股票使用默认0.5
+	if err := positions.UpdatePosition(trading.Trade{Symbol: "sh600036", Type: "buy", Price: 30, Amount: 100}); err != nil {
+		t.Fatalf("UpdatePosition: %v", err)
+	}
+	score, err = a.GetPortfolioRiskScore(context.Background())
+	if err != nil {
+		t.Fatalf("GetPortfolioRiskScore: %v", err)
+	}
+	if score.OverallScore != 0.5 || score.TotalValue != 3000 {
+		t.Errorf("portfolio score = %v over %.2f, want 0.5 over 3000", score.OverallScore, score.TotalValue)
+	}
+}
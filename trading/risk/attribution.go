@@ -80,10 +80,12 @@ func (am *AttributionManager) CalculateAttribution(
 	return &attribution
 }
 
+// CalculateFactorExposure 根据持仓权重和按日期正序排列的组合、基准日收益率计算因子暴露，
+// 未提供基准收益率时不计算beta
 func (am *AttributionManager) CalculateFactorExposure(
 	portfolio map[string]float64,
+	portfolioReturns []float64,
 	benchmarkReturns []float64,
-	lookbackDays int,
 ) map[string]float64 {
 	am.mu.Lock()
 	defer am.mu.Unlock()
@@ -106,9 +108,9 @@ func (am *AttributionManager) CalculateFactorExposure(
 	avgWeight := sum / float64(len(portfolioValues))
 	exposure["concentration"] = avgWeight
 
-	portfolioReturns := am.calculatePortfolioReturns(portfolioValues, lookbackDays)
-
-	exposure["beta"] = am.calculateBeta(portfolioReturns, benchmarkReturns)
+	if len(benchmarkReturns) > 0 {
+		exposure["beta"] = am.calculateBeta(portfolioReturns, benchmarkReturns)
+	}
 
 	exposure["volatility"] = am.calculateVolatility(portfolioReturns)
 
@@ -125,21 +127,6 @@ func (am *AttributionManager) CalculateFactorExposure(
 	return exposure
 }
 
-func (am *AttributionManager) calculatePortfolioReturns(values []float64, days int) []float64 {
-	if days <= 0 || days > len(values) {
-		days = len(values)
-	}
-
-	returns := make([]float64, days-1)
-	for i := 1; i < days; i++ {
-		if values[i-1] > 0 {
-			returns[i-1] = (values[i] - values[i-1]) / values[i-1]
-		}
-	}
-
-	return returns
-}
-
 func (am *AttributionManager) calculateBeta(portfolioReturns, benchmarkReturns []float64) float64 {
 	if len(portfolioReturns) == 0 || len(benchmarkReturns) == 0 {
 		return 1.0
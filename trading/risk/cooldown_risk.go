@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"strings"
 	"sync"
 	"time"
 
@@ -216,7 +217,7 @@ func (c *CooldownRisk) GetCooldownStatus(symbol string) *CooldownStatus {
 		TradeCount:        c.tradeCounts[symbol],
 		DailyTrades:       c.getDailyTradeCount(symbol, now),
 		WeeklyTrades:      c.getWeeklyTradeCount(symbol, now),
-		Blacklisted:       c.isBlacklisted(symbol),
+		Blacklisted:       now.Before(c.blacklist[symbol]),
 		CooldownRemaining: 0,
 	}
 
@@ -236,9 +237,13 @@ func (c *CooldownRisk) GetAllCooldownStatus() map[string]*CooldownStatus {
 	status := make(map[string]*CooldownStatus)
 
 	c.mu.RLock()
-	defer c.mu.RUnlock()
-
+	symbols := make([]string, 0, len(c.lastTrades))
 	for symbol := range c.lastTrades {
+		symbols = append(symbols, symbol)
+	}
+	c.mu.RUnlock()
+
+	for _, symbol := range symbols {
 		status[symbol] = c.GetCooldownStatus(symbol)
 	}
 
@@ -247,6 +252,11 @@ func (c *CooldownRisk) GetAllCooldownStatus() map[string]*CooldownStatus {
 
 // RemoveFromBlacklist 从黑名单移除
 func (c *CooldownRisk) RemoveFromBlacklist(symbol string) {
+	c.ClearBlacklist(symbol)
+}
+
+// ClearBlacklist 手动解除股票的黑名单，用于止损后需要提前放行的情况
+func (c *CooldownRisk) ClearBlacklist(symbol string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
@@ -254,6 +264,29 @@ func (c *CooldownRisk) RemoveFromBlacklist(symbol string) {
 	log.Printf("Removed %s from blacklist", symbol)
 }
 
+// GetBlacklist 获取当前生效的黑名单（股票代码 -> 解除时间），已过期的条目会被清理
+func (c *CooldownRisk) GetBlacklist() map[string]time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	blacklist := make(map[string]time.Time, len(c.blacklist))
+	for symbol, until := range c.blacklist {
+		if c.isBlacklisted(symbol) {
+			blacklist[symbol] = until
+		}
+	}
+	return blacklist
+}
+
+// IsAllowed 判断股票当前是否允许交易，不允许时返回原因
+func (c *CooldownRisk) IsAllowed(symbol string) (bool, string) {
+	result, err := c.CheckTradeCooldown(context.Background(), symbol)
+	if err != nil {
+		return false, err.Error()
+	}
+	return result.Allowed, strings.Join(result.Reasons, "; ")
+}
+
 // AddToBlacklist 添加到黑名单
 func (c *CooldownRisk) AddToBlacklist(symbol string, duration time.Duration) {
 	c.mu.Lock()
@@ -263,6 +296,15 @@ func (c *CooldownRisk) AddToBlacklist(symbol string, duration time.Duration) {
 	log.Printf("Added %s to blacklist for %v", symbol, duration)
 }
 
+// BlacklistAfterStopLoss 止损后按配置的黑名单时长禁止再次交易该股票，未启用冷却或时长为0时不处理
+func (c *CooldownRisk) BlacklistAfterStopLoss(symbol string) {
+	config := c.GetConfig()
+	if !config.EnableCooldown || config.BlacklistDuration <= 0 {
+		return
+	}
+	c.AddToBlacklist(symbol, config.BlacklistDuration)
+}
+
 // ResetCooldown 重置冷却状态
 func (c *CooldownRisk) ResetCooldown(symbol string) {
 	c.mu.Lock()
@@ -270,7 +312,7 @@ func (c *CooldownRisk) ResetCooldown(symbol string) {
 
 	delete(c.lastTrades, symbol)
 	delete(c.tradeCounts, symbol)
-	c.RemoveFromBlacklist(symbol)
+	delete(c.blacklist, symbol)
 	log.Printf("Reset cooldown for %s", symbol)
 }
 
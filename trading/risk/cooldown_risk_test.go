@@ -0,0 +1,63 @@
+package risk
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCooldownBlacklistClear(t *testing.T) {
+	c := NewCooldownRisk(CooldownRiskConfig{
+		MaxDailyTrades:  100,
+		MaxWeeklyTrades: 100,
+		EnableCooldown:  true,
+	}, nil)
+
+	c.AddToBlacklist("sh600000", time.Hour)
+	c.AddToBlacklist("sz000001", -time.Minute)
+
+	blacklist := c.GetBlacklist()
+	if _, ok := blacklist["sh600000"]; !ok || len(blacklist) != 1 {
+		t.Fatalf("GetBlacklist() = %v, want only sh600000", blacklist)
+	}
+	if stats := c.GetStats(); stats.BlacklistedSymbols != 1 {
+		t.Errorf("expired entry not pruned: %d blacklisted", stats.BlacklistedSymbols)
+	}
+
+	allowed, reason := c.IsAllowed("sh600000")
+	if allowed || !strings.Contains(reason, "黑名单") {
+		t.Errorf("IsAllowed(blacklisted) = %v, %q", allowed, reason)
+	}
+
+	c.ClearBlacklist("sh600000")
+	if allowed, reason := c.IsAllowed("sh600000"); !allowed || reason != "" {
+		t.Errorf("IsAllowed after clear = %v, %q", allowed, reason)
+	}
+	if len(c.GetBlacklist()) != 0 {
+		t.Errorf("blacklist not empty after clear: %v", c.GetBlacklist())
+	}
+}
+
+func TestCooldownResetAndStatusDoNotDeadlock(t *testing.T) {
+	c := NewCooldownRisk(CooldownRiskConfig{MinTradeInterval: time.Minute, EnableCooldown: true}, nil)
+	c.RecordTrade("sh600000", "buy", 10)
+	c.AddToBlacklist("sh600000", time.Hour)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		if status := c.GetAllCooldownStatus()["sh600000"]; status == nil || !status.Blacklisted {
+			t.Errorf("status = %+v, want blacklisted", status)
+		}
+		c.ResetCooldown("sh600000")
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("status/reset deadlocked")
+	}
+	if len(c.GetBlacklist()) != 0 {
+		t.Error("ResetCooldown did not clear blacklist")
+	}
+}
@@ -5,6 +5,8 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"sort"
 	"sync"
 	"time"
 
@@ -54,8 +56,10 @@ type RealtimeRiskMonitor struct {
 	alertCallback   func(RiskEvent)
 
 	riskLimits     map[string]*RiskLimit
+	limitsLock     sync.RWMutex // 保护riskLimits，监控循环与限额查询、修改并发进行
 	riskEvents     []RiskEvent
 	riskEventsLock sync.RWMutex
+	eventCounts    map[riskEventKey]int64 // 启动以来按类型和级别累计的事件数，不受历史截断影响
 
 	checkInterval time.Duration
 	stopChan      chan struct{}
@@ -63,13 +67,57 @@ type RealtimeRiskMonitor struct {
 
 	exposureCache map[string]float64
 	exposureLock  sync.RWMutex
+
+	priceHistory     map[string][]pricePoint // 按采样间隔记录的持仓价格，用于计算已实现波动率
+	priceLock        sync.Mutex
+	volatilityWindow int
+	sampleInterval   time.Duration
+
+	autoStopLoss      bool
+	stopLossExecutor  StopLossExecutor
+	stopLossSubmitted map[string]time.Time // 已提交止损的股票，持仓清空前不再重复提交
+	stopLossLock      sync.Mutex
+
+	alertCooldown time.Duration
+	breaches      map[string]*breachState // 按"限额|股票"记录仍在越限的告警
+	breachLock    sync.Mutex
+}
+
+// breachState 越限状态，用于告警冷却和恢复通知
+type breachState struct {
+	level     RiskLevel
+	lastAlert time.Time
+}
+
+// StopLossExecutor 止损执行器，trading.OrderExecutor实现该接口
+type StopLossExecutor interface {
+	ExecuteStopLoss(ctx context.Context, symbol string, currentPrice float64) error
+}
+
+var _ StopLossExecutor = (*trading.OrderExecutor)(nil)
+
+// pricePoint 价格采样点
+type pricePoint struct {
+	price float64
+	time  time.Time
 }
 
+const (
+	// tradingSecondsPerYear 每年交易时长（252个交易日，每日4小时），用于年化波动率
+	tradingSecondsPerYear = 252 * 4 * 3600
+)
+
 // MonitorConfig 监控配置
 type MonitorConfig struct {
 	CheckInterval      time.Duration
 	MaxEventHistory    int
 	EnableAutoStopLoss bool
+	// VolatilityWindow 计算已实现波动率使用的收益率个数，默认30
+	VolatilityWindow int
+	// VolatilitySampleInterval 价格采样间隔，默认1分钟
+	VolatilitySampleInterval time.Duration
+	// AlertCooldown 同一限额和股票持续越限时重复告警的最小间隔，默认5分钟
+	AlertCooldown time.Duration
 }
 
 // NewRealtimeRiskMonitor 创建实时风控监控器
@@ -77,15 +125,32 @@ func NewRealtimeRiskMonitor(riskManager *trading.RiskManager, positionManager *t
 	if config.CheckInterval == 0 {
 		config.CheckInterval = 5 * time.Second
 	}
+	if config.VolatilityWindow <= 1 {
+		config.VolatilityWindow = 30
+	}
+	if config.AlertCooldown <= 0 {
+		config.AlertCooldown = 5 * time.Minute
+	}
+	if config.VolatilitySampleInterval <= 0 {
+		config.VolatilitySampleInterval = time.Minute
+	}
 
 	monitor := &RealtimeRiskMonitor{
-		riskManager:     riskManager,
-		positionManager: positionManager,
-		checkInterval:   config.CheckInterval,
-		stopChan:        make(chan struct{}),
-		riskLimits:      make(map[string]*RiskLimit),
-		riskEvents:      make([]RiskEvent, 0, config.MaxEventHistory),
-		exposureCache:   make(map[string]float64),
+		riskManager:       riskManager,
+		positionManager:   positionManager,
+		checkInterval:     config.CheckInterval,
+		stopChan:          make(chan struct{}),
+		riskLimits:        make(map[string]*RiskLimit),
+		riskEvents:        make([]RiskEvent, 0, config.MaxEventHistory),
+		eventCounts:       make(map[riskEventKey]int64),
+		exposureCache:     make(map[string]float64),
+		priceHistory:      make(map[string][]pricePoint),
+		volatilityWindow:  config.VolatilityWindow,
+		sampleInterval:    config.VolatilitySampleInterval,
+		autoStopLoss:      config.EnableAutoStopLoss,
+		stopLossSubmitted: make(map[string]time.Time),
+		alertCooldown:     config.AlertCooldown,
+		breaches:          make(map[string]*breachState),
 	}
 
 	// 初始化默认风险限额
@@ -133,6 +198,13 @@ func (m *RealtimeRiskMonitor) SetAlertCallback(callback func(RiskEvent)) {
 	m.alertCallback = callback
 }
 
+// SetStopLossExecutor 设置自动止损使用的执行器，EnableAutoStopLoss开启时生效
+func (m *RealtimeRiskMonitor) SetStopLossExecutor(executor StopLossExecutor) {
+	m.stopLossLock.Lock()
+	defer m.stopLossLock.Unlock()
+	m.stopLossExecutor = executor
+}
+
 // Start 启动监控
 func (m *RealtimeRiskMonitor) Start() error {
 	log.Println("Starting real-time risk monitor...")
@@ -194,12 +266,12 @@ func (m *RealtimeRiskMonitor) checkRisks(ctx context.Context) error {
 
 // checkPositionRisk 检查持仓风险
 func (m *RealtimeRiskMonitor) checkPositionRisk(ctx context.Context) error {
-	_ = ctx
 	if m.positionManager == nil {
 		return nil
 	}
 
 	positions := m.positionManager.GetAllPositions()
+	m.releaseStopLoss(positions)
 
 	totalValue := 0.0
 	for _, pos := range positions {
@@ -214,8 +286,7 @@ func (m *RealtimeRiskMonitor) checkPositionRisk(ctx context.Context) error {
 			m.exposureCache[pos.Symbol] = exposure
 			m.exposureLock.Unlock()
 
-			if limit, ok := m.riskLimits["max_single_position"]; ok {
-				limit.CurrentValue = exposure
+			if limit, ok := m.observeLimit("max_single_position", exposure); ok {
 
 				if exposure >= limit.CriticalThreshold {
 					event := RiskEvent{
@@ -229,7 +300,8 @@ func (m *RealtimeRiskMonitor) checkPositionRisk(ctx context.Context) error {
 						Timestamp: time.Now(),
 						Metadata:  map[string]string{"position_value": fmt.Sprintf("%.2f", pos.MarketValue)},
 					}
-					m.triggerAlert(event)
+					m.raiseBreach(limit.Name, event)
+					m.executeAutoStopLoss(ctx, pos.Symbol, pos.CurrentPrice, event)
 				} else if exposure >= limit.WarningThreshold {
 					event := RiskEvent{
 						ID:        generateEventID(),
@@ -241,7 +313,9 @@ func (m *RealtimeRiskMonitor) checkPositionRisk(ctx context.Context) error {
 						Threshold: limit.WarningThreshold,
 						Timestamp: time.Now(),
 					}
-					m.triggerAlert(event)
+					m.raiseBreach(limit.Name, event)
+				} else {
+					m.clearBreach(limit.Name, pos.Symbol, "position_risk", exposure)
 				}
 			}
 		}
@@ -252,7 +326,6 @@ func (m *RealtimeRiskMonitor) checkPositionRisk(ctx context.Context) error {
 
 // checkPortfolioRisk 检查组合风险
 func (m *RealtimeRiskMonitor) checkPortfolioRisk(ctx context.Context) error {
-	_ = ctx
 	if m.riskManager == nil {
 		return nil
 	}
@@ -260,9 +333,8 @@ func (m *RealtimeRiskMonitor) checkPortfolioRisk(ctx context.Context) error {
 	// 检查回撤
 	portfolio := m.riskManager.GetPortfolioSummary()
 
-	if limit, ok := m.riskLimits["max_drawdown"]; ok {
-		drawdown := portfolio.Drawdown
-		limit.CurrentValue = drawdown
+	drawdown := portfolio.Drawdown
+	if limit, ok := m.observeLimit("max_drawdown", drawdown); ok {
 
 		if drawdown >= limit.CriticalThreshold {
 			event := RiskEvent{
@@ -274,7 +346,10 @@ func (m *RealtimeRiskMonitor) checkPortfolioRisk(ctx context.Context) error {
 				Threshold: limit.CriticalThreshold,
 				Timestamp: time.Now(),
 			}
-			m.triggerAlert(event)
+			m.raiseBreach(limit.Name, event)
+			if pos := m.worstPosition(); pos != nil {
+				m.executeAutoStopLoss(ctx, pos.Symbol, pos.CurrentPrice, event)
+			}
 		} else if drawdown >= limit.WarningThreshold {
 			event := RiskEvent{
 				ID:        generateEventID(),
@@ -285,40 +360,43 @@ func (m *RealtimeRiskMonitor) checkPortfolioRisk(ctx context.Context) error {
 				Threshold: limit.WarningThreshold,
 				Timestamp: time.Now(),
 			}
-			m.triggerAlert(event)
+			m.raiseBreach(limit.Name, event)
+		} else {
+			m.clearBreach(limit.Name, "", "drawdown_risk", drawdown)
 		}
 	}
 
 	// 检查当日亏损
-	dailyPnL := portfolio.DailyPnL
-	if dailyPnL < 0 {
-		dailyLoss := -dailyPnL / portfolio.TotalValue
-		if limit, ok := m.riskLimits["daily_loss_limit"]; ok {
-			limit.CurrentValue = dailyLoss
-
-			if dailyLoss >= limit.CriticalThreshold {
-				event := RiskEvent{
-					ID:        generateEventID(),
-					Type:      "daily_loss_risk",
-					Level:     RiskLevelCritical,
-					Message:   fmt.Sprintf("Daily loss %.2f%% exceeds critical threshold %.2f%%", dailyLoss*100, limit.CriticalThreshold*100),
-					Value:     dailyLoss,
-					Threshold: limit.CriticalThreshold,
-					Timestamp: time.Now(),
-				}
-				m.triggerAlert(event)
-			} else if dailyLoss >= limit.WarningThreshold {
-				event := RiskEvent{
-					ID:        generateEventID(),
-					Type:      "daily_loss_risk",
-					Level:     RiskLevelHigh,
-					Message:   fmt.Sprintf("Daily loss %.2f%% exceeds warning threshold %.2f%%", dailyLoss*100, limit.WarningThreshold*100),
-					Value:     dailyLoss,
-					Threshold: limit.WarningThreshold,
-					Timestamp: time.Now(),
-				}
-				m.triggerAlert(event)
+	dailyLoss := 0.0
+	if portfolio.DailyPnL < 0 && portfolio.TotalValue > 0 {
+		dailyLoss = -portfolio.DailyPnL / portfolio.TotalValue
+	}
+	if limit, ok := m.observeLimit("daily_loss_limit", dailyLoss); ok {
+
+		if dailyLoss >= limit.CriticalThreshold {
+			event := RiskEvent{
+				ID:        generateEventID(),
+				Type:      "daily_loss_risk",
+				Level:     RiskLevelCritical,
+				Message:   fmt.Sprintf("Daily loss %.2f%% exceeds critical threshold %.2f%%", dailyLoss*100, limit.CriticalThreshold*100),
+				Value:     dailyLoss,
+				Threshold: limit.CriticalThreshold,
+				Timestamp: time.Now(),
+			}
+			m.raiseBreach(limit.Name, event)
+		} else if dailyLoss >= limit.WarningThreshold {
+			event := RiskEvent{
+				ID:        generateEventID(),
+				Type:      "daily_loss_risk",
+				Level:     RiskLevelHigh,
+				Message:   fmt.Sprintf("Daily loss %.2f%% exceeds warning threshold %.2f%%", dailyLoss*100, limit.WarningThreshold*100),
+				Value:     dailyLoss,
+				Threshold: limit.WarningThreshold,
+				Timestamp: time.Now(),
 			}
+			m.raiseBreach(limit.Name, event)
+		} else {
+			m.clearBreach(limit.Name, "", "daily_loss_risk", dailyLoss)
 		}
 	}
 
@@ -332,50 +410,239 @@ func (m *RealtimeRiskMonitor) checkVolatilityRisk(ctx context.Context) error {
 		return nil
 	}
 
-	positions := m.positionManager.GetAllPositions()
+	now := time.Now()
+	for _, pos := range m.positionManager.GetAllPositions() {
+		m.RecordPrice(pos.Symbol, pos.CurrentPrice, now)
+		m.checkSymbolVolatility(pos.Symbol)
+	}
 
-	// 简化版波动率计算（实际应使用历史数据计算）
-	for _, pos := range positions {
-		// 这里应该计算实际的波动率
-		// 简化版：使用成本价与现价的变化作为代理
-		dailyChange := 0.0
-		if pos.CostPrice > 0 {
-			dailyChange = (pos.CurrentPrice - pos.CostPrice) / pos.CostPrice
-			if dailyChange < 0 {
-				dailyChange = -dailyChange
-			}
+	return nil
+}
+
+// checkSymbolVolatility 将单只股票的年化已实现波动率与限额比较，历史不足时不告警
+func (m *RealtimeRiskMonitor) checkSymbolVolatility(symbol string) {
+	volatility, ok := m.RealizedVolatility(symbol)
+	if !ok {
+		return
+	}
+
+	limit, ok := m.observeLimit("volatility_limit", volatility)
+	if !ok {
+		return
+	}
+
+	if volatility >= limit.CriticalThreshold {
+		event := RiskEvent{
+			ID:        generateEventID(),
+			Type:      "volatility_risk",
+			Level:     RiskLevelCritical,
+			Symbol:    symbol,
+			Message:   fmt.Sprintf("High volatility detected for %s: annualized %.2f%%", symbol, volatility*100),
+			Value:     volatility,
+			Threshold: limit.CriticalThreshold,
+			Timestamp: time.Now(),
 		}
+		m.raiseBreach(limit.Name, event)
+	} else if volatility >= limit.WarningThreshold {
+		event := RiskEvent{
+			ID:        generateEventID(),
+			Type:      "volatility_risk",
+			Level:     RiskLevelHigh,
+			Symbol:    symbol,
+			Message:   fmt.Sprintf("Elevated volatility for %s: annualized %.2f%%", symbol, volatility*100),
+			Value:     volatility,
+			Threshold: limit.WarningThreshold,
+			Timestamp: time.Now(),
+		}
+		m.raiseBreach(limit.Name, event)
+	} else {
+		m.clearBreach(limit.Name, symbol, "volatility_risk", volatility)
+	}
+}
 
-		if limit, ok := m.riskLimits["volatility_limit"]; ok {
-			if dailyChange >= limit.CriticalThreshold {
-				event := RiskEvent{
-					ID:        generateEventID(),
-					Type:      "volatility_risk",
-					Level:     RiskLevelCritical,
-					Symbol:    pos.Symbol,
-					Message:   fmt.Sprintf("High volatility detected for %s: %.2f%%", pos.Symbol, dailyChange*100),
-					Value:     dailyChange,
-					Threshold: limit.CriticalThreshold,
-					Timestamp: time.Now(),
-				}
-				m.triggerAlert(event)
-			} else if dailyChange >= limit.WarningThreshold {
-				event := RiskEvent{
-					ID:        generateEventID(),
-					Type:      "volatility_risk",
-					Level:     RiskLevelHigh,
-					Symbol:    pos.Symbol,
-					Message:   fmt.Sprintf("Elevated volatility for %s: %.2f%%", pos.Symbol, dailyChange*100),
-					Value:     dailyChange,
-					Threshold: limit.WarningThreshold,
-					Timestamp: time.Now(),
-				}
-				m.triggerAlert(event)
-			}
+// RecordPrice 记录价格采样，距上次采样不足采样间隔的价格被忽略，只保留最近的窗口
+func (m *RealtimeRiskMonitor) RecordPrice(symbol string, price float64, at time.Time) {
+	if price <= 0 {
+		return
+	}
+
+	m.priceLock.Lock()
+	defer m.priceLock.Unlock()
+
+	history := m.priceHistory[symbol]
+	if n := len(history); n > 0 && at.Sub(history[n-1].time) < m.sampleInterval {
+		return
+	}
+	history = append(history, pricePoint{price: price, time: at})
+	if len(history) > m.volatilityWindow+1 {
+		history = history[len(history)-m.volatilityWindow-1:]
+	}
+	m.priceHistory[symbol] = history
+}
+
+// RealizedVolatility 按最近窗口的对数收益率标准差计算年化波动率，采样不足一个窗口时返回false
+func (m *RealtimeRiskMonitor) RealizedVolatility(symbol string) (float64, bool) {
+	m.priceLock.Lock()
+	history := append([]pricePoint(nil), m.priceHistory[symbol]...)
+	m.priceLock.Unlock()
+
+	if len(history) < m.volatilityWindow+1 {
+		return 0, false
+	}
+
+	returns := make([]float64, 0, len(history)-1)
+	for i := 1; i < len(history); i++ {
+		returns = append(returns, math.Log(history[i].price/history[i-1].price))
+	}
+
+	mean := 0.0
+	for _, ret := range returns {
+		mean += ret
+	}
+	mean /= float64(len(returns))
+
+	variance := 0.0
+	for _, ret := range returns {
+		diff := ret - mean
+		variance += diff * diff
+	}
+	variance /= float64(len(returns) - 1)
+
+	periodsPerYear := tradingSecondsPerYear / m.sampleInterval.Seconds()
+	return math.Sqrt(variance) * math.Sqrt(periodsPerYear), true
+}
+
+// worstPosition 浮动亏损最大的持仓，组合回撤触发自动止损时作为止损对象
+func (m *RealtimeRiskMonitor) worstPosition() *trading.PositionState {
+	if m.positionManager == nil {
+		return nil
+	}
+	var worst *trading.PositionState
+	for _, pos := range m.positionManager.GetAllPositions() {
+		if pos.UnrealizedPnL < 0 && (worst == nil || pos.UnrealizedPnL < worst.UnrealizedPnL) {
+			worst = pos
 		}
 	}
+	return worst
+}
 
-	return nil
+// executeAutoStopLoss 严重风险事件触发时对股票提交止损，同一持仓只提交一次，
+// 每次自动操作都会记录日志并产生auto_action事件
+func (m *RealtimeRiskMonitor) executeAutoStopLoss(ctx context.Context, symbol string, price float64, trigger RiskEvent) {
+	if !m.autoStopLoss || trigger.Level != RiskLevelCritical {
+		return
+	}
+
+	m.stopLossLock.Lock()
+	executor := m.stopLossExecutor
+	if executor == nil {
+		m.stopLossLock.Unlock()
+		return
+	}
+	if _, submitted := m.stopLossSubmitted[symbol]; submitted {
+		m.stopLossLock.Unlock()
+		return
+	}
+	m.stopLossSubmitted[symbol] = time.Now()
+	m.stopLossLock.Unlock()
+
+	event := RiskEvent{
+		ID:        generateEventID(),
+		Type:      "auto_action",
+		Level:     RiskLevelHigh,
+		Symbol:    symbol,
+		Value:     price,
+		Timestamp: time.Now(),
+		Metadata: map[string]string{
+			"action":       "stop_loss",
+			"trigger_id":   trigger.ID,
+			"trigger_type": trigger.Type,
+		},
+	}
+
+	if err := executor.ExecuteStopLoss(ctx, symbol, price); err != nil {
+		// 提交失败时允许下一次检查重试
+		m.stopLossLock.Lock()
+		delete(m.stopLossSubmitted, symbol)
+		m.stopLossLock.Unlock()
+
+		log.Printf("Auto stop-loss for %s failed (trigger %s): %v", symbol, trigger.Type, err)
+		event.Level = RiskLevelCritical
+		event.Message = fmt.Sprintf("Auto stop-loss for %s failed: %v", symbol, err)
+		event.Metadata["status"] = "failed"
+		m.triggerAlert(event)
+		return
+	}
+
+	log.Printf("Auto stop-loss submitted for %s at %.2f (trigger %s)", symbol, price, trigger.Type)
+	event.Message = fmt.Sprintf("Auto stop-loss submitted for %s at %.2f after %s", symbol, price, trigger.Type)
+	event.Metadata["status"] = "submitted"
+	m.triggerAlert(event)
+}
+
+// releaseStopLoss 清除已不再持有的股票的止损记录，之后重新建仓可再次触发自动止损
+func (m *RealtimeRiskMonitor) releaseStopLoss(positions []*trading.PositionState) {
+	held := make(map[string]bool, len(positions))
+	for _, pos := range positions {
+		held[pos.Symbol] = true
+	}
+
+	m.stopLossLock.Lock()
+	defer m.stopLossLock.Unlock()
+	for symbol := range m.stopLossSubmitted {
+		if !held[symbol] {
+			delete(m.stopLossSubmitted, symbol)
+		}
+	}
+}
+
+// breachKey 越限状态的键
+func breachKey(limitName, symbol string) string {
+	return limitName + "|" + symbol
+}
+
+// raiseBreach 上报越限事件：同一限额和股票在冷却时间内只告警一次，级别升高时立即告警
+func (m *RealtimeRiskMonitor) raiseBreach(limitName string, event RiskEvent) {
+	key := breachKey(limitName, event.Symbol)
+
+	m.breachLock.Lock()
+	state, ok := m.breaches[key]
+	if ok && event.Level <= state.level && event.Timestamp.Sub(state.lastAlert) < m.alertCooldown {
+		m.breachLock.Unlock()
+		return
+	}
+	m.breaches[key] = &breachState{level: event.Level, lastAlert: event.Timestamp}
+	m.breachLock.Unlock()
+
+	m.triggerAlert(event)
+}
+
+// clearBreach 数值回落到预警阈值以下时清除越限状态，并发出一次resolved事件
+func (m *RealtimeRiskMonitor) clearBreach(limitName, symbol, eventType string, value float64) {
+	key := breachKey(limitName, symbol)
+
+	m.breachLock.Lock()
+	_, ok := m.breaches[key]
+	delete(m.breaches, key)
+	m.breachLock.Unlock()
+	if !ok {
+		return
+	}
+
+	target := limitName
+	if symbol != "" {
+		target = fmt.Sprintf("%s for %s", limitName, symbol)
+	}
+	m.triggerAlert(RiskEvent{
+		ID:        generateEventID(),
+		Type:      "resolved",
+		Level:     RiskLevelLow,
+		Symbol:    symbol,
+		Message:   fmt.Sprintf("Risk limit %s back below warning threshold", target),
+		Value:     value,
+		Timestamp: time.Now(),
+		Metadata:  map[string]string{"limit": limitName, "resolved_type": eventType},
+	})
 }
 
 // triggerAlert 触发告警
@@ -384,6 +651,7 @@ func (m *RealtimeRiskMonitor) triggerAlert(event RiskEvent) {
 	defer m.riskEventsLock.Unlock()
 
 	m.riskEvents = append(m.riskEvents, event)
+	m.eventCounts[riskEventKey{event.Type, event.Level}]++
 
 	// 限制事件历史大小
 	if len(m.riskEvents) > 1000 {
@@ -396,10 +664,23 @@ func (m *RealtimeRiskMonitor) triggerAlert(event RiskEvent) {
 	}
 }
 
+// observeLimit 记录限额的当前值并返回限额的副本，供检查时比较阈值
+func (m *RealtimeRiskMonitor) observeLimit(name string, value float64) (RiskLimit, bool) {
+	m.limitsLock.Lock()
+	defer m.limitsLock.Unlock()
+
+	limit, ok := m.riskLimits[name]
+	if !ok {
+		return RiskLimit{}, false
+	}
+	limit.CurrentValue = value
+	return *limit, true
+}
+
 // GetRiskLimits 获取所有风险限额
 func (m *RealtimeRiskMonitor) GetRiskLimits() map[string]*RiskLimit {
-	m.riskEventsLock.RLock()
-	defer m.riskEventsLock.RUnlock()
+	m.limitsLock.RLock()
+	defer m.limitsLock.RUnlock()
 
 	result := make(map[string]*RiskLimit)
 	for k, v := range m.riskLimits {
@@ -423,6 +704,37 @@ func (m *RealtimeRiskMonitor) GetRiskEvents(limit int) []RiskEvent {
 	return events
 }
 
+// riskEventKey 事件计数的分组键
+type riskEventKey struct {
+	Type  string
+	Level RiskLevel
+}
+
+// RiskEventCount 某类型和级别的累计事件数
+type RiskEventCount struct {
+	Type  string    `json:"type"`
+	Level RiskLevel `json:"level"`
+	Count int64     `json:"count"`
+}
+
+// GetEventCounts 获取启动以来按类型和级别累计的风险事件数
+func (m *RealtimeRiskMonitor) GetEventCounts() []RiskEventCount {
+	m.riskEventsLock.RLock()
+	defer m.riskEventsLock.RUnlock()
+
+	counts := make([]RiskEventCount, 0, len(m.eventCounts))
+	for key, count := range m.eventCounts {
+		counts = append(counts, RiskEventCount{Type: key.Type, Level: key.Level, Count: count})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Type != counts[j].Type {
+			return counts[i].Type < counts[j].Type
+		}
+		return counts[i].Level < counts[j].Level
+	})
+	return counts
+}
+
 // GetExposure 获取持仓敞口
 func (m *RealtimeRiskMonitor) GetExposure(symbol string) (float64, bool) {
 	m.exposureLock.RLock()
@@ -434,8 +746,8 @@ func (m *RealtimeRiskMonitor) GetExposure(symbol string) (float64, bool) {
 
 // UpdateRiskLimit 更新风险限额
 func (m *RealtimeRiskMonitor) UpdateRiskLimit(name string, warningThreshold, criticalThreshold float64) error {
-	m.riskEventsLock.Lock()
-	defer m.riskEventsLock.Unlock()
+	m.limitsLock.Lock()
+	defer m.limitsLock.Unlock()
 
 	if limit, ok := m.riskLimits[name]; ok {
 		limit.WarningThreshold = warningThreshold
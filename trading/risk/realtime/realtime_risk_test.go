@@ -0,0 +1,229 @@
+package realtime
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"cloudquant/trading"
+)
+
+func newVolatilityMonitor(events *[]RiskEvent) *RealtimeRiskMonitor {
+	monitor := NewRealtimeRiskMonitor(nil, nil, MonitorConfig{
+		VolatilityWindow:         10,
+		VolatilitySampleInterval: time.Minute,
+	})
+	monitor.SetAlertCallback(func(event RiskEvent) { *events = append(*events, event) })
+	return monitor
+}
+
+func TestVolatilityCheckIgnoresSteadyAppreciation(t *testing.T) {
+	var events []RiskEvent
+	monitor := newVolatilityMonitor(&events)
+
+	// 每分钟稳定上涨3%，累计收益远超阈值但几乎没有波动
+	start := time.Date(2024, 3, 8, 10, 0, 0, 0, time.UTC)
+	price := 10.0
+	for i := 0; i <= 10; i++ {
+		monitor.RecordPrice("sh600000", price, start.Add(time.Duration(i)*time.Minute))
+		price *= 1.03
+	}
+	monitor.checkSymbolVolatility("sh600000")
+
+	volatility, ok := monitor.RealizedVolatility("sh600000")
+	if !ok {
+		t.Fatal("expected enough history for a full window")
+	}
+	if volatility > 1e-6 {
+		t.Errorf("steady appreciation has volatility %.6f, want ~0", volatility)
+	}
+	if len(events) != 0 {
+		t.Errorf("got alerts %+v for a steadily appreciating position", events)
+	}
+}
+
+func TestVolatilityCheckNeedsFullWindow(t *testing.T) {
+	var events []RiskEvent
+	monitor := newVolatilityMonitor(&events)
+
+	start := time.Date(2024, 3, 8, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 10; i++ {
+		price := 10.0
+		if i%2 == 1 {
+			price = 11.0
+		}
+		monitor.RecordPrice("sh600000", price, start.Add(time.Duration(i)*time.Minute))
+		// 采样间隔内的价格不计入历史
+		monitor.RecordPrice("sh600000", 50, start.Add(time.Duration(i)*time.Minute+time.Second))
+	}
+	monitor.checkSymbolVolatility("sh600000")
+
+	if _, ok := monitor.RealizedVolatility("sh600000"); ok {
+		t.Error("volatility reported with only 10 samples for a 10-return window")
+	}
+	if len(events) != 0 {
+		t.Errorf("got alerts %+v with insufficient history", events)
+	}
+
+	monitor.RecordPrice("sh600000", 11.0, start.Add(10*time.Minute))
+	monitor.checkSymbolVolatility("sh600000")
+	if len(events) != 1 || events[0].Level != RiskLevelCritical || events[0].Type != "volatility_risk" {
+		t.Fatalf("got alerts %+v, want one critical volatility alert", events)
+	}
+}
+
+// recordingStopLoss 记录止损调用，可按次数返回错误
+type recordingStopLoss struct {
+	calls []string
+	errs  []error
+}
+
+func (r *recordingStopLoss) ExecuteStopLoss(ctx context.Context, symbol string, currentPrice float64) error {
+	r.calls = append(r.calls, symbol)
+	if len(r.errs) > 0 {
+		err := r.errs[0]
+		r.errs = r.errs[1:]
+		return err
+	}
+	return nil
+}
+
+func autoActions(events []RiskEvent) []RiskEvent {
+	var actions []RiskEvent
+	for _, event := range events {
+		if event.Type == "auto_action" {
+			actions = append(actions, event)
+		}
+	}
+	return actions
+}
+
+func TestAutoStopLossSubmitsOncePerPosition(t *testing.T) {
+	var events []RiskEvent
+	monitor := NewRealtimeRiskMonitor(nil, nil, MonitorConfig{EnableAutoStopLoss: true})
+	monitor.SetAlertCallback(func(event RiskEvent) { events = append(events, event) })
+	executor := &recordingStopLoss{errs: []error{errors.New("broker offline")}}
+	monitor.SetStopLossExecutor(executor)
+
+	ctx := context.Background()
+	critical := RiskEvent{ID: "risk_1", Type: "position_risk", Level: RiskLevelCritical, Symbol: "sh600000"}
+	warning := RiskEvent{ID: "risk_2", Type: "position_risk", Level: RiskLevelHigh, Symbol: "sz000001"}
+
+	// 首次提交失败，下一次检查重试
+	monitor.executeAutoStopLoss(ctx, "sh600000", 9.5, critical)
+	monitor.executeAutoStopLoss(ctx, "sh600000", 9.5, critical)
+	// 后续检查中同一事件不重复提交
+	monitor.executeAutoStopLoss(ctx, "sh600000", 9.4, critical)
+	// 非严重事件不触发
+	monitor.executeAutoStopLoss(ctx, "sz000001", 20, warning)
+
+	if len(executor.calls) != 2 {
+		t.Fatalf("stop-loss submitted %d times (%v), want 2", len(executor.calls), executor.calls)
+	}
+	actions := autoActions(events)
+	if len(actions) != 2 || actions[0].Metadata["status"] != "failed" || actions[1].Metadata["status"] != "submitted" {
+		t.Fatalf("got auto actions %+v, want failed then submitted", actions)
+	}
+	if actions[1].Symbol != "sh600000" || actions[1].Metadata["trigger_id"] != "risk_1" {
+		t.Errorf("auto action %+v not linked to trigger", actions[1])
+	}
+
+	// 持仓清空后重新建仓可再次止损
+	monitor.releaseStopLoss(nil)
+	monitor.executeAutoStopLoss(ctx, "sh600000", 9.0, critical)
+	if len(executor.calls) != 3 {
+		t.Errorf("stop-loss not resubmitted after position closed: %v", executor.calls)
+	}
+
+	// 仍持有的股票保持已提交状态
+	monitor.releaseStopLoss([]*trading.PositionState{{Symbol: "sh600000"}})
+	monitor.executeAutoStopLoss(ctx, "sh600000", 9.0, critical)
+	if len(executor.calls) != 3 {
+		t.Errorf("stop-loss resubmitted for a held position: %v", executor.calls)
+	}
+}
+
+func TestAutoStopLossDisabled(t *testing.T) {
+	monitor := NewRealtimeRiskMonitor(nil, nil, MonitorConfig{})
+	executor := &recordingStopLoss{}
+	monitor.SetStopLossExecutor(executor)
+
+	monitor.executeAutoStopLoss(context.Background(), "sh600000", 9.5, RiskEvent{Type: "position_risk", Level: RiskLevelCritical})
+	if len(executor.calls) != 0 {
+		t.Errorf("stop-loss submitted with EnableAutoStopLoss off: %v", executor.calls)
+	}
+}
+
+func TestBreachCooldownAndResolve(t *testing.T) {
+	var events []RiskEvent
+	monitor := NewRealtimeRiskMonitor(nil, nil, MonitorConfig{AlertCooldown: 5 * time.Minute})
+	monitor.SetAlertCallback(func(event RiskEvent) { events = append(events, event) })
+
+	start := time.Date(2024, 3, 8, 10, 0, 0, 0, time.UTC)
+	breach := func(symbol string, level RiskLevel, offset time.Duration) {
+		monitor.raiseBreach("max_single_position", RiskEvent{
+			Type:      "position_risk",
+			Level:     level,
+			Symbol:    symbol,
+			Timestamp: start.Add(offset),
+		})
+	}
+
+	breach("sh600000", RiskLevelHigh, 0)
+	breach("sh600000", RiskLevelHigh, 5*time.Second)   // 冷却中
+	breach("sz000001", RiskLevelHigh, 5*time.Second)   // 不同股票独立计算
+	breach("sh600000", RiskLevelCritical, time.Minute) // 级别升高立即告警
+	breach("sh600000", RiskLevelHigh, 2*time.Minute)   // 冷却中
+	breach("sh600000", RiskLevelCritical, 6*time.Minute)
+	if len(events) != 4 {
+		t.Fatalf("got %d alerts, want 4: %+v", len(events), events)
+	}
+
+	monitor.clearBreach("max_single_position", "sh600000", "position_risk", 0.1)
+	monitor.clearBreach("max_single_position", "sh600000", "position_risk", 0.1)
+	monitor.clearBreach("max_drawdown", "", "drawdown_risk", 0.01)
+	if len(events) != 5 {
+		t.Fatalf("got %d events after resolution, want a single resolved event", len(events))
+	}
+	resolved := events[4]
+	if resolved.Type != "resolved" || resolved.Symbol != "sh600000" || resolved.Metadata["resolved_type"] != "position_risk" {
+		t.Errorf("unexpected resolved event %+v", resolved)
+	}
+
+	// 恢复后再次越限立即告警
+	breach("sh600000", RiskLevelHigh, 7*time.Minute)
+	if len(events) != 6 {
+		t.Errorf("breach after resolution not alerted")
+	}
+}
+
+func TestRiskLimitsConcurrentWithChecks(t *testing.T) {
+	var events []RiskEvent
+	monitor := newVolatilityMonitor(&events)
+
+	start := time.Date(2024, 3, 8, 10, 0, 0, 0, time.UTC)
+	for i := 0; i <= 10; i++ {
+		monitor.RecordPrice("sh600000", 10+float64(i%2), start.Add(time.Duration(i)*time.Minute))
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			monitor.checkSymbolVolatility("sh600000")
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		if err := monitor.UpdateRiskLimit("volatility_limit", 100, 200); err != nil {
+			t.Fatalf("UpdateRiskLimit: %v", err)
+		}
+		_ = monitor.GetRiskLimits()
+	}
+	<-done
+
+	limit := monitor.GetRiskLimits()["volatility_limit"]
+	if limit.CurrentValue <= 0 || limit.WarningThreshold != 100 {
+		t.Errorf("volatility limit = %+v, want the observed value and updated thresholds", limit)
+	}
+}
@@ -1,12 +1,31 @@
 package risk
 
 import (
+	"errors"
+	"fmt"
 	"math"
 	"sort"
 	"sync"
 	"time"
 )
 
+// VaR计算方法
+const (
+	VaRMethodHistorical = "historical"
+	VaRMethodParametric = "parametric"
+	VaRMethodMonteCarlo = "montecarlo"
+)
+
+// minVaRObservations 计算VaR所需的最少收益率样本数
+const minVaRObservations = 10
+
+var (
+	// ErrUnknownVaRMethod 不支持的VaR计算方法
+	ErrUnknownVaRMethod = errors.New("unknown VaR method")
+	// ErrInsufficientReturns 收益率样本不足
+	ErrInsufficientReturns = errors.New("insufficient return history")
+)
+
 type VaRResult struct {
 	VaR         float64   `json:"var"`
 	CVaR        float64   `json:"cvar"`
@@ -48,7 +67,7 @@ func (vc *VaRCalculator) CalculateVaR(method string, timeHorizonDays int) *VaRRe
 	vc.mu.RLock()
 	defer vc.mu.RUnlock()
 
-	if len(vc.historical) < 10 {
+	if len(vc.historical) < minVaRObservations {
 		return nil
 	}
 
@@ -87,10 +106,7 @@ func (vc *VaRCalculator) calculateHistoricalVaR() (float64, float64) {
 
 	sort.Float64s(sortedReturns)
 
-	index := int((1 - vc.confidence) * float64(len(sortedReturns)))
-	if index >= len(sortedReturns) {
-		index = len(sortedReturns) - 1
-	}
+	index := tailIndex(vc.confidence, len(sortedReturns))
 
 	varValue := sortedReturns[index]
 
@@ -157,10 +173,7 @@ func (vc *VaRCalculator) calculateMonteCarloVaR() (float64, float64) {
 
 	sort.Float64s(simulatedReturns)
 
-	index := int((1 - vc.confidence) * float64(len(simulatedReturns)))
-	if index >= len(simulatedReturns) {
-		index = len(simulatedReturns) - 1
-	}
+	index := tailIndex(vc.confidence, len(simulatedReturns))
 
 	varValue := simulatedReturns[index]
 
@@ -179,6 +192,15 @@ func (vc *VaRCalculator) calculateMonteCarloVaR() (float64, float64) {
 	return varValue, cvarValue
 }
 
+// tailIndex 升序排列的n个收益率中置信度对应的尾部分位下标，容忍(1-confidence)*n的浮点误差
+func tailIndex(confidence float64, n int) int {
+	index := int((1-confidence)*float64(n) + 1e-9)
+	if index >= n {
+		index = n - 1
+	}
+	return index
+}
+
 func (vc *VaRCalculator) getZScore(confidence float64) float64 {
 	commonConfidences := map[float64]float64{
 		0.90:  1.282,
@@ -191,7 +213,7 @@ func (vc *VaRCalculator) getZScore(confidence float64) float64 {
 		return z
 	}
 
-	return 1.96
+	return inverseNormalCDF(confidence)
 }
 
 func getPhi(z float64) float64 {
@@ -298,10 +320,7 @@ func CalculateVaR(returns []float64, confidence float64) float64 {
 	copy(sortedReturns, returns)
 	sort.Float64s(sortedReturns)
 
-	index := int((1 - confidence) * float64(len(sortedReturns)))
-	if index >= len(sortedReturns) {
-		index = len(sortedReturns) - 1
-	}
+	index := tailIndex(confidence, len(sortedReturns))
 
 	return sortedReturns[index]
 }
@@ -315,10 +334,7 @@ func CalculateCVaR(returns []float64, confidence float64) float64 {
 	copy(sortedReturns, returns)
 	sort.Float64s(sortedReturns)
 
-	index := int((1 - confidence) * float64(len(sortedReturns)))
-	if index >= len(sortedReturns) {
-		index = len(sortedReturns) - 1
-	}
+	index := tailIndex(confidence, len(sortedReturns))
 
 	sum := 0.0
 	count := 0
@@ -333,3 +349,23 @@ func CalculateCVaR(returns []float64, confidence float64) float64 {
 
 	return 0.0
 }
+
+// EstimateVaR 由单期收益率序列按historical、parametric或montecarlo方法计算VaR和CVaR，
+// 结果以收益率表示，亏损为负
+func EstimateVaR(returns []float64, confidence float64, method string) (*VaRResult, error) {
+	switch method {
+	case VaRMethodHistorical, VaRMethodParametric, VaRMethodMonteCarlo:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnknownVaRMethod, method)
+	}
+	if confidence <= 0 || confidence >= 1 {
+		return nil, fmt.Errorf("confidence must be in (0, 1), got %.4f", confidence)
+	}
+	if len(returns) < minVaRObservations {
+		return nil, fmt.Errorf("%w: %d returns, need at least %d", ErrInsufficientReturns, len(returns), minVaRObservations)
+	}
+
+	calculator := NewVaRCalculator(confidence, len(returns))
+	calculator.historical = append(calculator.historical, returns...)
+	return calculator.CalculateVaR(method, 1), nil
+}
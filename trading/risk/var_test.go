@@ -0,0 +1,75 @@
+package risk
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestEstimateVaRHistorical(t *testing.T) {
+	// -0.10, -0.09, ..., 0.09
+	returns := make([]float64, 20)
+	for i := range returns {
+		returns[i] = float64(i-10) / 100
+	}
+
+	result, err := EstimateVaR(returns, 0.9, VaRMethodHistorical)
+	if err != nil {
+		t.Fatalf("EstimateVaR: %v", err)
+	}
+	// 90%置信度取第2小的收益率，CVaR为其及更差收益率的均值
+	if math.Abs(result.VaR-(-0.08)) > 1e-9 {
+		t.Errorf("VaR %.4f, want -0.08", result.VaR)
+	}
+	if math.Abs(result.CVaR-(-0.09)) > 1e-9 {
+		t.Errorf("CVaR %.4f, want -0.09", result.CVaR)
+	}
+	if result.Method != VaRMethodHistorical || result.Confidence != 0.9 {
+		t.Errorf("result %+v does not echo method and confidence", result)
+	}
+}
+
+func TestEstimateVaRParametricUsesConfidence(t *testing.T) {
+	returns := make([]float64, 100)
+	for i := range returns {
+		returns[i] = 0.02
+		if i%2 == 1 {
+			returns[i] = -0.02
+		}
+	}
+
+	var previous float64
+	for i, confidence := range []float64{0.9, 0.95, 0.98, 0.99} {
+		result, err := EstimateVaR(returns, confidence, VaRMethodParametric)
+		if err != nil {
+			t.Fatalf("EstimateVaR(%.2f): %v", confidence, err)
+		}
+		if result.CVaR > result.VaR {
+			t.Errorf("confidence %.2f: CVaR %.4f above VaR %.4f", confidence, result.CVaR, result.VaR)
+		}
+		if i > 0 && result.VaR >= previous {
+			t.Errorf("confidence %.2f: VaR %.4f not worse than %.4f at lower confidence", confidence, result.VaR, previous)
+		}
+		previous = result.VaR
+	}
+
+	// 均值0、标准差0.02时95%参数法VaR约为-1.645*0.02
+	result, _ := EstimateVaR(returns, 0.95, VaRMethodParametric)
+	if math.Abs(result.VaR-(-0.0329)) > 1e-4 {
+		t.Errorf("parametric VaR %.5f, want about -0.0329", result.VaR)
+	}
+}
+
+func TestEstimateVaRErrors(t *testing.T) {
+	returns := make([]float64, 30)
+
+	if _, err := EstimateVaR(returns, 0.95, "garch"); !errors.Is(err, ErrUnknownVaRMethod) {
+		t.Errorf("unknown method: got %v, want ErrUnknownVaRMethod", err)
+	}
+	if _, err := EstimateVaR(returns[:5], 0.95, VaRMethodHistorical); !errors.Is(err, ErrInsufficientReturns) {
+		t.Errorf("short history: got %v, want ErrInsufficientReturns", err)
+	}
+	if _, err := EstimateVaR(returns, 1.5, VaRMethodMonteCarlo); err == nil {
+		t.Error("confidence outside (0, 1) accepted")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
+	"math"
 	"sync"
 	"time"
 )
@@ -17,6 +18,7 @@ type RiskManager struct {
 	dailyPnL         float64
 	dailyStartEquity float64
 	emergencyStop    bool
+	highWaterMarks   map[string]float64 // 各持仓建仓以来的最高价，用于移动止损
 }
 
 // RiskConfig 风险配置
@@ -27,6 +29,11 @@ type RiskConfig struct {
 	MaxDailyLoss      float64 `yaml:"max_daily_loss" json:"max_daily_loss"`           // 单日最大亏损比例
 	MinOrderAmount    float64 `yaml:"min_order_amount" json:"min_order_amount"`       // 最小下单金额
 	StopLossPercent   float64 `yaml:"stop_loss_percent" json:"stop_loss_percent"`     // 单只股票止损比例
+	TakeProfitPercent float64 `yaml:"take_profit_percent" json:"take_profit_percent"` // 单只股票止盈比例（0表示不止盈）
+	MaxGrossExposure  float64 `yaml:"max_gross_exposure" json:"max_gross_exposure"`   // 总敞口占资金比例上限（0表示不限制）
+
+	// TrailingStopPercent 移动止损比例：价格从建仓以来的最高价回落超过该比例时卖出（0表示不启用）
+	TrailingStopPercent float64 `yaml:"trailing_stop_percent" json:"trailing_stop_percent"`
 }
 
 // DefaultRiskConfig 默认风险配置
@@ -37,6 +44,8 @@ var DefaultRiskConfig = RiskConfig{
 	MaxDailyLoss:      0.1,   // 单日亏损10%全部平仓
 	MinOrderAmount:    100.0, // 最小下单金额100元
 	StopLossPercent:   0.05,  // 单只股票亏损5%止损
+	TakeProfitPercent: 0.15,  // 单只股票盈利15%止盈
+	MaxGrossExposure:  1.0,   // 总敞口不超过初始资金
 }
 
 // NewRiskManager 创建风险管理器
@@ -46,19 +55,28 @@ func NewRiskManager(config RiskConfig, connector *BrokerConnector, tradeHistory
 	}
 
 	rm := &RiskManager{
-		config:        config,
-		connector:     connector,
-		tradeHistory:  tradeHistory,
-		dailyPnL:      0,
-		emergencyStop: false,
+		config:         config,
+		connector:      connector,
+		tradeHistory:   tradeHistory,
+		dailyPnL:       0,
+		emergencyStop:  false,
+		highWaterMarks: make(map[string]float64),
 	}
 
 	// 初始化当日初始权益
 	rm.initDailyEquity()
 
+	// 恢复移动止损的最高价记录
+	rm.loadHighWaterMarks()
+
 	return rm
 }
 
+// GetConfig 获取风险配置
+func (rm *RiskManager) GetConfig() RiskConfig {
+	return rm.config
+}
+
 // initDailyEquity 初始化当日权益
 func (rm *RiskManager) initDailyEquity() {
 	balance, err := rm.connector.GetCachedBalance()
@@ -126,6 +144,15 @@ func (rm *RiskManager) checkBuyOrder(ctx context.Context, order OrderRequest) er
 		return fmt.Errorf("获取持仓失败: %w", err)
 	}
 
+	// 检查总敞口
+	if rm.config.MaxGrossExposure > 0 {
+		grossExposure := calculateGrossExposure(positions)
+		maxGrossAmount := rm.config.InitialCapital * rm.config.MaxGrossExposure
+		if grossExposure+float64(order.Amount) > maxGrossAmount {
+			return fmt.Errorf("%w: 买入后总敞口 %.2f 超过上限 %.2f", ErrGrossExposureExceeded, grossExposure+float64(order.Amount), maxGrossAmount)
+		}
+	}
+
 	// 检查是否已有该股票持仓
 	for _, pos := range positions {
 		if pos.Symbol == order.Symbol {
@@ -146,6 +173,45 @@ func (rm *RiskManager) checkBuyOrder(ctx context.Context, order OrderRequest) er
 	return nil
 }
 
+// calculateGrossExposure 计算总敞口（所有持仓市值绝对值之和）
+func calculateGrossExposure(positions []Position) float64 {
+	gross := 0.0
+	for _, pos := range positions {
+		gross += math.Abs(float64(pos.Amount) * pos.CurrentPrice)
+	}
+	return gross
+}
+
+// GetExposure 获取当前总敞口
+func (rm *RiskManager) GetExposure() (*ExposureReport, error) {
+	positions, err := rm.connector.GetCachedPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	rm.mu.RLock()
+	capital := rm.config.InitialCapital
+	limit := rm.config.MaxGrossExposure
+	rm.mu.RUnlock()
+
+	gross := calculateGrossExposure(positions)
+	report := &ExposureReport{
+		GrossExposure: gross,
+		Capital:       capital,
+		MaxExposure:   limit,
+		PositionCount: len(positions),
+		Timestamp:     time.Now(),
+	}
+	if capital > 0 {
+		report.ExposureRatio = gross / capital
+	}
+	if limit > 0 {
+		report.Remaining = math.Max(capital*limit-gross, 0)
+	}
+
+	return report, nil
+}
+
 // checkDailyLoss 检查单日亏损
 func (rm *RiskManager) checkDailyLoss(ctx context.Context) error {
 	balance, err := rm.connector.GetCachedBalance()
@@ -233,6 +299,38 @@ func (rm *RiskManager) CheckPositionLoss(ctx context.Context) ([]string, error)
 	return stopLossSymbols, nil
 }
 
+// CheckPositionProfit 检查持仓止盈，TakeProfitPercent为0时不检查
+func (rm *RiskManager) CheckPositionProfit(ctx context.Context) ([]string, error) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	if rm.emergencyStop {
+		return nil, ErrEmergencyStop
+	}
+	if rm.config.TakeProfitPercent <= 0 {
+		return nil, nil
+	}
+
+	positions, err := rm.connector.GetCachedPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	var takeProfitSymbols []string
+
+	for _, pos := range positions {
+		profitPercent := pos.ProfitPercent / 100.0
+
+		// 如果盈利超过止盈比例，触发止盈
+		if profitPercent > rm.config.TakeProfitPercent {
+			takeProfitSymbols = append(takeProfitSymbols, pos.Symbol)
+			log.Printf("止盈触发: %s 盈亏 %.2f%%, 阈值 %.2f%%", pos.Symbol, profitPercent*100, rm.config.TakeProfitPercent*100)
+		}
+	}
+
+	return takeProfitSymbols, nil
+}
+
 // UpdateDailyPnL 更新当日盈亏
 func (rm *RiskManager) UpdateDailyPnL(ctx context.Context) (float64, error) {
 	balance, err := rm.connector.GetCachedBalance()
@@ -357,6 +455,17 @@ type RiskMetrics struct {
 	EmergencyStop   bool    `json:"emergency_stop"`
 }
 
+// ExposureReport 总敞口报告
+type ExposureReport struct {
+	GrossExposure float64   `json:"gross_exposure"` // 总敞口金额
+	Capital       float64   `json:"capital"`        // 资金基数
+	ExposureRatio float64   `json:"exposure_ratio"` // 总敞口占资金比例
+	MaxExposure   float64   `json:"max_exposure"`   // 总敞口比例上限（0表示不限制）
+	Remaining     float64   `json:"remaining"`      // 剩余可用敞口金额
+	PositionCount int       `json:"position_count"`
+	Timestamp     time.Time `json:"timestamp"`
+}
+
 // PortfolioSummary 投资组合摘要
 type PortfolioSummary struct {
 	TotalValue      float64   `json:"total_value"`
@@ -401,4 +510,6 @@ var (
 	ErrMaxPositionsExceeded = fmt.Errorf("超过最大持仓数量")
 	// ErrDailyLossExceeded 超过单日最大亏损错误
 	ErrDailyLossExceeded = fmt.Errorf("超过单日最大亏损")
+	// ErrGrossExposureExceeded 超过总敞口上限错误
+	ErrGrossExposureExceeded = fmt.Errorf("超过总敞口上限")
 )
@@ -0,0 +1,130 @@
+package trading
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// fakeBroker 测试用券商，返回固定的余额和持仓，记录卖出数量
+type fakeBroker struct {
+	balance   Balance
+	positions []Position
+	sold      map[string]int
+}
+
+func (b *fakeBroker) Login(ctx context.Context, username, password, exePath string) error {
+	return nil
+}
+func (b *fakeBroker) Logout(ctx context.Context) error { return nil }
+func (b *fakeBroker) Buy(ctx context.Context, symbol string, price float64, amount int) (string, error) {
+	return "buy_" + symbol, nil
+}
+func (b *fakeBroker) Sell(ctx context.Context, symbol string, price float64, amount int) (string, error) {
+	if b.sold == nil {
+		b.sold = make(map[string]int)
+	}
+	b.sold[symbol] += amount
+	return "sell_" + symbol, nil
+}
+func (b *fakeBroker) Cancel(ctx context.Context, orderID string) error { return nil }
+func (b *fakeBroker) GetBalance(ctx context.Context) (*Balance, error) {
+	balance := b.balance
+	return &balance, nil
+}
+func (b *fakeBroker) GetPositions(ctx context.Context) ([]Position, error) {
+	return b.positions, nil
+}
+func (b *fakeBroker) GetOrders(ctx context.Context) ([]Order, error)      { return nil, nil }
+func (b *fakeBroker) GetTodayTrades(ctx context.Context) ([]Trade, error) { return nil, nil }
+func (b *fakeBroker) IsConnected() bool                                   { return true }
+
+func TestRiskManagerGrossExposureLimit(t *testing.T) {
+	broker := &fakeBroker{
+		balance: Balance{TotalAssets: 100000, AvailableCash: 100000},
+	}
+	connector := &BrokerConnector{broker: broker}
+
+	config := RiskConfig{
+		InitialCapital:    100000,
+		MaxSinglePosition: 0.3,
+		MaxPositions:      10,
+		MaxDailyLoss:      0.5,
+		MinOrderAmount:    100,
+		StopLossPercent:   0.05,
+		MaxGrossExposure:  0.5,
+	}
+	rm := NewRiskManager(config, connector, nil)
+	ctx := context.Background()
+
+	// 逐步建仓至总敞口上限（50%）
+	for _, symbol := range []string{"sh600000", "sh601398"} {
+		order := OrderRequest{Type: OrderTypeBuy, Symbol: symbol, Price: 10, Amount: 25000}
+		if err := rm.CheckBeforeOrder(ctx, order); err != nil {
+			t.Fatalf("expected buy %s to pass, got %v", symbol, err)
+		}
+		broker.positions = append(broker.positions, Position{Symbol: symbol, Amount: 2500, CurrentPrice: 10})
+	}
+
+	exposure, err := rm.GetExposure()
+	if err != nil {
+		t.Fatalf("GetExposure failed: %v", err)
+	}
+	if exposure.GrossExposure != 50000 || exposure.Remaining != 0 {
+		t.Fatalf("unexpected exposure: %+v", exposure)
+	}
+
+	buy := OrderRequest{Type: OrderTypeBuy, Symbol: "sh600519", Price: 10, Amount: 1000}
+	if err := rm.CheckBeforeOrder(ctx, buy); !errors.Is(err, ErrGrossExposureExceeded) {
+		t.Fatalf("expected ErrGrossExposureExceeded, got %v", err)
+	}
+
+	sell := OrderRequest{Type: OrderTypeSell, Symbol: "sh600000", Price: 10, Amount: 1000}
+	if err := rm.CheckBeforeOrder(ctx, sell); err != nil {
+		t.Fatalf("expected sell to pass, got %v", err)
+	}
+}
+
+func TestCheckPositionProfitAndExecuteTakeProfit(t *testing.T) {
+	broker := &fakeBroker{
+		balance: Balance{TotalAssets: 100000, AvailableCash: 50000},
+		positions: []Position{
+			{Symbol: "sh600000", Amount: 1000, Available: 600, CurrentPrice: 12, ProfitPercent: 20},
+			{Symbol: "sh601398", Amount: 1000, Available: 1000, CurrentPrice: 5.5, ProfitPercent: 10},
+			{Symbol: "sz000001", Amount: 500, Available: 500, CurrentPrice: 9.2, ProfitPercent: -8},
+		},
+	}
+	connector := &BrokerConnector{broker: broker}
+	config := DefaultRiskConfig
+	config.InitialCapital = 100000
+	config.TakeProfitPercent = 0.15
+	rm := NewRiskManager(config, connector, nil)
+	ctx := context.Background()
+
+	symbols, err := rm.CheckPositionProfit(ctx)
+	if err != nil {
+		t.Fatalf("CheckPositionProfit: %v", err)
+	}
+	if len(symbols) != 1 || symbols[0] != "sh600000" {
+		t.Fatalf("take profit symbols = %v, want [sh600000]", symbols)
+	}
+
+	positions := NewPositionManager(connector)
+	if err := positions.SyncPositions(); err != nil {
+		t.Fatalf("SyncPositions: %v", err)
+	}
+	executor := NewOrderExecutor(connector, rm, positions, nil)
+	if err := executor.ExecuteTakeProfit(ctx, "sh600000", 12); err != nil {
+		t.Fatalf("ExecuteTakeProfit: %v", err)
+	}
+	// 只卖出可用部分
+	if broker.sold["sh600000"] != 600 {
+		t.Errorf("sold %d shares, want the 600 available", broker.sold["sh600000"])
+	}
+
+	config.TakeProfitPercent = 0
+	disabled := NewRiskManager(config, connector, nil)
+	if symbols, err := disabled.CheckPositionProfit(ctx); err != nil || symbols != nil {
+		t.Errorf("disabled take profit returned %v, %v", symbols, err)
+	}
+}
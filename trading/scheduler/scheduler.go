@@ -7,45 +7,122 @@ import (
 	"sync"
 	"time"
 
+	"cloudquant/backtest"
 	"cloudquant/market"
 	"cloudquant/trading/strategies"
+
+	"github.com/robfig/cron/v3"
 )
 
+// marketLocation cron表达式默认按交易所时区（北京时间）解释
+var marketLocation = time.FixedZone("CST", 8*3600)
+
+// calendarHorizon 查找下一个交易日触发时间的最大范围
+const calendarHorizon = 366 * 24 * time.Hour
+
+// TradingCalendar 调度使用的交易日历，*backtest.TradingCalendar实现了该接口
+type TradingCalendar interface {
+	IsTradingDay(day time.Time) bool
+	IsMarketOpen(t time.Time) bool
+	NextOpen(t time.Time) time.Time // 不早于t的最近开市时刻，没有时返回零值
+}
+
 // Scheduler 策略调度器
 type Scheduler struct {
-	mu                 sync.RWMutex
-	running            bool
-	interval           time.Duration
-	cronExpr           string
-	enabled            bool
-	lastExecution      time.Time
-	executionCount     int64
-	strategyManager    *strategies.StrategyManager
-	marketProvider     *market.MarketProvider
-	symbols            []string
-	currentSymbolIndex int
-	ticker             *time.Ticker
-	ctx                context.Context
-	cancel             context.CancelFunc
-}
-
-// NewScheduler 创建调度器
+	mu              sync.RWMutex
+	running         bool
+	interval        time.Duration
+	cronExpr        string
+	schedule        cron.Schedule   // 解析后的cron表达式，非空时优先于interval
+	calendar        TradingCalendar // 间隔模式只在交易时段执行，cron模式跳过非交易日
+	nextExecution   time.Time       // 下次执行时间，未运行时为零值
+	enabled         bool
+	lastExecution   time.Time
+	executionCount  int64
+	strategyManager *strategies.StrategyManager
+	marketProvider  *market.MarketProvider
+	symbols         []string
+	currentSymbol   string          // 当前周期正在执行的股票，周期之间为空
+	warmedSymbols   map[string]bool // 已按策略数据需求预热的股票
+	wake            chan struct{}   // 调度配置变化时通知调度协程重新计算下次执行时间
+	done            chan struct{}   // 调度协程退出时关闭
+	ctx             context.Context
+	cancel          context.CancelFunc
+}
+
+// NewScheduler 创建按固定间隔执行的调度器
 func NewScheduler(interval string) (*Scheduler, error) {
 	duration, err := time.ParseDuration(interval)
 	if err != nil {
 		return nil, fmt.Errorf("invalid interval format: %v", err)
 	}
 
+	s := newScheduler()
+	s.interval = duration
+	return s, nil
+}
+
+// NewCronScheduler 创建按cron表达式在交易日执行的调度器，见SetCronExpression
+func NewCronScheduler(expr string) (*Scheduler, error) {
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return nil, err
+	}
+
+	s := newScheduler()
+	s.cronExpr = expr
+	s.schedule = schedule
+	return s, nil
+}
+
+func newScheduler() *Scheduler {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	return &Scheduler{
-		interval:           duration,
-		enabled:            true,
-		ctx:                ctx,
-		cancel:             cancel,
-		symbols:            make([]string, 0),
-		currentSymbolIndex: 0,
-	}, nil
+		enabled:       true,
+		calendar:      backtest.DefaultTradingCalendar(),
+		ctx:           ctx,
+		cancel:        cancel,
+		symbols:       make([]string, 0),
+		warmedSymbols: make(map[string]bool),
+		wake:          make(chan struct{}, 1),
+	}
+}
+
+// ValidateCronExpression 校验cron表达式，格式见SetCronExpression
+func ValidateCronExpression(expr string) error {
+	_, err := parseCron(expr)
+	return err
+}
+
+// parseCron 解析标准5段cron表达式（分 时 日 月 周），也支持@daily等描述符和CRON_TZ=前缀
+func parseCron(expr string) (cron.Schedule, error) {
+	schedule, err := cron.ParseStandard(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid cron expression %q: %v", expr, err)
+	}
+	return schedule, nil
+}
+
+// SetTradingCalendar 设置交易日历：间隔模式只在交易日的交易时段内执行，
+// cron模式跳过落在非交易日的触发时间。nil恢复默认的A股日历（不含节假日）
+func (s *Scheduler) SetTradingCalendar(calendar TradingCalendar) {
+	if calendar == nil {
+		calendar = backtest.DefaultTradingCalendar()
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calendar = calendar
+	s.reschedule()
+}
+
+// IsMarketOpen 判断t是否在交易日历的交易时段内
+func (s *Scheduler) IsMarketOpen(t time.Time) bool {
+	s.mu.RLock()
+	calendar := s.calendar
+	s.mu.RUnlock()
+	return calendar.IsMarketOpen(t)
 }
 
 // SetStrategyManager 设置策略管理器
@@ -71,8 +148,20 @@ func (s *Scheduler) SetSymbols(symbols []string) {
 	log.Printf("Scheduler configured with %d symbols: %v", len(symbols), symbols)
 }
 
-// Start 启动调度器
+// Start 启动调度器。Stop后重新启动时先等待上一次的调度循环退出，
+// 避免其退出时清除运行状态覆盖新一次运行
 func (s *Scheduler) Start() error {
+	s.mu.RLock()
+	running := s.running
+	prev := s.done
+	s.mu.RUnlock()
+	if running {
+		return fmt.Errorf("scheduler is already running")
+	}
+	if prev != nil {
+		<-prev
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
@@ -92,12 +181,25 @@ func (s *Scheduler) Start() error {
 		return fmt.Errorf("no symbols configured")
 	}
 
+	if s.schedule == nil && s.interval <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", s.interval)
+	}
+
+	// Stop会取消上下文，重新启动时创建新的上下文
+	if s.ctx.Err() != nil {
+		s.ctx, s.cancel = context.WithCancel(context.Background())
+	}
+
 	s.running = true
-	s.ticker = time.NewTicker(s.interval)
+	s.done = make(chan struct{})
 
-	go s.runScheduler()
+	go s.runScheduler(s.ctx, s.done)
 
-	log.Printf("Strategy scheduler started with interval: %s", s.interval)
+	if s.schedule != nil {
+		log.Printf("Strategy scheduler started with cron expression: %s", s.cronExpr)
+	} else {
+		log.Printf("Strategy scheduler started with interval: %s", s.interval)
+	}
 	return nil
 }
 
@@ -111,17 +213,33 @@ func (s *Scheduler) Stop() error {
 	}
 
 	s.running = false
-
-	if s.ticker != nil {
-		s.ticker.Stop()
-		s.ticker = nil
-	}
-
 	s.cancel()
 	log.Printf("Strategy scheduler stopped")
 	return nil
 }
 
+// Shutdown 停止调度器并等待正在执行的调度周期完成，ctx到期时返回其错误；未运行时直接返回
+func (s *Scheduler) Shutdown(ctx context.Context) error {
+	s.mu.RLock()
+	running := s.running
+	done := s.done
+	s.mu.RUnlock()
+	if !running {
+		return nil
+	}
+
+	if err := s.Stop(); err != nil {
+		return err
+	}
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
 // IsRunning 检查调度器是否运行中
 func (s *Scheduler) IsRunning() bool {
 	s.mu.RLock()
@@ -156,6 +274,7 @@ func (s *Scheduler) GetStats() map[string]interface{} {
 	return map[string]interface{}{
 		"running":         s.running,
 		"enabled":         s.enabled,
+		"mode":            s.mode(),
 		"interval":        s.interval.String(),
 		"last_execution":  s.lastExecution,
 		"execution_count": s.executionCount,
@@ -165,160 +284,270 @@ func (s *Scheduler) GetStats() map[string]interface{} {
 	}
 }
 
-// getCurrentSymbol 获取当前股票
+// getCurrentSymbol 获取当前周期正在执行的股票，调用方持有s.mu
 func (s *Scheduler) getCurrentSymbol() string {
-	if len(s.symbols) == 0 {
-		return ""
-	}
-	return s.symbols[s.currentSymbolIndex%len(s.symbols)]
+	return s.currentSymbol
 }
 
-// runScheduler 运行调度器主循环
-func (s *Scheduler) runScheduler() {
+// setCurrentSymbol 记录当前周期正在执行的股票
+func (s *Scheduler) setCurrentSymbol(symbol string) {
+	s.mu.Lock()
+	s.currentSymbol = symbol
+	s.mu.Unlock()
+}
+
+// runScheduler 运行调度器主循环，每次执行后按当前调度配置计算下次执行时间
+func (s *Scheduler) runScheduler(ctx context.Context, done chan struct{}) {
 	defer func() {
 		s.mu.Lock()
 		s.running = false
-		if s.ticker != nil {
-			s.ticker.Stop()
-			s.ticker = nil
-		}
+		s.nextExecution = time.Time{}
 		s.mu.Unlock()
+		close(done)
 	}()
 
+	next := s.nextRun(time.Now())
 	for {
+		s.mu.Lock()
+		s.nextExecution = next
+		s.mu.Unlock()
+
+		// cron表达式在可查找范围内没有落在交易日的触发时间，等待配置变化
+		var fire <-chan time.Time
+		var timer *time.Timer
+		if next.IsZero() {
+			log.Printf("Scheduler has no upcoming execution on a trading day")
+		} else {
+			timer = time.NewTimer(time.Until(next))
+			fire = timer.C
+		}
+
 		select {
-		case <-s.ctx.Done():
+		case <-ctx.Done():
+			stopTimer(timer)
 			return
-		case <-s.ticker.C:
-			if !s.enabled {
-				continue
+		case <-s.wake:
+			stopTimer(timer)
+			next = s.nextRun(time.Now())
+		case <-fire:
+			if s.IsEnabled() {
+				// 执行策略调度
+				s.executeCycle()
 			}
+			// 执行耗时超过间隔时不补执行错过的周期
+			next = s.nextRun(next)
+			if now := time.Now(); !next.IsZero() && !next.After(now) {
+				next = s.nextRun(now)
+			}
+		}
+	}
+}
 
-			// 执行策略调度
-			s.executeCycle()
+func stopTimer(timer *time.Timer) {
+	if timer != nil {
+		timer.Stop()
+	}
+}
+
+// nextRun 计算after之后的下次执行时间：间隔模式为after加间隔，落在休市时间时顺延到下一个开盘时刻；
+// cron模式取落在交易日的下一个触发时间，不要求在交易时段内，以便在集合竞价或收盘时执行。找不到时返回零值
+func (s *Scheduler) nextRun(after time.Time) time.Time {
+	s.mu.RLock()
+	schedule := s.schedule
+	interval := s.interval
+	calendar := s.calendar
+	s.mu.RUnlock()
+
+	if schedule == nil {
+		return calendar.NextOpen(after.Add(interval))
+	}
+
+	limit := after.Add(calendarHorizon)
+	t := after.In(marketLocation)
+	for {
+		t = schedule.Next(t)
+		if t.IsZero() || t.After(limit) {
+			return time.Time{}
+		}
+		if calendar.IsTradingDay(t.In(marketLocation)) {
+			return t
 		}
+		// 跳到次日零点前，避免逐个遍历非交易日的每次触发
+		day := t.In(marketLocation)
+		t = time.Date(day.Year(), day.Month(), day.Day()+1, 0, 0, 0, 0, marketLocation).Add(-time.Nanosecond)
 	}
 }
 
-// executeCycle 执行一个调度周期
+// reschedule 通知调度协程重新计算下次执行时间，调用方持有s.mu
+func (s *Scheduler) reschedule() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// mode 调度方式，调用方持有s.mu
+func (s *Scheduler) mode() string {
+	if s.schedule != nil {
+		return "cron"
+	}
+	return "interval"
+}
+
+// executeCycle 执行一个调度周期：一次批量获取股票池的行情，再逐只执行策略并处理信号
 func (s *Scheduler) executeCycle() {
 	startTime := time.Now()
 	s.mu.Lock()
 	s.executionCount++
 	s.lastExecution = startTime
+	count := s.executionCount
+	symbols := append([]string(nil), s.symbols...)
+	provider := s.marketProvider
 	s.mu.Unlock()
 
-	log.Printf("Starting strategy execution cycle #%d", s.executionCount)
+	log.Printf("Starting strategy execution cycle #%d", count)
 
-	// 轮询股票池
-	symbol := s.getCurrentSymbol()
-	if symbol == "" {
+	if len(symbols) == 0 {
 		log.Printf("No symbols configured for scheduler")
 		return
 	}
-
-	// 更新股票索引
-	s.mu.Lock()
-	s.currentSymbolIndex++
-	s.mu.Unlock()
-
-	// 获取市场数据
-	ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
-	defer cancel()
-
-	marketData, err := s.getMarketData(ctx, symbol)
-	if err != nil {
-		log.Printf("Failed to get market data for %s: %v", symbol, err)
+	if provider == nil {
+		log.Printf("Market provider not set for scheduler")
 		return
 	}
 
-	// 执行策略
-	result, err := s.executeStrategiesForSymbol(ctx, symbol, marketData)
+	// 批量获取行情，数据源支持时整个股票池只需一次请求
+	quotes, err := provider.FetchQuotes(symbols)
 	if err != nil {
-		log.Printf("Strategy execution failed for %s: %v", symbol, err)
+		log.Printf("Failed to get market data for %d symbols: %v", len(symbols), err)
 		return
 	}
 
-	// 处理策略结果
-	if err := s.processStrategyResult(ctx, symbol, result); err != nil {
-		log.Printf("Failed to process strategy result for %s: %v", symbol, err)
+	defer s.setCurrentSymbol("")
+	for _, symbol := range symbols {
+		if s.ctx.Err() != nil {
+			return
+		}
+		quote, ok := quotes[symbol]
+		if !ok {
+			log.Printf("Failed to get market data for %s: no quote returned", symbol)
+			continue
+		}
+
+		s.setCurrentSymbol(symbol)
+		ctx, cancel := context.WithTimeout(s.ctx, 30*time.Second)
+		if err := s.runSymbol(ctx, s.marketData(symbol, &quote)); err != nil {
+			log.Printf("Strategy cycle failed for %s: %v", symbol, err)
+		}
+		cancel()
 	}
 
 	duration := time.Since(startTime)
-	log.Printf("Strategy execution cycle #%d completed in %v", s.executionCount, duration)
+	log.Printf("Strategy execution cycle #%d completed for %d symbols in %v", count, len(symbols), duration)
+}
+
+// runSymbol 按策略数据需求预热历史数据后，对一只股票执行策略并处理信号
+func (s *Scheduler) runSymbol(ctx context.Context, marketData *strategies.MarketData) error {
+	if err := s.ensureHistory(ctx, marketData.Symbol); err != nil {
+		log.Printf("Strategies not ready for %s: %v", marketData.Symbol, err)
+	}
+	return s.strategyManager.RunCycle(ctx, marketData)
 }
 
-// getMarketData 获取市场数据
+// getMarketData 获取单只股票的市场数据
 func (s *Scheduler) getMarketData(ctx context.Context, symbol string) (*strategies.MarketData, error) {
 	if s.marketProvider == nil {
 		return nil, fmt.Errorf("market provider not set")
 	}
 
-	// 调用市场数据提供者的接口
-	// 这里需要根据实际的market包接口进行调整
-	// 暂时返回模拟数据
-	return s.mockMarketData(symbol), nil
+	quote, err := s.marketProvider.GetQuoteCached(symbol)
+	if err != nil {
+		return nil, err
+	}
+	return s.marketData(symbol, quote), nil
 }
 
-// mockMarketData 模拟市场数据
-func (s *Scheduler) mockMarketData(symbol string) *strategies.MarketData {
-	// 模拟市场数据生成
+// marketData 将实时行情转换为策略使用的市场数据
+func (s *Scheduler) marketData(symbol string, quote *market.Quote) *strategies.MarketData {
+	// 实时行情是盘中尚在形成的日K线，当日收盘后才是收定的K线
 	return &strategies.MarketData{
-		Symbol:        symbol,
-		Open:          10.0,
-		High:          10.5,
-		Low:           9.8,
-		Close:         10.2,
-		Volume:        1000000,
-		Amount:        10000000.0,
-		Timestamp:     time.Now(),
-		PreClose:      10.0,
-		Change:        0.2,
-		ChangePercent: 2.0,
-	}
-}
-
-// executeStrategiesForSymbol 为特定股票执行策略
-func (s *Scheduler) executeStrategiesForSymbol(ctx context.Context, symbol string, marketData *strategies.MarketData) (*strategies.StrategyExecutionResult, error) {
-	if s.strategyManager == nil {
-		return nil, fmt.Errorf("strategy manager not set")
+		Symbol:    symbol,
+		Open:      quote.Open,
+		High:      quote.High,
+		Low:       quote.Low,
+		Close:     quote.Close,
+		Volume:    quote.Volume,
+		Timestamp: quote.Timestamp,
+		BarClosed: s.dailyBarClosed(quote.Timestamp, time.Now()),
 	}
+}
 
-	// 执行策略
-	result, err := s.strategyManager.ExecuteStrategies(ctx, marketData)
-	if err != nil {
-		return nil, fmt.Errorf("strategy execution failed: %v", err)
-	}
+// dailyBarClosed 判断行情时间所在交易日的日K线在now时是否已收定：当前不在交易时段，
+// 且下一次开市不在该交易日（午间休市时日K线仍在形成）
+func (s *Scheduler) dailyBarClosed(quoteTime, now time.Time) bool {
+	s.mu.RLock()
+	calendar := s.calendar
+	s.mu.RUnlock()
 
-	return result, nil
+	if calendar.IsMarketOpen(now) {
+		return false
+	}
+	if quoteTime.IsZero() {
+		quoteTime = now
+	}
+	next := calendar.NextOpen(now)
+	return next.IsZero() || next.In(marketLocation).Format("2006-01-02") != quoteTime.In(marketLocation).Format("2006-01-02")
 }
 
-// processStrategyResult 处理策略执行结果
-func (s *Scheduler) processStrategyResult(ctx context.Context, symbol string, result *strategies.StrategyExecutionResult) error {
-	if result == nil {
+// ensureHistory 首次执行某只股票时，按策略声明的数据需求获取历史K线并预热策略。
+// 获取或预热失败时不标记为已预热，下一周期重试
+func (s *Scheduler) ensureHistory(ctx context.Context, symbol string) error {
+	s.mu.RLock()
+	warmed := s.warmedSymbols[symbol]
+	manager := s.strategyManager
+	provider := s.marketProvider
+	s.mu.RUnlock()
+
+	if warmed {
+		return nil
+	}
+
+	if manager == nil || provider == nil {
 		return nil
 	}
 
-	// 记录执行结果
-	log.Printf("Strategy execution result for %s: %d signals, %d errors",
-		symbol, len(result.Signals), len(result.Errors))
+	req := manager.DataRequirements()
+	if req.MinHistory <= 0 {
+		return nil
+	}
 
-	// 处理错误
-	for _, err := range result.Errors {
-		log.Printf("Strategy error: %v", err)
+	klines, err := provider.GetHistoricalData(symbol, req.MinHistory)
+	if err != nil {
+		return fmt.Errorf("failed to fetch %d bars of history: %v", req.MinHistory, err)
 	}
 
-	// 处理信号
-	if len(result.Signals) > 0 {
-		log.Printf("Processing %d signals for %s", len(result.Signals), symbol)
+	history := make([]*strategies.MarketData, 0, len(klines))
+	for _, k := range klines {
+		history = append(history, &strategies.MarketData{
+			Symbol:    symbol,
+			Open:      k.Open,
+			High:      k.High,
+			Low:       k.Low,
+			Close:     k.Close,
+			Volume:    k.Volume,
+			Timestamp: k.Timestamp,
+			BarClosed: true,
+		})
+	}
 
-		// 将信号发送给策略管理器处理
-		if err := s.strategyManager.ProcessSignals(ctx, result.Signals); err != nil {
-			return fmt.Errorf("failed to process signals: %v", err)
-		}
+	if err := manager.WarmUp(ctx, history); err != nil {
+		return err
 	}
 
-	return nil
+	s.mu.Lock()
+	s.warmedSymbols[symbol] = true
+	s.mu.Unlock()
+	return manager.CheckReadiness(len(history))
 }
 
 // ExecuteNow 立即执行一次
@@ -338,6 +567,10 @@ func (s *Scheduler) ExecuteSymbol(symbol string) error {
 		return fmt.Errorf("scheduler is disabled")
 	}
 
+	if s.strategyManager == nil {
+		return fmt.Errorf("strategy manager not set")
+	}
+
 	log.Printf("Manual strategy execution for symbol: %s", symbol)
 
 	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
@@ -349,14 +582,11 @@ func (s *Scheduler) ExecuteSymbol(symbol string) error {
 		return fmt.Errorf("failed to get market data for %s: %v", symbol, err)
 	}
 
-	// 执行策略
-	result, err := s.executeStrategiesForSymbol(ctx, symbol, marketData)
-	if err != nil {
-		return fmt.Errorf("strategy execution failed for %s: %v", symbol, err)
+	// 执行策略并处理信号
+	if err := s.runSymbol(ctx, marketData); err != nil {
+		return fmt.Errorf("strategy cycle failed for %s: %w", symbol, err)
 	}
-
-	// 处理结果
-	return s.processStrategyResult(ctx, symbol, result)
+	return nil
 }
 
 // GetNextExecutionTime 获取下次执行时间
@@ -368,46 +598,61 @@ func (s *Scheduler) GetNextExecutionTime() time.Time {
 		return time.Time{}
 	}
 
-	return s.lastExecution.Add(s.interval)
+	return s.nextExecution
 }
 
-// SetInterval 设置执行间隔
+// SetInterval 设置执行间隔，设置了cron表达式时间隔不生效
 func (s *Scheduler) SetInterval(interval string) error {
 	duration, err := time.ParseDuration(interval)
 	if err != nil {
 		return fmt.Errorf("invalid interval format: %v", err)
 	}
+	if duration <= 0 {
+		return fmt.Errorf("interval must be positive, got %s", interval)
+	}
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	s.interval = duration
-
-	// 如果正在运行，重新启动ticker
-	if s.running && s.ticker != nil {
-		s.ticker.Stop()
-		s.ticker = time.NewTicker(duration)
-	}
+	s.reschedule()
 
 	log.Printf("Scheduler interval changed to: %s", interval)
 	return nil
 }
 
-// SetCronExpression 设置cron表达式（预留接口）
+// SetCronExpression 设置cron表达式，设置后按表达式在交易日执行，优先于间隔；空字符串恢复按间隔执行。
+// 表达式为标准5段格式（分 时 日 月 周），按北京时间解释，如 "30 9,13 * * 1-5" 在每个交易日
+// 上午和下午开盘时执行；可用 "CRON_TZ=时区 " 前缀指定其他时区
 func (s *Scheduler) SetCronExpression(expr string) error {
-	// TODO: 实现cron表达式解析和调度
-	// 目前仅保存表达式，后续可以基于cron库实现
+	var schedule cron.Schedule
+	if expr != "" {
+		var err error
+		if schedule, err = parseCron(expr); err != nil {
+			return err
+		}
+	}
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if schedule == nil && s.interval <= 0 {
+		return fmt.Errorf("cannot clear cron expression: no interval configured")
+	}
 	s.cronExpr = expr
-	log.Printf("Scheduler cron expression set to: %s", expr)
+	s.schedule = schedule
+	s.reschedule()
+
+	log.Printf("Scheduler cron expression set to: %q", expr)
 	return nil
 }
 
 // GetStatus 获取详细状态信息
 func (s *Scheduler) GetStatus() map[string]interface{} {
+	// 各方法自行加锁，须在持有读锁之前调用，避免重复获取读锁时与等待中的写锁死锁
 	stats := s.GetStats()
+	nextExecution := s.GetNextExecutionTime()
+	marketOpen := s.IsMarketOpen(time.Now())
 
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -415,7 +660,8 @@ func (s *Scheduler) GetStatus() map[string]interface{} {
 	status := map[string]interface{}{
 		"scheduler":      stats,
 		"current_symbol": s.getCurrentSymbol(),
-		"next_execution": s.GetNextExecutionTime(),
+		"next_execution": nextExecution,
+		"market_open":    marketOpen,
 		"uptime":         time.Since(s.lastExecution).String(),
 	}
 
@@ -429,12 +675,6 @@ func (s *Scheduler) ForceStop() {
 
 	s.running = false
 	s.enabled = false
-
-	if s.ticker != nil {
-		s.ticker.Stop()
-		s.ticker = nil
-	}
-
 	s.cancel()
 	log.Printf("Strategy scheduler force stopped")
 }
@@ -0,0 +1,402 @@
+package scheduler
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"cloudquant/backtest"
+	"cloudquant/market"
+	"cloudquant/market/providers"
+	"cloudquant/trading/strategies"
+)
+
+func cst(year int, month time.Month, day, hour, min int) time.Time {
+	return time.Date(year, month, day, hour, min, 0, 0, marketLocation)
+}
+
+// alwaysOpen 全天开市的交易日历
+type alwaysOpen struct{}
+
+func (alwaysOpen) IsTradingDay(time.Time) bool    { return true }
+func (alwaysOpen) IsMarketOpen(time.Time) bool    { return true }
+func (alwaysOpen) NextOpen(t time.Time) time.Time { return t }
+
+func TestCronSchedulerSkipsNonTradingDays(t *testing.T) {
+	s, err := NewCronScheduler("30 9,13 * * 1-5")
+	if err != nil {
+		t.Fatalf("NewCronScheduler: %v", err)
+	}
+
+	// 2026-10-16 为周五
+	cases := []struct {
+		after, want time.Time
+	}{
+		{cst(2026, 10, 16, 8, 0), cst(2026, 10, 16, 9, 30)},
+		{cst(2026, 10, 16, 9, 30), cst(2026, 10, 16, 13, 30)},
+		{cst(2026, 10, 16, 14, 0), cst(2026, 10, 19, 9, 30)},
+		{cst(2026, 10, 17, 10, 0), cst(2026, 10, 19, 9, 30)},
+		// UTC时间按北京时间解释：UTC 周五 05:00 为北京时间 13:00
+		{time.Date(2026, 10, 16, 5, 0, 0, 0, time.UTC), cst(2026, 10, 16, 13, 30)},
+	}
+	for _, c := range cases {
+		if got := s.nextRun(c.after); !got.Equal(c.want) {
+			t.Errorf("nextRun(%s) = %s, want %s", c.after, got, c.want)
+		}
+	}
+
+	calendar, err := backtest.NewTradingCalendar(backtest.CalendarConfig{Holidays: []string{"2026-10-19", "2026-10-20"}})
+	if err != nil {
+		t.Fatalf("NewTradingCalendar: %v", err)
+	}
+	s.SetTradingCalendar(calendar)
+	if got, want := s.nextRun(cst(2026, 10, 16, 14, 0)), cst(2026, 10, 21, 9, 30); !got.Equal(want) {
+		t.Errorf("nextRun over holidays = %s, want %s", got, want)
+	}
+
+	// 只在周六触发的表达式在默认交易日历下没有执行时间
+	if err := s.SetCronExpression("0 10 * * 6"); err != nil {
+		t.Fatalf("SetCronExpression: %v", err)
+	}
+	if got := s.nextRun(cst(2026, 10, 16, 14, 0)); !got.IsZero() {
+		t.Errorf("weekend-only cron scheduled at %s", got)
+	}
+}
+
+func TestCronExpressionTakesPrecedenceOverInterval(t *testing.T) {
+	s, err := NewScheduler("5m")
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	after := cst(2026, 10, 16, 10, 2)
+	if got, want := s.nextRun(after), after.Add(5*time.Minute); !got.Equal(want) {
+		t.Errorf("interval nextRun = %s, want %s", got, want)
+	}
+
+	if err := s.SetCronExpression("0 15 * * 1-5"); err != nil {
+		t.Fatalf("SetCronExpression: %v", err)
+	}
+	if got, want := s.nextRun(after), cst(2026, 10, 16, 15, 0); !got.Equal(want) {
+		t.Errorf("cron nextRun = %s, want %s", got, want)
+	}
+	if mode := s.GetStats()["mode"]; mode != "cron" {
+		t.Errorf("mode = %v, want cron", mode)
+	}
+
+	if err := s.SetCronExpression(""); err != nil {
+		t.Fatalf("clear cron expression: %v", err)
+	}
+	if got, want := s.nextRun(after), after.Add(5*time.Minute); !got.Equal(want) {
+		t.Errorf("nextRun after clearing cron = %s, want %s", got, want)
+	}
+}
+
+func TestIntervalSchedulerRunsDuringMarketHours(t *testing.T) {
+	s, err := NewScheduler("5m")
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	calendar, err := backtest.NewTradingCalendar(backtest.CalendarConfig{Holidays: []string{"2026-10-19"}})
+	if err != nil {
+		t.Fatalf("NewTradingCalendar: %v", err)
+	}
+	s.SetTradingCalendar(calendar)
+
+	// 2026-10-16 为周五，10-19 周一休市
+	cases := []struct {
+		after, want time.Time
+	}{
+		{cst(2026, 10, 16, 9, 25), cst(2026, 10, 16, 9, 30)},
+		{cst(2026, 10, 16, 11, 25), cst(2026, 10, 16, 11, 30)},
+		{cst(2026, 10, 16, 11, 28), cst(2026, 10, 16, 13, 0)},
+		{cst(2026, 10, 16, 14, 58), cst(2026, 10, 20, 9, 30)},
+		{cst(2026, 10, 17, 3, 0), cst(2026, 10, 20, 9, 30)},
+	}
+	for _, c := range cases {
+		if got := s.nextRun(c.after); !got.Equal(c.want) {
+			t.Errorf("nextRun(%s) = %s, want %s", c.after, got, c.want)
+		}
+	}
+
+	open := map[time.Time]bool{
+		cst(2026, 10, 16, 9, 29): false,
+		cst(2026, 10, 16, 9, 30): true,
+		cst(2026, 10, 16, 12, 0): false,
+		cst(2026, 10, 16, 15, 0): true,
+		cst(2026, 10, 17, 10, 0): false,
+		cst(2026, 10, 19, 10, 0): false,
+		cst(2026, 10, 20, 10, 0): true,
+	}
+	for at, want := range open {
+		if got := s.IsMarketOpen(at); got != want {
+			t.Errorf("IsMarketOpen(%s) = %v, want %v", at, got, want)
+		}
+	}
+}
+
+func TestInvalidCronExpression(t *testing.T) {
+	for _, expr := range []string{"0 9", "61 9 * * 1-5", "every morning"} {
+		if err := ValidateCronExpression(expr); err == nil {
+			t.Errorf("ValidateCronExpression(%q) accepted", expr)
+		}
+		if _, err := NewCronScheduler(expr); err == nil {
+			t.Errorf("NewCronScheduler(%q) accepted", expr)
+		}
+	}
+
+	s, err := NewCronScheduler("@daily")
+	if err != nil {
+		t.Fatalf("NewCronScheduler(@daily): %v", err)
+	}
+	if err := s.SetCronExpression(""); err == nil {
+		t.Error("cleared cron expression without an interval to fall back to")
+	}
+}
+
+func TestRunningSchedulerFollowsCronChanges(t *testing.T) {
+	s, err := NewCronScheduler("0 9 1 1 *")
+	if err != nil {
+		t.Fatalf("NewCronScheduler: %v", err)
+	}
+	s.SetStrategyManager(strategies.NewStrategyManager(strategies.NewStrategyLoader(), ""))
+	s.SetMarketProvider(market.NewMarketProvider(0))
+	s.SetSymbols([]string{"sh600000"})
+	s.SetTradingCalendar(alwaysOpen{})
+
+	if err := s.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer s.Shutdown(context.Background())
+
+	waitNext := func(want time.Time) {
+		t.Helper()
+		deadline := time.Now().Add(2 * time.Second)
+		for {
+			got := s.GetNextExecutionTime()
+			if got.Equal(want) {
+				return
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("next execution = %s, want %s", got, want)
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+
+	nextYearly := func(month time.Month, day int) time.Time {
+		now := time.Now().In(marketLocation)
+		next := time.Date(now.Year(), month, day, 9, 0, 0, 0, marketLocation)
+		if !next.After(now) {
+			next = next.AddDate(1, 0, 0)
+		}
+		return next
+	}
+	waitNext(nextYearly(1, 1))
+
+	if err := s.SetCronExpression("0 9 31 12 *"); err != nil {
+		t.Fatalf("SetCronExpression: %v", err)
+	}
+	waitNext(nextYearly(12, 31))
+}
+
+func TestDailyBarClosedAfterSession(t *testing.T) {
+	s, err := NewScheduler("5m")
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+
+	// 2026-10-16 为周五
+	quote := cst(2026, 10, 16, 11, 29)
+	cases := []struct {
+		now  time.Time
+		want bool
+	}{
+		{cst(2026, 10, 16, 10, 0), false},
+		{cst(2026, 10, 16, 12, 0), false}, // 午间休市
+		{cst(2026, 10, 16, 15, 1), true},
+		{cst(2026, 10, 17, 10, 0), true},
+		{cst(2026, 10, 19, 9, 0), true}, // 下一交易日开盘前
+	}
+	for _, c := range cases {
+		if got := s.dailyBarClosed(quote, c.now); got != c.want {
+			t.Errorf("dailyBarClosed at %s = %v, want %v", c.now, got, c.want)
+		}
+	}
+	if s.dailyBarClosed(cst(2026, 10, 16, 15, 0), cst(2026, 10, 16, 15, 0)) {
+		t.Error("bar reported closed at the closing minute while the session is still open")
+	}
+}
+
+// batchProvider 支持批量行情接口的数据源，记录单只和批量请求次数
+type batchProvider struct {
+	mu      sync.Mutex
+	single  int
+	batches [][]string
+}
+
+func (p *batchProvider) Name() string       { return "batch" }
+func (p *batchProvider) Priority() int      { return 1 }
+func (p *batchProvider) HealthCheck() error { return nil }
+
+func (p *batchProvider) FetchTick(ctx context.Context, symbol string) (*providers.Tick, error) {
+	p.mu.Lock()
+	p.single++
+	p.mu.Unlock()
+	return &providers.Tick{Symbol: symbol, Price: 10, Time: time.Now()}, nil
+}
+
+func (p *batchProvider) FetchTicks(ctx context.Context, symbols []string) (map[string]*providers.Tick, error) {
+	p.mu.Lock()
+	p.batches = append(p.batches, symbols)
+	p.mu.Unlock()
+	ticks := make(map[string]*providers.Tick, len(symbols))
+	for _, symbol := range symbols {
+		ticks[symbol] = &providers.Tick{Symbol: symbol, Price: 10, Time: time.Now()}
+	}
+	return ticks, nil
+}
+
+func (p *batchProvider) FetchKLines(ctx context.Context, symbol string, days int) ([]providers.KLine, error) {
+	return nil, fmt.Errorf("no history")
+}
+
+func TestExecuteCycleBatchesQuotes(t *testing.T) {
+	provider := &batchProvider{}
+	registry := providers.NewProviderManager()
+	registry.AddProvider(provider)
+	market.SetProviderRegistry(registry)
+	defer market.SetProviderRegistry(nil)
+
+	s, err := NewScheduler("5m")
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	s.SetStrategyManager(strategies.NewStrategyManager(strategies.NewStrategyLoader(), ""))
+	s.SetMarketProvider(market.NewMarketProvider(0))
+	symbols := []string{"sh600000", "sz000001", "sh600519"}
+	s.SetSymbols(symbols)
+
+	s.executeCycle()
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	if len(provider.batches) != 1 || len(provider.batches[0]) != len(symbols) || provider.single != 0 {
+		t.Errorf("cycle made %d batch requests %v and %d single requests, want one batch for the whole pool",
+			len(provider.batches), provider.batches, provider.single)
+	}
+	if current := s.GetStats()["current_symbol"]; current != "" {
+		t.Errorf("current_symbol = %v after the cycle, want empty", current)
+	}
+}
+
+// flakyWarmupStrategy 需要2根历史K线，前failures次生成信号时返回错误
+type flakyWarmupStrategy struct {
+	*strategies.BaseStrategy
+	failures int
+	calls    int
+}
+
+func (f *flakyWarmupStrategy) DataRequirements() strategies.DataRequirements {
+	return strategies.DataRequirements{MinHistory: 2, Timeframe: strategies.DefaultTimeframe}
+}
+
+func (f *flakyWarmupStrategy) GenerateSignal(ctx context.Context, data *strategies.MarketData) (*strategies.Signal, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return nil, fmt.Errorf("indicator not ready")
+	}
+	return nil, nil
+}
+
+func TestEnsureHistoryRetriesFailedWarmUp(t *testing.T) {
+	var fetches int
+	market.SetHistoricalDataFetcher(func(symbol string, days int) ([]market.KLine, error) {
+		fetches++
+		now := time.Now()
+		return []market.KLine{{Close: 10, Timestamp: now.AddDate(0, 0, -2)}, {Close: 11, Timestamp: now.AddDate(0, 0, -1)}}, nil
+	})
+	defer market.SetHistoricalDataFetcher(nil)
+
+	strategy := &flakyWarmupStrategy{BaseStrategy: strategies.NewBaseStrategy("flaky", 1), failures: 1}
+	loader := strategies.NewStrategyLoader()
+	loader.RegisterFactory("flaky", func() strategies.Strategy { return strategy })
+	if err := loader.LoadStrategies([]strategies.StrategyConfig{{Name: "flaky", Type: "flaky", Enabled: true, Weight: 1}}); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+
+	s, err := NewScheduler("5m")
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	s.SetStrategyManager(strategies.NewStrategyManager(loader, ""))
+	s.SetMarketProvider(market.NewMarketProvider(0))
+
+	if err := s.ensureHistory(context.Background(), "sh600000"); err == nil {
+		t.Fatal("first warm-up succeeded, want the strategy error")
+	}
+	if err := s.ensureHistory(context.Background(), "sh600000"); err != nil {
+		t.Fatalf("retried warm-up: %v", err)
+	}
+	if err := s.ensureHistory(context.Background(), "sh600000"); err != nil {
+		t.Fatalf("warm-up after success: %v", err)
+	}
+	if fetches != 2 {
+		t.Errorf("fetched history %d times, want 2: once for the failed warm-up and once for the retry", fetches)
+	}
+}
+
+func TestRestartAfterStopKeepsRunning(t *testing.T) {
+	s, err := NewScheduler("1h")
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+	s.SetStrategyManager(strategies.NewStrategyManager(strategies.NewStrategyLoader(), ""))
+	s.SetMarketProvider(market.NewMarketProvider(0))
+	s.SetSymbols([]string{"sh600000"})
+	s.SetTradingCalendar(alwaysOpen{})
+	defer s.Shutdown(context.Background())
+
+	for i := 0; i < 20; i++ {
+		if err := s.Start(); err != nil {
+			t.Fatalf("Start #%d: %v", i, err)
+		}
+		if err := s.Stop(); err != nil {
+			t.Fatalf("Stop #%d: %v", i, err)
+		}
+	}
+	if err := s.Start(); err != nil {
+		t.Fatalf("final Start: %v", err)
+	}
+
+	// 上一次调度循环退出时不能清除新一次运行的状态
+	time.Sleep(20 * time.Millisecond)
+	if !s.IsRunning() || s.GetNextExecutionTime().IsZero() {
+		t.Errorf("running = %v, next execution = %s after restart; want a running scheduler", s.IsRunning(), s.GetNextExecutionTime())
+	}
+}
+
+func TestGetStatusWhileCalendarChanges(t *testing.T) {
+	s, err := NewScheduler("5m")
+	if err != nil {
+		t.Fatalf("NewScheduler: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 200; i++ {
+			s.SetTradingCalendar(alwaysOpen{})
+		}
+	}()
+	for i := 0; i < 200; i++ {
+		s.GetStatus()
+	}
+	wg.Wait()
+
+	if open := s.GetStatus()["market_open"]; open != true {
+		t.Errorf("market_open = %v, want true for an always-open calendar", open)
+	}
+}
@@ -14,6 +14,7 @@ type SignalHandler struct {
 	riskManager   *RiskManager
 	positionMgr   *PositionManager
 	orderExecutor *OrderExecutor
+	sizing        SizingConfig
 }
 
 // AISignal AI分析信号
@@ -37,15 +38,19 @@ type MLSignal struct {
 
 // TradingSignal 交易信号（融合后的信号）
 type TradingSignal struct {
-	Symbol       string    `json:"symbol"`
-	Action       string    `json:"action"`     // buy/sell/hold
-	Confidence   float64   `json:"confidence"` // 综合置信度
-	AIAction     string    `json:"ai_action"`
-	AIConfidence float64   `json:"ai_confidence"`
-	MLLabel      int       `json:"ml_label"`
-	MLConfidence float64   `json:"ml_confidence"`
-	Reason       string    `json:"reason"`
-	Timestamp    time.Time `json:"timestamp"`
+	Symbol        string    `json:"symbol"`
+	Action        string    `json:"action"`     // buy/sell/hold
+	Confidence    float64   `json:"confidence"` // 综合置信度
+	AIAction      string    `json:"ai_action"`
+	AIConfidence  float64   `json:"ai_confidence"`
+	MLLabel       int       `json:"ml_label"`
+	MLConfidence  float64   `json:"ml_confidence"`
+	Reason        string    `json:"reason"`
+	Timestamp     time.Time `json:"timestamp"`
+	Strategy      string    `json:"strategy,omitempty"`
+	WinRate       float64   `json:"win_rate,omitempty"`       // 产生信号的策略历史胜率
+	WinLossRatio  float64   `json:"win_loss_ratio,omitempty"` // 策略历史盈亏比，0表示未知
+	HistoryTrades int       `json:"history_trades,omitempty"` // 统计胜率的历史交易数，0表示历史不足
 }
 
 // NewSignalHandler 创建信号处理器
@@ -61,9 +66,49 @@ func NewSignalHandler(
 		riskManager:   riskManager,
 		positionMgr:   positionMgr,
 		orderExecutor: orderExecutor,
+		sizing:        DefaultSizingConfig(),
 	}
 }
 
+// SetSizingConfig 设置买入仓位的计算方式
+func (sh *SignalHandler) SetSizingConfig(config SizingConfig) error {
+	if config.Method == "" {
+		config.Method = SizingFixed
+	}
+	if config.WinLossRatio == 0 {
+		config.WinLossRatio = DefaultSizingConfig().WinLossRatio
+	}
+	if err := config.Validate(); err != nil {
+		return err
+	}
+	sh.sizing = config
+	return nil
+}
+
+// PositionAmount 计算买入金额：fixed方式直接使用amount，其他方式按资金比例计算，
+// 比例不超过MaxSinglePosition，金额不超过风控允许的单只股票上限
+func (sh *SignalHandler) PositionAmount(signal *TradingSignal, amount float64) (float64, error) {
+	if sh.sizing.Method == SizingFixed {
+		return amount, nil
+	}
+	if sh.riskManager == nil {
+		return 0, fmt.Errorf("按%s计算仓位需要风险管理器", sh.sizing.Method)
+	}
+
+	config := sh.riskManager.GetConfig()
+	fraction := sh.sizing.positionFraction(signal, config.MaxSinglePosition)
+	if fraction <= 0 {
+		return 0, fmt.Errorf("%s 仓位为0: 置信度 %.2f, 策略胜率 %.2f, 期望收益不为正",
+			signal.Symbol, signal.Confidence, signal.WinRate)
+	}
+
+	sized := sh.riskManager.GetPortfolioSummary().TotalValue * fraction
+	if limit := config.InitialCapital * config.MaxSinglePosition; limit > 0 && sized > limit {
+		sized = limit
+	}
+	return sized, nil
+}
+
 // ProcessSignal 处理AI和ML信号，生成交易决策
 func (sh *SignalHandler) ProcessSignal(ctx context.Context, aiSignal AISignal, mlSignal MLSignal) (*TradingSignal, error) {
 	// 1. 评估AI信号
@@ -234,6 +279,10 @@ func (sh *SignalHandler) ExecuteSignal(ctx context.Context, signal *TradingSigna
 		if signal.Confidence < sh.aiThreshold {
 			return "", fmt.Errorf("买入置信度 %.2f 低于阈值 %.2f", signal.Confidence, sh.aiThreshold)
 		}
+		amount, err := sh.PositionAmount(signal, amount)
+		if err != nil {
+			return "", err
+		}
 		return sh.orderExecutor.ExecuteBuy(ctx, signal.Symbol, price, amount)
 
 	case "sell":
@@ -0,0 +1,88 @@
+package trading
+
+import (
+	"fmt"
+	"math"
+)
+
+// SizingMethod 买入仓位的计算方式
+type SizingMethod string
+
+const (
+	SizingFixed         SizingMethod = "fixed"          // 使用调用方给定的固定金额
+	SizingFixedFraction SizingMethod = "fixed_fraction" // 按当前资金的固定比例
+	SizingKelly         SizingMethod = "kelly"          // 按凯利公式，由信号置信度和策略历史胜率决定比例
+)
+
+// SizingConfig 仓位计算配置
+type SizingConfig struct {
+	Method       SizingMethod `yaml:"sizing_method" json:"sizing_method"`
+	Fraction     float64      `yaml:"sizing_fraction" json:"sizing_fraction"` // fixed_fraction为资金比例，kelly为凯利系数（0.5即半凯利）
+	WinLossRatio float64      `yaml:"win_loss_ratio" json:"win_loss_ratio"`   // 策略没有历史盈亏比时使用的默认盈亏比
+}
+
+// DefaultSizingConfig 默认使用固定金额，与引入仓位计算前的行为一致
+func DefaultSizingConfig() SizingConfig {
+	return SizingConfig{
+		Method:       SizingFixed,
+		Fraction:     0.5,
+		WinLossRatio: 1,
+	}
+}
+
+// Validate 校验配置
+func (c SizingConfig) Validate() error {
+	switch c.Method {
+	case SizingFixed:
+		return nil
+	case SizingFixedFraction, SizingKelly:
+	default:
+		return fmt.Errorf("unknown sizing method %q", c.Method)
+	}
+	if c.Fraction <= 0 || c.Fraction > 1 {
+		return fmt.Errorf("sizing fraction must be in (0, 1], got %.2f", c.Fraction)
+	}
+	if c.WinLossRatio < 0 {
+		return fmt.Errorf("win/loss ratio must not be negative, got %.2f", c.WinLossRatio)
+	}
+	return nil
+}
+
+// KellySize 凯利公式 f* = p - (1-p)/b 给出的资金比例乘以fraction，期望为负时返回0
+func KellySize(winProb, winLossRatio, fraction float64) float64 {
+	if winLossRatio <= 0 || fraction <= 0 {
+		return 0
+	}
+	winProb = math.Max(0, math.Min(1, winProb))
+
+	size := (winProb - (1-winProb)/winLossRatio) * fraction
+	if size < 0 {
+		return 0
+	}
+	return math.Min(size, 1)
+}
+
+// positionFraction 按配置计算买入占资金的比例，不超过maxPosition。
+// 凯利方式下胜率取信号置信度，策略有历史胜率时取两者均值。
+func (c SizingConfig) positionFraction(signal *TradingSignal, maxPosition float64) float64 {
+	var fraction float64
+	switch c.Method {
+	case SizingFixedFraction:
+		fraction = c.Fraction
+	case SizingKelly:
+		winProb := signal.Confidence
+		if signal.HistoryTrades > 0 {
+			winProb = (signal.Confidence + signal.WinRate) / 2
+		}
+		winLossRatio := signal.WinLossRatio
+		if winLossRatio <= 0 {
+			winLossRatio = c.WinLossRatio
+		}
+		fraction = KellySize(winProb, winLossRatio, c.Fraction)
+	}
+
+	if maxPosition > 0 && fraction > maxPosition {
+		fraction = maxPosition
+	}
+	return fraction
+}
@@ -0,0 +1,60 @@
+package trading
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKellySize(t *testing.T) {
+	for _, tc := range []struct {
+		winProb, ratio, fraction, want float64
+	}{
+		{0.6, 1, 1, 0.2},
+		{0.6, 1, 0.5, 0.1},
+		{0.5, 2, 1, 0.25},
+		{0.4, 1, 1, 0}, // 期望为负不下注
+		{0.9, 0, 1, 0},
+	} {
+		if got := KellySize(tc.winProb, tc.ratio, tc.fraction); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("KellySize(%.2f, %.2f, %.2f) = %.4f, want %.4f", tc.winProb, tc.ratio, tc.fraction, got, tc.want)
+		}
+	}
+}
+
+func TestSignalHandlerPositionAmount(t *testing.T) {
+	connector := &BrokerConnector{broker: &fakeBroker{balance: Balance{TotalAssets: 100000, AvailableCash: 100000}}}
+	rm := NewRiskManager(RiskConfig{InitialCapital: 100000, MaxSinglePosition: 0.3, MaxPositions: 5}, connector, nil)
+	sh := NewSignalHandler(0.5, 0.5, rm, nil, nil)
+
+	signal := &TradingSignal{Symbol: "sh600000", Action: "buy", Confidence: 0.8}
+	if amount, err := sh.PositionAmount(signal, 5000); err != nil || amount != 5000 {
+		t.Errorf("fixed sizing = %.2f, %v; want the given 5000", amount, err)
+	}
+
+	if err := sh.SetSizingConfig(SizingConfig{Method: "martingale"}); err == nil {
+		t.Error("expected unknown sizing method to be rejected")
+	}
+
+	if err := sh.SetSizingConfig(SizingConfig{Method: SizingKelly, Fraction: 0.5}); err != nil {
+		t.Fatalf("SetSizingConfig: %v", err)
+	}
+	// 无历史时胜率取置信度：(0.6 - 0.4/1) * 0.5 = 10%
+	signal.Confidence = 0.6
+	if amount, err := sh.PositionAmount(signal, 5000); err != nil || math.Abs(amount-10000) > 1e-6 {
+		t.Errorf("kelly sizing = %.2f, %v; want 10000", amount, err)
+	}
+
+	// 高置信度且策略历史优秀时受MaxSinglePosition限制
+	signal.Confidence = 0.95
+	signal.WinRate, signal.WinLossRatio, signal.HistoryTrades = 0.9, 3, 20
+	if amount, err := sh.PositionAmount(signal, 5000); err != nil || math.Abs(amount-30000) > 1e-6 {
+		t.Errorf("kelly sizing = %.2f, %v; want capped at 30000", amount, err)
+	}
+
+	// 策略历史全部亏损时拉低胜率，期望为负则不下单
+	signal.Confidence = 0.7
+	signal.WinRate, signal.WinLossRatio, signal.HistoryTrades = 0, 0, 10
+	if amount, err := sh.PositionAmount(signal, 5000); err == nil {
+		t.Errorf("expected no position for a losing strategy, got %.2f", amount)
+	}
+}
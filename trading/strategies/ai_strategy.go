@@ -2,9 +2,9 @@ package strategies
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"log"
+	"sync"
 	"time"
 
 	"cloudquant/llm"
@@ -14,12 +14,23 @@ import (
 // AIStrategy DeepSeek AI策略
 type AIStrategy struct {
 	*BaseStrategy
-	llmAnalyzer    *llm.DeepSeekAnalyzer
-	threshold      float64 // AI信号阈值
-	confidence     float64 // 置信度阈值
-	marketData     *MarketData
-	lastAnalysis   time.Time
-	analysisResult *AIAnalysisResult
+	llmAnalyzer promptAnalyzer
+	threshold   float64 // AI信号阈值
+	confidence  float64 // 置信度阈值
+
+	mu       sync.Mutex
+	analyses map[string]*symbolAnalysis // 按股票缓存的AI分析结果
+}
+
+// promptAnalyzer 按提示词执行AI分析，由llm.DeepSeekAnalyzer实现
+type promptAnalyzer interface {
+	AnalyzePrompt(ctx context.Context, prompt string) (string, error)
+}
+
+// symbolAnalysis 单只股票最近一次AI分析
+type symbolAnalysis struct {
+	result     *AIAnalysisResult
+	analyzedAt time.Time
 }
 
 // AIAnalysisResult AI分析结果
@@ -32,23 +43,30 @@ type AIAnalysisResult struct {
 	Timestamp  time.Time `json:"timestamp"`
 }
 
+// DeepSeek请求的默认重试参数，配置未设置时使用
+const (
+	defaultAIMaxRetries     = 3
+	defaultAIRetryBaseDelay = 500 * time.Millisecond
+)
+
 // NewAIStrategy 创建AI策略
 func NewAIStrategy() Strategy {
 	strategy := &AIStrategy{
 		BaseStrategy: NewBaseStrategy("ai_strategy", 0.4),
 		threshold:    0.7,
 		confidence:   0.6,
-		marketData:   nil,
-		lastAnalysis: time.Time{},
+		analyses:     make(map[string]*symbolAnalysis),
 	}
 
 	// 设置默认参数
 	strategy.parameters = map[string]interface{}{
 		"threshold":         0.7,
 		"confidence":        0.6,
-		"analysis_interval": "1h", // 分析间隔
-		"market_context":    true, // 是否包含市场上下文
-		"risk_analysis":     true, // 是否包含风险分析
+		"analysis_interval": "1h",                             // 分析间隔
+		"market_context":    true,                             // 是否包含市场上下文
+		"risk_analysis":     true,                             // 是否包含风险分析
+		"max_retries":       defaultAIMaxRetries,              // DeepSeek请求429/5xx/网络错误的重试次数
+		"retry_base_delay":  defaultAIRetryBaseDelay.String(), // 首次重试等待，之后指数退避
 	}
 
 	return strategy
@@ -70,7 +88,20 @@ func (a *AIStrategy) Init(ctx context.Context, symbol string, config map[string]
 
 	// 创建DeepSeek分析器（如果配置中有API Key）
 	if apiKey, ok := config["api_key"].(string); ok && apiKey != "" {
-		a.llmAnalyzer = llm.NewDeepSeekAnalyzer(apiKey, "deepseek-chat", 10*time.Second, 200)
+		// 配置会整体替换默认参数，未设置的重试参数使用默认值
+		maxRetries := defaultAIMaxRetries
+		if retries, ok := config["max_retries"].(int); ok {
+			maxRetries = retries
+		}
+		retryBase := defaultAIRetryBaseDelay
+		if delay, ok := config["retry_base_delay"].(string); ok {
+			d, err := time.ParseDuration(delay)
+			if err != nil {
+				return fmt.Errorf("invalid retry_base_delay %q: %w", delay, err)
+			}
+			retryBase = d
+		}
+		a.llmAnalyzer = llm.NewDeepSeekAnalyzer(apiKey, "deepseek-chat", 10*time.Second, 200, llm.WithRetry(maxRetries, retryBase))
 		log.Printf("AI strategy initialized with DeepSeek analyzer")
 	} else {
 		log.Printf("AI strategy initialized without DeepSeek analyzer (no API key)")
@@ -94,35 +125,20 @@ func (a *AIStrategy) GenerateSignal(ctx context.Context, marketData *MarketData)
 		return nil, fmt.Errorf("market data is nil")
 	}
 
-	a.marketData = marketData
-
 	// 如果没有LLM分析器，返回简单信号
 	if a.llmAnalyzer == nil {
 		return a.generateSimpleSignal(marketData)
 	}
 
-	// 检查是否需要重新分析
-	now := time.Now()
-	analysisInterval := time.Hour // 默认1小时
-	if interval, ok := a.parameters["analysis_interval"].(string); ok {
-		if d, err := time.ParseDuration(interval); err == nil {
-			analysisInterval = d
-		}
-	}
-
-	if a.lastAnalysis.IsZero() || now.Sub(a.lastAnalysis) > analysisInterval {
-		// 进行AI分析
-		result, err := a.performAIAnalysis(ctx, marketData)
-		if err != nil {
-			log.Printf("AI analysis failed: %v", err)
-			return nil, err
-		}
-		a.analysisResult = result
-		a.lastAnalysis = now
+	// 按股票检查是否需要重新分析
+	analysis, err := a.analysisFor(ctx, marketData)
+	if err != nil {
+		log.Printf("AI analysis failed for %s: %v", marketData.Symbol, err)
+		return nil, err
 	}
 
 	// 根据AI分析结果生成信号
-	if a.analysisResult == nil {
+	if analysis == nil {
 		return nil, nil
 	}
 
@@ -132,22 +148,22 @@ func (a *AIStrategy) GenerateSignal(ctx context.Context, marketData *MarketData)
 	var reason string
 
 	// 检查置信度
-	if a.analysisResult.Confidence < a.confidence {
+	if analysis.Confidence < a.confidence {
 		return nil, nil // 置信度不足
 	}
 
-	switch a.analysisResult.Signal {
+	switch analysis.Signal {
 	case "buy":
-		if a.analysisResult.Confidence >= a.threshold {
+		if analysis.Confidence >= a.threshold {
 			signalType = "buy"
-			strength = a.analysisResult.Confidence
-			reason = fmt.Sprintf("AI Buy Signal: %s (confidence: %.2f)", a.analysisResult.Reason, a.analysisResult.Confidence)
+			strength = analysis.Confidence
+			reason = fmt.Sprintf("AI Buy Signal: %s (confidence: %.2f)", analysis.Reason, analysis.Confidence)
 		}
 	case "sell":
-		if a.analysisResult.Confidence >= a.threshold {
+		if analysis.Confidence >= a.threshold {
 			signalType = "sell"
-			strength = a.analysisResult.Confidence
-			reason = fmt.Sprintf("AI Sell Signal: %s (confidence: %.2f)", a.analysisResult.Reason, a.analysisResult.Confidence)
+			strength = analysis.Confidence
+			reason = fmt.Sprintf("AI Sell Signal: %s (confidence: %.2f)", analysis.Reason, analysis.Confidence)
 		}
 	default:
 		return nil, nil // hold或其他信号不执行
@@ -163,17 +179,46 @@ func (a *AIStrategy) GenerateSignal(ctx context.Context, marketData *MarketData)
 	signal.Reason = reason
 
 	// 添加AI分析信息到元数据
-	signal.Metadata["ai_confidence"] = a.analysisResult.Confidence
-	signal.Metadata["ai_risk_level"] = a.analysisResult.RiskLevel
-	signal.Metadata["ai_score"] = a.analysisResult.Score
-	signal.Metadata["ai_reason"] = a.analysisResult.Reason
+	signal.Metadata["ai_confidence"] = analysis.Confidence
+	signal.Metadata["ai_risk_level"] = analysis.RiskLevel
+	signal.Metadata["ai_score"] = analysis.Score
+	signal.Metadata["ai_reason"] = analysis.Reason
 
 	log.Printf("AI strategy generated signal: %s %s (confidence: %.3f, risk: %s)",
-		marketData.Symbol, signalType, a.analysisResult.Confidence, a.analysisResult.RiskLevel)
+		marketData.Symbol, signalType, analysis.Confidence, analysis.RiskLevel)
 
 	return signal, nil
 }
 
+// analysisFor 返回该股票的AI分析结果，距上次分析超过analysis_interval时重新分析
+func (a *AIStrategy) analysisFor(ctx context.Context, marketData *MarketData) (*AIAnalysisResult, error) {
+	analysisInterval := time.Hour // 默认1小时
+	if interval, ok := a.parameters["analysis_interval"].(string); ok {
+		if d, err := time.ParseDuration(interval); err == nil {
+			analysisInterval = d
+		}
+	}
+
+	now := time.Now()
+	a.mu.Lock()
+	cached, ok := a.analyses[marketData.Symbol]
+	a.mu.Unlock()
+	if ok && now.Sub(cached.analyzedAt) <= analysisInterval {
+		return cached.result, nil
+	}
+
+	// 分析期间不持有锁，避免阻塞其他股票
+	result, err := a.performAIAnalysis(ctx, marketData)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.analyses[marketData.Symbol] = &symbolAnalysis{result: result, analyzedAt: now}
+	a.mu.Unlock()
+	return result, nil
+}
+
 // performAIAnalysis 执行AI分析
 func (a *AIStrategy) performAIAnalysis(ctx context.Context, marketData *MarketData) (*AIAnalysisResult, error) {
 	if a.llmAnalyzer == nil {
@@ -252,7 +297,7 @@ func (a *AIStrategy) buildAnalysisPrompt(marketData *MarketData) string {
 func (a *AIStrategy) parseAIResponse(response string) (*AIAnalysisResult, error) {
 	// 尝试解析JSON
 	var result AIAnalysisResult
-	if err := json.Unmarshal([]byte(response), &result); err != nil {
+	if err := llm.UnmarshalJSONResponse(response, &result); err != nil {
 		// 如果不是JSON，尝试提取关键信息
 		return a.extractInfoFromText(response)
 	}
@@ -288,21 +333,21 @@ func (a *AIStrategy) extractInfoFromText(text string) (*AIAnalysisResult, error)
 	text = fmt.Sprintf(" %s ", text) // 添加空格便于匹配
 
 	// 检测买入信号
-	if containsAny(text, []string{"买入", "买入", "买入", "做多", "做多", "做多", "建议买入", "买入时机"}) {
+	if llm.ContainsAnyKeyword(text, []string{"买入", "做多", "建议买入", "买入时机"}) {
 		result.Signal = "buy"
 		result.Confidence = 0.7
 	}
 
 	// 检测卖出信号
-	if containsAny(text, []string{"卖出", "卖出", "卖出", "做空", "做空", "做空", "建议卖出", "卖出时机"}) {
+	if llm.ContainsAnyKeyword(text, []string{"卖出", "做空", "建议卖出", "卖出时机"}) {
 		result.Signal = "sell"
 		result.Confidence = 0.7
 	}
 
 	// 检测风险等级
-	if containsAny(text, []string{"低风险", "风险较低", "安全"}) {
+	if llm.ContainsAnyKeyword(text, []string{"低风险", "风险较低", "安全"}) {
 		result.RiskLevel = "low"
-	} else if containsAny(text, []string{"高风险", "风险较高", "危险", "注意风险"}) {
+	} else if llm.ContainsAnyKeyword(text, []string{"高风险", "风险较高", "危险", "注意风险"}) {
 		result.RiskLevel = "high"
 	}
 
@@ -337,8 +382,9 @@ func (a *AIStrategy) OnTrade(ctx context.Context, trade *trading.TradeRecord) er
 // OnDailyClose 收盘回调
 func (a *AIStrategy) OnDailyClose(ctx context.Context, date time.Time) error {
 	// 重置分析状态
-	a.lastAnalysis = time.Time{}
-	a.analysisResult = nil
+	a.mu.Lock()
+	a.analyses = make(map[string]*symbolAnalysis)
+	a.mu.Unlock()
 	log.Printf("AI strategy daily close processing for %s", date.Format("2006-01-02"))
 	return nil
 }
@@ -363,9 +409,14 @@ func (a *AIStrategy) calculateStopLoss(currentPrice float64, signalType string,
 	return currentPrice
 }
 
-// GetLatestAnalysis 获取最新AI分析结果
-func (a *AIStrategy) GetLatestAnalysis() *AIAnalysisResult {
-	return a.analysisResult
+// GetLatestAnalysis 获取指定股票最新AI分析结果，未分析过时返回nil
+func (a *AIStrategy) GetLatestAnalysis(symbol string) *AIAnalysisResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if cached, ok := a.analyses[symbol]; ok {
+		return cached.result
+	}
+	return nil
 }
 
 // GetParameters 获取策略参数
@@ -391,22 +442,3 @@ func (a *AIStrategy) UpdateParameters(params map[string]interface{}) error {
 
 	return nil
 }
-
-// 工具函数
-func containsAny(text string, keywords []string) bool {
-	for _, keyword := range keywords {
-		if len(keyword) > 0 && contains(text, keyword) {
-			return true
-		}
-	}
-	return false
-}
-
-func contains(s, substr string) bool {
-	for i := 0; i <= len(s)-len(substr); i++ {
-		if s[i:i+len(substr)] == substr {
-			return true
-		}
-	}
-	return false
-}
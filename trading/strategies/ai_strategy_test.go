@@ -0,0 +1,139 @@
+package strategies
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// scriptedAnalyzer 按提示中的股票代码返回预设的AI回复，并记录调用次数
+type scriptedAnalyzer struct {
+	mu        sync.Mutex
+	responses map[string]string
+	calls     map[string]int
+}
+
+func (s *scriptedAnalyzer) AnalyzePrompt(ctx context.Context, prompt string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for symbol, response := range s.responses {
+		if strings.Contains(prompt, symbol) {
+			s.calls[symbol]++
+			return response, nil
+		}
+	}
+	return `{"signal": "hold", "confidence": 0.5}`, nil
+}
+
+func (s *scriptedAnalyzer) callCount(symbol string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.calls[symbol]
+}
+
+func TestAIStrategyCachesAnalysisPerSymbol(t *testing.T) {
+	analyzer := &scriptedAnalyzer{
+		responses: map[string]string{
+			"sh600000": `{"signal": "buy", "confidence": 0.9, "reason": "breakout", "risk_level": "low"}`,
+			"sz000001": `{"signal": "sell", "confidence": 0.8, "reason": "breakdown", "risk_level": "high"}`,
+		},
+		calls: make(map[string]int),
+	}
+
+	strategy := NewAIStrategy().(*AIStrategy)
+	if err := strategy.Init(context.Background(), "", map[string]interface{}{"analysis_interval": "1h"}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	strategy.llmAnalyzer = analyzer
+
+	ctx := context.Background()
+	bars := []*MarketData{
+		{Symbol: "sh600000", Close: 10, Timestamp: time.Now()},
+		{Symbol: "sz000001", Close: 20, Timestamp: time.Now()},
+	}
+	want := map[string]string{"sh600000": "buy", "sz000001": "sell"}
+
+	// 两轮调度：同一实例交替处理两只股票，每只股票只分析一次且信号互不影响
+	for round := 0; round < 2; round++ {
+		for _, bar := range bars {
+			signal, err := strategy.GenerateSignal(ctx, bar)
+			if err != nil {
+				t.Fatalf("round %d %s: GenerateSignal: %v", round, bar.Symbol, err)
+			}
+			if signal == nil || signal.Symbol != bar.Symbol || signal.SignalType != want[bar.Symbol] {
+				t.Fatalf("round %d %s: got signal %+v, want %s", round, bar.Symbol, signal, want[bar.Symbol])
+			}
+		}
+	}
+
+	for symbol := range want {
+		if n := analyzer.callCount(symbol); n != 1 {
+			t.Errorf("%s analyzed %d times within analysis_interval, want 1", symbol, n)
+		}
+		if analysis := strategy.GetLatestAnalysis(symbol); analysis == nil || analysis.Signal != want[symbol] {
+			t.Errorf("%s latest analysis = %+v, want %s", symbol, analysis, want[symbol])
+		}
+	}
+
+	// 间隔到期后只重新分析过期的股票
+	strategy.mu.Lock()
+	strategy.analyses["sh600000"].analyzedAt = time.Now().Add(-2 * time.Hour)
+	strategy.mu.Unlock()
+	for _, bar := range bars {
+		if _, err := strategy.GenerateSignal(ctx, bar); err != nil {
+			t.Fatalf("GenerateSignal: %v", err)
+		}
+	}
+	if n := analyzer.callCount("sh600000"); n != 2 {
+		t.Errorf("expired sh600000 analyzed %d times, want 2", n)
+	}
+	if n := analyzer.callCount("sz000001"); n != 1 {
+		t.Errorf("fresh sz000001 analyzed %d times, want 1", n)
+	}
+}
+
+func TestAIStrategyParsesFencedResponse(t *testing.T) {
+	strategy := NewAIStrategy().(*AIStrategy)
+
+	for name, response := range map[string]string{
+		"fenced":   "```json\n{\"signal\": \"sell\", \"confidence\": 0.82, \"reason\": \"跌破支撑\"}\n```",
+		"prefixed": "分析完成。结论如下 {\"signal\": \"sell\", \"confidence\": 0.82, \"reason\": \"跌破支撑\"}",
+	} {
+		result, err := strategy.parseAIResponse(response)
+		if err != nil {
+			t.Fatalf("%s: parseAIResponse: %v", name, err)
+		}
+		if result.Signal != "sell" || result.Confidence != 0.82 || result.Reason != "跌破支撑" {
+			t.Errorf("%s: got %+v, want the embedded JSON fields", name, result)
+		}
+	}
+}
+
+func TestAIStrategyRejectsInvalidRetryDelay(t *testing.T) {
+	strategy := NewAIStrategy().(*AIStrategy)
+	err := strategy.Init(context.Background(), "", map[string]interface{}{"api_key": "key", "max_retries": 2, "retry_base_delay": "soon"})
+	if err == nil {
+		t.Fatal("Init accepted an invalid retry_base_delay")
+	}
+
+	strategy = NewAIStrategy().(*AIStrategy)
+	if err := strategy.Init(context.Background(), "", map[string]interface{}{"api_key": "key", "max_retries": 2, "retry_base_delay": "1s"}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if strategy.llmAnalyzer == nil {
+		t.Error("analyzer not created from the api key")
+	}
+}
+
+func TestAIStrategyInitWithOnlyAPIKeyUsesRetryDefaults(t *testing.T) {
+	strategy := NewAIStrategy().(*AIStrategy)
+	// 配置替换默认参数后不含重试参数，Init应使用默认值而不是panic
+	if err := strategy.Init(context.Background(), "", map[string]interface{}{"api_key": "key"}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+	if strategy.llmAnalyzer == nil {
+		t.Error("analyzer not created from the api key")
+	}
+}
@@ -0,0 +1,241 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"cloudquant/trading"
+)
+
+// 布林带策略的信号模式
+const (
+	BollingerReversion = "reversion" // 均值回归：触及下轨买入，触及上轨卖出
+	BollingerBreakout  = "breakout"  // 突破：向上突破上轨买入，向下跌破下轨卖出
+)
+
+// BollingerStrategy 布林带策略
+type BollingerStrategy struct {
+	*BaseStrategy
+	period     int       // 均线周期
+	numStd     float64   // 上下轨距均线的标准差倍数
+	mode       string    // 信号模式
+	dataSeries []float64 // 价格数据序列
+}
+
+// NewBollingerStrategy 创建布林带策略
+func NewBollingerStrategy() Strategy {
+	strategy := &BollingerStrategy{
+		BaseStrategy: NewBaseStrategy("bollinger_strategy", 0.2),
+		period:       20,
+		numStd:       2.0,
+		mode:         BollingerReversion,
+		dataSeries:   make([]float64, 0, 100),
+	}
+
+	// 设置默认参数
+	strategy.parameters = map[string]interface{}{
+		"period":  20,
+		"num_std": 2.0,
+		"mode":    BollingerReversion,
+	}
+
+	return strategy
+}
+
+// Init 初始化策略
+func (b *BollingerStrategy) Init(ctx context.Context, symbol string, config map[string]interface{}) error {
+	if err := b.BaseStrategy.Init(ctx, symbol, config); err != nil {
+		return err
+	}
+	if err := b.applyParameters(config); err != nil {
+		return err
+	}
+
+	log.Printf("Bollinger strategy initialized: period=%d, num_std=%.1f, mode=%s",
+		b.period, b.numStd, b.mode)
+	return nil
+}
+
+// DataRequirements 布林带策略需要周期+1根K线判断穿越
+func (b *BollingerStrategy) DataRequirements() DataRequirements {
+	return DataRequirements{
+		MinHistory: b.period + 1,
+		Timeframe:  DefaultTimeframe,
+	}
+}
+
+// GenerateSignal 生成交易信号，只在价格由带内穿出的那根K线上发出
+func (b *BollingerStrategy) GenerateSignal(ctx context.Context, marketData *MarketData) (*Signal, error) {
+	if marketData == nil {
+		return nil, fmt.Errorf("market data is nil")
+	}
+
+	// 更新价格数据
+	b.updatePriceData(marketData.Close)
+
+	// 需要上一根K线的布林带判断是否穿越
+	if len(b.dataSeries) < b.period+1 {
+		return nil, nil // 数据不足
+	}
+
+	middle, upper, lower := b.calculateBands(b.dataSeries)
+	_, prevUpper, prevLower := b.calculateBands(b.dataSeries[:len(b.dataSeries)-1])
+	if upper == lower {
+		return nil, nil // 价格无波动
+	}
+
+	currentPrice := marketData.Close
+	prevPrice := b.dataSeries[len(b.dataSeries)-2]
+	crossedUpper := currentPrice >= upper && prevPrice < prevUpper
+	crossedLower := currentPrice <= lower && prevPrice > prevLower
+
+	var signalType string
+	var reason string
+	switch {
+	case b.mode == BollingerBreakout && crossedUpper:
+		signalType = "buy"
+		reason = fmt.Sprintf("Breakout: price %.2f above upper band %.2f", currentPrice, upper)
+	case b.mode == BollingerBreakout && crossedLower:
+		signalType = "sell"
+		reason = fmt.Sprintf("Breakdown: price %.2f below lower band %.2f", currentPrice, lower)
+	case b.mode == BollingerReversion && crossedLower:
+		signalType = "buy"
+		reason = fmt.Sprintf("Lower band touch: price %.2f <= %.2f", currentPrice, lower)
+	case b.mode == BollingerReversion && crossedUpper:
+		signalType = "sell"
+		reason = fmt.Sprintf("Upper band touch: price %.2f >= %.2f", currentPrice, upper)
+	default:
+		return nil, nil
+	}
+
+	// 恰好触及轨道时强度为0.5，偏离均线达到两倍带宽时为1
+	strength := math.Min(math.Abs(currentPrice-middle)/(upper-middle)/2, 1.0)
+
+	signal := NewSignal(marketData.Symbol, signalType, strength, currentPrice)
+	signal.TargetPrice = b.calculateTargetPrice(currentPrice, signalType, 0.03, middle)
+	signal.StopLoss = b.calculateStopLoss(currentPrice, signalType, 0.02)
+	signal.Reason = reason
+	signal.Metadata["middle_band"] = middle
+	signal.Metadata["upper_band"] = upper
+	signal.Metadata["lower_band"] = lower
+	signal.Metadata["mode"] = b.mode
+
+	log.Printf("Bollinger strategy generated signal: %s %s (strength: %.3f)",
+		marketData.Symbol, signalType, strength)
+
+	return signal, nil
+}
+
+// OnTrade 交易回调
+func (b *BollingerStrategy) OnTrade(ctx context.Context, trade *trading.TradeRecord) error {
+	log.Printf("Bollinger strategy trade executed: %s %d shares at %.2f",
+		trade.Symbol, trade.Volume, trade.Price)
+	return nil
+}
+
+// OnDailyClose 收盘回调
+func (b *BollingerStrategy) OnDailyClose(ctx context.Context, date time.Time) error {
+	log.Printf("Bollinger strategy daily close processing for %s", date.Format("2006-01-02"))
+	return nil
+}
+
+// updatePriceData 更新价格数据
+func (b *BollingerStrategy) updatePriceData(price float64) {
+	b.dataSeries = append(b.dataSeries, price)
+
+	// 限制数据长度，至少保留周期+1根
+	limit := 200
+	if b.period+1 > limit {
+		limit = b.period + 1
+	}
+	if len(b.dataSeries) > limit {
+		b.dataSeries = b.dataSeries[len(b.dataSeries)-limit:]
+	}
+}
+
+// calculateBands 用最近period个价格计算中轨、上轨和下轨
+func (b *BollingerStrategy) calculateBands(data []float64) (middle, upper, lower float64) {
+	window := data[len(data)-b.period:]
+	for _, price := range window {
+		middle += price
+	}
+	middle /= float64(b.period)
+
+	var variance float64
+	for _, price := range window {
+		variance += (price - middle) * (price - middle)
+	}
+	width := b.numStd * math.Sqrt(variance/float64(b.period))
+	return middle, middle + width, middle - width
+}
+
+// calculateTargetPrice 计算目标价格：回归模式以中轨为目标，突破模式按比例
+func (b *BollingerStrategy) calculateTargetPrice(currentPrice float64, signalType string, targetPercent, middle float64) float64 {
+	if b.mode == BollingerReversion {
+		return middle
+	}
+	if signalType == "buy" {
+		return currentPrice * (1 + targetPercent)
+	} else if signalType == "sell" {
+		return currentPrice * (1 - targetPercent)
+	}
+	return currentPrice
+}
+
+// calculateStopLoss 计算止损价格
+func (b *BollingerStrategy) calculateStopLoss(currentPrice float64, signalType string, stopLossPercent float64) float64 {
+	if signalType == "buy" {
+		return currentPrice * (1 - stopLossPercent)
+	} else if signalType == "sell" {
+		return currentPrice * (1 + stopLossPercent)
+	}
+	return currentPrice
+}
+
+// GetParameters 获取策略参数
+func (b *BollingerStrategy) GetParameters() map[string]interface{} {
+	params := b.BaseStrategy.GetParameters()
+	params["period"] = b.period
+	params["num_std"] = b.numStd
+	params["mode"] = b.mode
+	return params
+}
+
+// UpdateParameters 更新策略参数，校验失败时原参数保持不变
+func (b *BollingerStrategy) UpdateParameters(params map[string]interface{}) error {
+	if err := b.applyParameters(params); err != nil {
+		return err
+	}
+	return b.BaseStrategy.UpdateParameters(params)
+}
+
+// applyParameters 解析并校验参数，非法时保持原参数不变
+func (b *BollingerStrategy) applyParameters(params map[string]interface{}) error {
+	period, numStd, mode := b.period, b.numStd, b.mode
+	if value, ok := numberParam(params["period"]); ok {
+		period = int(value)
+	}
+	if value, ok := numberParam(params["num_std"]); ok {
+		numStd = value
+	}
+	if value, ok := params["mode"].(string); ok {
+		mode = value
+	}
+
+	// 参数验证
+	if period < 2 {
+		return fmt.Errorf("bollinger period must be at least 2, got %d", period)
+	}
+	if numStd <= 0 {
+		return fmt.Errorf("num_std must be positive, got %.2f", numStd)
+	}
+	if mode != BollingerReversion && mode != BollingerBreakout {
+		return fmt.Errorf("mode must be %s or %s, got %q", BollingerReversion, BollingerBreakout, mode)
+	}
+
+	b.period, b.numStd, b.mode = period, numStd, mode
+	return nil
+}
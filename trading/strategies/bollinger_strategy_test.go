@@ -0,0 +1,119 @@
+package strategies
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// runBollinger 依次输入收盘价，返回每根K线产生的信号（无信号为nil）
+func runBollinger(t *testing.T, strategy Strategy, closes []float64) []*Signal {
+	t.Helper()
+
+	start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.Local)
+	signals := make([]*Signal, len(closes))
+	for i, price := range closes {
+		signal, err := strategy.GenerateSignal(context.Background(), &MarketData{
+			Symbol: "sh600000", Close: price, Timestamp: start.AddDate(0, 0, i), BarClosed: true,
+		})
+		if err != nil {
+			t.Fatalf("GenerateSignal: %v", err)
+		}
+		if signal != nil {
+			if err := ValidateSignal(signal); err != nil {
+				t.Fatalf("invalid signal at bar %d: %v", i, err)
+			}
+		}
+		signals[i] = signal
+	}
+	return signals
+}
+
+// rangeBound 围绕10小幅震荡的价格，之后追加tail
+func rangeBound(tail ...float64) []float64 {
+	closes := make([]float64, 0, 20+len(tail))
+	for i := 0; i < 20; i++ {
+		closes = append(closes, 10+0.1*float64(i%2*2-1))
+	}
+	return append(closes, tail...)
+}
+
+func TestBollingerStrategyModes(t *testing.T) {
+	tests := []struct {
+		mode     string
+		tail     []float64
+		signalAt int
+		want     string
+	}{
+		{BollingerReversion, []float64{9.0, 8.9}, 20, "buy"},
+		{BollingerReversion, []float64{11.0, 11.1}, 20, "sell"},
+		{BollingerBreakout, []float64{11.0, 11.1}, 20, "buy"},
+		{BollingerBreakout, []float64{9.0, 8.9}, 20, "sell"},
+	}
+
+	for _, tt := range tests {
+		strategy := NewBollingerStrategy()
+		if err := strategy.Init(context.Background(), "", map[string]interface{}{
+			"period": 20, "num_std": 2.0, "mode": tt.mode,
+		}); err != nil {
+			t.Fatalf("Init: %v", err)
+		}
+
+		signals := runBollinger(t, strategy, rangeBound(tt.tail...))
+		for i, signal := range signals {
+			if i == tt.signalAt {
+				if signal == nil || signal.SignalType != tt.want {
+					t.Errorf("%s %v: bar %d got %+v, want %s", tt.mode, tt.tail, i, signal, tt.want)
+				}
+				continue
+			}
+			// 仍在轨道外的后续K线不重复发出信号
+			if signal != nil {
+				t.Errorf("%s %v: unexpected %s signal at bar %d", tt.mode, tt.tail, signal.SignalType, i)
+			}
+		}
+
+		signal := signals[tt.signalAt]
+		if signal == nil {
+			continue
+		}
+		middle := signal.Metadata["middle_band"].(float64)
+		switch {
+		case tt.mode == BollingerReversion && signal.TargetPrice != middle:
+			t.Errorf("reversion target %.2f, want middle band %.2f", signal.TargetPrice, middle)
+		case tt.want == "buy" && signal.StopLoss >= signal.Price:
+			t.Errorf("buy stop loss %.2f not below price %.2f", signal.StopLoss, signal.Price)
+		case tt.want == "sell" && signal.StopLoss <= signal.Price:
+			t.Errorf("sell stop loss %.2f not above price %.2f", signal.StopLoss, signal.Price)
+		}
+	}
+}
+
+func TestBollingerStrategyParameters(t *testing.T) {
+	strategy := NewBollingerStrategy()
+	if err := strategy.Init(context.Background(), "", map[string]interface{}{"mode": "momentum"}); err == nil {
+		t.Error("Init with unknown mode should fail")
+	}
+	if err := strategy.UpdateParameters(map[string]interface{}{"period": 1, "note": "rejected"}); err == nil {
+		t.Error("UpdateParameters with period 1 should fail")
+	}
+	if params := strategy.GetParameters(); params["period"] != 20 || params["note"] != nil {
+		t.Errorf("parameters after rejected update = %v, want the previous ones", params)
+	}
+
+	if err := strategy.UpdateParameters(map[string]interface{}{"period": 10.0, "num_std": 1.5, "mode": BollingerBreakout}); err != nil {
+		t.Fatalf("UpdateParameters: %v", err)
+	}
+	params := strategy.GetParameters()
+	if params["period"] != 10 || params["num_std"] != 1.5 || params["mode"] != BollingerBreakout {
+		t.Errorf("GetParameters = %v", params)
+	}
+	if req := strategy.DataRequirements(); req.MinHistory != 11 {
+		t.Errorf("MinHistory = %d, want 11", req.MinHistory)
+	}
+
+	loader := NewStrategyLoader()
+	if err := loader.ValidateConfig(&StrategyConfig{Name: "boll", Type: BollingerStrategyType, Weight: 0.2}); err != nil {
+		t.Errorf("bollinger type not registered with loader: %v", err)
+	}
+}
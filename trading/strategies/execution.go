@@ -0,0 +1,363 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"cloudquant/trading"
+)
+
+// signalOrderTimeout 单个信号下单的超时时间
+const signalOrderTimeout = 30 * time.Second
+
+// ErrTradingNotConfigured 未通过SetTradingComponents设置风险管理器、持仓管理器或订单执行器
+var ErrTradingNotConfigured = NewStrategyError("trading components not set")
+
+// ExecutionConfig 策略信号的执行配置
+type ExecutionConfig struct {
+	DryRun      bool    `yaml:"dry_run" json:"dry_run"`           // 只做风险检查和仓位计算并记录计划委托，不提交券商
+	OrderAmount float64 `yaml:"order_amount" json:"order_amount"` // fixed仓位方式每笔买入金额(元)，0表示按单只股票仓位上限
+}
+
+// DefaultExecutionConfig 默认执行配置，未显式配置时只记录不下单
+func DefaultExecutionConfig() ExecutionConfig {
+	return ExecutionConfig{DryRun: true}
+}
+
+// CycleOrder 一个执行周期中由合并信号生成的委托
+type CycleOrder struct {
+	Symbol   string  `json:"symbol"`
+	Type     string  `json:"type"` // buy/sell
+	Price    float64 `json:"price"`
+	Quantity int     `json:"quantity"` // 股数
+	Amount   float64 `json:"amount"`   // 委托金额(元)
+	Strategy string  `json:"strategy,omitempty"`
+	DryRun   bool    `json:"dry_run"`
+	OrderID  string  `json:"order_id,omitempty"`
+	Error    string  `json:"error,omitempty"` // 风险检查、仓位计算或下单失败的原因
+}
+
+// SetExecutionConfig 设置信号执行配置
+func (m *StrategyManager) SetExecutionConfig(config ExecutionConfig) error {
+	if config.OrderAmount < 0 {
+		return fmt.Errorf("order amount must not be negative, got %.2f", config.OrderAmount)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.execution = config
+	log.Printf("Strategy execution config: dry_run=%v, order_amount=%.2f", config.DryRun, config.OrderAmount)
+	return nil
+}
+
+// GetExecutionConfig 获取信号执行配置
+func (m *StrategyManager) GetExecutionConfig() ExecutionConfig {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.execution
+}
+
+// GetLastCycleOrders 获取最近一次处理信号生成的委托，包括失败和试运行的委托
+func (m *StrategyManager) GetLastCycleOrders() []CycleOrder {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders := make([]CycleOrder, len(m.lastOrders))
+	copy(orders, m.lastOrders)
+	return orders
+}
+
+// RunCycle 执行一个完整的交易周期：执行并合并策略信号，对每个买卖信号计算仓位、
+// 经riskManager检查后通过orderExecutor下单；试运行模式只记录计划委托。
+// 单个信号失败不影响其他信号，返回所有失败信号的错误
+func (m *StrategyManager) RunCycle(ctx context.Context, marketData *MarketData) error {
+	result, err := m.ExecuteStrategies(ctx, marketData)
+	if err != nil {
+		return fmt.Errorf("strategy execution failed: %w", err)
+	}
+
+	for _, err := range result.Errors {
+		log.Printf("Strategy error for %s: %v", marketData.Symbol, err)
+	}
+	if len(result.Signals) == 0 {
+		return nil
+	}
+
+	return m.ProcessSignals(ctx, result.Signals)
+}
+
+// signalRouter 处理一批信号时使用的交易组件快照
+type signalRouter struct {
+	config        ExecutionConfig
+	riskManager   *trading.RiskManager
+	positionMgr   *trading.PositionManager
+	orderExecutor *trading.OrderExecutor
+	signalHandler *trading.SignalHandler
+	weighter      *PerformanceWeighter
+}
+
+// newSignalRouter 读取当前交易组件，下单需要的组件未设置时返回ErrTradingNotConfigured
+func (m *StrategyManager) newSignalRouter() (*signalRouter, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	r := &signalRouter{
+		config:        m.execution,
+		riskManager:   m.riskManager,
+		positionMgr:   m.positionManager,
+		orderExecutor: m.orderExecutor,
+		signalHandler: m.signalHandler,
+		weighter:      m.weighter,
+	}
+	if r.riskManager == nil || r.positionMgr == nil || (r.orderExecutor == nil && !r.config.DryRun) {
+		return nil, ErrTradingNotConfigured
+	}
+	return r, nil
+}
+
+// route 处理单个信号，返回生成的委托；hold信号、无可卖持仓时返回nil
+func (r *signalRouter) route(ctx context.Context, signal *Signal) (*CycleOrder, error) {
+	ctx, cancel := context.WithTimeout(ctx, signalOrderTimeout)
+	defer cancel()
+
+	switch signal.SignalType {
+	case "buy":
+		return r.buy(ctx, signal)
+	case "sell":
+		return r.sell(ctx, signal)
+	default:
+		log.Printf("Hold signal for %s: %s", signal.Symbol, signal.Reason)
+		return nil, nil
+	}
+}
+
+// checkCooldown 检查股票是否处于交易冷却期，试运行时也检查以便如实记录计划委托
+func (r *signalRouter) checkCooldown(symbol string) error {
+	if r.orderExecutor == nil {
+		return nil
+	}
+	return r.orderExecutor.CheckCooldown(symbol)
+}
+
+// buy 计算买入金额，风险检查通过后按手数下单
+func (r *signalRouter) buy(ctx context.Context, signal *Signal) (*CycleOrder, error) {
+	order := &CycleOrder{
+		Symbol: signal.Symbol,
+		Type:   trading.OrderTypeBuy,
+		Price:  signal.Price,
+		DryRun: r.config.DryRun,
+	}
+
+	amount := r.config.OrderAmount
+	if amount <= 0 {
+		config := r.riskManager.GetConfig()
+		amount = config.InitialCapital * config.MaxSinglePosition
+	}
+
+	// 附带策略历史胜率和盈亏比，供凯利仓位计算使用
+	tradingSignal := &trading.TradingSignal{
+		Symbol:     signal.Symbol,
+		Action:     "buy",
+		Confidence: signal.Strength,
+		Reason:     signal.Reason,
+		Timestamp:  time.Now(),
+	}
+	if name, ok := signal.Metadata["strategy_name"].(string); ok {
+		order.Strategy = name
+		tradingSignal.Strategy = name
+		tradingSignal.WinRate, tradingSignal.WinLossRatio, tradingSignal.HistoryTrades = r.weighter.WinStats(name, tradingSignal.Timestamp)
+	}
+	if r.signalHandler != nil {
+		sized, err := r.signalHandler.PositionAmount(tradingSignal, amount)
+		if err != nil {
+			return order, fmt.Errorf("仓位计算失败: %w", err)
+		}
+		amount = sized
+	}
+
+	req := trading.OrderRequest{
+		Type:   trading.OrderTypeBuy,
+		Symbol: signal.Symbol,
+		Price:  signal.Price,
+		Amount: int(amount),
+	}
+	order.Quantity = req.CalculateQuantity()
+	order.Amount = float64(order.Quantity) * signal.Price
+	if err := r.riskManager.CheckBeforeOrder(ctx, req); err != nil {
+		return order, fmt.Errorf("风险检查失败: %w", err)
+	}
+	if err := r.checkCooldown(signal.Symbol); err != nil {
+		return order, err
+	}
+	if order.Quantity <= 0 {
+		return order, fmt.Errorf("下单数量不足: 金额 %.2f, 价格 %.2f", amount, signal.Price)
+	}
+
+	if r.config.DryRun {
+		log.Printf("[dry-run] 计划买入: %s, 价格: %.2f, 数量: %d, 策略: %s", order.Symbol, order.Price, order.Quantity, order.Strategy)
+		return order, nil
+	}
+
+	orderID, err := r.orderExecutor.ExecuteBuy(ctx, signal.Symbol, signal.Price, amount)
+	if err != nil {
+		return order, err
+	}
+	order.OrderID = orderID
+	return order, nil
+}
+
+// sell 卖出该股票全部可用持仓，T+1未解冻的部分不卖
+func (r *signalRouter) sell(ctx context.Context, signal *Signal) (*CycleOrder, error) {
+	pos, err := r.positionMgr.GetPosition(signal.Symbol)
+	if err != nil || pos.Available <= 0 {
+		log.Printf("Sell signal for %s ignored: no available position", signal.Symbol)
+		return nil, nil
+	}
+
+	order := &CycleOrder{
+		Symbol:   signal.Symbol,
+		Type:     trading.OrderTypeSell,
+		Price:    signal.Price,
+		Quantity: pos.Available,
+		Amount:   float64(pos.Available) * signal.Price,
+		DryRun:   r.config.DryRun,
+	}
+	if name, ok := signal.Metadata["strategy_name"].(string); ok {
+		order.Strategy = name
+	}
+
+	req := trading.OrderRequest{
+		Type:   trading.OrderTypeSell,
+		Symbol: signal.Symbol,
+		Price:  signal.Price,
+		Amount: int(order.Amount),
+	}
+	if err := r.riskManager.CheckBeforeOrder(ctx, req); err != nil {
+		return order, fmt.Errorf("风险检查失败: %w", err)
+	}
+	if err := r.checkCooldown(signal.Symbol); err != nil {
+		return order, err
+	}
+
+	if r.config.DryRun {
+		log.Printf("[dry-run] 计划卖出: %s, 价格: %.2f, 数量: %d, 策略: %s", order.Symbol, order.Price, order.Quantity, order.Strategy)
+		return order, nil
+	}
+
+	orderID, err := r.orderExecutor.ExecuteSell(ctx, signal.Symbol, signal.Price, pos.Available)
+	if err != nil {
+		return order, err
+	}
+	order.OrderID = orderID
+	return order, nil
+}
+
+// routeSignals 逐个处理信号并记录生成的委托
+func (m *StrategyManager) routeSignals(ctx context.Context, signals []*Signal) error {
+	router, err := m.newSignalRouter()
+	if err != nil {
+		return err
+	}
+
+	// 先同步上一周期以来的成交，使凯利仓位使用的策略胜率包含最新平仓的交易
+	if !router.config.DryRun {
+		if err := router.orderExecutor.SyncTrades(ctx); err != nil {
+			log.Printf("Failed to sync trades before routing signals: %v", err)
+		}
+	}
+
+	var orders []CycleOrder
+	var errs []error
+	for _, signal := range signals {
+		if err := ValidateSignal(signal); err != nil {
+			log.Printf("Invalid signal from strategy: %v", err)
+			continue
+		}
+
+		order, err := router.route(ctx, signal)
+		if err != nil {
+			err = fmt.Errorf("%s %s: %w", signal.SignalType, signal.Symbol, err)
+			errs = append(errs, err)
+			log.Printf("Signal not executed: %v", err)
+		}
+		if order != nil {
+			if err != nil {
+				order.Error = err.Error()
+			}
+			orders = append(orders, *order)
+		}
+	}
+
+	m.mu.Lock()
+	m.lastOrders = orders
+	for _, order := range orders {
+		if order.OrderID != "" && order.Strategy != "" {
+			m.orderStrategies[order.OrderID] = order.Strategy
+		}
+	}
+	m.mu.Unlock()
+
+	return errors.Join(errs...)
+}
+
+// strategyLot 某个策略开仓的持仓
+type strategyLot struct {
+	strategy string
+	quantity int
+	cost     float64 // 含买入手续费的总成本
+}
+
+// OnFill 处理券商成交，由OrderExecutor.AddFillHandler注册：策略信号的买入成交记为该策略的开仓，
+// 卖出成交按开仓均价计算扣除手续费后的收益率，作为开仓策略的一笔已平仓交易计入表现统计，
+// 供表现加权和凯利仓位使用。非策略信号开仓的持仓不参与统计
+func (m *StrategyManager) OnFill(trade trading.Trade) {
+	if trade.Amount <= 0 || trade.Price <= 0 {
+		return
+	}
+
+	m.mu.Lock()
+	var closed *StrategyTrade
+	switch trade.Type {
+	case trading.OrderTypeBuy, "买入":
+		lot, ok := m.lots[trade.Symbol]
+		if !ok {
+			strategy, tagged := m.orderStrategies[trade.OrderID]
+			if !tagged {
+				break
+			}
+			lot = &strategyLot{strategy: strategy}
+			m.lots[trade.Symbol] = lot
+		}
+		lot.quantity += trade.Amount
+		lot.cost += trade.Price*float64(trade.Amount) + trade.Commission
+	case trading.OrderTypeSell, "卖出":
+		lot, ok := m.lots[trade.Symbol]
+		if !ok {
+			break
+		}
+		quantity := trade.Amount
+		if quantity > lot.quantity {
+			quantity = lot.quantity
+		}
+		cost := lot.cost * float64(quantity) / float64(lot.quantity)
+		proceeds := trade.Price*float64(quantity) - trade.Commission*float64(quantity)/float64(trade.Amount)
+		closed = &StrategyTrade{Strategy: lot.strategy, Return: proceeds/cost - 1, Time: trade.TradeTime}
+
+		lot.quantity -= quantity
+		lot.cost -= cost
+		if lot.quantity == 0 {
+			delete(m.lots, trade.Symbol)
+		}
+	}
+	m.mu.Unlock()
+
+	if closed != nil {
+		m.RecordStrategyTrade(*closed)
+		log.Printf("Strategy %s closed %s: return %.2f%%", closed.Strategy, trade.Symbol, closed.Return*100)
+	}
+}
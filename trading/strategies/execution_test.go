@@ -0,0 +1,211 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"cloudquant/trading"
+	"cloudquant/trading/risk"
+)
+
+// newExecutionManager 创建连接模拟盘的策略管理器，唯一的策略对sh600000发出10元的买入信号
+func newExecutionManager(t *testing.T, config ExecutionConfig) (*StrategyManager, trading.Broker) {
+	t.Helper()
+
+	strategy := &fixedSignalStrategy{BaseStrategy: NewBaseStrategy("buyer", 1), symbol: "sh600000", signalType: "buy", price: 10}
+	loader := NewStrategyLoader()
+	loader.RegisterFactory("buyer", func() Strategy { return strategy })
+	if err := loader.LoadStrategies([]StrategyConfig{{Name: "buyer", Type: "buyer", Enabled: true, Weight: 1}}); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+
+	connector, err := trading.NewBrokerConnector(trading.BrokerConfig{Type: trading.BrokerTypePaper, InitialCash: 100000})
+	if err != nil {
+		t.Fatalf("NewBrokerConnector: %v", err)
+	}
+	riskConfig := trading.DefaultRiskConfig
+	riskConfig.InitialCapital = 100000
+	rm := trading.NewRiskManager(riskConfig, connector, nil)
+	pm := trading.NewPositionManager(connector)
+	oe := trading.NewOrderExecutor(connector, rm, pm, nil)
+
+	manager := NewStrategyManager(loader, WeightedCombination)
+	manager.SetTradingComponents(rm, pm, oe, nil)
+	if err := manager.SetExecutionConfig(config); err != nil {
+		t.Fatalf("SetExecutionConfig: %v", err)
+	}
+	return manager, connector.GetBroker()
+}
+
+func runCycleBar() *MarketData {
+	return &MarketData{Symbol: "sh600000", Close: 10, Timestamp: time.Now(), BarClosed: true}
+}
+
+func TestRunCycleDryRunDoesNotSubmit(t *testing.T) {
+	manager, broker := newExecutionManager(t, ExecutionConfig{DryRun: true})
+
+	if err := manager.RunCycle(context.Background(), runCycleBar()); err != nil {
+		t.Fatalf("RunCycle: %v", err)
+	}
+
+	orders := manager.GetLastCycleOrders()
+	if len(orders) != 1 {
+		t.Fatalf("got %d cycle orders, want 1: %+v", len(orders), orders)
+	}
+	// 默认按单只股票仓位上限 100000 * 0.3 买入
+	if o := orders[0]; !o.DryRun || o.Type != trading.OrderTypeBuy || o.Quantity != 3000 || o.OrderID != "" {
+		t.Errorf("planned order = %+v, want dry-run buy of 3000 shares", o)
+	}
+
+	submitted, _ := broker.GetOrders(context.Background())
+	if len(submitted) != 0 {
+		t.Errorf("dry run submitted %d orders to the broker", len(submitted))
+	}
+}
+
+func TestRunCycleSubmitsSizedOrder(t *testing.T) {
+	manager, broker := newExecutionManager(t, ExecutionConfig{OrderAmount: 5000})
+
+	if err := manager.RunCycle(context.Background(), runCycleBar()); err != nil {
+		t.Fatalf("RunCycle: %v", err)
+	}
+
+	orders := manager.GetLastCycleOrders()
+	if len(orders) != 1 || orders[0].DryRun || orders[0].OrderID == "" || orders[0].Quantity != 500 {
+		t.Fatalf("cycle orders = %+v, want one submitted buy of 500 shares", orders)
+	}
+
+	submitted, _ := broker.GetOrders(context.Background())
+	if len(submitted) != 1 || submitted[0].OrderID != orders[0].OrderID || submitted[0].Amount != 500 {
+		t.Errorf("broker orders = %+v, want the submitted order", submitted)
+	}
+}
+
+func TestRunCycleRiskRejection(t *testing.T) {
+	manager, broker := newExecutionManager(t, ExecutionConfig{OrderAmount: 50})
+
+	err := manager.RunCycle(context.Background(), runCycleBar())
+	if !errors.Is(err, trading.ErrMinOrderAmount) {
+		t.Fatalf("RunCycle error = %v, want ErrMinOrderAmount", err)
+	}
+
+	orders := manager.GetLastCycleOrders()
+	if len(orders) != 1 || orders[0].Error == "" || orders[0].OrderID != "" {
+		t.Errorf("cycle orders = %+v, want one rejected order", orders)
+	}
+	submitted, _ := broker.GetOrders(context.Background())
+	if len(submitted) != 0 {
+		t.Errorf("rejected signal submitted %d orders", len(submitted))
+	}
+}
+
+func TestRunCycleHonorsTradeCooldown(t *testing.T) {
+	manager, broker := newExecutionManager(t, ExecutionConfig{OrderAmount: 5000})
+	cooldown := risk.NewCooldownRisk(risk.CooldownRiskConfig{MinTradeInterval: time.Hour, MaxDailyTrades: 10, MaxWeeklyTrades: 100, EnableCooldown: true}, nil)
+	manager.orderExecutor.SetTradeCooldown(cooldown)
+
+	if err := manager.RunCycle(context.Background(), runCycleBar()); err != nil {
+		t.Fatalf("first RunCycle: %v", err)
+	}
+	if status := cooldown.GetCooldownStatus("sh600000"); status.TradeCount != 1 {
+		t.Errorf("cooldown recorded %d trades, want 1", status.TradeCount)
+	}
+
+	// 最小交易间隔内的第二个买入信号被拒绝
+	if err := manager.RunCycle(context.Background(), runCycleBar()); !errors.Is(err, trading.ErrTradeCooldown) {
+		t.Fatalf("second RunCycle error = %v, want ErrTradeCooldown", err)
+	}
+	if orders := manager.GetLastCycleOrders(); len(orders) != 1 || orders[0].OrderID != "" || orders[0].Error == "" {
+		t.Errorf("cycle orders = %+v, want one rejected order", orders)
+	}
+	submitted, _ := broker.GetOrders(context.Background())
+	if len(submitted) != 1 {
+		t.Errorf("broker received %d orders, want 1", len(submitted))
+	}
+}
+
+func TestRunCycleRequiresTradingComponents(t *testing.T) {
+	manager, _ := newExecutionManager(t, DefaultExecutionConfig())
+	manager.SetTradingComponents(nil, nil, nil, nil)
+
+	if err := manager.RunCycle(context.Background(), runCycleBar()); !errors.Is(err, ErrTradingNotConfigured) {
+		t.Errorf("RunCycle error = %v, want ErrTradingNotConfigured", err)
+	}
+	if err := manager.SetExecutionConfig(ExecutionConfig{OrderAmount: -1}); err == nil {
+		t.Error("SetExecutionConfig accepted a negative order amount")
+	}
+}
+
+func TestOnFillRecordsClosedStrategyTrades(t *testing.T) {
+	manager, _ := newExecutionManager(t, ExecutionConfig{OrderAmount: 5000})
+	if err := manager.RunCycle(context.Background(), runCycleBar()); err != nil {
+		t.Fatalf("RunCycle: %v", err)
+	}
+	orderID := manager.GetLastCycleOrders()[0].OrderID
+
+	now := time.Now()
+	manager.OnFill(trading.Trade{TradeID: "b1", OrderID: orderID, Symbol: "sh600000", Type: trading.OrderTypeBuy, Price: 10, Amount: 500, Commission: 5, TradeTime: now})
+	// 非策略信号的成交不参与统计
+	manager.OnFill(trading.Trade{TradeID: "m1", OrderID: "manual", Symbol: "sz000001", Type: trading.OrderTypeBuy, Price: 20, Amount: 100, TradeTime: now})
+	manager.OnFill(trading.Trade{TradeID: "m2", OrderID: "manual2", Symbol: "sz000001", Type: trading.OrderTypeSell, Price: 25, Amount: 100, TradeTime: now})
+	// 分两笔卖出，每笔按开仓均价计算收益
+	manager.OnFill(trading.Trade{TradeID: "s1", OrderID: "sell1", Symbol: "sh600000", Type: trading.OrderTypeSell, Price: 11, Amount: 200, Commission: 2, TradeTime: now})
+	manager.OnFill(trading.Trade{TradeID: "s2", OrderID: "sell2", Symbol: "sh600000", Type: trading.OrderTypeSell, Price: 9, Amount: 300, Commission: 3, TradeTime: now})
+
+	trades := manager.weighter.trades["buyer"]
+	if len(trades) != 2 || len(manager.weighter.trades) != 1 {
+		t.Fatalf("recorded trades = %+v, want two closed trades for buyer only", manager.weighter.trades)
+	}
+	// 开仓成本 (5000+5)/500 = 10.01 元/股
+	if want := (11*200-2)/(10.01*200) - 1; math.Abs(trades[0].Return-want) > 1e-9 {
+		t.Errorf("first return = %.6f, want %.6f", trades[0].Return, want)
+	}
+	if trades[1].Return >= 0 {
+		t.Errorf("second return = %.6f, want a loss", trades[1].Return)
+	}
+	if len(manager.lots) != 0 {
+		t.Errorf("open lots after closing = %+v", manager.lots)
+	}
+}
+
+func TestKellySizingUsesRecordedStrategyTrades(t *testing.T) {
+	manager, _ := newExecutionManager(t, ExecutionConfig{DryRun: true})
+	handler := trading.NewSignalHandler(0.6, 0.6, manager.riskManager, manager.positionManager, manager.orderExecutor)
+	if err := handler.SetSizingConfig(trading.SizingConfig{Method: trading.SizingKelly, Fraction: 0.25, WinLossRatio: 1}); err != nil {
+		t.Fatalf("SetSizingConfig: %v", err)
+	}
+	manager.SetTradingComponents(manager.riskManager, manager.positionManager, manager.orderExecutor, handler)
+
+	plannedQuantity := func() int {
+		t.Helper()
+		if err := manager.RunCycle(context.Background(), runCycleBar()); err != nil {
+			t.Fatalf("RunCycle: %v", err)
+		}
+		orders := manager.GetLastCycleOrders()
+		if len(orders) != 1 || orders[0].Strategy != "buyer" {
+			t.Fatalf("cycle orders = %+v, want one buy attributed to buyer", orders)
+		}
+		return orders[0].Quantity
+	}
+
+	// 没有历史交易时胜率取信号置信度0.8：(0.8-0.2/1)*0.25 = 15%
+	if got := plannedQuantity(); got != 1500 {
+		t.Errorf("quantity without history = %d, want 1500", got)
+	}
+
+	// 成交回报记录一笔盈利20%、两笔亏损5%的交易：胜率1/3，盈亏比4
+	manager.orderStrategies["o1"] = "buyer"
+	now := time.Now()
+	for i, exit := range []float64{12, 9.5, 9.5} {
+		manager.OnFill(trading.Trade{OrderID: "o1", Symbol: "sz000001", Type: trading.OrderTypeBuy, Price: 10, Amount: 100, TradeTime: now})
+		manager.OnFill(trading.Trade{OrderID: "s" + string(rune('1'+i)), Symbol: "sz000001", Type: trading.OrderTypeSell, Price: exit, Amount: 100, TradeTime: now})
+	}
+
+	// 胜率取(0.8+1/3)/2：(0.5667-0.4333/4)*0.25 ≈ 11.46%，按手取整为1100股
+	if got := plannedQuantity(); got != 1100 {
+		t.Errorf("quantity with recorded trades = %d, want 1100", got)
+	}
+}
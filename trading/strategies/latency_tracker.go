@@ -0,0 +1,187 @@
+package strategies
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// LatencyConfig 策略延迟监控配置
+type LatencyConfig struct {
+	WindowSize int           `yaml:"window_size" json:"window_size"` // 滚动窗口样本数
+	Threshold  time.Duration `yaml:"threshold" json:"threshold"`     // 平均执行时间告警阈值，0使用默认值，负数表示不告警
+}
+
+// DefaultLatencyConfig 默认策略延迟监控配置
+func DefaultLatencyConfig() LatencyConfig {
+	return LatencyConfig{
+		WindowSize: 20,
+		Threshold:  5 * time.Second,
+	}
+}
+
+// StrategyLatency 单个策略的延迟统计
+type StrategyLatency struct {
+	Strategy  string    `json:"strategy"`
+	Samples   int       `json:"samples"`
+	AvgMs     float64   `json:"avg_ms"`
+	MaxMs     float64   `json:"max_ms"`
+	LastMs    float64   `json:"last_ms"`
+	Slow      bool      `json:"slow"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// SlowStrategyAlert 慢策略告警
+type SlowStrategyAlert struct {
+	Strategy  string        `json:"strategy"`
+	Average   time.Duration `json:"average"`
+	Threshold time.Duration `json:"threshold"`
+	Timestamp time.Time     `json:"timestamp"`
+}
+
+// LatencyTracker 策略执行延迟跟踪器
+type LatencyTracker struct {
+	mu       sync.Mutex
+	config   LatencyConfig
+	samples  map[string][]time.Duration
+	slow     map[string]bool
+	updated  map[string]time.Time
+	callback func(SlowStrategyAlert)
+}
+
+// withDefaults 未配置的窗口大小和告警阈值取默认值
+func (c LatencyConfig) withDefaults() LatencyConfig {
+	defaults := DefaultLatencyConfig()
+	if c.WindowSize <= 0 {
+		c.WindowSize = defaults.WindowSize
+	}
+	if c.Threshold == 0 {
+		c.Threshold = defaults.Threshold
+	}
+	return c
+}
+
+// NewLatencyTracker 创建延迟跟踪器
+func NewLatencyTracker(config LatencyConfig) *LatencyTracker {
+	return &LatencyTracker{
+		config:  config.withDefaults(),
+		samples: make(map[string][]time.Duration),
+		slow:    make(map[string]bool),
+		updated: make(map[string]time.Time),
+	}
+}
+
+// SetConfig 更新配置，窗口缩小时丢弃最早的样本
+func (t *LatencyTracker) SetConfig(config LatencyConfig) {
+	config = config.withDefaults()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.config = config
+	for name, window := range t.samples {
+		if len(window) > config.WindowSize {
+			t.samples[name] = window[len(window)-config.WindowSize:]
+		}
+	}
+}
+
+// SetAlertCallback 设置慢策略告警回调
+func (t *LatencyTracker) SetAlertCallback(callback func(SlowStrategyAlert)) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.callback = callback
+}
+
+// Record 记录一次策略执行耗时。
+// 平均耗时首次达到阈值时触发告警，回落到阈值以下后才会再次告警。
+func (t *LatencyTracker) Record(strategy string, d time.Duration) {
+	t.mu.Lock()
+
+	window := append(t.samples[strategy], d)
+	if len(window) > t.config.WindowSize {
+		window = window[len(window)-t.config.WindowSize:]
+	}
+	t.samples[strategy] = window
+	now := time.Now()
+	t.updated[strategy] = now
+
+	avg := averageDuration(window)
+	threshold := t.config.Threshold
+	callback := t.callback
+
+	var alert *SlowStrategyAlert
+	if threshold > 0 && avg >= threshold {
+		if !t.slow[strategy] {
+			t.slow[strategy] = true
+			alert = &SlowStrategyAlert{
+				Strategy:  strategy,
+				Average:   avg,
+				Threshold: threshold,
+				Timestamp: now,
+			}
+		}
+	} else {
+		t.slow[strategy] = false
+	}
+	t.mu.Unlock()
+
+	if alert != nil && callback != nil {
+		callback(*alert)
+	}
+}
+
+// GetLatencies 获取所有策略的延迟统计，按策略名排序
+func (t *LatencyTracker) GetLatencies() []StrategyLatency {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	stats := make([]StrategyLatency, 0, len(t.samples))
+	for name, window := range t.samples {
+		if len(window) == 0 {
+			continue
+		}
+		var max time.Duration
+		for _, d := range window {
+			if d > max {
+				max = d
+			}
+		}
+		stats = append(stats, StrategyLatency{
+			Strategy:  name,
+			Samples:   len(window),
+			AvgMs:     durationMs(averageDuration(window)),
+			MaxMs:     durationMs(max),
+			LastMs:    durationMs(window[len(window)-1]),
+			Slow:      t.slow[name],
+			UpdatedAt: t.updated[name],
+		})
+	}
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Strategy < stats[j].Strategy
+	})
+	return stats
+}
+
+// Threshold 获取当前告警阈值
+func (t *LatencyTracker) Threshold() time.Duration {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.config.Threshold
+}
+
+func averageDuration(window []time.Duration) time.Duration {
+	if len(window) == 0 {
+		return 0
+	}
+	var total time.Duration
+	for _, d := range window {
+		total += d
+	}
+	return total / time.Duration(len(window))
+}
+
+func durationMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,86 @@
+package strategies
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLatencyTrackerSlowStrategyAlert(t *testing.T) {
+	tracker := NewLatencyTracker(LatencyConfig{
+		WindowSize: 3,
+		Threshold:  40 * time.Millisecond,
+	})
+
+	var alerts []SlowStrategyAlert
+	tracker.SetAlertCallback(func(alert SlowStrategyAlert) {
+		alerts = append(alerts, alert)
+	})
+
+	// 滚动平均依次为 10, 15, 20, 30, 40, 50 ms
+	for i := 1; i <= 6; i++ {
+		tracker.Record("ma_strategy", time.Duration(i*10)*time.Millisecond)
+
+		if i < 5 && len(alerts) != 0 {
+			t.Fatalf("record %d: alert fired below threshold: %+v", i, alerts)
+		}
+		if i == 5 && len(alerts) != 1 {
+			t.Fatalf("record %d: expected alert at threshold, got %d", i, len(alerts))
+		}
+	}
+
+	if len(alerts) != 1 {
+		t.Fatalf("expected a single alert while strategy stays slow, got %d", len(alerts))
+	}
+	if alerts[0].Strategy != "ma_strategy" {
+		t.Errorf("alert strategy = %s, want ma_strategy", alerts[0].Strategy)
+	}
+	if alerts[0].Average != 40*time.Millisecond {
+		t.Errorf("alert average = %v, want 40ms", alerts[0].Average)
+	}
+
+	stats := tracker.GetLatencies()
+	if len(stats) != 1 {
+		t.Fatalf("expected stats for 1 strategy, got %d", len(stats))
+	}
+	if stats[0].Samples != 3 || stats[0].AvgMs != 50 || stats[0].MaxMs != 60 || !stats[0].Slow {
+		t.Errorf("unexpected stats: %+v", stats[0])
+	}
+
+	// 回落到阈值以下后再次变慢应重新告警
+	for i := 0; i < 3; i++ {
+		tracker.Record("ma_strategy", time.Millisecond)
+	}
+	for i := 0; i < 3; i++ {
+		tracker.Record("ma_strategy", 100*time.Millisecond)
+	}
+	if len(alerts) != 2 {
+		t.Fatalf("expected alert after recovery and slowdown, got %d", len(alerts))
+	}
+}
+
+func TestLatencyTrackerZeroThresholdUsesDefault(t *testing.T) {
+	tracker := NewLatencyTracker(LatencyConfig{WindowSize: 2})
+	if got, want := tracker.Threshold(), DefaultLatencyConfig().Threshold; got != want {
+		t.Fatalf("threshold = %v, want default %v", got, want)
+	}
+
+	fired := false
+	tracker.SetAlertCallback(func(SlowStrategyAlert) { fired = true })
+
+	tracker.Record("rsi_strategy", time.Hour)
+	if !fired {
+		t.Fatal("expected alert with default threshold")
+	}
+}
+
+func TestLatencyTrackerNegativeThresholdDisablesAlerts(t *testing.T) {
+	tracker := NewLatencyTracker(LatencyConfig{WindowSize: 2, Threshold: -1})
+
+	fired := false
+	tracker.SetAlertCallback(func(SlowStrategyAlert) { fired = true })
+
+	tracker.Record("rsi_strategy", time.Hour)
+	if fired {
+		t.Fatal("alert fired with negative threshold")
+	}
+}
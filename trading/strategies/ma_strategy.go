@@ -12,10 +12,12 @@ import (
 
 // Strategy type constants (must match strategy_loader.go)
 const (
-	MAStrategyType  StrategyType = "ma"
-	RSIStrategyType StrategyType = "rsi"
-	AIStrategyType  StrategyType = "ai"
-	MLStrategyType  StrategyType = "ml"
+	MAStrategyType        StrategyType = "ma"
+	RSIStrategyType       StrategyType = "rsi"
+	AIStrategyType        StrategyType = "ai"
+	MLStrategyType        StrategyType = "ml"
+	PairsStrategyType     StrategyType = "pairs"
+	BollingerStrategyType StrategyType = "bollinger"
 )
 
 // MAStrategy 均线策略
@@ -74,6 +76,14 @@ func (m *MAStrategy) Init(ctx context.Context, symbol string, config map[string]
 	return nil
 }
 
+// DataRequirements 均线策略需要长期均线周期的历史数据
+func (m *MAStrategy) DataRequirements() DataRequirements {
+	return DataRequirements{
+		MinHistory: m.longPeriod,
+		Timeframe:  DefaultTimeframe,
+	}
+}
+
 // GenerateSignal 生成交易信号
 func (m *MAStrategy) GenerateSignal(ctx context.Context, marketData *MarketData) (*Signal, error) {
 	if marketData == nil {
@@ -100,6 +100,14 @@ func (m *MLStrategy) SetModelProvider(provider ml.ModelProvider) {
 	log.Printf("ML strategy model provider set")
 }
 
+// DataRequirements ML策略需要回看天数+5根K线提取特征
+func (m *MLStrategy) DataRequirements() DataRequirements {
+	return DataRequirements{
+		MinHistory: m.lookbackDays + 5,
+		Timeframe:  DefaultTimeframe,
+	}
+}
+
 // GenerateSignal 生成ML交易信号
 func (m *MLStrategy) GenerateSignal(ctx context.Context, marketData *MarketData) (*Signal, error) {
 	if marketData == nil {
@@ -272,6 +280,10 @@ func (m *MLStrategy) parsePredictionResult(result interface{}) (*MLPrediction, e
 			prediction.Probability = prediction.Confidence
 		}
 
+		if importance, ok := resultMap["feature_importance"].(map[string]float64); ok {
+			prediction.FeatureImportance = importance
+		}
+
 		return prediction, nil
 	}
 
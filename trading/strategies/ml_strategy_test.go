@@ -0,0 +1,72 @@
+package strategies
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMLStrategyDeclaresLookbackRequirement(t *testing.T) {
+	strategy := NewMLStrategy()
+
+	if got := strategy.DataRequirements().MinHistory; got != 25 {
+		t.Fatalf("default MinHistory = %d, want 25", got)
+	}
+
+	if err := strategy.Init(context.Background(), "sh600000", map[string]interface{}{
+		"lookback_days": 10,
+	}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	req := strategy.DataRequirements()
+	if req.MinHistory != 15 {
+		t.Errorf("MinHistory = %d, want lookback_days+5 = 15", req.MinHistory)
+	}
+	if req.Timeframe != DefaultTimeframe {
+		t.Errorf("Timeframe = %q, want %q", req.Timeframe, DefaultTimeframe)
+	}
+
+	if err := CheckDataReady(strategy, 14); !errors.Is(err, ErrInsufficientData) {
+		t.Errorf("CheckDataReady(14) = %v, want ErrInsufficientData", err)
+	}
+	if err := CheckDataReady(strategy, 15); err != nil {
+		t.Errorf("CheckDataReady(15) = %v, want nil", err)
+	}
+}
+
+func TestWarmUpFeedsOnlyRequiredHistory(t *testing.T) {
+	strategy := NewMLStrategy().(*MLStrategy)
+	strategy.lookbackDays = 3
+
+	history := make([]*MarketData, 20)
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range history {
+		history[i] = &MarketData{Symbol: "sh600000", Close: 10 + float64(i), Timestamp: start.AddDate(0, 0, i)}
+	}
+
+	if err := WarmUp(context.Background(), strategy, history); err != nil {
+		t.Fatalf("WarmUp: %v", err)
+	}
+	if len(strategy.dataBuffer) != 8 {
+		t.Fatalf("expected 8 warm-up bars, got %d", len(strategy.dataBuffer))
+	}
+	if strategy.dataBuffer[0] != 22 {
+		t.Errorf("expected warm-up to use the most recent bars, first close = %.0f", strategy.dataBuffer[0])
+	}
+}
+
+func TestMergeDataRequirements(t *testing.T) {
+	merged := MergeDataRequirements(
+		DataRequirements{MinHistory: 20, Symbols: []string{"sh600519"}},
+		DataRequirements{MinHistory: 15, Symbols: []string{"sh600000", "sh600519"}},
+	)
+
+	if merged.MinHistory != 20 {
+		t.Errorf("MinHistory = %d, want 20", merged.MinHistory)
+	}
+	if len(merged.Symbols) != 2 || merged.Symbols[0] != "sh600000" || merged.Symbols[1] != "sh600519" {
+		t.Errorf("Symbols = %v, want [sh600000 sh600519]", merged.Symbols)
+	}
+}
@@ -0,0 +1,314 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"cloudquant/trading"
+)
+
+// PairsStrategy 配对交易策略：跟踪两只股票价差的滚动z-score，
+// 价差偏离均值超过入场阈值时做多被低估的一腿、做空被高估的一腿，回归到平仓阈值以内时平仓
+type PairsStrategy struct {
+	*BaseStrategy
+	symbolA  string  // 第一条腿
+	symbolB  string  // 第二条腿
+	lookback int     // 计算对冲比例和z-score的窗口长度
+	entryZ   float64 // 入场阈值
+	exitZ    float64 // 平仓阈值
+
+	lastA      pairsQuote
+	lastB      pairsQuote
+	pricesA    []float64 // 按时间对齐的第一条腿收盘价
+	pricesB    []float64 // 按时间对齐的第二条腿收盘价
+	lastPaired time.Time // 最近一次对齐的K线时间
+	hedgeRatio float64   // 价差 = A - hedgeRatio*B
+	zScore     float64
+	ready      bool
+	position   int            // 价差方向：1做多价差（买A卖B），-1做空价差（卖A买B），0空仓
+	delivered  map[string]int // 每条腿已发出信号对应的价差方向
+}
+
+// pairsQuote 一条腿最新的收盘价
+type pairsQuote struct {
+	price     float64
+	timestamp time.Time
+}
+
+// NewPairsStrategy 创建配对交易策略
+func NewPairsStrategy() Strategy {
+	strategy := &PairsStrategy{
+		BaseStrategy: NewBaseStrategy("pairs_strategy", 0.2),
+		lookback:     60,
+		entryZ:       2.0,
+		exitZ:        0.5,
+		delivered:    make(map[string]int),
+	}
+
+	// 设置默认参数
+	strategy.parameters = map[string]interface{}{
+		"lookback": 60,
+		"entry_z":  2.0,
+		"exit_z":   0.5,
+	}
+
+	return strategy
+}
+
+// Init 初始化策略
+func (p *PairsStrategy) Init(ctx context.Context, symbol string, config map[string]interface{}) error {
+	if err := p.BaseStrategy.Init(ctx, symbol, config); err != nil {
+		return err
+	}
+	if err := p.applyParameters(config); err != nil {
+		return err
+	}
+
+	log.Printf("Pairs strategy initialized: %s/%s, lookback=%d, entry_z=%.2f, exit_z=%.2f",
+		p.symbolA, p.symbolB, p.lookback, p.entryZ, p.exitZ)
+	return nil
+}
+
+// DataRequirements 配对策略需要两条腿各lookback根K线
+func (p *PairsStrategy) DataRequirements() DataRequirements {
+	return DataRequirements{
+		Symbols:    []string{p.symbolA, p.symbolB},
+		MinHistory: p.lookback,
+		Timeframe:  DefaultTimeframe,
+	}
+}
+
+// GenerateSignal 生成交易信号
+//
+// 两条腿同一时间的K线都到达后更新价差和z-score。每次调用只能返回当前股票的信号，
+// 因此价差方向变化时先到达的一腿在其下一根K线上发出对应信号。
+func (p *PairsStrategy) GenerateSignal(ctx context.Context, marketData *MarketData) (*Signal, error) {
+	if marketData == nil {
+		return nil, fmt.Errorf("market data is nil")
+	}
+
+	var legSign int
+	switch marketData.Symbol {
+	case p.symbolA:
+		p.lastA = pairsQuote{price: marketData.Close, timestamp: marketData.Timestamp}
+		legSign = 1
+	case p.symbolB:
+		p.lastB = pairsQuote{price: marketData.Close, timestamp: marketData.Timestamp}
+		legSign = -1
+	default:
+		return nil, nil // 不属于本配对
+	}
+
+	if p.lastA.timestamp.Equal(p.lastB.timestamp) && p.lastA.timestamp.After(p.lastPaired) {
+		p.lastPaired = p.lastA.timestamp
+		p.updateSpread(p.lastA.price, p.lastB.price)
+	}
+	if !p.ready {
+		return nil, nil // 数据不足
+	}
+
+	// 该腿的持仓变化：做多价差时A多B空，做空价差时A空B多
+	delivered := p.delivered[marketData.Symbol]
+	if p.position == delivered {
+		return nil, nil
+	}
+	p.delivered[marketData.Symbol] = p.position
+
+	signalType := "buy"
+	if (p.position-delivered)*legSign < 0 {
+		signalType = "sell"
+	}
+
+	var strength float64
+	var reason string
+	if p.position == 0 {
+		strength = math.Max(0, 1-math.Abs(p.zScore)/p.entryZ)
+		reason = fmt.Sprintf("Spread reverted: z-score %.2f within ±%.2f", p.zScore, p.exitZ)
+	} else {
+		strength = math.Min(math.Abs(p.zScore)/(2*p.entryZ), 1.0)
+		reason = fmt.Sprintf("Spread diverged: z-score %.2f beyond ±%.2f", p.zScore, p.entryZ)
+	}
+
+	signal := NewSignal(marketData.Symbol, signalType, strength, marketData.Close)
+	signal.Timestamp = marketData.Timestamp
+	signal.Reason = reason
+	signal.Metadata["z_score"] = p.zScore
+	signal.Metadata["hedge_ratio"] = p.hedgeRatio
+	signal.Metadata["spread_position"] = p.position
+	signal.Metadata["pair"] = p.symbolA + "/" + p.symbolB
+
+	log.Printf("Pairs strategy generated signal: %s %s (z-score: %.2f, strength: %.3f)",
+		marketData.Symbol, signalType, p.zScore, strength)
+
+	return signal, nil
+}
+
+// updateSpread 记录一组对齐的价格，重新估计对冲比例并按z-score更新价差方向
+func (p *PairsStrategy) updateSpread(priceA, priceB float64) {
+	p.pricesA = append(p.pricesA, priceA)
+	p.pricesB = append(p.pricesB, priceB)
+	p.trimPrices()
+	if len(p.pricesA) < p.lookback {
+		return
+	}
+
+	// 最小二乘估计对冲比例
+	meanA, meanB := average(p.pricesA), average(p.pricesB)
+	var cov, varB float64
+	for i := range p.pricesA {
+		cov += (p.pricesA[i] - meanA) * (p.pricesB[i] - meanB)
+		varB += (p.pricesB[i] - meanB) * (p.pricesB[i] - meanB)
+	}
+	if varB == 0 {
+		return // B价格不变，无法估计对冲比例
+	}
+	p.hedgeRatio = cov / varB
+
+	spreads := make([]float64, len(p.pricesA))
+	for i := range p.pricesA {
+		spreads[i] = p.pricesA[i] - p.hedgeRatio*p.pricesB[i]
+	}
+	mean := average(spreads)
+	var variance float64
+	for _, spread := range spreads {
+		variance += (spread - mean) * (spread - mean)
+	}
+	std := math.Sqrt(variance / float64(len(spreads)))
+	p.zScore = 0
+	if std > 0 {
+		p.zScore = (spreads[len(spreads)-1] - mean) / std
+	}
+	p.ready = true
+
+	// 持仓时回归到平仓阈值以内先平仓，再判断是否反向入场
+	if p.position == 1 && p.zScore >= -p.exitZ || p.position == -1 && p.zScore <= p.exitZ {
+		p.position = 0
+	}
+	if p.zScore >= p.entryZ {
+		p.position = -1
+	} else if p.zScore <= -p.entryZ {
+		p.position = 1
+	}
+}
+
+// trimPrices 只保留最近lookback组价格
+func (p *PairsStrategy) trimPrices() {
+	if excess := len(p.pricesA) - p.lookback; excess > 0 {
+		p.pricesA = p.pricesA[excess:]
+		p.pricesB = p.pricesB[excess:]
+	}
+}
+
+// OnTrade 交易回调
+func (p *PairsStrategy) OnTrade(ctx context.Context, trade *trading.TradeRecord) error {
+	log.Printf("Pairs strategy trade executed: %s %d shares at %.2f",
+		trade.Symbol, trade.Volume, trade.Price)
+	return nil
+}
+
+// OnDailyClose 收盘回调
+func (p *PairsStrategy) OnDailyClose(ctx context.Context, date time.Time) error {
+	log.Printf("Pairs strategy daily close processing for %s", date.Format("2006-01-02"))
+	return nil
+}
+
+// GetZScore 获取当前价差z-score，数据不足时返回false
+func (p *PairsStrategy) GetZScore() (float64, bool) {
+	return p.zScore, p.ready
+}
+
+// GetParameters 获取策略参数
+func (p *PairsStrategy) GetParameters() map[string]interface{} {
+	params := p.BaseStrategy.GetParameters()
+	params["symbol_a"] = p.symbolA
+	params["symbol_b"] = p.symbolB
+	params["lookback"] = p.lookback
+	params["entry_z"] = p.entryZ
+	params["exit_z"] = p.exitZ
+	return params
+}
+
+// UpdateParameters 更新策略参数，更换股票时清空价差历史
+func (p *PairsStrategy) UpdateParameters(params map[string]interface{}) error {
+	if err := p.BaseStrategy.UpdateParameters(params); err != nil {
+		return err
+	}
+	return p.applyParameters(params)
+}
+
+// applyParameters 解析并校验参数，非法时保持原参数不变
+func (p *PairsStrategy) applyParameters(params map[string]interface{}) error {
+	symbolA, symbolB := p.symbolA, p.symbolB
+	lookback, entryZ, exitZ := p.lookback, p.entryZ, p.exitZ
+
+	if symbol, ok := params["symbol_a"].(string); ok {
+		symbolA = symbol
+	}
+	if symbol, ok := params["symbol_b"].(string); ok {
+		symbolB = symbol
+	}
+	if value, ok := numberParam(params["lookback"]); ok {
+		lookback = int(value)
+	}
+	if value, ok := numberParam(params["entry_z"]); ok {
+		entryZ = value
+	}
+	if value, ok := numberParam(params["exit_z"]); ok {
+		exitZ = value
+	}
+
+	// 参数验证
+	if symbolA == "" || symbolB == "" {
+		return fmt.Errorf("pairs strategy requires symbol_a and symbol_b")
+	}
+	if symbolA == symbolB {
+		return fmt.Errorf("symbol_a and symbol_b must differ")
+	}
+	if lookback < 3 {
+		return fmt.Errorf("lookback must be at least 3, got %d", lookback)
+	}
+	if exitZ < 0 || entryZ <= exitZ {
+		return fmt.Errorf("entry_z must be greater than exit_z and exit_z non-negative, got %.2f and %.2f", entryZ, exitZ)
+	}
+
+	if symbolA != p.symbolA || symbolB != p.symbolB {
+		p.lastA, p.lastB = pairsQuote{}, pairsQuote{}
+		p.pricesA, p.pricesB = nil, nil
+		p.lastPaired = time.Time{}
+		p.ready = false
+		p.position = 0
+		p.delivered = make(map[string]int)
+	}
+	p.symbolA, p.symbolB = symbolA, symbolB
+	p.lookback, p.entryZ, p.exitZ = lookback, entryZ, exitZ
+	p.trimPrices()
+	return nil
+}
+
+// numberParam 读取数值参数，兼容YAML整数和JSON浮点数
+func numberParam(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// average 算术平均
+func average(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
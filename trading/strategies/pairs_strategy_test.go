@@ -0,0 +1,120 @@
+package strategies
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+// cointegratedPair 生成协整的价格序列：B为随机游走，A = 1.5*B + 5 + 有界的价差噪声，
+// 在shockStart起的shockLen根K线上价差偏离1.5
+func cointegratedPair(n, shockStart, shockLen int) (pricesA, pricesB []float64) {
+	rng := rand.New(rand.NewSource(7))
+	b := 20.0
+	for t := 0; t < n; t++ {
+		b += rng.NormFloat64() * 0.2
+		spread := 0.1 * math.Sin(float64(t))
+		if t >= shockStart && t < shockStart+shockLen {
+			spread = 1.5
+		}
+		pricesA = append(pricesA, 1.5*b+5+spread)
+		pricesB = append(pricesB, b)
+	}
+	return pricesA, pricesB
+}
+
+type pairsSignal struct {
+	bar        int
+	symbol     string
+	signalType string
+}
+
+func TestPairsStrategyTradesSpreadDivergence(t *testing.T) {
+	strategy := NewPairsStrategy()
+	if err := strategy.Init(context.Background(), "", map[string]interface{}{
+		"symbol_a": "sh600000",
+		"symbol_b": "sh601166",
+		"lookback": 30,
+		"entry_z":  2.0,
+		"exit_z":   0.5,
+	}); err != nil {
+		t.Fatalf("Init: %v", err)
+	}
+
+	pricesA, pricesB := cointegratedPair(120, 80, 4)
+	start := time.Date(2024, 1, 2, 15, 0, 0, 0, time.Local)
+	var signals []pairsSignal
+	for i := range pricesA {
+		ts := start.AddDate(0, 0, i)
+		for _, bar := range []*MarketData{
+			{Symbol: "sh600000", Close: pricesA[i], Timestamp: ts, BarClosed: true},
+			{Symbol: "sh601166", Close: pricesB[i], Timestamp: ts, BarClosed: true},
+			{Symbol: "sz000001", Close: 10, Timestamp: ts, BarClosed: true},
+		} {
+			signal, err := strategy.GenerateSignal(context.Background(), bar)
+			if err != nil {
+				t.Fatalf("GenerateSignal: %v", err)
+			}
+			if signal == nil {
+				continue
+			}
+			if err := ValidateSignal(signal); err != nil {
+				t.Fatalf("invalid signal %+v: %v", signal, err)
+			}
+			signals = append(signals, pairsSignal{bar: i, symbol: signal.Symbol, signalType: signal.SignalType})
+		}
+	}
+
+	// A相对B被高估：先做空价差（买B、下一根K线卖A），回归后反向平仓
+	want := []pairsSignal{
+		{80, "sh601166", "buy"},
+		{81, "sh600000", "sell"},
+		{84, "sh601166", "sell"},
+		{85, "sh600000", "buy"},
+	}
+	if len(signals) != len(want) {
+		t.Fatalf("got signals %+v, want %+v", signals, want)
+	}
+	for i := range want {
+		if signals[i] != want[i] {
+			t.Errorf("signal %d = %+v, want %+v", i, signals[i], want[i])
+		}
+	}
+
+	pairs := strategy.(*PairsStrategy)
+	if math.Abs(pairs.hedgeRatio-1.5) > 0.2 {
+		t.Errorf("hedge ratio %.3f, want about 1.5", pairs.hedgeRatio)
+	}
+	if z, ready := pairs.GetZScore(); !ready || math.Abs(z) >= 2 {
+		t.Errorf("final z-score %.2f (ready %v), want back inside entry band", z, ready)
+	}
+}
+
+func TestPairsStrategyParameters(t *testing.T) {
+	strategy := NewPairsStrategy()
+	if err := strategy.Init(context.Background(), "", map[string]interface{}{"symbol_a": "sh600000"}); err == nil {
+		t.Error("Init without symbol_b should fail")
+	}
+	if err := strategy.Init(context.Background(), "", map[string]interface{}{
+		"symbol_a": "sh600000", "symbol_b": "sh601166", "entry_z": 0.5, "exit_z": 1.0,
+	}); err == nil {
+		t.Error("Init with entry_z below exit_z should fail")
+	}
+
+	// JSON数值为float64
+	if err := strategy.UpdateParameters(map[string]interface{}{
+		"symbol_a": "sh600000", "symbol_b": "sh601166", "lookback": 40.0, "entry_z": 2, "exit_z": 0.25,
+	}); err != nil {
+		t.Fatalf("UpdateParameters: %v", err)
+	}
+	params := strategy.GetParameters()
+	if params["lookback"] != 40 || params["entry_z"] != 2.0 || params["exit_z"] != 0.25 || params["symbol_b"] != "sh601166" {
+		t.Errorf("GetParameters = %v", params)
+	}
+	req := strategy.DataRequirements()
+	if req.MinHistory != 40 || len(req.Symbols) != 2 {
+		t.Errorf("DataRequirements = %+v", req)
+	}
+}
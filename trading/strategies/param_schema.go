@@ -0,0 +1,201 @@
+package strategies
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// ParamType 策略参数类型
+type ParamType string
+
+const (
+	ParamInt        ParamType = "int"
+	ParamFloat      ParamType = "float"
+	ParamString     ParamType = "string"
+	ParamBool       ParamType = "bool"
+	ParamStringList ParamType = "string_list"
+)
+
+// ParamSpec 单个参数的类型和取值约束
+type ParamSpec struct {
+	Type ParamType
+	Min  *float64 // 数值下限（含），nil表示不限
+	Max  *float64 // 数值上限（含），nil表示不限
+	Enum []string // 字符串可选值，为空表示不限
+}
+
+// ParameterSchema 策略类型的参数约束，键为参数名；配置中出现未声明的参数视为拼写错误
+type ParameterSchema map[string]ParamSpec
+
+// bound 构造ParamSpec的数值边界
+func bound(v float64) *float64 {
+	return &v
+}
+
+// builtinSchemas 内置策略的参数约束
+var builtinSchemas = map[StrategyType]ParameterSchema{
+	MAStrategyType: {
+		"short_period": {Type: ParamInt, Min: bound(1)},
+		"long_period":  {Type: ParamInt, Min: bound(1)},
+		"min_volume":   {Type: ParamFloat, Min: bound(0)},
+		"max_change":   {Type: ParamFloat, Min: bound(0), Max: bound(1)},
+	},
+	RSIStrategyType: {
+		"period":       {Type: ParamInt, Min: bound(1)},
+		"oversold":     {Type: ParamFloat, Min: bound(0), Max: bound(100)},
+		"overbought":   {Type: ParamFloat, Min: bound(0), Max: bound(100)},
+		"min_volume":   {Type: ParamFloat, Min: bound(0)},
+		"price_filter": {Type: ParamBool},
+	},
+	AIStrategyType: {
+		"threshold":         {Type: ParamFloat, Min: bound(0), Max: bound(1)},
+		"confidence":        {Type: ParamFloat, Min: bound(0), Max: bound(1)},
+		"api_key":           {Type: ParamString},
+		"analysis_interval": {Type: ParamString},
+		"market_context":    {Type: ParamBool},
+		"risk_analysis":     {Type: ParamBool},
+		"max_retries":       {Type: ParamInt, Min: bound(0)},
+		"retry_base_delay":  {Type: ParamString},
+	},
+	MLStrategyType: {
+		"lookback_days":    {Type: ParamInt, Min: bound(1)},
+		"confidence":       {Type: ParamFloat, Min: bound(0), Max: bound(1)},
+		"features":         {Type: ParamStringList},
+		"update_frequency": {Type: ParamString},
+		"use_real_time":    {Type: ParamBool},
+	},
+	PairsStrategyType: {
+		"symbol_a": {Type: ParamString},
+		"symbol_b": {Type: ParamString},
+		"lookback": {Type: ParamInt, Min: bound(3)},
+		"entry_z":  {Type: ParamFloat, Min: bound(0)},
+		"exit_z":   {Type: ParamFloat, Min: bound(0)},
+	},
+	BollingerStrategyType: {
+		"period":  {Type: ParamInt, Min: bound(2)},
+		"num_std": {Type: ParamFloat, Min: bound(0)},
+		"mode":    {Type: ParamString, Enum: []string{BollingerReversion, BollingerBreakout}},
+	},
+}
+
+// Validate 按约束校验参数，返回统一为约束类型的参数副本（int为int，float为float64，
+// 字符串列表为[]interface{}），使策略中的类型断言生效。错误列出所有不合法的参数。
+func (s ParameterSchema) Validate(params map[string]interface{}) (map[string]interface{}, error) {
+	normalized := make(map[string]interface{}, len(params))
+	var problems []string
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		spec, ok := s[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("%s: unknown parameter", key))
+			continue
+		}
+		value, err := spec.normalize(params[key])
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		normalized[key] = value
+	}
+
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("%s", strings.Join(problems, "; "))
+	}
+	return normalized, nil
+}
+
+// normalize 检查单个参数值的类型和范围，返回约束类型的值
+func (p ParamSpec) normalize(value interface{}) (interface{}, error) {
+	switch p.Type {
+	case ParamInt:
+		number, ok := numberParam(value)
+		if !ok || number != math.Trunc(number) {
+			return nil, fmt.Errorf("expected integer, got %s", describeValue(value))
+		}
+		if err := p.checkRange(number); err != nil {
+			return nil, err
+		}
+		return int(number), nil
+	case ParamFloat:
+		number, ok := numberParam(value)
+		if !ok {
+			return nil, fmt.Errorf("expected number, got %s", describeValue(value))
+		}
+		if err := p.checkRange(number); err != nil {
+			return nil, err
+		}
+		return number, nil
+	case ParamString:
+		str, ok := value.(string)
+		if !ok {
+			return nil, fmt.Errorf("expected string, got %s", describeValue(value))
+		}
+		if len(p.Enum) > 0 && !containsString(p.Enum, str) {
+			return nil, fmt.Errorf("must be one of %s, got %q", strings.Join(p.Enum, ", "), str)
+		}
+		return str, nil
+	case ParamBool:
+		if _, ok := value.(bool); !ok {
+			return nil, fmt.Errorf("expected bool, got %s", describeValue(value))
+		}
+		return value, nil
+	case ParamStringList:
+		var items []interface{}
+		switch list := value.(type) {
+		case []interface{}:
+			items = list
+		case []string:
+			for _, item := range list {
+				items = append(items, item)
+			}
+		default:
+			return nil, fmt.Errorf("expected list of strings, got %s", describeValue(value))
+		}
+		for i, item := range items {
+			if _, ok := item.(string); !ok {
+				return nil, fmt.Errorf("item %d: expected string, got %s", i, describeValue(item))
+			}
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("unsupported parameter type %s", p.Type)
+}
+
+// checkRange 检查数值是否在上下限内
+func (p ParamSpec) checkRange(number float64) error {
+	if p.Min != nil && number < *p.Min {
+		return fmt.Errorf("%v is below minimum %v", number, *p.Min)
+	}
+	if p.Max != nil && number > *p.Max {
+		return fmt.Errorf("%v is above maximum %v", number, *p.Max)
+	}
+	return nil
+}
+
+// describeValue 错误信息中描述参数值及其类型
+func describeValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return fmt.Sprintf("string %q", v)
+	}
+	return fmt.Sprintf("%T %v", value, value)
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
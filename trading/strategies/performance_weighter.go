@@ -0,0 +1,308 @@
+package strategies
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// PerformanceWeightingConfig 按近期表现自动调整策略权重的配置
+type PerformanceWeightingConfig struct {
+	Enabled   bool          `yaml:"enabled" json:"enabled"`       // 关闭时保持配置的固定权重
+	Lookback  time.Duration `yaml:"lookback" json:"lookback"`     // 统计近期交易的时间窗口
+	Interval  time.Duration `yaml:"interval" json:"interval"`     // 重新计算权重的间隔
+	MinWeight float64       `yaml:"min_weight" json:"min_weight"` // 权重下限
+	MaxWeight float64       `yaml:"max_weight" json:"max_weight"` // 权重上限
+	MinTrades int           `yaml:"min_trades" json:"min_trades"` // 参与评分所需的最少交易数
+}
+
+// DefaultPerformanceWeightingConfig 默认配置，默认关闭
+func DefaultPerformanceWeightingConfig() PerformanceWeightingConfig {
+	return PerformanceWeightingConfig{
+		Enabled:   false,
+		Lookback:  30 * 24 * time.Hour,
+		Interval:  24 * time.Hour,
+		MinWeight: 0.05,
+		MaxWeight: 0.6,
+		MinTrades: 3,
+	}
+}
+
+// Validate 校验配置
+func (c PerformanceWeightingConfig) Validate() error {
+	if c.MinWeight < 0 || c.MaxWeight > 1 || c.MinWeight > c.MaxWeight {
+		return fmt.Errorf("invalid weight bounds [%.2f, %.2f]", c.MinWeight, c.MaxWeight)
+	}
+	if c.Lookback <= 0 {
+		return fmt.Errorf("lookback must be positive")
+	}
+	return nil
+}
+
+// StrategyTrade 带策略标签的已平仓交易
+type StrategyTrade struct {
+	Strategy string    `json:"strategy"`
+	Return   float64   `json:"return"` // 交易收益率
+	Time     time.Time `json:"time"`
+}
+
+// PerformanceWeighter 根据各策略近期风险调整收益计算权重
+type PerformanceWeighter struct {
+	mu         sync.Mutex
+	config     PerformanceWeightingConfig
+	trades     map[string][]StrategyTrade
+	lastUpdate time.Time
+}
+
+// NewPerformanceWeighter 创建表现加权器
+func NewPerformanceWeighter(config PerformanceWeightingConfig) *PerformanceWeighter {
+	return &PerformanceWeighter{
+		config: config,
+		trades: make(map[string][]StrategyTrade),
+	}
+}
+
+// Config 获取当前配置
+func (p *PerformanceWeighter) Config() PerformanceWeightingConfig {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.config
+}
+
+// SetConfig 更新配置
+func (p *PerformanceWeighter) SetConfig(config PerformanceWeightingConfig) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.config = config
+}
+
+// RecordTrade 记录一笔带策略标签的交易，同时丢弃该策略回溯窗口外的交易
+func (p *PerformanceWeighter) RecordTrade(trade StrategyTrade) {
+	if trade.Strategy == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cutoff := trade.Time.Add(-p.config.Lookback)
+	recent := p.trades[trade.Strategy][:0]
+	for _, t := range p.trades[trade.Strategy] {
+		if !t.Time.Before(cutoff) {
+			recent = append(recent, t)
+		}
+	}
+	p.trades[trade.Strategy] = append(recent, trade)
+}
+
+// Due 检查是否到了重新计算权重的时间
+func (p *PerformanceWeighter) Due(now time.Time) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.config.Enabled && now.Sub(p.lastUpdate) >= p.config.Interval
+}
+
+// Scores 计算各策略在回溯窗口内的风险调整收益（收益均值/标准差），交易不足的策略不出现在结果中
+func (p *PerformanceWeighter) Scores(now time.Time) map[string]float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.scoresLocked(now)
+}
+
+// scoresLocked 计算评分并丢弃窗口外的交易，调用方需持有锁
+func (p *PerformanceWeighter) scoresLocked(now time.Time) map[string]float64 {
+	cutoff := now.Add(-p.config.Lookback)
+	minTrades := p.config.MinTrades
+	if minTrades < 2 {
+		minTrades = 2
+	}
+
+	scores := make(map[string]float64)
+	for name, trades := range p.trades {
+		recent := trades[:0]
+		for _, trade := range trades {
+			if !trade.Time.Before(cutoff) {
+				recent = append(recent, trade)
+			}
+		}
+		p.trades[name] = recent
+		if len(recent) < minTrades {
+			continue
+		}
+
+		var mean float64
+		for _, trade := range recent {
+			mean += trade.Return
+		}
+		mean /= float64(len(recent))
+
+		var variance float64
+		for _, trade := range recent {
+			variance += (trade.Return - mean) * (trade.Return - mean)
+		}
+		stdDev := math.Sqrt(variance / float64(len(recent)-1))
+
+		// 收益完全稳定时以收益方向计分
+		switch {
+		case stdDev > 0:
+			scores[name] = mean / stdDev
+		case mean > 0:
+			scores[name] = 1
+		default:
+			scores[name] = 0
+		}
+	}
+	return scores
+}
+
+// WinStats 策略在回溯窗口内的胜率、盈亏比（平均盈利/平均亏损）和交易数，交易数少于MinTrades时全部返回0。
+// 盈利和亏损交易不同时存在时盈亏比为0，由调用方使用默认值。
+func (p *PerformanceWeighter) WinStats(strategy string, now time.Time) (winRate, winLossRatio float64, trades int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	cutoff := now.Add(-p.config.Lookback)
+	var wins, losses int
+	var gain, loss float64
+	for _, trade := range p.trades[strategy] {
+		if trade.Time.Before(cutoff) {
+			continue
+		}
+		if trade.Return > 0 {
+			wins++
+			gain += trade.Return
+		} else {
+			losses++
+			loss -= trade.Return
+		}
+	}
+
+	trades = wins + losses
+	if trades == 0 || trades < p.config.MinTrades {
+		return 0, 0, 0
+	}
+	winRate = float64(wins) / float64(trades)
+	if wins > 0 && losses > 0 && loss > 0 {
+		winLossRatio = (gain / float64(wins)) / (loss / float64(losses))
+	}
+	return winRate, winLossRatio, trades
+}
+
+// Weights 按风险调整收益计算names中各策略的权重，归一化后限制在[MinWeight, MaxWeight]内。
+// 交易不足的策略按平均评分计算；所有评分都不为正时返回nil，保持现有权重。
+func (p *PerformanceWeighter) Weights(names []string, now time.Time) map[string]float64 {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(names) == 0 {
+		return nil
+	}
+
+	scores := p.scoresLocked(now)
+	p.lastUpdate = now
+
+	// 无评分的策略取已评分策略的平均值
+	var sum float64
+	var scored int
+	for _, name := range names {
+		if score, ok := scores[name]; ok {
+			sum += math.Max(score, 0)
+			scored++
+		}
+	}
+	if scored == 0 || sum <= 0 {
+		return nil
+	}
+	neutral := sum / float64(scored)
+
+	raw := make(map[string]float64, len(names))
+	var total float64
+	for _, name := range names {
+		score, ok := scores[name]
+		if !ok {
+			score = neutral
+		}
+		raw[name] = math.Max(score, 0)
+		total += raw[name]
+	}
+	for name := range raw {
+		raw[name] /= total
+	}
+
+	return boundWeights(raw, p.config.MinWeight, p.config.MaxWeight)
+}
+
+// boundWeights 将和为1的权重限制在[min, max]内，超出部分按比例分给未触及边界的策略，
+// 边界允许时结果之和为1
+func boundWeights(weights map[string]float64, min, max float64) map[string]float64 {
+	names := make([]string, 0, len(weights))
+	for name := range weights {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fixed := make(map[string]bool, len(names))
+	for range names {
+		var fixedSum, freeSum float64
+		for _, name := range names {
+			if fixed[name] {
+				fixedSum += weights[name]
+			} else {
+				freeSum += weights[name]
+			}
+		}
+
+		scale := 0.0
+		if freeSum > 0 {
+			scale = (1 - fixedSum) / freeSum
+		}
+
+		changed := false
+		for _, name := range names {
+			if fixed[name] {
+				continue
+			}
+			weights[name] *= scale
+			if weights[name] < min {
+				weights[name] = min
+				fixed[name] = true
+				changed = true
+			} else if weights[name] > max {
+				weights[name] = max
+				fixed[name] = true
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	// 全部触及边界后仍有剩余时（如多数策略评分为0），按各自可调整空间分摊
+	var sum float64
+	for _, name := range names {
+		sum += weights[name]
+	}
+	var room float64
+	for _, name := range names {
+		if sum < 1 {
+			room += max - weights[name]
+		} else {
+			room += weights[name] - min
+		}
+	}
+	if residual := 1 - sum; room > 0 && math.Abs(residual) > 1e-12 {
+		share := math.Min(math.Abs(residual)/room, 1)
+		for _, name := range names {
+			if residual > 0 {
+				weights[name] += share * (max - weights[name])
+			} else {
+				weights[name] -= share * (weights[name] - min)
+			}
+		}
+	}
+
+	return weights
+}
@@ -78,6 +78,14 @@ func (r *RSIStrategy) Init(ctx context.Context, symbol string, config map[string
 	return nil
 }
 
+// DataRequirements RSI策略需要周期+1根K线计算涨跌幅
+func (r *RSIStrategy) DataRequirements() DataRequirements {
+	return DataRequirements{
+		MinHistory: r.period + 1,
+		Timeframe:  DefaultTimeframe,
+	}
+}
+
 // GenerateSignal 生成交易信号
 func (r *RSIStrategy) GenerateSignal(ctx context.Context, marketData *MarketData) (*Signal, error) {
 	if marketData == nil {
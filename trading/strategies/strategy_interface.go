@@ -2,6 +2,8 @@ package strategies
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"cloudquant/trading"
@@ -41,8 +43,21 @@ type Strategy interface {
 
 	// SetEnabled 设置策略启用状态
 	SetEnabled(enabled bool)
+
+	// DataRequirements 声明策略所需的行情数据
+	DataRequirements() DataRequirements
+}
+
+// DataRequirements 策略数据需求
+type DataRequirements struct {
+	Symbols    []string `json:"symbols,omitempty"` // 除当前股票外还需要的股票
+	MinHistory int      `json:"min_history"`       // 生成信号所需的最少K线数
+	Timeframe  string   `json:"timeframe"`         // K线周期，如 1d
 }
 
+// DefaultTimeframe 默认K线周期
+const DefaultTimeframe = "1d"
+
 // Signal 交易信号结构
 type Signal struct {
 	Symbol      string                 `json:"symbol"`       // 股票代码
@@ -69,6 +84,7 @@ type MarketData struct {
 	PreClose      float64   `json:"pre_close"`
 	Change        float64   `json:"change"`
 	ChangePercent float64   `json:"change_percent"`
+	BarClosed     bool      `json:"bar_closed"` // K线是否已收盘，false表示盘中尚在形成的K线
 }
 
 // StrategyResult 策略执行结果
@@ -84,11 +100,13 @@ type StrategyResult struct {
 type StrategyType string
 
 const (
-	MA     StrategyType = "ma"     // 均线策略
-	RSI    StrategyType = "rsi"    // RSI策略
-	AI     StrategyType = "ai"     // AI策略
-	ML     StrategyType = "ml"     // 机器学习策略
-	CUSTOM StrategyType = "custom" // 自定义策略
+	MA        StrategyType = "ma"        // 均线策略
+	RSI       StrategyType = "rsi"       // RSI策略
+	AI        StrategyType = "ai"        // AI策略
+	ML        StrategyType = "ml"        // 机器学习策略
+	PAIRS     StrategyType = "pairs"     // 配对交易策略
+	BOLLINGER StrategyType = "bollinger" // 布林带策略
+	CUSTOM    StrategyType = "custom"    // 自定义策略
 )
 
 // BaseStrategy 策略基类，实现通用功能
@@ -161,6 +179,11 @@ func (b *BaseStrategy) Init(ctx context.Context, symbol string, config map[strin
 	return nil
 }
 
+// DataRequirements 基础数据需求：仅需当前K线
+func (b *BaseStrategy) DataRequirements() DataRequirements {
+	return DataRequirements{Timeframe: DefaultTimeframe}
+}
+
 // OnTrade 基础交易回调
 func (b *BaseStrategy) OnTrade(ctx context.Context, trade *trading.TradeRecord) error {
 	// 默认实现：记录交易日志
@@ -208,8 +231,61 @@ var (
 	ErrInvalidSignalType = NewStrategyError("invalid signal type")
 	ErrInvalidStrength   = NewStrategyError("invalid strength")
 	ErrInvalidPrice      = NewStrategyError("invalid price")
+	ErrInsufficientData  = NewStrategyError("insufficient market data")
 )
 
+// MergeDataRequirements 合并多个策略的数据需求：取最长历史，合并所需股票
+func MergeDataRequirements(reqs ...DataRequirements) DataRequirements {
+	merged := DataRequirements{Timeframe: DefaultTimeframe}
+	seen := make(map[string]bool)
+
+	for _, req := range reqs {
+		if req.MinHistory > merged.MinHistory {
+			merged.MinHistory = req.MinHistory
+		}
+		if req.Timeframe != "" {
+			merged.Timeframe = req.Timeframe
+		}
+		for _, symbol := range req.Symbols {
+			if !seen[symbol] {
+				seen[symbol] = true
+				merged.Symbols = append(merged.Symbols, symbol)
+			}
+		}
+	}
+
+	sort.Strings(merged.Symbols)
+	return merged
+}
+
+// CheckDataReady 检查可用K线数是否满足策略的数据需求
+func CheckDataReady(strategy Strategy, availableBars int) error {
+	req := strategy.DataRequirements()
+	if availableBars < req.MinHistory {
+		return fmt.Errorf("%w: strategy %s needs %d bars, got %d",
+			ErrInsufficientData, strategy.GetName(), req.MinHistory, availableBars)
+	}
+	return nil
+}
+
+// WarmUp 用历史K线预热策略，只使用策略声明需要的最近MinHistory根K线，预热期间的信号被丢弃
+func WarmUp(ctx context.Context, strategy Strategy, history []*MarketData) error {
+	req := strategy.DataRequirements()
+	if req.MinHistory <= 0 || len(history) == 0 {
+		return nil
+	}
+	if len(history) > req.MinHistory {
+		history = history[len(history)-req.MinHistory:]
+	}
+
+	for _, data := range history {
+		if _, err := strategy.GenerateSignal(ctx, data); err != nil {
+			return fmt.Errorf("warm up strategy %s: %w", strategy.GetName(), err)
+		}
+	}
+	return nil
+}
+
 // StrategyError 策略错误
 type StrategyError struct {
 	message string
@@ -2,26 +2,33 @@ package strategies
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"sync"
 
 	"gopkg.in/yaml.v2"
 )
 
 // StrategyConfig 策略配置
 type StrategyConfig struct {
-	Name       string                 `yaml:"name"`       // 策略名称
-	Type       StrategyType           `yaml:"type"`       // 策略类型
-	Enabled    bool                   `yaml:"enabled"`    // 是否启用
-	Weight     float64                `yaml:"weight"`     // 策略权重
-	Parameters map[string]interface{} `yaml:"parameters"` // 策略参数
-	Priority   int                    `yaml:"priority"`   // 优先级
+	Name       string                 `yaml:"name" json:"name"`             // 策略名称
+	Type       StrategyType           `yaml:"type" json:"type"`             // 策略类型
+	Enabled    bool                   `yaml:"enabled" json:"enabled"`       // 是否启用
+	Weight     float64                `yaml:"weight" json:"weight"`         // 策略权重
+	Parameters map[string]interface{} `yaml:"parameters" json:"parameters"` // 策略参数
+	Priority   int                    `yaml:"priority" json:"priority"`     // 优先级
+	CloseOnly  bool                   `yaml:"close_only" json:"close_only"` // 仅在K线收盘后执行，避免盘中信号重绘
 }
 
 // StrategyLoader 策略加载器
 type StrategyLoader struct {
+	mu         sync.RWMutex
 	strategies map[string]Strategy              // 已注册的策略
 	factories  map[StrategyType]StrategyFactory // 策略工厂
+	closeOnly  map[string]bool                  // 仅在收盘K线上执行的策略
+	types      map[string]StrategyType          // 已加载策略的类型，热更新时判断能否原地更新
+	schemas    map[StrategyType]ParameterSchema // 各策略类型的参数约束
 }
 
 // StrategyFactory 策略工厂接口
@@ -32,6 +39,12 @@ func NewStrategyLoader() *StrategyLoader {
 	loader := &StrategyLoader{
 		strategies: make(map[string]Strategy),
 		factories:  make(map[StrategyType]StrategyFactory),
+		closeOnly:  make(map[string]bool),
+		types:      make(map[string]StrategyType),
+		schemas:    make(map[StrategyType]ParameterSchema),
+	}
+	for strategyType, schema := range builtinSchemas {
+		loader.schemas[strategyType] = schema
 	}
 
 	// 注册内置策略工厂
@@ -39,48 +52,161 @@ func NewStrategyLoader() *StrategyLoader {
 	loader.RegisterFactory(RSIStrategyType, NewRSIStrategy)
 	loader.RegisterFactory(AIStrategyType, NewAIStrategy)
 	loader.RegisterFactory(MLStrategyType, NewMLStrategy)
+	loader.RegisterFactory(PairsStrategyType, NewPairsStrategy)
+	loader.RegisterFactory(BollingerStrategyType, NewBollingerStrategy)
 
 	return loader
 }
 
 // RegisterFactory 注册策略工厂
 func (l *StrategyLoader) RegisterFactory(strategyType StrategyType, factory StrategyFactory) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	l.factories[strategyType] = factory
 	log.Printf("Registered strategy factory: %s", strategyType)
 }
 
-// LoadStrategies 从配置加载策略
+// RegisterSchema 注册策略类型的参数约束，未注册约束的类型不校验参数
+func (l *StrategyLoader) RegisterSchema(strategyType StrategyType, schema ParameterSchema) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.schemas[strategyType] = schema
+}
+
+// LoadStrategies 从配置加载策略，加载前按参数约束校验每个配置。不合法的配置（包括已禁用的）
+// 记录日志后跳过，其余策略照常加载；返回所有被跳过配置的错误
 func (l *StrategyLoader) LoadStrategies(configs []StrategyConfig) error {
-	for _, config := range configs {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	configs = append([]StrategyConfig(nil), configs...)
+	var errs []error
+	for i := range configs {
+		config := &configs[i]
+		if err := l.validateParameters(config); err != nil {
+			log.Printf("Skipping strategy %s with invalid config: %v", config.Name, err)
+			errs = append(errs, err)
+			continue
+		}
+
 		if !config.Enabled {
 			log.Printf("Strategy %s is disabled, skipping", config.Name)
 			continue
 		}
 
-		strategy, err := l.CreateStrategy(config)
+		strategy, err := l.initStrategy(*config)
 		if err != nil {
-			log.Printf("Failed to create strategy %s: %v", config.Name, err)
+			log.Printf("Failed to load strategy %s: %v", config.Name, err)
 			continue
 		}
 
-		// 初始化策略
-		ctx := context.Background()
-		if err := strategy.Init(ctx, "", config.Parameters); err != nil {
-			log.Printf("Failed to initialize strategy %s: %v", config.Name, err)
+		l.strategies[config.Name] = strategy
+		l.closeOnly[config.Name] = config.CloseOnly
+		l.types[config.Name] = config.Type
+		log.Printf("Successfully loaded strategy: %s (type: %s, weight: %.2f, close_only: %v)",
+			config.Name, config.Type, config.Weight, config.CloseOnly)
+	}
+
+	log.Printf("Loaded %d strategies", len(l.strategies))
+	return errors.Join(errs...)
+}
+
+// ReloadStrategies 按新配置热更新策略，无需重启进程：移除配置中已删除的策略，创建新增的策略，
+// 类型未变的策略原地更新权重、启用状态和参数以保留其内部缓存，类型变化的策略重新创建。
+// 需要新建的策略全部创建并初始化成功后才修改已加载的策略，任一失败时保持原状并返回错误。
+func (l *StrategyLoader) ReloadStrategies(configs []StrategyConfig) error {
+	configs = append([]StrategyConfig(nil), configs...)
+	names := make(map[string]bool, len(configs))
+	for i := range configs {
+		if err := l.ValidateConfig(&configs[i]); err != nil {
+			return fmt.Errorf("invalid config at index %d: %v", i, err)
+		}
+		if names[configs[i].Name] {
+			return fmt.Errorf("duplicate strategy name %s", configs[i].Name)
+		}
+		names[configs[i].Name] = true
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	// 先创建新增和类型变化的策略
+	created := make(map[string]Strategy)
+	for _, config := range configs {
+		if l.canUpdateInPlace(config) || !config.Enabled {
 			continue
 		}
+		strategy, err := l.initStrategy(config)
+		if err != nil {
+			return fmt.Errorf("failed to reload strategy %s: %v", config.Name, err)
+		}
+		created[config.Name] = strategy
+	}
 
-		l.strategies[config.Name] = strategy
-		log.Printf("Successfully loaded strategy: %s (type: %s, weight: %.2f)",
-			config.Name, config.Type, config.Weight)
+	var added, updated, removed int
+	for name := range l.strategies {
+		if !names[name] {
+			l.removeStrategy(name)
+			removed++
+		}
 	}
 
-	log.Printf("Loaded %d strategies", len(l.strategies))
-	return nil
+	var errs []error
+	for _, config := range configs {
+		switch strategy, isNew := created[config.Name]; {
+		case l.canUpdateInPlace(config):
+			if err := l.updateStrategy(config.Name, config); err != nil {
+				errs = append(errs, fmt.Errorf("strategy %s: %v", config.Name, err))
+				continue
+			}
+			updated++
+		case isNew:
+			l.strategies[config.Name] = strategy
+			l.closeOnly[config.Name] = config.CloseOnly
+			l.types[config.Name] = config.Type
+			added++
+		default:
+			// 类型变化且已禁用的策略不再保留旧实例
+			if _, exists := l.strategies[config.Name]; exists {
+				l.removeStrategy(config.Name)
+				removed++
+			}
+		}
+	}
+
+	log.Printf("Reloaded strategies: %d added, %d updated, %d removed", added, updated, removed)
+	return errors.Join(errs...)
+}
+
+// canUpdateInPlace 策略已加载且类型未变，可以原地更新
+func (l *StrategyLoader) canUpdateInPlace(config StrategyConfig) bool {
+	_, exists := l.strategies[config.Name]
+	return exists && l.types[config.Name] == config.Type
+}
+
+// initStrategy 创建并初始化策略，调用方需持有写锁
+func (l *StrategyLoader) initStrategy(config StrategyConfig) (Strategy, error) {
+	strategy, err := l.createStrategy(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := strategy.Init(context.Background(), "", config.Parameters); err != nil {
+		return nil, fmt.Errorf("failed to initialize: %v", err)
+	}
+	return strategy, nil
 }
 
 // CreateStrategy 根据配置创建策略
 func (l *StrategyLoader) CreateStrategy(config StrategyConfig) (Strategy, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.createStrategy(config)
+}
+
+func (l *StrategyLoader) createStrategy(config StrategyConfig) (Strategy, error) {
 	factory, exists := l.factories[config.Type]
 	if !exists {
 		return nil, fmt.Errorf("strategy type %s not registered", config.Type)
@@ -107,12 +233,18 @@ func (l *StrategyLoader) CreateStrategy(config StrategyConfig) (Strategy, error)
 
 // GetStrategy 获取策略
 func (l *StrategyLoader) GetStrategy(name string) (Strategy, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	strategy, exists := l.strategies[name]
 	return strategy, exists
 }
 
 // GetAllStrategies 获取所有策略
 func (l *StrategyLoader) GetAllStrategies() map[string]Strategy {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	result := make(map[string]Strategy)
 	for name, strategy := range l.strategies {
 		result[name] = strategy
@@ -122,6 +254,9 @@ func (l *StrategyLoader) GetAllStrategies() map[string]Strategy {
 
 // GetEnabledStrategies 获取所有启用的策略
 func (l *StrategyLoader) GetEnabledStrategies() map[string]Strategy {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	result := make(map[string]Strategy)
 	for name, strategy := range l.strategies {
 		if strategy.IsEnabled() {
@@ -131,8 +266,46 @@ func (l *StrategyLoader) GetEnabledStrategies() map[string]Strategy {
 	return result
 }
 
+// NewEnabledInstances 按已加载策略的类型、权重和参数为每个启用的策略创建并初始化全新实例，
+// 新实例不共享已加载策略的价格缓存等运行状态，可用于不影响实盘策略的试算
+func (l *StrategyLoader) NewEnabledInstances() (map[string]Strategy, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := make(map[string]Strategy)
+	for name, strategy := range l.strategies {
+		if !strategy.IsEnabled() {
+			continue
+		}
+
+		params := make(map[string]interface{})
+		for k, v := range strategy.GetParameters() {
+			params[k] = v
+		}
+		instance, err := l.initStrategy(StrategyConfig{
+			Name:       name,
+			Type:       l.types[name],
+			Enabled:    true,
+			Weight:     strategy.GetWeight(),
+			Parameters: params,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("strategy %s: %v", name, err)
+		}
+		result[name] = instance
+	}
+	return result, nil
+}
+
 // UpdateStrategy 更新策略配置
 func (l *StrategyLoader) UpdateStrategy(name string, config StrategyConfig) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.updateStrategy(name, config)
+}
+
+func (l *StrategyLoader) updateStrategy(name string, config StrategyConfig) error {
 	strategy, exists := l.strategies[name]
 	if !exists {
 		return fmt.Errorf("strategy %s not found", name)
@@ -144,6 +317,9 @@ func (l *StrategyLoader) UpdateStrategy(name string, config StrategyConfig) erro
 	// 更新启用状态
 	strategy.SetEnabled(config.Enabled)
 
+	// 更新执行时机
+	l.closeOnly[name] = config.CloseOnly
+
 	// 更新参数
 	if err := strategy.UpdateParameters(config.Parameters); err != nil {
 		return fmt.Errorf("failed to update parameters: %v", err)
@@ -155,15 +331,44 @@ func (l *StrategyLoader) UpdateStrategy(name string, config StrategyConfig) erro
 	return nil
 }
 
+// SetCloseOnly 设置策略是否仅在收盘K线上执行
+func (l *StrategyLoader) SetCloseOnly(name string, closeOnly bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, exists := l.strategies[name]; !exists {
+		return fmt.Errorf("strategy %s not found", name)
+	}
+	l.closeOnly[name] = closeOnly
+	return nil
+}
+
+// IsCloseOnly 检查策略是否仅在收盘K线上执行
+func (l *StrategyLoader) IsCloseOnly(name string) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	return l.closeOnly[name]
+}
+
 // RemoveStrategy 移除策略
 func (l *StrategyLoader) RemoveStrategy(name string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
 	if _, exists := l.strategies[name]; !exists {
 		return fmt.Errorf("strategy %s not found", name)
 	}
 
+	l.removeStrategy(name)
+	return nil
+}
+
+func (l *StrategyLoader) removeStrategy(name string) {
 	delete(l.strategies, name)
+	delete(l.closeOnly, name)
+	delete(l.types, name)
 	log.Printf("Removed strategy: %s", name)
-	return nil
 }
 
 // ValidateConfig 验证策略配置
@@ -181,10 +386,26 @@ func (l *StrategyLoader) ValidateConfig(config *StrategyConfig) error {
 	}
 
 	// 检查策略类型是否已注册
+	l.mu.RLock()
+	defer l.mu.RUnlock()
 	if _, exists := l.factories[config.Type]; !exists {
 		return fmt.Errorf("strategy type %s is not registered", config.Type)
 	}
 
+	return l.validateParameters(config)
+}
+
+// validateParameters 按策略类型的参数约束校验参数，并将参数替换为统一类型后的副本，调用方需持有锁
+func (l *StrategyLoader) validateParameters(config *StrategyConfig) error {
+	schema, exists := l.schemas[config.Type]
+	if !exists || len(config.Parameters) == 0 {
+		return nil
+	}
+	params, err := schema.Validate(config.Parameters)
+	if err != nil {
+		return fmt.Errorf("strategy %s (type %s) has invalid parameters: %v", config.Name, config.Type, err)
+	}
+	config.Parameters = params
 	return nil
 }
 
@@ -201,8 +422,8 @@ func (l *StrategyLoader) LoadFromYAML(filename string) ([]StrategyConfig, error)
 	}
 
 	// 验证所有配置
-	for i, config := range configs {
-		if err := l.ValidateConfig(&config); err != nil {
+	for i := range configs {
+		if err := l.ValidateConfig(&configs[i]); err != nil {
 			return nil, fmt.Errorf("invalid config at index %d: %v", i, err)
 		}
 	}
@@ -227,6 +448,9 @@ func (l *StrategyLoader) SaveToYAML(filename string, configs []StrategyConfig) e
 
 // GetStrategyNames 获取所有策略名称
 func (l *StrategyLoader) GetStrategyNames() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	names := make([]string, 0, len(l.strategies))
 	for name := range l.strategies {
 		names = append(names, name)
@@ -236,11 +460,17 @@ func (l *StrategyLoader) GetStrategyNames() []string {
 
 // GetStrategyCount 获取策略数量
 func (l *StrategyLoader) GetStrategyCount() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	return len(l.strategies)
 }
 
 // GetEnabledStrategyCount 获取启用策略数量
 func (l *StrategyLoader) GetEnabledStrategyCount() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	count := 0
 	for _, strategy := range l.strategies {
 		if strategy.IsEnabled() {
@@ -256,6 +486,7 @@ type StrategySummary struct {
 	Type        StrategyType           `json:"type"`
 	Enabled     bool                   `json:"enabled"`
 	Weight      float64                `json:"weight"`
+	CloseOnly   bool                   `json:"close_only"`
 	Parameters  map[string]interface{} `json:"parameters"`
 	CreatedAt   string                 `json:"created_at"`
 	Description string                 `json:"description"`
@@ -263,6 +494,9 @@ type StrategySummary struct {
 
 // GetStrategySummary 获取策略摘要
 func (l *StrategyLoader) GetStrategySummary(name string) (*StrategySummary, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	strategy, exists := l.strategies[name]
 	if !exists {
 		return nil, fmt.Errorf("strategy %s not found", name)
@@ -272,6 +506,7 @@ func (l *StrategyLoader) GetStrategySummary(name string) (*StrategySummary, erro
 		Name:       strategy.GetName(),
 		Enabled:    strategy.IsEnabled(),
 		Weight:     strategy.GetWeight(),
+		CloseOnly:  l.closeOnly[name],
 		Parameters: strategy.GetParameters(),
 		CreatedAt:  "unknown", // 可以从元数据中获取
 	}
@@ -281,6 +516,9 @@ func (l *StrategyLoader) GetStrategySummary(name string) (*StrategySummary, erro
 
 // GetAllStrategySummaries 获取所有策略摘要
 func (l *StrategyLoader) GetAllStrategySummaries() []StrategySummary {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
 	summaries := make([]StrategySummary, 0, len(l.strategies))
 
 	for _, strategy := range l.strategies {
@@ -0,0 +1,145 @@
+package strategies
+
+import (
+	"context"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestReloadStrategiesKeepsRunningStrategies(t *testing.T) {
+	loader := NewStrategyLoader()
+	loader.RegisterFactory("counting", func() Strategy {
+		return &countingStrategy{BaseStrategy: NewBaseStrategy("counting", 0.5)}
+	})
+	if err := loader.LoadStrategies([]StrategyConfig{
+		{Name: "keep", Type: "counting", Enabled: true, Weight: 0.5, Parameters: map[string]interface{}{"period": 5}},
+		{Name: "drop", Type: "counting", Enabled: true, Weight: 0.5},
+	}); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+
+	manager := NewStrategyManager(loader, WeightedCombination)
+	bar := &MarketData{Symbol: "sh600000", Close: 10, Timestamp: time.Now(), BarClosed: true}
+	if _, err := manager.ExecuteStrategies(context.Background(), bar); err != nil {
+		t.Fatalf("ExecuteStrategies: %v", err)
+	}
+	kept, _ := loader.GetStrategy("keep")
+
+	err := manager.ReloadStrategies([]StrategyConfig{
+		{Name: "keep", Type: "counting", Enabled: true, Weight: 0.8, CloseOnly: true, Parameters: map[string]interface{}{"period": 10}},
+		{Name: "new", Type: "counting", Enabled: true, Weight: 0.2},
+	})
+	if err != nil {
+		t.Fatalf("ReloadStrategies: %v", err)
+	}
+
+	strategy, ok := loader.GetStrategy("keep")
+	if !ok || strategy != kept {
+		t.Fatal("updated strategy was recreated instead of updated in place")
+	}
+	if n := atomic.LoadInt32(&kept.(*countingStrategy).calls); n != 1 {
+		t.Errorf("kept strategy state lost: calls = %d, want 1", n)
+	}
+	if strategy.GetWeight() != 0.8 || strategy.GetParameters()["period"] != 10 || !loader.IsCloseOnly("keep") {
+		t.Errorf("keep not updated: weight %.2f params %v close_only %v",
+			strategy.GetWeight(), strategy.GetParameters(), loader.IsCloseOnly("keep"))
+	}
+	if _, ok := loader.GetStrategy("drop"); ok {
+		t.Error("strategy removed from config is still loaded")
+	}
+	if _, ok := loader.GetStrategy("new"); !ok {
+		t.Error("strategy added to config was not loaded")
+	}
+}
+
+func TestReloadStrategiesRejectsInvalidConfigWithoutChanges(t *testing.T) {
+	loader := NewStrategyLoader()
+	loader.RegisterFactory("counting", func() Strategy {
+		return &countingStrategy{BaseStrategy: NewBaseStrategy("counting", 0.5)}
+	})
+	if err := loader.LoadStrategies([]StrategyConfig{
+		{Name: "keep", Type: "counting", Enabled: true, Weight: 0.5},
+	}); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+
+	cases := map[string][]StrategyConfig{
+		"unknown type": {
+			{Name: "keep", Type: "counting", Enabled: true, Weight: 0.9},
+			{Name: "bad", Type: "missing", Enabled: true, Weight: 0.1},
+		},
+		"duplicate name": {
+			{Name: "keep", Type: "counting", Enabled: true, Weight: 0.9},
+			{Name: "keep", Type: "counting", Enabled: true, Weight: 0.1},
+		},
+		"init failure": {
+			{Name: "keep", Type: "counting", Enabled: true, Weight: 0.9},
+			{Name: "ma", Type: MAStrategyType, Enabled: true, Weight: 0.1, Parameters: map[string]interface{}{"short_period": 30, "long_period": 10}},
+		},
+	}
+	for name, configs := range cases {
+		if err := loader.ReloadStrategies(configs); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		strategy, ok := loader.GetStrategy("keep")
+		if !ok || strategy.GetWeight() != 0.5 || loader.GetStrategyCount() != 1 {
+			t.Errorf("%s: loaded strategies changed by rejected reload", name)
+		}
+	}
+}
+
+func TestLoadStrategiesRejectsMalformedParameters(t *testing.T) {
+	loader := NewStrategyLoader()
+	err := loader.LoadStrategies([]StrategyConfig{
+		{Name: "ma_ok", Type: MAStrategyType, Enabled: true, Weight: 0.3, Parameters: map[string]interface{}{
+			"short_period": 5, "long_period": 20,
+		}},
+		{Name: "ai_bad", Type: AIStrategyType, Enabled: true, Weight: 0.3, Parameters: map[string]interface{}{
+			"confidence": "high", "threshold": 1.5, "risk_analysys": true,
+		}},
+		{Name: "boll_bad", Type: BollingerStrategyType, Enabled: false, Weight: 0.3, Parameters: map[string]interface{}{
+			"period": 20.5, "mode": "momentum",
+		}},
+	})
+	if err == nil {
+		t.Fatal("expected error for malformed parameters")
+	}
+	for _, want := range []string{
+		`ai_bad`, `confidence: expected number, got string "high"`, `threshold: 1.5 is above maximum 1`,
+		`risk_analysys: unknown parameter`, `boll_bad`, `period: expected integer`, `mode: must be one of`,
+	} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if strings.Contains(err.Error(), "ma_ok") {
+		t.Errorf("valid strategy reported as invalid: %v", err)
+	}
+	// 不合法的配置被跳过，不影响其他策略加载
+	if _, ok := loader.GetStrategy("ma_ok"); !ok || loader.GetStrategyCount() != 1 {
+		t.Errorf("loaded %d strategies, want only ma_ok", loader.GetStrategyCount())
+	}
+}
+
+func TestLoadStrategiesNormalizesParameterTypes(t *testing.T) {
+	loader := NewStrategyLoader()
+	// YAML中整数写法的浮点参数和JSON中浮点写法的整数参数都应按约束类型生效
+	if err := loader.LoadStrategies([]StrategyConfig{
+		{Name: "rsi", Type: RSIStrategyType, Enabled: true, Weight: 0.3, Parameters: map[string]interface{}{
+			"period": 10.0, "oversold": 25, "overbought": 75,
+		}},
+	}); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+
+	strategy, ok := loader.GetStrategy("rsi")
+	if !ok {
+		t.Fatal("rsi strategy not loaded")
+	}
+	rsi := strategy.(*RSIStrategy)
+	if rsi.period != 10 || rsi.oversold != 25 || rsi.overbought != 75 {
+		t.Errorf("rsi parameters period=%d oversold=%.0f overbought=%.0f, want 10/25/75", rsi.period, rsi.oversold, rsi.overbought)
+	}
+}
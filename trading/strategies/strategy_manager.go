@@ -2,6 +2,7 @@ package strategies
 
 import (
     "context"
+    "errors"
     "fmt"
     "log"
     "sort"
@@ -18,8 +19,22 @@ const (
     VoteCombination     SignalCombination = "vote"     // 投票法
     WeightedCombination SignalCombination = "weighted" // 加权法
     PriorityCombination SignalCombination = "priority" // 优先级法
+    // ConsensusCombination 共识法：同向信号占启用策略的比例达到阈值才发出信号
+    ConsensusCombination SignalCombination = "consensus"
 )
 
+// Valid 判断是否为支持的信号组合方法
+func (c SignalCombination) Valid() bool {
+    switch c {
+    case VoteCombination, WeightedCombination, PriorityCombination, ConsensusCombination:
+        return true
+    }
+    return false
+}
+
+// DefaultConsensusThreshold 共识法默认要求的同向策略比例
+const DefaultConsensusThreshold = 0.6
+
 // StrategyManager 策略管理器
 type StrategyManager struct {
     loader          *StrategyLoader
@@ -30,7 +45,15 @@ type StrategyManager struct {
     orderExecutor   *trading.OrderExecutor
     signalHandler   *trading.SignalHandler
     lastExecution   time.Time
+    lastResult      *StrategyExecutionResult
     executionCount  int64
+    latency         *LatencyTracker
+    weighter        *PerformanceWeighter
+    consensus       float64 // 共识法要求的同向策略比例
+    execution       ExecutionConfig
+    lastOrders      []CycleOrder            // 最近一次处理信号生成的委托
+    orderStrategies map[string]string       // 策略信号提交的委托ID -> 策略名
+    lots            map[string]*strategyLot // 按股票记录策略开仓的持仓，卖出成交时计算该策略的交易收益
 }
 
 // NewStrategyManager 创建策略管理器
@@ -41,7 +64,91 @@ func NewStrategyManager(
     return &StrategyManager{
         loader:      loader,
         combination: combination,
+        latency:     NewLatencyTracker(DefaultLatencyConfig()),
+        weighter:    NewPerformanceWeighter(DefaultPerformanceWeightingConfig()),
+        consensus:   DefaultConsensusThreshold,
+        execution:   DefaultExecutionConfig(),
+
+        orderStrategies: make(map[string]string),
+        lots:            make(map[string]*strategyLot),
+    }
+}
+
+// SetConsensusThreshold 设置共识法要求的同向策略比例，取值(0, 1]
+func (m *StrategyManager) SetConsensusThreshold(fraction float64) error {
+    if fraction <= 0 || fraction > 1 {
+        return fmt.Errorf("consensus threshold must be in (0, 1], got %.2f", fraction)
+    }
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    m.consensus = fraction
+    return nil
+}
+
+// SetLatencyConfig 设置策略延迟监控配置
+func (m *StrategyManager) SetLatencyConfig(config LatencyConfig) {
+    m.latency.SetConfig(config)
+}
+
+// SetLatencyAlertCallback 设置慢策略告警回调
+func (m *StrategyManager) SetLatencyAlertCallback(callback func(SlowStrategyAlert)) {
+    m.latency.SetAlertCallback(callback)
+}
+
+// GetLatencyStats 获取各策略的滚动延迟统计
+func (m *StrategyManager) GetLatencyStats() []StrategyLatency {
+    return m.latency.GetLatencies()
+}
+
+// GetLatencyThreshold 获取慢策略告警阈值
+func (m *StrategyManager) GetLatencyThreshold() time.Duration {
+    return m.latency.Threshold()
+}
+
+// SetPerformanceWeighting 设置按近期表现自动调整权重的配置，关闭时保持固定权重
+func (m *StrategyManager) SetPerformanceWeighting(config PerformanceWeightingConfig) error {
+    if config.Enabled {
+        if err := config.Validate(); err != nil {
+            return err
+        }
     }
+    m.weighter.SetConfig(config)
+    return nil
+}
+
+// RecordStrategyTrade 记录一笔带策略标签的交易，用于表现加权
+func (m *StrategyManager) RecordStrategyTrade(trade StrategyTrade) {
+    m.weighter.RecordTrade(trade)
+}
+
+// RecomputeWeights 立即按近期表现重新计算启用策略的权重，返回新权重；
+// 未启用表现加权或没有可用的表现数据时返回nil
+func (m *StrategyManager) RecomputeWeights(now time.Time) map[string]float64 {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+    return m.recomputeWeights(now)
+}
+
+// recomputeWeights 重新计算并应用权重，调用方需持有锁
+func (m *StrategyManager) recomputeWeights(now time.Time) map[string]float64 {
+    if !m.weighter.Config().Enabled {
+        return nil
+    }
+
+    enabledStrategies := m.loader.GetEnabledStrategies()
+    names := make([]string, 0, len(enabledStrategies))
+    for name := range enabledStrategies {
+        names = append(names, name)
+    }
+
+    weights := m.weighter.Weights(names, now)
+    for name, weight := range weights {
+        enabledStrategies[name].SetWeight(weight)
+    }
+    if len(weights) > 0 {
+        log.Printf("Strategy weights recomputed from recent performance: %v", weights)
+    }
+    return weights
 }
 
 // SetTradingComponents 设置交易组件
@@ -60,15 +167,141 @@ func (m *StrategyManager) SetTradingComponents(
     m.signalHandler = signalHandler
 }
 
+// ReloadStrategies 热更新策略配置，等待正在进行的策略执行结束后再修改，见StrategyLoader.ReloadStrategies
+func (m *StrategyManager) ReloadStrategies(configs []StrategyConfig) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    return m.loader.ReloadStrategies(configs)
+}
+
+// DataRequirements 获取所有启用策略合并后的数据需求
+func (m *StrategyManager) DataRequirements() DataRequirements {
+    enabledStrategies := m.loader.GetEnabledStrategies()
+
+    reqs := make([]DataRequirements, 0, len(enabledStrategies))
+    for _, strategy := range enabledStrategies {
+        reqs = append(reqs, strategy.DataRequirements())
+    }
+    return MergeDataRequirements(reqs...)
+}
+
+// WarmUp 用历史K线预热所有启用的策略
+func (m *StrategyManager) WarmUp(ctx context.Context, history []*MarketData) error {
+    m.mu.Lock()
+    defer m.mu.Unlock()
+
+    var errs []error
+    for _, strategy := range m.loader.GetEnabledStrategies() {
+        if err := WarmUp(ctx, strategy, history); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    return errors.Join(errs...)
+}
+
+// CheckReadiness 检查可用历史K线数是否满足所有启用策略的数据需求
+func (m *StrategyManager) CheckReadiness(availableBars int) error {
+    var errs []error
+    for _, strategy := range m.loader.GetEnabledStrategies() {
+        if err := CheckDataReady(strategy, availableBars); err != nil {
+            errs = append(errs, err)
+        }
+    }
+    return errors.Join(errs...)
+}
+
 // ExecuteStrategies 执行所有策略
 func (m *StrategyManager) ExecuteStrategies(ctx context.Context, marketData *MarketData) (*StrategyExecutionResult, error) {
     m.mu.Lock()
     defer m.mu.Unlock()
 
+    result, err := m.executeStrategies(ctx, marketData)
+    m.lastResult = result
+    return result, err
+}
+
+// EvaluateStrategies 试算：用已启用策略的全新实例执行一次并返回合并后的信号。
+// 新实例先用history预热，不修改实盘策略的内部状态、执行统计、延迟记录和最近一次执行结果
+func (m *StrategyManager) EvaluateStrategies(
+    ctx context.Context,
+    history []*MarketData,
+    marketData *MarketData,
+) (*StrategyExecutionResult, error) {
+    startTime := time.Now()
+
+    instances, err := m.loader.NewEnabledInstances()
+    if err != nil {
+        return nil, err
+    }
+    if len(instances) == 0 {
+        return &StrategyExecutionResult{
+            Timestamp: startTime,
+            Error:     fmt.Errorf("no enabled strategies"),
+        }, nil
+    }
+
+    skipped := 0
+    if !marketData.BarClosed {
+        for name := range instances {
+            if m.loader.IsCloseOnly(name) {
+                delete(instances, name)
+                skipped++
+            }
+        }
+    }
+
+    signals := make(chan *Signal, len(instances))
+    var errs []error
+    for name, strategy := range instances {
+        if err := WarmUp(ctx, strategy, history); err != nil {
+            errs = append(errs, err)
+            continue
+        }
+        result, err := m.executeSingleStrategy(ctx, strategy, marketData)
+        if err != nil {
+            errs = append(errs, fmt.Errorf("strategy %s failed: %v", name, err))
+            continue
+        }
+        for _, signal := range result.Signals {
+            signals <- signal
+        }
+    }
+    close(signals)
+
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    combinedSignals, err := m.combineSignals(signals, marketData, len(instances))
+    return &StrategyExecutionResult{
+        Timestamp:     startTime,
+        Duration:      time.Since(startTime).Milliseconds(),
+        Signals:       combinedSignals,
+        StrategyCount: len(instances),
+        SkippedCount:  skipped,
+        Errors:        errs,
+        Error:         err,
+    }, err
+}
+
+// GetLastResult 获取最近一次策略执行结果，尚未执行时返回nil
+func (m *StrategyManager) GetLastResult() *StrategyExecutionResult {
+    m.mu.RLock()
+    defer m.mu.RUnlock()
+
+    return m.lastResult
+}
+
+func (m *StrategyManager) executeStrategies(ctx context.Context, marketData *MarketData) (*StrategyExecutionResult, error) {
     startTime := time.Now()
     m.executionCount++
     m.lastExecution = startTime
 
+    // 按配置周期根据近期表现调整权重
+    if m.weighter.Due(startTime) {
+        m.recomputeWeights(startTime)
+    }
+
     // 获取所有启用的策略
     enabledStrategies := m.loader.GetEnabledStrategies()
     if len(enabledStrategies) == 0 {
@@ -78,6 +311,17 @@ func (m *StrategyManager) ExecuteStrategies(ctx context.Context, marketData *Mar
         }, nil
     }
 
+    // 盘中K线跳过仅收盘执行的策略，避免信号重绘
+    skipped := 0
+    if !marketData.BarClosed {
+        for name := range enabledStrategies {
+            if m.loader.IsCloseOnly(name) {
+                delete(enabledStrategies, name)
+                skipped++
+            }
+        }
+    }
+
     // 并行执行所有策略
     signals := make(chan *Signal, len(enabledStrategies))
     errCh := make(chan error, len(enabledStrategies))
@@ -89,7 +333,9 @@ func (m *StrategyManager) ExecuteStrategies(ctx context.Context, marketData *Mar
             defer wg.Done()
 
             // 执行策略生成信号
+            strategyStart := time.Now()
             result, err := m.executeSingleStrategy(ctx, strategy, marketData)
+            m.latency.Record(name, time.Since(strategyStart))
             if err != nil {
                 errCh <- fmt.Errorf("strategy %s failed: %v", name, err)
                 return
@@ -114,7 +360,7 @@ func (m *StrategyManager) ExecuteStrategies(ctx context.Context, marketData *Mar
     }
 
     // 合并信号
-    combinedSignals, err := m.combineSignals(signals, marketData)
+    combinedSignals, err := m.combineSignals(signals, marketData, len(enabledStrategies))
     if err != nil {
         return &StrategyExecutionResult{
             Timestamp: startTime,
@@ -129,6 +375,7 @@ func (m *StrategyManager) ExecuteStrategies(ctx context.Context, marketData *Mar
         Duration:      time.Since(startTime).Milliseconds(),
         Signals:       combinedSignals,
         StrategyCount: len(enabledStrategies),
+        SkippedCount:  skipped,
         Errors:        errors,
     }, nil
 }
@@ -178,8 +425,8 @@ func (m *StrategyManager) executeSingleStrategy(
     }, nil
 }
 
-// combineSignals 合并多个策略的信号
-func (m *StrategyManager) combineSignals(signals <-chan *Signal, marketData *MarketData) ([]*Signal, error) {
+// combineSignals 合并多个策略的信号，strategyCount为本次执行的策略数
+func (m *StrategyManager) combineSignals(signals <-chan *Signal, marketData *MarketData, strategyCount int) ([]*Signal, error) {
     // 收集所有信号
     var allSignals []*Signal
     for signal := range signals {
@@ -190,36 +437,67 @@ func (m *StrategyManager) combineSignals(signals <-chan *Signal, marketData *Mar
         return nil, nil
     }
 
-    // 按股票分组
+    if !m.combination.Valid() {
+        return allSignals, nil // 默认返回所有信号
+    }
+
+    // 按股票分组，每只股票各自合并出一个信号
     signalsBySymbol := make(map[string][]*Signal)
     for _, signal := range allSignals {
-        signalsBySymbol[signal.SignalType] = append(signalsBySymbol[signal.SignalType], signal)
+        signalsBySymbol[signal.Symbol] = append(signalsBySymbol[signal.Symbol], signal)
+    }
+    symbols := make([]string, 0, len(signalsBySymbol))
+    for symbol := range signalsBySymbol {
+        symbols = append(symbols, symbol)
     }
+    sort.Strings(symbols)
 
-    // 根据组合方法合并信号
     var combined []*Signal
-
-    switch m.combination {
-    case VoteCombination:
-        combined = m.combineByVote(signalsBySymbol, marketData)
-    case WeightedCombination:
-        combined = m.combineByWeight(signalsBySymbol, marketData)
-    case PriorityCombination:
-        combined = m.combineByPriority(signalsBySymbol, marketData)
-    default:
-        return allSignals, nil // 默认返回所有信号
+    for _, symbol := range symbols {
+        group := signalsBySymbol[symbol]
+        price := symbolPrice(symbol, group, marketData)
+
+        switch m.combination {
+        case VoteCombination:
+            combined = append(combined, m.combineByVote(symbol, group, price)...)
+        case WeightedCombination:
+            combined = append(combined, m.combineByWeight(symbol, group, price)...)
+        case PriorityCombination:
+            combined = append(combined, m.combineByPriority(group)...)
+        case ConsensusCombination:
+            combined = append(combined, m.combineByConsensus(symbol, group, price, strategyCount)...)
+        }
     }
 
     return combined, nil
 }
 
-// combineByVote 投票法合并信号
-func (m *StrategyManager) combineByVote(signalsBySymbol map[string][]*Signal, marketData *MarketData) []*Signal {
+// symbolPrice 合并信号使用的价格：行情股票取最新收盘价，其他股票取其信号价格的均值
+func symbolPrice(symbol string, signals []*Signal, marketData *MarketData) float64 {
+    if marketData != nil && marketData.Symbol == symbol {
+        return marketData.Close
+    }
+    var sum float64
+    for _, signal := range signals {
+        sum += signal.Price
+    }
+    return sum / float64(len(signals))
+}
+
+// combineByVote 投票法合并同一股票的信号
+func (m *StrategyManager) combineByVote(symbol string, signals []*Signal, price float64) []*Signal {
     var combined []*Signal
 
     // 按信号类型统计
-    buySignals := len(signalsBySymbol["buy"])
-    sellSignals := len(signalsBySymbol["sell"])
+    var buySignals, sellSignals int
+    for _, signal := range signals {
+        switch signal.SignalType {
+        case "buy":
+            buySignals++
+        case "sell":
+            sellSignals++
+        }
+    }
     totalSignals := buySignals + sellSignals
 
     // 简单多数决
@@ -240,12 +518,13 @@ func (m *StrategyManager) combineByVote(signalsBySymbol map[string][]*Signal, ma
 
     if signalType != "hold" {
         finalSignal = NewSignal(
-            marketData.Symbol,
+            symbol,
             signalType,
             float64(max(buySignals, sellSignals))/float64(totalSignals),
-            marketData.Close,
+            price,
         )
         finalSignal.Reason = fmt.Sprintf("Vote: %d buy, %d sell", buySignals, sellSignals)
+        attributeSignal(finalSignal, signals)
     }
 
     if finalSignal != nil {
@@ -255,32 +534,31 @@ func (m *StrategyManager) combineByVote(signalsBySymbol map[string][]*Signal, ma
     return combined
 }
 
-// combineByWeight 加权法合并信号
-func (m *StrategyManager) combineByWeight(signalsBySymbol map[string][]*Signal, marketData *MarketData) []*Signal {
+// combineByWeight 加权法合并同一股票的信号
+func (m *StrategyManager) combineByWeight(symbol string, signals []*Signal, price float64) []*Signal {
     // 计算加权得分
     var weightedScore float64
     var totalWeight float64
 
-    for _, signals := range signalsBySymbol {
-        for _, signal := range signals {
-            weight, ok := signal.Metadata["strategy_weight"].(float64)
-            if !ok {
-                weight = 0.5 // 默认权重
-            }
-
-            var score float64
-            switch signal.SignalType {
-            case "buy":
-                score = 1.0
-            case "sell":
-                score = -1.0
-            default:
-                score = 0.0
-            }
+    for _, signal := range signals {
+        weight, ok := signal.Metadata["strategy_weight"].(float64)
+        if !ok {
+            weight = 0.5 // 默认权重
+        }
 
-            weightedScore += score * weight
-            totalWeight += weight
+        // 方向乘以信号强度，高置信度信号的影响大于边缘信号
+        var direction float64
+        switch signal.SignalType {
+        case "buy":
+            direction = 1.0
+        case "sell":
+            direction = -1.0
+        default:
+            direction = 0.0
         }
+
+        weightedScore += direction * signal.Strength * weight
+        totalWeight += weight
     }
 
     if totalWeight == 0 {
@@ -303,18 +581,80 @@ func (m *StrategyManager) combineByWeight(signalsBySymbol map[string][]*Signal,
     }
 
     signal := NewSignal(
-        marketData.Symbol,
+        symbol,
         signalType,
         abs(normalizedScore),
-        marketData.Close,
+        price,
     )
     signal.Reason = fmt.Sprintf("Weighted: %.3f", normalizedScore)
+    attributeSignal(signal, signals)
 
     return []*Signal{signal}
 }
 
-// combineByPriority 优先级法合并信号
-func (m *StrategyManager) combineByPriority(signalsBySymbol map[string][]*Signal, marketData *MarketData) []*Signal {
+// attributeSignal 将合并信号归属到同方向信号中权重与强度乘积最大的策略，
+// 该策略的历史胜率用于凯利仓位，成交后的收益也计入该策略
+func attributeSignal(combined *Signal, signals []*Signal) {
+    var lead string
+    best := -1.0
+    for _, signal := range signals {
+        if signal.SignalType != combined.SignalType {
+            continue
+        }
+        name, ok := signal.Metadata["strategy_name"].(string)
+        if !ok {
+            continue
+        }
+        weight, ok := signal.Metadata["strategy_weight"].(float64)
+        if !ok {
+            weight = 0.5
+        }
+        if score := weight * signal.Strength; score > best || (score == best && name < lead) {
+            lead, best = name, score
+        }
+    }
+    if lead != "" {
+        combined.Metadata["strategy_name"] = lead
+    }
+}
+
+// combineByConsensus 共识法合并同一股票的信号：买入或卖出策略占本次执行策略的比例达到阈值时发出信号，
+// 信号强度为该比例；否则视为持有，不发出信号
+func (m *StrategyManager) combineByConsensus(symbol string, signals []*Signal, price float64, strategyCount int) []*Signal {
+    if strategyCount <= 0 {
+        return nil
+    }
+
+    var buySignals, sellSignals int
+    for _, signal := range signals {
+        switch signal.SignalType {
+        case "buy":
+            buySignals++
+        case "sell":
+            sellSignals++
+        }
+    }
+
+    for _, side := range []struct {
+        signalType string
+        count      int
+    }{{"buy", buySignals}, {"sell", sellSignals}} {
+        agreement := float64(side.count) / float64(strategyCount)
+        // 容忍浮点误差，恰好达到阈值时视为达成共识
+        if agreement+1e-9 < m.consensus {
+            continue
+        }
+        signal := NewSignal(symbol, side.signalType, agreement, price)
+        signal.Reason = fmt.Sprintf("Consensus: %d/%d strategies %s (threshold %.0f%%)",
+            side.count, strategyCount, side.signalType, m.consensus*100)
+        attributeSignal(signal, signals)
+        return []*Signal{signal}
+    }
+    return nil
+}
+
+// combineByPriority 优先级法合并同一股票的信号
+func (m *StrategyManager) combineByPriority(signals []*Signal) []*Signal {
     // 收集所有信号，包含优先级信息
     type PrioritySignal struct {
         Signal   *Signal
@@ -324,28 +664,26 @@ func (m *StrategyManager) combineByPriority(signalsBySymbol map[string][]*Signal
 
     var prioritySignals []PrioritySignal
 
-    for _, signals := range signalsBySymbol {
-        for _, signal := range signals {
-            priority, _ := signal.Metadata["strategy_priority"].(int)
-            if priority == 0 {
-                priority = 5 // 默认优先级
-            }
-
-            weight, _ := signal.Metadata["strategy_weight"].(float64)
-            if weight == 0 {
-                weight = 0.5
-            }
+    for _, signal := range signals {
+        priority, _ := signal.Metadata["strategy_priority"].(int)
+        if priority == 0 {
+            priority = 5 // 默认优先级
+        }
 
-            prioritySignals = append(prioritySignals, PrioritySignal{
-                Signal:   signal,
-                Priority: priority,
-                Weight:   weight,
-            })
+        weight, _ := signal.Metadata["strategy_weight"].(float64)
+        if weight == 0 {
+            weight = 0.5
         }
+
+        prioritySignals = append(prioritySignals, PrioritySignal{
+            Signal:   signal,
+            Priority: priority,
+            Weight:   weight,
+        })
     }
 
     // 按优先级排序
-    sort.Slice(prioritySignals, func(i, j int) bool {
+    sort.SliceStable(prioritySignals, func(i, j int) bool {
         return prioritySignals[i].Priority < prioritySignals[j].Priority
     })
 
@@ -366,21 +704,28 @@ func (m *StrategyManager) GetStats() map[string]interface{} {
     defer m.mu.RUnlock()
 
     return map[string]interface{}{
-        "last_execution":   m.lastExecution,
-        "execution_count":  m.executionCount,
-        "strategy_count":   m.loader.GetStrategyCount(),
-        "enabled_count":    m.loader.GetEnabledStrategyCount(),
-        "combination_type": m.combination,
+        "last_execution":        m.lastExecution,
+        "execution_count":       m.executionCount,
+        "strategy_count":        m.loader.GetStrategyCount(),
+        "enabled_count":         m.loader.GetEnabledStrategyCount(),
+        "combination_type":      m.combination,
+        "performance_weighting": m.weighter.Config().Enabled,
+        "dry_run":               m.execution.DryRun,
+        "last_cycle_orders":     len(m.lastOrders),
     }
 }
 
-// SetCombinationMethod 设置信号组合方法
-func (m *StrategyManager) SetCombinationMethod(method SignalCombination) {
+// SetCombinationMethod 设置信号组合方法，不支持的方法返回错误且不改变当前设置
+func (m *StrategyManager) SetCombinationMethod(method SignalCombination) error {
+    if !method.Valid() {
+        return fmt.Errorf("unknown combination method %q", method)
+    }
     m.mu.Lock()
     defer m.mu.Unlock()
 
     m.combination = method
     log.Printf("Strategy combination method changed to: %s", method)
+    return nil
 }
 
 // GetCombinationMethod 获取信号组合方法
@@ -397,6 +742,7 @@ type StrategyExecutionResult struct {
     Duration      int64     `json:"duration"`       // 执行时间(毫秒)
     Signals       []*Signal `json:"signals"`        // 合并后的信号
     StrategyCount int       `json:"strategy_count"` // 策略数量
+    SkippedCount  int       `json:"skipped_count"`  // 因K线未收盘跳过的策略数量
     Errors        []error   `json:"errors"`         // 执行错误
     Error         error     `json:"error"`          // 整体错误
 }
@@ -411,55 +757,10 @@ func (r *StrategyExecutionResult) GetSuccessCount() int {
     return r.StrategyCount - len(r.Errors)
 }
 
-// ProcessSignals 处理策略信号
+// ProcessSignals 处理合并后的策略信号：买入信号计算仓位并经风险检查后下单，
+// 卖出信号卖出可用持仓，试运行模式只记录计划委托，见RunCycle
 func (m *StrategyManager) ProcessSignals(ctx context.Context, signals []*Signal) error {
-    if m.signalHandler == nil {
-        return fmt.Errorf("signal handler not set")
-    }
-
-    for _, signal := range signals {
-        // 验证信号
-        if err := ValidateSignal(signal); err != nil {
-            log.Printf("Invalid signal from strategy: %v", err)
-            continue
-        }
-
-        // 检查风险
-        if m.riskManager != nil {
-            // 可以在这里进行信号级别的风险检查
-        }
-
-        // 发送到信号处理器
-        ctxWithTimeout, cancel := context.WithTimeout(ctx, 30*time.Second)
-        defer cancel()
-
-        // 根据信号类型进行处理
-        switch signal.SignalType {
-        case "buy":
-            // 处理买入信号
-            tradingSignal := &trading.TradingSignal{
-                Symbol:     signal.Symbol,
-                Action:     "buy",
-                Confidence: signal.Strength,
-                Timestamp:  time.Now(),
-            }
-            _, _ = m.signalHandler.ExecuteSignal(ctxWithTimeout, tradingSignal, signal.Price, 100)
-        case "sell":
-            // 处理卖出信号
-            tradingSignal := &trading.TradingSignal{
-                Symbol:     signal.Symbol,
-                Action:     "sell",
-                Confidence: signal.Strength,
-                Timestamp:  time.Now(),
-            }
-            _, _ = m.signalHandler.ExecuteSignal(ctxWithTimeout, tradingSignal, signal.Price, 0)
-        case "hold":
-            // 持仓信号，不需要特殊处理
-            log.Printf("Hold signal for %s: %s", signal.Symbol, signal.Reason)
-        }
-    }
-
-    return nil
+    return m.routeSignals(ctx, signals)
 }
 
 // 工具函数
@@ -0,0 +1,417 @@
+package strategies
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingStrategy 记录评估次数和最后一次收到的K线
+type countingStrategy struct {
+	*BaseStrategy
+	calls  int32
+	closed atomic.Bool
+}
+
+func (c *countingStrategy) GenerateSignal(ctx context.Context, data *MarketData) (*Signal, error) {
+	atomic.AddInt32(&c.calls, 1)
+	c.closed.Store(data.BarClosed)
+	return nil, nil
+}
+
+func TestCloseOnlyStrategySkipsFormingBars(t *testing.T) {
+	closeOnly := &countingStrategy{BaseStrategy: NewBaseStrategy("close_only", 1)}
+	intrabar := &countingStrategy{BaseStrategy: NewBaseStrategy("intrabar", 1)}
+
+	loader := NewStrategyLoader()
+	loader.RegisterFactory("close_only", func() Strategy { return closeOnly })
+	loader.RegisterFactory("intrabar", func() Strategy { return intrabar })
+	if err := loader.LoadStrategies([]StrategyConfig{
+		{Name: "close_only", Type: "close_only", Enabled: true, Weight: 1, CloseOnly: true},
+		{Name: "intrabar", Type: "intrabar", Enabled: true, Weight: 1},
+	}); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+
+	manager := NewStrategyManager(loader, "")
+	ctx := context.Background()
+	bar := &MarketData{Symbol: "sh600000", Close: 10, Timestamp: time.Now()}
+
+	// 盘中K线：仅非收盘策略执行
+	result, err := manager.ExecuteStrategies(ctx, bar)
+	if err != nil {
+		t.Fatalf("ExecuteStrategies (forming): %v", err)
+	}
+	if result.StrategyCount != 1 || result.SkippedCount != 1 {
+		t.Errorf("forming bar: executed %d skipped %d, want 1 and 1", result.StrategyCount, result.SkippedCount)
+	}
+	if n := atomic.LoadInt32(&closeOnly.calls); n != 0 {
+		t.Errorf("close-only strategy evaluated %d times on forming bar", n)
+	}
+
+	// 同一根K线收盘
+	closed := *bar
+	closed.Close = 10.2
+	closed.BarClosed = true
+	if _, err := manager.ExecuteStrategies(ctx, &closed); err != nil {
+		t.Fatalf("ExecuteStrategies (closed): %v", err)
+	}
+
+	if n := atomic.LoadInt32(&closeOnly.calls); n != 1 {
+		t.Errorf("close-only strategy evaluated %d times, want 1", n)
+	}
+	if !closeOnly.closed.Load() {
+		t.Error("close-only strategy evaluated on a forming bar")
+	}
+	if n := atomic.LoadInt32(&intrabar.calls); n != 2 {
+		t.Errorf("intrabar strategy evaluated %d times, want 2", n)
+	}
+}
+
+func newWeightingManager(t *testing.T, names ...string) (*StrategyManager, *StrategyLoader) {
+	t.Helper()
+
+	loader := NewStrategyLoader()
+	configs := make([]StrategyConfig, 0, len(names))
+	for _, name := range names {
+		strategy := &countingStrategy{BaseStrategy: NewBaseStrategy(name, 0.3)}
+		loader.RegisterFactory(StrategyType(name), func() Strategy { return strategy })
+		configs = append(configs, StrategyConfig{Name: name, Type: StrategyType(name), Enabled: true, Weight: 0.3})
+	}
+	if err := loader.LoadStrategies(configs); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+	return NewStrategyManager(loader, WeightedCombination), loader
+}
+
+func TestPerformanceWeightingFavorsWinningStrategy(t *testing.T) {
+	manager, loader := newWeightingManager(t, "trend", "mean_revert", "breakout")
+	config := PerformanceWeightingConfig{
+		Enabled:   true,
+		Lookback:  7 * 24 * time.Hour,
+		Interval:  time.Hour,
+		MinWeight: 0.1,
+		MaxWeight: 0.6,
+		MinTrades: 3,
+	}
+	if err := manager.SetPerformanceWeighting(config); err != nil {
+		t.Fatalf("SetPerformanceWeighting: %v", err)
+	}
+
+	now := time.Date(2024, 3, 8, 15, 0, 0, 0, time.UTC)
+	returns := map[string][]float64{
+		"trend":       {0.03, 0.025, 0.035, 0.03},
+		"mean_revert": {0.01, -0.012, 0.008, -0.006},
+		"breakout":    {-0.02, -0.01, -0.015, 0.005},
+	}
+	for name, series := range returns {
+		for i, ret := range series {
+			manager.RecordStrategyTrade(StrategyTrade{Strategy: name, Return: ret, Time: now.Add(-time.Duration(i+1) * time.Hour)})
+		}
+	}
+	// 回溯窗口外的亏损交易不影响评分
+	manager.RecordStrategyTrade(StrategyTrade{Strategy: "trend", Return: -0.5, Time: now.Add(-30 * 24 * time.Hour)})
+
+	before, _ := loader.GetStrategy("trend")
+	beforeWeight := before.GetWeight()
+
+	weights := manager.RecomputeWeights(now)
+	if len(weights) != 3 {
+		t.Fatalf("got weights %v, want 3 strategies", weights)
+	}
+
+	var total float64
+	for name, weight := range weights {
+		if weight < config.MinWeight-1e-9 || weight > config.MaxWeight+1e-9 {
+			t.Errorf("%s weight %.4f outside [%.2f, %.2f]", name, weight, config.MinWeight, config.MaxWeight)
+		}
+		total += weight
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("weights sum to %.4f, want 1", total)
+	}
+
+	trend, _ := loader.GetStrategy("trend")
+	if trend.GetWeight() <= beforeWeight || trend.GetWeight() != weights["trend"] {
+		t.Errorf("trend weight %.4f, want above %.4f and applied", trend.GetWeight(), beforeWeight)
+	}
+	if weights["trend"] != config.MaxWeight {
+		t.Errorf("dominant strategy weight %.4f, want capped at %.2f", weights["trend"], config.MaxWeight)
+	}
+	if weights["breakout"] > weights["mean_revert"] || weights["breakout"] >= weights["trend"] {
+		t.Errorf("losing strategy weight %.4f should not exceed the others: %v", weights["breakout"], weights)
+	}
+}
+
+func TestPerformanceWeighterWinStats(t *testing.T) {
+	weighter := NewPerformanceWeighter(PerformanceWeightingConfig{Lookback: 24 * time.Hour, MinTrades: 3})
+	now := time.Date(2024, 3, 8, 15, 0, 0, 0, time.UTC)
+	for i, ret := range []float64{0.04, 0.02, -0.01, 0.03} {
+		weighter.RecordTrade(StrategyTrade{Strategy: "trend", Return: ret, Time: now.Add(-time.Duration(i+1) * time.Hour)})
+	}
+	weighter.RecordTrade(StrategyTrade{Strategy: "trend", Return: -0.5, Time: now.Add(-48 * time.Hour)})
+	weighter.RecordTrade(StrategyTrade{Strategy: "breakout", Return: 0.01, Time: now.Add(-time.Hour)})
+
+	winRate, ratio, trades := weighter.WinStats("trend", now)
+	if trades != 4 || winRate != 0.75 || math.Abs(ratio-3) > 1e-9 {
+		t.Errorf("trend WinStats = %.2f, %.2f, %d; want 0.75, 3, 4", winRate, ratio, trades)
+	}
+	if _, _, trades := weighter.WinStats("breakout", now); trades != 0 {
+		t.Errorf("breakout has too few trades, got %d", trades)
+	}
+}
+
+func TestPerformanceWeightingDisabledKeepsFixedWeights(t *testing.T) {
+	manager, loader := newWeightingManager(t, "trend", "breakout")
+
+	now := time.Now()
+	for i := 0; i < 5; i++ {
+		manager.RecordStrategyTrade(StrategyTrade{Strategy: "trend", Return: 0.03, Time: now})
+		manager.RecordStrategyTrade(StrategyTrade{Strategy: "breakout", Return: -0.02, Time: now})
+	}
+
+	if weights := manager.RecomputeWeights(now); weights != nil {
+		t.Errorf("got weights %v with performance weighting disabled", weights)
+	}
+	for _, name := range []string{"trend", "breakout"} {
+		strategy, _ := loader.GetStrategy(name)
+		if strategy.GetWeight() != 0.3 {
+			t.Errorf("%s weight changed to %.4f", name, strategy.GetWeight())
+		}
+	}
+}
+
+// fixedSignalStrategy 固定对某只股票发出同一信号
+type fixedSignalStrategy struct {
+	*BaseStrategy
+	symbol     string
+	signalType string
+	price      float64
+}
+
+func (f *fixedSignalStrategy) GenerateSignal(ctx context.Context, data *MarketData) (*Signal, error) {
+	return NewSignal(f.symbol, f.signalType, 0.8, f.price), nil
+}
+
+func TestCombineSignalsPerSymbol(t *testing.T) {
+	strategies := []*fixedSignalStrategy{
+		{BaseStrategy: NewBaseStrategy("a_buy_600000", 0.5), symbol: "sh600000", signalType: "buy", price: 10},
+		{BaseStrategy: NewBaseStrategy("b_buy_600000", 0.5), symbol: "sh600000", signalType: "buy", price: 10},
+		{BaseStrategy: NewBaseStrategy("c_sell_000001", 0.5), symbol: "sz000001", signalType: "sell", price: 20},
+		{BaseStrategy: NewBaseStrategy("d_sell_000001", 0.5), symbol: "sz000001", signalType: "sell", price: 20},
+		{BaseStrategy: NewBaseStrategy("e_buy_000001", 0.5), symbol: "sz000001", signalType: "buy", price: 20},
+	}
+
+	loader := NewStrategyLoader()
+	configs := make([]StrategyConfig, 0, len(strategies))
+	for _, strategy := range strategies {
+		strategy := strategy
+		name := strategy.GetName()
+		loader.RegisterFactory(StrategyType(name), func() Strategy { return strategy })
+		configs = append(configs, StrategyConfig{Name: name, Type: StrategyType(name), Enabled: true, Weight: 0.5})
+	}
+	if err := loader.LoadStrategies(configs); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+
+	bar := &MarketData{Symbol: "sh600000", Close: 10.5, Timestamp: time.Now(), BarClosed: true}
+	want := map[string]struct {
+		signalType string
+		price      float64
+	}{
+		"sh600000": {"buy", 10.5},
+		"sz000001": {"sell", 20},
+	}
+
+	for _, combination := range []SignalCombination{VoteCombination, WeightedCombination} {
+		manager := NewStrategyManager(loader, combination)
+		result, err := manager.ExecuteStrategies(context.Background(), bar)
+		if err != nil {
+			t.Fatalf("%s: ExecuteStrategies: %v", combination, err)
+		}
+		if len(result.Signals) != 2 {
+			t.Fatalf("%s: got %d combined signals, want one per symbol: %+v", combination, len(result.Signals), result.Signals)
+		}
+		for _, signal := range result.Signals {
+			expected, ok := want[signal.Symbol]
+			if !ok {
+				t.Fatalf("%s: unexpected symbol %s", combination, signal.Symbol)
+			}
+			if signal.SignalType != expected.signalType || signal.Price != expected.price {
+				t.Errorf("%s: %s combined to %s @ %.2f, want %s @ %.2f", combination, signal.Symbol, signal.SignalType, signal.Price, expected.signalType, expected.price)
+			}
+		}
+	}
+
+	manager := NewStrategyManager(loader, PriorityCombination)
+	result, err := manager.ExecuteStrategies(context.Background(), bar)
+	if err != nil {
+		t.Fatalf("priority: ExecuteStrategies: %v", err)
+	}
+	seen := make(map[string]int)
+	for _, signal := range result.Signals {
+		seen[signal.Symbol]++
+	}
+	if len(result.Signals) != 2 || seen["sh600000"] != 1 || seen["sz000001"] != 1 {
+		t.Errorf("priority: got signals for %v, want one per symbol", seen)
+	}
+}
+
+func newConsensusManager(t *testing.T, signalTypes ...string) *StrategyManager {
+	t.Helper()
+
+	loader := NewStrategyLoader()
+	configs := make([]StrategyConfig, 0, len(signalTypes))
+	for i, signalType := range signalTypes {
+		name := fmt.Sprintf("s%d_%s", i, signalType)
+		strategy := &fixedSignalStrategy{BaseStrategy: NewBaseStrategy(name, 0.2), symbol: "sh600000", signalType: signalType, price: 10}
+		loader.RegisterFactory(StrategyType(name), func() Strategy { return strategy })
+		configs = append(configs, StrategyConfig{Name: name, Type: StrategyType(name), Enabled: true, Weight: 0.2})
+	}
+	if err := loader.LoadStrategies(configs); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+	return NewStrategyManager(loader, ConsensusCombination)
+}
+
+func TestConsensusCombinationThreshold(t *testing.T) {
+	bar := &MarketData{Symbol: "sh600000", Close: 10, Timestamp: time.Now(), BarClosed: true}
+
+	tests := []struct {
+		name         string
+		signalTypes  []string
+		threshold    float64
+		wantType     string
+		wantStrength float64
+	}{
+		{"exactly at threshold", []string{"buy", "buy", "buy", "sell", "hold"}, 0.6, "buy", 0.6},
+		{"below threshold", []string{"buy", "buy", "sell", "sell", "hold"}, 0.6, "", 0},
+		{"sell consensus", []string{"sell", "sell", "sell", "buy"}, 0.75, "sell", 0.75},
+		{"unanimous required", []string{"buy", "buy", "buy", "hold"}, 1, "", 0},
+		{"default threshold", []string{"buy", "buy", "buy", "hold", "hold"}, 0, "buy", 0.6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manager := newConsensusManager(t, tt.signalTypes...)
+			if tt.threshold > 0 {
+				if err := manager.SetConsensusThreshold(tt.threshold); err != nil {
+					t.Fatalf("SetConsensusThreshold: %v", err)
+				}
+			}
+
+			result, err := manager.ExecuteStrategies(context.Background(), bar)
+			if err != nil {
+				t.Fatalf("ExecuteStrategies: %v", err)
+			}
+			if tt.wantType == "" {
+				if len(result.Signals) != 0 {
+					t.Fatalf("got signals %+v, want hold", result.Signals)
+				}
+				return
+			}
+			if len(result.Signals) != 1 {
+				t.Fatalf("got %d signals, want 1", len(result.Signals))
+			}
+			signal := result.Signals[0]
+			if signal.SignalType != tt.wantType || math.Abs(signal.Strength-tt.wantStrength) > 1e-9 {
+				t.Errorf("got %s strength %.4f, want %s strength %.4f", signal.SignalType, signal.Strength, tt.wantType, tt.wantStrength)
+			}
+		})
+	}
+}
+
+func TestSetConsensusThresholdRejectsOutOfRange(t *testing.T) {
+	manager := NewStrategyManager(NewStrategyLoader(), ConsensusCombination)
+	for _, fraction := range []float64{0, -0.1, 1.01} {
+		if err := manager.SetConsensusThreshold(fraction); err == nil {
+			t.Errorf("threshold %.2f accepted", fraction)
+		}
+	}
+}
+
+func TestSetCombinationMethodRejectsUnknown(t *testing.T) {
+	manager := NewStrategyManager(NewStrategyLoader(), WeightedCombination)
+	for _, method := range []SignalCombination{"", "best", "Vote"} {
+		if err := manager.SetCombinationMethod(method); err == nil {
+			t.Errorf("method %q accepted", method)
+		}
+	}
+	if got := manager.GetCombinationMethod(); got != WeightedCombination {
+		t.Errorf("combination changed to %q after rejected updates", got)
+	}
+	if err := manager.SetCombinationMethod(ConsensusCombination); err != nil || manager.GetCombinationMethod() != ConsensusCombination {
+		t.Errorf("SetCombinationMethod(consensus) = %v, method %q", err, manager.GetCombinationMethod())
+	}
+}
+
+func TestWeightedCombinationUsesSignalStrength(t *testing.T) {
+	weighted := func(signalType string, strength float64) *Signal {
+		signal := NewSignal("sh600000", signalType, strength, 10)
+		signal.Metadata["strategy_weight"] = 0.5
+		return signal
+	}
+	manager := NewStrategyManager(NewStrategyLoader(), WeightedCombination)
+
+	// 等权重下一强一弱的买卖信号：强度决定方向
+	for _, tt := range []struct {
+		buyStrength, sellStrength float64
+		want                      string
+	}{
+		{0.9, 0.3, "buy"},
+		{0.3, 0.9, "sell"},
+	} {
+		combined := manager.combineByWeight("sh600000", []*Signal{
+			weighted("buy", tt.buyStrength),
+			weighted("sell", tt.sellStrength),
+		}, 10)
+		if len(combined) != 1 || combined[0].SignalType != tt.want {
+			t.Fatalf("buy %.1f vs sell %.1f combined to %+v, want %s", tt.buyStrength, tt.sellStrength, combined, tt.want)
+		}
+		if want := math.Abs(tt.buyStrength-tt.sellStrength) / 2; math.Abs(combined[0].Strength-want) > 1e-9 {
+			t.Errorf("combined strength %.4f, want %.4f", combined[0].Strength, want)
+		}
+	}
+
+	// 两个边缘信号相互抵消后低于阈值
+	if combined := manager.combineByWeight("sh600000", []*Signal{weighted("buy", 0.2), weighted("sell", 0.1)}, 10); len(combined) != 0 {
+		t.Errorf("marginal signals combined to %+v, want none", combined)
+	}
+}
+
+func TestEvaluateStrategiesLeavesLiveStateUntouched(t *testing.T) {
+	loader := NewStrategyLoader()
+	if err := loader.LoadStrategies([]StrategyConfig{{
+		Name: "ma", Type: MAStrategyType, Enabled: true, Weight: 1,
+		Parameters: map[string]interface{}{"short_period": 2, "long_period": 3},
+	}}); err != nil {
+		t.Fatalf("LoadStrategies: %v", err)
+	}
+	manager := NewStrategyManager(loader, WeightedCombination)
+
+	start := time.Date(2026, 10, 12, 15, 0, 0, 0, time.UTC)
+	var history []*MarketData
+	for i, price := range []float64{10, 9, 8} {
+		history = append(history, &MarketData{Symbol: "sh600000", Close: price, Timestamp: start.AddDate(0, 0, i), BarClosed: true})
+	}
+	bar := &MarketData{Symbol: "sh600000", Close: 20, Volume: 2000000, Timestamp: start.AddDate(0, 0, 3), BarClosed: true}
+
+	result, err := manager.EvaluateStrategies(context.Background(), history, bar)
+	if err != nil {
+		t.Fatalf("EvaluateStrategies: %v", err)
+	}
+	if result.StrategyCount != 1 || len(result.Signals) != 1 || result.Signals[0].SignalType != "buy" {
+		t.Errorf("evaluation = %+v, want one buy from the warmed-up copy", result)
+	}
+
+	live, _ := loader.GetStrategy("ma")
+	if n := len(live.(*MAStrategy).dataSeries); n != 0 {
+		t.Errorf("live strategy buffered %d prices during evaluation", n)
+	}
+	if stats := manager.GetStats(); stats["execution_count"] != int64(0) || manager.GetLastResult() != nil {
+		t.Errorf("evaluation changed execution stats: %v", stats)
+	}
+}
@@ -81,6 +81,11 @@ func createTradeTables(db *sql.DB) error {
             buy_count INTEGER DEFAULT 0,
             sell_count INTEGER DEFAULT 0,
             created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+        )`,
+		`CREATE TABLE IF NOT EXISTS trailing_stops (
+            symbol TEXT PRIMARY KEY,
+            high_price REAL NOT NULL,
+            updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
         )`,
 	}
 
@@ -286,6 +291,55 @@ func (th *TradeHistory) GetDailyPnL(days int) ([]DailyPnL, error) {
 	return pnls, nil
 }
 
+// SaveHighWaterMark 保存持仓建仓以来的最高价，用于移动止损
+func (th *TradeHistory) SaveHighWaterMark(symbol string, highPrice float64) error {
+	if th.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := th.db.Exec(`
+        INSERT OR REPLACE INTO trailing_stops (symbol, high_price, updated_at)
+        VALUES (?, ?, CURRENT_TIMESTAMP)
+    `, symbol, highPrice)
+
+	return err
+}
+
+// DeleteHighWaterMark 删除已平仓股票的最高价记录
+func (th *TradeHistory) DeleteHighWaterMark(symbol string) error {
+	if th.db == nil {
+		return fmt.Errorf("数据库未初始化")
+	}
+
+	_, err := th.db.Exec(`DELETE FROM trailing_stops WHERE symbol = ?`, symbol)
+	return err
+}
+
+// GetHighWaterMarks 获取所有持仓的最高价记录
+func (th *TradeHistory) GetHighWaterMarks() (map[string]float64, error) {
+	if th.db == nil {
+		return nil, fmt.Errorf("数据库未初始化")
+	}
+
+	rows, err := th.db.Query(`SELECT symbol, high_price FROM trailing_stops`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	marks := make(map[string]float64)
+	for rows.Next() {
+		var symbol string
+		var highPrice float64
+		if err := rows.Scan(&symbol, &highPrice); err != nil {
+			return nil, err
+		}
+		marks[symbol] = highPrice
+	}
+
+	return marks, rows.Err()
+}
+
 // PerformanceMetrics 绩效指标
 type PerformanceMetrics struct {
 	TotalReturn    float64 `json:"total_return"`     // 总收益率
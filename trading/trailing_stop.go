@@ -0,0 +1,137 @@
+package trading
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+)
+
+// TrailingStopStatus 单只持仓的移动止损状态
+type TrailingStopStatus struct {
+	Symbol       string  `json:"symbol"`
+	CurrentPrice float64 `json:"current_price"`
+	HighPrice    float64 `json:"high_price"` // 建仓以来的最高价
+	StopPrice    float64 `json:"stop_price"` // 当前止损价
+	Drawdown     float64 `json:"drawdown"`   // 相对最高价的回落比例
+	Triggered    bool    `json:"triggered"`  // 是否已触发
+}
+
+// loadHighWaterMarks 从交易历史恢复最高价记录，使移动止损在重启后延续
+func (rm *RiskManager) loadHighWaterMarks() {
+	if rm.tradeHistory == nil {
+		return
+	}
+	marks, err := rm.tradeHistory.GetHighWaterMarks()
+	if err != nil {
+		log.Printf("加载移动止损最高价失败: %v", err)
+		return
+	}
+	rm.highWaterMarks = marks
+}
+
+// CheckTrailingStops 更新各持仓的最高价并返回从最高价回落超过TrailingStopPercent的股票，
+// 最高价至少为成本价；已平仓股票的记录会被清除。TrailingStopPercent为0时不检查
+func (rm *RiskManager) CheckTrailingStops(ctx context.Context) ([]string, error) {
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	if rm.emergencyStop {
+		return nil, ErrEmergencyStop
+	}
+	if rm.config.TrailingStopPercent <= 0 {
+		return nil, nil
+	}
+
+	positions, err := rm.connector.GetCachedPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	held := make(map[string]bool, len(positions))
+	var triggered []string
+	for _, pos := range positions {
+		held[pos.Symbol] = true
+
+		high := rm.highWaterMarks[pos.Symbol]
+		if peak := rm.peakPrice(pos); peak > high {
+			high = peak
+			rm.highWaterMarks[pos.Symbol] = high
+			if rm.tradeHistory != nil {
+				if err := rm.tradeHistory.SaveHighWaterMark(pos.Symbol, high); err != nil {
+					log.Printf("保存移动止损最高价失败 %s: %v", pos.Symbol, err)
+				}
+			}
+		}
+
+		if status := rm.trailingStatus(pos, high); status.Triggered {
+			triggered = append(triggered, pos.Symbol)
+			log.Printf("移动止损触发: %s 现价 %.2f, 最高价 %.2f, 回落 %.2f%%, 阈值 %.2f%%",
+				pos.Symbol, pos.CurrentPrice, high, status.Drawdown*100, rm.config.TrailingStopPercent*100)
+		}
+	}
+
+	// 清除已平仓股票的记录，重新建仓时从新的成本价开始跟踪
+	for symbol := range rm.highWaterMarks {
+		if held[symbol] {
+			continue
+		}
+		delete(rm.highWaterMarks, symbol)
+		if rm.tradeHistory != nil {
+			if err := rm.tradeHistory.DeleteHighWaterMark(symbol); err != nil {
+				log.Printf("删除移动止损最高价失败 %s: %v", symbol, err)
+			}
+		}
+	}
+
+	return triggered, nil
+}
+
+// GetTrailingStopStatus 获取各持仓的移动止损状态，按股票代码排序，不更新最高价记录
+func (rm *RiskManager) GetTrailingStopStatus() ([]TrailingStopStatus, error) {
+	rm.mu.RLock()
+	defer rm.mu.RUnlock()
+
+	positions, err := rm.connector.GetCachedPositions()
+	if err != nil {
+		return nil, fmt.Errorf("获取持仓失败: %w", err)
+	}
+
+	statuses := make([]TrailingStopStatus, 0, len(positions))
+	for _, pos := range positions {
+		high := rm.highWaterMarks[pos.Symbol]
+		if peak := rm.peakPrice(pos); peak > high {
+			high = peak
+		}
+		statuses = append(statuses, rm.trailingStatus(pos, high))
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Symbol < statuses[j].Symbol })
+
+	return statuses, nil
+}
+
+// peakPrice 当前观察到的最高价：现价与成本价中的较大者
+func (rm *RiskManager) peakPrice(pos Position) float64 {
+	if pos.CostPrice > pos.CurrentPrice {
+		return pos.CostPrice
+	}
+	return pos.CurrentPrice
+}
+
+// trailingStatus 按最高价计算持仓的移动止损状态
+func (rm *RiskManager) trailingStatus(pos Position, high float64) TrailingStopStatus {
+	status := TrailingStopStatus{
+		Symbol:       pos.Symbol,
+		CurrentPrice: pos.CurrentPrice,
+		HighPrice:    high,
+	}
+	if high <= 0 {
+		return status
+	}
+	status.Drawdown = 1 - pos.CurrentPrice/high
+	if rm.config.TrailingStopPercent > 0 {
+		status.StopPrice = high * (1 - rm.config.TrailingStopPercent)
+		status.Triggered = pos.CurrentPrice > 0 && pos.CurrentPrice <= status.StopPrice
+	}
+	return status
+}
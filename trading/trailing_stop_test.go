@@ -0,0 +1,69 @@
+package trading
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+)
+
+func TestTrailingStopRatchetsAndSurvivesRestart(t *testing.T) {
+	th, err := NewTradeHistory(filepath.Join(t.TempDir(), "trades.db"))
+	if err != nil {
+		t.Fatalf("NewTradeHistory: %v", err)
+	}
+	defer th.Close()
+
+	broker := &fakeBroker{balance: Balance{TotalAssets: 100000, AvailableCash: 80000}}
+	connector := &BrokerConnector{broker: broker}
+	config := DefaultRiskConfig
+	config.InitialCapital = 100000
+	config.TrailingStopPercent = 0.05
+	ctx := context.Background()
+
+	setPrice := func(price float64) {
+		broker.positions = []Position{{Symbol: "sh600000", Amount: 1000, Available: 1000, CostPrice: 10, CurrentPrice: price}}
+	}
+
+	rm := NewRiskManager(config, connector, th)
+	for _, price := range []float64{10.5, 12, 11.5} {
+		setPrice(price)
+		triggered, err := rm.CheckTrailingStops(ctx)
+		if err != nil {
+			t.Fatalf("CheckTrailingStops: %v", err)
+		}
+		if len(triggered) != 0 {
+			t.Fatalf("price %.2f triggered trailing stop %v", price, triggered)
+		}
+	}
+
+	// 重启后从交易历史恢复最高价12，止损价11.4
+	rm = NewRiskManager(config, connector, th)
+	setPrice(11.3)
+	statuses, err := rm.GetTrailingStopStatus()
+	if err != nil {
+		t.Fatalf("GetTrailingStopStatus: %v", err)
+	}
+	if len(statuses) != 1 || statuses[0].HighPrice != 12 || !statuses[0].Triggered {
+		t.Fatalf("status after restart = %+v, want high 12 triggered", statuses)
+	}
+	triggered, err := rm.CheckTrailingStops(ctx)
+	if err != nil {
+		t.Fatalf("CheckTrailingStops: %v", err)
+	}
+	if len(triggered) != 1 || triggered[0] != "sh600000" {
+		t.Fatalf("triggered = %v, want [sh600000]", triggered)
+	}
+
+	// 平仓后清除记录
+	broker.positions = nil
+	if _, err := rm.CheckTrailingStops(ctx); err != nil {
+		t.Fatalf("CheckTrailingStops: %v", err)
+	}
+	marks, err := th.GetHighWaterMarks()
+	if err != nil {
+		t.Fatalf("GetHighWaterMarks: %v", err)
+	}
+	if len(marks) != 0 {
+		t.Errorf("high water marks after close = %v, want none", marks)
+	}
+}